@@ -128,6 +128,478 @@ func main() {
 			},
 		},
 		// --- Fin del Seed para el Usuario Administrador ---
+		{
+			ID: "20250601100000_create_app_settings_table",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tabla 'app_settings'...")
+				err := tx.AutoMigrate(&models.AppSetting{})
+				if err == nil {
+					log.Println("Tabla 'app_settings' creada/actualizada exitosamente.")
+				}
+				return err
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tabla 'app_settings'...")
+				return tx.Migrator().DropTable(&models.AppSetting{})
+			},
+		},
+		{
+			ID: "20250602090000_add_version_to_users_and_employee_details",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: añadiendo columna 'version' a 'users' y 'employee_details'...")
+				return tx.AutoMigrate(&models.User{}, &models.EmployeeDetail{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando columna 'version' de 'users' y 'employee_details'...")
+				if err := tx.Migrator().DropColumn(&models.User{}, "version"); err != nil {
+					return err
+				}
+				return tx.Migrator().DropColumn(&models.EmployeeDetail{}, "version")
+			},
+		},
+		{
+			ID: "20250603120000_create_audit_events_table",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tabla 'audit_events'...")
+				if err := tx.AutoMigrate(&models.AuditEvent{}); err != nil {
+					return err
+				}
+				// Defensa adicional a nivel de BD: impedir UPDATE y DELETE sobre la auditoría.
+				// Algunos MySQL gestionados exigen privilegios extra para crear triggers
+				// (log_bin_trust_function_creators); en ese caso solo se advierte, ya que la
+				// cadena de hashes sigue permitiendo detectar alteraciones.
+				triggers := []string{
+					"CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_events es de solo inserción'",
+					"CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_events es de solo inserción'",
+				}
+				for _, stmt := range triggers {
+					if err := tx.Exec(stmt).Error; err != nil {
+						log.Printf("Advertencia: no se pudo crear trigger de inmutabilidad en 'audit_events': %v", err)
+					}
+				}
+				log.Println("Tabla 'audit_events' creada/actualizada exitosamente.")
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tabla 'audit_events'...")
+				tx.Exec("DROP TRIGGER IF EXISTS audit_events_no_update")
+				tx.Exec("DROP TRIGGER IF EXISTS audit_events_no_delete")
+				return tx.Migrator().DropTable(&models.AuditEvent{})
+			},
+		},
+		{
+			ID: "20250604100000_create_user_histories_table",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tabla 'user_histories'...")
+				if err := tx.AutoMigrate(&models.UserHistory{}); err != nil {
+					return err
+				}
+
+				// Versión inicial para los usuarios existentes, vigente desde su creación.
+				var users []models.User
+				if err := tx.Unscoped().Preload("EmployeeDetail").Find(&users).Error; err != nil {
+					return err
+				}
+				for _, u := range users {
+					entry := models.UserHistory{
+						UserID:      u.ID,
+						UserVersion: u.Version,
+						Username:    u.Username,
+						Role:        u.Role,
+						ValidFrom:   u.CreatedAt,
+						Action:      models.AuditActionUserCreated,
+					}
+					if u.EmployeeDetail.ID != 0 {
+						entry.DetailVersion = u.EmployeeDetail.Version
+						entry.Name = u.EmployeeDetail.Name
+						entry.LastName = u.EmployeeDetail.LastName
+						entry.Email = u.EmployeeDetail.Email
+						entry.PhoneNumber = u.EmployeeDetail.PhoneNumber
+						entry.Position = u.EmployeeDetail.Position
+					}
+					if u.DeletedAt.Valid {
+						deletedAt := u.DeletedAt.Time
+						entry.ValidTo = &deletedAt
+					}
+					if err := tx.Create(&entry).Error; err != nil {
+						return err
+					}
+				}
+				log.Printf("Tabla 'user_histories' creada con %d versiones iniciales.", len(users))
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tabla 'user_histories'...")
+				return tx.Migrator().DropTable(&models.UserHistory{})
+			},
+		},
+		{
+			ID: "20250605090000_create_org_structure",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tablas 'departments' y 'teams' y asignaciones de empleados...")
+				// AutoMigrate añade department_id, team_id y manager_id a employee_details y user_histories.
+				return tx.AutoMigrate(&models.Department{}, &models.Team{}, &models.EmployeeDetail{}, &models.UserHistory{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando estructura organizativa...")
+				for _, column := range []string{"DepartmentID", "TeamID", "ManagerID"} {
+					if err := tx.Migrator().DropColumn(&models.EmployeeDetail{}, column); err != nil {
+						return err
+					}
+					if err := tx.Migrator().DropColumn(&models.UserHistory{}, column); err != nil {
+						return err
+					}
+				}
+				return tx.Migrator().DropTable(&models.Team{}, &models.Department{})
+			},
+		},
+		{
+			ID: "20250606090000_create_custom_fields_tables",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tablas de campos personalizados...")
+				return tx.AutoMigrate(&models.CustomFieldDefinition{}, &models.CustomFieldValue{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tablas de campos personalizados...")
+				return tx.Migrator().DropTable(&models.CustomFieldValue{}, &models.CustomFieldDefinition{})
+			},
+		},
+		{
+			ID: "20250607090000_add_avatar_to_employee_details",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: añadiendo foto de perfil a employee_details...")
+				return tx.AutoMigrate(&models.EmployeeDetail{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando foto de perfil de employee_details...")
+				for _, column := range []string{"AvatarKey", "AvatarUpdatedAt"} {
+					if err := tx.Migrator().DropColumn(&models.EmployeeDetail{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			ID: "20250608090000_add_employee_lifecycle",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: añadiendo estados del ciclo de vida y transiciones programadas...")
+				// Las cuentas existentes quedan activas por el valor por defecto de la columna status.
+				if err := tx.AutoMigrate(&models.User{}, &models.EmployeeDetail{}, &models.UserHistory{}); err != nil {
+					return err
+				}
+				if err := tx.Model(&models.UserHistory{}).Where("status IS NULL OR status = ''").
+					Update("status", models.StatusActive).Error; err != nil {
+					return err
+				}
+				return tx.AutoMigrate(&models.LifecycleTransition{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando estados del ciclo de vida...")
+				if err := tx.Migrator().DropTable(&models.LifecycleTransition{}); err != nil {
+					return err
+				}
+				for _, column := range []string{"Status", "HireDate", "TerminationDate"} {
+					if err := tx.Migrator().DropColumn(&models.UserHistory{}, column); err != nil {
+						return err
+					}
+				}
+				for _, column := range []string{"HireDate", "TerminationDate"} {
+					if err := tx.Migrator().DropColumn(&models.EmployeeDetail{}, column); err != nil {
+						return err
+					}
+				}
+				return tx.Migrator().DropColumn(&models.User{}, "Status")
+			},
+		},
+		{
+			ID: "20250609090000_create_invitations_table",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tabla invitations...")
+				return tx.AutoMigrate(&models.Invitation{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tabla invitations...")
+				return tx.Migrator().DropTable(&models.Invitation{})
+			},
+		},
+		{
+			ID: "20250610090000_create_outbox_emails_table",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando bandeja de salida de correo e idioma de invitaciones...")
+				if err := tx.AutoMigrate(&models.Invitation{}); err != nil {
+					return err
+				}
+				return tx.AutoMigrate(&models.OutboxEmail{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando bandeja de salida de correo...")
+				if err := tx.Migrator().DropTable(&models.OutboxEmail{}); err != nil {
+					return err
+				}
+				return tx.Migrator().DropColumn(&models.Invitation{}, "Language")
+			},
+		},
+		{
+			ID: "20250611090000_create_notifications_tables",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tablas notifications y notification_preferences...")
+				return tx.AutoMigrate(&models.Notification{}, &models.NotificationPreference{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tablas de notificaciones...")
+				return tx.Migrator().DropTable(&models.NotificationPreference{}, &models.Notification{})
+			},
+		},
+		{
+			ID: "20250612090000_create_webhooks_tables",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tablas de webhooks salientes...")
+				return tx.AutoMigrate(&models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.WebhookDeliveryAttempt{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tablas de webhooks...")
+				return tx.Migrator().DropTable(&models.WebhookDeliveryAttempt{}, &models.WebhookDelivery{}, &models.WebhookSubscription{})
+			},
+		},
+		{
+			ID: "20250613090000_create_domain_events_tables",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tablas de eventos de dominio e índice de búsqueda...")
+				if err := tx.AutoMigrate(&models.DomainEvent{}, &models.DomainEventDelivery{}, &models.UserSearchDocument{}); err != nil {
+					return err
+				}
+				// Indexar los usuarios existentes; los cambios posteriores llegan como eventos.
+				// El texto debe coincidir con userSearchContent.
+				return tx.Exec(`INSERT INTO user_search_documents (user_id, content, updated_at)
+					SELECT u.id, LOWER(CONCAT_WS(' ', u.username, NULLIF(d.name, ''), NULLIF(d.last_name, ''), NULLIF(d.email, ''), NULLIF(d.position, ''))), NOW()
+					FROM users u
+					LEFT JOIN employee_details d ON d.user_id = u.id AND d.deleted_at IS NULL
+					WHERE u.deleted_at IS NULL`).Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tablas de eventos de dominio...")
+				return tx.Migrator().DropTable(&models.UserSearchDocument{}, &models.DomainEventDelivery{}, &models.DomainEvent{})
+			},
+		},
+		{
+			ID: "20250614090000_create_jobs_tables",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tablas de trabajos en segundo plano...")
+				return tx.AutoMigrate(&models.Job{}, &models.JobSchedule{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tablas de trabajos...")
+				return tx.Migrator().DropTable(&models.JobSchedule{}, &models.Job{})
+			},
+		},
+		{
+			// Catálogo de cursos: cursos, módulos y lecciones ordenados por posición
+			ID: "20250615090000_create_courses_tables",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tablas de cursos, módulos y lecciones...")
+				return tx.AutoMigrate(&models.Course{}, &models.Module{}, &models.Lesson{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tablas de cursos...")
+				return tx.Migrator().DropTable(&models.Lesson{}, &models.Module{}, &models.Course{})
+			},
+		},
+		{
+			// Inscripciones en cursos y reglas de asignación automática
+			ID: "20250616090000_create_enrollments_tables",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tablas de inscripciones y reglas de asignación...")
+				return tx.AutoMigrate(&models.Enrollment{}, &models.AssignmentRule{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tablas de inscripciones...")
+				return tx.Migrator().DropTable(&models.AssignmentRule{}, &models.Enrollment{})
+			},
+		},
+		{
+			// Avance de cada usuario en las lecciones de sus cursos
+			ID: "20250617090000_create_lesson_progress_table",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tabla lesson_progress...")
+				return tx.AutoMigrate(&models.LessonProgress{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tabla lesson_progress...")
+				return tx.Migrator().DropTable(&models.LessonProgress{})
+			},
+		},
+		{
+			// Cuestionarios: preguntas, opciones, intentos y respuestas
+			ID: "20250618090000_create_quizzes_tables",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tablas de cuestionarios...")
+				return tx.AutoMigrate(&models.Quiz{}, &models.Question{}, &models.QuestionOption{},
+					&models.QuizAttempt{}, &models.QuizAttemptAnswer{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tablas de cuestionarios...")
+				return tx.Migrator().DropTable(&models.QuizAttemptAnswer{}, &models.QuizAttempt{},
+					&models.QuestionOption{}, &models.Question{}, &models.Quiz{})
+			},
+		},
+		{
+			// Bancos de preguntas y reglas de sorteo: las preguntas pasan a pertenecer a un
+			// cuestionario o a un banco
+			ID: "20250619090000_create_question_banks",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando bancos de preguntas y reglas de sorteo...")
+				if err := tx.Migrator().AlterColumn(&models.Question{}, "QuizID"); err != nil {
+					return err
+				}
+				// AutoMigrate añade bank_id, topic y difficulty a questions.
+				return tx.AutoMigrate(&models.QuestionBank{}, &models.Question{}, &models.QuizDrawRule{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando bancos de preguntas y reglas de sorteo...")
+				if err := tx.Migrator().DropTable(&models.QuizDrawRule{}); err != nil {
+					return err
+				}
+				if err := tx.Exec("DELETE FROM question_options WHERE question_id IN (SELECT id FROM questions WHERE quiz_id IS NULL)").Error; err != nil {
+					return err
+				}
+				if err := tx.Exec("DELETE FROM questions WHERE quiz_id IS NULL").Error; err != nil {
+					return err
+				}
+				if err := tx.Exec("ALTER TABLE questions MODIFY quiz_id BIGINT UNSIGNED NOT NULL").Error; err != nil {
+					return err
+				}
+				for _, column := range []string{"BankID", "Topic", "Difficulty"} {
+					if err := tx.Migrator().DropColumn(&models.Question{}, column); err != nil {
+						return err
+					}
+				}
+				return tx.Migrator().DropTable(&models.QuestionBank{})
+			},
+		},
+		// Migración para los certificados de finalización y sus plantillas
+		{
+			ID: "20250620090000_create_certificates_tables",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando plantillas de certificado y certificados...")
+				// AutoMigrate de Course añade certificate_template_id.
+				return tx.AutoMigrate(&models.CertificateTemplate{}, &models.Certificate{}, &models.Course{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando plantillas de certificado y certificados...")
+				if err := tx.Migrator().DropColumn(&models.Course{}, "CertificateTemplateID"); err != nil {
+					return err
+				}
+				return tx.Migrator().DropTable(&models.Certificate{}, &models.CertificateTemplate{})
+			},
+		},
+		// Migración para la vigencia de las certificaciones y la recertificación
+		{
+			ID: "20250621090000_add_certification_expiry",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: añadiendo vigencia de certificaciones y recertificación...")
+				// Un certificado por vuelta de la inscripción: el índice único sobre enrollment_id
+				// se reemplaza por uno sobre (enrollment_id, cycle).
+				if tx.Migrator().HasIndex(&models.Certificate{}, "idx_certificates_enrollment_id") {
+					if err := tx.Migrator().DropIndex(&models.Certificate{}, "idx_certificates_enrollment_id"); err != nil {
+						return err
+					}
+				}
+				return tx.AutoMigrate(&models.Course{}, &models.Enrollment{}, &models.QuizAttempt{}, &models.Certificate{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando vigencia de certificaciones y recertificación...")
+				// Sin vueltas solo puede quedar el certificado de la primera.
+				if err := tx.Exec("DELETE FROM certificates WHERE cycle > 1").Error; err != nil {
+					return err
+				}
+				if err := tx.Migrator().DropIndex(&models.Certificate{}, "idx_certificate_enrollment_cycle"); err != nil {
+					return err
+				}
+				for _, column := range []string{"Cycle", "ExpiresAt", "ReminderDays"} {
+					if err := tx.Migrator().DropColumn(&models.Certificate{}, column); err != nil {
+						return err
+					}
+				}
+				if err := tx.Exec("CREATE UNIQUE INDEX idx_certificates_enrollment_id ON certificates (enrollment_id)").Error; err != nil {
+					return err
+				}
+				if err := tx.Migrator().DropColumn(&models.QuizAttempt{}, "Cycle"); err != nil {
+					return err
+				}
+				if err := tx.Migrator().DropColumn(&models.Enrollment{}, "Cycle"); err != nil {
+					return err
+				}
+				for _, column := range []string{"ValidityDays", "RecertifyLeadDays"} {
+					if err := tx.Migrator().DropColumn(&models.Course{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		// Migración para las rutas de aprendizaje y sus certificados
+		{
+			ID: "20250622090000_create_learning_paths",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando rutas de aprendizaje...")
+				if err := tx.AutoMigrate(&models.LearningPath{}, &models.LearningPathCourse{}, &models.LearningPathPrerequisite{}, &models.LearningPathEnrollment{}); err != nil {
+					return err
+				}
+				// Los certificados de una ruta no tienen curso ni inscripción.
+				for _, column := range []string{"CourseID", "EnrollmentID"} {
+					if err := tx.Migrator().AlterColumn(&models.Certificate{}, column); err != nil {
+						return err
+					}
+				}
+				// AutoMigrate añade path_id y path_enrollment_id a certificates.
+				return tx.AutoMigrate(&models.Certificate{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando rutas de aprendizaje...")
+				if err := tx.Exec("DELETE FROM certificates WHERE path_id IS NOT NULL").Error; err != nil {
+					return err
+				}
+				for _, column := range []string{"PathID", "PathEnrollmentID"} {
+					if err := tx.Migrator().DropColumn(&models.Certificate{}, column); err != nil {
+						return err
+					}
+				}
+				if err := tx.Exec("ALTER TABLE certificates MODIFY course_id BIGINT UNSIGNED NOT NULL").Error; err != nil {
+					return err
+				}
+				if err := tx.Exec("ALTER TABLE certificates MODIFY enrollment_id BIGINT UNSIGNED NOT NULL").Error; err != nil {
+					return err
+				}
+				return tx.Migrator().DropTable(&models.LearningPathEnrollment{}, &models.LearningPathPrerequisite{}, &models.LearningPathCourse{}, &models.LearningPath{})
+			},
+		},
+		// Migración para los paquetes SCORM y sus datos de ejecución
+		{
+			ID: "20250623090000_create_scorm_packages",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando paquetes SCORM...")
+				return tx.AutoMigrate(&models.ScormPackage{}, &models.ScormRuntime{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando paquetes SCORM...")
+				return tx.Migrator().DropTable(&models.ScormRuntime{}, &models.ScormPackage{})
+			},
+		},
+		// Migración para el LRS xAPI: clientes, declaraciones y documentos
+		{
+			ID: "20250624090000_create_xapi_lrs",
+			Migrate: func(tx *gorm.DB) error {
+				log.Println("Ejecutando migración: creando tablas del LRS xAPI...")
+				return tx.AutoMigrate(&models.XAPIClient{}, &models.XAPIActivityLink{}, &models.XAPIStatement{},
+					&models.XAPIStatementRef{}, &models.XAPIDocument{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				log.Println("Ejecutando rollback: eliminando tablas del LRS xAPI...")
+				return tx.Migrator().DropTable(&models.XAPIDocument{}, &models.XAPIStatementRef{}, &models.XAPIStatement{},
+					&models.XAPIActivityLink{}, &models.XAPIClient{})
+			},
+		},
 		// --- Aquí puedes añadir más migraciones en el futuro ---
 		// {
 		// 	ID: "YYYYMMDDHHMMSS_add_new_field_to_users",
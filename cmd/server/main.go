@@ -1,20 +1,28 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/Unikyri/yamerito-mvp/internal/config"
 	"github.com/Unikyri/yamerito-mvp/internal/database"
+	"github.com/Unikyri/yamerito-mvp/internal/events"
 	"github.com/Unikyri/yamerito-mvp/internal/handlers"
+	"github.com/Unikyri/yamerito-mvp/internal/jobs"
+	"github.com/Unikyri/yamerito-mvp/internal/mailer"
 	"github.com/Unikyri/yamerito-mvp/internal/auth"
 	"github.com/Unikyri/yamerito-mvp/internal/middleware"
 	"github.com/Unikyri/yamerito-mvp/internal/models"
 	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/Unikyri/yamerito-mvp/internal/storage"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
@@ -42,20 +50,32 @@ func main() {
 	}
 	log.Println("Conexión a la base de datos establecida y obtenida para el servidor.")
 
+	// Almacenamiento de archivos (fotos de perfil): disco local o bucket compatible con S3
+	blobStore, err := storage.New(appConfig.Storage)
+	if err != nil {
+		log.Fatalf("Error al inicializar el almacenamiento de archivos: %v", err)
+	}
+	log.Printf("Almacenamiento de archivos '%s' inicializado.", appConfig.Storage.Driver)
+
+	// Correo saliente (invitaciones, avisos): driver de envío, plantillas y bandeja de salida
+	mailTransport, err := mailer.New(appConfig.Mail)
+	if err != nil {
+		log.Fatalf("Error al inicializar el envío de correo: %v", err)
+	}
+	mailTemplates, err := mailer.NewTemplates(appConfig.Mail.DefaultLanguage)
+	if err != nil {
+		log.Fatalf("Error al cargar las plantillas de correo: %v", err)
+	}
+	mailOutbox := mailer.NewOutbox(db, mailTransport)
+	log.Printf("Envío de correo '%s' inicializado.", appConfig.Mail.Driver)
+
 	// Inicializar el router Gin
 	// gin.SetMode(gin.ReleaseMode) // Descomentar para producción
 	router := gin.Default() // Default() incluye logger y recovery middleware
+	router.Use(middleware.RequestID()) // ID de solicitud para correlacionar logs y auditoría
 
-	// Configurar CORS
-	// Para desarrollo, podemos ser un poco más permisivos.
-	// Para producción, deberías restringir los orígenes a tu dominio de frontend real.
-	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowOrigins = []string{"http://localhost:5173"} // Puerto común de Vite en `wails dev`
-	// Si usas `wails build` y sirves desde file:// o un localhost diferente para el frontend en prod,
-	// podrías necesitar añadir más orígenes o usar corsConfig.AllowAllOrigins = true (menos seguro).
-	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"} // Añadir Authorization para JWT
-	router.Use(cors.New(corsConfig))
+	// Configurar CORS (ver middleware.CORS)
+	router.Use(middleware.CORS(services.ScormContentPath))
 
 	// Rutas de prueba
 	router.GET("/ping", func(c *gin.Context) {
@@ -69,16 +89,101 @@ func main() {
 	// Inicializar servicios
 	authSvc := services.NewAuthService(db)
 	userSvc := services.NewUserService(db) // NewUserService devuelve *UserService, que implementa UserServiceInterface
+	profileSvc := services.NewProfileService(db)
+	auditSvc := services.NewAuditService(db)
+	orgSvc := services.NewOrgService(db)
+	customFieldSvc := services.NewCustomFieldService(db)
+	avatarSvc := services.NewAvatarService(db, blobStore)
+	lifecycleSvc := services.NewLifecycleService(db)
+	invitationSvc := services.NewInvitationService(db, mailOutbox, mailTemplates, lifecycleSvc, appConfig.Invitations)
+	notificationSvc := services.NewNotificationService(db, services.NewNotificationHub(), mailOutbox, mailTemplates, appConfig.Mail.AppURL)
+	webhookSvc := services.NewWebhookService(db, appConfig.IsDevelopment())
+
+	// Eventos de dominio: los servicios los registran junto con cada cambio y el bus los
+	// entrega después a cada suscriptor, reintentando los que fallen
+	eventBus := events.NewBus(db)
+	services.RegisterEventSubscribers(eventBus, notificationSvc)
+
+	jobSvc := services.NewJobService(db)
+	courseSvc := services.NewCourseService(db)
+	enrollmentSvc := services.NewEnrollmentService(db)
+	progressSvc := services.NewProgressService(db)
+	quizSvc := services.NewQuizService(db)
+	questionBankSvc := services.NewQuestionBankService(db)
+	certificateSvc := services.NewCertificateService(db, blobStore, appConfig.Certificates.VerifyURL)
+	learningPathSvc := services.NewLearningPathService(db)
+	scormSvc := services.NewScormService(db, blobStore)
+	xapiSvc := services.NewXAPIService(db, appConfig.Mail.AppURL)
+
+	// SIGINT/SIGTERM cancelan ctx: el servidor deja de aceptar conexiones y los procesos en
+	// segundo plano terminan lo que están haciendo antes de salir
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Procesamiento en segundo plano: trabajos (transiciones programadas del ciclo de vida,
+	// certificados, limpieza...), bandeja de salida de correo, webhooks salientes y eventos
+	// de dominio. Con JOBS_RUN_IN_SERVER=false el servidor solo los registra y los procesa
+	// cmd/worker; las notificaciones creadas allí llegan al stream en su siguiente consulta
+	jobWorker := jobs.NewWorker(db, appConfig.Jobs.Concurrency)
+	jobWorker.DrainTimeout = appConfig.Jobs.DrainTimeout
+	if err := services.RegisterJobs(jobWorker, db, lifecycleSvc, certificateSvc); err != nil {
+		log.Fatalf("Error al registrar los trabajos en segundo plano: %v", err)
+	}
+	workerDone := make(chan struct{})
+	if appConfig.Jobs.RunInServer {
+		go func() {
+			var background sync.WaitGroup
+			background.Add(1)
+			go func() {
+				defer background.Done()
+				services.RunDeliveryLoops(ctx, mailOutbox, webhookSvc, eventBus)
+			}()
+			jobWorker.Run(ctx)
+			background.Wait()
+			close(workerDone)
+		}()
+	} else {
+		close(workerDone)
+		log.Println("Procesamiento en segundo plano desactivado en el servidor (JOBS_RUN_IN_SERVER=false): lo realiza cmd/worker.")
+	}
 
 	// Inicializar handlers
 	authHandler := handlers.NewAuthHandler(authSvc)
 	userHandler := handlers.NewUserHandler(userSvc) 
+	profileHandler := handlers.NewProfileHandler(profileSvc)
+	auditHandler := handlers.NewAuditHandler(auditSvc)
+	orgHandler := handlers.NewOrgHandler(orgSvc)
+	customFieldHandler := handlers.NewCustomFieldHandler(customFieldSvc)
+	avatarHandler := handlers.NewAvatarHandler(avatarSvc)
+	lifecycleHandler := handlers.NewLifecycleHandler(lifecycleSvc)
+	invitationHandler := handlers.NewInvitationHandler(invitationSvc)
+	notificationHandler := handlers.NewNotificationHandler(notificationSvc)
+	webhookHandler := handlers.NewWebhookHandler(webhookSvc)
+	jobHandler := handlers.NewJobHandler(jobSvc)
+	courseHandler := handlers.NewCourseHandler(courseSvc)
+	enrollmentHandler := handlers.NewEnrollmentHandler(enrollmentSvc)
+	progressHandler := handlers.NewProgressHandler(progressSvc)
+	quizHandler := handlers.NewQuizHandler(quizSvc)
+	questionBankHandler := handlers.NewQuestionBankHandler(questionBankSvc)
+	certificateHandler := handlers.NewCertificateHandler(certificateSvc)
+	learningPathHandler := handlers.NewLearningPathHandler(learningPathSvc)
+	scormHandler := handlers.NewScormHandler(scormSvc)
+	xapiHandler := handlers.NewXAPIHandler(xapiSvc)
+
+	// Estado y rol vigentes de la cuenta de cada token, consultados en cada petición
+	accountStatus := middleware.AccountStatusFromDB(db)
 
 	// Agrupar rutas de la API bajo /api/v1
 	apiV1 := router.Group("/api/v1")
 	{
 		// Rutas de autenticación (login)
 		authHandler.RegisterAuthRoutes(apiV1)
+		// Aceptación de invitaciones (pública: el token del enlace es la credencial)
+		invitationHandler.RegisterInvitationRoutes(apiV1)
+		// Archivos de los paquetes SCORM (públicos: el token del paquete en la ruta es la credencial)
+		scormHandler.RegisterScormContentRoutes(apiV1)
+		// LRS xAPI para el contenido de proveedores (HTTP Basic con las credenciales de cada cliente)
+		xapiHandler.RegisterXAPIRoutes(apiV1)
 
 		// Rutas de usuario (login, etc. - las que queden públicas o semi-públicas)
 		// userHandler.RegisterUserRoutes(apiV1) // Esta función ahora está vacía o eliminada, ya que el login se movió.
@@ -86,16 +191,54 @@ func main() {
 		// Rutas de administración para gestión de usuarios
 		// Estas rutas requieren autenticación y rol de Admin.
 		adminRoutes := apiV1.Group("/admin")
-		adminRoutes.Use(middleware.AuthMiddleware())                       // Primero, autenticar JWT
+		adminRoutes.Use(middleware.AuthMiddleware(accountStatus))   // Primero, autenticar JWT
 		adminRoutes.Use(middleware.AuthorizeRole(models.RoleAdmin)) // Luego, verificar rol Admin
 		{
 			// Aquí registramos las rutas que userHandler expondrá para /admin/users/*
 			userHandler.RegisterAdminUserRoutes(adminRoutes) // Pasamos el grupo adminRoutes
+			// Configuración de qué campos del perfil puede editar cada empleado
+			profileHandler.RegisterAdminProfileSettingsRoutes(adminRoutes)
+			// Registro de auditoría (solo lectura) y verificación de la cadena de hashes
+			auditHandler.RegisterAdminAuditRoutes(adminRoutes)
+			// Departamentos, equipos y organigrama
+			orgHandler.RegisterAdminOrgRoutes(adminRoutes)
+			// Esquema de campos personalizados de empleados
+			customFieldHandler.RegisterAdminCustomFieldRoutes(adminRoutes)
+			// Fotos de perfil de cualquier empleado
+			avatarHandler.RegisterAdminAvatarRoutes(adminRoutes)
+			// Estados del ciclo de vida (alta, licencia, baja) y transiciones programadas
+			lifecycleHandler.RegisterAdminLifecycleRoutes(adminRoutes)
+			// Alta de usuarios por invitación
+			invitationHandler.RegisterAdminInvitationRoutes(adminRoutes)
+			// Webhooks salientes hacia sistemas externos (nómina, RR. HH.)
+			webhookHandler.RegisterAdminWebhookRoutes(adminRoutes)
+			// Estado de los trabajos en segundo plano y programaciones recurrentes
+			jobHandler.RegisterAdminJobRoutes(adminRoutes)
+			// Asignación de cursos (individual, masiva y por reglas automáticas)
+			enrollmentHandler.RegisterAdminEnrollmentRoutes(adminRoutes)
+			// Plantillas de certificado, reemisión y revocación de certificados
+			certificateHandler.RegisterAdminCertificateRoutes(adminRoutes)
+			// Rutas de aprendizaje: cursos encadenados por prerrequisitos
+			learningPathHandler.RegisterAdminLearningPathRoutes(adminRoutes)
+			// Clientes del LRS xAPI y actividades vinculadas a lecciones
+			xapiHandler.RegisterAdminXAPIRoutes(adminRoutes)
+		}
+
+		// Gestión del catálogo de cursos: administradores (cualquier curso) e instructores
+		// (los cursos que tienen asignados)
+		manageRoutes := apiV1.Group("/manage")
+		manageRoutes.Use(middleware.AuthMiddleware(accountStatus))
+		manageRoutes.Use(middleware.AuthorizeRoles(models.RoleAdmin, models.RoleInstructor))
+		{
+			courseHandler.RegisterManageCourseRoutes(manageRoutes)
+			quizHandler.RegisterManageQuizRoutes(manageRoutes)
+			questionBankHandler.RegisterManageQuestionBankRoutes(manageRoutes)
+			scormHandler.RegisterManageScormRoutes(manageRoutes)
 		}
 
 		// Grupo de rutas autenticadas
 		authRequired := apiV1.Group("") // Podría ser /auth o directamente bajo v1
-		authRequired.Use(middleware.AuthMiddleware()) // Aplicar middleware JWT a este grupo
+		authRequired.Use(middleware.AuthMiddleware(accountStatus)) // Aplicar middleware JWT a este grupo
 		{
 			// Endpoint de ejemplo para obtener información del usuario autenticado
 			authRequired.GET("/me", func(c *gin.Context) {
@@ -113,8 +256,39 @@ func main() {
 					"expires_at": claims.ExpiresAt.Time,
 				})
 			})
+
+			// Perfil completo (desde la BD) y autoedición de campos habilitados
+			profileHandler.RegisterProfileRoutes(authRequired)
+			// Foto de perfil propia y descarga de fotos de otros usuarios
+			avatarHandler.RegisterAvatarRoutes(authRequired)
+			// Centro de notificaciones y preferencias de aviso
+			notificationHandler.RegisterNotificationRoutes(authRequired)
+			// Catálogo de cursos publicados (solo lectura)
+			courseHandler.RegisterCourseRoutes(authRequired)
+			// Cursos asignados al usuario autenticado
+			enrollmentHandler.RegisterEnrollmentRoutes(authRequired)
+			// Avance en las lecciones y posición para retomar cada curso
+			progressHandler.RegisterProgressRoutes(authRequired)
+			// Cuestionarios de los cursos: intentos, guardado de respuestas y entrega
+			quizHandler.RegisterQuizRoutes(authRequired)
+			// Certificados de finalización propios
+			certificateHandler.RegisterCertificateRoutes(authRequired)
+			// Rutas de aprendizaje asignadas: cursos desbloqueados y avance
+			learningPathHandler.RegisterLearningPathRoutes(authRequired)
+			// Lecciones SCORM: apertura del SCO y backend de su API de ejecución
+			scormHandler.RegisterScormRoutes(authRequired)
+		}
+
+		// Conexiones en tiempo real (Server-Sent Events): además del encabezado se acepta ?ticket=,
+		// un ticket de un minuto emitido por POST /me/notifications/stream-ticket
+		streamRoutes := apiV1.Group("")
+		streamRoutes.Use(middleware.StreamAuthMiddleware(accountStatus))
+		{
+			notificationHandler.RegisterNotificationStreamRoutes(streamRoutes)
 		}
 	}
+	// Verificación pública de certificados: es el enlace impreso en cada certificado, fuera de /api
+	certificateHandler.RegisterPublicCertificateRoutes(&router.RouterGroup)
 	// --- Fin Configurar Handlers y Rutas de la API ---
 
 	// --- Servir Frontend --- 
@@ -149,7 +323,21 @@ func main() {
 	// Iniciar el servidor
 	serverPort := config.GetEnv("API_SERVER_PORT", "8080") // Puedes definir esto en .env
 	log.Printf("Servidor escuchando en el puerto %s...\n", serverPort)
-	if err := router.Run(":" + serverPort); err != nil {
-		log.Fatalf("Error al iniciar el servidor Gin: %v", err)
+	srv := &http.Server{Addr: ":" + serverPort, Handler: router}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Error al iniciar el servidor Gin: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Apagando el servidor...")
+	// Las conexiones en tiempo real no terminan solas: se espera un máximo razonable
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error al apagar el servidor HTTP: %v", err)
 	}
+	<-workerDone
+	log.Println("Servidor detenido.")
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/Unikyri/yamerito-mvp/internal/config"
+	"github.com/Unikyri/yamerito-mvp/internal/database"
+	"github.com/Unikyri/yamerito-mvp/internal/events"
+	"github.com/Unikyri/yamerito-mvp/internal/jobs"
+	"github.com/Unikyri/yamerito-mvp/internal/mailer"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/Unikyri/yamerito-mvp/internal/storage"
+)
+
+// cmd/worker hace el procesamiento en segundo plano fuera del servidor HTTP: ejecuta los
+// trabajos de la cola y entrega la bandeja de salida de correo, los webhooks salientes y
+// los eventos de dominio. Puede haber varias instancias a la vez; para que el servidor
+// deje de hacerlo y todo quede a cargo de cmd/worker, configurar JOBS_RUN_IN_SERVER=false.
+func main() {
+	log.Println("Iniciando procesador de trabajos Yamerito MVP...")
+
+	appConfig := config.LoadConfig()
+	if appConfig == nil {
+		log.Fatal("Error: No se pudo cargar la configuración.")
+	}
+
+	database.ConnectDB(appConfig)
+	db := database.GetDB()
+	if db == nil {
+		log.Fatal("Error: No se pudo obtener la instancia de la base de datos.")
+	}
+
+	// Los certificados de finalización se generan aquí y se guardan en el mismo almacenamiento que usa el servidor
+	blobStore, err := storage.New(appConfig.Storage)
+	if err != nil {
+		log.Fatalf("Error al inicializar el almacenamiento de archivos: %v", err)
+	}
+
+	// Correo saliente: los suscriptores de eventos encolan avisos y aquí se entregan
+	mailTransport, err := mailer.New(appConfig.Mail)
+	if err != nil {
+		log.Fatalf("Error al inicializar el envío de correo: %v", err)
+	}
+	mailTemplates, err := mailer.NewTemplates(appConfig.Mail.DefaultLanguage)
+	if err != nil {
+		log.Fatalf("Error al cargar las plantillas de correo: %v", err)
+	}
+	mailOutbox := mailer.NewOutbox(db, mailTransport)
+
+	lifecycleSvc := services.NewLifecycleService(db)
+	certificateSvc := services.NewCertificateService(db, blobStore, appConfig.Certificates.VerifyURL)
+	// Sin conexiones en tiempo real en este proceso: el stream del servidor recoge las
+	// notificaciones nuevas en su siguiente consulta a la BD
+	notificationSvc := services.NewNotificationService(db, services.NewNotificationHub(), mailOutbox, mailTemplates, appConfig.Mail.AppURL)
+	webhookSvc := services.NewWebhookService(db, appConfig.IsDevelopment())
+	eventBus := events.NewBus(db)
+	services.RegisterEventSubscribers(eventBus, notificationSvc)
+
+	worker := jobs.NewWorker(db, appConfig.Jobs.Concurrency)
+	worker.DrainTimeout = appConfig.Jobs.DrainTimeout
+	if err := services.RegisterJobs(worker, db, lifecycleSvc, certificateSvc); err != nil {
+		log.Fatalf("Error al registrar los trabajos: %v", err)
+	}
+
+	// SIGINT/SIGTERM detienen la toma de trabajos y esperan a los que están en curso.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		services.RunDeliveryLoops(ctx, mailOutbox, webhookSvc, eventBus)
+	}()
+	worker.Run(ctx)
+	wg.Wait()
+	log.Println("Procesador de trabajos detenido.")
+}
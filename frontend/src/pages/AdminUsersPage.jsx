@@ -9,6 +9,7 @@ function AdminUsersPage() {
   const [error, setError] = useState('');
   const [isModalOpen, setIsModalOpen] = useState(false);
   const [editingUser, setEditingUser] = useState(null); // Estado para el usuario en edición
+  const [editingETag, setEditingETag] = useState(null); // ETag de la versión que se está editando (para If-Match)
 
   const styles = {
     container: {
@@ -144,15 +145,41 @@ function AdminUsersPage() {
     fetchUsers();
   }, []);
 
-  const handleOpenModal = (userToEdit = null) => { 
-    setEditingUser(userToEdit); 
+  const handleOpenModal = async (userToEdit = null) => { 
     setError(''); 
-    setIsModalOpen(true);
+    setEditingETag(null);
+    if (!userToEdit) {
+      setEditingUser(null);
+      setIsModalOpen(true);
+      return;
+    }
+    // Cargar la versión actual del usuario para obtener su ETag; se enviará como If-Match al guardar
+    // y así evitar sobrescribir cambios hechos por otro administrador mientras tanto.
+    try {
+      const token = localStorage.getItem('token');
+      const response = await fetch(`/api/v1/admin/users/${userToEdit.id}`, {
+        method: 'GET',
+        headers: {
+          'Authorization': `Bearer ${token}`,
+        },
+      });
+      const data = await response.json();
+      if (!response.ok) {
+        throw new Error(data.error || `Error: ${response.status}`);
+      }
+      setEditingUser(data);
+      setEditingETag(response.headers.get('ETag'));
+      setIsModalOpen(true);
+    } catch (err) {
+      console.error('Error fetching user for edit:', err);
+      setError(err.message || 'No se pudo cargar el usuario para editar.');
+    }
   };
 
   const handleCloseModal = () => {
     setIsModalOpen(false);
     setEditingUser(null); // Siempre limpiar editingUser al cerrar el modal
+    setEditingETag(null);
   };
 
   const handleCreateUser = async (userData) => {
@@ -224,10 +251,19 @@ function AdminUsersPage() {
         headers: {
           'Content-Type': 'application/json',
           'Authorization': `Bearer ${token}`,
+          'If-Match': editingETag || '',
         },
         body: JSON.stringify(payload),
       });
 
+      if (response.status === 412) {
+        // Otro administrador modificó el usuario: mostrar la versión actual y pedir que se revise.
+        const conflictData = await response.json();
+        setEditingUser(conflictData.current);
+        setEditingETag(response.headers.get('ETag'));
+        throw new Error('Otro administrador modificó este usuario mientras lo editabas. Se cargaron los datos actuales; revisa los cambios y vuelve a guardar.');
+      }
+
       if (!response.ok) {
         let errorMsg = `Error ${response.status}: ${response.statusText}`;
         try {
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// invitationAudience distingue los tokens de invitación de los de sesión.
+const invitationAudience = "yamerito-invitation"
+
+// InvitationClaims son los datos firmados en un enlace de invitación. Nonce debe coincidir
+// con el guardado en la invitación: reenviarla genera uno nuevo e invalida el enlace anterior.
+type InvitationClaims struct {
+	InvitationID uint   `json:"invitation_id"`
+	Nonce        string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// invitationKey deriva una clave propia para las invitaciones a partir de JWT_SECRET_KEY,
+// de modo que un token de invitación nunca sea aceptado como token de sesión ni al revés.
+func invitationKey() ([]byte, error) {
+	if len(jwtSecretKey) == 0 {
+		if err := InitJWT(); err != nil {
+			return nil, err
+		}
+	}
+	key := sha256.Sum256(append([]byte("invitation:"), jwtSecretKey...))
+	return key[:], nil
+}
+
+// GenerateInvitationToken firma un token de invitación que vence en expiresAt.
+func GenerateInvitationToken(invitationID uint, nonce string, expiresAt time.Time) (string, error) {
+	key, err := invitationKey()
+	if err != nil {
+		return "", err
+	}
+	claims := &InvitationClaims{
+		InvitationID: invitationID,
+		Nonce:        nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(invitationID), 10),
+			Audience:  jwt.ClaimStrings{invitationAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "yamerito-mvp",
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+// ValidateInvitationToken verifica la firma, la audiencia y la vigencia de un token de invitación.
+func ValidateInvitationToken(tokenString string) (*InvitationClaims, error) {
+	key, err := invitationKey()
+	if err != nil {
+		return nil, err
+	}
+	claims := &InvitationClaims{}
+	_, err = jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("método de firma inesperado")
+		}
+		return key, nil
+	}, jwt.WithAudience(invitationAudience), jwt.WithExpirationRequired())
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, errors.New("la invitación expiró")
+		}
+		return nil, errors.New("invitación inválida")
+	}
+	if claims.InvitationID == 0 || claims.Nonce == "" {
+		return nil, errors.New("invitación inválida")
+	}
+	return claims, nil
+}
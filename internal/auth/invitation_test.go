@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestInvitationTokenRoundTrip(t *testing.T) {
+	setTestSecret(t)
+	expiresAt := time.Now().Add(72 * time.Hour)
+	token, err := GenerateInvitationToken(15, "nonce-1", expiresAt)
+	if err != nil {
+		t.Fatalf("GenerateInvitationToken: %v", err)
+	}
+	claims, err := ValidateInvitationToken(token)
+	if err != nil {
+		t.Fatalf("ValidateInvitationToken: %v", err)
+	}
+	if claims.InvitationID != 15 || claims.Nonce != "nonce-1" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if !claims.ExpiresAt.Time.Equal(expiresAt.Truncate(time.Second)) {
+		t.Errorf("vence %v, se esperaba %v", claims.ExpiresAt.Time, expiresAt)
+	}
+}
+
+func TestInvitationTokenRejected(t *testing.T) {
+	setTestSecret(t)
+	valid, err := GenerateInvitationToken(15, "nonce-1", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := GenerateInvitationToken(15, "nonce-1", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := GenerateJWT(15, "ana", models.RoleEmployee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := invitationKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Firmado con la clave de invitaciones pero sin su audiencia.
+	wrongAudience, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &InvitationClaims{
+		InvitationID:     15,
+		Nonce:            "nonce-1",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Con la audiencia correcta pero firmado con la clave de sesión.
+	sessionKey, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &InvitationClaims{
+		InvitationID: 15,
+		Nonce:        "nonce-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{invitationAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString(jwtSecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(valid, ".")
+	signature := []byte(parts[2])
+	if signature[10] == 'A' {
+		signature[10] = 'B'
+	} else {
+		signature[10] = 'A'
+	}
+	otherPayload := strings.Split(mustInvitationToken(t, 16, "nonce-1"), ".")[1]
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"vencido", expired, "la invitación expiró"},
+		{"firma alterada", parts[0] + "." + parts[1] + "." + string(signature), "invitación inválida"},
+		{"contenido alterado", parts[0] + "." + otherPayload + "." + parts[2], "invitación inválida"},
+		{"token de sesión", session, "invitación inválida"},
+		{"audiencia de sesión", wrongAudience, "invitación inválida"},
+		{"clave de sesión", sessionKey, "invitación inválida"},
+		{"vacío", "", "invitación inválida"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ValidateInvitationToken(tt.token)
+			if err == nil {
+				t.Fatalf("se aceptó el token: %+v", claims)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("error = %q, se esperaba %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Un enlace de invitación no sirve como token de sesión.
+func TestInvitationTokenIsNotASession(t *testing.T) {
+	setTestSecret(t)
+	if _, err := ValidateJWT(mustInvitationToken(t, 15, "nonce-1")); err == nil {
+		t.Error("ValidateJWT aceptó un token de invitación")
+	}
+}
+
+func mustInvitationToken(t *testing.T, invitationID uint, nonce string) string {
+	t.Helper()
+	token, err := GenerateInvitationToken(invitationID, nonce, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
@@ -28,9 +28,21 @@ type Claims struct {
 	UserID   uint        `json:"user_id"`
 	Username string      `json:"username"`
 	Role     models.Role `json:"role"`
+	// SessionExpiresAt solo está en los tickets de conexión: es el vencimiento del token de
+	// sesión con el que se pidió el ticket (ver GenerateStreamTicket).
+	SessionExpiresAt *jwt.NumericDate `json:"session_exp,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// SessionExpiry devuelve cuándo vence la sesión de estos claims. En un ticket de conexión
+// no es su propio vencimiento sino el del token de sesión del que salió.
+func (c *Claims) SessionExpiry() *jwt.NumericDate {
+	if c.SessionExpiresAt != nil {
+		return c.SessionExpiresAt
+	}
+	return c.ExpiresAt
+}
+
 // GenerateJWT genera un nuevo token JWT para un usuario.
 func GenerateJWT(userID uint, username string, role models.Role) (string, error) {
 	if len(jwtSecretKey) == 0 {
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// streamTicketAudience distingue los tickets de conexión en tiempo real de los tokens de sesión.
+const streamTicketAudience = "yamerito-stream"
+
+// StreamTicketTTL es la vigencia de un ticket: solo tiene que durar lo que tarda el cliente
+// en abrir la conexión. Una conexión ya abierta no se corta cuando el ticket vence, sino
+// cuando vence la sesión (Claims.SessionExpiry).
+const StreamTicketTTL = time.Minute
+
+// streamTicketKey deriva una clave propia para los tickets a partir de JWT_SECRET_KEY, de
+// modo que un ticket nunca sea aceptado como token de sesión ni al revés.
+func streamTicketKey() ([]byte, error) {
+	if len(jwtSecretKey) == 0 {
+		if err := InitJWT(); err != nil {
+			return nil, err
+		}
+	}
+	key := sha256.Sum256(append([]byte("stream:"), jwtSecretKey...))
+	return key[:], nil
+}
+
+// GenerateStreamTicket firma un ticket de corta duración para abrir una conexión
+// Server-Sent Events. EventSource no permite enviar encabezados y el ticket viaja en la
+// URL, donde queda en los logs de acceso: por eso nunca se envía allí el JWT de sesión.
+// sessionExpiresAt es el vencimiento del token de sesión que pide el ticket: viaja en el
+// claim session_exp para que la conexión termine con la sesión y no con el ticket.
+func GenerateStreamTicket(userID uint, username string, role models.Role, sessionExpiresAt time.Time) (string, time.Time, error) {
+	key, err := streamTicketKey()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	if !sessionExpiresAt.After(now) {
+		return "", time.Time{}, errors.New("la sesión ya expiró")
+	}
+	expiresAt := now.Add(StreamTicketTTL)
+	if expiresAt.After(sessionExpiresAt) {
+		expiresAt = sessionExpiresAt
+	}
+	claims := &Claims{
+		UserID:           userID,
+		Username:         username,
+		Role:             role,
+		SessionExpiresAt: jwt.NewNumericDate(sessionExpiresAt),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Audience:  jwt.ClaimStrings{streamTicketAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "yamerito-mvp",
+		},
+	}
+	ticket, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return ticket, expiresAt, nil
+}
+
+// ValidateStreamTicket verifica la firma, la audiencia y la vigencia de un ticket y
+// devuelve los mismos claims que un token de sesión.
+func ValidateStreamTicket(ticket string) (*Claims, error) {
+	key, err := streamTicketKey()
+	if err != nil {
+		return nil, err
+	}
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(ticket, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("método de firma inesperado")
+		}
+		return key, nil
+	}, jwt.WithAudience(streamTicketAudience), jwt.WithExpirationRequired())
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, errors.New("ticket expirado")
+		}
+		return nil, errors.New("ticket inválido")
+	}
+	if claims.UserID == 0 || claims.SessionExpiresAt == nil {
+		return nil, errors.New("ticket inválido")
+	}
+	return claims, nil
+}
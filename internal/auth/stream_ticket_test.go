@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+)
+
+func setTestSecret(t *testing.T) {
+	t.Helper()
+	t.Setenv("JWT_SECRET_KEY", "clave-de-prueba")
+	jwtSecretKey = nil
+	t.Cleanup(func() { jwtSecretKey = nil })
+}
+
+func TestStreamTicketRoundTrip(t *testing.T) {
+	setTestSecret(t)
+	session := time.Now().Add(2 * time.Hour)
+	ticket, expiresAt, err := GenerateStreamTicket(42, "ana", models.RoleEmployee, session)
+	if err != nil {
+		t.Fatalf("GenerateStreamTicket: %v", err)
+	}
+	claims, err := ValidateStreamTicket(ticket)
+	if err != nil {
+		t.Fatalf("ValidateStreamTicket: %v", err)
+	}
+	if claims.UserID != 42 || claims.Username != "ana" || claims.Role != models.RoleEmployee {
+		t.Errorf("claims = %+v", claims)
+	}
+	if !claims.ExpiresAt.Time.Equal(expiresAt.Truncate(1e9)) {
+		t.Errorf("vence %v, se informó %v", claims.ExpiresAt.Time, expiresAt)
+	}
+	// La conexión abierta con el ticket debe durar lo que la sesión, no lo que el ticket.
+	if got := claims.SessionExpiry(); got == nil || !got.Time.Equal(session.Truncate(time.Second)) {
+		t.Errorf("SessionExpiry = %v, se esperaba %v", got, session)
+	}
+}
+
+func TestStreamTicketDoesNotOutliveSession(t *testing.T) {
+	setTestSecret(t)
+	session := time.Now().Add(20 * time.Second)
+	_, expiresAt, err := GenerateStreamTicket(42, "ana", models.RoleEmployee, session)
+	if err != nil {
+		t.Fatalf("GenerateStreamTicket: %v", err)
+	}
+	if expiresAt.After(session) {
+		t.Errorf("el ticket vence %v, después de la sesión (%v)", expiresAt, session)
+	}
+	if _, _, err := GenerateStreamTicket(42, "ana", models.RoleEmployee, time.Now().Add(-time.Second)); err == nil {
+		t.Error("se emitió un ticket para una sesión vencida")
+	}
+}
+
+func TestSessionExpiryOfSessionToken(t *testing.T) {
+	setTestSecret(t)
+	token, err := GenerateJWT(42, "ana", models.RoleEmployee)
+	if err != nil {
+		t.Fatalf("GenerateJWT: %v", err)
+	}
+	claims, err := ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("ValidateJWT: %v", err)
+	}
+	if got := claims.SessionExpiry(); got == nil || !got.Time.Equal(claims.ExpiresAt.Time) {
+		t.Errorf("SessionExpiry = %v, se esperaba %v", got, claims.ExpiresAt)
+	}
+}
+
+func TestStreamTicketIsNotASessionToken(t *testing.T) {
+	setTestSecret(t)
+	ticket, _, err := GenerateStreamTicket(42, "ana", models.RoleAdmin, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GenerateStreamTicket: %v", err)
+	}
+	if _, err := ValidateJWT(ticket); err == nil {
+		t.Error("un ticket de conexión fue aceptado como token de sesión")
+	}
+
+	session, err := GenerateJWT(42, "ana", models.RoleAdmin)
+	if err != nil {
+		t.Fatalf("GenerateJWT: %v", err)
+	}
+	if _, err := ValidateStreamTicket(session); err == nil {
+		t.Error("un token de sesión fue aceptado como ticket de conexión")
+	}
+}
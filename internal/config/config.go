@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv" // Para cargar .env
 )
@@ -19,9 +21,63 @@ type DBConfig struct {
 	// DSN ya no es necesario aquí, se construye en database.go
 }
 
+// StorageConfig define dónde se guardan los archivos binarios (fotos de perfil, etc.).
+// Driver "local" usa un directorio del servidor; "s3" usa un servicio compatible con S3 (AWS, MinIO, ...).
+type StorageConfig struct {
+	Driver      string
+	LocalDir    string
+	S3Endpoint  string // p. ej. http://localhost:9000 para MinIO
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// MailConfig define cómo se envía el correo saliente.
+// Driver "smtp" usa un servidor SMTP; "file" guarda cada mensaje como .eml en FileDir y
+// "log" solo lo escribe en el log del servidor (ambos para desarrollo).
+type MailConfig struct {
+	Driver          string
+	From            string // Remitente, p. ej. "Yamerito <no-reply@yamerito.com>"
+	DefaultLanguage string // Idioma de las plantillas si el destinatario no tiene uno ("es" o "en")
+	AppURL          string // URL pública del frontend, base de los enlaces incluidos en los correos
+	FileDir         string
+	SMTPHost        string
+	SMTPPort        string
+	SMTPUsername    string
+	SMTPPassword    string
+	SMTPTLS         string // "starttls" (por defecto), "tls" (SMTPS, puerto 465) o "none"
+}
+
+// InvitationConfig define las invitaciones de alta de usuarios.
+type InvitationConfig struct {
+	AcceptURL string        // Página del frontend a la que se agrega ?token=...
+	TTL       time.Duration // Vigencia del enlace
+}
+
+// JobsConfig define el procesamiento en segundo plano: trabajos, bandeja de salida de
+// correo, webhooks salientes y eventos de dominio. Con RunInServer en false el servidor
+// solo los registra y los procesa cmd/worker, que siempre se ocupa de los cuatro.
+type JobsConfig struct {
+	RunInServer  bool
+	Concurrency  int           // Trabajos simultáneos por proceso
+	DrainTimeout time.Duration // Espera máxima a los trabajos en curso al apagar
+}
+
+// CertificateConfig define los certificados de finalización de cursos.
+type CertificateConfig struct {
+	VerifyURL string // Base pública de la verificación; el certificado imprime <VerifyURL>/<código>
+}
+
 // AppConfig almacena toda la configuración de la aplicación
 type AppConfig struct {
-	Database DBConfig
+	Environment  string // "development" relaja los controles pensados para producción (p. ej. webhooks por http)
+	Database     DBConfig
+	Storage      StorageConfig
+	Mail         MailConfig
+	Invitations  InvitationConfig
+	Jobs         JobsConfig
+	Certificates CertificateConfig
 }
 
 // LoadConfig carga la configuración de la aplicación desde variables de entorno
@@ -58,6 +114,7 @@ func LoadConfig() *AppConfig {
 	}
 
 	return &AppConfig{
+		Environment: GetEnv("APP_ENV", "production"),
 		Database: DBConfig{
 			Username:     dbUser,
 			Password:     dbPassword,
@@ -67,7 +124,55 @@ func LoadConfig() *AppConfig {
 			SSLMode:      dbSSLMode,
 			SSLCertPath:  dbSSLCertPath,
 		},
+		Storage: StorageConfig{
+			Driver:      GetEnv("STORAGE_DRIVER", "local"),
+			LocalDir:    GetEnv("STORAGE_LOCAL_DIR", "./data/blobs"),
+			S3Endpoint:  GetEnv("S3_ENDPOINT", ""),
+			S3Region:    GetEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    GetEnv("S3_BUCKET", ""),
+			S3AccessKey: GetEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: GetEnv("S3_SECRET_KEY", ""),
+		},
+		Mail: MailConfig{
+			Driver:          GetEnv("MAIL_DRIVER", "log"),
+			From:            GetEnv("MAIL_FROM", "Yamerito <no-reply@localhost>"),
+			DefaultLanguage: GetEnv("MAIL_DEFAULT_LANGUAGE", "es"),
+			AppURL:          GetEnv("APP_URL", "http://localhost:5173"),
+			FileDir:         GetEnv("MAIL_FILE_DIR", "./data/mail"),
+			SMTPHost:        GetEnv("SMTP_HOST", ""),
+			SMTPPort:        GetEnv("SMTP_PORT", "587"),
+			SMTPUsername:    GetEnv("SMTP_USERNAME", ""),
+			SMTPPassword:    GetEnv("SMTP_PASSWORD", ""),
+			SMTPTLS:         GetEnv("SMTP_TLS", "starttls"),
+		},
+		Invitations: InvitationConfig{
+			AcceptURL: GetEnv("INVITATION_ACCEPT_URL", "http://localhost:5173/aceptar-invitacion"),
+			TTL:       time.Duration(GetEnvInt("INVITATION_TTL_HOURS", 72)) * time.Hour,
+		},
+		Jobs: JobsConfig{
+			RunInServer:  GetEnv("JOBS_RUN_IN_SERVER", "true") != "false",
+			Concurrency:  GetEnvInt("JOBS_CONCURRENCY", 4),
+			DrainTimeout: time.Duration(GetEnvInt("JOBS_DRAIN_TIMEOUT_SECONDS", 30)) * time.Second,
+		},
+		Certificates: CertificateConfig{
+			VerifyURL: GetEnv("CERTIFICATE_VERIFY_URL", "http://localhost:8080/verify"),
+		},
+	}
+}
+
+// IsDevelopment indica si la aplicación corre en un entorno de desarrollo (APP_ENV=development).
+func (c *AppConfig) IsDevelopment() bool {
+	return c.Environment == "development"
+}
+
+// GetEnvInt recupera una variable de entorno entera; si no existe o no es un número
+// positivo, devuelve el valor por defecto.
+func GetEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(GetEnv(key, ""))
+	if err != nil || value <= 0 {
+		return fallback
 	}
+	return value
 }
 
 // GetEnv recupera una variable de entorno o devuelve un valor por defecto.
@@ -0,0 +1,324 @@
+// Package events implementa los eventos de dominio: una bandeja de salida transaccional
+// (tabla domain_events) y un despachador en proceso que entrega cada evento a los
+// suscriptores interesados al menos una vez.
+//
+// La auditoría no es un suscriptor: los servicios escriben el evento de auditoría en la
+// misma transacción que el cambio (ver services.recordAudit). Una entrega puede agotar sus
+// reintentos y descartarse, y la cadena de hashes de la auditoría no admite huecos; además
+// se auditan acciones que no generan eventos de dominio, como los logins fallidos.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultMaxAttempts = 10
+	batchSize          = 50
+	// lease es el tiempo que una entrega en curso reserva la fila; si el proceso muere a
+	// mitad, la entrega vuelve a estar disponible pasado este margen.
+	lease       = 2 * time.Minute
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// Subscriber reacciona a los eventos de dominio. Handle se ejecuta dentro de una
+// transacción que también marca la entrega como hecha: los cambios que el suscriptor haga
+// con tx se confirman exactamente una vez. Los efectos externos (HTTP, memoria) pueden
+// repetirse si el proceso cae entre el efecto y la confirmación, así que deben ser idempotentes.
+type Subscriber interface {
+	// Name identifica al suscriptor en la tabla de entregas; no debe cambiar entre versiones.
+	Name() string
+	Handles(eventType string) bool
+	Handle(tx *gorm.DB, event *models.DomainEvent) error
+}
+
+// CommitObserver lo implementan los suscriptores que necesitan actuar cuando su
+// transacción ya se confirmó (p. ej. despertar las conexiones en tiempo real).
+type CommitObserver interface {
+	Committed(event *models.DomainEvent)
+}
+
+// Record guarda event en tx, que debe ser la transacción del cambio que lo origina: el
+// evento existe si y solo si el cambio se confirma. data se serializa como Payload.
+func Record(tx *gorm.DB, event *models.DomainEvent, data interface{}) error {
+	if event.EventID == "" {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return fmt.Errorf("no se pudo generar el ID del evento: %w", err)
+		}
+		event.EventID = hex.EncodeToString(buf)
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("no se pudo serializar el evento %s: %w", event.Type, err)
+	}
+	event.Payload = payload
+	if err := tx.Create(event).Error; err != nil {
+		return fmt.Errorf("no se pudo registrar el evento %s: %w", event.Type, err)
+	}
+	return nil
+}
+
+// Bus reparte los eventos registrados con Record entre los suscriptores. Es seguro
+// ejecutar Run en varios procesos a la vez: las entregas se reclaman con una
+// actualización condicional, como en la bandeja de salida de correo.
+type Bus struct {
+	DB          *gorm.DB
+	MaxAttempts int
+
+	mu          sync.RWMutex
+	subscribers map[string]Subscriber
+}
+
+// NewBus crea un despachador sin suscriptores.
+func NewBus(db *gorm.DB) *Bus {
+	return &Bus{DB: db, MaxAttempts: defaultMaxAttempts, subscribers: map[string]Subscriber{}}
+}
+
+// Subscribe registra un suscriptor. Recibe los eventos que todavía no se hayan repartido;
+// los ya repartidos antes de registrarlo no se le entregan.
+func (b *Bus) Subscribe(sub Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[sub.Name()] = sub
+}
+
+func (b *Bus) subscriber(name string) (Subscriber, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	sub, ok := b.subscribers[name]
+	return sub, ok
+}
+
+func (b *Bus) interested(eventType string) []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var names []string
+	for name, sub := range b.subscribers {
+		if sub.Handles(eventType) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// backoff devuelve la espera antes del intento número attempts+1.
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait
+}
+
+// Dispatch reparte los eventos nuevos y procesa las entregas vencidas. Devuelve cuántas
+// entregas se completaron.
+func (b *Bus) Dispatch(ctx context.Context) (int, error) {
+	if err := b.fanOut(ctx); err != nil {
+		return 0, err
+	}
+	return b.deliverPending(ctx)
+}
+
+// fanOut crea una entrega por cada suscriptor interesado en los eventos sin repartir.
+func (b *Bus) fanOut(ctx context.Context) error {
+	var fresh []models.DomainEvent
+	if err := b.DB.WithContext(ctx).Where("dispatched_at IS NULL").
+		Order("id").Limit(batchSize).Find(&fresh).Error; err != nil {
+		return err
+	}
+	for i := range fresh {
+		event := &fresh[i]
+		now := time.Now()
+		deliveries := make([]models.DomainEventDelivery, 0)
+		for _, name := range b.interested(event.Type) {
+			deliveries = append(deliveries, models.DomainEventDelivery{
+				EventID:       event.ID,
+				Subscriber:    name,
+				Status:        models.DomainEventDeliveryPending,
+				NextAttemptAt: now,
+			})
+		}
+
+		tx := b.DB.WithContext(ctx).Begin()
+		if len(deliveries) > 0 {
+			// Si otro proceso ya repartió el evento, sus entregas se conservan.
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error; err != nil {
+				tx.Rollback()
+				return fmt.Errorf("no se pudieron crear las entregas del evento %d: %w", event.ID, err)
+			}
+		}
+		if err := tx.Model(&models.DomainEvent{}).Where("id = ? AND dispatched_at IS NULL", event.ID).
+			Update("dispatched_at", now).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("no se pudo marcar el evento %d como repartido: %w", event.ID, err)
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverPending procesa las entregas vencidas.
+func (b *Bus) deliverPending(ctx context.Context) (int, error) {
+	now := time.Now()
+	var due []models.DomainEventDelivery
+	if err := b.DB.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.DomainEventDeliveryPending, now).
+		Order("next_attempt_at, id").Limit(batchSize).Find(&due).Error; err != nil {
+		return 0, err
+	}
+	if err := b.loadEvents(ctx, due); err != nil {
+		return 0, err
+	}
+
+	done := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		delivery := &due[i]
+		claim := b.DB.WithContext(ctx).Model(&models.DomainEventDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.DomainEventDeliveryPending, delivery.NextAttemptAt).
+			Update("next_attempt_at", now.Add(lease))
+		if claim.Error != nil {
+			return done, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue // Otro proceso la tomó
+		}
+		if b.deliver(ctx, delivery) {
+			done++
+		}
+	}
+	return done, nil
+}
+
+// loadEvents completa el evento de cada entrega.
+func (b *Bus) loadEvents(ctx context.Context, deliveries []models.DomainEventDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.EventID)
+	}
+	var found []models.DomainEvent
+	if err := b.DB.WithContext(ctx).Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]models.DomainEvent, len(found))
+	for _, e := range found {
+		byID[e.ID] = e
+	}
+	for i := range deliveries {
+		deliveries[i].Event = byID[deliveries[i].EventID]
+	}
+	return nil
+}
+
+// handle ejecuta el suscriptor convirtiendo un pánico en error, para que un suscriptor
+// defectuoso no detenga el despachador.
+func handle(sub Subscriber, tx *gorm.DB, event *models.DomainEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pánico en el suscriptor: %v", r)
+		}
+	}()
+	return sub.Handle(tx, event)
+}
+
+// deliver entrega un evento ya reclamado a su suscriptor y registra el resultado.
+func (b *Bus) deliver(ctx context.Context, delivery *models.DomainEventDelivery) bool {
+	attempts := delivery.Attempts + 1
+	sub, ok := b.subscriber(delivery.Subscriber)
+	var err error
+	if !ok {
+		err = fmt.Errorf("el suscriptor %s no está registrado en este proceso", delivery.Subscriber)
+	} else {
+		now := time.Now()
+		tx := b.DB.WithContext(ctx).Begin()
+		if err = handle(sub, tx, &delivery.Event); err == nil {
+			err = tx.Model(&models.DomainEventDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+				"status":       models.DomainEventDeliveryDone,
+				"attempts":     attempts,
+				"processed_at": now,
+				"last_error":   "",
+			}).Error
+		}
+		if err == nil {
+			err = tx.Commit().Error
+		} else {
+			tx.Rollback()
+		}
+		if err == nil {
+			if observer, ok := sub.(CommitObserver); ok {
+				observer.Committed(&delivery.Event)
+			}
+			return true
+		}
+	}
+
+	updates := b.failureUpdates(delivery, err, time.Now())
+	if updates["status"] == models.DomainEventDeliveryFailed {
+		log.Printf("Evento %s (%s) descartado para %s tras %d intentos: %v", delivery.Event.EventID, delivery.Event.Type, delivery.Subscriber, attempts, err)
+	} else {
+		log.Printf("Error al entregar el evento %s (%s) a %s (intento %d): %v", delivery.Event.EventID, delivery.Event.Type, delivery.Subscriber, attempts, err)
+	}
+	if dbErr := b.DB.Model(&models.DomainEventDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; dbErr != nil {
+		log.Printf("Error al registrar el resultado de la entrega %d: %v", delivery.ID, dbErr)
+	}
+	return false
+}
+
+// failureUpdates calcula los cambios a guardar tras un intento fallido: se reprograma con
+// espera exponencial hasta agotar MaxAttempts y entonces queda fallida.
+func (b *Bus) failureUpdates(delivery *models.DomainEventDelivery, err error, now time.Time) map[string]interface{} {
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts, "last_error": err.Error()}
+	maxAttempts := b.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	if attempts >= maxAttempts {
+		updates["status"] = models.DomainEventDeliveryFailed
+	} else {
+		updates["next_attempt_at"] = now.Add(backoff(attempts))
+	}
+	return updates
+}
+
+// Run procesa los eventos cada interval hasta que ctx se cancele.
+func (b *Bus) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := b.Dispatch(ctx); err != nil {
+			log.Printf("Error al despachar eventos de dominio: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// stubSubscriber cuenta las entregas recibidas y falla si err no es nil.
+type stubSubscriber struct {
+	name  string
+	err   error
+	calls int
+	last  string // EventID del último evento recibido
+}
+
+func (s *stubSubscriber) Name() string          { return s.name }
+func (s *stubSubscriber) Handles(_ string) bool { return true }
+func (s *stubSubscriber) Handle(_ *gorm.DB, event *models.DomainEvent) error {
+	s.calls++
+	s.last = event.EventID
+	return s.err
+}
+
+func newMockBus(t *testing.T) (*Bus, sqlmock.Sqlmock) {
+	t.Helper()
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}),
+		&gorm.Config{SkipDefaultTransaction: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewBus(db), mock
+}
+
+// expectDue devuelve dos entregas vencidas del mismo evento, una por suscriptor.
+func expectDue(mock sqlmock.Sqlmock, due time.Time, subscribers ...string) {
+	rows := sqlmock.NewRows([]string{"id", "event_id", "subscriber", "status", "attempts", "next_attempt_at"})
+	for i, name := range subscribers {
+		rows.AddRow(i+1, 1, name, models.DomainEventDeliveryPending, 0, due)
+	}
+	mock.ExpectQuery("SELECT \\* FROM `domain_event_deliveries` WHERE status = \\? AND next_attempt_at <= \\?").
+		WillReturnRows(rows)
+	mock.ExpectQuery("SELECT \\* FROM `domain_events` WHERE id IN \\(\\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "type", "payload"}).
+			AddRow(1, "evt-1", models.DomainEventUserCreated, []byte("{}")))
+}
+
+// expectClaim espera la actualización condicional que reclama la entrega id.
+func expectClaim(mock sqlmock.Sqlmock, id int, due time.Time, claimed bool) {
+	var affected int64
+	if claimed {
+		affected = 1
+	}
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `domain_event_deliveries` SET `next_attempt_at`=?,`updated_at`=? WHERE id = ? AND status = ? AND next_attempt_at = ?")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), id, models.DomainEventDeliveryPending, due).
+		WillReturnResult(sqlmock.NewResult(0, affected))
+}
+
+// Un suscriptor que falla se reprograma sin impedir que los demás reciban el evento.
+func TestDeliverPendingIsolatesFailingSubscriber(t *testing.T) {
+	bus, mock := newMockBus(t)
+	failing := &stubSubscriber{name: "a-failing", err: errors.New("índice caído")}
+	healthy := &stubSubscriber{name: "b-healthy"}
+	bus.Subscribe(failing)
+	bus.Subscribe(healthy)
+
+	due := time.Now().Add(-time.Minute).Truncate(time.Second)
+	expectDue(mock, due, failing.name, healthy.name)
+
+	// Entrega fallida: se deshace la transacción del suscriptor y se anota el reintento.
+	expectClaim(mock, 1, due, true)
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectExec("UPDATE `domain_event_deliveries` SET .*`attempts`=\\?.*`last_error`=\\?.*`next_attempt_at`=\\?.* WHERE id = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Entrega correcta: se marca como hecha en la misma transacción.
+	expectClaim(mock, 2, due, true)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `domain_event_deliveries` SET .*`status`=\\?.* WHERE id = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	done, err := bus.deliverPending(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done != 1 {
+		t.Errorf("entregas completadas = %d, se esperaba 1", done)
+	}
+	if failing.calls != 1 || healthy.calls != 1 {
+		t.Errorf("llamadas: fallido=%d sano=%d, se esperaba 1 y 1", failing.calls, healthy.calls)
+	}
+	if failing.last != "evt-1" || healthy.last != "evt-1" {
+		t.Errorf("eventos recibidos: %q y %q, se esperaba evt-1", failing.last, healthy.last)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// Si otro proceso reclamó la entrega (la actualización condicional no afecta filas), no
+// se vuelve a ejecutar el suscriptor.
+func TestDeliverPendingSkipsDeliveryClaimedElsewhere(t *testing.T) {
+	bus, mock := newMockBus(t)
+	sub := &stubSubscriber{name: "search"}
+	bus.Subscribe(sub)
+
+	due := time.Now().Add(-time.Minute).Truncate(time.Second)
+	expectDue(mock, due, sub.name)
+	expectClaim(mock, 1, due, false)
+
+	done, err := bus.deliverPending(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done != 0 || sub.calls != 0 {
+		t.Errorf("done=%d llamadas=%d, la entrega reclamada por otro proceso no debe ejecutarse", done, sub.calls)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBusFailureUpdatesRetriesWithBackoff(t *testing.T) {
+	bus := &Bus{MaxAttempts: 4}
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	delivery := &models.DomainEventDelivery{ID: 3}
+	handleErr := errors.New("timeout")
+
+	for attempt, wait := range []time.Duration{baseBackoff, 2 * baseBackoff, 4 * baseBackoff} {
+		updates := bus.failureUpdates(delivery, handleErr, now)
+		if updates["attempts"] != attempt+1 {
+			t.Fatalf("intento %d: attempts = %v", attempt+1, updates["attempts"])
+		}
+		if _, ok := updates["status"]; ok {
+			t.Fatalf("intento %d: la entrega cambió de estado antes de agotar los intentos", attempt+1)
+		}
+		if updates["next_attempt_at"] != now.Add(wait) {
+			t.Fatalf("intento %d: next_attempt_at = %v, se esperaba %v", attempt+1, updates["next_attempt_at"], now.Add(wait))
+		}
+		delivery.Attempts++
+	}
+
+	updates := bus.failureUpdates(delivery, handleErr, now)
+	if updates["status"] != models.DomainEventDeliveryFailed {
+		t.Fatalf("status = %v, se esperaba %s", updates["status"], models.DomainEventDeliveryFailed)
+	}
+	if _, ok := updates["next_attempt_at"]; ok {
+		t.Error("una entrega fallida no debe reprogramarse")
+	}
+}
+
+func TestBusBackoffIsCapped(t *testing.T) {
+	if got := backoff(1); got != baseBackoff {
+		t.Errorf("backoff(1) = %v, se esperaba %v", got, baseBackoff)
+	}
+	if got := backoff(50); got != maxBackoff {
+		t.Errorf("backoff(50) = %v, se esperaba %v", got, maxBackoff)
+	}
+}
+
+// Un pánico en un suscriptor se convierte en error y no detiene el despachador.
+func TestHandleRecoversPanic(t *testing.T) {
+	err := handle(panicSubscriber{}, nil, &models.DomainEvent{})
+	if err == nil {
+		t.Fatal("se esperaba un error tras el pánico del suscriptor")
+	}
+}
+
+type panicSubscriber struct{}
+
+func (panicSubscriber) Name() string          { return "panic" }
+func (panicSubscriber) Handles(_ string) bool { return true }
+func (panicSubscriber) Handle(_ *gorm.DB, _ *models.DomainEvent) error {
+	panic("nil map")
+}
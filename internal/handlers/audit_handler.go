@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/middleware"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler expone la consulta y verificación del registro de auditoría.
+type AuditHandler struct {
+	AuditService services.AuditServiceInterface
+}
+
+// NewAuditHandler crea una nueva instancia de AuditHandler.
+func NewAuditHandler(auditService services.AuditServiceInterface) *AuditHandler {
+	return &AuditHandler{AuditService: auditService}
+}
+
+// requestActor construye el actor de la solicitud (usuario autenticado, IP e ID de
+// solicitud) que los servicios registran en la auditoría.
+func requestActor(c *gin.Context) services.RequestActor {
+	actor := services.RequestActor{
+		IP:        c.ClientIP(),
+		RequestID: middleware.GetRequestID(c),
+	}
+	if claims, ok := middleware.GetAuthClaims(c); ok {
+		actor.UserID = claims.UserID
+		actor.Username = claims.Username
+		actor.Role = claims.Role
+	}
+	return actor
+}
+
+// parseOptionalUint lee un parámetro de consulta numérico opcional.
+func parseOptionalUint(c *gin.Context, name string) (*uint, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	v, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		return nil, false
+	}
+	u := uint(v)
+	return &u, true
+}
+
+// parseOptionalTime acepta fechas RFC 3339 o simplemente YYYY-MM-DD.
+func parseOptionalTime(c *gin.Context, name string) (*time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return &t, true
+		}
+	}
+	return nil, false
+}
+
+// ListEvents lista los eventos de auditoría con paginación y filtros.
+// GET /api/v1/admin/audit?actor_id=&action=&target_type=&target_id=&request_id=&from=&to=&page=&page_size=
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	filter := services.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		RequestID:  c.Query("request_id"),
+	}
+	var ok bool
+	if filter.ActorID, ok = parseOptionalUint(c, "actor_id"); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "actor_id inválido"})
+		return
+	}
+	if filter.TargetID, ok = parseOptionalUint(c, "target_id"); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target_id inválido"})
+		return
+	}
+	if filter.From, ok = parseOptionalTime(c, "from"); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from inválido (use RFC 3339 o YYYY-MM-DD)"})
+		return
+	}
+	if filter.To, ok = parseOptionalTime(c, "to"); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to inválido (use RFC 3339 o YYYY-MM-DD)"})
+		return
+	}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	page, err := h.AuditService.ListEvents(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al consultar la auditoría"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// VerifyChain recorre la cadena de hashes e informa si fue alterada.
+// GET /api/v1/admin/audit/verify
+func (h *AuditHandler) VerifyChain(c *gin.Context) {
+	result, err := h.AuditService.VerifyChain()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al verificar la auditoría"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// RegisterAdminAuditRoutes registra las rutas de auditoría bajo el grupo /admin.
+func (h *AuditHandler) RegisterAdminAuditRoutes(rg *gin.RouterGroup) {
+	auditRoutes := rg.Group("/audit")
+	{
+		auditRoutes.GET("", h.ListEvents)
+		auditRoutes.GET("/verify", h.VerifyChain)
+	}
+}
@@ -40,11 +40,13 @@ func (h *AuthHandler) LoginUser(c *gin.Context) {
 	}
 
 	// Llamar al servicio de login
-	token, user, err := h.AuthService.LoginUser(dto)
+	token, user, err := h.AuthService.LoginUser(requestActor(c), dto)
 	if err != nil {
 		// El servicio ya debería loguear errores internos.
 		if err.Error() == "usuario o contraseña incorrectos" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		} else if err.Error() == "la cuenta no está activa" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno del servidor"})
 		}
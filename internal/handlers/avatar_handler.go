@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/imaging"
+	"github.com/Unikyri/yamerito-mvp/internal/middleware"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// maxAvatarBytes limita el tamaño del archivo de imagen subido.
+const maxAvatarBytes = 5 << 20
+
+// avatarCacheMaxAge es el tiempo que el navegador puede reutilizar una foto sin revalidarla.
+// Cada foto nueva tiene un ETag distinto, así que no hay riesgo de servir una foto vieja por mucho tiempo.
+const avatarCacheMaxAge = 24 * time.Hour
+
+// AvatarHandler expone la subida y descarga de fotos de perfil.
+type AvatarHandler struct {
+	AvatarService services.AvatarServiceInterface
+}
+
+// NewAvatarHandler crea una nueva instancia de AvatarHandler.
+func NewAvatarHandler(avatarService services.AvatarServiceInterface) *AvatarHandler {
+	return &AvatarHandler{AvatarService: avatarService}
+}
+
+// readAvatarUpload lee el archivo del campo "file" de un formulario multipart.
+func readAvatarUpload(c *gin.Context) ([]byte, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAvatarBytes+(64<<10))
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "La imagen supera el tamaño máximo de 5 MB"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Se esperaba un formulario multipart con el campo 'file'", "details": err.Error()})
+		}
+		return nil, false
+	}
+	if fileHeader.Size > maxAvatarBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "La imagen supera el tamaño máximo de 5 MB"})
+		return nil, false
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+		return nil, false
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxAvatarBytes+1))
+	if err != nil || len(data) > maxAvatarBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "La imagen supera el tamaño máximo de 5 MB"})
+		return nil, false
+	}
+	return data, true
+}
+
+func (h *AvatarHandler) setAvatar(c *gin.Context, userID uint) {
+	data, ok := readAvatarUpload(c)
+	if !ok {
+		return
+	}
+	user, err := h.AvatarService.SetAvatar(requestActor(c), userID, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedFormat):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		case errors.Is(err, imaging.ErrTooLarge):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case err.Error() == "usuario no encontrado":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case err.Error() == "el usuario no tiene detalles de empleado",
+			err.Error() == "el usuario fue modificado al mismo tiempo; intente de nuevo":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar la foto de perfil"})
+		}
+		return
+	}
+	c.Header("ETag", user.ETag())
+	c.JSON(http.StatusOK, gin.H{"message": "Foto de perfil actualizada", "user": user})
+}
+
+func (h *AvatarHandler) deleteAvatar(c *gin.Context, userID uint) {
+	if err := h.AvatarService.DeleteAvatar(requestActor(c), userID); err != nil {
+		if err.Error() == "usuario no encontrado" || err.Error() == "el usuario no tiene foto de perfil" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar la foto de perfil"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Foto de perfil eliminada"})
+}
+
+// SetOwnAvatar sube la foto de perfil del usuario autenticado.
+// PUT /api/v1/me/avatar (multipart/form-data, campo "file")
+func (h *AvatarHandler) SetOwnAvatar(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	h.setAvatar(c, claims.UserID)
+}
+
+// DeleteOwnAvatar quita la foto de perfil del usuario autenticado.
+// DELETE /api/v1/me/avatar
+func (h *AvatarHandler) DeleteOwnAvatar(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	h.deleteAvatar(c, claims.UserID)
+}
+
+// SetUserAvatar permite a un administrador subir la foto de cualquier empleado.
+// PUT /api/v1/admin/users/:id/avatar
+func (h *AvatarHandler) SetUserAvatar(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de usuario inválido")
+	if !ok {
+		return
+	}
+	h.setAvatar(c, id)
+}
+
+// DeleteUserAvatar permite a un administrador quitar la foto de cualquier empleado.
+// DELETE /api/v1/admin/users/:id/avatar
+func (h *AvatarHandler) DeleteUserAvatar(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de usuario inválido")
+	if !ok {
+		return
+	}
+	h.deleteAvatar(c, id)
+}
+
+// GetAvatar sirve la foto de perfil de un usuario a cualquier usuario autenticado.
+// GET /api/v1/users/:id/avatar?size=small|medium|large
+// Responde 304 si el ETag enviado en If-None-Match sigue vigente.
+func (h *AvatarHandler) GetAvatar(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de usuario inválido")
+	if !ok {
+		return
+	}
+	obj, err := h.AvatarService.GetAvatar(id, c.Query("size"))
+	if err != nil {
+		switch err.Error() {
+		case "tamaño de foto inválido":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "el usuario no tiene foto de perfil":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la foto de perfil"})
+		}
+		return
+	}
+
+	// La respuesta depende del usuario autenticado (requiere token), por eso es "private".
+	c.Header("Cache-Control", "private, max-age="+strconv.Itoa(int(avatarCacheMaxAge.Seconds())))
+	c.Header("ETag", obj.ETag)
+	if !obj.UpdatedAt.IsZero() {
+		c.Header("Last-Modified", obj.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	if inm := c.GetHeader("If-None-Match"); inm != "" && inm == obj.ETag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	rc, info, err := h.AvatarService.OpenAvatar(c.Request.Context(), obj)
+	if err != nil {
+		if err.Error() == "el usuario no tiene foto de perfil" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la foto de perfil"})
+		}
+		return
+	}
+	defer rc.Close()
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, info.Size, "image/jpeg", rc, nil)
+}
+
+// RegisterAvatarRoutes registra las rutas de fotos de perfil bajo un grupo ya autenticado.
+func (h *AvatarHandler) RegisterAvatarRoutes(rg *gin.RouterGroup) {
+	rg.PUT("/me/avatar", h.SetOwnAvatar)
+	rg.DELETE("/me/avatar", h.DeleteOwnAvatar)
+	rg.GET("/users/:id/avatar", h.GetAvatar)
+}
+
+// RegisterAdminAvatarRoutes registra la gestión de fotos de otros usuarios bajo el grupo /admin.
+func (h *AvatarHandler) RegisterAdminAvatarRoutes(rg *gin.RouterGroup) {
+	rg.PUT("/users/:id/avatar", h.SetUserAvatar)
+	rg.DELETE("/users/:id/avatar", h.DeleteUserAvatar)
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/imaging"
+	"github.com/Unikyri/yamerito-mvp/internal/middleware"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CertificateHandler expone las plantillas de certificado, la descarga de certificados y
+// su verificación pública.
+type CertificateHandler struct {
+	CertificateService services.CertificateServiceInterface
+}
+
+// NewCertificateHandler crea una nueva instancia de CertificateHandler.
+func NewCertificateHandler(certificateService services.CertificateServiceInterface) *CertificateHandler {
+	return &CertificateHandler{CertificateService: certificateService}
+}
+
+// respondCertificateError traduce los errores del servicio de certificados a códigos HTTP.
+func respondCertificateError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, imaging.ErrUnsupportedFormat):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, imaging.ErrTooLarge):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	switch err.Error() {
+	case "plantilla de certificado no encontrada", "certificado no encontrado", "curso no encontrado", "usuario no encontrado":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "el nombre de la plantilla no puede estar vacío", "orientación de plantilla inválida",
+		"el motivo de la revocación no puede estar vacío":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case "no se puede eliminar la plantilla predeterminada", "la plantilla de certificado está asignada a algún curso o ruta",
+		"el certificado ya está revocado":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		if strings.HasPrefix(err.Error(), "la plantilla ") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListTemplates lista las plantillas de certificado.
+// GET /api/v1/admin/certificate-templates
+func (h *CertificateHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.CertificateService.ListTemplates()
+	if err != nil {
+		respondCertificateError(c, err, "Error al obtener las plantillas de certificado")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"templates": templates, "placeholders": services.CertificatePlaceholders})
+}
+
+// GetTemplate devuelve una plantilla de certificado.
+// GET /api/v1/admin/certificate-templates/:id
+func (h *CertificateHandler) GetTemplate(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de plantilla inválido")
+	if !ok {
+		return
+	}
+	template, err := h.CertificateService.GetTemplate(id)
+	if err != nil {
+		respondCertificateError(c, err, "Error al obtener la plantilla de certificado")
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// CreateTemplate crea una plantilla de certificado.
+// POST /api/v1/admin/certificate-templates
+func (h *CertificateHandler) CreateTemplate(c *gin.Context) {
+	var dto services.CertificateTemplateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	template, err := h.CertificateService.CreateTemplate(requestActor(c), dto)
+	if err != nil {
+		respondCertificateError(c, err, "Error al crear la plantilla de certificado")
+		return
+	}
+	c.JSON(http.StatusCreated, template)
+}
+
+// UpdateTemplate reemplaza el diseño de una plantilla y programa la reemisión de sus certificados.
+// PUT /api/v1/admin/certificate-templates/:id
+func (h *CertificateHandler) UpdateTemplate(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de plantilla inválido")
+	if !ok {
+		return
+	}
+	var dto services.CertificateTemplateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	template, err := h.CertificateService.UpdateTemplate(requestActor(c), id, dto)
+	if err != nil {
+		respondCertificateError(c, err, "Error al actualizar la plantilla de certificado")
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteTemplate elimina una plantilla que ningún curso use.
+// DELETE /api/v1/admin/certificate-templates/:id
+func (h *CertificateHandler) DeleteTemplate(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de plantilla inválido")
+	if !ok {
+		return
+	}
+	if err := h.CertificateService.DeleteTemplate(requestActor(c), id); err != nil {
+		respondCertificateError(c, err, "Error al eliminar la plantilla de certificado")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SetTemplateSignature sube la imagen de la firma de una plantilla.
+// PUT /api/v1/admin/certificate-templates/:id/signature (multipart/form-data, campo "file")
+func (h *CertificateHandler) SetTemplateSignature(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de plantilla inválido")
+	if !ok {
+		return
+	}
+	data, ok := readAvatarUpload(c)
+	if !ok {
+		return
+	}
+	template, err := h.CertificateService.SetTemplateSignature(requestActor(c), id, data)
+	if err != nil {
+		respondCertificateError(c, err, "Error al guardar la firma")
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// PreviewTemplate genera un certificado de ejemplo con la plantilla.
+// GET /api/v1/admin/certificate-templates/:id/preview
+func (h *CertificateHandler) PreviewTemplate(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de plantilla inválido")
+	if !ok {
+		return
+	}
+	doc, err := h.CertificateService.PreviewTemplate(c.Request.Context(), id)
+	if err != nil {
+		respondCertificateError(c, err, "Error al generar la vista previa")
+		return
+	}
+	c.Header("Content-Disposition", "inline; filename=\"vista-previa.pdf\"")
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/pdf", doc)
+}
+
+// ReissueTemplate vuelve a programar la reemisión de los certificados desactualizados de una plantilla.
+// POST /api/v1/admin/certificate-templates/:id/reissue
+func (h *CertificateHandler) ReissueTemplate(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de plantilla inválido")
+	if !ok {
+		return
+	}
+	outdated, err := h.CertificateService.ReissueTemplate(requestActor(c), id)
+	if err != nil {
+		respondCertificateError(c, err, "Error al programar la reemisión")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Reemisión programada", "certificates": outdated})
+}
+
+// SetCourseTemplate asigna la plantilla de certificado de un curso.
+// PUT /api/v1/admin/courses/:id/certificate-template
+func (h *CertificateHandler) SetCourseTemplate(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	var dto services.SetCourseTemplateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	course, err := h.CertificateService.SetCourseTemplate(requestActor(c), id, dto.TemplateID)
+	if err != nil {
+		respondCertificateError(c, err, "Error al asignar la plantilla de certificado")
+		return
+	}
+	c.JSON(http.StatusOK, course)
+}
+
+// ListCertificates lista los certificados emitidos.
+// GET /api/v1/admin/certificates?course_id=&path_id=&user_id=&revoked=true|false&page=&page_size=
+func (h *CertificateHandler) ListCertificates(c *gin.Context) {
+	var filter services.CertificateFilter
+	if courseID, err := strconv.ParseUint(c.Query("course_id"), 10, 32); err == nil {
+		filter.CourseID = uint(courseID)
+	}
+	if pathID, err := strconv.ParseUint(c.Query("path_id"), 10, 32); err == nil {
+		filter.PathID = uint(pathID)
+	}
+	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
+		filter.UserID = uint(userID)
+	}
+	if revoked, err := strconv.ParseBool(c.Query("revoked")); err == nil {
+		filter.Revoked = &revoked
+	}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	page, err := h.CertificateService.ListCertificates(filter)
+	if err != nil {
+		respondCertificateError(c, err, "Error al obtener los certificados")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// RevokeCertificate revoca un certificado.
+// POST /api/v1/admin/certificates/:id/revoke
+func (h *CertificateHandler) RevokeCertificate(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de certificado inválido")
+	if !ok {
+		return
+	}
+	var dto services.RevokeCertificateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	certificate, err := h.CertificateService.RevokeCertificate(requestActor(c), id, dto.Reason)
+	if err != nil {
+		respondCertificateError(c, err, "Error al revocar el certificado")
+		return
+	}
+	c.JSON(http.StatusOK, certificate)
+}
+
+// DownloadCertificate descarga el PDF de un certificado (del propio usuario o, para un
+// administrador, de cualquiera).
+// GET /api/v1/me/certificates/:id/pdf y GET /api/v1/admin/certificates/:id/pdf
+func (h *CertificateHandler) DownloadCertificate(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de certificado inválido")
+	if !ok {
+		return
+	}
+	certificate, rc, info, err := h.CertificateService.OpenCertificate(c.Request.Context(), requestActor(c), id)
+	if err != nil {
+		respondCertificateError(c, err, "Error al obtener el certificado")
+		return
+	}
+	defer rc.Close()
+	c.Header("Cache-Control", "private, no-cache")
+	c.DataFromReader(http.StatusOK, info.Size, "application/pdf", rc, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=\"certificado-%s.pdf\"", certificate.Code),
+	})
+}
+
+// ListMyCertificates lista los certificados del usuario autenticado.
+// GET /api/v1/me/certificates
+func (h *CertificateHandler) ListMyCertificates(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	certificates, err := h.CertificateService.ListMyCertificates(claims.UserID)
+	if err != nil {
+		respondCertificateError(c, err, "Error al obtener los certificados")
+		return
+	}
+	c.JSON(http.StatusOK, certificates)
+}
+
+// GetMyCompliance maneja GET /api/v1/me/compliance: el estado de cumplimiento del
+// usuario autenticado y el vencimiento de cada una de sus certificaciones.
+func (h *CertificateHandler) GetMyCompliance(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	compliance, err := h.CertificateService.GetUserCompliance(claims.UserID)
+	if err != nil {
+		respondCertificateError(c, err, "Error al obtener el estado de cumplimiento")
+		return
+	}
+	c.JSON(http.StatusOK, compliance)
+}
+
+// GetUserCompliance maneja GET /api/v1/admin/users/:id/compliance.
+func (h *CertificateHandler) GetUserCompliance(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de usuario inválido")
+	if !ok {
+		return
+	}
+	compliance, err := h.CertificateService.GetUserCompliance(id)
+	if err != nil {
+		respondCertificateError(c, err, "Error al obtener el estado de cumplimiento")
+		return
+	}
+	c.JSON(http.StatusOK, compliance)
+}
+
+// VerifyCertificate confirma, sin iniciar sesión, si un código corresponde a un certificado
+// auténtico. Un certificado revocado o vencido responde 200 con "valid": false.
+// GET /verify/:code
+func (h *CertificateHandler) VerifyCertificate(c *gin.Context) {
+	verification, err := h.CertificateService.VerifyCertificate(c.Param("code"))
+	if err != nil {
+		if err.Error() == "certificado no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{"valid": false, "error": "No existe ningún certificado con ese código"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al verificar el certificado"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, verification)
+}
+
+// RegisterCertificateRoutes registra los certificados propios bajo un grupo ya autenticado.
+func (h *CertificateHandler) RegisterCertificateRoutes(rg *gin.RouterGroup) {
+	rg.GET("/me/certificates", h.ListMyCertificates)
+	rg.GET("/me/certificates/:id/pdf", h.DownloadCertificate)
+	rg.GET("/me/compliance", h.GetMyCompliance)
+}
+
+// RegisterAdminCertificateRoutes registra las plantillas y la gestión de certificados bajo el grupo /admin.
+func (h *CertificateHandler) RegisterAdminCertificateRoutes(rg *gin.RouterGroup) {
+	templateRoutes := rg.Group("/certificate-templates")
+	{
+		templateRoutes.GET("", h.ListTemplates)
+		templateRoutes.POST("", h.CreateTemplate)
+		templateRoutes.GET("/:id", h.GetTemplate)
+		templateRoutes.PUT("/:id", h.UpdateTemplate)
+		templateRoutes.DELETE("/:id", h.DeleteTemplate)
+		templateRoutes.PUT("/:id/signature", h.SetTemplateSignature)
+		templateRoutes.GET("/:id/preview", h.PreviewTemplate)
+		templateRoutes.POST("/:id/reissue", h.ReissueTemplate)
+	}
+	rg.PUT("/courses/:id/certificate-template", h.SetCourseTemplate)
+	rg.GET("/certificates", h.ListCertificates)
+	rg.POST("/certificates/:id/revoke", h.RevokeCertificate)
+	rg.GET("/certificates/:id/pdf", h.DownloadCertificate)
+	rg.GET("/users/:id/compliance", h.GetUserCompliance)
+}
+
+// RegisterPublicCertificateRoutes registra la verificación pública de certificados, que no
+// requiere autenticación: es el enlace impreso en cada certificado.
+func (h *CertificateHandler) RegisterPublicCertificateRoutes(rg *gin.RouterGroup) {
+	rg.GET("/verify/:code", h.VerifyCertificate)
+}
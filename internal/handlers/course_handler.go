@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CourseHandler expone la gestión del catálogo de cursos (administradores e instructores)
+// y su consulta por parte de los empleados.
+type CourseHandler struct {
+	CourseService services.CourseServiceInterface
+}
+
+// NewCourseHandler crea una nueva instancia de CourseHandler.
+func NewCourseHandler(courseService services.CourseServiceInterface) *CourseHandler {
+	return &CourseHandler{CourseService: courseService}
+}
+
+// respondCourseError traduce los errores del servicio de cursos a códigos HTTP.
+func respondCourseError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "curso no encontrado", "módulo no encontrado", "lección no encontrada":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "no tienes permiso para modificar este curso", "solo un administrador puede reasignar el instructor":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "no se puede editar un curso archivado",
+		"solo se pueden eliminar cursos en borrador o archivados",
+		"transición de estado de curso no permitida",
+		"el curso necesita al menos un módulo con lecciones para publicarse",
+		"las lecciones SCORM necesitan un paquete para publicar el curso",
+		"un curso publicado debe conservar al menos una lección":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "estado de curso inválido",
+		"tipo de lección inválido",
+		"el instructor indicado no existe o no tiene rol de instructor",
+		"el título del curso no puede estar vacío",
+		"la anticipación de la recertificación debe ser menor que la vigencia",
+		"el título del módulo no puede estar vacío",
+		"el título de la lección no puede estar vacío",
+		"las lecciones de texto requieren contenido",
+		"las lecciones de video, documento o enlace requieren content_url",
+		"la lista debe incluir exactamente los módulos del curso",
+		"la lista debe incluir exactamente las lecciones del módulo":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// parseCourseChildIDs lee el ID del curso y, según names, los de módulo y lección de la ruta.
+func parseCourseChildIDs(c *gin.Context, names ...string) ([]uint, bool) {
+	courseID, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return nil, false
+	}
+	ids := []uint{courseID}
+	for _, name := range names {
+		id, err := strconv.ParseUint(c.Param(name), 10, 32)
+		if err != nil {
+			message := "ID de módulo inválido"
+			if name == "lessonId" {
+				message = "ID de lección inválido"
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return nil, false
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, true
+}
+
+func courseFilterFromQuery(c *gin.Context) services.CourseFilter {
+	filter := services.CourseFilter{
+		Status:   c.Query("status"),
+		Category: c.Query("category"),
+		Query:    c.Query("q"),
+	}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	return filter
+}
+
+// ListManagedCourses lista los cursos que el usuario puede gestionar (todos para un
+// administrador, los asignados para un instructor).
+// GET /api/v1/manage/courses?status=draft|published|archived&category=&q=&page=&page_size=
+func (h *CourseHandler) ListManagedCourses(c *gin.Context) {
+	page, err := h.CourseService.ListManagedCourses(requestActor(c), courseFilterFromQuery(c))
+	if err != nil {
+		respondCourseError(c, err, "Error al obtener los cursos")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetManagedCourse devuelve un curso con sus módulos y lecciones, en cualquier estado.
+// GET /api/v1/manage/courses/:id
+func (h *CourseHandler) GetManagedCourse(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	course, err := h.CourseService.GetManagedCourse(requestActor(c), id)
+	if err != nil {
+		respondCourseError(c, err, "Error al obtener el curso")
+		return
+	}
+	c.JSON(http.StatusOK, course)
+}
+
+// CreateCourse crea un curso en borrador.
+// POST /api/v1/manage/courses
+func (h *CourseHandler) CreateCourse(c *gin.Context) {
+	var dto services.CreateCourseDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	course, err := h.CourseService.CreateCourse(requestActor(c), dto)
+	if err != nil {
+		respondCourseError(c, err, "Error al crear el curso")
+		return
+	}
+	c.JSON(http.StatusCreated, course)
+}
+
+// UpdateCourse modifica los datos generales de un curso.
+// PUT /api/v1/manage/courses/:id
+func (h *CourseHandler) UpdateCourse(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	var dto services.UpdateCourseDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	course, err := h.CourseService.UpdateCourse(requestActor(c), id, dto)
+	if err != nil {
+		respondCourseError(c, err, "Error al actualizar el curso")
+		return
+	}
+	c.JSON(http.StatusOK, course)
+}
+
+// DeleteCourse elimina un curso en borrador o archivado.
+// DELETE /api/v1/manage/courses/:id
+func (h *CourseHandler) DeleteCourse(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	if err := h.CourseService.DeleteCourse(requestActor(c), id); err != nil {
+		respondCourseError(c, err, "Error al eliminar el curso")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ChangeCourseStatus publica, archiva o devuelve a borrador un curso.
+// POST /api/v1/manage/courses/:id/status
+func (h *CourseHandler) ChangeCourseStatus(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	var dto services.ChangeCourseStatusDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	course, err := h.CourseService.ChangeCourseStatus(requestActor(c), id, dto)
+	if err != nil {
+		respondCourseError(c, err, "Error al cambiar el estado del curso")
+		return
+	}
+	c.JSON(http.StatusOK, course)
+}
+
+// AddModule agrega un módulo al final del curso.
+// POST /api/v1/manage/courses/:id/modules
+func (h *CourseHandler) AddModule(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	var dto services.ModuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	module, err := h.CourseService.AddModule(requestActor(c), id, dto)
+	if err != nil {
+		respondCourseError(c, err, "Error al crear el módulo")
+		return
+	}
+	c.JSON(http.StatusCreated, module)
+}
+
+// UpdateModule modifica un módulo.
+// PUT /api/v1/manage/courses/:id/modules/:moduleId
+func (h *CourseHandler) UpdateModule(c *gin.Context) {
+	ids, ok := parseCourseChildIDs(c, "moduleId")
+	if !ok {
+		return
+	}
+	var dto services.UpdateModuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	module, err := h.CourseService.UpdateModule(requestActor(c), ids[0], ids[1], dto)
+	if err != nil {
+		respondCourseError(c, err, "Error al actualizar el módulo")
+		return
+	}
+	c.JSON(http.StatusOK, module)
+}
+
+// DeleteModule elimina un módulo con sus lecciones.
+// DELETE /api/v1/manage/courses/:id/modules/:moduleId
+func (h *CourseHandler) DeleteModule(c *gin.Context) {
+	ids, ok := parseCourseChildIDs(c, "moduleId")
+	if !ok {
+		return
+	}
+	if err := h.CourseService.DeleteModule(requestActor(c), ids[0], ids[1]); err != nil {
+		respondCourseError(c, err, "Error al eliminar el módulo")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ReorderModules reordena los módulos del curso.
+// PUT /api/v1/manage/courses/:id/modules/order
+func (h *CourseHandler) ReorderModules(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	var dto services.ReorderDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	course, err := h.CourseService.ReorderModules(requestActor(c), id, dto)
+	if err != nil {
+		respondCourseError(c, err, "Error al reordenar los módulos")
+		return
+	}
+	c.JSON(http.StatusOK, course)
+}
+
+// AddLesson agrega una lección al final de un módulo.
+// POST /api/v1/manage/courses/:id/modules/:moduleId/lessons
+func (h *CourseHandler) AddLesson(c *gin.Context) {
+	ids, ok := parseCourseChildIDs(c, "moduleId")
+	if !ok {
+		return
+	}
+	var dto services.LessonDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	lesson, err := h.CourseService.AddLesson(requestActor(c), ids[0], ids[1], dto)
+	if err != nil {
+		respondCourseError(c, err, "Error al crear la lección")
+		return
+	}
+	c.JSON(http.StatusCreated, lesson)
+}
+
+// UpdateLesson modifica una lección.
+// PUT /api/v1/manage/courses/:id/modules/:moduleId/lessons/:lessonId
+func (h *CourseHandler) UpdateLesson(c *gin.Context) {
+	ids, ok := parseCourseChildIDs(c, "moduleId", "lessonId")
+	if !ok {
+		return
+	}
+	var dto services.UpdateLessonDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	lesson, err := h.CourseService.UpdateLesson(requestActor(c), ids[0], ids[1], ids[2], dto)
+	if err != nil {
+		respondCourseError(c, err, "Error al actualizar la lección")
+		return
+	}
+	c.JSON(http.StatusOK, lesson)
+}
+
+// DeleteLesson elimina una lección.
+// DELETE /api/v1/manage/courses/:id/modules/:moduleId/lessons/:lessonId
+func (h *CourseHandler) DeleteLesson(c *gin.Context) {
+	ids, ok := parseCourseChildIDs(c, "moduleId", "lessonId")
+	if !ok {
+		return
+	}
+	if err := h.CourseService.DeleteLesson(requestActor(c), ids[0], ids[1], ids[2]); err != nil {
+		respondCourseError(c, err, "Error al eliminar la lección")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ReorderLessons reordena las lecciones de un módulo.
+// PUT /api/v1/manage/courses/:id/modules/:moduleId/lessons/order
+func (h *CourseHandler) ReorderLessons(c *gin.Context) {
+	ids, ok := parseCourseChildIDs(c, "moduleId")
+	if !ok {
+		return
+	}
+	var dto services.ReorderDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	module, err := h.CourseService.ReorderLessons(requestActor(c), ids[0], ids[1], dto)
+	if err != nil {
+		respondCourseError(c, err, "Error al reordenar las lecciones")
+		return
+	}
+	c.JSON(http.StatusOK, module)
+}
+
+// ListCourses lista el catálogo de cursos publicados.
+// GET /api/v1/courses?category=&q=&page=&page_size=
+func (h *CourseHandler) ListCourses(c *gin.Context) {
+	page, err := h.CourseService.ListPublishedCourses(courseFilterFromQuery(c))
+	if err != nil {
+		respondCourseError(c, err, "Error al obtener los cursos")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetCourse devuelve un curso publicado con su temario.
+// GET /api/v1/courses/:id
+func (h *CourseHandler) GetCourse(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	course, err := h.CourseService.GetPublishedCourse(id)
+	if err != nil {
+		respondCourseError(c, err, "Error al obtener el curso")
+		return
+	}
+	c.JSON(http.StatusOK, course)
+}
+
+// RegisterManageCourseRoutes registra las rutas de gestión de cursos bajo el grupo /manage
+// (administradores e instructores).
+func (h *CourseHandler) RegisterManageCourseRoutes(rg *gin.RouterGroup) {
+	courseRoutes := rg.Group("/courses")
+	{
+		courseRoutes.GET("", h.ListManagedCourses)
+		courseRoutes.POST("", h.CreateCourse)
+		courseRoutes.GET("/:id", h.GetManagedCourse)
+		courseRoutes.PUT("/:id", h.UpdateCourse)
+		courseRoutes.DELETE("/:id", h.DeleteCourse)
+		courseRoutes.POST("/:id/status", h.ChangeCourseStatus)
+
+		courseRoutes.POST("/:id/modules", h.AddModule)
+		courseRoutes.PUT("/:id/modules/order", h.ReorderModules)
+		courseRoutes.PUT("/:id/modules/:moduleId", h.UpdateModule)
+		courseRoutes.DELETE("/:id/modules/:moduleId", h.DeleteModule)
+
+		courseRoutes.POST("/:id/modules/:moduleId/lessons", h.AddLesson)
+		courseRoutes.PUT("/:id/modules/:moduleId/lessons/order", h.ReorderLessons)
+		courseRoutes.PUT("/:id/modules/:moduleId/lessons/:lessonId", h.UpdateLesson)
+		courseRoutes.DELETE("/:id/modules/:moduleId/lessons/:lessonId", h.DeleteLesson)
+	}
+}
+
+// RegisterCourseRoutes registra el catálogo de cursos publicados para cualquier usuario autenticado.
+func (h *CourseHandler) RegisterCourseRoutes(rg *gin.RouterGroup) {
+	rg.GET("/courses", h.ListCourses)
+	rg.GET("/courses/:id", h.GetCourse)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CustomFieldHandler expone la administración de los campos personalizados de empleados.
+type CustomFieldHandler struct {
+	CustomFieldService services.CustomFieldServiceInterface
+}
+
+// NewCustomFieldHandler crea una nueva instancia de CustomFieldHandler.
+func NewCustomFieldHandler(customFieldService services.CustomFieldServiceInterface) *CustomFieldHandler {
+	return &CustomFieldHandler{CustomFieldService: customFieldService}
+}
+
+// respondCustomFieldError responde con status si err es un error de validación de campos
+// personalizados. Devuelve true si escribió la respuesta.
+func respondCustomFieldError(c *gin.Context, err error, status int) bool {
+	var validationErr *services.CustomFieldValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+	c.JSON(status, gin.H{"error": "Campo personalizado inválido", "details": err.Error()})
+	return true
+}
+
+// ListDefinitions lista los campos personalizados definidos.
+// GET /api/v1/admin/custom-fields
+func (h *CustomFieldHandler) ListDefinitions(c *gin.Context) {
+	defs, err := h.CustomFieldService.ListDefinitions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al listar campos personalizados"})
+		return
+	}
+	c.JSON(http.StatusOK, defs)
+}
+
+// CreateDefinition crea un campo personalizado.
+// POST /api/v1/admin/custom-fields
+func (h *CustomFieldHandler) CreateDefinition(c *gin.Context) {
+	var dto services.CustomFieldDefinitionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	def, err := h.CustomFieldService.CreateDefinition(requestActor(c), dto)
+	if err != nil {
+		if respondCustomFieldError(c, err, http.StatusBadRequest) {
+			return
+		}
+		if err.Error() == "ya existe un campo personalizado con esa clave" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el campo personalizado"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, def)
+}
+
+// UpdateDefinition actualiza un campo personalizado (la clave y el tipo no cambian).
+// PUT /api/v1/admin/custom-fields/:id
+func (h *CustomFieldHandler) UpdateDefinition(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de campo personalizado inválido")
+	if !ok {
+		return
+	}
+	var dto services.CustomFieldDefinitionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	def, err := h.CustomFieldService.UpdateDefinition(requestActor(c), id, dto)
+	if err != nil {
+		if respondCustomFieldError(c, err, http.StatusBadRequest) {
+			return
+		}
+		if err.Error() == "campo personalizado no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el campo personalizado"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, def)
+}
+
+// DeleteDefinition elimina un campo personalizado y todos sus valores.
+// DELETE /api/v1/admin/custom-fields/:id
+func (h *CustomFieldHandler) DeleteDefinition(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de campo personalizado inválido")
+	if !ok {
+		return
+	}
+	if err := h.CustomFieldService.DeleteDefinition(requestActor(c), id); err != nil {
+		if err.Error() == "campo personalizado no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el campo personalizado"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Campo personalizado eliminado exitosamente"})
+}
+
+// RegisterAdminCustomFieldRoutes registra las rutas de campos personalizados bajo el grupo /admin.
+func (h *CustomFieldHandler) RegisterAdminCustomFieldRoutes(rg *gin.RouterGroup) {
+	customFieldRoutes := rg.Group("/custom-fields")
+	{
+		customFieldRoutes.GET("", h.ListDefinitions)
+		customFieldRoutes.POST("", h.CreateDefinition)
+		customFieldRoutes.PUT("/:id", h.UpdateDefinition)
+		customFieldRoutes.DELETE("/:id", h.DeleteDefinition)
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Unikyri/yamerito-mvp/internal/middleware"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// EnrollmentHandler expone la asignación de cursos a usuarios y los cursos de cada empleado.
+type EnrollmentHandler struct {
+	EnrollmentService services.EnrollmentServiceInterface
+}
+
+// NewEnrollmentHandler crea una nueva instancia de EnrollmentHandler.
+func NewEnrollmentHandler(enrollmentService services.EnrollmentServiceInterface) *EnrollmentHandler {
+	return &EnrollmentHandler{EnrollmentService: enrollmentService}
+}
+
+// respondEnrollmentError traduce los errores del servicio de inscripciones a códigos HTTP.
+func respondEnrollmentError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "curso no encontrado", "inscripción no encontrada", "regla de asignación no encontrada":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "solo se pueden asignar cursos publicados", "solo se pueden cancelar inscripciones pendientes":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "indica los usuarios o los criterios de la asignación",
+		"la fecha de vencimiento debe ser futura",
+		"algún usuario no existe o está dado de baja",
+		"rol inválido",
+		"estado de inscripción inválido",
+		"la regla necesita al menos un criterio (rol, puesto o departamento)":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// AssignCourse inscribe en un curso a usuarios concretos y/o a los que cumplan unos criterios.
+// POST /api/v1/admin/courses/:id/enrollments
+func (h *EnrollmentHandler) AssignCourse(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	var dto services.AssignCourseDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	result, err := h.EnrollmentService.AssignCourse(requestActor(c), id, dto)
+	if err != nil {
+		respondEnrollmentError(c, err, "Error al asignar el curso")
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ListCourseEnrollments lista las inscripciones de un curso.
+// GET /api/v1/admin/courses/:id/enrollments?status=assigned|in_progress|completed|cancelled&page=&page_size=
+func (h *EnrollmentHandler) ListCourseEnrollments(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	filter := services.EnrollmentFilter{Status: c.Query("status")}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	page, err := h.EnrollmentService.ListCourseEnrollments(id, filter)
+	if err != nil {
+		respondEnrollmentError(c, err, "Error al obtener las inscripciones")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// CancelEnrollment retira una asignación pendiente.
+// POST /api/v1/admin/enrollments/:id/cancel
+func (h *EnrollmentHandler) CancelEnrollment(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de inscripción inválido")
+	if !ok {
+		return
+	}
+	enrollment, err := h.EnrollmentService.CancelEnrollment(requestActor(c), id)
+	if err != nil {
+		respondEnrollmentError(c, err, "Error al cancelar la inscripción")
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ListRules lista las reglas de asignación automática.
+// GET /api/v1/admin/assignment-rules
+func (h *EnrollmentHandler) ListRules(c *gin.Context) {
+	rules, err := h.EnrollmentService.ListRules()
+	if err != nil {
+		respondEnrollmentError(c, err, "Error al obtener las reglas de asignación")
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateRule crea una regla de asignación automática.
+// POST /api/v1/admin/assignment-rules
+func (h *EnrollmentHandler) CreateRule(c *gin.Context) {
+	var dto services.CreateAssignmentRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	result, err := h.EnrollmentService.CreateRule(requestActor(c), dto)
+	if err != nil {
+		respondEnrollmentError(c, err, "Error al crear la regla de asignación")
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// UpdateRule activa o desactiva una regla o cambia su plazo.
+// PUT /api/v1/admin/assignment-rules/:id
+func (h *EnrollmentHandler) UpdateRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de regla inválido")
+	if !ok {
+		return
+	}
+	var dto services.UpdateAssignmentRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	rule, err := h.EnrollmentService.UpdateRule(requestActor(c), id, dto)
+	if err != nil {
+		respondEnrollmentError(c, err, "Error al actualizar la regla de asignación")
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule elimina una regla de asignación.
+// DELETE /api/v1/admin/assignment-rules/:id
+func (h *EnrollmentHandler) DeleteRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de regla inválido")
+	if !ok {
+		return
+	}
+	if err := h.EnrollmentService.DeleteRule(requestActor(c), id); err != nil {
+		respondEnrollmentError(c, err, "Error al eliminar la regla de asignación")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListMyEnrollments lista los cursos asignados al usuario autenticado.
+// GET /api/v1/me/enrollments
+func (h *EnrollmentHandler) ListMyEnrollments(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	enrollments, err := h.EnrollmentService.ListMyEnrollments(claims.UserID)
+	if err != nil {
+		respondEnrollmentError(c, err, "Error al obtener tus cursos")
+		return
+	}
+	c.JSON(http.StatusOK, enrollments)
+}
+
+// RegisterAdminEnrollmentRoutes registra las rutas de asignación bajo el grupo /admin.
+func (h *EnrollmentHandler) RegisterAdminEnrollmentRoutes(rg *gin.RouterGroup) {
+	rg.POST("/courses/:id/enrollments", h.AssignCourse)
+	rg.GET("/courses/:id/enrollments", h.ListCourseEnrollments)
+	rg.POST("/enrollments/:id/cancel", h.CancelEnrollment)
+
+	ruleRoutes := rg.Group("/assignment-rules")
+	{
+		ruleRoutes.GET("", h.ListRules)
+		ruleRoutes.POST("", h.CreateRule)
+		ruleRoutes.PUT("/:id", h.UpdateRule)
+		ruleRoutes.DELETE("/:id", h.DeleteRule)
+	}
+}
+
+// RegisterEnrollmentRoutes registra los cursos del usuario autenticado.
+func (h *EnrollmentHandler) RegisterEnrollmentRoutes(rg *gin.RouterGroup) {
+	rg.GET("/me/enrollments", h.ListMyEnrollments)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// InvitationHandler expone el alta de usuarios por invitación.
+type InvitationHandler struct {
+	InvitationService services.InvitationServiceInterface
+}
+
+// NewInvitationHandler crea una nueva instancia de InvitationHandler.
+func NewInvitationHandler(invitationService services.InvitationServiceInterface) *InvitationHandler {
+	return &InvitationHandler{InvitationService: invitationService}
+}
+
+// respondInvitationError traduce los errores del servicio de invitaciones a códigos HTTP.
+func respondInvitationError(c *gin.Context, err error, fallback string) {
+	if respondLifecycleError(c, err) {
+		return
+	}
+	switch err.Error() {
+	case "invitación no encontrada":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "invitación inválida", "debe elegir un nombre de usuario", "estado de invitación inválido":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case "la invitación expiró", "la invitación ya fue utilizada", "la invitación fue revocada":
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case "la invitación ya no está pendiente", "ya existe un usuario con ese email", "el nombre de usuario ya está en uso":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// CreateInvitation crea un usuario invitado y le envía el enlace para activar su cuenta.
+// POST /api/v1/admin/invitations
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	var dto services.CreateInvitationDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	invitation, err := h.InvitationService.CreateInvitation(requestActor(c), dto)
+	if err != nil {
+		respondInvitationError(c, err, "Error al crear la invitación")
+		return
+	}
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// ListInvitations lista las invitaciones.
+// GET /api/v1/admin/invitations?status=pending|accepted|revoked|expired
+func (h *InvitationHandler) ListInvitations(c *gin.Context) {
+	invitations, err := h.InvitationService.ListInvitations(c.Query("status"))
+	if err != nil {
+		respondInvitationError(c, err, "Error al obtener las invitaciones")
+		return
+	}
+	c.JSON(http.StatusOK, invitations)
+}
+
+// ResendInvitation envía un enlace nuevo; el anterior deja de funcionar.
+// POST /api/v1/admin/invitations/:id/resend
+func (h *InvitationHandler) ResendInvitation(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de invitación inválido")
+	if !ok {
+		return
+	}
+	invitation, err := h.InvitationService.ResendInvitation(requestActor(c), id)
+	if err != nil {
+		respondInvitationError(c, err, "Error al reenviar la invitación")
+		return
+	}
+	c.JSON(http.StatusOK, invitation)
+}
+
+// RevokeInvitation anula una invitación pendiente.
+// DELETE /api/v1/admin/invitations/:id
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de invitación inválido")
+	if !ok {
+		return
+	}
+	if err := h.InvitationService.RevokeInvitation(requestActor(c), id); err != nil {
+		respondInvitationError(c, err, "Error al revocar la invitación")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Invitación revocada"})
+}
+
+// PreviewInvitation devuelve los datos precargados para el formulario de aceptación.
+// GET /api/v1/invitations?token=... (público)
+func (h *InvitationHandler) PreviewInvitation(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Falta el parámetro token"})
+		return
+	}
+	preview, err := h.InvitationService.PreviewInvitation(token)
+	if err != nil {
+		respondInvitationError(c, err, "Error al obtener la invitación")
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+// AcceptInvitation fija la contraseña del invitado y activa su cuenta.
+// POST /api/v1/invitations/accept (público)
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	var dto services.AcceptInvitationDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	user, err := h.InvitationService.AcceptInvitation(requestActor(c), dto)
+	if err != nil {
+		respondInvitationError(c, err, "Error al aceptar la invitación")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Invitación aceptada; ya puede iniciar sesión", "user": user})
+}
+
+// RegisterAdminInvitationRoutes registra la gestión de invitaciones bajo el grupo /admin.
+func (h *InvitationHandler) RegisterAdminInvitationRoutes(rg *gin.RouterGroup) {
+	invitationRoutes := rg.Group("/invitations")
+	{
+		invitationRoutes.POST("", h.CreateInvitation)
+		invitationRoutes.GET("", h.ListInvitations)
+		invitationRoutes.POST("/:id/resend", h.ResendInvitation)
+		invitationRoutes.DELETE("/:id", h.RevokeInvitation)
+	}
+}
+
+// RegisterInvitationRoutes registra los endpoints públicos que usa el invitado.
+func (h *InvitationHandler) RegisterInvitationRoutes(rg *gin.RouterGroup) {
+	rg.GET("/invitations", h.PreviewInvitation)
+	rg.POST("/invitations/accept", h.AcceptInvitation)
+}
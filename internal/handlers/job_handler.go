@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler expone el estado de los trabajos en segundo plano y sus programaciones.
+type JobHandler struct {
+	JobService services.JobServiceInterface
+}
+
+// NewJobHandler crea una nueva instancia de JobHandler.
+func NewJobHandler(jobService services.JobServiceInterface) *JobHandler {
+	return &JobHandler{JobService: jobService}
+}
+
+// respondJobError traduce los errores del servicio de trabajos a códigos HTTP.
+func respondJobError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "trabajo no encontrado", "programación no encontrada":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "estado de trabajo inválido":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case "solo se pueden reintentar trabajos fallidos o cancelados", "solo se pueden cancelar trabajos en cola":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListJobs lista los trabajos.
+// GET /api/v1/admin/jobs?status=queued|running|succeeded|failed|cancelled&type=&page=&page_size=
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	filter := services.JobFilter{Status: c.Query("status"), Type: c.Query("type")}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	page, err := h.JobService.ListJobs(filter)
+	if err != nil {
+		respondJobError(c, err, "Error al obtener los trabajos")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// JobStats devuelve cuántos trabajos hay de cada tipo en cada estado.
+// GET /api/v1/admin/jobs/stats
+func (h *JobHandler) JobStats(c *gin.Context) {
+	stats, err := h.JobService.JobStats()
+	if err != nil {
+		respondJobError(c, err, "Error al obtener las estadísticas de trabajos")
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetJob devuelve un trabajo.
+// GET /api/v1/admin/jobs/:id
+func (h *JobHandler) GetJob(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de trabajo inválido")
+	if !ok {
+		return
+	}
+	job, err := h.JobService.GetJob(id)
+	if err != nil {
+		respondJobError(c, err, "Error al obtener el trabajo")
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// RetryJob vuelve a encolar un trabajo fallido o cancelado.
+// POST /api/v1/admin/jobs/:id/retry
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de trabajo inválido")
+	if !ok {
+		return
+	}
+	job, err := h.JobService.RetryJob(requestActor(c), id)
+	if err != nil {
+		respondJobError(c, err, "Error al reintentar el trabajo")
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJob cancela un trabajo en cola.
+// POST /api/v1/admin/jobs/:id/cancel
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de trabajo inválido")
+	if !ok {
+		return
+	}
+	job, err := h.JobService.CancelJob(requestActor(c), id)
+	if err != nil {
+		respondJobError(c, err, "Error al cancelar el trabajo")
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// ListSchedules lista las programaciones recurrentes con su próxima ejecución.
+// GET /api/v1/admin/jobs/schedules
+func (h *JobHandler) ListSchedules(c *gin.Context) {
+	schedules, err := h.JobService.ListSchedules()
+	if err != nil {
+		respondJobError(c, err, "Error al obtener las programaciones")
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
+
+// UpdateSchedule activa o desactiva una programación.
+// PUT /api/v1/admin/jobs/schedules/:id
+func (h *JobHandler) UpdateSchedule(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de programación inválido")
+	if !ok {
+		return
+	}
+	var dto services.UpdateJobScheduleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	schedule, err := h.JobService.UpdateSchedule(requestActor(c), id, dto)
+	if err != nil {
+		respondJobError(c, err, "Error al actualizar la programación")
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// RegisterAdminJobRoutes registra las rutas de trabajos bajo el grupo /admin.
+func (h *JobHandler) RegisterAdminJobRoutes(rg *gin.RouterGroup) {
+	jobRoutes := rg.Group("/jobs")
+	{
+		jobRoutes.GET("", h.ListJobs)
+		jobRoutes.GET("/stats", h.JobStats)
+		jobRoutes.GET("/schedules", h.ListSchedules)
+		jobRoutes.PUT("/schedules/:id", h.UpdateSchedule)
+		jobRoutes.GET("/:id", h.GetJob)
+		jobRoutes.POST("/:id/retry", h.RetryJob)
+		jobRoutes.POST("/:id/cancel", h.CancelJob)
+	}
+}
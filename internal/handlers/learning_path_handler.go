@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/middleware"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// LearningPathHandler expone la gestión de rutas de aprendizaje y el avance de cada empleado.
+type LearningPathHandler struct {
+	LearningPathService services.LearningPathServiceInterface
+}
+
+// NewLearningPathHandler crea una nueva instancia de LearningPathHandler.
+func NewLearningPathHandler(learningPathService services.LearningPathServiceInterface) *LearningPathHandler {
+	return &LearningPathHandler{LearningPathService: learningPathService}
+}
+
+// respondLearningPathError traduce los errores del servicio de rutas a códigos HTTP.
+func respondLearningPathError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "ruta de aprendizaje no encontrada", "ruta de aprendizaje no asignada", "usuario no encontrado",
+		"curso no encontrado", "el curso no forma parte de la ruta":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "todos los cursos de la ruta deben estar publicados para asignarla",
+		"solo se pueden asignar cursos publicados",
+		"el curso todavía está bloqueado":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "el título de la ruta no puede estar vacío",
+		"la fecha de vencimiento debe ser futura",
+		"algún usuario no existe o está dado de baja",
+		"estado de inscripción inválido":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		// Validaciones de la estructura: cursos repetidos, prerrequisitos inválidos o circulares.
+		if strings.HasPrefix(err.Error(), "la ruta ") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListPaths lista las rutas de aprendizaje.
+// GET /api/v1/admin/learning-paths
+func (h *LearningPathHandler) ListPaths(c *gin.Context) {
+	paths, err := h.LearningPathService.ListPaths()
+	if err != nil {
+		respondLearningPathError(c, err, "Error al obtener las rutas de aprendizaje")
+		return
+	}
+	c.JSON(http.StatusOK, paths)
+}
+
+// CreatePath crea una ruta de aprendizaje.
+// POST /api/v1/admin/learning-paths
+func (h *LearningPathHandler) CreatePath(c *gin.Context) {
+	var dto services.LearningPathDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	path, err := h.LearningPathService.CreatePath(requestActor(c), dto)
+	if err != nil {
+		respondLearningPathError(c, err, "Error al crear la ruta de aprendizaje")
+		return
+	}
+	c.JSON(http.StatusCreated, path)
+}
+
+// GetPath devuelve una ruta con sus cursos y prerrequisitos.
+// GET /api/v1/admin/learning-paths/:id
+func (h *LearningPathHandler) GetPath(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de ruta inválido")
+	if !ok {
+		return
+	}
+	path, err := h.LearningPathService.GetPath(id)
+	if err != nil {
+		respondLearningPathError(c, err, "Error al obtener la ruta de aprendizaje")
+		return
+	}
+	c.JSON(http.StatusOK, path)
+}
+
+// UpdatePath reemplaza una ruta de aprendizaje.
+// PUT /api/v1/admin/learning-paths/:id
+func (h *LearningPathHandler) UpdatePath(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de ruta inválido")
+	if !ok {
+		return
+	}
+	var dto services.LearningPathDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	path, err := h.LearningPathService.UpdatePath(requestActor(c), id, dto)
+	if err != nil {
+		respondLearningPathError(c, err, "Error al actualizar la ruta de aprendizaje")
+		return
+	}
+	c.JSON(http.StatusOK, path)
+}
+
+// DeletePath elimina una ruta de aprendizaje.
+// DELETE /api/v1/admin/learning-paths/:id
+func (h *LearningPathHandler) DeletePath(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de ruta inválido")
+	if !ok {
+		return
+	}
+	if err := h.LearningPathService.DeletePath(requestActor(c), id); err != nil {
+		respondLearningPathError(c, err, "Error al eliminar la ruta de aprendizaje")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AssignPath asigna una ruta a usuarios.
+// POST /api/v1/admin/learning-paths/:id/enrollments
+func (h *LearningPathHandler) AssignPath(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de ruta inválido")
+	if !ok {
+		return
+	}
+	var dto services.AssignLearningPathDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	result, err := h.LearningPathService.AssignPath(requestActor(c), id, dto)
+	if err != nil {
+		respondLearningPathError(c, err, "Error al asignar la ruta de aprendizaje")
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ListPathEnrollments lista las asignaciones de una ruta.
+// GET /api/v1/admin/learning-paths/:id/enrollments?status=assigned|in_progress|completed|cancelled&page=&page_size=
+func (h *LearningPathHandler) ListPathEnrollments(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de ruta inválido")
+	if !ok {
+		return
+	}
+	filter := services.EnrollmentFilter{Status: c.Query("status")}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	page, err := h.LearningPathService.ListPathEnrollments(id, filter)
+	if err != nil {
+		respondLearningPathError(c, err, "Error al obtener las asignaciones")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetUserPathProgress devuelve el avance de un usuario en una ruta.
+// GET /api/v1/admin/learning-paths/:id/users/:userId/progress
+func (h *LearningPathHandler) GetUserPathProgress(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de ruta inválido")
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+	progress, err := h.LearningPathService.GetUserPathProgress(uint(userID), id)
+	if err != nil {
+		respondLearningPathError(c, err, "Error al obtener el avance de la ruta")
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
+
+// ListMyPaths lista las rutas asignadas al usuario autenticado con su avance.
+// GET /api/v1/me/learning-paths
+func (h *LearningPathHandler) ListMyPaths(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	paths, err := h.LearningPathService.ListMyPaths(claims.UserID)
+	if err != nil {
+		respondLearningPathError(c, err, "Error al obtener tus rutas de aprendizaje")
+		return
+	}
+	c.JSON(http.StatusOK, paths)
+}
+
+// GetMyPathProgress devuelve el avance del usuario autenticado en una ruta, con el estado
+// (bloqueado, disponible, en curso, completado) de cada curso.
+// GET /api/v1/me/learning-paths/:id
+func (h *LearningPathHandler) GetMyPathProgress(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	id, ok := parseIDParam(c, "ID de ruta inválido")
+	if !ok {
+		return
+	}
+	progress, err := h.LearningPathService.GetMyPathProgress(claims.UserID, id)
+	if err != nil {
+		respondLearningPathError(c, err, "Error al obtener el avance de la ruta")
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
+
+// StartPathCourse inscribe al usuario autenticado en un curso desbloqueado de su ruta.
+// POST /api/v1/me/learning-paths/:id/courses/:courseId/start
+func (h *LearningPathHandler) StartPathCourse(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de ruta inválido")
+	if !ok {
+		return
+	}
+	courseID, err := strconv.ParseUint(c.Param("courseId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de curso inválido"})
+		return
+	}
+	enrollment, err := h.LearningPathService.StartPathCourse(requestActor(c), id, uint(courseID))
+	if err != nil {
+		respondLearningPathError(c, err, "Error al empezar el curso")
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// RegisterAdminLearningPathRoutes registra la gestión de rutas bajo el grupo /admin.
+func (h *LearningPathHandler) RegisterAdminLearningPathRoutes(rg *gin.RouterGroup) {
+	pathRoutes := rg.Group("/learning-paths")
+	{
+		pathRoutes.GET("", h.ListPaths)
+		pathRoutes.POST("", h.CreatePath)
+		pathRoutes.GET("/:id", h.GetPath)
+		pathRoutes.PUT("/:id", h.UpdatePath)
+		pathRoutes.DELETE("/:id", h.DeletePath)
+		pathRoutes.POST("/:id/enrollments", h.AssignPath)
+		pathRoutes.GET("/:id/enrollments", h.ListPathEnrollments)
+		pathRoutes.GET("/:id/users/:userId/progress", h.GetUserPathProgress)
+	}
+}
+
+// RegisterLearningPathRoutes registra las rutas del usuario autenticado.
+func (h *LearningPathHandler) RegisterLearningPathRoutes(rg *gin.RouterGroup) {
+	rg.GET("/me/learning-paths", h.ListMyPaths)
+	rg.GET("/me/learning-paths/:id", h.GetMyPathProgress)
+	rg.POST("/me/learning-paths/:id/courses/:courseId/start", h.StartPathCourse)
+}
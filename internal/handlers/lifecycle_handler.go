@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// LifecycleHandler expone los cambios de estado de las cuentas y su programación.
+type LifecycleHandler struct {
+	LifecycleService services.LifecycleServiceInterface
+}
+
+// NewLifecycleHandler crea una nueva instancia de LifecycleHandler.
+func NewLifecycleHandler(lifecycleService services.LifecycleServiceInterface) *LifecycleHandler {
+	return &LifecycleHandler{LifecycleService: lifecycleService}
+}
+
+// respondLifecycleError responde 422 si err es un cambio de estado o de fechas laborales
+// inválido. Devuelve true si escribió la respuesta.
+func respondLifecycleError(c *gin.Context, err error) bool {
+	var lifecycleErr *services.LifecycleError
+	if !errors.As(err, &lifecycleErr) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Cambio de ciclo de vida inválido", "details": err.Error()})
+	return true
+}
+
+func respondLifecycleServiceError(c *gin.Context, err error, fallback string) {
+	if respondLifecycleError(c, err) {
+		return
+	}
+	switch err.Error() {
+	case "usuario no encontrado", "transición no encontrada":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "la transición ya no está pendiente", "el usuario fue modificado al mismo tiempo; intente de nuevo":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ChangeStatus cambia el estado de un usuario de inmediato.
+// POST /api/v1/admin/users/:id/status
+func (h *LifecycleHandler) ChangeStatus(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de usuario inválido")
+	if !ok {
+		return
+	}
+	var dto services.ChangeStatusDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	user, err := h.LifecycleService.ChangeStatus(requestActor(c), id, dto)
+	if err != nil {
+		respondLifecycleServiceError(c, err, "Error al cambiar el estado del usuario")
+		return
+	}
+	c.Header("ETag", user.ETag())
+	c.JSON(http.StatusOK, gin.H{"message": "Estado del usuario actualizado", "user": user})
+}
+
+// ListTransitions lista las transiciones programadas (pendientes e históricas) de un usuario.
+// GET /api/v1/admin/users/:id/lifecycle-transitions
+func (h *LifecycleHandler) ListTransitions(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de usuario inválido")
+	if !ok {
+		return
+	}
+	transitions, err := h.LifecycleService.ListTransitions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las transiciones"})
+		return
+	}
+	c.JSON(http.StatusOK, transitions)
+}
+
+// ScheduleTransition programa un cambio de estado para una fecha futura (RFC 3339).
+// POST /api/v1/admin/users/:id/lifecycle-transitions
+func (h *LifecycleHandler) ScheduleTransition(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de usuario inválido")
+	if !ok {
+		return
+	}
+	var dto services.ScheduleTransitionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	transition, err := h.LifecycleService.ScheduleTransition(requestActor(c), id, dto)
+	if err != nil {
+		respondLifecycleServiceError(c, err, "Error al programar la transición")
+		return
+	}
+	c.JSON(http.StatusCreated, transition)
+}
+
+// CancelTransition cancela una transición pendiente.
+// DELETE /api/v1/admin/users/:id/lifecycle-transitions/:transitionId
+func (h *LifecycleHandler) CancelTransition(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de usuario inválido")
+	if !ok {
+		return
+	}
+	transitionID, err := strconv.ParseUint(c.Param("transitionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de transición inválido"})
+		return
+	}
+	if err := h.LifecycleService.CancelTransition(requestActor(c), id, uint(transitionID)); err != nil {
+		respondLifecycleServiceError(c, err, "Error al cancelar la transición")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transición cancelada"})
+}
+
+// RegisterAdminLifecycleRoutes registra las rutas del ciclo de vida bajo el grupo /admin.
+func (h *LifecycleHandler) RegisterAdminLifecycleRoutes(rg *gin.RouterGroup) {
+	rg.POST("/users/:id/status", h.ChangeStatus)
+	rg.GET("/users/:id/lifecycle-transitions", h.ListTransitions)
+	rg.POST("/users/:id/lifecycle-transitions", h.ScheduleTransition)
+	rg.DELETE("/users/:id/lifecycle-transitions/:transitionId", h.CancelTransition)
+}
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/auth"
+	"github.com/Unikyri/yamerito-mvp/internal/middleware"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// notificationStreamPoll es cada cuánto la conexión en tiempo real vuelve a consultar la BD
+// (por si otra instancia del servidor creó la notificación), verifica que la cuenta siga
+// activa y envía un comentario para que los proxies no cierren la conexión inactiva.
+const notificationStreamPoll = 15 * time.Second
+
+// NotificationHandler expone el centro de notificaciones del usuario autenticado.
+type NotificationHandler struct {
+	NotificationService services.NotificationServiceInterface
+}
+
+// NewNotificationHandler crea una nueva instancia de NotificationHandler.
+func NewNotificationHandler(notificationService services.NotificationServiceInterface) *NotificationHandler {
+	return &NotificationHandler{NotificationService: notificationService}
+}
+
+// ListNotifications lista las notificaciones propias con el total sin leer.
+// GET /api/v1/me/notifications?unread=true&page=&page_size=
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	filter := services.NotificationFilter{UnreadOnly: c.Query("unread") == "true"}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	page, err := h.NotificationService.ListNotifications(claims.UserID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las notificaciones"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// UnreadCount devuelve solo el número de notificaciones sin leer (para el indicador del menú).
+// GET /api/v1/me/notifications/unread-count
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	count, err := h.NotificationService.UnreadCount(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las notificaciones"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread_count": count})
+}
+
+// MarkRead marca una notificación como leída.
+// POST /api/v1/me/notifications/:id/read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	id, ok := parseIDParam(c, "ID de notificación inválido")
+	if !ok {
+		return
+	}
+	notification, err := h.NotificationService.MarkRead(claims.UserID, id)
+	if err != nil {
+		if err.Error() == "notificación no encontrada" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar la notificación"})
+		return
+	}
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllRead marca como leídas todas las notificaciones propias.
+// POST /api/v1/me/notifications/read-all
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	updated, err := h.NotificationService.MarkAllRead(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar las notificaciones"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": updated, "unread_count": 0})
+}
+
+// GetPreferences devuelve los canales (in-app, email) de cada tipo de notificación.
+// GET /api/v1/me/notification-preferences
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	prefs, err := h.NotificationService.GetPreferences(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las preferencias"})
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences cambia los canales de los tipos indicados.
+// PUT /api/v1/me/notification-preferences
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	var dto services.UpdateNotificationPreferencesDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	prefs, err := h.NotificationService.UpdatePreferences(claims.UserID, dto)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "tipo de notificación desconocido") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar las preferencias"})
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// writeSSE escribe un evento Server-Sent Events y lo envía de inmediato.
+func writeSSE(c *gin.Context, event, id string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(c.Writer, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// StreamTicket emite un ticket de un minuto para abrir el stream de notificaciones. El
+// ticket solo sirve para eso, de modo que el JWT de sesión no tiene que viajar en la URL.
+// POST /api/v1/me/notifications/stream-ticket
+func (h *NotificationHandler) StreamTicket(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	if claims.ExpiresAt == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "token inválido o expirado"})
+		return
+	}
+	ticket, expiresAt, err := auth.GenerateStreamTicket(claims.UserID, claims.Username, claims.Role, claims.ExpiresAt.Time)
+	if err != nil {
+		log.Printf("Error al generar el ticket de conexión del usuario %d: %v", claims.UserID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar el ticket de conexión"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, gin.H{"ticket": ticket, "expires_at": expiresAt})
+}
+
+// Stream mantiene abierta una conexión Server-Sent Events por la que llegan las
+// notificaciones nuevas (evento "notification", con el ID de la notificación como id del
+// evento) y el total sin leer cada vez que cambia (evento "unread_count").
+// Al reconectarse, EventSource envía Last-Event-ID y se reciben las notificaciones
+// perdidas. El navegador no puede enviar encabezados con EventSource, así que la conexión
+// se abre con ?ticket= obtenido de POST /me/notifications/stream-ticket (ver
+// middleware.StreamAuthMiddleware); al reconectarse, el cliente pide un ticket nuevo y
+// envía el último ID recibido en ?last_event_id=.
+// GET /api/v1/me/notifications/stream
+func (h *NotificationHandler) Stream(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	userID := claims.UserID
+
+	lastID := uint(0)
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	if lastEventID != "" {
+		parsed, err := strconv.ParseUint(lastEventID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Last-Event-ID inválido"})
+			return
+		}
+		lastID = uint(parsed)
+	} else {
+		// Conexión nueva: solo interesan las notificaciones a partir de ahora; las
+		// anteriores se obtienen con GET /me/notifications.
+		latest, err := h.NotificationService.LatestNotificationID(userID)
+		if err != nil {
+			log.Printf("Error al iniciar el stream de notificaciones del usuario %d: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las notificaciones"})
+			return
+		}
+		lastID = latest
+	}
+
+	wake, unsubscribe := h.NotificationService.Subscribe(userID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Evita que nginx acumule la respuesta
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 5000\n\n")
+	c.Writer.Flush()
+
+	// deliver envía las notificaciones posteriores a lastID y el total sin leer.
+	lastUnread := int64(-1)
+	deliver := func() bool {
+		notifications, err := h.NotificationService.NotificationsAfter(userID, lastID)
+		if err != nil {
+			log.Printf("Error al leer notificaciones nuevas del usuario %d: %v", userID, err)
+			return true // Se reintenta en la siguiente consulta
+		}
+		for i := range notifications {
+			n := notifications[i]
+			if err := writeSSE(c, "notification", strconv.FormatUint(uint64(n.ID), 10), n); err != nil {
+				return false
+			}
+			lastID = n.ID
+		}
+		unread, err := h.NotificationService.UnreadCount(userID)
+		if err == nil && unread != lastUnread {
+			if err := writeSSE(c, "unread_count", "", gin.H{"unread_count": unread}); err != nil {
+				return false
+			}
+			lastUnread = unread
+		}
+		return true
+	}
+	if !deliver() {
+		return
+	}
+
+	// La conexión termina cuando vence la sesión, no el ticket con el que se abrió.
+	var expired <-chan time.Time
+	if sessionExpiry := claims.SessionExpiry(); sessionExpiry != nil {
+		timer := time.NewTimer(time.Until(sessionExpiry.Time))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	ticker := time.NewTicker(notificationStreamPoll)
+	defer ticker.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expired:
+			writeSSE(c, "session_expired", "", gin.H{"error": "token expirado"})
+			return
+		case <-wake:
+			if !deliver() {
+				return
+			}
+		case <-ticker.C:
+			active, err := h.NotificationService.AccountActive(userID)
+			if err == nil && !active {
+				writeSSE(c, "session_expired", "", gin.H{"error": "la cuenta no está activa"})
+				return
+			}
+			if !deliver() {
+				return
+			}
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// RegisterNotificationRoutes registra el centro de notificaciones del usuario autenticado.
+func (h *NotificationHandler) RegisterNotificationRoutes(rg *gin.RouterGroup) {
+	rg.GET("/me/notifications", h.ListNotifications)
+	rg.GET("/me/notifications/unread-count", h.UnreadCount)
+	rg.POST("/me/notifications/read-all", h.MarkAllRead)
+	rg.POST("/me/notifications/stream-ticket", h.StreamTicket)
+	rg.POST("/me/notifications/:id/read", h.MarkRead)
+	rg.GET("/me/notification-preferences", h.GetPreferences)
+	rg.PUT("/me/notification-preferences", h.UpdatePreferences)
+}
+
+// RegisterNotificationStreamRoutes registra el stream en tiempo real. Debe ir en un grupo
+// protegido con middleware.StreamAuthMiddleware.
+func (h *NotificationHandler) RegisterNotificationStreamRoutes(rg *gin.RouterGroup) {
+	rg.GET("/me/notifications/stream", h.Stream)
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// OrgHandler expone la gestión de departamentos, equipos y el organigrama.
+type OrgHandler struct {
+	OrgService services.OrgServiceInterface
+}
+
+// NewOrgHandler crea una nueva instancia de OrgHandler.
+func NewOrgHandler(orgService services.OrgServiceInterface) *OrgHandler {
+	return &OrgHandler{OrgService: orgService}
+}
+
+// respondOrgAssignmentError responde 422 si err es una asignación organizativa inválida
+// (referencia inexistente, equipo de otro departamento o ciclo). Devuelve true si escribió la respuesta.
+func respondOrgAssignmentError(c *gin.Context, err error) bool {
+	var assignmentErr *services.OrgAssignmentError
+	if !errors.As(err, &assignmentErr) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Asignación organizativa inválida", "details": err.Error()})
+	return true
+}
+
+// respondOrgError traduce los errores del servicio de organización a códigos HTTP.
+func respondOrgError(c *gin.Context, err error, fallback string) {
+	if respondOrgAssignmentError(c, err) {
+		return
+	}
+	switch err.Error() {
+	case "departamento no encontrado", "equipo no encontrado", "usuario no encontrado":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "ya existe un departamento con ese nombre", "ya existe un equipo con ese nombre en el departamento":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func parseIDParam(c *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ListDepartments lista todos los departamentos.
+// GET /api/v1/admin/departments
+func (h *OrgHandler) ListDepartments(c *gin.Context) {
+	departments, err := h.OrgService.ListDepartments()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al listar departamentos"})
+		return
+	}
+	c.JSON(http.StatusOK, departments)
+}
+
+// GetDepartment obtiene un departamento por su ID.
+// GET /api/v1/admin/departments/:id
+func (h *OrgHandler) GetDepartment(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de departamento inválido")
+	if !ok {
+		return
+	}
+	department, err := h.OrgService.GetDepartment(id)
+	if err != nil {
+		respondOrgError(c, err, "Error al obtener el departamento")
+		return
+	}
+	c.JSON(http.StatusOK, department)
+}
+
+// CreateDepartment crea un departamento.
+// POST /api/v1/admin/departments
+func (h *OrgHandler) CreateDepartment(c *gin.Context) {
+	var dto services.DepartmentInputDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	department, err := h.OrgService.CreateDepartment(requestActor(c), dto)
+	if err != nil {
+		respondOrgError(c, err, "Error al crear el departamento")
+		return
+	}
+	c.JSON(http.StatusCreated, department)
+}
+
+// UpdateDepartment actualiza un departamento.
+// PUT /api/v1/admin/departments/:id
+func (h *OrgHandler) UpdateDepartment(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de departamento inválido")
+	if !ok {
+		return
+	}
+	var dto services.DepartmentInputDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	department, err := h.OrgService.UpdateDepartment(requestActor(c), id, dto)
+	if err != nil {
+		respondOrgError(c, err, "Error al actualizar el departamento")
+		return
+	}
+	c.JSON(http.StatusOK, department)
+}
+
+// DeleteDepartment elimina un departamento vacío.
+// DELETE /api/v1/admin/departments/:id
+func (h *OrgHandler) DeleteDepartment(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de departamento inválido")
+	if !ok {
+		return
+	}
+	if err := h.OrgService.DeleteDepartment(requestActor(c), id); err != nil {
+		var assignmentErr *services.OrgAssignmentError
+		if errors.As(err, &assignmentErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": "No se puede eliminar el departamento", "details": err.Error()})
+			return
+		}
+		respondOrgError(c, err, "Error al eliminar el departamento")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Departamento eliminado exitosamente"})
+}
+
+// ListDepartmentMembers devuelve los IDs de usuario del departamento.
+// GET /api/v1/admin/departments/:id/members?include_subdepartments=true
+func (h *OrgHandler) ListDepartmentMembers(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de departamento inválido")
+	if !ok {
+		return
+	}
+	includeSub := c.Query("include_subdepartments") == "true"
+	userIDs, err := h.OrgService.DepartmentMemberIDs(id, includeSub)
+	if err != nil {
+		respondOrgError(c, err, "Error al obtener los miembros del departamento")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"department_id": id, "user_ids": userIDs})
+}
+
+// ListTeams lista los equipos, opcionalmente de un solo departamento.
+// GET /api/v1/admin/teams?department_id=
+func (h *OrgHandler) ListTeams(c *gin.Context) {
+	departmentID, ok := parseOptionalUint(c, "department_id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "department_id inválido"})
+		return
+	}
+	teams, err := h.OrgService.ListTeams(departmentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al listar equipos"})
+		return
+	}
+	c.JSON(http.StatusOK, teams)
+}
+
+// GetTeam obtiene un equipo por su ID.
+// GET /api/v1/admin/teams/:id
+func (h *OrgHandler) GetTeam(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de equipo inválido")
+	if !ok {
+		return
+	}
+	team, err := h.OrgService.GetTeam(id)
+	if err != nil {
+		respondOrgError(c, err, "Error al obtener el equipo")
+		return
+	}
+	c.JSON(http.StatusOK, team)
+}
+
+// CreateTeam crea un equipo.
+// POST /api/v1/admin/teams
+func (h *OrgHandler) CreateTeam(c *gin.Context) {
+	var dto services.TeamInputDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	team, err := h.OrgService.CreateTeam(requestActor(c), dto)
+	if err != nil {
+		respondOrgError(c, err, "Error al crear el equipo")
+		return
+	}
+	c.JSON(http.StatusCreated, team)
+}
+
+// UpdateTeam actualiza un equipo.
+// PUT /api/v1/admin/teams/:id
+func (h *OrgHandler) UpdateTeam(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de equipo inválido")
+	if !ok {
+		return
+	}
+	var dto services.TeamInputDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	team, err := h.OrgService.UpdateTeam(requestActor(c), id, dto)
+	if err != nil {
+		respondOrgError(c, err, "Error al actualizar el equipo")
+		return
+	}
+	c.JSON(http.StatusOK, team)
+}
+
+// DeleteTeam elimina un equipo sin miembros.
+// DELETE /api/v1/admin/teams/:id
+func (h *OrgHandler) DeleteTeam(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de equipo inválido")
+	if !ok {
+		return
+	}
+	if err := h.OrgService.DeleteTeam(requestActor(c), id); err != nil {
+		var assignmentErr *services.OrgAssignmentError
+		if errors.As(err, &assignmentErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": "No se puede eliminar el equipo", "details": err.Error()})
+			return
+		}
+		respondOrgError(c, err, "Error al eliminar el equipo")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Equipo eliminado exitosamente"})
+}
+
+// GetOrgChart devuelve el organigrama (o el subárbol de un empleado).
+// GET /api/v1/admin/org-chart?root_user_id=
+func (h *OrgHandler) GetOrgChart(c *gin.Context) {
+	rootUserID, ok := parseOptionalUint(c, "root_user_id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "root_user_id inválido"})
+		return
+	}
+	chart, err := h.OrgService.GetOrgChart(rootUserID)
+	if err != nil {
+		respondOrgError(c, err, "Error al generar el organigrama")
+		return
+	}
+	c.JSON(http.StatusOK, chart)
+}
+
+// RegisterAdminOrgRoutes registra las rutas de estructura organizativa bajo el grupo /admin.
+func (h *OrgHandler) RegisterAdminOrgRoutes(rg *gin.RouterGroup) {
+	departmentRoutes := rg.Group("/departments")
+	{
+		departmentRoutes.GET("", h.ListDepartments)
+		departmentRoutes.POST("", h.CreateDepartment)
+		departmentRoutes.GET("/:id", h.GetDepartment)
+		departmentRoutes.PUT("/:id", h.UpdateDepartment)
+		departmentRoutes.DELETE("/:id", h.DeleteDepartment)
+		departmentRoutes.GET("/:id/members", h.ListDepartmentMembers)
+	}
+	teamRoutes := rg.Group("/teams")
+	{
+		teamRoutes.GET("", h.ListTeams)
+		teamRoutes.POST("", h.CreateTeam)
+		teamRoutes.GET("/:id", h.GetTeam)
+		teamRoutes.PUT("/:id", h.UpdateTeam)
+		teamRoutes.DELETE("/:id", h.DeleteTeam)
+	}
+	rg.GET("/org-chart", h.GetOrgChart)
+}
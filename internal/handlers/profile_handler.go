@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/middleware"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ProfileHandler maneja las solicitudes de autoservicio sobre el perfil del usuario autenticado.
+type ProfileHandler struct {
+	ProfileService services.ProfileServiceInterface
+}
+
+// NewProfileHandler crea una nueva instancia de ProfileHandler.
+func NewProfileHandler(profileService services.ProfileServiceInterface) *ProfileHandler {
+	return &ProfileHandler{ProfileService: profileService}
+}
+
+// GetOwnProfile devuelve el perfil completo del usuario autenticado.
+// GET /api/v1/me/profile
+func (h *ProfileHandler) GetOwnProfile(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+
+	profile, err := h.ProfileService.GetOwnProfile(claims.UserID)
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el perfil"})
+		}
+		return
+	}
+	c.Header("ETag", profile.ETag())
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateOwnProfile permite al usuario autenticado editar los campos habilitados de su perfil.
+// PATCH /api/v1/me/profile
+// If-Match es opcional; si se envía, se rechaza con 412 cuando el perfil cambió.
+func (h *ProfileHandler) UpdateOwnProfile(c *gin.Context) {
+	if _, exists := middleware.GetAuthClaims(c); !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+
+	// Se decodifica manualmente para rechazar campos desconocidos (p. ej. "position" o "role"),
+	// en lugar de ignorarlos en silencio como haría ShouldBindJSON.
+	var dto services.SelfUpdateProfileDTO
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+
+	profile, err := h.ProfileService.UpdateOwnProfile(requestActor(c), c.GetHeader("If-Match"), dto)
+	if err != nil {
+		if respondPreconditionFailed(c, err) || respondCustomFieldError(c, err, http.StatusUnprocessableEntity) {
+			return
+		}
+		var notEditable *services.FieldNotEditableError
+		if errors.As(err, &notEditable) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if err.Error() == "usuario no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if err.Error() == "el email ya está en uso por otro empleado" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el perfil"})
+		}
+		return
+	}
+	c.Header("ETag", profile.ETag())
+	c.JSON(http.StatusOK, gin.H{"message": "Perfil actualizado exitosamente", "user": profile})
+}
+
+// GetSelfEditableFields devuelve los campos que los empleados pueden editar de su perfil.
+// GET /api/v1/admin/settings/profile-fields
+func (h *ProfileHandler) GetSelfEditableFields(c *gin.Context) {
+	fields, err := h.ProfileService.GetSelfEditableFields()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la configuración"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"fields": fields, "candidates": services.SelfEditableProfileFieldCandidates})
+}
+
+// SetSelfEditableFields reemplaza los campos que los empleados pueden editar de su perfil.
+// PUT /api/v1/admin/settings/profile-fields
+func (h *ProfileHandler) SetSelfEditableFields(c *gin.Context) {
+	var dto services.SelfEditableFieldsDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+
+	fields, err := h.ProfileService.SetSelfEditableFields(requestActor(c), dto.Fields)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "campo no autoeditable") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar la configuración"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Configuración actualizada", "fields": fields})
+}
+
+// RegisterProfileRoutes registra las rutas de autoservicio bajo un grupo ya autenticado.
+func (h *ProfileHandler) RegisterProfileRoutes(rg *gin.RouterGroup) {
+	profileRoutes := rg.Group("/me/profile")
+	{
+		profileRoutes.GET("", h.GetOwnProfile)
+		profileRoutes.PATCH("", h.UpdateOwnProfile)
+	}
+}
+
+// RegisterAdminProfileSettingsRoutes registra la configuración de autoedición bajo el grupo /admin.
+func (h *ProfileHandler) RegisterAdminProfileSettingsRoutes(rg *gin.RouterGroup) {
+	settingsRoutes := rg.Group("/settings")
+	{
+		settingsRoutes.GET("/profile-fields", h.GetSelfEditableFields)
+		settingsRoutes.PUT("/profile-fields", h.SetSelfEditableFields)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Unikyri/yamerito-mvp/internal/auth"
+	"github.com/Unikyri/yamerito-mvp/internal/middleware"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// conflictProfileService responde a la autoedición del perfil con el error indicado.
+type conflictProfileService struct {
+	services.ProfileServiceInterface
+	err error
+}
+
+func (f *conflictProfileService) UpdateOwnProfile(actor services.RequestActor, ifMatch string, dto services.SelfUpdateProfileDTO) (*services.UserDetailDTO, error) {
+	return nil, f.err
+}
+
+func TestUpdateOwnProfileEmailTakenIs409(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET_KEY", "clave-de-prueba")
+	token, err := auth.GenerateJWT(7, "ana", models.RoleEmployee)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	authRequired := router.Group("")
+	authRequired.Use(middleware.AuthMiddleware(func(uint) (models.UserStatus, models.Role, error) {
+		return models.StatusActive, models.RoleEmployee, nil
+	}))
+	NewProfileHandler(&conflictProfileService{err: errors.New("el email ya está en uso por otro empleado")}).
+		RegisterProfileRoutes(authRequired)
+
+	req := httptest.NewRequest(http.MethodPatch, "/me/profile", strings.NewReader(`{"email":"luis@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, se esperaba 409", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "el email ya está en uso") {
+		t.Errorf("cuerpo = %s, se esperaba el motivo del conflicto", rec.Body.String())
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Unikyri/yamerito-mvp/internal/middleware"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ProgressHandler expone el avance del usuario autenticado en sus cursos.
+type ProgressHandler struct {
+	ProgressService services.ProgressServiceInterface
+}
+
+// NewProgressHandler crea una nueva instancia de ProgressHandler.
+func NewProgressHandler(progressService services.ProgressServiceInterface) *ProgressHandler {
+	return &ProgressHandler{ProgressService: progressService}
+}
+
+// respondProgressError traduce los errores del servicio de avance a códigos HTTP.
+func respondProgressError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "lección no encontrada":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "no estás inscrito en este curso":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "el avance de las lecciones SCORM lo informa el paquete":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// GetCourseProgress devuelve el avance en un curso, por módulos y lecciones, y dónde retomarlo.
+// GET /api/v1/me/courses/:id/progress
+func (h *ProgressHandler) GetCourseProgress(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	progress, err := h.ProgressService.GetCourseProgress(claims.UserID, id)
+	if err != nil {
+		respondProgressError(c, err, "Error al obtener el avance del curso")
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
+
+// UpdateLessonProgress registra el avance en una lección. El cliente puede repetir o
+// desordenar los envíos sin efectos adversos.
+// PUT /api/v1/me/courses/:id/lessons/:lessonId/progress
+func (h *ProgressHandler) UpdateLessonProgress(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	lessonID, err := strconv.ParseUint(c.Param("lessonId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de lección inválido"})
+		return
+	}
+	var dto services.UpdateLessonProgressDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	progress, err := h.ProgressService.UpdateLessonProgress(requestActor(c), id, uint(lessonID), dto)
+	if err != nil {
+		respondProgressError(c, err, "Error al guardar el avance")
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
+
+// RegisterProgressRoutes registra las rutas de avance del usuario autenticado.
+func (h *ProgressHandler) RegisterProgressRoutes(rg *gin.RouterGroup) {
+	rg.GET("/me/courses/:id/progress", h.GetCourseProgress)
+	rg.PUT("/me/courses/:id/lessons/:lessonId/progress", h.UpdateLessonProgress)
+}
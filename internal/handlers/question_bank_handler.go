@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// QuestionBankHandler expone la gestión de bancos de preguntas reutilizables.
+type QuestionBankHandler struct {
+	QuestionBankService services.QuestionBankServiceInterface
+}
+
+// NewQuestionBankHandler crea una nueva instancia de QuestionBankHandler.
+func NewQuestionBankHandler(questionBankService services.QuestionBankServiceInterface) *QuestionBankHandler {
+	return &QuestionBankHandler{QuestionBankService: questionBankService}
+}
+
+// respondQuestionBankError traduce los errores del servicio de bancos de preguntas a códigos
+// HTTP. Las validaciones de preguntas son las mismas que las de los cuestionarios.
+func respondQuestionBankError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "el título del banco de preguntas no puede estar vacío":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case "el banco de preguntas se usa en algún cuestionario":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		respondQuizError(c, err, fallback)
+	}
+}
+
+// parseBankQuestionIDs lee el ID del banco y el de la pregunta de la ruta.
+func parseBankQuestionIDs(c *gin.Context) (uint, uint, bool) {
+	bankID, ok := parseIDParam(c, "ID de banco de preguntas inválido")
+	if !ok {
+		return 0, 0, false
+	}
+	questionID, err := strconv.ParseUint(c.Param("questionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de pregunta inválido"})
+		return 0, 0, false
+	}
+	return bankID, uint(questionID), true
+}
+
+// ListBanks lista los bancos de preguntas gestionables por el usuario.
+// GET /api/v1/manage/question-banks
+func (h *QuestionBankHandler) ListBanks(c *gin.Context) {
+	banks, err := h.QuestionBankService.ListBanks(requestActor(c))
+	if err != nil {
+		respondQuestionBankError(c, err, "Error al obtener los bancos de preguntas")
+		return
+	}
+	c.JSON(http.StatusOK, banks)
+}
+
+// GetBank devuelve un banco con sus preguntas.
+// GET /api/v1/manage/question-banks/:id?topic=&difficulty=easy|medium|hard
+func (h *QuestionBankHandler) GetBank(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de banco de preguntas inválido")
+	if !ok {
+		return
+	}
+	filter := services.BankQuestionFilter{Topic: c.Query("topic"), Difficulty: c.Query("difficulty")}
+	bank, err := h.QuestionBankService.GetBank(requestActor(c), id, filter)
+	if err != nil {
+		respondQuestionBankError(c, err, "Error al obtener el banco de preguntas")
+		return
+	}
+	c.JSON(http.StatusOK, bank)
+}
+
+// CreateBank crea un banco de preguntas.
+// POST /api/v1/manage/question-banks
+func (h *QuestionBankHandler) CreateBank(c *gin.Context) {
+	var dto services.QuestionBankDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	bank, err := h.QuestionBankService.CreateBank(requestActor(c), dto)
+	if err != nil {
+		respondQuestionBankError(c, err, "Error al crear el banco de preguntas")
+		return
+	}
+	c.JSON(http.StatusCreated, bank)
+}
+
+// UpdateBank modifica un banco de preguntas.
+// PUT /api/v1/manage/question-banks/:id
+func (h *QuestionBankHandler) UpdateBank(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de banco de preguntas inválido")
+	if !ok {
+		return
+	}
+	var dto services.QuestionBankDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	bank, err := h.QuestionBankService.UpdateBank(requestActor(c), id, dto)
+	if err != nil {
+		respondQuestionBankError(c, err, "Error al actualizar el banco de preguntas")
+		return
+	}
+	c.JSON(http.StatusOK, bank)
+}
+
+// DeleteBank elimina un banco de preguntas que ningún cuestionario use.
+// DELETE /api/v1/manage/question-banks/:id
+func (h *QuestionBankHandler) DeleteBank(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de banco de preguntas inválido")
+	if !ok {
+		return
+	}
+	if err := h.QuestionBankService.DeleteBank(requestActor(c), id); err != nil {
+		respondQuestionBankError(c, err, "Error al eliminar el banco de preguntas")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AddBankQuestion agrega una pregunta, con tema y dificultad, a un banco.
+// POST /api/v1/manage/question-banks/:id/questions
+func (h *QuestionBankHandler) AddBankQuestion(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de banco de preguntas inválido")
+	if !ok {
+		return
+	}
+	var dto services.QuestionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	question, err := h.QuestionBankService.AddBankQuestion(requestActor(c), id, dto)
+	if err != nil {
+		respondQuestionBankError(c, err, "Error al crear la pregunta")
+		return
+	}
+	c.JSON(http.StatusCreated, question)
+}
+
+// UpdateBankQuestion reemplaza una pregunta de un banco.
+// PUT /api/v1/manage/question-banks/:id/questions/:questionId
+func (h *QuestionBankHandler) UpdateBankQuestion(c *gin.Context) {
+	bankID, questionID, ok := parseBankQuestionIDs(c)
+	if !ok {
+		return
+	}
+	var dto services.QuestionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	question, err := h.QuestionBankService.UpdateBankQuestion(requestActor(c), bankID, questionID, dto)
+	if err != nil {
+		respondQuestionBankError(c, err, "Error al actualizar la pregunta")
+		return
+	}
+	c.JSON(http.StatusOK, question)
+}
+
+// DeleteBankQuestion elimina una pregunta de un banco.
+// DELETE /api/v1/manage/question-banks/:id/questions/:questionId
+func (h *QuestionBankHandler) DeleteBankQuestion(c *gin.Context) {
+	bankID, questionID, ok := parseBankQuestionIDs(c)
+	if !ok {
+		return
+	}
+	if err := h.QuestionBankService.DeleteBankQuestion(requestActor(c), bankID, questionID); err != nil {
+		respondQuestionBankError(c, err, "Error al eliminar la pregunta")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetBankItemStats devuelve las estadísticas de las preguntas del banco en todos los
+// cuestionarios que sortean de él.
+// GET /api/v1/manage/question-banks/:id/stats
+func (h *QuestionBankHandler) GetBankItemStats(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de banco de preguntas inválido")
+	if !ok {
+		return
+	}
+	stats, err := h.QuestionBankService.GetBankItemStats(requestActor(c), id)
+	if err != nil {
+		respondQuestionBankError(c, err, "Error al calcular las estadísticas")
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// RegisterManageQuestionBankRoutes registra las rutas de bancos de preguntas bajo el grupo
+// /manage (administradores e instructores).
+func (h *QuestionBankHandler) RegisterManageQuestionBankRoutes(rg *gin.RouterGroup) {
+	bankRoutes := rg.Group("/question-banks")
+	{
+		bankRoutes.GET("", h.ListBanks)
+		bankRoutes.POST("", h.CreateBank)
+		bankRoutes.GET("/:id", h.GetBank)
+		bankRoutes.PUT("/:id", h.UpdateBank)
+		bankRoutes.DELETE("/:id", h.DeleteBank)
+		bankRoutes.GET("/:id/stats", h.GetBankItemStats)
+		bankRoutes.POST("/:id/questions", h.AddBankQuestion)
+		bankRoutes.PUT("/:id/questions/:questionId", h.UpdateBankQuestion)
+		bankRoutes.DELETE("/:id/questions/:questionId", h.DeleteBankQuestion)
+	}
+}
@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/middleware"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// QuizHandler expone la gestión de cuestionarios (administradores e instructores) y su
+// realización por los usuarios inscritos.
+type QuizHandler struct {
+	QuizService services.QuizServiceInterface
+}
+
+// NewQuizHandler crea una nueva instancia de QuizHandler.
+func NewQuizHandler(quizService services.QuizServiceInterface) *QuizHandler {
+	return &QuizHandler{QuizService: quizService}
+}
+
+// respondQuizError traduce los errores del servicio de cuestionarios a códigos HTTP.
+func respondQuizError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "cuestionario no encontrado", "pregunta no encontrada", "intento no encontrado", "curso no encontrado",
+		"regla de sorteo no encontrada", "banco de preguntas no encontrado":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "no tienes permiso para modificar este curso", "no estás inscrito en este curso",
+		"no tienes permiso para modificar este banco de preguntas":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "no se puede editar un curso archivado",
+		"el banco no tiene suficientes preguntas para la regla de sorteo",
+		"alcanzaste el número máximo de intentos",
+		"el intento ya fue entregado",
+		"se acabó el tiempo del intento",
+		"el cuestionario no tiene preguntas":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "la lección no pertenece al curso",
+		"el título del cuestionario no puede estar vacío",
+		"tipo de pregunta inválido",
+		"dificultad de pregunta inválida",
+		"los porcentajes de dificultad deben sumar 100 (o ser todos 0)",
+		"el enunciado de la pregunta no puede estar vacío",
+		"las preguntas de opción única necesitan al menos dos opciones y exactamente una correcta",
+		"las preguntas de opción múltiple necesitan al menos dos opciones y alguna correcta",
+		"las preguntas de ordenar necesitan al menos dos elementos",
+		"las preguntas de verdadero/falso requieren correct_boolean",
+		"las preguntas de respuesta corta requieren al menos una respuesta aceptada":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		// Respuestas que no corresponden al intento ("la pregunta N ...", "la opción N ...").
+		if strings.HasPrefix(err.Error(), "la pregunta ") || strings.HasPrefix(err.Error(), "la opción ") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// parseQuestionIDs lee el ID del cuestionario y el de la pregunta de la ruta.
+func parseQuestionIDs(c *gin.Context) (uint, uint, bool) {
+	quizID, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return 0, 0, false
+	}
+	questionID, err := strconv.ParseUint(c.Param("questionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de pregunta inválido"})
+		return 0, 0, false
+	}
+	return quizID, uint(questionID), true
+}
+
+// ListCourseQuizzes lista los cuestionarios de un curso.
+// GET /api/v1/manage/courses/:id/quizzes
+func (h *QuizHandler) ListCourseQuizzes(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	quizzes, err := h.QuizService.ListCourseQuizzes(requestActor(c), id)
+	if err != nil {
+		respondQuizError(c, err, "Error al obtener los cuestionarios")
+		return
+	}
+	c.JSON(http.StatusOK, quizzes)
+}
+
+// CreateQuiz crea un cuestionario en un curso.
+// POST /api/v1/manage/courses/:id/quizzes
+func (h *QuizHandler) CreateQuiz(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	var dto services.QuizDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	quiz, err := h.QuizService.CreateQuiz(requestActor(c), id, dto)
+	if err != nil {
+		respondQuizError(c, err, "Error al crear el cuestionario")
+		return
+	}
+	c.JSON(http.StatusCreated, quiz)
+}
+
+// GetManagedQuiz devuelve un cuestionario con sus preguntas y respuestas correctas.
+// GET /api/v1/manage/quizzes/:id
+func (h *QuizHandler) GetManagedQuiz(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return
+	}
+	quiz, err := h.QuizService.GetManagedQuiz(requestActor(c), id)
+	if err != nil {
+		respondQuizError(c, err, "Error al obtener el cuestionario")
+		return
+	}
+	c.JSON(http.StatusOK, quiz)
+}
+
+// UpdateQuiz modifica la configuración de un cuestionario.
+// PUT /api/v1/manage/quizzes/:id
+func (h *QuizHandler) UpdateQuiz(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return
+	}
+	var dto services.QuizDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	quiz, err := h.QuizService.UpdateQuiz(requestActor(c), id, dto)
+	if err != nil {
+		respondQuizError(c, err, "Error al actualizar el cuestionario")
+		return
+	}
+	c.JSON(http.StatusOK, quiz)
+}
+
+// DeleteQuiz elimina un cuestionario.
+// DELETE /api/v1/manage/quizzes/:id
+func (h *QuizHandler) DeleteQuiz(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return
+	}
+	if err := h.QuizService.DeleteQuiz(requestActor(c), id); err != nil {
+		respondQuizError(c, err, "Error al eliminar el cuestionario")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AddQuestion agrega una pregunta a un cuestionario.
+// POST /api/v1/manage/quizzes/:id/questions
+func (h *QuizHandler) AddQuestion(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return
+	}
+	var dto services.QuestionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	question, err := h.QuizService.AddQuestion(requestActor(c), id, dto)
+	if err != nil {
+		respondQuizError(c, err, "Error al crear la pregunta")
+		return
+	}
+	c.JSON(http.StatusCreated, question)
+}
+
+// UpdateQuestion reemplaza una pregunta y sus opciones.
+// PUT /api/v1/manage/quizzes/:id/questions/:questionId
+func (h *QuizHandler) UpdateQuestion(c *gin.Context) {
+	quizID, questionID, ok := parseQuestionIDs(c)
+	if !ok {
+		return
+	}
+	var dto services.QuestionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	question, err := h.QuizService.UpdateQuestion(requestActor(c), quizID, questionID, dto)
+	if err != nil {
+		respondQuizError(c, err, "Error al actualizar la pregunta")
+		return
+	}
+	c.JSON(http.StatusOK, question)
+}
+
+// DeleteQuestion elimina una pregunta.
+// DELETE /api/v1/manage/quizzes/:id/questions/:questionId
+func (h *QuizHandler) DeleteQuestion(c *gin.Context) {
+	quizID, questionID, ok := parseQuestionIDs(c)
+	if !ok {
+		return
+	}
+	if err := h.QuizService.DeleteQuestion(requestActor(c), quizID, questionID); err != nil {
+		respondQuizError(c, err, "Error al eliminar la pregunta")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListQuizAttempts lista los intentos de todos los usuarios en un cuestionario.
+// GET /api/v1/manage/quizzes/:id/attempts
+func (h *QuizHandler) ListQuizAttempts(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return
+	}
+	attempts, err := h.QuizService.ListQuizAttempts(requestActor(c), id)
+	if err != nil {
+		respondQuizError(c, err, "Error al obtener los intentos")
+		return
+	}
+	c.JSON(http.StatusOK, attempts)
+}
+
+// parseDrawRuleIDs lee el ID del cuestionario y el de la regla de sorteo de la ruta.
+func parseDrawRuleIDs(c *gin.Context) (uint, uint, bool) {
+	quizID, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return 0, 0, false
+	}
+	ruleID, err := strconv.ParseUint(c.Param("drawId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de regla de sorteo inválido"})
+		return 0, 0, false
+	}
+	return quizID, uint(ruleID), true
+}
+
+// AddDrawRule agrega una regla de sorteo desde un banco de preguntas.
+// POST /api/v1/manage/quizzes/:id/draws
+func (h *QuizHandler) AddDrawRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return
+	}
+	var dto services.DrawRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	rule, err := h.QuizService.AddDrawRule(requestActor(c), id, dto)
+	if err != nil {
+		respondQuizError(c, err, "Error al crear la regla de sorteo")
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateDrawRule modifica una regla de sorteo.
+// PUT /api/v1/manage/quizzes/:id/draws/:drawId
+func (h *QuizHandler) UpdateDrawRule(c *gin.Context) {
+	quizID, ruleID, ok := parseDrawRuleIDs(c)
+	if !ok {
+		return
+	}
+	var dto services.DrawRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	rule, err := h.QuizService.UpdateDrawRule(requestActor(c), quizID, ruleID, dto)
+	if err != nil {
+		respondQuizError(c, err, "Error al actualizar la regla de sorteo")
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteDrawRule elimina una regla de sorteo.
+// DELETE /api/v1/manage/quizzes/:id/draws/:drawId
+func (h *QuizHandler) DeleteDrawRule(c *gin.Context) {
+	quizID, ruleID, ok := parseDrawRuleIDs(c)
+	if !ok {
+		return
+	}
+	if err := h.QuizService.DeleteDrawRule(requestActor(c), quizID, ruleID); err != nil {
+		respondQuizError(c, err, "Error al eliminar la regla de sorteo")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetQuizItemStats devuelve el índice de facilidad y de discriminación de cada pregunta
+// según los intentos calificados.
+// GET /api/v1/manage/quizzes/:id/stats
+func (h *QuizHandler) GetQuizItemStats(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return
+	}
+	stats, err := h.QuizService.GetQuizItemStats(requestActor(c), id)
+	if err != nil {
+		respondQuizError(c, err, "Error al calcular las estadísticas")
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// ListMyCourseQuizzes lista los cuestionarios de un curso en el que el usuario está inscrito.
+// GET /api/v1/me/courses/:id/quizzes
+func (h *QuizHandler) ListMyCourseQuizzes(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return
+	}
+	quizzes, err := h.QuizService.ListMyCourseQuizzes(claims.UserID, id)
+	if err != nil {
+		respondQuizError(c, err, "Error al obtener los cuestionarios")
+		return
+	}
+	c.JSON(http.StatusOK, quizzes)
+}
+
+// GetQuiz devuelve un cuestionario (sin preguntas) y los intentos usados y restantes.
+// GET /api/v1/quizzes/:id
+func (h *QuizHandler) GetQuiz(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	id, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return
+	}
+	info, err := h.QuizService.GetQuizInfo(claims.UserID, id)
+	if err != nil {
+		respondQuizError(c, err, "Error al obtener el cuestionario")
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// StartAttempt empieza un intento, o retoma el que esté en curso.
+// POST /api/v1/quizzes/:id/attempts
+func (h *QuizHandler) StartAttempt(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return
+	}
+	attempt, err := h.QuizService.StartAttempt(requestActor(c), id)
+	if err != nil {
+		respondQuizError(c, err, "Error al empezar el intento")
+		return
+	}
+	c.JSON(http.StatusOK, attempt)
+}
+
+// ListMyAttempts lista los intentos propios en un cuestionario.
+// GET /api/v1/quizzes/:id/attempts
+func (h *QuizHandler) ListMyAttempts(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	id, ok := parseIDParam(c, "ID de cuestionario inválido")
+	if !ok {
+		return
+	}
+	attempts, err := h.QuizService.ListMyAttempts(claims.UserID, id)
+	if err != nil {
+		respondQuizError(c, err, "Error al obtener los intentos")
+		return
+	}
+	c.JSON(http.StatusOK, attempts)
+}
+
+// GetAttempt devuelve un intento propio: en curso, sus preguntas y respuestas guardadas;
+// calificado, además, la corrección.
+// GET /api/v1/quiz-attempts/:id
+func (h *QuizHandler) GetAttempt(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	id, ok := parseIDParam(c, "ID de intento inválido")
+	if !ok {
+		return
+	}
+	attempt, err := h.QuizService.GetAttempt(claims.UserID, id)
+	if err != nil {
+		respondQuizError(c, err, "Error al obtener el intento")
+		return
+	}
+	c.JSON(http.StatusOK, attempt)
+}
+
+// SaveAnswers guarda respuestas de un intento en curso sin entregarlo.
+// PUT /api/v1/quiz-attempts/:id/answers
+func (h *QuizHandler) SaveAnswers(c *gin.Context) {
+	claims, exists := middleware.GetAuthClaims(c)
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener claims de autenticación"})
+		return
+	}
+	id, ok := parseIDParam(c, "ID de intento inválido")
+	if !ok {
+		return
+	}
+	var dto services.SaveAnswersDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	attempt, err := h.QuizService.SaveAnswers(claims.UserID, id, dto)
+	if err != nil {
+		respondQuizError(c, err, "Error al guardar las respuestas")
+		return
+	}
+	c.JSON(http.StatusOK, attempt)
+}
+
+// SubmitAttempt entrega un intento y devuelve la calificación con las respuestas correctas.
+// El cuerpo es opcional: sin él se califica con las respuestas ya guardadas.
+// POST /api/v1/quiz-attempts/:id/submit
+func (h *QuizHandler) SubmitAttempt(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de intento inválido")
+	if !ok {
+		return
+	}
+	var dto services.SaveAnswersDTO
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+			return
+		}
+	}
+	attempt, err := h.QuizService.SubmitAttempt(requestActor(c), id, dto)
+	if err != nil {
+		respondQuizError(c, err, "Error al entregar el intento")
+		return
+	}
+	c.JSON(http.StatusOK, attempt)
+}
+
+// RegisterManageQuizRoutes registra las rutas de gestión de cuestionarios bajo el grupo
+// /manage (administradores e instructores).
+func (h *QuizHandler) RegisterManageQuizRoutes(rg *gin.RouterGroup) {
+	rg.GET("/courses/:id/quizzes", h.ListCourseQuizzes)
+	rg.POST("/courses/:id/quizzes", h.CreateQuiz)
+
+	quizRoutes := rg.Group("/quizzes")
+	{
+		quizRoutes.GET("/:id", h.GetManagedQuiz)
+		quizRoutes.PUT("/:id", h.UpdateQuiz)
+		quizRoutes.DELETE("/:id", h.DeleteQuiz)
+		quizRoutes.POST("/:id/questions", h.AddQuestion)
+		quizRoutes.PUT("/:id/questions/:questionId", h.UpdateQuestion)
+		quizRoutes.DELETE("/:id/questions/:questionId", h.DeleteQuestion)
+		quizRoutes.GET("/:id/attempts", h.ListQuizAttempts)
+		quizRoutes.POST("/:id/draws", h.AddDrawRule)
+		quizRoutes.PUT("/:id/draws/:drawId", h.UpdateDrawRule)
+		quizRoutes.DELETE("/:id/draws/:drawId", h.DeleteDrawRule)
+		quizRoutes.GET("/:id/stats", h.GetQuizItemStats)
+	}
+}
+
+// RegisterQuizRoutes registra las rutas para responder cuestionarios de cualquier usuario autenticado.
+func (h *QuizHandler) RegisterQuizRoutes(rg *gin.RouterGroup) {
+	rg.GET("/me/courses/:id/quizzes", h.ListMyCourseQuizzes)
+	rg.GET("/quizzes/:id", h.GetQuiz)
+	rg.POST("/quizzes/:id/attempts", h.StartAttempt)
+	rg.GET("/quizzes/:id/attempts", h.ListMyAttempts)
+	rg.GET("/quiz-attempts/:id", h.GetAttempt)
+	rg.PUT("/quiz-attempts/:id/answers", h.SaveAnswers)
+	rg.POST("/quiz-attempts/:id/submit", h.SubmitAttempt)
+}
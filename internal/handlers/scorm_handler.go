@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// maxScormPackageBytes limita el tamaño del zip subido.
+const maxScormPackageBytes = 500 << 20
+
+// ScormHandler expone la importación de paquetes SCORM, el backend de su API de ejecución y
+// los archivos del contenido.
+type ScormHandler struct {
+	ScormService services.ScormServiceInterface
+}
+
+// NewScormHandler crea una nueva instancia de ScormHandler.
+func NewScormHandler(scormService services.ScormServiceInterface) *ScormHandler {
+	return &ScormHandler{ScormService: scormService}
+}
+
+// respondScormError traduce los errores del servicio SCORM a códigos HTTP.
+func respondScormError(c *gin.Context, err error, fallback string) {
+	switch err.Error() {
+	case "curso no encontrado", "módulo no encontrado", "lección no encontrada", "la lección no tiene paquete SCORM":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "no tienes permiso para modificar este curso", "no estás inscrito en este curso":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "no se puede editar un curso archivado":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "la lección no es de tipo SCORM", "versión de SCORM no soportada":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		// Validaciones del paquete y del modelo de datos, con el detalle en el mensaje.
+		for _, prefix := range []string{"el paquete SCORM", "el manifiesto", "el recurso ", "versión de SCORM",
+			"elemento SCORM", "valor SCORM inválido"} {
+			if strings.HasPrefix(err.Error(), prefix) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ImportPackage sube el paquete SCORM (zip) de una lección de tipo "scorm" y reemplaza el anterior.
+// PUT /api/v1/manage/courses/:id/modules/:moduleId/lessons/:lessonId/scorm (multipart, campo "file")
+func (h *ScormHandler) ImportPackage(c *gin.Context) {
+	ids, ok := parseCourseChildIDs(c, "moduleId", "lessonId")
+	if !ok {
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxScormPackageBytes+(64<<10))
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "El paquete supera el tamaño máximo de 500 MB"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Se esperaba un formulario multipart con el campo 'file'", "details": err.Error()})
+		}
+		return
+	}
+	if fileHeader.Size > maxScormPackageBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "El paquete supera el tamaño máximo de 500 MB"})
+		return
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+		return
+	}
+	defer f.Close()
+	pkg, err := h.ScormService.ImportPackage(c.Request.Context(), requestActor(c), ids[0], ids[1], ids[2], f, fileHeader.Size)
+	if err != nil {
+		respondScormError(c, err, "Error al importar el paquete SCORM")
+		return
+	}
+	c.JSON(http.StatusOK, pkg)
+}
+
+// GetPackage devuelve los datos del paquete SCORM de una lección.
+// GET /api/v1/manage/courses/:id/modules/:moduleId/lessons/:lessonId/scorm
+func (h *ScormHandler) GetPackage(c *gin.Context) {
+	ids, ok := parseCourseChildIDs(c, "moduleId", "lessonId")
+	if !ok {
+		return
+	}
+	pkg, err := h.ScormService.GetPackage(requestActor(c), ids[0], ids[1], ids[2])
+	if err != nil {
+		respondScormError(c, err, "Error al obtener el paquete SCORM")
+		return
+	}
+	c.JSON(http.StatusOK, pkg)
+}
+
+// parseScormLessonIDs lee el ID del curso y el de la lección de las rutas del empleado.
+func parseScormLessonIDs(c *gin.Context) (uint, uint, bool) {
+	id, ok := parseIDParam(c, "ID de curso inválido")
+	if !ok {
+		return 0, 0, false
+	}
+	lessonID, err := strconv.ParseUint(c.Param("lessonId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de lección inválido"})
+		return 0, 0, false
+	}
+	return id, uint(lessonID), true
+}
+
+// Launch devuelve la URL del SCO y los valores iniciales de su modelo de datos.
+// GET /api/v1/me/courses/:id/lessons/:lessonId/scorm
+func (h *ScormHandler) Launch(c *gin.Context) {
+	id, lessonID, ok := parseScormLessonIDs(c)
+	if !ok {
+		return
+	}
+	launch, err := h.ScormService.Launch(requestActor(c), id, lessonID)
+	if err != nil {
+		respondScormError(c, err, "Error al abrir la lección SCORM")
+		return
+	}
+	c.JSON(http.StatusOK, launch)
+}
+
+// Commit guarda los valores escritos por el SCO (LMSCommit / Commit y, con finish,
+// LMSFinish / Terminate). Un valor inválido rechaza el lote completo.
+// PUT /api/v1/me/courses/:id/lessons/:lessonId/scorm
+func (h *ScormHandler) Commit(c *gin.Context) {
+	id, lessonID, ok := parseScormLessonIDs(c)
+	if !ok {
+		return
+	}
+	var dto services.ScormCommitDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	result, err := h.ScormService.Commit(requestActor(c), id, lessonID, dto)
+	if err != nil {
+		respondScormError(c, err, "Error al guardar los datos SCORM")
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ServeContent sirve un archivo de un paquete SCORM. No requiere autenticación: el token del
+// paquete en la ruta es la credencial (ver services.ScormContentPath). El contenido lo sube
+// un instructor y no es de confianza: se sirve con una CSP sandbox sin allow-same-origin, así
+// que corre en un origen opaco y habla con la aplicación solo por postMessage (ver
+// services.ScormLaunch).
+// GET /api/v1/scorm-content/:token/*path
+func (h *ScormHandler) ServeContent(c *gin.Context) {
+	rc, info, err := h.ScormService.OpenContent(c.Request.Context(), c.Param("token"), c.Param("path"))
+	if err != nil {
+		if err.Error() == "archivo SCORM no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el archivo SCORM"})
+		}
+		return
+	}
+	defer rc.Close()
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", services.ScormContentSecurityPolicy)
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, rc, nil)
+}
+
+// RegisterManageScormRoutes registra la importación de paquetes bajo el grupo /manage.
+func (h *ScormHandler) RegisterManageScormRoutes(rg *gin.RouterGroup) {
+	rg.PUT("/courses/:id/modules/:moduleId/lessons/:lessonId/scorm", h.ImportPackage)
+	rg.GET("/courses/:id/modules/:moduleId/lessons/:lessonId/scorm", h.GetPackage)
+}
+
+// RegisterScormRoutes registra la API de ejecución bajo un grupo ya autenticado.
+func (h *ScormHandler) RegisterScormRoutes(rg *gin.RouterGroup) {
+	rg.GET("/me/courses/:id/lessons/:lessonId/scorm", h.Launch)
+	rg.PUT("/me/courses/:id/lessons/:lessonId/scorm", h.Commit)
+}
+
+// RegisterScormContentRoutes registra los archivos de los paquetes, sin autenticación.
+func (h *ScormHandler) RegisterScormContentRoutes(rg *gin.RouterGroup) {
+	rg.GET("/scorm-content/:token/*path", h.ServeContent)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Unikyri/yamerito-mvp/internal/middleware"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/Unikyri/yamerito-mvp/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeScormService sirve siempre la misma página y registra si se llegó a la API de
+// ejecución.
+type fakeScormService struct {
+	page    string
+	reached bool
+}
+
+func (f *fakeScormService) ImportPackage(ctx context.Context, actor services.RequestActor, courseID, moduleID, lessonID uint, r io.ReaderAt, size int64) (*models.ScormPackage, error) {
+	f.reached = true
+	return nil, nil
+}
+
+func (f *fakeScormService) GetPackage(actor services.RequestActor, courseID, moduleID, lessonID uint) (*models.ScormPackage, error) {
+	f.reached = true
+	return nil, nil
+}
+
+func (f *fakeScormService) Launch(actor services.RequestActor, courseID, lessonID uint) (*services.ScormLaunch, error) {
+	f.reached = true
+	return &services.ScormLaunch{}, nil
+}
+
+func (f *fakeScormService) Commit(actor services.RequestActor, courseID, lessonID uint, dto services.ScormCommitDTO) (*services.ScormCommitResult, error) {
+	f.reached = true
+	return &services.ScormCommitResult{}, nil
+}
+
+func (f *fakeScormService) OpenContent(ctx context.Context, token, name string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	if token != "tok" {
+		return nil, nil, errors.New("archivo SCORM no encontrado")
+	}
+	return io.NopCloser(strings.NewReader(f.page)), &storage.ObjectInfo{
+		Key: name, Size: int64(len(f.page)), ContentType: "text/html; charset=utf-8",
+	}, nil
+}
+
+// newScormTestRouter arma las rutas SCORM igual que cmd/server: CORS global, contenido
+// público y la API de ejecución detrás de AuthMiddleware.
+func newScormTestRouter(svc services.ScormServiceInterface) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.CORS(services.ScormContentPath))
+	h := NewScormHandler(svc)
+	apiV1 := router.Group("/api/v1")
+	h.RegisterScormContentRoutes(apiV1)
+	authRequired := apiV1.Group("")
+	authRequired.Use(middleware.AuthMiddleware(func(uint) (models.UserStatus, models.Role, error) {
+		return models.StatusActive, models.RoleEmployee, nil
+	}))
+	h.RegisterScormRoutes(authRequired)
+	return router
+}
+
+func TestServeContentIsSandboxed(t *testing.T) {
+	svc := &fakeScormService{page: "<html><head></head><body>SCO</body></html>"}
+	router := newScormTestRouter(svc)
+
+	req := httptest.NewRequest(http.MethodGet, services.ScormContentPath+"/tok/index.html", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, se esperaba 200", rec.Code)
+	}
+	csp := rec.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "sandbox") || !strings.Contains(csp, "allow-scripts") {
+		t.Errorf("Content-Security-Policy = %q, se esperaba sandbox con allow-scripts", csp)
+	}
+	if strings.Contains(csp, "allow-same-origin") {
+		t.Errorf("Content-Security-Policy = %q: allow-same-origin le devolvería al SCO el origen de la API", csp)
+	}
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q", got)
+	}
+}
+
+// TestSandboxedScoCannotReachAPI reproduce lo que puede hacer un SCO malicioso. Con la CSP
+// sandbox el navegador le da un origen opaco: no puede leer el JWT del localStorage de la
+// aplicación y todas sus peticiones llevan "Origin: null".
+func TestSandboxedScoCannotReachAPI(t *testing.T) {
+	svc := &fakeScormService{page: "<html></html>"}
+	router := newScormTestRouter(svc)
+	launchPath := "/api/v1/me/courses/1/lessons/2/scorm"
+
+	t.Run("los archivos de su paquete sí se pueden leer", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, services.ScormContentPath+"/tok/data.html", nil)
+		req.Header.Set("Origin", "null")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "null" {
+			t.Fatalf("status = %d, Access-Control-Allow-Origin = %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+	})
+
+	t.Run("la API rechaza el origen opaco", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodPut} {
+			req := httptest.NewRequest(method, launchPath, strings.NewReader(`{"values":{},"finish":true}`))
+			req.Header.Set("Origin", "null")
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("%s: status = %d, se esperaba 403", method, rec.Code)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+				t.Errorf("%s: Access-Control-Allow-Origin = %q, el SCO podría leer la respuesta", method, got)
+			}
+		}
+	})
+
+	t.Run("no se permite preparar una petición con Authorization", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, launchPath, nil)
+		req.Header.Set("Origin", "null")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+		req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("status = %d, se esperaba 403", rec.Code)
+		}
+	})
+
+	t.Run("el contenido no acepta escrituras desde el origen opaco", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, services.ScormContentPath+"/tok/index.html", nil)
+		req.Header.Set("Origin", "null")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("status = %d, se esperaba 403", rec.Code)
+		}
+	})
+
+	t.Run("sin el JWT la API exige autenticación", func(t *testing.T) {
+		// Un formulario (allow-forms) o una navegación no llevan Origin válido ni el JWT:
+		// la API no usa cookies, así que la petición llega sin credenciales.
+		req := httptest.NewRequest(http.MethodPut, launchPath, strings.NewReader(`{"values":{},"finish":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, se esperaba 401", rec.Code)
+		}
+	})
+
+	if svc.reached {
+		t.Error("la API de ejecución se ejecutó para una petición del SCO")
+	}
+}
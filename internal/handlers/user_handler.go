@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Unikyri/yamerito-mvp/internal/jsonpatch"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
 	"github.com/Unikyri/yamerito-mvp/internal/services"
 	"github.com/gin-gonic/gin"
 )
@@ -39,8 +45,11 @@ func (h *UserHandler) CreateUserByAdmin(c *gin.Context) {
 		return
 	}
 
-	user, err := h.UserService.CreateUserByAdmin(dto)
+	user, err := h.UserService.CreateUserByAdmin(requestActor(c), dto)
 	if err != nil {
+		if respondOrgAssignmentError(c, err) || respondLifecycleError(c, err) || respondCustomFieldError(c, err, http.StatusUnprocessableEntity) {
+			return
+		}
 		if err.Error() == "el nombre de usuario ya está en uso" || err.Error() == "rol proporcionado inválido" {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		} else {
@@ -51,11 +60,49 @@ func (h *UserHandler) CreateUserByAdmin(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"message": "Usuario creado exitosamente por admin", "user": user})
 }
 
-// ListUsers maneja la solicitud para listar todos los usuarios.
-// GET /api/v1/admin/users
+// ListUsers maneja la solicitud para listar los usuarios.
+// GET /api/v1/admin/users?q=&department_id=&team_id=&status=&compliance=&cf.<clave>=<valor>
 func (h *UserHandler) ListUsers(c *gin.Context) {
-	users, err := h.UserService.ListUsers()
+	filter := services.UserListFilter{Query: c.Query("q")}
+	var ok bool
+	if filter.DepartmentID, ok = parseOptionalUint(c, "department_id"); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "department_id inválido"})
+		return
+	}
+	if filter.TeamID, ok = parseOptionalUint(c, "team_id"); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "team_id inválido"})
+		return
+	}
+	if raw := c.Query("status"); raw != "" {
+		status, err := models.ParseUserStatus(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status inválido", "details": err.Error()})
+			return
+		}
+		filter.Status = &status
+	}
+	if raw := c.Query("compliance"); raw != "" {
+		compliance, err := models.ParseComplianceStatus(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "compliance inválido", "details": err.Error()})
+			return
+		}
+		filter.Compliance = &compliance
+	}
+	for name, values := range c.Request.URL.Query() {
+		if key := strings.TrimPrefix(name, "cf."); key != name && len(values) > 0 {
+			if filter.CustomFields == nil {
+				filter.CustomFields = map[string]string{}
+			}
+			filter.CustomFields[key] = values[0]
+		}
+	}
+
+	users, err := h.UserService.ListUsers(filter)
 	if err != nil {
+		if respondCustomFieldError(c, err, http.StatusBadRequest) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al listar usuarios"})
 		return
 	}
@@ -81,11 +128,14 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 		}
 		return
 	}
+	c.Header("ETag", user.ETag())
+	c.Header("Accept-Patch", jsonpatch.MediaTypeMergePatch+", "+jsonpatch.MediaTypeJSONPatch)
 	c.JSON(http.StatusOK, user)
 }
 
 // UpdateUserByAdmin maneja la actualización de un usuario por un administrador.
 // PUT /api/v1/admin/users/:id
+// Requiere el encabezado If-Match con el ETag devuelto por GET /api/v1/admin/users/:id.
 func (h *UserHandler) UpdateUserByAdmin(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -94,6 +144,12 @@ func (h *UserHandler) UpdateUserByAdmin(c *gin.Context) {
 		return
 	}
 
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "Se requiere el encabezado If-Match con el ETag del usuario"})
+		return
+	}
+
 	var dto services.AdminUpdateUserDTO
 	if err := c.ShouldBindJSON(&dto); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
@@ -104,20 +160,98 @@ func (h *UserHandler) UpdateUserByAdmin(c *gin.Context) {
 	// (Esta lógica es compleja y podría ir en el servicio o requerir más contexto sobre cómo identificar al "yo")
 	// Por ahora, se permite.
 
-	user, err := h.UserService.UpdateUserByAdmin(uint(id), dto)
+	user, err := h.UserService.UpdateUserByAdmin(requestActor(c), uint(id), ifMatch, dto)
 	if err != nil {
+		if respondPreconditionFailed(c, err) || respondOrgAssignmentError(c, err) || respondLifecycleError(c, err) || respondCustomFieldError(c, err, http.StatusUnprocessableEntity) {
+			return
+		}
 		if err.Error() == "usuario no encontrado para actualizar" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else if err.Error() == "el nuevo nombre de usuario ya está en uso por otro usuario" || err.Error() == "rol proporcionado inválido para la actualización" {
+		} else if err.Error() == "el nuevo nombre de usuario ya está en uso por otro usuario" || err.Error() == "el email ya está en uso por otro empleado" || err.Error() == "rol proporcionado inválido para la actualización" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el usuario"})
+		}
+		return
+	}
+	c.Header("ETag", user.ETag())
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario actualizado exitosamente", "user": user})
+}
+
+// maxPatchBodyBytes limita el tamaño de un documento de parche.
+const maxPatchBodyBytes = 1 << 20
+
+// PatchUserByAdmin aplica un parche parcial sobre un usuario.
+// PATCH /api/v1/admin/users/:id
+// Acepta application/merge-patch+json (RFC 7396) y application/json-patch+json (RFC 6902),
+// y requiere If-Match igual que PUT.
+func (h *UserHandler) PatchUserByAdmin(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	mediaType := c.ContentType()
+	if mediaType != jsonpatch.MediaTypeMergePatch && mediaType != jsonpatch.MediaTypeJSONPatch {
+		c.Header("Accept-Patch", jsonpatch.MediaTypeMergePatch+", "+jsonpatch.MediaTypeJSONPatch)
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type no soportado para PATCH", "details": mediaType})
+		return
+	}
+
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "Se requiere el encabezado If-Match con el ETag del usuario"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el cuerpo de la solicitud", "details": err.Error()})
+		return
+	}
+
+	user, err := h.UserService.PatchUserByAdmin(requestActor(c), uint(id), ifMatch, mediaType, body)
+	if err != nil {
+		if respondPreconditionFailed(c, err) {
+			return
+		}
+		var patchErr *services.PatchError
+		if errors.As(err, &patchErr) {
+			status := http.StatusBadRequest
+			switch patchErr.Kind {
+			case services.PatchConflict:
+				status = http.StatusConflict
+			case services.PatchInvalidResult:
+				status = http.StatusUnprocessableEntity
+			}
+			c.JSON(status, gin.H{"error": "No se pudo aplicar el parche", "details": err.Error()})
+		} else if err.Error() == "usuario no encontrado para actualizar" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if err.Error() == "el nuevo nombre de usuario ya está en uso por otro usuario" || err.Error() == "el email ya está en uso por otro empleado" {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el usuario"})
 		}
 		return
 	}
+	c.Header("ETag", user.ETag())
 	c.JSON(http.StatusOK, gin.H{"message": "Usuario actualizado exitosamente", "user": user})
 }
 
+// respondPreconditionFailed responde 412 con la representación vigente si err es un
+// conflicto de versión. Devuelve true si escribió la respuesta.
+func respondPreconditionFailed(c *gin.Context, err error) bool {
+	var conflict *services.PreconditionFailedError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	c.Header("ETag", conflict.Current.ETag())
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error(), "current": conflict.Current})
+	return true
+}
+
 // DeleteUser maneja la eliminación de un usuario por un administrador.
 // DELETE /api/v1/admin/users/:id
 func (h *UserHandler) DeleteUser(c *gin.Context) {
@@ -137,7 +271,7 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	// 	return
 	// }
 
-	err = h.UserService.DeleteUser(uint(id))
+	err = h.UserService.DeleteUser(requestActor(c), uint(id))
 	if err != nil {
 		if err.Error() == "usuario no encontrado para eliminar" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -149,6 +283,62 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Usuario eliminado exitosamente"})
 }
 
+// GetUserHistory devuelve las versiones históricas de un usuario (rol, cargo, email, etc.).
+// GET /api/v1/admin/users/:id/history
+func (h *UserHandler) GetUserHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	history, err := h.UserService.GetUserHistory(uint(id))
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el historial"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// GetUserAsOf devuelve el registro del usuario tal como estaba en una fecha dada.
+// GET /api/v1/admin/users/:id/history/as-of?date=2025-03-31 (o un instante RFC 3339)
+// Una fecha sin hora se interpreta como el final de ese día.
+func (h *UserHandler) GetUserAsOf(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	raw := c.Query("date")
+	var at time.Time
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		at = t
+	} else if d, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		at = d.AddDate(0, 0, 1).Add(-time.Millisecond)
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro 'date' inválido (use YYYY-MM-DD o RFC 3339)"})
+		return
+	}
+
+	entry, err := h.UserService.GetUserAsOf(uint(id), at)
+	if err != nil {
+		if err.Error() == "el usuario no existía en la fecha indicada" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el historial"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"as_of": at, "user": entry})
+}
+
 // RegisterAdminUserRoutes registra las rutas CRUD para la gestión de usuarios por administradores.
 func (h *UserHandler) RegisterAdminUserRoutes(rg *gin.RouterGroup) {
 	adminUserRoutes := rg.Group("/users") // Corregido: Rutas bajo /api/v1/admin/users
@@ -157,7 +347,10 @@ func (h *UserHandler) RegisterAdminUserRoutes(rg *gin.RouterGroup) {
 		adminUserRoutes.GET("", h.ListUsers)
 		adminUserRoutes.GET("/:id", h.GetUserByID)
 		adminUserRoutes.PUT("/:id", h.UpdateUserByAdmin)
+		adminUserRoutes.PATCH("/:id", h.PatchUserByAdmin)
 		adminUserRoutes.DELETE("/:id", h.DeleteUser)
+		adminUserRoutes.GET("/:id/history", h.GetUserHistory)
+		adminUserRoutes.GET("/:id/history/as-of", h.GetUserAsOf)
 	}
 }
 
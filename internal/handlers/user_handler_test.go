@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Unikyri/yamerito-mvp/internal/jsonpatch"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// conflictUserService responde a PUT y PATCH con el error de servicio indicado.
+type conflictUserService struct {
+	services.UserServiceInterface
+	err error
+}
+
+func (f *conflictUserService) UpdateUserByAdmin(actor services.RequestActor, id uint, ifMatch string, dto services.AdminUpdateUserDTO) (*services.UserDetailDTO, error) {
+	return nil, f.err
+}
+
+func (f *conflictUserService) PatchUserByAdmin(actor services.RequestActor, id uint, ifMatch, mediaType string, patch []byte) (*services.UserDetailDTO, error) {
+	return nil, f.err
+}
+
+func TestUserUpdateUniquenessConflictsAre409(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, msg := range []string{"el nuevo nombre de usuario ya está en uso por otro usuario", "el email ya está en uso por otro empleado"} {
+		router := gin.New()
+		NewUserHandler(&conflictUserService{err: errors.New(msg)}).RegisterAdminUserRoutes(router.Group("/admin"))
+
+		requests := []*http.Request{
+			httptest.NewRequest(http.MethodPut, "/admin/users/7", strings.NewReader(`{"username":"ana"}`)),
+			httptest.NewRequest(http.MethodPatch, "/admin/users/7", strings.NewReader(`{"username":"ana"}`)),
+		}
+		requests[0].Header.Set("Content-Type", "application/json")
+		requests[1].Header.Set("Content-Type", jsonpatch.MediaTypeMergePatch)
+		for _, req := range requests {
+			req.Header.Set("If-Match", `"v1"`)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusConflict {
+				t.Errorf("%s con %q: status = %d, se esperaba 409", req.Method, msg, rec.Code)
+			}
+		}
+	}
+}
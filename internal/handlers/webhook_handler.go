@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler expone la administración de webhooks salientes y su registro de entregas.
+type WebhookHandler struct {
+	WebhookService services.WebhookServiceInterface
+}
+
+// NewWebhookHandler crea una nueva instancia de WebhookHandler.
+func NewWebhookHandler(webhookService services.WebhookServiceInterface) *WebhookHandler {
+	return &WebhookHandler{WebhookService: webhookService}
+}
+
+// respondWebhookError traduce los errores del servicio de webhooks a códigos HTTP.
+func respondWebhookError(c *gin.Context, err error, fallback string) {
+	var validationErr *services.WebhookValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Webhook inválido", "details": err.Error()})
+		return
+	}
+	switch err.Error() {
+	case "webhook no encontrado", "entrega no encontrada":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListEventTypes devuelve los eventos a los que se puede suscribir un webhook.
+// GET /api/v1/admin/webhooks/event-types
+func (h *WebhookHandler) ListEventTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, h.WebhookService.ListEventTypes())
+}
+
+// ListWebhooks lista las suscripciones.
+// GET /api/v1/admin/webhooks
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	subs, err := h.WebhookService.ListWebhooks()
+	if err != nil {
+		respondWebhookError(c, err, "Error al obtener los webhooks")
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// GetWebhook devuelve una suscripción (sin el secreto).
+// GET /api/v1/admin/webhooks/:id
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de webhook inválido")
+	if !ok {
+		return
+	}
+	sub, err := h.WebhookService.GetWebhook(id)
+	if err != nil {
+		respondWebhookError(c, err, "Error al obtener el webhook")
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// CreateWebhook crea una suscripción. La respuesta incluye el secreto de firma, que no
+// vuelve a mostrarse.
+// POST /api/v1/admin/webhooks
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var dto services.WebhookDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	sub, err := h.WebhookService.CreateWebhook(requestActor(c), dto)
+	if err != nil {
+		respondWebhookError(c, err, "Error al crear el webhook")
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+// UpdateWebhook reemplaza nombre, URL, eventos y estado de una suscripción.
+// PUT /api/v1/admin/webhooks/:id
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de webhook inválido")
+	if !ok {
+		return
+	}
+	var dto services.WebhookDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	sub, err := h.WebhookService.UpdateWebhook(requestActor(c), id, dto)
+	if err != nil {
+		respondWebhookError(c, err, "Error al actualizar el webhook")
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// DeleteWebhook elimina una suscripción.
+// DELETE /api/v1/admin/webhooks/:id
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de webhook inválido")
+	if !ok {
+		return
+	}
+	if err := h.WebhookService.DeleteWebhook(requestActor(c), id); err != nil {
+		respondWebhookError(c, err, "Error al eliminar el webhook")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook eliminado exitosamente"})
+}
+
+// RotateSecret genera un secreto de firma nuevo y lo devuelve.
+// POST /api/v1/admin/webhooks/:id/rotate-secret
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de webhook inválido")
+	if !ok {
+		return
+	}
+	sub, err := h.WebhookService.RotateSecret(requestActor(c), id)
+	if err != nil {
+		respondWebhookError(c, err, "Error al rotar el secreto del webhook")
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// ListDeliveries devuelve el registro de entregas de una suscripción.
+// GET /api/v1/admin/webhooks/:id/deliveries?status=pending|succeeded|dead&page=&page_size=
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de webhook inválido")
+	if !ok {
+		return
+	}
+	filter := services.WebhookDeliveryFilter{Status: c.Query("status")}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	page, err := h.WebhookService.ListDeliveries(id, filter)
+	if err != nil {
+		respondWebhookError(c, err, "Error al obtener las entregas del webhook")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// parseDeliveryParams lee los parámetros :id y :deliveryId.
+func parseDeliveryParams(c *gin.Context) (uint, uint, bool) {
+	id, ok := parseIDParam(c, "ID de webhook inválido")
+	if !ok {
+		return 0, 0, false
+	}
+	deliveryID, err := strconv.ParseUint(c.Param("deliveryId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de entrega inválido"})
+		return 0, 0, false
+	}
+	return id, uint(deliveryID), true
+}
+
+// GetDelivery devuelve una entrega con el detalle de cada intento.
+// GET /api/v1/admin/webhooks/:id/deliveries/:deliveryId
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, deliveryID, ok := parseDeliveryParams(c)
+	if !ok {
+		return
+	}
+	delivery, err := h.WebhookService.GetDelivery(id, deliveryID)
+	if err != nil {
+		respondWebhookError(c, err, "Error al obtener la entrega")
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+// Redeliver vuelve a enviar el evento de una entrega.
+// POST /api/v1/admin/webhooks/:id/deliveries/:deliveryId/redeliver
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, deliveryID, ok := parseDeliveryParams(c)
+	if !ok {
+		return
+	}
+	delivery, err := h.WebhookService.Redeliver(requestActor(c), id, deliveryID)
+	if err != nil {
+		respondWebhookError(c, err, "Error al reenviar la entrega")
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// RegisterAdminWebhookRoutes registra las rutas de webhooks bajo el grupo /admin.
+func (h *WebhookHandler) RegisterAdminWebhookRoutes(rg *gin.RouterGroup) {
+	webhookRoutes := rg.Group("/webhooks")
+	{
+		webhookRoutes.GET("/event-types", h.ListEventTypes)
+		webhookRoutes.GET("", h.ListWebhooks)
+		webhookRoutes.POST("", h.CreateWebhook)
+		webhookRoutes.GET("/:id", h.GetWebhook)
+		webhookRoutes.PUT("/:id", h.UpdateWebhook)
+		webhookRoutes.DELETE("/:id", h.DeleteWebhook)
+		webhookRoutes.POST("/:id/rotate-secret", h.RotateSecret)
+		webhookRoutes.GET("/:id/deliveries", h.ListDeliveries)
+		webhookRoutes.GET("/:id/deliveries/:deliveryId", h.GetDelivery)
+		webhookRoutes.POST("/:id/deliveries/:deliveryId/redeliver", h.Redeliver)
+	}
+}
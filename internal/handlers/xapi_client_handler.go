@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ListClients lista los clientes del LRS.
+// GET /api/v1/admin/xapi-clients
+func (h *XAPIHandler) ListClients(c *gin.Context) {
+	clients, err := h.XAPIService.ListClients()
+	if err != nil {
+		respondXAPIError(c, err, "Error al obtener los clientes xAPI")
+		return
+	}
+	c.JSON(http.StatusOK, clients)
+}
+
+// GetClient devuelve un cliente (sin el secreto).
+// GET /api/v1/admin/xapi-clients/:id
+func (h *XAPIHandler) GetClient(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cliente inválido")
+	if !ok {
+		return
+	}
+	client, err := h.XAPIService.GetClient(id)
+	if err != nil {
+		respondXAPIError(c, err, "Error al obtener el cliente xAPI")
+		return
+	}
+	c.JSON(http.StatusOK, client)
+}
+
+// CreateClient crea un cliente. La respuesta incluye la clave y el secreto para HTTP Basic;
+// el secreto no vuelve a mostrarse.
+// POST /api/v1/admin/xapi-clients
+func (h *XAPIHandler) CreateClient(c *gin.Context) {
+	var dto services.XAPIClientDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	client, err := h.XAPIService.CreateClient(requestActor(c), dto)
+	if err != nil {
+		respondXAPIError(c, err, "Error al crear el cliente xAPI")
+		return
+	}
+	c.JSON(http.StatusCreated, client)
+}
+
+// UpdateClient cambia el nombre o el estado de un cliente.
+// PUT /api/v1/admin/xapi-clients/:id
+func (h *XAPIHandler) UpdateClient(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cliente inválido")
+	if !ok {
+		return
+	}
+	var dto services.XAPIClientDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	client, err := h.XAPIService.UpdateClient(requestActor(c), id, dto)
+	if err != nil {
+		respondXAPIError(c, err, "Error al actualizar el cliente xAPI")
+		return
+	}
+	c.JSON(http.StatusOK, client)
+}
+
+// DeleteClient elimina un cliente.
+// DELETE /api/v1/admin/xapi-clients/:id
+func (h *XAPIHandler) DeleteClient(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cliente inválido")
+	if !ok {
+		return
+	}
+	if err := h.XAPIService.DeleteClient(requestActor(c), id); err != nil {
+		respondXAPIError(c, err, "Error al eliminar el cliente xAPI")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Cliente xAPI eliminado exitosamente"})
+}
+
+// RotateClientSecret genera un secreto nuevo y lo devuelve.
+// POST /api/v1/admin/xapi-clients/:id/rotate-secret
+func (h *XAPIHandler) RotateClientSecret(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cliente inválido")
+	if !ok {
+		return
+	}
+	client, err := h.XAPIService.RotateClientSecret(requestActor(c), id)
+	if err != nil {
+		respondXAPIError(c, err, "Error al rotar el secreto del cliente xAPI")
+		return
+	}
+	c.JSON(http.StatusOK, client)
+}
+
+// ListActivityLinks lista las actividades del cliente vinculadas a lecciones.
+// GET /api/v1/admin/xapi-clients/:id/activities
+func (h *XAPIHandler) ListActivityLinks(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cliente inválido")
+	if !ok {
+		return
+	}
+	links, err := h.XAPIService.ListActivityLinks(id)
+	if err != nil {
+		respondXAPIError(c, err, "Error al obtener las actividades del cliente xAPI")
+		return
+	}
+	c.JSON(http.StatusOK, links)
+}
+
+// LinkActivity vincula una actividad del cliente a una lección.
+// POST /api/v1/admin/xapi-clients/:id/activities
+func (h *XAPIHandler) LinkActivity(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cliente inválido")
+	if !ok {
+		return
+	}
+	var dto services.XAPIActivityLinkDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida", "details": err.Error()})
+		return
+	}
+	link, err := h.XAPIService.LinkActivity(requestActor(c), id, dto)
+	if err != nil {
+		respondXAPIError(c, err, "Error al vincular la actividad")
+		return
+	}
+	c.JSON(http.StatusCreated, link)
+}
+
+// UnlinkActivity quita el vínculo de una actividad.
+// DELETE /api/v1/admin/xapi-clients/:id/activities/:linkId
+func (h *XAPIHandler) UnlinkActivity(c *gin.Context) {
+	id, ok := parseIDParam(c, "ID de cliente inválido")
+	if !ok {
+		return
+	}
+	linkID, err := strconv.ParseUint(c.Param("linkId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de vínculo inválido"})
+		return
+	}
+	if err := h.XAPIService.UnlinkActivity(requestActor(c), id, uint(linkID)); err != nil {
+		respondXAPIError(c, err, "Error al desvincular la actividad")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Actividad desvinculada exitosamente"})
+}
+
+// RegisterAdminXAPIRoutes registra la administración de clientes xAPI bajo el grupo /admin.
+func (h *XAPIHandler) RegisterAdminXAPIRoutes(rg *gin.RouterGroup) {
+	clientRoutes := rg.Group("/xapi-clients")
+	{
+		clientRoutes.GET("", h.ListClients)
+		clientRoutes.POST("", h.CreateClient)
+		clientRoutes.GET("/:id", h.GetClient)
+		clientRoutes.PUT("/:id", h.UpdateClient)
+		clientRoutes.DELETE("/:id", h.DeleteClient)
+		clientRoutes.POST("/:id/rotate-secret", h.RotateClientSecret)
+		clientRoutes.GET("/:id/activities", h.ListActivityLinks)
+		clientRoutes.POST("/:id/activities", h.LinkActivity)
+		clientRoutes.DELETE("/:id/activities/:linkId", h.UnlinkActivity)
+	}
+}
@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	xapiVersionHeader    = "X-Experience-API-Version"
+	xapiConsistentHeader = "X-Experience-API-Consistent-Through"
+	xapiClientContextKey = "xapi_client"
+
+	maxXAPIStatementsBodyBytes = 5 << 20
+	maxXAPIDocumentBytes       = 2 << 20
+)
+
+// XAPIHandler expone el LRS xAPI (declaraciones, estado y perfiles de actividad) y la
+// administración de sus clientes.
+type XAPIHandler struct {
+	XAPIService services.XAPIServiceInterface
+}
+
+// NewXAPIHandler crea una nueva instancia de XAPIHandler.
+func NewXAPIHandler(xapiService services.XAPIServiceInterface) *XAPIHandler {
+	return &XAPIHandler{XAPIService: xapiService}
+}
+
+// respondXAPIError traduce los errores del servicio xAPI a códigos HTTP.
+func respondXAPIError(c *gin.Context, err error, fallback string) {
+	var validationErr *services.XAPIValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch err.Error() {
+	case "cliente xAPI no encontrado", "actividad vinculada no encontrada", "lección no encontrada",
+		"declaración no encontrada", "documento no encontrado":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "no se puede anular una declaración de otro cliente":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "ya existe una declaración con ese id y otro contenido",
+		"el documento ya existe: envíe If-Match o If-None-Match para modificarlo",
+		"la actividad ya está vinculada a una lección":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "el documento cambió: la condición If-Match o If-None-Match no se cumple":
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	default:
+		if strings.HasPrefix(err.Error(), "la actividad") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// authenticateClient exige la versión de xAPI y las credenciales HTTP Basic de un cliente
+// activo, y deja el cliente en el contexto.
+func (h *XAPIHandler) authenticateClient(c *gin.Context) {
+	c.Header(xapiVersionHeader, models.XAPIVersion)
+	if version := c.GetHeader(xapiVersionHeader); version != "1.0" && !strings.HasPrefix(version, "1.0.") {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "falta el encabezado X-Experience-API-Version o la versión no está soportada"})
+		return
+	}
+	key, secret, ok := c.Request.BasicAuth()
+	if !ok {
+		c.Header("WWW-Authenticate", `Basic realm="xAPI"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "falta la autenticación HTTP Basic del cliente xAPI"})
+		return
+	}
+	client, err := h.XAPIService.AuthenticateClient(key, secret)
+	if err != nil {
+		if err.Error() == "credenciales xAPI inválidas" {
+			c.Header("WWW-Authenticate", `Basic realm="xAPI"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		} else {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error interno del servidor"})
+		}
+		return
+	}
+	c.Set(xapiClientContextKey, client)
+	c.Next()
+}
+
+// xapiClient recupera el cliente autenticado por authenticateClient.
+func xapiClient(c *gin.Context) *models.XAPIClient {
+	client, _ := c.MustGet(xapiClientContextKey).(*models.XAPIClient)
+	return client
+}
+
+// readXAPIBody lee el cuerpo hasta limit bytes; si lo supera responde 413 y devuelve false.
+func readXAPIBody(c *gin.Context, limit int64) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "El cuerpo supera el tamaño máximo permitido"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el cuerpo de la solicitud"})
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+// readStatementsBody lee las declaraciones de PUT y POST. Los adjuntos en multipart no
+// están soportados.
+func readStatementsBody(c *gin.Context) ([]byte, bool) {
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "el LRS no admite adjuntos en multipart; use fileUrl"})
+		return nil, false
+	}
+	return readXAPIBody(c, maxXAPIStatementsBodyBytes)
+}
+
+// About informa las versiones de xAPI soportadas. No requiere autenticación.
+// GET /api/v1/xapi/about
+func (h *XAPIHandler) About(c *gin.Context) {
+	c.Header(xapiVersionHeader, models.XAPIVersion)
+	c.JSON(http.StatusOK, gin.H{"version": []string{models.XAPIVersion}})
+}
+
+// PutStatement guarda una declaración con el ID indicado.
+// PUT /api/v1/xapi/statements?statementId=
+func (h *XAPIHandler) PutStatement(c *gin.Context) {
+	statementID := c.Query("statementId")
+	if statementID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "falta el parámetro statementId"})
+		return
+	}
+	body, ok := readStatementsBody(c)
+	if !ok {
+		return
+	}
+	if _, err := h.XAPIService.StoreStatements(xapiClient(c), body, statementID); err != nil {
+		respondXAPIError(c, err, "Error al guardar la declaración")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PostStatements guarda una declaración o un lote y devuelve sus IDs.
+// POST /api/v1/xapi/statements
+func (h *XAPIHandler) PostStatements(c *gin.Context) {
+	body, ok := readStatementsBody(c)
+	if !ok {
+		return
+	}
+	ids, err := h.XAPIService.StoreStatements(xapiClient(c), body, "")
+	if err != nil {
+		respondXAPIError(c, err, "Error al guardar las declaraciones")
+		return
+	}
+	c.JSON(http.StatusOK, ids)
+}
+
+// parseXAPITime lee un parámetro de fecha ISO 8601; si es inválido responde 400.
+func parseXAPITime(c *gin.Context, name string) (*time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	value, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parámetro inválido: " + name + " debe tener formato ISO 8601 con zona horaria"})
+		return nil, false
+	}
+	return &value, true
+}
+
+// parseXAPIBool lee un parámetro booleano ("true" o "false"); si es inválido responde 400.
+func parseXAPIBool(c *gin.Context, name string) (bool, bool) {
+	switch c.Query(name) {
+	case "", "false":
+		return false, true
+	case "true":
+		return true, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "parámetro inválido: " + name + " debe ser true o false"})
+	return false, false
+}
+
+// GetStatements devuelve una declaración (statementId o voidedStatementId) o las que
+// cumplen los filtros, paginadas con la URL "more".
+// GET /api/v1/xapi/statements?agent=&verb=&activity=&registration=&related_activities=&related_agents=&since=&until=&limit=&ascending=&format=
+func (h *XAPIHandler) GetStatements(c *gin.Context) {
+	client := xapiClient(c)
+	c.Header(xapiConsistentHeader, time.Now().UTC().Format(time.RFC3339Nano))
+	if attachments, ok := parseXAPIBool(c, "attachments"); !ok {
+		return
+	} else if attachments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "el LRS no admite adjuntos en multipart; use fileUrl"})
+		return
+	}
+
+	statementID, voidedID := c.Query("statementId"), c.Query("voidedStatementId")
+	if statementID != "" || voidedID != "" {
+		for name := range c.Request.URL.Query() {
+			if name != "statementId" && name != "voidedStatementId" && name != "format" && name != "attachments" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "parámetro inválido: " + name + " no se puede combinar con statementId"})
+				return
+			}
+		}
+		if statementID != "" && voidedID != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parámetro inválido: use statementId o voidedStatementId, no ambos"})
+			return
+		}
+		id := statementID
+		if voidedID != "" {
+			id = voidedID
+		}
+		statement, err := h.XAPIService.GetStatement(client, id, voidedID != "", c.Query("format"))
+		if err != nil {
+			respondXAPIError(c, err, "Error al obtener la declaración")
+			return
+		}
+		c.Header("Last-Modified", statement.Stored.UTC().Format(http.TimeFormat))
+		c.Data(http.StatusOK, "application/json", statement.Statement)
+		return
+	}
+
+	query := services.XAPIStatementQuery{
+		Agent:        c.Query("agent"),
+		Verb:         c.Query("verb"),
+		Activity:     c.Query("activity"),
+		Registration: c.Query("registration"),
+		Format:       c.Query("format"),
+	}
+	var ok bool
+	if query.Since, ok = parseXAPITime(c, "since"); !ok {
+		return
+	}
+	if query.Until, ok = parseXAPITime(c, "until"); !ok {
+		return
+	}
+	if query.RelatedActivities, ok = parseXAPIBool(c, "related_activities"); !ok {
+		return
+	}
+	if query.RelatedAgents, ok = parseXAPIBool(c, "related_agents"); !ok {
+		return
+	}
+	if query.Ascending, ok = parseXAPIBool(c, "ascending"); !ok {
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parámetro inválido: limit debe ser un entero no negativo"})
+			return
+		}
+		query.Limit = limit
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parámetro inválido: cursor"})
+			return
+		}
+		query.Cursor = uint(cursor)
+	}
+
+	result, err := h.XAPIService.QueryStatements(client, query)
+	if err != nil {
+		respondXAPIError(c, err, "Error al obtener las declaraciones")
+		return
+	}
+	if result.NextCursor != 0 {
+		// "more" repite la consulta con el cursor de la página siguiente.
+		params := c.Request.URL.Query()
+		params.Set("cursor", strconv.FormatUint(uint64(result.NextCursor), 10))
+		result.More = (&url.URL{Path: c.Request.URL.Path, RawQuery: params.Encode()}).String()
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// xapiDocumentKey lee los parámetros de la clave de un documento.
+func xapiDocumentKey(c *gin.Context, kind string) services.XAPIDocumentKey {
+	key := services.XAPIDocumentKey{Kind: kind, ActivityID: c.Query("activityId")}
+	if kind == models.XAPIDocumentState {
+		key.Agent = c.Query("agent")
+		key.Registration = c.Query("registration")
+		key.DocumentID = c.Query("stateId")
+	} else {
+		key.DocumentID = c.Query("profileId")
+	}
+	return key
+}
+
+// getDocument devuelve un documento o, sin stateId/profileId, la lista de sus IDs.
+// GET /api/v1/xapi/activities/state y /api/v1/xapi/activities/profile
+func (h *XAPIHandler) getDocument(kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := xapiDocumentKey(c, kind)
+		if key.DocumentID == "" {
+			since, ok := parseXAPITime(c, "since")
+			if !ok {
+				return
+			}
+			ids, err := h.XAPIService.ListDocumentIDs(xapiClient(c), key, since)
+			if err != nil {
+				respondXAPIError(c, err, "Error al obtener los documentos")
+				return
+			}
+			c.JSON(http.StatusOK, ids)
+			return
+		}
+		document, err := h.XAPIService.GetDocument(xapiClient(c), key)
+		if err != nil {
+			respondXAPIError(c, err, "Error al obtener el documento")
+			return
+		}
+		c.Header("ETag", `"`+document.ETag+`"`)
+		c.Header("Last-Modified", document.UpdatedAt.UTC().Format(http.TimeFormat))
+		c.Data(http.StatusOK, document.ContentType, document.Content)
+	}
+}
+
+// saveDocument guarda un documento: PUT lo reemplaza y POST combina objetos JSON.
+// PUT|POST /api/v1/xapi/activities/state y /api/v1/xapi/activities/profile
+func (h *XAPIHandler) saveDocument(kind string, merge bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, ok := readXAPIBody(c, maxXAPIDocumentBytes)
+		if !ok {
+			return
+		}
+		cond := services.XAPIPrecondition{IfMatch: c.GetHeader("If-Match"), IfNoneMatch: c.GetHeader("If-None-Match")}
+		document, err := h.XAPIService.SaveDocument(xapiClient(c), xapiDocumentKey(c, kind), body, c.GetHeader("Content-Type"), cond, merge)
+		if err != nil {
+			respondXAPIError(c, err, "Error al guardar el documento")
+			return
+		}
+		c.Header("ETag", `"`+document.ETag+`"`)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// deleteDocument borra un documento o, en el estado y sin stateId, todos los del agente.
+// DELETE /api/v1/xapi/activities/state y /api/v1/xapi/activities/profile
+func (h *XAPIHandler) deleteDocument(kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cond := services.XAPIPrecondition{IfMatch: c.GetHeader("If-Match"), IfNoneMatch: c.GetHeader("If-None-Match")}
+		if err := h.XAPIService.DeleteDocuments(xapiClient(c), xapiDocumentKey(c, kind), cond); err != nil {
+			respondXAPIError(c, err, "Error al eliminar el documento")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RegisterXAPIRoutes registra el LRS bajo /xapi. Usa sus propias credenciales (HTTP Basic
+// por cliente), no el JWT de los usuarios.
+func (h *XAPIHandler) RegisterXAPIRoutes(rg *gin.RouterGroup) {
+	xapiRoutes := rg.Group("/xapi")
+	xapiRoutes.GET("/about", h.About)
+
+	lrsRoutes := xapiRoutes.Group("")
+	lrsRoutes.Use(h.authenticateClient)
+	{
+		lrsRoutes.PUT("/statements", h.PutStatement)
+		lrsRoutes.POST("/statements", h.PostStatements)
+		lrsRoutes.GET("/statements", h.GetStatements)
+
+		lrsRoutes.GET("/activities/state", h.getDocument(models.XAPIDocumentState))
+		lrsRoutes.PUT("/activities/state", h.saveDocument(models.XAPIDocumentState, false))
+		lrsRoutes.POST("/activities/state", h.saveDocument(models.XAPIDocumentState, true))
+		lrsRoutes.DELETE("/activities/state", h.deleteDocument(models.XAPIDocumentState))
+
+		lrsRoutes.GET("/activities/profile", h.getDocument(models.XAPIDocumentActivityProfile))
+		lrsRoutes.PUT("/activities/profile", h.saveDocument(models.XAPIDocumentActivityProfile, false))
+		lrsRoutes.POST("/activities/profile", h.saveDocument(models.XAPIDocumentActivityProfile, true))
+		lrsRoutes.DELETE("/activities/profile", h.deleteDocument(models.XAPIDocumentActivityProfile))
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/Unikyri/yamerito-mvp/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// storeErrorXAPIService autentica a cualquier cliente y responde a las declaraciones con
+// el error indicado.
+type storeErrorXAPIService struct {
+	services.XAPIServiceInterface
+	err error
+}
+
+func (f *storeErrorXAPIService) AuthenticateClient(key, secret string) (*models.XAPIClient, error) {
+	return &models.XAPIClient{ID: 1}, nil
+}
+
+func (f *storeErrorXAPIService) StoreStatements(client *models.XAPIClient, body []byte, statementID string) ([]string, error) {
+	return nil, f.err
+}
+
+func TestStoreStatementErrorStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"mismo id con otro contenido", errors.New("ya existe una declaración con ese id y otro contenido"), http.StatusConflict},
+		{"anulación de otro cliente", errors.New("no se puede anular una declaración de otro cliente"), http.StatusForbidden},
+		{"declaración inválida", &services.XAPIValidationError{Reason: "declaración inválida: no se puede anular una declaración de anulación"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			NewXAPIHandler(&storeErrorXAPIService{err: tt.err}).RegisterXAPIRoutes(router.Group(""))
+
+			requests := []*http.Request{
+				httptest.NewRequest(http.MethodPut, "/xapi/statements?statementId=3f2504e0-4f89-41d3-9a0c-0305e82c3301", strings.NewReader(`{}`)),
+				httptest.NewRequest(http.MethodPost, "/xapi/statements", strings.NewReader(`{}`)),
+			}
+			for _, req := range requests {
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set(xapiVersionHeader, "1.0.3")
+				req.SetBasicAuth("clave", "secreto")
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+				if rec.Code != tt.want {
+					t.Errorf("%s: status = %d, se esperaba %d", req.Method, rec.Code, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Package imaging valida, normaliza y redimensiona imágenes subidas por los usuarios
+// usando solo la biblioteca estándar. Toda imagen se vuelve a codificar, por lo que los
+// metadatos originales (EXIF, GPS, perfiles, comentarios) nunca se conservan.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	_ "image/gif" // Registra el decodificador GIF
+	_ "image/png" // Registra el decodificador PNG
+)
+
+var (
+	// ErrUnsupportedFormat indica que el contenido no es una imagen JPEG, PNG o GIF.
+	ErrUnsupportedFormat = errors.New("formato de imagen no soportado (use JPEG, PNG o GIF)")
+	// ErrTooLarge indica que la imagen excede las dimensiones máximas permitidas.
+	ErrTooLarge = errors.New("la imagen excede las dimensiones máximas permitidas")
+)
+
+// MaxPixels limita el tamaño de imagen decodificado (ancho × alto) para evitar que un
+// archivo pequeño pero muy comprimido consuma demasiada memoria al decodificarse.
+const MaxPixels = 40_000_000
+
+// allowedContentTypes son los tipos aceptados, detectados a partir del contenido y no
+// del encabezado Content-Type ni de la extensión que envía el cliente.
+var allowedContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// SniffContentType detecta el tipo real de la imagen a partir de sus primeros bytes.
+func SniffContentType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !allowedContentTypes[contentType] {
+		return "", ErrUnsupportedFormat
+	}
+	return contentType, nil
+}
+
+// Decode valida el tipo y las dimensiones, decodifica la imagen y aplica la orientación
+// EXIF de las fotos JPEG (las cámaras de los móviles suelen guardar la foto girada).
+func Decode(data []byte) (image.Image, error) {
+	contentType, err := SniffContentType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if contentType == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	return img, nil
+}
+
+// Thumbnail recorta la imagen al cuadrado central y la reduce a size×size píxeles.
+// Si la imagen es más pequeña no se amplía.
+func Thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	crop := image.Rect(x0, y0, x0+side, y0+side)
+
+	if side < size {
+		size = side
+	}
+	return resizeBox(toRGBA(img, crop), size, size)
+}
+
+// EncodeJPEG codifica la imagen como JPEG. Las zonas transparentes se rellenan de blanco.
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	b := img.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(canvas, canvas.Bounds(), img, b.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, canvas, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// toRGBA copia la región r de img a una imagen RGBA con origen en (0, 0).
+func toRGBA(img image.Image, r image.Rectangle) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
+
+// resizeBox reduce src a w×h promediando todos los píxeles de origen que caen en cada
+// píxel de destino (filtro de caja). Da buenos resultados al reducir, que es el único
+// caso que se usa aquí.
+func resizeBox(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if sw == w && sh == h {
+		copy(dst.Pix, src.Pix)
+		return dst
+	}
+
+	for y := 0; y < h; y++ {
+		sy0 := y * sh / h
+		sy1 := (y + 1) * sh / h
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+		for x := 0; x < w; x++ {
+			sx0 := x * sw / w
+			sx1 := (x + 1) * sw / w
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := sx0; sx < sx1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+			d := dst.Pix[y*dst.Stride+x*4 : y*dst.Stride+x*4+4]
+			d[0] = uint8(r / n)
+			d[1] = uint8(g / n)
+			d[2] = uint8(b / n)
+			d[3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,158 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// exifJPEG codifica una imagen de 40×20 con el cuadrante superior izquierdo rojo y el resto
+// azul, e inserta un bloque APP1 con la orientación EXIF indicada.
+func exifJPEG(t *testing.T, orientation uint16) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{B: 255, A: 255}
+			if x < 10 && y < 10 {
+				c = color.RGBA{R: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0) // Relleno del valor y fin de la lista de IFD
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(payload)+2))
+	app1 = append(app1, payload...)
+
+	encoded := buf.Bytes()
+	out := append([]byte{}, encoded[:2]...)
+	out = append(out, app1...)
+	return append(out, encoded[2:]...)
+}
+
+// jpegMarkers lista los marcadores de los segmentos anteriores a los datos de imagen.
+func jpegMarkers(data []byte) []byte {
+	var markers []byte
+	for pos := 2; pos+4 <= len(data) && data[pos] == 0xFF; {
+		marker := data[pos+1]
+		markers = append(markers, marker)
+		if marker == 0xDA {
+			break
+		}
+		pos += 2 + int(binary.BigEndian.Uint16(data[pos+2:pos+4]))
+	}
+	return markers
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func TestDecodeAppliesExifOrientation(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation uint16
+		red         image.Point // Un píxel del cuadrante rojo una vez orientada la imagen
+		blue        image.Point
+	}{
+		{"girada 90°", 6, image.Pt(17, 2), image.Pt(2, 2)},
+		{"girada 270°", 8, image.Pt(2, 37), image.Pt(2, 2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := exifJPEG(t, tt.orientation)
+			if got := jpegOrientation(data); got != int(tt.orientation) {
+				t.Fatalf("jpegOrientation() = %d, se esperaba %d", got, tt.orientation)
+			}
+
+			img, err := Decode(data)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != 20 || b.Dy() != 40 {
+				t.Fatalf("dimensiones %dx%d, se esperaba 20x40", b.Dx(), b.Dy())
+			}
+			if !isRed(img.At(tt.red.X, tt.red.Y)) || isRed(img.At(tt.blue.X, tt.blue.Y)) {
+				t.Errorf("la imagen no quedó orientada: %v en %v, %v en %v",
+					img.At(tt.red.X, tt.red.Y), tt.red, img.At(tt.blue.X, tt.blue.Y), tt.blue)
+			}
+
+			out, err := EncodeJPEG(img, 85)
+			if err != nil {
+				t.Fatalf("EncodeJPEG: %v", err)
+			}
+			if bytes.IndexByte(jpegMarkers(out), 0xE1) >= 0 || bytes.Contains(out, []byte("Exif\x00\x00")) {
+				t.Error("la imagen codificada conserva el bloque APP1")
+			}
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != 20 || cfg.Height != 40 {
+				t.Errorf("la imagen codificada mide %dx%d, se esperaba 20x40", cfg.Width, cfg.Height)
+			}
+
+			thumb := Thumbnail(img, 16)
+			if b := thumb.Bounds(); b.Dx() != 16 || b.Dy() != 16 {
+				t.Errorf("miniatura de %dx%d, se esperaba 16x16", b.Dx(), b.Dy())
+			}
+			if b := Thumbnail(img, 64).Bounds(); b.Dx() != 20 || b.Dy() != 20 {
+				t.Errorf("miniatura de %dx%d, no debe ampliarse más allá de 20x20", b.Dx(), b.Dy())
+			}
+		})
+	}
+}
+
+// pngHeader arma un PNG que solo declara sus dimensiones en IHDR.
+func pngHeader(width, height uint32) []byte {
+	ihdr := []byte("IHDR")
+	ihdr = binary.BigEndian.AppendUint32(ihdr, width)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, height)
+	ihdr = append(ihdr, 8, 2, 0, 0, 0) // 8 bits, RGB, sin entrelazado
+
+	out := []byte("\x89PNG\r\n\x1a\n")
+	out = binary.BigEndian.AppendUint32(out, uint32(len(ihdr)-4))
+	out = append(out, ihdr...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(ihdr))
+}
+
+func TestDecodeRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"texto", []byte("hola, esto no es una imagen"), ErrUnsupportedFormat},
+		{"PDF", []byte("%PDF-1.4\n%âãÏÓ\n1 0 obj\n"), ErrUnsupportedFormat},
+		{"JPEG truncado", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}, ErrUnsupportedFormat},
+		{"demasiados píxeles", pngHeader(8000, 8000), ErrTooLarge},
+		{"sin ancho", pngHeader(0, 10), ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("Decode() error = %v, se esperaba %v", err, tt.want)
+			}
+		})
+	}
+}
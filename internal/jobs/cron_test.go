@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func bitsOf(values ...int) uint64 {
+	var bits uint64
+	for _, v := range values {
+		bits |= 1 << uint(v)
+	}
+	return bits
+}
+
+func bitsRange(lo, hi int) uint64 {
+	var bits uint64
+	for v := lo; v <= hi; v++ {
+		bits |= 1 << uint(v)
+	}
+	return bits
+}
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     uint64
+		invalid  bool
+	}{
+		{field: "*", min: 0, max: 59, want: bitsRange(0, 59)},
+		{field: "5", min: 0, max: 59, want: bitsOf(5)},
+		{field: "1-5", min: 0, max: 59, want: bitsRange(1, 5)},
+		{field: "1,15,30", min: 0, max: 59, want: bitsOf(1, 15, 30)},
+		{field: "*/20", min: 0, max: 59, want: bitsOf(0, 20, 40)},
+		{field: "*/5", min: 1, max: 12, want: bitsOf(1, 6, 11)},
+		{field: "8-18/4", min: 0, max: 23, want: bitsOf(8, 12, 16)},
+		{field: "5/20", min: 0, max: 59, want: bitsOf(5, 25, 45)},
+		{field: "1-3,10-12/2", min: 0, max: 59, want: bitsOf(1, 2, 3, 10, 12)},
+		{field: "0-7", min: 0, max: 7, want: bitsRange(0, 7)},
+		{field: "60", min: 0, max: 59, invalid: true},
+		{field: "0", min: 1, max: 31, invalid: true},
+		{field: "5-1", min: 0, max: 59, invalid: true},
+		{field: "1-", min: 0, max: 59, invalid: true},
+		{field: "-1", min: 0, max: 59, invalid: true},
+		{field: "*/0", min: 0, max: 59, invalid: true},
+		{field: "*/x", min: 0, max: 59, invalid: true},
+		{field: "a", min: 0, max: 59, invalid: true},
+		{field: "1,,2", min: 0, max: 59, invalid: true},
+		{field: "", min: 0, max: 59, invalid: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCronField(tt.field, tt.min, tt.max)
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("parseCronField(%q, %d, %d) = %b, se esperaba un error", tt.field, tt.min, tt.max, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseCronField(%q, %d, %d): error inesperado: %v", tt.field, tt.min, tt.max, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCronField(%q, %d, %d) = %b, se esperaba %b", tt.field, tt.min, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * 0 *",
+		"* * * * 8",
+		"@every 30s",
+		"@every 1x",
+		"@every",
+		"@often",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q): se esperaba un error", expr)
+		}
+	}
+}
+
+func TestParseCronSundayAlias(t *testing.T) {
+	s, err := ParseCron("0 0 * * 7")
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+	if s.dow&1 == 0 {
+		t.Error("7 debe aceptarse como domingo (0)")
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04:05", value)
+		if err != nil {
+			t.Fatalf("fecha de prueba inválida %q: %v", value, err)
+		}
+		return parsed
+	}
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want string // Vacío: sin próxima ejecución
+	}{
+		{"cada 15 minutos", "*/15 * * * *", "2025-06-10 10:07:30", "2025-06-10 10:15:00"},
+		{"estrictamente posterior", "*/15 * * * *", "2025-06-10 10:15:00", "2025-06-10 10:30:00"},
+		{"cambio de hora", "0 * * * *", "2025-06-10 10:59:59", "2025-06-10 11:00:00"},
+		{"cambio de día", "30 2 * * *", "2025-06-10 03:00:00", "2025-06-11 02:30:00"},
+		{"lista de horas", "0 8,12,18 * * *", "2025-06-10 12:00:00", "2025-06-10 18:00:00"},
+		{"rango con paso", "0 8-18/4 * * *", "2025-06-10 16:01:00", "2025-06-11 08:00:00"},
+		{"fin de mes", "30 2 1 * *", "2025-01-31 23:59:00", "2025-02-01 02:30:00"},
+		{"salta meses sin el día 31", "0 0 31 * *", "2025-02-01 00:00:00", "2025-03-31 00:00:00"},
+		{"fin de año", "0 0 1 1 *", "2025-12-31 23:59:30", "2026-01-01 00:00:00"},
+		{"mes posterior en el año siguiente", "0 12 * 3 *", "2025-11-10 00:00:00", "2026-03-01 12:00:00"},
+		{"29 de febrero", "0 0 29 2 *", "2025-03-01 00:00:00", "2028-02-29 00:00:00"},
+		{"fecha imposible", "0 0 31 2 *", "2025-01-01 00:00:00", ""},
+		{"días hábiles desde el viernes", "0 9 * * 1-5", "2025-01-03 18:00:00", "2025-01-06 09:00:00"},
+		{"días hábiles en el mismo día", "0 9 * * 1-5", "2025-01-06 08:00:00", "2025-01-06 09:00:00"},
+		{"domingo como 7", "0 0 * * 7", "2025-01-04 12:00:00", "2025-01-05 00:00:00"},
+		{"semana que cruza el mes", "0 6 * * 1", "2025-05-28 00:00:00", "2025-06-02 06:00:00"},
+		{"día del mes o de la semana", "0 0 13 * 5", "2025-06-01 00:00:00", "2025-06-06 00:00:00"},
+		{"día del mes con semana *", "0 0 13 * *", "2025-06-01 00:00:00", "2025-06-13 00:00:00"},
+		{"día de la semana con mes *", "0 0 * * 5", "2025-06-07 00:00:00", "2025-06-13 00:00:00"},
+		{"atajo @monthly", "@monthly", "2025-06-15 00:00:00", "2025-07-01 00:00:00"},
+		{"atajo @weekly", "@weekly", "2025-06-10 00:00:00", "2025-06-15 00:00:00"},
+		{"@every trunca al minuto", "@every 15m", "2025-06-10 10:07:30", "2025-06-10 10:22:00"},
+		{"@every en horas", "@every 2h", "2025-06-10 23:30:00", "2025-06-11 01:30:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			got := s.Next(at(tt.from))
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Fatalf("Next = %v, se esperaba ninguna ejecución", got)
+				}
+				return
+			}
+			if want := at(tt.want); !got.Equal(want) {
+				t.Fatalf("Next(%s) = %s, se esperaba %s", tt.from, got.Format(time.DateTime), want.Format(time.DateTime))
+			}
+		})
+	}
+}
+
+func TestCronNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC-3", -3*60*60)
+	s, err := ParseCron("0 9 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+	got := s.Next(time.Date(2025, 6, 10, 10, 0, 0, 0, loc))
+	if want := time.Date(2025, 6, 11, 9, 0, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Fatalf("Next = %v, se esperaba %v en la zona de entrada", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type scheduleDef struct {
+	name     string
+	cronExpr string
+	cron     *CronSchedule
+	jobType  string
+	payload  json.RawMessage
+}
+
+// Schedule declara una programación recurrente: cada vez que vence cron se encola un
+// trabajo jobType con payload. name identifica la programación en la tabla job_schedules;
+// si varias instancias la declaran, cada vencimiento se encola una sola vez. Las
+// ejecuciones perdidas mientras no había ningún proceso activo se agrupan en una.
+func (w *Worker) Schedule(name, cron, jobType string, payload interface{}) error {
+	parsed, err := ParseCron(cron)
+	if err != nil {
+		return err
+	}
+	def := &scheduleDef{name: name, cronExpr: cron, cron: parsed, jobType: jobType}
+	if payload != nil {
+		if def.payload, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("no se pudo serializar el payload de la programación %s: %w", name, err)
+		}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.schedules = append(w.schedules, def)
+	return nil
+}
+
+// next devuelve el siguiente vencimiento después de now.
+func (d *scheduleDef) next(now time.Time) time.Time {
+	next := d.cron.Next(now)
+	if next.IsZero() {
+		next = now.AddDate(100, 0, 0) // La expresión no vuelve a cumplirse
+	}
+	return next
+}
+
+func (w *Worker) scheduleDefs() []*scheduleDef {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]*scheduleDef(nil), w.schedules...)
+}
+
+// syncSchedules crea las filas de las programaciones declaradas y actualiza las que
+// cambiaron en el código. Conserva si un administrador las desactivó.
+func (w *Worker) syncSchedules(ctx context.Context) error {
+	now := time.Now()
+	for _, def := range w.scheduleDefs() {
+		var row models.JobSchedule
+		err := w.DB.WithContext(ctx).Where("name = ?", def.name).First(&row).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			row = models.JobSchedule{
+				Name:      def.name,
+				Cron:      def.cronExpr,
+				JobType:   def.jobType,
+				Payload:   def.payload,
+				Enabled:   true,
+				NextRunAt: def.next(now),
+			}
+			// Otra instancia pudo crearla a la vez.
+			if err := w.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+				return fmt.Errorf("no se pudo crear la programación %s: %w", def.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("no se pudo leer la programación %s: %w", def.name, err)
+		}
+		if row.Cron == def.cronExpr && row.JobType == def.jobType && bytes.Equal(row.Payload, def.payload) {
+			continue
+		}
+		updates := map[string]interface{}{"job_type": def.jobType, "payload": def.payload}
+		if row.Cron != def.cronExpr {
+			updates["cron"] = def.cronExpr
+			updates["next_run_at"] = def.next(now)
+		}
+		if err := w.DB.WithContext(ctx).Model(&row).Updates(updates).Error; err != nil {
+			return fmt.Errorf("no se pudo actualizar la programación %s: %w", def.name, err)
+		}
+	}
+	return nil
+}
+
+// enqueueDueSchedules encola un trabajo por cada programación vencida de este proceso.
+func (w *Worker) enqueueDueSchedules(ctx context.Context) error {
+	defs := w.scheduleDefs()
+	if len(defs) == 0 {
+		return nil
+	}
+	byName := make(map[string]*scheduleDef, len(defs))
+	names := make([]string, 0, len(defs))
+	for _, def := range defs {
+		byName[def.name] = def
+		names = append(names, def.name)
+	}
+
+	now := time.Now()
+	tx := w.DB.WithContext(ctx).Begin()
+	var due []models.JobSchedule
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("enabled = ? AND next_run_at <= ? AND name IN ?", true, now, names).
+		Find(&due).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range due {
+		row := &due[i]
+		def := byName[row.Name]
+		var payload interface{}
+		if len(row.Payload) > 0 {
+			payload = row.Payload
+		}
+		job, err := Enqueue(tx, row.JobType, payload, EnqueueOptions{ScheduleName: row.Name})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Model(row).Updates(map[string]interface{}{
+			"last_run_at": now,
+			"last_job_id": job.ID,
+			"next_run_at": def.next(now),
+		}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
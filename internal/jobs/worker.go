@@ -0,0 +1,436 @@
+// Package jobs implementa una cola de trabajos en segundo plano sobre la base de datos:
+// los trabajos se guardan en la tabla jobs, los procesos los reclaman con
+// SELECT ... FOR UPDATE SKIP LOCKED y los reintentan con espera exponencial. También
+// encola trabajos recurrentes según programaciones estilo cron (ver Worker.Schedule).
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultConcurrency  = 4
+	defaultPollInterval = 5 * time.Second
+	defaultDrainTimeout = 30 * time.Second
+	defaultMaxAttempts  = 5
+	defaultTimeout      = 5 * time.Minute
+	// leaseGrace se suma al tiempo máximo del trabajo para calcular LockedUntil.
+	leaseGrace  = time.Minute
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// HandlerFunc ejecuta un trabajo. Un error provoca un reintento, salvo que se envuelva
+// con Permanent o se agoten los intentos.
+type HandlerFunc func(ctx context.Context, job *models.Job) error
+
+// HandlerOptions configura un tipo de trabajo.
+type HandlerOptions struct {
+	Concurrency int           // Máximo de trabajos de este tipo a la vez en este proceso; 0 sin límite propio
+	MaxAttempts int           // Por defecto 5
+	Timeout     time.Duration // Tiempo máximo de cada intento; por defecto 5 minutos
+}
+
+type handler struct {
+	fn   HandlerFunc
+	opts HandlerOptions
+}
+
+// permanentError marca un error que no se arreglará reintentando.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent envuelve err para que el trabajo falle sin más reintentos.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// EnqueueOptions ajusta un trabajo al encolarlo.
+type EnqueueOptions struct {
+	RunAt        time.Time // Por defecto, de inmediato
+	Priority     int
+	MaxAttempts  int    // 0 usa el valor del tipo de trabajo
+	ScheduleName string // Programación que lo encola, si la hay
+}
+
+// Enqueue encola un trabajo usando tx, que puede ser la transacción del cambio que lo
+// origina: el trabajo solo existirá si esa transacción se confirma.
+func Enqueue(tx *gorm.DB, jobType string, payload interface{}, opts EnqueueOptions) (*models.Job, error) {
+	job := models.Job{
+		Type:         jobType,
+		Status:       models.JobQueued,
+		Priority:     opts.Priority,
+		RunAt:        opts.RunAt,
+		MaxAttempts:  opts.MaxAttempts,
+		ScheduleName: opts.ScheduleName,
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo serializar el trabajo %s: %w", jobType, err)
+		}
+		job.Payload = raw
+	}
+	if err := tx.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("no se pudo encolar el trabajo %s: %w", jobType, err)
+	}
+	return &job, nil
+}
+
+// Worker ejecuta los trabajos de los tipos registrados y encola los de las programaciones.
+// Pueden ejecutarse varios Worker (en el servidor y en cmd/worker) sobre la misma base de
+// datos: cada trabajo lo toma un único proceso.
+type Worker struct {
+	DB           *gorm.DB
+	ID           string        // Identifica al proceso en jobs.locked_by
+	Concurrency  int           // Trabajos simultáneos en total
+	PollInterval time.Duration // Cada cuánto se buscan trabajos si la cola estaba vacía
+	DrainTimeout time.Duration // Espera máxima a los trabajos en curso al apagar
+
+	mu        sync.Mutex
+	handlers  map[string]*handler
+	running   map[string]int
+	schedules []*scheduleDef
+	wake      chan struct{}
+}
+
+// NewWorker crea un Worker sin tipos registrados.
+func NewWorker(db *gorm.DB, concurrency int) *Worker {
+	host, _ := os.Hostname()
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	return &Worker{
+		DB:           db,
+		ID:           fmt.Sprintf("%s-%d", host, os.Getpid()),
+		Concurrency:  concurrency,
+		PollInterval: defaultPollInterval,
+		DrainTimeout: defaultDrainTimeout,
+		handlers:     map[string]*handler{},
+		running:      map[string]int{},
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// Handle registra la función que ejecuta los trabajos de jobType.
+func (w *Worker) Handle(jobType string, opts HandlerOptions, fn HandlerFunc) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[jobType] = &handler{fn: fn, opts: opts}
+}
+
+// Register registra un tipo de trabajo cuyo payload se decodifica como T. Un payload que
+// no se puede decodificar hace fallar el trabajo sin reintentos.
+func Register[T any](w *Worker, jobType string, opts HandlerOptions, fn func(ctx context.Context, payload T) error) {
+	w.Handle(jobType, opts, func(ctx context.Context, job *models.Job) error {
+		var payload T
+		if len(job.Payload) > 0 {
+			if err := json.Unmarshal(job.Payload, &payload); err != nil {
+				return Permanent(fmt.Errorf("payload inválido: %w", err))
+			}
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// Types devuelve los tipos de trabajo registrados.
+func (w *Worker) Types() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	types := make([]string, 0, len(w.handlers))
+	for jobType := range w.handlers {
+		types = append(types, jobType)
+	}
+	return types
+}
+
+// available devuelve los tipos que todavía admiten un trabajo más en este proceso.
+func (w *Worker) available() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var types []string
+	for jobType, h := range w.handlers {
+		if h.opts.Concurrency <= 0 || w.running[jobType] < h.opts.Concurrency {
+			types = append(types, jobType)
+		}
+	}
+	return types
+}
+
+func (w *Worker) handlerFor(jobType string) *handler {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.handlers[jobType]
+}
+
+func (w *Worker) track(jobType string, delta int) {
+	w.mu.Lock()
+	w.running[jobType] += delta
+	w.mu.Unlock()
+}
+
+// backoff devuelve la espera antes del intento número attempts+1.
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait
+}
+
+// claim toma el siguiente trabajo vencido de un tipo con capacidad libre. Devuelve nil si
+// no hay ninguno. Los trabajos que ya agotaron sus intentos se marcan como fallidos y se
+// sigue buscando, de modo que no dejen huecos libres hasta la siguiente consulta.
+func (w *Worker) claim(ctx context.Context) (*models.Job, *handler, error) {
+	for {
+		job, h, exhausted, err := w.claimNext(ctx)
+		if err != nil || !exhausted {
+			return job, h, err
+		}
+	}
+}
+
+// claimNext reclama el siguiente trabajo vencido. exhausted indica que el trabajo
+// encontrado había agotado sus intentos y quedó fallido en lugar de reclamarse.
+func (w *Worker) claimNext(ctx context.Context) (job *models.Job, h *handler, exhausted bool, err error) {
+	types := w.available()
+	if len(types) == 0 {
+		return nil, nil, false, nil
+	}
+	now := time.Now()
+	tx := w.DB.WithContext(ctx).Begin()
+	var found models.Job
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND run_at <= ? AND type IN ?", models.JobQueued, now, types).
+		Order("priority DESC, run_at, id").Limit(1).Find(&found)
+	if result.Error != nil {
+		tx.Rollback()
+		return nil, nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return nil, nil, false, nil
+	}
+
+	h = w.handlerFor(found.Type)
+	maxAttempts := found.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = h.opts.MaxAttempts
+	}
+	// Un trabajo que agotó los intentos sin registrar su resultado (el proceso murió en
+	// cada intento) no se vuelve a ejecutar.
+	if found.Attempts >= maxAttempts {
+		err := tx.Model(&found).Updates(map[string]interface{}{
+			"status":      models.JobFailed,
+			"finished_at": now,
+			"last_error":  "el trabajo se interrumpió en todos sus intentos",
+		}).Error
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, false, err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return nil, nil, false, err
+		}
+		log.Printf("Trabajo %d (%s) fallido: se interrumpió en sus %d intento(s).", found.ID, found.Type, found.Attempts)
+		return nil, nil, true, nil
+	}
+
+	lockedUntil := now.Add(h.opts.Timeout + leaseGrace)
+	found.Status = models.JobRunning
+	found.Attempts++
+	found.LockedBy = w.ID
+	found.LockedUntil = &lockedUntil
+	found.StartedAt = &now
+	if err := tx.Model(&found).Updates(map[string]interface{}{
+		"status":       found.Status,
+		"attempts":     found.Attempts,
+		"locked_by":    found.LockedBy,
+		"locked_until": lockedUntil,
+		"started_at":   now,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, false, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, false, err
+	}
+	return &found, h, false, nil
+}
+
+// call ejecuta el trabajo convirtiendo un pánico en error.
+func call(ctx context.Context, h *handler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pánico en el trabajo: %v", r)
+		}
+	}()
+	return h.fn(ctx, job)
+}
+
+// execute ejecuta un trabajo ya reclamado y registra el resultado. base se cancela si
+// el apagado no termina a tiempo; en ese caso el trabajo vuelve a la cola sin consumir
+// el intento.
+func (w *Worker) execute(base context.Context, job *models.Job, h *handler) {
+	ctx, cancel := context.WithTimeout(base, h.opts.Timeout)
+	start := time.Now()
+	err := call(ctx, h, job)
+	cancel()
+
+	now := time.Now()
+	updates := map[string]interface{}{"locked_by": "", "locked_until": nil}
+	switch {
+	case err == nil:
+		updates["status"] = models.JobSucceeded
+		updates["finished_at"] = now
+		updates["last_error"] = ""
+		log.Printf("Trabajo %d (%s) completado en %s.", job.ID, job.Type, now.Sub(start).Round(time.Millisecond))
+	case base.Err() != nil:
+		updates["status"] = models.JobQueued
+		updates["run_at"] = now
+		updates["attempts"] = job.Attempts - 1
+		updates["last_error"] = "interrumpido por el apagado del proceso"
+		log.Printf("Trabajo %d (%s) interrumpido por el apagado; vuelve a la cola.", job.ID, job.Type)
+	default:
+		maxAttempts := job.MaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = h.opts.MaxAttempts
+		}
+		updates["last_error"] = err.Error()
+		var permanent *permanentError
+		if errors.As(err, &permanent) || job.Attempts >= maxAttempts {
+			updates["status"] = models.JobFailed
+			updates["finished_at"] = now
+			log.Printf("Trabajo %d (%s) fallido tras %d intento(s): %v", job.ID, job.Type, job.Attempts, err)
+		} else {
+			updates["status"] = models.JobQueued
+			updates["run_at"] = now.Add(backoff(job.Attempts))
+			log.Printf("Error en el trabajo %d (%s), intento %d: %v", job.ID, job.Type, job.Attempts, err)
+		}
+	}
+	// Solo si sigue siendo nuestro: si la reserva venció, otro proceso pudo tomarlo.
+	result := w.DB.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, models.JobRunning, w.ID).
+		Updates(updates)
+	if result.Error != nil {
+		log.Printf("Error al registrar el resultado del trabajo %d: %v", job.ID, result.Error)
+	}
+}
+
+// requeueExpired devuelve a la cola los trabajos cuya reserva venció (el proceso que los
+// ejecutaba murió sin registrar el resultado).
+func (w *Worker) requeueExpired(ctx context.Context) error {
+	result := w.DB.WithContext(ctx).Model(&models.Job{}).
+		Where("status = ? AND locked_until < ?", models.JobRunning, time.Now()).
+		Updates(map[string]interface{}{
+			"status":       models.JobQueued,
+			"locked_by":    "",
+			"locked_until": nil,
+			"last_error":   "la reserva del trabajo venció sin resultado",
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("%d trabajo(s) con la reserva vencida vuelven a la cola.", result.RowsAffected)
+	}
+	return nil
+}
+
+func (w *Worker) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run ejecuta trabajos hasta que ctx se cancele. Entonces deja de tomar trabajos nuevos y
+// espera a los que están en curso durante DrainTimeout; pasado ese plazo cancela su
+// contexto y los devuelve a la cola. Run vuelve cuando ya no queda ninguno en curso.
+func (w *Worker) Run(ctx context.Context) {
+	if err := w.syncSchedules(ctx); err != nil {
+		log.Printf("Error al registrar las programaciones de trabajos: %v", err)
+	}
+
+	hardCtx, hardCancel := context.WithCancel(context.Background())
+	defer hardCancel()
+	slots := make(chan struct{}, w.Concurrency)
+	var wg sync.WaitGroup
+
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+	log.Printf("Procesador de trabajos %s iniciado (%d simultáneos).", w.ID, w.Concurrency)
+	for ctx.Err() == nil {
+		if err := w.requeueExpired(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error al recuperar trabajos con la reserva vencida: %v", err)
+		}
+		if err := w.enqueueDueSchedules(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error al encolar trabajos programados: %v", err)
+		}
+	fill:
+		for len(slots) < cap(slots) && ctx.Err() == nil {
+			job, h, err := w.claim(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Error al tomar un trabajo de la cola: %v", err)
+				}
+				break fill
+			}
+			if job == nil {
+				break fill
+			}
+			slots <- struct{}{}
+			w.track(job.Type, 1)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots; w.track(job.Type, -1); w.notify() }()
+				w.execute(hardCtx, job, h)
+			}()
+		}
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+
+	log.Printf("Procesador de trabajos %s: esperando a %d trabajo(s) en curso...", w.ID, len(slots))
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(w.DrainTimeout):
+		hardCancel()
+		<-done
+	}
+	log.Printf("Procesador de trabajos %s detenido.", w.ID)
+}
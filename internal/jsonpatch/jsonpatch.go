@@ -0,0 +1,293 @@
+// Package jsonpatch implementa JSON Merge Patch (RFC 7396) y JSON Patch (RFC 6902)
+// sobre documentos JSON genéricos, sin depender del tipo Go de destino.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Tipos de medio registrados para cada formato de parche.
+const (
+	MediaTypeMergePatch = "application/merge-patch+json"
+	MediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch indica que el documento de parche está mal formado.
+	ErrInvalidPatch = errors.New("parche inválido")
+	// ErrPathNotFound indica que una operación referencia una ruta inexistente.
+	ErrPathNotFound = errors.New("ruta inexistente")
+	// ErrTestFailed indica que una operación "test" no se cumplió.
+	ErrTestFailed = errors.New("la operación test no se cumplió")
+)
+
+// Operation es una operación de JSON Patch (RFC 6902, sección 4).
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"` // Vacío si no se envió; "null" es un valor válido
+}
+
+// MergePatch aplica un JSON Merge Patch (RFC 7396) sobre target.
+// Un null en el parche elimina el miembro correspondiente del documento.
+func MergePatch(target, patch []byte) ([]byte, error) {
+	var targetDoc interface{}
+	if len(bytes.TrimSpace(target)) > 0 {
+		if err := decode(target, &targetDoc); err != nil {
+			return nil, fmt.Errorf("documento destino inválido: %w", err)
+		}
+	}
+	var patchDoc interface{}
+	if err := decode(patch, &patchDoc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergeValue(targetDoc, patchDoc))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+			continue
+		}
+		targetObj[name] = mergeValue(targetObj[name], value)
+	}
+	return targetObj
+}
+
+// Apply aplica un JSON Patch (RFC 6902) sobre doc. Las operaciones se aplican en orden
+// y, si alguna falla, no se devuelve ningún resultado parcial.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var ops []Operation
+	if err := decode(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: se esperaba un arreglo de operaciones: %v", ErrInvalidPatch, err)
+	}
+
+	var current interface{}
+	if err := decode(doc, &current); err != nil {
+		return nil, fmt.Errorf("documento destino inválido: %w", err)
+	}
+
+	for i, op := range ops {
+		var err error
+		current, err = applyOperation(current, op)
+		if err != nil {
+			return nil, fmt.Errorf("operación %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(current)
+}
+
+func applyOperation(doc interface{}, op Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("%w: falta 'value'", ErrInvalidPatch)
+		}
+		var value interface{}
+		if err := decode(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: 'value' inválido: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			return replace(doc, path, value)
+		default:
+			actual, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(actual, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if from.isPrefixOf(path) {
+				return nil, fmt.Errorf("%w: no se puede mover un valor dentro de sí mismo", ErrInvalidPatch)
+			}
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		return add(doc, path, value)
+	default:
+		return nil, fmt.Errorf("%w: operación desconocida '%s'", ErrInvalidPatch, op.Op)
+	}
+}
+
+func add(doc interface{}, path pointer, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return set(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			idx, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("%w: '%s'", ErrPathNotFound, path)
+		}
+	})
+}
+
+func replace(doc interface{}, path pointer, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	if _, err := get(doc, path); err != nil {
+		return nil, err
+	}
+	return set(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			idx, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			node[idx] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("%w: '%s'", ErrPathNotFound, path)
+		}
+	})
+}
+
+func remove(doc interface{}, path pointer) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: no se puede eliminar la raíz del documento", ErrInvalidPatch)
+	}
+	return set(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := node[token]; !ok {
+				return nil, fmt.Errorf("%w: '%s'", ErrPathNotFound, path)
+			}
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			idx, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:idx], node[idx+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%w: '%s'", ErrPathNotFound, path)
+		}
+	})
+}
+
+// decode usa json.Number para no perder precisión al comparar números en "test".
+func decode(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("datos adicionales tras el valor JSON")
+	}
+	return nil
+}
+
+// equal compara dos valores JSON según la semántica de "test" (RFC 6902, sección 4.6).
+func equal(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			w, ok := bv[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okX := new(big.Rat).SetString(av.String())
+		y, okY := new(big.Rat).SetString(bv.String())
+		return okX && okY && x.Cmp(y) == 0
+	default:
+		return a == b
+	}
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(node))
+		for k, val := range node {
+			out[k] = deepCopy(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(node))
+		for i, val := range node {
+			out[i] = deepCopy(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,365 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// errorKind agrupa los errores como los traduce el servicio de usuarios: ErrInvalidPatch
+// es un 400 y una ruta inexistente o un "test" fallido son un 409. El 422 no nace aquí: lo
+// produce el servicio al validar el documento resultante (ver TestApplyResultNotValidated).
+type errorKind int
+
+const (
+	kindNone errorKind = iota
+	kindMalformed
+	kindConflict
+)
+
+func classify(err error) errorKind {
+	switch {
+	case err == nil:
+		return kindNone
+	case errors.Is(err, ErrInvalidPatch):
+		return kindMalformed
+	case errors.Is(err, ErrPathNotFound), errors.Is(err, ErrTestFailed):
+		return kindConflict
+	default:
+		return -1
+	}
+}
+
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var gotDoc, wantDoc interface{}
+	if err := json.Unmarshal(got, &gotDoc); err != nil {
+		t.Fatalf("resultado no es JSON válido: %v (%s)", err, got)
+	}
+	if err := json.Unmarshal([]byte(want), &wantDoc); err != nil {
+		t.Fatalf("documento esperado inválido: %v", err)
+	}
+	if !reflect.DeepEqual(gotDoc, wantDoc) {
+		t.Fatalf("resultado = %s, se esperaba %s", got, want)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		kind  errorKind
+	}{
+		// RFC 6902, apéndice A.
+		{
+			name:  "A.1 agregar miembro de objeto",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want:  `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:  "A.2 agregar elemento de arreglo",
+			doc:   `{"foo":["bar","baz"]}`,
+			patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:  `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:  "A.3 eliminar miembro de objeto",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"remove","path":"/baz"}]`,
+			want:  `{"foo":"bar"}`,
+		},
+		{
+			name:  "A.4 eliminar elemento de arreglo",
+			doc:   `{"foo":["bar","qux","baz"]}`,
+			patch: `[{"op":"remove","path":"/foo/1"}]`,
+			want:  `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:  "A.5 reemplazar un valor",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			want:  `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:  "A.6 mover un valor",
+			doc:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want:  `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:  "A.7 mover un elemento de arreglo",
+			doc:   `{"foo":["all","grass","cows","eat"]}`,
+			patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			want:  `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:  "A.8 test exitoso",
+			doc:   `{"baz":"qux","foo":["a",2,"c"]}`,
+			patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			want:  `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name:  "A.9 test fallido",
+			doc:   `{"baz":"qux"}`,
+			patch: `[{"op":"test","path":"/baz","value":"bar"}]`,
+			kind:  kindConflict,
+		},
+		{
+			name:  "A.10 agregar objeto anidado",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			want:  `{"foo":"bar","child":{"grandchild":{}}}`,
+		},
+		{
+			name:  "A.11 ignorar miembros desconocidos de la operación",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+			want:  `{"foo":"bar","baz":"qux"}`,
+		},
+		{
+			name:  "A.12 agregar bajo un destino inexistente",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			kind:  kindConflict,
+		},
+		{
+			// encoding/json se queda con el último "op" repetido: el "remove" de /baz falla.
+			name:  "A.13 operación con miembros repetidos",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux","op":"remove"}]`,
+			kind:  kindConflict,
+		},
+		{
+			name:  "A.14 orden de los escapes ~0 y ~1",
+			doc:   `{"/":9,"~1":10}`,
+			patch: `[{"op":"test","path":"/~01","value":10}]`,
+			want:  `{"/":9,"~1":10}`,
+		},
+		{
+			name:  "A.15 comparar texto con número",
+			doc:   `{"/":9,"~1":10}`,
+			patch: `[{"op":"test","path":"/~01","value":"10"}]`,
+			kind:  kindConflict,
+		},
+		{
+			name:  "A.16 agregar un arreglo al final con -",
+			doc:   `{"foo":["bar"]}`,
+			patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			want:  `{"foo":["bar",["abc","def"]]}`,
+		},
+
+		// Casos propios.
+		{
+			name:  "escape ~1 en la ruta",
+			doc:   `{"a/b":1}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":2}]`,
+			want:  `{"a/b":2}`,
+		},
+		{
+			name:  "reemplazar la raíz",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"replace","path":"","value":[1,2]}]`,
+			want:  `[1,2]`,
+		},
+		{
+			name:  "value null es un valor válido",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"replace","path":"/foo","value":null}]`,
+			want:  `{"foo":null}`,
+		},
+		{
+			name:  "test compara números por valor",
+			doc:   `{"n":1}`,
+			patch: `[{"op":"test","path":"/n","value":1.0}]`,
+			want:  `{"n":1}`,
+		},
+		{
+			name:  "test compara objetos sin importar el orden",
+			doc:   `{"o":{"a":1,"b":[true,null]}}`,
+			patch: `[{"op":"test","path":"/o","value":{"b":[true,null],"a":1}}]`,
+			want:  `{"o":{"a":1,"b":[true,null]}}`,
+		},
+		{
+			name:  "copy hace una copia profunda",
+			doc:   `{"a":{"x":1}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/b"},{"op":"replace","path":"/b/x","value":2}]`,
+			want:  `{"a":{"x":1},"b":{"x":2}}`,
+		},
+		{
+			name:  "copy de un elemento de arreglo",
+			doc:   `{"foo":["a","b"]}`,
+			patch: `[{"op":"copy","from":"/foo/0","path":"/foo/-"}]`,
+			want:  `{"foo":["a","b","a"]}`,
+		},
+		{
+			name:  "move hacia un hijo propio",
+			doc:   `{"a":{"b":{}}}`,
+			patch: `[{"op":"move","from":"/a","path":"/a/b/c"}]`,
+			kind:  kindMalformed,
+		},
+		{
+			name:  "move sobre la misma ruta",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"move","from":"/a","path":"/a"}]`,
+			want:  `{"a":1}`,
+		},
+		{
+			name:  "move desde una ruta inexistente",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"move","from":"/x","path":"/b"}]`,
+			kind:  kindConflict,
+		},
+		{
+			name:  "sin value en add",
+			doc:   `{}`,
+			patch: `[{"op":"add","path":"/a"}]`,
+			kind:  kindMalformed,
+		},
+		{
+			name:  "operación desconocida",
+			doc:   `{}`,
+			patch: `[{"op":"merge","path":"/a","value":1}]`,
+			kind:  kindMalformed,
+		},
+		{
+			name:  "el parche no es un arreglo",
+			doc:   `{}`,
+			patch: `{"op":"add","path":"/a","value":1}`,
+			kind:  kindMalformed,
+		},
+		{
+			name:  "puntero sin barra inicial",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"remove","path":"a"}]`,
+			kind:  kindMalformed,
+		},
+		{
+			name:  "secuencia de escape inválida",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"remove","path":"/a~2"}]`,
+			kind:  kindMalformed,
+		},
+		{
+			name:  "eliminar la raíz",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"remove","path":""}]`,
+			kind:  kindMalformed,
+		},
+		{
+			name:  "eliminar un miembro inexistente",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"remove","path":"/b"}]`,
+			kind:  kindConflict,
+		},
+		{
+			name:  "reemplazar un miembro inexistente",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"replace","path":"/b","value":2}]`,
+			kind:  kindConflict,
+		},
+		{
+			name:  "índice con cero a la izquierda",
+			doc:   `{"foo":["a","b"]}`,
+			patch: `[{"op":"replace","path":"/foo/01","value":"c"}]`,
+			kind:  kindConflict,
+		},
+		{
+			name:  "índice fuera de rango",
+			doc:   `{"foo":["a","b"]}`,
+			patch: `[{"op":"add","path":"/foo/3","value":"c"}]`,
+			kind:  kindConflict,
+		},
+		{
+			name:  "agregar en la posición final con índice",
+			doc:   `{"foo":["a","b"]}`,
+			patch: `[{"op":"add","path":"/foo/2","value":"c"}]`,
+			want:  `{"foo":["a","b","c"]}`,
+		},
+		{
+			name:  "- solo es válido en add",
+			doc:   `{"foo":["a"]}`,
+			patch: `[{"op":"remove","path":"/foo/-"}]`,
+			kind:  kindConflict,
+		},
+		{
+			name:  "índice sobre un valor escalar",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/foo/0","value":"x"}]`,
+			kind:  kindConflict,
+		},
+		{
+			name:  "una operación fallida descarta las anteriores",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1}]`,
+			kind:  kindConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if kind := classify(err); kind != tt.kind {
+				t.Fatalf("error = %v (tipo %d), se esperaba tipo %d", err, kind, tt.kind)
+			}
+			if tt.kind != kindNone {
+				if got != nil {
+					t.Fatalf("se devolvió un resultado parcial: %s", got)
+				}
+				return
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+// TestApplyResultNotValidated comprueba que Apply no valida el documento resultante: un
+// parche bien formado puede dejar un documento que el servicio rechaza después con 422.
+func TestApplyResultNotValidated(t *testing.T) {
+	got, err := Apply([]byte(`{"role":"employee"}`), []byte(`[{"op":"add","path":"/salary","value":1000}]`))
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	assertJSONEqual(t, got, `{"role":"employee","salary":1000}`)
+}
+
+func TestMergePatch(t *testing.T) {
+	// RFC 7396, apéndice A.
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got, err := MergePatch([]byte(tt.target), []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("MergePatch(%s, %s): error inesperado: %v", tt.target, tt.patch, err)
+		}
+		assertJSONEqual(t, got, tt.want)
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	if _, err := MergePatch([]byte(`{}`), []byte(`{"a":`)); classify(err) != kindMalformed {
+		t.Fatalf("error = %v, se esperaba ErrInvalidPatch", err)
+	}
+}
@@ -0,0 +1,159 @@
+package jsonpatch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pointer es un JSON Pointer (RFC 6901) ya decodificado en sus tokens de referencia.
+type pointer []string
+
+// parsePointer decodifica una cadena JSON Pointer. "" referencia al documento completo.
+func parsePointer(s string) (pointer, error) {
+	if s == "" {
+		return pointer{}, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("%w: puntero JSON '%s' debe comenzar con '/'", ErrInvalidPatch, s)
+	}
+	parts := strings.Split(s[1:], "/")
+	for i, p := range parts {
+		if !validEscapes(p) {
+			return nil, fmt.Errorf("%w: secuencia de escape inválida en '%s'", ErrInvalidPatch, s)
+		}
+		// El orden importa: primero ~1 y luego ~0 (RFC 6901, sección 4).
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(p, "~1", "/"), "~0", "~")
+	}
+	return parts, nil
+}
+
+// validEscapes indica si cada '~' del token va seguido de '0' o '1'. Se recorre el token
+// en vez de eliminar las secuencias válidas, porque eliminarlas puede formar una nueva
+// (p. ej. "~~01" → "~1").
+func validEscapes(token string) bool {
+	for i := 0; i < len(token); i++ {
+		if token[i] != '~' {
+			continue
+		}
+		if i+1 >= len(token) || (token[i+1] != '0' && token[i+1] != '1') {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func (p pointer) String() string {
+	if len(p) == 0 {
+		return ""
+	}
+	escaped := make([]string, len(p))
+	for i, t := range p {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~", "~0"), "/", "~1")
+	}
+	return "/" + strings.Join(escaped, "/")
+}
+
+// isPrefixOf indica si p es un prefijo propio de other (usado para impedir mover un
+// objeto dentro de sí mismo).
+func (p pointer) isPrefixOf(other pointer) bool {
+	if len(p) >= len(other) {
+		return false
+	}
+	for i := range p {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex interpreta un token como índice de arreglo. allowEnd habilita "-" (posición
+// tras el último elemento), válido solo en "add".
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" {
+		if allowEnd {
+			return length, nil
+		}
+		return 0, fmt.Errorf("%w: '-' no es válido en esta operación", ErrPathNotFound)
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: índice de arreglo inválido '%s'", ErrPathNotFound, token)
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 {
+		return 0, fmt.Errorf("%w: índice de arreglo inválido '%s'", ErrPathNotFound, token)
+	}
+	max := length - 1
+	if allowEnd {
+		max = length
+	}
+	if idx > max {
+		return 0, fmt.Errorf("%w: índice %d fuera de rango", ErrPathNotFound, idx)
+	}
+	return idx, nil
+}
+
+// get devuelve el valor referenciado por p dentro de doc.
+func get(doc interface{}, p pointer) (interface{}, error) {
+	current := doc
+	for _, token := range p {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			v, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: '%s'", ErrPathNotFound, p)
+			}
+			current = v
+		case []interface{}:
+			idx, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[idx]
+		default:
+			return nil, fmt.Errorf("%w: '%s'", ErrPathNotFound, p)
+		}
+	}
+	return current, nil
+}
+
+// set reemplaza el contenedor padre de p aplicando fn sobre él y devuelve el documento
+// resultante. Como los arreglos de Go cambian de cabecera al insertar o borrar, cada
+// nivel reasigna el hijo modificado en su padre.
+func set(doc interface{}, p pointer, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(p) == 0 {
+		return nil, fmt.Errorf("%w: la raíz no tiene contenedor padre", ErrInvalidPatch)
+	}
+	if len(p) == 1 {
+		return fn(doc, p[0])
+	}
+	token := p[0]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: '%s'", ErrPathNotFound, p)
+		}
+		updated, err := set(child, p[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = updated
+		return node, nil
+	case []interface{}:
+		idx, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := set(node[idx], p[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = updated
+		return node, nil
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrPathNotFound, p)
+	}
+}
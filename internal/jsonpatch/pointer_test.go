@@ -0,0 +1,101 @@
+package jsonpatch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    pointer
+		invalid bool
+	}{
+		{in: "", want: pointer{}},
+		{in: "/", want: pointer{""}},
+		{in: "/foo", want: pointer{"foo"}},
+		{in: "/foo/0", want: pointer{"foo", "0"}},
+		{in: "/a~1b", want: pointer{"a/b"}},
+		{in: "/m~0n", want: pointer{"m~n"}},
+		{in: "/~01", want: pointer{"~1"}},
+		{in: "/~10", want: pointer{"/0"}},
+		{in: "//", want: pointer{"", ""}},
+		{in: "/foo/-", want: pointer{"foo", "-"}},
+		{in: "foo", invalid: true},
+		{in: "/a~", invalid: true},
+		{in: "/a~2", invalid: true},
+		{in: "/~~01", invalid: true},
+	}
+	for _, tt := range tests {
+		got, err := parsePointer(tt.in)
+		if tt.invalid {
+			if !errors.Is(err, ErrInvalidPatch) {
+				t.Errorf("parsePointer(%q): error = %v, se esperaba ErrInvalidPatch", tt.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePointer(%q): error inesperado: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePointer(%q) = %q, se esperaba %q", tt.in, got, tt.want)
+		}
+		if s := got.String(); s != tt.in {
+			t.Errorf("parsePointer(%q).String() = %q", tt.in, s)
+		}
+	}
+}
+
+func TestArrayIndex(t *testing.T) {
+	tests := []struct {
+		token    string
+		length   int
+		allowEnd bool
+		want     int
+		ok       bool
+	}{
+		{"0", 2, false, 0, true},
+		{"1", 2, false, 1, true},
+		{"2", 2, false, 0, false},
+		{"2", 2, true, 2, true},
+		{"3", 2, true, 0, false},
+		{"-", 2, true, 2, true},
+		{"-", 2, false, 0, false},
+		{"01", 2, false, 0, false},
+		{"-1", 2, false, 0, false},
+		{"", 2, false, 0, false},
+		{"x", 2, false, 0, false},
+	}
+	for _, tt := range tests {
+		got, err := arrayIndex(tt.token, tt.length, tt.allowEnd)
+		if !tt.ok {
+			if !errors.Is(err, ErrPathNotFound) {
+				t.Errorf("arrayIndex(%q, %d, %v): error = %v, se esperaba ErrPathNotFound", tt.token, tt.length, tt.allowEnd, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("arrayIndex(%q, %d, %v) = %d, %v; se esperaba %d", tt.token, tt.length, tt.allowEnd, got, err, tt.want)
+		}
+	}
+}
+
+func TestPointerIsPrefixOf(t *testing.T) {
+	tests := []struct {
+		p, other pointer
+		want     bool
+	}{
+		{pointer{}, pointer{"a"}, true},
+		{pointer{"a"}, pointer{"a", "b"}, true},
+		{pointer{"a"}, pointer{"a"}, false},
+		{pointer{"a"}, pointer{"ab"}, false},
+		{pointer{"a", "b"}, pointer{"a"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.p.isPrefixOf(tt.other); got != tt.want {
+			t.Errorf("%q.isPrefixOf(%q) = %v, se esperaba %v", tt.p, tt.other, got, tt.want)
+		}
+	}
+}
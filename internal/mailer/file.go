@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"time"
+)
+
+// FileMailer guarda cada mensaje como un archivo .eml en un directorio, en el mismo
+// formato que recibiría un servidor SMTP. Sustituye al servidor de correo en desarrollo:
+// los archivos se pueden abrir con cualquier cliente de correo.
+type FileMailer struct {
+	dir  string
+	from *mail.Address
+}
+
+// NewFileMailer crea el directorio si no existe.
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("falta la variable de entorno MAIL_FILE_DIR")
+	}
+	fromAddr, err := parseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("no se pudo crear el directorio de correo '%s': %w", dir, err)
+	}
+	return &FileMailer{dir: dir, from: fromAddr}, nil
+}
+
+// Send implementa Mailer.
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	to := make([]*mail.Address, 0, len(msg.To))
+	for _, addr := range msg.To {
+		parsed, err := parseAddress(addr)
+		if err != nil {
+			return err
+		}
+		to = append(to, parsed)
+	}
+	now := time.Now()
+	data, err := buildMIME(m.from, to, msg, now)
+	if err != nil {
+		return fmt.Errorf("no se pudo armar el mensaje: %w", err)
+	}
+
+	f, err := os.CreateTemp(m.dir, now.Format("20060102-150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	return f.Close()
+}
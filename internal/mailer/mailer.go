@@ -0,0 +1,66 @@
+// Package mailer envía los correos salientes de la aplicación (invitaciones, avisos...)
+// a través de un driver intercambiable (SMTP, archivos .eml o log), con plantillas por
+// idioma y una bandeja de salida persistente que reintenta los envíos fallidos.
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/config"
+)
+
+// Message es un correo listo para enviar. Text es obligatorio; HTML es una alternativa opcional.
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer envía un mensaje. Las implementaciones deben ser seguras para uso concurrente.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// ErrNoRecipients indica que el mensaje no tiene destinatarios.
+var ErrNoRecipients = errors.New("el mensaje no tiene destinatarios")
+
+// New crea el Mailer indicado por la configuración.
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", "log":
+		return &LogMailer{From: cfg.From}, nil
+	case "file":
+		return NewFileMailer(cfg.FileDir, cfg.From)
+	case "smtp":
+		return NewSMTPMailer(SMTPOptions{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			TLS:      cfg.SMTPTLS,
+			From:     cfg.From,
+		})
+	default:
+		return nil, fmt.Errorf("driver de correo desconocido: '%s'", cfg.Driver)
+	}
+}
+
+// LogMailer no envía nada: escribe el mensaje en el log del servidor. Sirve para
+// desarrollo, donde interesa ver el enlace de una invitación sin configurar un servidor SMTP.
+type LogMailer struct {
+	From string
+}
+
+// Send implementa Mailer.
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	log.Printf("[correo] De: %s | Para: %s | Asunto: %s\n%s", m.From, strings.Join(msg.To, ", "), msg.Subject, msg.Text)
+	return nil
+}
@@ -0,0 +1,111 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// parseAddress valida una dirección ("Nombre <a@b.com>" o "a@b.com").
+func parseAddress(addr string) (*mail.Address, error) {
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil {
+		return nil, fmt.Errorf("dirección de correo inválida '%s': %w", addr, err)
+	}
+	return parsed, nil
+}
+
+// buildMIME arma el mensaje RFC 5322 completo. Si hay HTML se envía como
+// multipart/alternative con la versión de texto primero; el cuerpo va en quoted-printable
+// para que las líneas largas y los acentos lleguen intactos.
+func buildMIME(from *mail.Address, to []*mail.Address, msg Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+
+	recipients := make([]string, 0, len(to))
+	for _, addr := range to {
+		recipients = append(recipients, addr.String())
+	}
+	token := make([]byte, 12)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+		domain = from.Address[at+1:]
+	}
+
+	header := [][2]string{
+		{"From", from.String()},
+		{"To", strings.Join(recipients, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", "<" + hex.EncodeToString(token) + "@" + domain + ">"},
+		{"MIME-Version", "1.0"},
+	}
+
+	if msg.HTML == "" {
+		header = append(header,
+			[2]string{"Content-Type", "text/plain; charset=utf-8"},
+			[2]string{"Content-Transfer-Encoding", "quoted-printable"})
+		writeHeader(&buf, header)
+		if err := writeQuotedPrintable(&buf, msg.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	header = append(header, [2]string{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()})
+	writeHeader(&buf, header)
+
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, header [][2]string) {
+	for _, field := range header {
+		fmt.Fprintf(buf, "%s: %s\r\n", field[0], field[1])
+	}
+	buf.WriteString("\r\n")
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	// SMTP exige CRLF como fin de línea.
+	content = strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
@@ -0,0 +1,177 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultOutboxMaxAttempts = 8
+	outboxBatchSize          = 20
+	// outboxLease es el tiempo que un envío en curso reserva la fila; si el proceso muere
+	// a mitad del envío, el correo vuelve a estar disponible pasado este margen.
+	outboxLease       = 5 * time.Minute
+	outboxBaseBackoff = time.Minute
+	outboxMaxBackoff  = 6 * time.Hour
+)
+
+// Outbox es una bandeja de salida persistente: los mensajes se guardan en la tabla
+// outbox_emails y Run los entrega a través de Transport, reintentando con espera
+// exponencial los que fallan. Implementa Mailer, de modo que puede usarse donde se
+// espera un envío directo.
+type Outbox struct {
+	DB          *gorm.DB
+	Transport   Mailer
+	MaxAttempts int
+}
+
+// NewOutbox crea una bandeja de salida que entrega los mensajes con transport.
+func NewOutbox(db *gorm.DB, transport Mailer) *Outbox {
+	return &Outbox{DB: db, Transport: transport, MaxAttempts: defaultOutboxMaxAttempts}
+}
+
+// Enqueue guarda el mensaje para su envío usando tx, que puede ser una transacción en curso:
+// el correo solo se enviará si esa transacción se confirma.
+func (o *Outbox) Enqueue(tx *gorm.DB, msg Message) (*models.OutboxEmail, error) {
+	if len(msg.To) == 0 {
+		return nil, ErrNoRecipients
+	}
+	for _, addr := range msg.To {
+		if _, err := parseAddress(addr); err != nil {
+			return nil, err
+		}
+	}
+	email := models.OutboxEmail{
+		Recipients:    msg.To,
+		Subject:       msg.Subject,
+		TextBody:      msg.Text,
+		HTMLBody:      msg.HTML,
+		Status:        models.OutboxEmailPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := tx.Create(&email).Error; err != nil {
+		return nil, err
+	}
+	return &email, nil
+}
+
+// Send implementa Mailer encolando el mensaje fuera de cualquier transacción.
+func (o *Outbox) Send(ctx context.Context, msg Message) error {
+	_, err := o.Enqueue(o.DB.WithContext(ctx), msg)
+	return err
+}
+
+// outboxBackoff devuelve la espera antes del intento número attempts+1.
+func outboxBackoff(attempts int) time.Duration {
+	wait := outboxBaseBackoff
+	for i := 1; i < attempts && wait < outboxMaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > outboxMaxBackoff {
+		wait = outboxMaxBackoff
+	}
+	return wait
+}
+
+// DispatchPending envía los correos vencidos y devuelve cuántos se entregaron. Es seguro
+// ejecutarlo desde varios procesos: cada fila se reclama con una actualización condicional.
+func (o *Outbox) DispatchPending(ctx context.Context) (int, error) {
+	now := time.Now()
+	var due []models.OutboxEmail
+	if err := o.DB.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.OutboxEmailPending, now).
+		Order("next_attempt_at, id").Limit(outboxBatchSize).Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		email := &due[i]
+		claim := o.DB.WithContext(ctx).Model(&models.OutboxEmail{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", email.ID, models.OutboxEmailPending, email.NextAttemptAt).
+			Update("next_attempt_at", now.Add(outboxLease))
+		if claim.Error != nil {
+			return sent, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue // Otro proceso lo tomó
+		}
+		if o.deliver(ctx, email) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// deliver envía un correo ya reclamado y registra el resultado.
+func (o *Outbox) deliver(ctx context.Context, email *models.OutboxEmail) bool {
+	sendCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	err := o.Transport.Send(sendCtx, Message{
+		To:      email.Recipients,
+		Subject: email.Subject,
+		Text:    email.TextBody,
+		HTML:    email.HTMLBody,
+	})
+	cancel()
+
+	updates := o.deliveryUpdates(email, err, time.Now())
+	if dbErr := o.DB.Model(&models.OutboxEmail{}).Where("id = ?", email.ID).Updates(updates).Error; dbErr != nil {
+		// Si esto falla tras un envío correcto, el correo se reenviará al vencer la reserva.
+		log.Printf("Error al registrar el resultado del correo %d: %v", email.ID, dbErr)
+	}
+	return err == nil
+}
+
+// deliveryUpdates calcula las columnas que cambian tras un intento de envío con resultado
+// err: el correo queda enviado, se reprograma con espera exponencial o, si agotó los
+// intentos, queda fallido.
+func (o *Outbox) deliveryUpdates(email *models.OutboxEmail, err error, now time.Time) map[string]interface{} {
+	attempts := email.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+	if err == nil {
+		updates["status"] = models.OutboxEmailSent
+		updates["sent_at"] = now
+		updates["last_error"] = ""
+		return updates
+	}
+	updates["last_error"] = err.Error()
+	maxAttempts := o.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultOutboxMaxAttempts
+	}
+	// Un mensaje mal formado no se arreglará reintentando.
+	if attempts >= maxAttempts || errors.Is(err, ErrNoRecipients) {
+		updates["status"] = models.OutboxEmailFailed
+		log.Printf("Correo %d descartado tras %d intentos: %v", email.ID, attempts, err)
+	} else {
+		updates["next_attempt_at"] = now.Add(outboxBackoff(attempts))
+		log.Printf("Error al enviar el correo %d (intento %d): %v", email.ID, attempts, err)
+	}
+	return updates
+}
+
+// Run entrega los correos pendientes cada interval hasta que ctx se cancele.
+func (o *Outbox) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if sent, err := o.DispatchPending(ctx); err != nil {
+			log.Printf("Error al procesar la bandeja de salida de correo: %v", err)
+		} else if sent > 0 {
+			log.Printf("Bandeja de salida: %d correo(s) enviados.", sent)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
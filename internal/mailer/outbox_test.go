@@ -0,0 +1,104 @@
+package mailer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{8, 128 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, outboxMaxBackoff},
+		{50, outboxMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := outboxBackoff(tt.attempts); got != tt.want {
+			t.Errorf("outboxBackoff(%d) = %v, se esperaba %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestOutboxDeliveryUpdatesSchedulesRetries(t *testing.T) {
+	o := &Outbox{MaxAttempts: 4}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	email := &models.OutboxEmail{ID: 7}
+	sendErr := errors.New("conexión rechazada")
+
+	// Los tres primeros fallos se reprograman con espera creciente.
+	for attempt, wait := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		updates := o.deliveryUpdates(email, sendErr, now)
+		if updates["attempts"] != attempt+1 {
+			t.Fatalf("intento %d: attempts = %v", attempt+1, updates["attempts"])
+		}
+		if _, ok := updates["status"]; ok {
+			t.Fatalf("intento %d: el correo cambió de estado (%v) antes de agotar los intentos", attempt+1, updates["status"])
+		}
+		if updates["next_attempt_at"] != now.Add(wait) {
+			t.Fatalf("intento %d: next_attempt_at = %v, se esperaba %v", attempt+1, updates["next_attempt_at"], now.Add(wait))
+		}
+		if updates["last_error"] != sendErr.Error() {
+			t.Fatalf("intento %d: last_error = %v", attempt+1, updates["last_error"])
+		}
+		email.Attempts++
+	}
+
+	// El cuarto agota los intentos: el correo queda fallido y no se reprograma.
+	updates := o.deliveryUpdates(email, sendErr, now)
+	if updates["status"] != models.OutboxEmailFailed {
+		t.Fatalf("status = %v, se esperaba %s", updates["status"], models.OutboxEmailFailed)
+	}
+	if _, ok := updates["next_attempt_at"]; ok {
+		t.Error("un correo fallido no debe reprogramarse")
+	}
+}
+
+func TestOutboxDeliveryUpdatesDefaultMaxAttempts(t *testing.T) {
+	o := &Outbox{}
+	now := time.Now()
+	sendErr := errors.New("timeout")
+	if updates := o.deliveryUpdates(&models.OutboxEmail{Attempts: defaultOutboxMaxAttempts - 2}, sendErr, now); updates["status"] != nil {
+		t.Errorf("status = %v antes del último intento", updates["status"])
+	}
+	if updates := o.deliveryUpdates(&models.OutboxEmail{Attempts: defaultOutboxMaxAttempts - 1}, sendErr, now); updates["status"] != models.OutboxEmailFailed {
+		t.Errorf("status = %v tras %d intentos, se esperaba %s", updates["status"], defaultOutboxMaxAttempts, models.OutboxEmailFailed)
+	}
+}
+
+func TestOutboxDeliveryUpdatesPermanentError(t *testing.T) {
+	o := &Outbox{MaxAttempts: 8}
+	updates := o.deliveryUpdates(&models.OutboxEmail{}, ErrNoRecipients, time.Now())
+	if updates["status"] != models.OutboxEmailFailed || updates["attempts"] != 1 {
+		t.Errorf("updates = %v, se esperaba el estado fallido al primer intento", updates)
+	}
+}
+
+func TestOutboxDeliveryUpdatesSent(t *testing.T) {
+	o := &Outbox{MaxAttempts: 8}
+	now := time.Now()
+	updates := o.deliveryUpdates(&models.OutboxEmail{Attempts: 3, LastError: "timeout"}, nil, now)
+	if updates["status"] != models.OutboxEmailSent || updates["sent_at"] != now || updates["last_error"] != "" || updates["attempts"] != 4 {
+		t.Errorf("updates = %v", updates)
+	}
+}
+
+func TestOutboxEnqueueValidatesRecipients(t *testing.T) {
+	// La validación ocurre antes de tocar la base de datos, por eso basta un tx nil.
+	o := &Outbox{}
+	if _, err := o.Enqueue(nil, Message{Subject: "Hola", Text: "Hola"}); err != ErrNoRecipients {
+		t.Errorf("error = %v, se esperaba ErrNoRecipients", err)
+	}
+	if _, err := o.Enqueue(nil, Message{To: []string{"ana@yamerito.test", "inválida"}, Text: "Hola"}); err == nil {
+		t.Error("se encoló un correo con un destinatario inválido")
+	}
+}
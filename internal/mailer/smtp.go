@@ -0,0 +1,149 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPOptions configura un SMTPMailer.
+type SMTPOptions struct {
+	Host     string
+	Port     string
+	Username string // Vacío para servidores sin autenticación (p. ej. un relay interno)
+	Password string
+	TLS      string // "starttls" (por defecto), "tls" o "none"
+	From     string
+	// TLSConfig es opcional; por defecto se verifica el certificado contra Host.
+	TLSConfig *tls.Config
+	Timeout   time.Duration // Por defecto 30 s para toda la conversación
+}
+
+// SMTPMailer envía los mensajes a un servidor SMTP. Abre una conexión por mensaje, lo
+// que es suficiente para el volumen de la aplicación y evita manejar conexiones caídas.
+type SMTPMailer struct {
+	opts SMTPOptions
+	from *mail.Address
+}
+
+// NewSMTPMailer valida las opciones y crea el mailer. No se conecta al servidor.
+func NewSMTPMailer(opts SMTPOptions) (*SMTPMailer, error) {
+	if opts.Host == "" {
+		return nil, errors.New("falta la variable de entorno SMTP_HOST")
+	}
+	if opts.Port == "" {
+		opts.Port = "587"
+	}
+	opts.TLS = strings.ToLower(opts.TLS)
+	if opts.TLS == "" {
+		opts.TLS = "starttls"
+	}
+	if opts.TLS != "starttls" && opts.TLS != "tls" && opts.TLS != "none" {
+		return nil, fmt.Errorf("SMTP_TLS inválido: '%s' (use starttls, tls o none)", opts.TLS)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	from, err := parseAddress(opts.From)
+	if err != nil {
+		return nil, err
+	}
+	return &SMTPMailer{opts: opts, from: from}, nil
+}
+
+func (m *SMTPMailer) tlsConfig() *tls.Config {
+	if m.opts.TLSConfig != nil {
+		return m.opts.TLSConfig
+	}
+	return &tls.Config{ServerName: m.opts.Host, MinVersion: tls.VersionTLS12}
+}
+
+// Send implementa Mailer.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	to := make([]*mail.Address, 0, len(msg.To))
+	for _, addr := range msg.To {
+		parsed, err := parseAddress(addr)
+		if err != nil {
+			return err
+		}
+		to = append(to, parsed)
+	}
+	data, err := buildMIME(m.from, to, msg, time.Now())
+	if err != nil {
+		return fmt.Errorf("no se pudo armar el mensaje: %w", err)
+	}
+
+	deadline := time.Now().Add(m.opts.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	addr := net.JoinHostPort(m.opts.Host, m.opts.Port)
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	if m.opts.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, m.tlsConfig())
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("no se pudo conectar con el servidor SMTP %s: %w", addr, err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, m.opts.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("saludo SMTP fallido: %w", err)
+	}
+	defer client.Close()
+
+	if m.opts.TLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("el servidor SMTP no soporta STARTTLS (configure SMTP_TLS=none para permitir texto plano)")
+		}
+		if err := client.StartTLS(m.tlsConfig()); err != nil {
+			return fmt.Errorf("STARTTLS fallido: %w", err)
+		}
+	}
+	if m.opts.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("el servidor SMTP no admite autenticación")
+		}
+		if err := client.Auth(smtp.PlainAuth("", m.opts.Username, m.opts.Password, m.opts.Host)); err != nil {
+			return fmt.Errorf("autenticación SMTP fallida: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("remitente rechazado: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt.Address); err != nil {
+			return fmt.Errorf("destinatario '%s' rechazado: %w", rcpt.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("comando DATA rechazado: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("error al enviar el mensaje: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("el servidor SMTP rechazó el mensaje: %w", err)
+	}
+	return client.Quit()
+}
@@ -0,0 +1,369 @@
+package mailer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession es lo que el servidor de prueba registró de una conexión.
+type smtpSession struct {
+	helo     string
+	tls      bool
+	authUser string
+	from     string
+	rcpts    []string
+	data     string
+}
+
+// fakeSMTPServer es un servidor SMTP mínimo en memoria: EHLO, STARTTLS, AUTH PLAIN, MAIL,
+// RCPT, DATA y QUIT. Cada conexión terminada se publica en sessions.
+type fakeSMTPServer struct {
+	ln net.Listener
+	// tlsConfig habilita STARTTLS (o TLS implícito si implicitTLS); nil lo deshabilita.
+	tlsConfig   *tls.Config
+	implicitTLS bool
+	// username vacío deshabilita AUTH.
+	username string
+	password string
+	sessions chan *smtpSession
+}
+
+func startFakeSMTPServer(t *testing.T, srv *fakeSMTPServer) *fakeSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("no se pudo abrir el puerto: %v", err)
+	}
+	srv.ln = ln
+	srv.sessions = make(chan *smtpSession, 4)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.handle(conn)
+		}
+	}()
+	return srv
+}
+
+func (s *fakeSMTPServer) port() string {
+	return strings.TrimPrefix(s.ln.Addr().String(), "127.0.0.1:")
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	session := &smtpSession{}
+	defer func() { s.sessions <- session }()
+	defer func() { conn.Close() }()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if s.implicitTLS {
+		tlsConn := tls.Server(conn, s.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+		conn = tlsConn
+		session.tls = true
+	}
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP prueba")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			session.helo = arg
+			lines := []string{"localhost"}
+			if s.tlsConfig != nil && !session.tls {
+				lines = append(lines, "STARTTLS")
+			}
+			if s.username != "" {
+				lines = append(lines, "AUTH PLAIN")
+			}
+			lines = append(lines, "8BITMIME")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				tp.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			if s.tlsConfig == nil || session.tls {
+				tp.PrintfLine("502 5.5.1 STARTTLS no disponible")
+				continue
+			}
+			tp.PrintfLine("220 2.0.0 Listo para TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			session.tls = true
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			if s.username == "" || !strings.EqualFold(mechanism, "PLAIN") {
+				tp.PrintfLine("504 5.5.4 mecanismo no soportado")
+				continue
+			}
+			decoded, err := base64.StdEncoding.DecodeString(initial)
+			parts := strings.Split(string(decoded), "\x00")
+			if err != nil || len(parts) != 3 || parts[1] != s.username || parts[2] != s.password {
+				tp.PrintfLine("535 5.7.8 credenciales inválidas")
+				continue
+			}
+			session.authUser = parts[1]
+			tp.PrintfLine("235 2.7.0 autenticado")
+		case "MAIL":
+			if s.username != "" && session.authUser == "" {
+				tp.PrintfLine("530 5.7.0 autenticación requerida")
+				continue
+			}
+			session.from = smtpPathArg(arg, "FROM:")
+			tp.PrintfLine("250 2.1.0 OK")
+		case "RCPT":
+			session.rcpts = append(session.rcpts, smtpPathArg(arg, "TO:"))
+			tp.PrintfLine("250 2.1.5 OK")
+		case "DATA":
+			tp.PrintfLine("354 termine con <CRLF>.<CRLF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			session.data = string(data)
+			tp.PrintfLine("250 2.0.0 encolado")
+		case "RSET", "NOOP":
+			tp.PrintfLine("250 2.0.0 OK")
+		case "QUIT":
+			tp.PrintfLine("221 2.0.0 adiós")
+			return
+		default:
+			tp.PrintfLine("502 5.5.2 comando no reconocido")
+		}
+	}
+}
+
+// smtpPathArg extrae la dirección de "FROM:<a@b.com> BODY=8BITMIME".
+func smtpPathArg(arg, prefix string) string {
+	arg = strings.TrimSpace(arg)
+	if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+		arg = arg[len(prefix):]
+	}
+	if end := strings.Index(arg, ">"); end >= 0 {
+		arg = arg[:end]
+	}
+	return strings.TrimPrefix(arg, "<")
+}
+
+func (s *fakeSMTPServer) waitSession(t *testing.T) *smtpSession {
+	t.Helper()
+	select {
+	case session := <-s.sessions:
+		return session
+	case <-time.After(5 * time.Second):
+		t.Fatal("el servidor SMTP de prueba no registró la conexión")
+		return nil
+	}
+}
+
+// testTLSConfigs genera un certificado autofirmado para 127.0.0.1 y devuelve la
+// configuración del servidor y la del cliente que confía en él.
+func testTLSConfigs(t *testing.T) (server, client *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client = &tls.Config{RootCAs: pool, ServerName: "127.0.0.1", MinVersion: tls.VersionTLS12}
+	return server, client
+}
+
+func newTestSMTPMailer(t *testing.T, srv *fakeSMTPServer, opts SMTPOptions) *SMTPMailer {
+	t.Helper()
+	opts.Host = "127.0.0.1"
+	opts.Port = srv.port()
+	if opts.From == "" {
+		opts.From = "Yamerito <no-reply@yamerito.test>"
+	}
+	opts.Timeout = 5 * time.Second
+	m, err := NewSMTPMailer(opts)
+	if err != nil {
+		t.Fatalf("NewSMTPMailer: %v", err)
+	}
+	return m
+}
+
+func TestSMTPMailerStartTLSAuthAndData(t *testing.T) {
+	serverTLS, clientTLS := testTLSConfigs(t)
+	srv := startFakeSMTPServer(t, &fakeSMTPServer{tlsConfig: serverTLS, username: "usuario", password: "secreto"})
+	m := newTestSMTPMailer(t, srv, SMTPOptions{Username: "usuario", Password: "secreto", TLS: "starttls", TLSConfig: clientTLS})
+
+	msg := Message{
+		To:      []string{"Ana Pérez <ana@yamerito.test>", "luis@yamerito.test"},
+		Subject: "Invitación a Yamerito",
+		Text:    "Hola Ana:\nabre este enlace: https://yamerito.test/invitacion?token=abc\n",
+	}
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	session := srv.waitSession(t)
+	if !session.tls {
+		t.Error("la conversación no pasó a TLS antes de autenticarse")
+	}
+	if session.authUser != "usuario" {
+		t.Errorf("usuario autenticado = %q", session.authUser)
+	}
+	if session.from != "no-reply@yamerito.test" {
+		t.Errorf("MAIL FROM = %q", session.from)
+	}
+	if strings.Join(session.rcpts, ",") != "ana@yamerito.test,luis@yamerito.test" {
+		t.Errorf("RCPT TO = %v", session.rcpts)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(session.data))
+	if err != nil {
+		t.Fatalf("mensaje recibido inválido: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q (%v), se esperaba %q", subject, err, msg.Subject)
+	}
+	if ct := parsed.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatalf("cuerpo quoted-printable inválido: %v", err)
+	}
+	// ReadDotBytes ya convierte los CRLF de la transmisión en \n.
+	if got := string(body); got != msg.Text {
+		t.Errorf("cuerpo = %q, se esperaba %q", got, msg.Text)
+	}
+}
+
+func TestSMTPMailerImplicitTLS(t *testing.T) {
+	serverTLS, clientTLS := testTLSConfigs(t)
+	srv := startFakeSMTPServer(t, &fakeSMTPServer{tlsConfig: serverTLS, implicitTLS: true})
+	m := newTestSMTPMailer(t, srv, SMTPOptions{TLS: "tls", TLSConfig: clientTLS})
+
+	if err := m.Send(context.Background(), Message{To: []string{"ana@yamerito.test"}, Subject: "Hola", Text: "Hola", HTML: "<p>Hola</p>"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	session := srv.waitSession(t)
+	if !session.tls || session.data == "" {
+		t.Fatalf("sesión = %+v, se esperaba un mensaje recibido por TLS", session)
+	}
+	if !strings.Contains(session.data, "multipart/alternative") {
+		t.Error("un mensaje con HTML debe enviarse como multipart/alternative")
+	}
+}
+
+func TestSMTPMailerAuthFailure(t *testing.T) {
+	serverTLS, clientTLS := testTLSConfigs(t)
+	srv := startFakeSMTPServer(t, &fakeSMTPServer{tlsConfig: serverTLS, username: "usuario", password: "secreto"})
+	m := newTestSMTPMailer(t, srv, SMTPOptions{Username: "usuario", Password: "incorrecto", TLSConfig: clientTLS})
+
+	err := m.Send(context.Background(), Message{To: []string{"ana@yamerito.test"}, Subject: "Hola", Text: "Hola"})
+	if err == nil || !strings.Contains(err.Error(), "autenticación SMTP fallida") {
+		t.Fatalf("error = %v, se esperaba un fallo de autenticación", err)
+	}
+	if session := srv.waitSession(t); session.from != "" || session.data != "" {
+		t.Errorf("se envió el mensaje pese al fallo de autenticación: %+v", session)
+	}
+}
+
+func TestSMTPMailerRequiresStartTLS(t *testing.T) {
+	srv := startFakeSMTPServer(t, &fakeSMTPServer{username: "usuario", password: "secreto"})
+	m := newTestSMTPMailer(t, srv, SMTPOptions{Username: "usuario", Password: "secreto", TLS: "starttls"})
+
+	err := m.Send(context.Background(), Message{To: []string{"ana@yamerito.test"}, Subject: "Hola", Text: "Hola"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("error = %v, se esperaba el rechazo por falta de STARTTLS", err)
+	}
+	if session := srv.waitSession(t); session.authUser != "" || session.from != "" {
+		t.Errorf("se enviaron credenciales o el mensaje en texto plano: %+v", session)
+	}
+}
+
+func TestSMTPMailerPlainRelay(t *testing.T) {
+	srv := startFakeSMTPServer(t, &fakeSMTPServer{})
+	m := newTestSMTPMailer(t, srv, SMTPOptions{TLS: "none"})
+
+	if err := m.Send(context.Background(), Message{To: []string{"ana@yamerito.test"}, Subject: "Hola", Text: "Hola"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if session := srv.waitSession(t); session.tls || session.authUser != "" || session.data == "" {
+		t.Errorf("sesión = %+v, se esperaba un envío sin TLS ni AUTH", session)
+	}
+}
+
+func TestSMTPMailerRejectsBadInput(t *testing.T) {
+	srv := startFakeSMTPServer(t, &fakeSMTPServer{})
+	m := newTestSMTPMailer(t, srv, SMTPOptions{TLS: "none"})
+
+	if err := m.Send(context.Background(), Message{Subject: "Hola", Text: "Hola"}); err != ErrNoRecipients {
+		t.Errorf("error = %v, se esperaba ErrNoRecipients", err)
+	}
+	if err := m.Send(context.Background(), Message{To: []string{"no es una dirección"}, Text: "Hola"}); err == nil {
+		t.Error("se aceptó un destinatario inválido")
+	}
+}
+
+func TestNewSMTPMailerOptions(t *testing.T) {
+	if _, err := NewSMTPMailer(SMTPOptions{From: "a@b.test"}); err == nil {
+		t.Error("se aceptó una configuración sin SMTP_HOST")
+	}
+	if _, err := NewSMTPMailer(SMTPOptions{Host: "smtp.test", TLS: "ssl", From: "a@b.test"}); err == nil {
+		t.Error("se aceptó un SMTP_TLS inválido")
+	}
+	m, err := NewSMTPMailer(SMTPOptions{Host: "smtp.test", From: "a@b.test"})
+	if err != nil {
+		t.Fatalf("NewSMTPMailer: %v", err)
+	}
+	if m.opts.Port != "587" || m.opts.TLS != "starttls" || m.opts.Timeout != 30*time.Second {
+		t.Errorf("valores por defecto = %+v", m.opts)
+	}
+}
@@ -0,0 +1,139 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// SupportedLanguages son los idiomas con plantillas propias.
+var SupportedLanguages = []string{"es", "en"}
+
+// Templates arma los correos a partir de las plantillas embebidas en templates/.
+//
+// Cada correo tiene una plantilla de texto obligatoria <nombre>.<idioma>.txt que define
+// los bloques "subject" y "body", y opcionalmente una versión HTML <nombre>.<idioma>.html
+// que define el bloque "content" y se inserta dentro de layout.html. Si el idioma pedido
+// no tiene plantilla se usa el idioma por defecto.
+type Templates struct {
+	defaultLang string
+	text        map[string]*texttemplate.Template // clave: "<nombre>.<idioma>"
+	html        map[string]*htmltemplate.Template
+}
+
+// NewTemplates analiza todas las plantillas al arrancar, de modo que un error de sintaxis
+// se detecta antes de intentar enviar el primer correo.
+func NewTemplates(defaultLang string) (*Templates, error) {
+	defaultLang = NormalizeLanguage(defaultLang, "es")
+	t := &Templates{
+		defaultLang: defaultLang,
+		text:        map[string]*texttemplate.Template{},
+		html:        map[string]*htmltemplate.Template{},
+	}
+	layout, err := htmltemplate.ParseFS(templateFS, "templates/layout.html")
+	if err != nil {
+		return nil, fmt.Errorf("plantilla layout.html inválida: %w", err)
+	}
+
+	entries, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		path := "templates/" + name
+		switch {
+		case strings.HasSuffix(name, ".txt"):
+			tmpl, err := texttemplate.ParseFS(templateFS, path)
+			if err != nil {
+				return nil, fmt.Errorf("plantilla %s inválida: %w", name, err)
+			}
+			t.text[strings.TrimSuffix(name, ".txt")] = tmpl
+		case strings.HasSuffix(name, ".html") && name != "layout.html":
+			base, err := layout.Clone()
+			if err != nil {
+				return nil, err
+			}
+			tmpl, err := base.ParseFS(templateFS, path)
+			if err != nil {
+				return nil, fmt.Errorf("plantilla %s inválida: %w", name, err)
+			}
+			t.html[strings.TrimSuffix(name, ".html")] = tmpl
+		}
+	}
+	// Toda plantilla HTML necesita su versión de texto, que es la que define el asunto.
+	for key := range t.html {
+		if _, ok := t.text[key]; !ok {
+			return nil, fmt.Errorf("la plantilla %s.html no tiene su versión %s.txt", key, key)
+		}
+	}
+	return t, nil
+}
+
+// NormalizeLanguage reduce un código de idioma ("en-US", "ES") a uno soportado;
+// devuelve fallback si no lo es.
+func NormalizeLanguage(lang, fallback string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	for _, supported := range SupportedLanguages {
+		if lang == supported {
+			return lang
+		}
+	}
+	return fallback
+}
+
+// DefaultLanguage devuelve el idioma usado cuando el destinatario no tiene uno soportado.
+func (t *Templates) DefaultLanguage() string {
+	return t.defaultLang
+}
+
+// Render arma el mensaje name en el idioma lang con los datos data. El destinatario
+// lo completa quien llama.
+func (t *Templates) Render(name, lang string, data any) (Message, error) {
+	lang = NormalizeLanguage(lang, t.defaultLang)
+	key := name + "." + lang
+	text, ok := t.text[key]
+	if !ok {
+		key = name + "." + t.defaultLang
+		if text, ok = t.text[key]; !ok {
+			return Message{}, fmt.Errorf("plantilla de correo '%s' no encontrada", name)
+		}
+		lang = t.defaultLang
+	}
+
+	var subject, body bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("error en el asunto de la plantilla '%s': %w", key, err)
+	}
+	if err := text.ExecuteTemplate(&body, "body", data); err != nil {
+		return Message{}, fmt.Errorf("error en el cuerpo de la plantilla '%s': %w", key, err)
+	}
+	msg := Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    body.String(),
+	}
+
+	if html, ok := t.html[key]; ok {
+		var out bytes.Buffer
+		err := html.ExecuteTemplate(&out, "layout", struct {
+			Lang    string
+			Subject string
+			Data    any
+		}{lang, msg.Subject, data})
+		if err != nil {
+			return Message{}, fmt.Errorf("error en la versión HTML de la plantilla '%s': %w", key, err)
+		}
+		msg.HTML = out.String()
+	}
+	return msg, nil
+}
@@ -0,0 +1,163 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type invitationData struct {
+	Name      string
+	Link      string
+	ExpiresAt time.Time
+}
+
+var testInvitation = invitationData{
+	Name:      "Ana <Admin>",
+	Link:      "https://yamerito.test/invitacion?token=abc&lang=es",
+	ExpiresAt: time.Date(2025, 7, 3, 18, 30, 0, 0, time.UTC),
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		lang, fallback, want string
+	}{
+		{"es", "en", "es"},
+		{"EN", "es", "en"},
+		{"en-US", "es", "en"},
+		{"es_AR", "en", "es"},
+		{" en ", "es", "en"},
+		{"fr", "es", "es"},
+		{"", "en", "en"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeLanguage(tt.lang, tt.fallback); got != tt.want {
+			t.Errorf("NormalizeLanguage(%q, %q) = %q, se esperaba %q", tt.lang, tt.fallback, got, tt.want)
+		}
+	}
+}
+
+func TestTemplatesRenderLanguages(t *testing.T) {
+	templates, err := NewTemplates("es")
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	tests := []struct {
+		lang     string
+		subject  string
+		htmlLang string
+		text     []string
+	}{
+		{"es", "Invitación a Yamerito", `lang="es"`, []string{"Hola Ana <Admin>:", "El enlace vence el 03/07/2025 18:30."}},
+		{"en", "You're invited to Yamerito", `lang="en"`, []string{"Hello Ana <Admin>,", "The link expires on Jul 3, 2025 18:30."}},
+		{"en-GB", "You're invited to Yamerito", `lang="en"`, nil},
+		{"fr", "Invitación a Yamerito", `lang="es"`, nil},
+		{"", "Invitación a Yamerito", `lang="es"`, nil},
+	}
+	for _, tt := range tests {
+		msg, err := templates.Render("invitation", tt.lang, testInvitation)
+		if err != nil {
+			t.Fatalf("Render(%q): %v", tt.lang, err)
+		}
+		if msg.Subject != tt.subject {
+			t.Errorf("Render(%q): Subject = %q, se esperaba %q", tt.lang, msg.Subject, tt.subject)
+		}
+		if !strings.Contains(msg.Text, testInvitation.Link) {
+			t.Errorf("Render(%q): el texto no incluye el enlace", tt.lang)
+		}
+		for _, fragment := range tt.text {
+			if !strings.Contains(msg.Text, fragment) {
+				t.Errorf("Render(%q): el texto no incluye %q:\n%s", tt.lang, fragment, msg.Text)
+			}
+		}
+		if !strings.Contains(msg.HTML, tt.htmlLang) {
+			t.Errorf("Render(%q): el HTML no declara %s", tt.lang, tt.htmlLang)
+		}
+	}
+}
+
+func TestTemplatesRenderEscapesHTML(t *testing.T) {
+	templates, err := NewTemplates("es")
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	msg, err := templates.Render("invitation", "es", testInvitation)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if strings.Contains(msg.HTML, "<Admin>") || !strings.Contains(msg.HTML, "Ana &lt;Admin&gt;") {
+		t.Error("el nombre no se escapó en la versión HTML")
+	}
+	if !strings.Contains(msg.HTML, "token=abc&amp;lang=es") {
+		t.Error("el enlace no se escapó en la versión HTML")
+	}
+}
+
+func TestTemplatesRenderDefaultLanguage(t *testing.T) {
+	templates, err := NewTemplates("en-US")
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	if templates.DefaultLanguage() != "en" {
+		t.Fatalf("DefaultLanguage = %q", templates.DefaultLanguage())
+	}
+	msg, err := templates.Render("invitation", "pt", testInvitation)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if msg.Subject != "You're invited to Yamerito" {
+		t.Errorf("Subject = %q, se esperaba el idioma por defecto (en)", msg.Subject)
+	}
+
+	if templates, err = NewTemplates("fr"); err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	if templates.DefaultLanguage() != "es" {
+		t.Errorf("un idioma por defecto no soportado debe caer en es, no %q", templates.DefaultLanguage())
+	}
+}
+
+func TestTemplatesRenderFallsBackWhenVariantMissing(t *testing.T) {
+	templates, err := NewTemplates("es")
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	// Simula un correo que todavía no se tradujo al inglés.
+	delete(templates.text, "invitation.en")
+	delete(templates.html, "invitation.en")
+
+	msg, err := templates.Render("invitation", "en", testInvitation)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if msg.Subject != "Invitación a Yamerito" || !strings.Contains(msg.HTML, `lang="es"`) {
+		t.Errorf("se esperaba la versión en español, se obtuvo %q", msg.Subject)
+	}
+}
+
+func TestTemplatesRenderNotification(t *testing.T) {
+	templates, err := NewTemplates("es")
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	data := struct{ Title, Body, URL string }{"Nuevo curso asignado", "Seguridad 101", "https://yamerito.test/cursos/1"}
+	for lang, fragment := range map[string]string{"es": "Puedes verlo en Yamerito", "en": "View it in Yamerito"} {
+		msg, err := templates.Render("notification", lang, data)
+		if err != nil {
+			t.Fatalf("Render(%q): %v", lang, err)
+		}
+		if msg.Subject != data.Title || !strings.Contains(msg.Text, fragment) || !strings.Contains(msg.Text, data.URL) {
+			t.Errorf("Render(%q) = %+v", lang, msg)
+		}
+	}
+}
+
+func TestTemplatesRenderUnknown(t *testing.T) {
+	templates, err := NewTemplates("es")
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	if _, err := templates.Render("inexistente", "es", nil); err == nil {
+		t.Error("se esperaba un error para una plantilla inexistente")
+	}
+}
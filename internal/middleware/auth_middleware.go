@@ -1,60 +1,156 @@
 package middleware
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strings"
 
-	"github.com/Unikyri/yamerito-mvp/internal/auth" // Para ValidateJWT
+	"github.com/Unikyri/yamerito-mvp/internal/auth"   // Para ValidateJWT
 	"github.com/Unikyri/yamerito-mvp/internal/models" // Para models.Role
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 const (
 	authorizationHeaderKey  = "Authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authorization_payload" // Clave para guardar los claims en el contexto de Gin
+	streamTicketQueryKey    = "ticket"                // Solo lo acepta StreamAuthMiddleware
 )
 
-// AuthMiddleware crea un middleware de Gin para la autenticación JWT.
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader(authorizationHeaderKey)
-		if len(authHeader) == 0 {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "falta encabezado de autorización"})
-			return
-		}
+// AccountStatusFunc devuelve el estado y el rol actuales de una cuenta. Para una cuenta
+// inexistente o eliminada devuelve un estado vacío.
+type AccountStatusFunc func(userID uint) (models.UserStatus, models.Role, error)
 
-		fields := strings.Fields(authHeader)
-		if len(fields) < 2 {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "formato de encabezado de autorización inválido"})
-			return
+// AccountStatusFromDB consulta el estado y el rol de la cuenta en la tabla users.
+func AccountStatusFromDB(db *gorm.DB) AccountStatusFunc {
+	return func(userID uint) (models.UserStatus, models.Role, error) {
+		if db == nil {
+			return "", "", errors.New("sin conexión a la base de datos")
+		}
+		var user models.User
+		if err := db.Select("status, role").Where("id = ?", userID).Limit(1).Find(&user).Error; err != nil {
+			return "", "", err
 		}
+		return user.Status, user.Role, nil
+	}
+}
 
-		authType := strings.ToLower(fields[0])
-		if authType != authorizationTypeBearer {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "tipo de autorización no soportado: " + authType})
+// AuthMiddleware crea un middleware de Gin para la autenticación JWT. lookup da el estado
+// y el rol vigentes de la cuenta; sin él se rechaza toda petición.
+func AuthMiddleware(lookup AccountStatusFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessToken, ok := bearerToken(c)
+		if !ok {
 			return
 		}
+		authenticate(c, accessToken, lookup)
+	}
+}
 
-		accessToken := fields[1]
-		claims, err := auth.ValidateJWT(accessToken)
-		if err != nil {
-			log.Printf("Error al validar token JWT: %v", err) // Loguear el error específico
-			// Devolver un error genérico al cliente por seguridad
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token inválido o expirado"})
+// StreamAuthMiddleware es AuthMiddleware para conexiones de larga duración (Server-Sent
+// Events). EventSource no permite enviar encabezados, así que además del encabezado
+// Authorization acepta en el parámetro de consulta ticket un ticket de corta duración
+// (ver auth.GenerateStreamTicket). El JWT de sesión nunca se acepta en la URL: quedaría
+// en los logs de acceso y en el historial del navegador.
+func StreamAuthMiddleware(lookup AccountStatusFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(authorizationHeaderKey) == "" {
+			if ticket := c.Query(streamTicketQueryKey); ticket != "" {
+				claims, err := auth.ValidateStreamTicket(ticket)
+				if err != nil {
+					log.Printf("Error al validar ticket de conexión: %v", err)
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "ticket inválido o expirado"})
+					return
+				}
+				authorizeClaims(c, claims, lookup)
+				return
+			}
+		}
+		accessToken, ok := bearerToken(c)
+		if !ok {
 			return
 		}
+		authenticate(c, accessToken, lookup)
+	}
+}
+
+// bearerToken extrae el token del encabezado Authorization; si falta o es inválido
+// responde 401 y devuelve false.
+func bearerToken(c *gin.Context) (string, bool) {
+	authHeader := c.GetHeader(authorizationHeaderKey)
+	if len(authHeader) == 0 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "falta encabezado de autorización"})
+		return "", false
+	}
 
-		// Guardar los claims en el contexto de Gin para uso posterior en los handlers
-		c.Set(authorizationPayloadKey, claims)
-		c.Next() // Continuar con el siguiente handler en la cadena
+	fields := strings.Fields(authHeader)
+	if len(fields) < 2 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "formato de encabezado de autorización inválido"})
+		return "", false
 	}
+
+	authType := strings.ToLower(fields[0])
+	if authType != authorizationTypeBearer {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "tipo de autorización no soportado: " + authType})
+		return "", false
+	}
+	return fields[1], true
+}
+
+// authenticate valida el token y el estado de la cuenta, y guarda los claims en el contexto.
+func authenticate(c *gin.Context, accessToken string, lookup AccountStatusFunc) {
+	claims, err := auth.ValidateJWT(accessToken)
+	if err != nil {
+		log.Printf("Error al validar token JWT: %v", err) // Loguear el error específico
+		// Devolver un error genérico al cliente por seguridad
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token inválido o expirado"})
+		return
+	}
+
+	authorizeClaims(c, claims, lookup)
+}
+
+// authorizeClaims comprueba que la cuenta de unos claims ya validados siga activa y los
+// guarda en el contexto con el rol vigente de la cuenta.
+func authorizeClaims(c *gin.Context, claims *auth.Claims, lookup AccountStatusFunc) {
+	// Un token válido no basta: la cuenta debe seguir existiendo y estar habilitada.
+	// Así una baja o eliminación retira el acceso de inmediato, sin esperar a que expire el token.
+	if lookup == nil {
+		log.Printf("Error: no hay forma de verificar el estado de la cuenta %d", claims.UserID)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error interno del servidor"})
+		return
+	}
+	status, role, err := lookup(claims.UserID)
+	if err != nil {
+		log.Printf("Error al verificar el estado de la cuenta %d: %v", claims.UserID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error interno del servidor"})
+		return
+	}
+	if !status.CanLogin() { // Estado vacío: el usuario no existe o fue eliminado
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "la cuenta no está activa"})
+		return
+	}
+	// El rol del token es el del momento del inicio de sesión; un cambio de rol posterior
+	// (p. ej. quitar el rol de administrador) debe aplicarse ya en AuthorizeRoles.
+	claims.Role = role
+
+	// Guardar los claims en el contexto de Gin para uso posterior en los handlers
+	c.Set(authorizationPayloadKey, claims)
+	c.Next() // Continuar con el siguiente handler en la cadena
 }
 
 // AuthorizeRole es un middleware para verificar si el usuario tiene un rol específico.
 // Debe usarse DESPUÉS de AuthMiddleware.
 func AuthorizeRole(requiredRole models.Role) gin.HandlerFunc {
+	return AuthorizeRoles(requiredRole)
+}
+
+// AuthorizeRoles permite el paso si el usuario tiene cualquiera de los roles indicados
+// (p. ej. administradores e instructores en la gestión de cursos).
+// Debe usarse DESPUÉS de AuthMiddleware.
+func AuthorizeRoles(allowedRoles ...models.Role) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		payload, exists := c.Get(authorizationPayloadKey)
 		if !exists {
@@ -70,13 +166,14 @@ func AuthorizeRole(requiredRole models.Role) gin.HandlerFunc {
 			return
 		}
 
-		if claims.Role != requiredRole {
-			log.Printf("Acceso denegado para el usuario %s (rol %s). Se requiere rol: %s", claims.Username, claims.Role, requiredRole)
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "no tienes permiso para realizar esta acción"})
-			return
+		for _, role := range allowedRoles {
+			if claims.Role == role {
+				c.Next()
+				return
+			}
 		}
-
-		c.Next()
+		log.Printf("Acceso denegado para el usuario %s (rol %s). Se requiere rol: %v", claims.Username, claims.Role, allowedRoles)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "no tienes permiso para realizar esta acción"})
 	}
 }
 
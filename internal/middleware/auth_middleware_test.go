@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Unikyri/yamerito-mvp/internal/auth"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddlewareUsesCurrentAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET_KEY", "clave-de-prueba")
+	// El token dice administrador; lo que vale es lo que devuelve la consulta.
+	token, err := auth.GenerateJWT(7, "ana", models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account := func(status models.UserStatus, role models.Role, err error) AccountStatusFunc {
+		return func(userID uint) (models.UserStatus, models.Role, error) {
+			if userID != 7 {
+				t.Errorf("se consultó la cuenta %d, se esperaba 7", userID)
+			}
+			return status, role, err
+		}
+	}
+	tests := []struct {
+		name   string
+		lookup AccountStatusFunc
+		want   int
+	}{
+		{"administrador activo", account(models.StatusActive, models.RoleAdmin, nil), http.StatusOK},
+		{"rol retirado después del inicio de sesión", account(models.StatusActive, models.RoleEmployee, nil), http.StatusForbidden},
+		{"cuenta dada de baja", account(models.StatusOffboarded, models.RoleAdmin, nil), http.StatusUnauthorized},
+		{"cuenta eliminada", account("", "", nil), http.StatusUnauthorized},
+		{"error al consultar", account("", "", errors.New("conexión perdida")), http.StatusInternalServerError},
+		{"sin consulta", nil, http.StatusInternalServerError},
+		{"sin base de datos", AccountStatusFromDB(nil), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/admin", AuthMiddleware(tt.lookup), AuthorizeRole(models.RoleAdmin), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, se esperaba %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
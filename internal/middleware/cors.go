@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// opaqueOrigin es el Origin que envían los documentos con origen opaco, como las páginas de
+// un paquete SCORM servidas en un sandbox sin allow-same-origin.
+const opaqueOrigin = "null"
+
+// CORS configura los orígenes que pueden llamar a la API.
+// Para desarrollo, podemos ser un poco más permisivos.
+// Para producción, deberías restringir los orígenes a tu dominio de frontend real.
+// opaqueOriginPaths son los prefijos de ruta que aceptan lecturas desde un origen opaco
+// (p. ej. services.ScormContentPath); el resto de la API lo rechaza.
+func CORS(opaqueOriginPaths ...string) gin.HandlerFunc {
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = []string{"http://localhost:5173"} // Puerto común de Vite en `wails dev`
+	// Si usas `wails build` y sirves desde file:// o un localhost diferente para el frontend en prod,
+	// podrías necesitar añadir más orígenes o usar corsConfig.AllowAllOrigins = true (menos seguro).
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "If-Match", "If-None-Match", "Last-Event-ID", "X-Request-ID", "X-Experience-API-Version"} // Añadir Authorization para JWT e If-Match para concurrencia optimista
+	corsConfig.ExposeHeaders = []string{"ETag", "Accept-Patch", "X-Request-ID", "X-Experience-API-Version", "X-Experience-API-Consistent-Through"}
+	corsConfig.AllowOriginWithContextFunc = func(c *gin.Context, origin string) bool {
+		return allowOpaqueOrigin(c, origin, opaqueOriginPaths)
+	}
+	return cors.New(corsConfig)
+}
+
+// allowOpaqueOrigin deja que un SCO lea los archivos de su propio paquete (p. ej. con
+// fetch o XMLHttpRequest), que el navegador envía con Origin "null". Solo se permiten
+// lecturas bajo alguno de paths.
+func allowOpaqueOrigin(c *gin.Context, origin string, paths []string) bool {
+	if origin != opaqueOrigin {
+		return false
+	}
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+	for _, path := range paths {
+		if strings.HasPrefix(c.Request.URL.Path, path+"/") {
+			return true
+		}
+	}
+	return false
+}
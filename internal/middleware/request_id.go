@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	requestIDHeaderKey  = "X-Request-ID"
+	requestIDContextKey = "request_id" // Clave para guardar el ID de la solicitud en el contexto de Gin
+)
+
+// validRequestID limita los IDs aceptados desde el cliente para que no se cuele basura en los logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID asigna un identificador a cada solicitud (reutilizando X-Request-ID si el
+// cliente o un proxy ya lo envió) y lo devuelve en la respuesta, de modo que los eventos
+// de auditoría y los logs puedan correlacionarse.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeaderKey)
+		if !validRequestID.MatchString(requestID) {
+			buf := make([]byte, 16)
+			if _, err := rand.Read(buf); err == nil {
+				requestID = hex.EncodeToString(buf)
+			} else {
+				requestID = ""
+			}
+		}
+		c.Set(requestIDContextKey, requestID)
+		c.Header(requestIDHeaderKey, requestID)
+		c.Next()
+	}
+}
+
+// GetRequestID recupera el ID de la solicitud asignado por RequestID.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDContextKey)
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Claves conocidas de AppSetting.
+const (
+	// SettingSelfEditableProfileFields guarda (como arreglo JSON) los campos del perfil
+	// que un empleado puede editar por sí mismo desde PATCH /api/v1/me/profile.
+	SettingSelfEditableProfileFields = "profile.self_editable_fields"
+)
+
+// AppSetting almacena parámetros de configuración que un administrador puede cambiar
+// en tiempo de ejecución, sin necesidad de redeplegar ni tocar variables de entorno.
+type AppSetting struct {
+	Key       string    `gorm:"type:varchar(100);primaryKey" json:"key"`
+	Value     string    `gorm:"type:text" json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Acciones registradas en la auditoría.
+const (
+	AuditActionUserCreated           = "user.created"
+	AuditActionUserUpdated           = "user.updated"
+	AuditActionUserDeleted           = "user.deleted"
+	AuditActionUserProfileSelfEdit   = "user.profile_self_updated"
+	AuditActionUserAvatarUpdated     = "user.avatar_updated"
+	AuditActionUserAvatarDeleted     = "user.avatar_deleted"
+	AuditActionUserStatusChanged     = "user.status_changed"
+	AuditActionTransitionScheduled   = "user.transition_scheduled"
+	AuditActionTransitionCancelled   = "user.transition_cancelled"
+	AuditActionUserAccessRevoked     = "user.access_revoked"
+	AuditActionSettingsUpdated       = "settings.updated"
+	AuditActionLoginSucceeded        = "auth.login_succeeded"
+	AuditActionLoginFailed           = "auth.login_failed"
+	AuditActionDepartmentCreated     = "department.created"
+	AuditActionDepartmentUpdated     = "department.updated"
+	AuditActionDepartmentDeleted     = "department.deleted"
+	AuditActionTeamCreated           = "team.created"
+	AuditActionTeamUpdated           = "team.updated"
+	AuditActionTeamDeleted           = "team.deleted"
+	AuditActionCustomFieldCreated    = "custom_field.created"
+	AuditActionCustomFieldUpdated    = "custom_field.updated"
+	AuditActionCustomFieldDeleted    = "custom_field.deleted"
+	AuditActionInvitationCreated     = "invitation.created"
+	AuditActionInvitationResent      = "invitation.resent"
+	AuditActionInvitationRevoked     = "invitation.revoked"
+	AuditActionInvitationAccepted    = "invitation.accepted"
+	AuditActionWebhookCreated        = "webhook.created"
+	AuditActionWebhookUpdated        = "webhook.updated"
+	AuditActionWebhookDeleted        = "webhook.deleted"
+	AuditActionWebhookSecretRotated  = "webhook.secret_rotated"
+	AuditActionWebhookRedelivered    = "webhook.redelivered"
+	AuditActionJobRetried            = "job.retried"
+	AuditActionJobCancelled          = "job.cancelled"
+	AuditActionJobScheduleUpdated    = "job_schedule.updated"
+	AuditActionCourseCreated         = "course.created"
+	AuditActionCourseUpdated         = "course.updated"
+	AuditActionCourseDeleted         = "course.deleted"
+	AuditActionCourseStatusChanged   = "course.status_changed"
+	AuditActionCourseAssigned        = "course.assigned"
+	AuditActionEnrollmentCreated     = "enrollment.created"
+	AuditActionEnrollmentCancelled   = "enrollment.cancelled"
+	AuditActionAssignmentRuleCreated = "assignment_rule.created"
+	AuditActionAssignmentRuleUpdated = "assignment_rule.updated"
+	AuditActionAssignmentRuleDeleted = "assignment_rule.deleted"
+	AuditActionQuizCreated           = "quiz.created"
+	AuditActionQuizUpdated           = "quiz.updated"
+	AuditActionQuizDeleted           = "quiz.deleted"
+	AuditActionQuestionBankCreated   = "question_bank.created"
+	AuditActionQuestionBankUpdated   = "question_bank.updated"
+	AuditActionQuestionBankDeleted   = "question_bank.deleted"
+	AuditActionCertTemplateCreated   = "certificate_template.created"
+	AuditActionCertTemplateUpdated   = "certificate_template.updated"
+	AuditActionCertTemplateDeleted   = "certificate_template.deleted"
+	AuditActionCertificateRevoked    = "certificate.revoked"
+	AuditActionLearningPathCreated   = "learning_path.created"
+	AuditActionLearningPathUpdated   = "learning_path.updated"
+	AuditActionLearningPathDeleted   = "learning_path.deleted"
+	AuditActionLearningPathAssigned  = "learning_path.assigned"
+	AuditActionXAPIClientCreated     = "xapi_client.created"
+	AuditActionXAPIClientUpdated     = "xapi_client.updated"
+	AuditActionXAPIClientDeleted     = "xapi_client.deleted"
+	AuditActionXAPISecretRotated     = "xapi_client.secret_rotated"
+	AuditActionXAPIActivityLinked    = "xapi_client.activity_linked"
+	AuditActionXAPIActivityUnlinked  = "xapi_client.activity_unlinked"
+)
+
+// Tipos de objetivo de un evento de auditoría.
+const (
+	AuditTargetUser           = "user"
+	AuditTargetSetting        = "setting"
+	AuditTargetDepartment     = "department"
+	AuditTargetTeam           = "team"
+	AuditTargetCustomField    = "custom_field"
+	AuditTargetInvitation     = "invitation"
+	AuditTargetWebhook        = "webhook"
+	AuditTargetJob            = "job"
+	AuditTargetJobSchedule    = "job_schedule"
+	AuditTargetCourse         = "course"
+	AuditTargetEnrollment     = "enrollment"
+	AuditTargetAssignmentRule = "assignment_rule"
+	AuditTargetQuiz           = "quiz"
+	AuditTargetQuestionBank   = "question_bank"
+	AuditTargetCertTemplate   = "certificate_template"
+	AuditTargetCertificate    = "certificate"
+	AuditTargetLearningPath   = "learning_path"
+	AuditTargetXAPIClient     = "xapi_client"
+)
+
+// ErrAuditEventImmutable se devuelve si algún código intenta modificar o borrar un evento.
+var ErrAuditEventImmutable = errors.New("los eventos de auditoría son inmutables")
+
+// AuditEvent es un registro de solo inserción de una acción administrativa o de seguridad.
+// Cada evento guarda el hash del anterior (PrevHash) y el suyo propio (Hash), formando una
+// cadena: alterar o borrar cualquier fila rompe la verificación de todas las siguientes.
+type AuditEvent struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	OccurredAt    time.Time       `gorm:"not null;index" json:"occurred_at"`
+	ActorID       *uint           `gorm:"index" json:"actor_id,omitempty"`
+	ActorUsername string          `gorm:"type:varchar(50)" json:"actor_username,omitempty"`
+	Action        string          `gorm:"type:varchar(50);not null;index" json:"action"`
+	TargetType    string          `gorm:"type:varchar(50);index:idx_audit_target" json:"target_type,omitempty"`
+	TargetID      *uint           `gorm:"index:idx_audit_target" json:"target_id,omitempty"`
+	Before        json.RawMessage `gorm:"type:mediumtext" json:"before,omitempty"`
+	After         json.RawMessage `gorm:"type:mediumtext" json:"after,omitempty"`
+	Diff          json.RawMessage `gorm:"type:mediumtext" json:"diff,omitempty"`
+	IP            string          `gorm:"type:varchar(45)" json:"ip,omitempty"`
+	RequestID     string          `gorm:"type:varchar(64);index" json:"request_id,omitempty"`
+	// PrevHash es único: dos eventos no pueden encadenarse al mismo predecesor,
+	// lo que impide bifurcaciones aunque dos transacciones escriban a la vez.
+	PrevHash string `gorm:"type:char(64);uniqueIndex;not null" json:"prev_hash"`
+	Hash     string `gorm:"type:char(64);uniqueIndex;not null" json:"hash"`
+}
+
+// ComputeHash calcula el hash SHA-256 del evento encadenado con PrevHash.
+// OccurredAt debe estar truncado a milisegundos (precisión de la columna DATETIME(3))
+// para que el hash recalculado al leer desde la BD coincida.
+func (e *AuditEvent) ComputeHash() string {
+	optionalID := func(id *uint) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*id), 10)
+	}
+	fields := []string{
+		e.PrevHash,
+		e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		optionalID(e.ActorID),
+		e.ActorUsername,
+		e.Action,
+		e.TargetType,
+		optionalID(e.TargetID),
+		string(e.Before),
+		string(e.After),
+		string(e.Diff),
+		e.IP,
+		e.RequestID,
+	}
+	// Cada campo se prefija con su longitud para que no existan dos combinaciones
+	// distintas de campos que produzcan la misma cadena.
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(strconv.Itoa(len(f)))
+		b.WriteByte(':')
+		b.WriteString(f)
+		b.WriteByte('|')
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// BeforeUpdate impide modificar eventos ya registrados.
+func (e *AuditEvent) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditEventImmutable
+}
+
+// BeforeDelete impide borrar eventos ya registrados.
+func (e *AuditEvent) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditEventImmutable
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// El prefijo de longitud evita que dos repartos distintos del mismo texto entre campos
+// consecutivos produzcan la misma cadena a hashear, aunque los campos contengan '|'.
+func TestAuditEventHashLengthPrefix(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 6_000_000, time.UTC)
+	a := AuditEvent{OccurredAt: at, ActorUsername: "a|b", Action: "c"}
+	b := AuditEvent{OccurredAt: at, ActorUsername: "a", Action: "b|c"}
+	if a.ComputeHash() == b.ComputeHash() {
+		t.Fatalf("campos %q+%q y %q+%q producen el mismo hash", a.ActorUsername, a.Action, b.ActorUsername, b.Action)
+	}
+
+	c := AuditEvent{OccurredAt: at, IP: "10.0.0.1", RequestID: ""}
+	d := AuditEvent{OccurredAt: at, IP: "", RequestID: "10.0.0.1"}
+	if c.ComputeHash() == d.ComputeHash() {
+		t.Fatal("mover un valor a un campo vecino vacío no cambia el hash")
+	}
+}
+
+func TestAuditEventHashIsDeterministic(t *testing.T) {
+	id := uint(7)
+	e := AuditEvent{
+		OccurredAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		ActorID:    &id,
+		Action:     AuditActionUserCreated,
+		After:      []byte(`{"id":7}`),
+	}
+	if e.ComputeHash() != e.ComputeHash() {
+		t.Fatal("ComputeHash no es determinista")
+	}
+	local := e
+	local.OccurredAt = e.OccurredAt.In(time.FixedZone("UTC-5", -5*3600))
+	if local.ComputeHash() != e.ComputeHash() {
+		t.Fatal("el hash depende de la zona horaria de OccurredAt")
+	}
+}
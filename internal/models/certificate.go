@@ -0,0 +1,113 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Orientaciones de página de una plantilla de certificado.
+const (
+	CertificateLandscape = "landscape"
+	CertificatePortrait  = "portrait"
+)
+
+// Tipos de elemento de una plantilla de certificado.
+const (
+	CertificateElementText      = "text"      // Texto con marcadores {{...}}
+	CertificateElementSignature = "signature" // Imagen de la firma de la plantilla
+	CertificateElementLine      = "line"      // Línea horizontal (p. ej. sobre la firma)
+)
+
+// CertificateElement es un elemento posicionado de una plantilla. Las medidas son en
+// milímetros desde la esquina superior izquierda; en los textos, Y es la línea base.
+type CertificateElement struct {
+	Type     string  `json:"type"`
+	Text     string  `json:"text,omitempty"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Width    float64 `json:"width,omitempty"`  // Firma y línea
+	Height   float64 `json:"height,omitempty"` // Firma
+	FontSize float64 `json:"font_size,omitempty"`
+	Bold     bool    `json:"bold,omitempty"`
+	Italic   bool    `json:"italic,omitempty"`
+	Align    string  `json:"align,omitempty"` // left (por defecto), center o right respecto de X
+	Color    string  `json:"color,omitempty"` // #RRGGBB
+}
+
+// CertificateTemplate es el diseño de los certificados de finalización. Version aumenta
+// con cada cambio de diseño o de firma; los certificados guardan la versión con la que se
+// generaron para poder regenerar los desactualizados.
+type CertificateTemplate struct {
+	ID              uint                 `gorm:"primaryKey" json:"id"`
+	Name            string               `gorm:"size:150;not null" json:"name"`
+	Orientation     string               `gorm:"type:varchar(10);not null;default:'landscape'" json:"orientation"`
+	BackgroundColor string               `gorm:"size:7" json:"background_color,omitempty"`
+	BorderColor     string               `gorm:"size:7" json:"border_color,omitempty"`
+	Elements        []CertificateElement `gorm:"type:text;serializer:json;not null" json:"elements"`
+	// SignatureKey es la clave en el almacenamiento de blobs de la imagen de la firma (JPEG).
+	SignatureKey       string         `gorm:"size:255" json:"-"`
+	SignatureUpdatedAt *time.Time     `json:"signature_updated_at,omitempty"`
+	IsDefault          bool           `gorm:"not null;default:false" json:"is_default"` // Para los cursos sin plantilla propia
+	Version            int            `gorm:"not null;default:1" json:"version"`
+	CreatedByID        *uint          `json:"created_by_id,omitempty"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ComplianceStatus es la situación de un empleado respecto de las certificaciones con vigencia.
+type ComplianceStatus string
+
+const (
+	ComplianceCompliant    ComplianceStatus = "compliant"     // Todas sus certificaciones vigentes
+	ComplianceExpiringSoon ComplianceStatus = "expiring_soon" // Alguna entró en el plazo de recertificación
+	ComplianceExpired      ComplianceStatus = "expired"       // Alguna venció sin renovarse
+)
+
+// ParseComplianceStatus convierte una cadena a ComplianceStatus.
+func ParseComplianceStatus(s string) (ComplianceStatus, error) {
+	switch status := ComplianceStatus(strings.ToLower(strings.TrimSpace(s))); status {
+	case ComplianceCompliant, ComplianceExpiringSoon, ComplianceExpired:
+		return status, nil
+	default:
+		return "", fmt.Errorf("estado de cumplimiento inválido: '%s'", s)
+	}
+}
+
+// Certificate es el certificado emitido al completar un curso o una ruta. Los datos que se
+// imprimen se copian al emitirlo, para que el certificado y su verificación no cambien si
+// después se renombra el curso o el empleado; una reemisión solo cambia el diseño.
+type Certificate struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"not null;index" json:"user_id"`
+	// Un certificado es de un curso (CourseID y EnrollmentID) o de una ruta de aprendizaje
+	// (PathID y PathEnrollmentID); los del otro caso quedan en nil. En los de una ruta,
+	// CourseTitle es el título de la ruta.
+	CourseID         *uint `gorm:"index" json:"course_id,omitempty"`
+	EnrollmentID     *uint `gorm:"uniqueIndex:idx_certificate_enrollment_cycle,priority:1" json:"enrollment_id,omitempty"`
+	Cycle            int   `gorm:"not null;default:1;uniqueIndex:idx_certificate_enrollment_cycle,priority:2" json:"cycle"` // Vuelta de certificación de la inscripción
+	PathID           *uint `gorm:"index" json:"path_id,omitempty"`
+	PathEnrollmentID *uint `gorm:"uniqueIndex" json:"path_enrollment_id,omitempty"`
+	// Code es el código de verificación público impreso en el certificado.
+	Code            string     `gorm:"size:32;not null;uniqueIndex" json:"code"`
+	RecipientName   string     `gorm:"size:201;not null" json:"recipient_name"`
+	CourseTitle     string     `gorm:"size:200;not null" json:"course_title"`
+	Score           *int       `json:"score,omitempty"` // Porcentaje medio de los cuestionarios del curso, si tiene
+	CompletedAt     time.Time  `gorm:"not null" json:"completed_at"`
+	ExpiresAt       *time.Time `gorm:"index" json:"expires_at,omitempty"` // Nil si el curso no tiene vigencia
+	ReminderDays    *int       `json:"-"`                                 // Umbral (días antes del vencimiento) del último aviso enviado
+	TemplateID      *uint      `gorm:"index" json:"template_id,omitempty"`
+	TemplateVersion int        `gorm:"not null;default:0" json:"template_version"`
+	BlobKey         string     `gorm:"size:255" json:"-"` // PDF generado con el diseño vigente
+	IssuedAt        time.Time  `gorm:"not null" json:"issued_at"`
+	ReissuedAt      *time.Time `json:"reissued_at,omitempty"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason   string     `gorm:"size:255" json:"revoked_reason,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	Course *Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CourseStatus es el estado de publicación de un curso.
+type CourseStatus string
+
+const (
+	CourseDraft     CourseStatus = "draft"     // En preparación: solo lo ven administradores e instructores
+	CoursePublished CourseStatus = "published" // Visible en el catálogo de los empleados
+	CourseArchived  CourseStatus = "archived"  // Retirado del catálogo; no se puede editar
+)
+
+// ParseCourseStatus convierte una cadena a CourseStatus.
+func ParseCourseStatus(s string) (CourseStatus, error) {
+	switch status := CourseStatus(strings.ToLower(strings.TrimSpace(s))); status {
+	case CourseDraft, CoursePublished, CourseArchived:
+		return status, nil
+	default:
+		return "", fmt.Errorf("estado de curso inválido: '%s'", s)
+	}
+}
+
+// courseTransitions define los cambios de estado permitidos.
+var courseTransitions = map[CourseStatus][]CourseStatus{
+	CourseDraft:     {CoursePublished, CourseArchived},
+	CoursePublished: {CourseDraft, CourseArchived},
+	CourseArchived:  {CourseDraft},
+}
+
+// CanTransitionTo indica si un curso puede pasar del estado s a to.
+func (s CourseStatus) CanTransitionTo(to CourseStatus) bool {
+	for _, allowed := range courseTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Course es un curso del catálogo de formación. Se compone de módulos ordenados, y cada
+// módulo de lecciones ordenadas.
+type Course struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Title       string       `gorm:"size:200;not null" json:"title"`
+	Summary     string       `gorm:"size:500" json:"summary,omitempty"`
+	Description string       `gorm:"type:text" json:"description,omitempty"`
+	Category    string       `gorm:"size:100;index" json:"category,omitempty"`
+	Status      CourseStatus `gorm:"type:varchar(20);not null;default:draft;index" json:"status"`
+	// InstructorID es el instructor responsable; puede editar el curso además de los administradores.
+	InstructorID *uint `gorm:"index" json:"instructor_id,omitempty"`
+	CreatedByID  *uint `json:"created_by_id,omitempty"`
+	// CertificateTemplateID es la plantilla de sus certificados; si es nil se usa la
+	// plantilla predeterminada.
+	CertificateTemplateID *uint `json:"certificate_template_id,omitempty"`
+	// ValidityDays es la vigencia de sus certificados (0 = no vencen). RecertifyLeadDays es
+	// cuántos días antes del vencimiento se vuelve a inscribir al empleado; desde ese momento
+	// su certificación figura como "por vencer".
+	ValidityDays      int            `gorm:"not null;default:0" json:"validity_days"`
+	RecertifyLeadDays int            `gorm:"not null;default:30" json:"recertify_lead_days"`
+	PublishedAt       *time.Time     `json:"published_at,omitempty"`
+	ArchivedAt        *time.Time     `json:"archived_at,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Modules []Module `gorm:"foreignKey:CourseID" json:"modules,omitempty"`
+}
+
+// Module es una sección de un curso. Position define el orden dentro del curso (desde 1).
+type Module struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	CourseID    uint           `gorm:"not null;index:idx_module_course_position,priority:1" json:"course_id"`
+	Title       string         `gorm:"size:200;not null" json:"title"`
+	Description string         `gorm:"type:text" json:"description,omitempty"`
+	Position    int            `gorm:"not null;index:idx_module_course_position,priority:2" json:"position"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Lessons []Lesson `gorm:"foreignKey:ModuleID" json:"lessons,omitempty"`
+}
+
+// TableName evita el nombre genérico "modules".
+func (Module) TableName() string {
+	return "course_modules"
+}
+
+// LessonType es el tipo de contenido de una lección.
+type LessonType string
+
+const (
+	LessonText     LessonType = "text"     // Contenido en Content (Markdown)
+	LessonVideo    LessonType = "video"    // ContentURL apunta al video
+	LessonDocument LessonType = "document" // ContentURL apunta al documento (PDF, presentación...)
+	LessonLink     LessonType = "link"     // Recurso externo en ContentURL
+	LessonScorm    LessonType = "scorm"    // Paquete SCORM subido aparte (ScormPackage)
+)
+
+// ParseLessonType convierte una cadena a LessonType.
+func ParseLessonType(s string) (LessonType, error) {
+	switch t := LessonType(strings.ToLower(strings.TrimSpace(s))); t {
+	case LessonText, LessonVideo, LessonDocument, LessonLink, LessonScorm:
+		return t, nil
+	default:
+		return "", fmt.Errorf("tipo de lección inválido: '%s'", s)
+	}
+}
+
+// Lesson es una unidad de contenido dentro de un módulo. Position define el orden dentro
+// del módulo (desde 1).
+type Lesson struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	ModuleID        uint           `gorm:"not null;index:idx_lesson_module_position,priority:1" json:"module_id"`
+	Title           string         `gorm:"size:200;not null" json:"title"`
+	Type            LessonType     `gorm:"type:varchar(20);not null" json:"type"`
+	Content         string         `gorm:"type:mediumtext" json:"content,omitempty"`
+	ContentURL      string         `gorm:"size:500" json:"content_url,omitempty"`
+	DurationMinutes int            `gorm:"not null;default:0" json:"duration_minutes"`
+	Position        int            `gorm:"not null;index:idx_lesson_module_position,priority:2" json:"position"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Tipos de dato admitidos para un campo personalizado.
+const (
+	CustomFieldTypeText    = "text"
+	CustomFieldTypeNumber  = "number"
+	CustomFieldTypeDate    = "date" // YYYY-MM-DD
+	CustomFieldTypeBoolean = "boolean"
+	CustomFieldTypeEnum    = "enum"
+)
+
+// Visibilidad de un campo personalizado para el propio empleado.
+const (
+	CustomFieldAdminOnly        = "admin_only"        // Solo lo ven los administradores
+	CustomFieldEmployeeVisible  = "employee_visible"  // El empleado lo ve en su perfil
+	CustomFieldEmployeeEditable = "employee_editable" // El empleado lo ve y lo puede editar
+)
+
+// CustomFieldDefinition describe un campo adicional de los empleados definido por un
+// administrador (p. ej. número de empleado, turno o planta).
+type CustomFieldDefinition struct {
+	ID    uint   `gorm:"primaryKey" json:"id"`
+	Key   string `gorm:"type:varchar(50);uniqueIndex;not null" json:"key"` // Identificador estable usado en la API
+	Label string `gorm:"size:100;not null" json:"label"`
+	Type  string `gorm:"type:varchar(20);not null" json:"type"`
+	// Required obliga a informar el campo al crear un usuario y prohíbe vaciarlo después.
+	Required bool `gorm:"not null;default:false" json:"required"`
+	// Options son los valores permitidos para los campos de tipo enum.
+	Options []string `gorm:"type:text;serializer:json" json:"options,omitempty"`
+	// ValidationRegex es una expresión regular opcional que deben cumplir los campos de texto.
+	ValidationRegex string    `gorm:"size:255" json:"validation_regex,omitempty"`
+	Visibility      string    `gorm:"type:varchar(20);not null;default:'admin_only'" json:"visibility"`
+	SortOrder       int       `gorm:"not null;default:0" json:"sort_order"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// CustomFieldValue guarda el valor de un campo personalizado para un usuario.
+// Value está en forma canónica (números sin ceros sobrantes, fechas YYYY-MM-DD,
+// booleanos "true"/"false") para poder filtrar por igualdad.
+type CustomFieldValue struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"not null;uniqueIndex:idx_custom_value_user_definition,priority:1" json:"user_id"`
+	DefinitionID uint      `gorm:"not null;uniqueIndex:idx_custom_value_user_definition,priority:2;index:idx_custom_value_lookup,priority:1" json:"definition_id"`
+	Value        string    `gorm:"type:varchar(500);not null;index:idx_custom_value_lookup,priority:2" json:"value"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
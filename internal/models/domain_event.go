@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Tipos de evento de dominio. Los servicios los registran en la misma transacción que el
+// cambio; los suscriptores (webhooks, notificaciones, índice de búsqueda...) los reciben después.
+const (
+	DomainEventUserCreated       = "UserCreated"
+	DomainEventUserUpdated       = "UserUpdated"
+	DomainEventUserRoleChanged   = "UserRoleChanged" // Se registra además de UserUpdated
+	DomainEventUserStatusChanged = "UserStatusChanged"
+	DomainEventUserDeleted       = "UserDeleted"
+
+	DomainEventCoursePublished = "CoursePublished"
+	DomainEventCourseArchived  = "CourseArchived"
+
+	DomainEventEnrollmentCompleted = "EnrollmentCompleted"
+
+	DomainEventCertificateIssued   = "CertificateIssued"
+	DomainEventCertificateExpiring = "CertificateExpiring" // Recordatorio de vencimiento próximo o cumplido
+	DomainEventRecertificationDue  = "RecertificationDue"  // Se volvió a inscribir al usuario para renovar la certificación
+
+	DomainEventLearningPathCompleted = "LearningPathCompleted"
+)
+
+// Tipos de entidad a los que se refiere un evento de dominio.
+const (
+	AggregateUser        = "user"
+	AggregateCourse      = "course"
+	AggregateEnrollment  = "enrollment"
+	AggregateCertificate = "certificate"
+	// Los eventos de rutas se refieren a la asignación de la ruta al usuario.
+	AggregatePathEnrollment = "learning_path_enrollment"
+)
+
+// DomainEvent es un hecho ocurrido en el dominio ("se creó el usuario 7"). Funciona como
+// bandeja de salida: se inserta junto con el cambio y, si la transacción se confirma, el
+// despachador lo reparte entre los suscriptores (ver events.Bus).
+type DomainEvent struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	EventID       string `gorm:"type:varchar(64);not null;uniqueIndex" json:"event_id"`
+	Type          string `gorm:"type:varchar(50);not null;index" json:"type"`
+	AggregateType string `gorm:"type:varchar(30);not null;index:idx_domain_event_aggregate,priority:1" json:"aggregate_type"`
+	AggregateID   uint   `gorm:"not null;index:idx_domain_event_aggregate,priority:2" json:"aggregate_id"`
+	ActorID       *uint  `json:"actor_id,omitempty"`
+	ActorUsername string `gorm:"size:50" json:"actor_username,omitempty"`
+	RequestID     string `gorm:"size:64" json:"request_id,omitempty"`
+	// Payload es una foto de los datos en el momento del cambio, en JSON.
+	Payload    json.RawMessage `gorm:"type:mediumtext;not null" json:"payload"`
+	OccurredAt time.Time       `gorm:"not null" json:"occurred_at"`
+	// DispatchedAt indica cuándo se crearon las entregas para los suscriptores; nil si aún no.
+	DispatchedAt *time.Time `gorm:"index" json:"dispatched_at,omitempty"`
+}
+
+// Estados de la entrega de un evento a un suscriptor.
+const (
+	DomainEventDeliveryPending = "pending"
+	DomainEventDeliveryDone    = "done"
+	DomainEventDeliveryFailed  = "failed" // Agotó los reintentos
+)
+
+// DomainEventDelivery es la entrega de un evento a un suscriptor concreto. Cada suscriptor
+// avanza por su cuenta: si uno falla, se reintenta solo ese y los demás no se repiten.
+type DomainEventDelivery struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	EventID    uint   `gorm:"not null;uniqueIndex:idx_domain_event_subscriber,priority:1" json:"event_id"`
+	Subscriber string `gorm:"type:varchar(50);not null;uniqueIndex:idx_domain_event_subscriber,priority:2" json:"subscriber"`
+	Status     string `gorm:"type:varchar(20);not null;default:pending;index:idx_domain_event_delivery_due,priority:1" json:"status"`
+	Attempts   int    `gorm:"not null;default:0" json:"attempts"`
+	// NextAttemptAt funciona como en OutboxEmail: se adelanta mientras la entrega está en curso.
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_domain_event_delivery_due,priority:2" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Event lo carga el despachador. No se declara como asociación: GORM la interpretaría
+	// como has-one sobre DomainEvent.EventID, que es el identificador público del evento.
+	Event DomainEvent `gorm:"-" json:"-"`
+}
+
+// UserSearchDocument es el texto normalizado por el que se busca a un usuario en
+// GET /admin/users?q=. Lo mantiene el suscriptor del índice de búsqueda.
+type UserSearchDocument struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	Content   string    `gorm:"type:text;not null" json:"content"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
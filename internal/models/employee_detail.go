@@ -8,14 +8,33 @@ import (
 
 // EmployeeDetail contiene información detallada sobre un empleado, vinculada a un User.
 type EmployeeDetail struct {
-	ID            uint           `gorm:"primarykey" json:"id"`
-	UserID        uint           `gorm:"uniqueIndex;not null" json:"user_id"` // Clave foránea a users.id, debe ser única
-	Name          string         `gorm:"size:100" json:"name"`
-	LastName      string         `gorm:"size:100" json:"last_name"`
-	Email         string         `gorm:"size:100;uniqueIndex" json:"email"` // Email del empleado, también único
-	PhoneNumber   string         `gorm:"size:20;index" json:"phone_number,omitempty"`
-	Position      string         `gorm:"size:100" json:"position,omitempty"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	ID           uint   `gorm:"primarykey" json:"id"`
+	UserID       uint   `gorm:"uniqueIndex;not null" json:"user_id"` // Clave foránea a users.id, debe ser única
+	Name         string `gorm:"size:100" json:"name"`
+	LastName     string `gorm:"size:100" json:"last_name"`
+	Email        string `gorm:"size:100;uniqueIndex" json:"email"` // Email del empleado, también único
+	PhoneNumber  string `gorm:"size:20;index" json:"phone_number,omitempty"`
+	Position     string `gorm:"size:100" json:"position,omitempty"`
+	DepartmentID *uint  `gorm:"index" json:"department_id,omitempty"`
+	TeamID       *uint  `gorm:"index" json:"team_id,omitempty"`
+	ManagerID    *uint  `gorm:"index" json:"manager_id,omitempty"` // users.id del responsable directo
+	// HireDate es el primer día de trabajo y TerminationDate el último (sin hora).
+	HireDate        *time.Time `gorm:"type:date" json:"hire_date,omitempty"`
+	TerminationDate *time.Time `gorm:"type:date" json:"termination_date,omitempty"`
+	// AvatarKey es el prefijo en el almacenamiento de blobs de la foto de perfil vigente
+	// (cada tamaño se guarda como <AvatarKey>/<tamaño>.jpg). Vacío si no tiene foto.
+	AvatarKey       string         `gorm:"size:255" json:"-"`
+	AvatarUpdatedAt *time.Time     `json:"avatar_updated_at,omitempty"`
+	Version         uint           `gorm:"not null;default:1" json:"version"` // Control de concurrencia optimista
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// BeforeCreate asegura que todo detalle de empleado nuevo comience en la versión 1.
+func (d *EmployeeDetail) BeforeCreate(tx *gorm.DB) error {
+	if d.Version == 0 {
+		d.Version = 1
+	}
+	return nil
 }
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EnrollmentStatus es el estado de la inscripción de un usuario en un curso.
+type EnrollmentStatus string
+
+const (
+	EnrollmentAssigned   EnrollmentStatus = "assigned"    // Asignado, todavía sin empezar
+	EnrollmentInProgress EnrollmentStatus = "in_progress" // El usuario ya abrió alguna lección
+	EnrollmentCompleted  EnrollmentStatus = "completed"
+	EnrollmentCancelled  EnrollmentStatus = "cancelled" // Asignación retirada por un administrador
+)
+
+// Orígenes de una inscripción.
+const (
+	EnrollmentSourceManual = "manual" // Asignación directa o masiva de un administrador
+	EnrollmentSourceRule   = "rule"   // Regla de asignación automática
+	EnrollmentSourcePath   = "path"   // Curso desbloqueado de una ruta de aprendizaje asignada
+)
+
+// Enrollment es la inscripción de un usuario en un curso. Hay como mucho una por usuario y
+// curso: volver a asignar un curso cancelado reactiva la inscripción existente.
+type Enrollment struct {
+	ID       uint             `gorm:"primaryKey" json:"id"`
+	UserID   uint             `gorm:"not null;uniqueIndex:idx_enrollment_user_course,priority:1" json:"user_id"`
+	CourseID uint             `gorm:"not null;uniqueIndex:idx_enrollment_user_course,priority:2;index" json:"course_id"`
+	Status   EnrollmentStatus `gorm:"type:varchar(20);not null;default:assigned;index" json:"status"`
+	Source   string           `gorm:"type:varchar(20);not null" json:"source"`
+	// RuleID es la regla que creó la inscripción, si Source es "rule".
+	RuleID       *uint      `gorm:"index" json:"rule_id,omitempty"`
+	AssignedByID *uint      `json:"assigned_by_id,omitempty"`
+	Cycle        int        `gorm:"not null;default:1" json:"cycle"` // Vuelta de certificación: aumenta en cada recertificación
+	DueAt        *time.Time `gorm:"index" json:"due_at,omitempty"`
+	AssignedAt   time.Time  `gorm:"not null" json:"assigned_at"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	Course *Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+}
+
+// IsOpen indica si la inscripción sigue pendiente de completar.
+func (e *Enrollment) IsOpen() bool {
+	return e.Status == EnrollmentAssigned || e.Status == EnrollmentInProgress
+}
+
+// AssignmentRule inscribe automáticamente en un curso a los usuarios que cumplen todos sus
+// criterios (los vacíos no filtran): al crearla, si se pide, a los existentes, y después a
+// cada usuario nuevo.
+type AssignmentRule struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	CourseID     uint   `gorm:"not null;index" json:"course_id"`
+	Role         Role   `gorm:"type:varchar(20)" json:"role,omitempty"`
+	Position     string `gorm:"size:100" json:"position,omitempty"`
+	DepartmentID *uint  `gorm:"index" json:"department_id,omitempty"`
+	// DueInDays fija el vencimiento de cada inscripción a N días de su creación; nil = sin vencimiento.
+	DueInDays   *int           `json:"due_in_days,omitempty"`
+	Active      bool           `gorm:"not null;default:true" json:"active"`
+	CreatedByID *uint          `json:"created_by_id,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Course *Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+}
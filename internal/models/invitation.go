@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Estados de una invitación, derivados de sus fechas.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// Invitation es el alta de un usuario pendiente de que el invitado elija su contraseña.
+// El usuario se crea en estado invited al emitir la invitación; el enlace enviado por
+// correo lleva un token firmado cuyo Nonce debe coincidir con el de la fila.
+type Invitation struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	UserID uint   `gorm:"not null;index" json:"user_id"`
+	Email  string `gorm:"size:100;not null;index" json:"email"`
+	// Language es el idioma de los correos de la invitación ("es", "en").
+	Language string `gorm:"type:varchar(5);not null;default:es" json:"language"`
+	// Nonce cambia en cada reenvío: solo el último enlace enviado es válido.
+	Nonce string `gorm:"type:varchar(64);not null" json:"-"`
+	// UsernamePending indica que el invitado debe elegir su nombre de usuario al aceptar.
+	UsernamePending bool       `gorm:"not null;default:false" json:"username_pending"`
+	ExpiresAt       time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt      *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	SentCount       int        `gorm:"not null;default:0" json:"sent_count"`
+	LastSentAt      *time.Time `json:"last_sent_at,omitempty"`
+	CreatedByID     *uint      `json:"created_by_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Status devuelve el estado de la invitación en el instante now.
+func (i *Invitation) Status(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationAccepted
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case !now.Before(i.ExpiresAt):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Estados de un trabajo en segundo plano.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed" // Agotó los reintentos o falló de forma permanente
+	JobCancelled = "cancelled"
+)
+
+// Job es un trabajo en segundo plano. Se encola con jobs.Enqueue (opcionalmente dentro de
+// la transacción del cambio que lo origina) y lo ejecuta un jobs.Worker, ya sea dentro del
+// servidor o en el binario cmd/worker.
+type Job struct {
+	ID       uint            `gorm:"primaryKey" json:"id"`
+	Type     string          `gorm:"type:varchar(100);not null;index" json:"type"`
+	Payload  json.RawMessage `gorm:"type:mediumtext" json:"payload,omitempty"`
+	Status   string          `gorm:"type:varchar(20);not null;default:queued;index:idx_job_claim,priority:1" json:"status"`
+	Priority int             `gorm:"not null;default:0" json:"priority"` // Mayor primero
+	RunAt    time.Time       `gorm:"not null;index:idx_job_claim,priority:2" json:"run_at"`
+	Attempts int             `gorm:"not null;default:0" json:"attempts"`
+	// MaxAttempts en 0 usa el valor configurado para el tipo de trabajo.
+	MaxAttempts int    `gorm:"not null;default:0" json:"max_attempts"`
+	LastError   string `gorm:"type:text" json:"last_error,omitempty"`
+	// LockedBy y LockedUntil identifican al proceso que lo ejecuta y hasta cuándo; si el
+	// proceso muere, el trabajo vuelve a la cola al vencer LockedUntil.
+	LockedBy     string     `gorm:"type:varchar(100)" json:"locked_by,omitempty"`
+	LockedUntil  *time.Time `gorm:"index" json:"locked_until,omitempty"`
+	ScheduleName string     `gorm:"type:varchar(100);index" json:"schedule_name,omitempty"` // Programación que lo generó
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// JobSchedule es una programación recurrente (estilo cron) que encola un trabajo cada vez
+// que vence. Las programaciones se declaran en el código; la tabla coordina a los procesos
+// para que cada ejecución se encole una sola vez y guarda si un administrador la desactivó.
+type JobSchedule struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	Name      string          `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	Cron      string          `gorm:"type:varchar(100);not null" json:"cron"`
+	JobType   string          `gorm:"type:varchar(100);not null" json:"job_type"`
+	Payload   json.RawMessage `gorm:"type:text" json:"payload,omitempty"`
+	Enabled   bool            `gorm:"not null;default:true" json:"enabled"`
+	NextRunAt time.Time       `gorm:"not null;index" json:"next_run_at"`
+	LastRunAt *time.Time      `json:"last_run_at,omitempty"`
+	LastJobID *uint           `json:"last_job_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LearningPath es una ruta de aprendizaje: un conjunto de cursos encadenados por
+// prerrequisitos. En una ruta secuencial cada curso requiere, por defecto, el anterior; en
+// las demás los prerrequisitos forman un grafo sin ciclos.
+type LearningPath struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Title       string `gorm:"size:200;not null" json:"title"`
+	Description string `gorm:"type:text" json:"description,omitempty"`
+	Sequential  bool   `gorm:"not null;default:false" json:"sequential"`
+	// CertificateTemplateID es la plantilla del certificado de la ruta; si es nil se usa la
+	// plantilla predeterminada.
+	CertificateTemplateID *uint          `json:"certificate_template_id,omitempty"`
+	CreatedByID           *uint          `json:"created_by_id,omitempty"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Courses       []LearningPathCourse       `gorm:"foreignKey:PathID" json:"courses,omitempty"`
+	Prerequisites []LearningPathPrerequisite `gorm:"foreignKey:PathID" json:"prerequisites,omitempty"`
+}
+
+// LearningPathCourse es un curso de una ruta, con su posición para mostrarlos en orden.
+type LearningPathCourse struct {
+	ID       uint `gorm:"primaryKey" json:"-"`
+	PathID   uint `gorm:"not null;uniqueIndex:idx_path_course,priority:1" json:"-"`
+	CourseID uint `gorm:"not null;uniqueIndex:idx_path_course,priority:2;index" json:"course_id"`
+	Position int  `gorm:"not null" json:"position"`
+
+	Course *Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+}
+
+// LearningPathPrerequisite indica que CourseID se desbloquea al aprobar RequiredCourseID
+// y, si se indica, con una calificación de al menos MinScore. Un curso con varios
+// prerrequisitos necesita cumplirlos todos.
+type LearningPathPrerequisite struct {
+	ID               uint `gorm:"primaryKey" json:"-"`
+	PathID           uint `gorm:"not null;index" json:"-"`
+	CourseID         uint `gorm:"not null" json:"course_id"`
+	RequiredCourseID uint `gorm:"not null" json:"required_course_id"`
+	MinScore         *int `json:"min_score,omitempty"` // Porcentaje 0-100
+}
+
+// LearningPathEnrollment es la asignación de una ruta a un usuario. Los cursos de la ruta
+// se inscriben a medida que se desbloquean; la ruta se completa al aprobarlos todos.
+type LearningPathEnrollment struct {
+	ID           uint             `gorm:"primaryKey" json:"id"`
+	UserID       uint             `gorm:"not null;uniqueIndex:idx_path_enrollment_user_path,priority:1" json:"user_id"`
+	PathID       uint             `gorm:"not null;uniqueIndex:idx_path_enrollment_user_path,priority:2;index" json:"path_id"`
+	Status       EnrollmentStatus `gorm:"type:varchar(20);not null;default:assigned;index" json:"status"`
+	AssignedByID *uint            `json:"assigned_by_id,omitempty"`
+	DueAt        *time.Time       `json:"due_at,omitempty"`
+	AssignedAt   time.Time        `gorm:"not null" json:"assigned_at"`
+	CompletedAt  *time.Time       `json:"completed_at,omitempty"`
+	CancelledAt  *time.Time       `json:"cancelled_at,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+
+	Path *LearningPath `gorm:"foreignKey:PathID" json:"path,omitempty"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UserStatus es el estado del ciclo de vida de una cuenta.
+type UserStatus string
+
+const (
+	StatusInvited    UserStatus = "invited"    // Alta registrada, aún no empezó a trabajar
+	StatusActive     UserStatus = "active"     // Empleado en funciones
+	StatusOnLeave    UserStatus = "on_leave"   // Licencia o excedencia; conserva el acceso
+	StatusOffboarded UserStatus = "offboarded" // Baja: sin acceso a la plataforma
+)
+
+func (s UserStatus) String() string {
+	return string(s)
+}
+
+// CanLogin indica si una cuenta en este estado puede iniciar sesión y usar la API.
+func (s UserStatus) CanLogin() bool {
+	return s == StatusActive || s == StatusOnLeave
+}
+
+// ParseUserStatus convierte una cadena a un UserStatus.
+// Devuelve un error si la cadena no es un estado válido.
+func ParseUserStatus(s string) (UserStatus, error) {
+	status := UserStatus(strings.ToLower(strings.TrimSpace(s)))
+	switch status {
+	case StatusInvited, StatusActive, StatusOnLeave, StatusOffboarded:
+		return status, nil
+	default:
+		return "", fmt.Errorf("estado inválido: '%s'", s)
+	}
+}
+
+// allowedStatusTransitions define a qué estados se puede pasar desde cada estado.
+// Una baja puede revertirse (recontratación) volviendo a activo.
+var allowedStatusTransitions = map[UserStatus][]UserStatus{
+	StatusInvited:    {StatusActive, StatusOffboarded},
+	StatusActive:     {StatusOnLeave, StatusOffboarded},
+	StatusOnLeave:    {StatusActive, StatusOffboarded},
+	StatusOffboarded: {StatusActive},
+}
+
+// CanTransitionTo indica si el cambio de estado está permitido.
+func (s UserStatus) CanTransitionTo(to UserStatus) bool {
+	for _, allowed := range allowedStatusTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Estados de una transición programada.
+const (
+	TransitionPending   = "pending"
+	TransitionApplied   = "applied"
+	TransitionCancelled = "cancelled"
+	TransitionFailed    = "failed"
+)
+
+// Origen de una transición programada. Las que se generan a partir de las fechas de
+// contratación o de baja se reprograman solas cuando esas fechas cambian.
+const (
+	TransitionSourceManual          = "manual"
+	TransitionSourceHireDate        = "hire_date"
+	TransitionSourceTerminationDate = "termination_date"
+)
+
+// LifecycleTransition es un cambio de estado programado para una fecha futura
+// (p. ej. activar la cuenta el día de ingreso o darla de baja tras el último día).
+type LifecycleTransition struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	ToStatus    UserStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	EffectiveAt time.Time  `gorm:"not null;index:idx_lifecycle_due,priority:2" json:"effective_at"`
+	State       string     `gorm:"type:varchar(20);not null;index:idx_lifecycle_due,priority:1" json:"state"`
+	Source      string     `gorm:"type:varchar(20);not null" json:"source"`
+	Reason      string     `gorm:"size:255" json:"reason,omitempty"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+	Error       string     `gorm:"size:255" json:"error,omitempty"` // Motivo si State es failed
+	CreatedByID *uint      `json:"created_by_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Canales por los que puede entregarse una notificación.
+const (
+	NotificationChannelInApp = "in_app"
+	NotificationChannelEmail = "email"
+)
+
+// NotificationTypeInfo describe un tipo de notificación y los canales activos por defecto,
+// que el usuario puede cambiar en sus preferencias.
+type NotificationTypeInfo struct {
+	Type         string `json:"type"`
+	Description  string `json:"description"`
+	DefaultInApp bool   `json:"default_in_app"`
+	DefaultEmail bool   `json:"default_email"`
+}
+
+// Tipos de notificación. Cada módulo que notifica añade aquí los suyos.
+const (
+	NotificationAccountStatus = "account_status"
+	NotificationAccountRole   = "account_role"
+	NotificationCertification = "certification"
+)
+
+// NotificationTypes es el catálogo de tipos de notificación conocidos.
+var NotificationTypes = []NotificationTypeInfo{
+	{Type: NotificationAccountStatus, Description: "Cambios en el estado de la cuenta (licencias, reincorporaciones)", DefaultInApp: true, DefaultEmail: true},
+	{Type: NotificationAccountRole, Description: "Cambios en el rol de la cuenta", DefaultInApp: true, DefaultEmail: false},
+	{Type: NotificationCertification, Description: "Vencimiento de certificaciones y recertificaciones pendientes", DefaultInApp: true, DefaultEmail: true},
+}
+
+// FindNotificationType busca un tipo en el catálogo.
+func FindNotificationType(notificationType string) (NotificationTypeInfo, bool) {
+	for _, info := range NotificationTypes {
+		if info.Type == notificationType {
+			return info, true
+		}
+	}
+	return NotificationTypeInfo{}, false
+}
+
+// Notification es un aviso para un usuario en el centro de notificaciones de la aplicación.
+type Notification struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	UserID uint   `gorm:"not null;index:idx_notification_user_read,priority:1" json:"user_id"`
+	Type   string `gorm:"type:varchar(50);not null" json:"type"`
+	Title  string `gorm:"size:200;not null" json:"title"`
+	Body   string `gorm:"type:text" json:"body,omitempty"`
+	// Link es una ruta del frontend relacionada con el aviso (p. ej. /cursos/12).
+	Link      string                 `gorm:"size:500" json:"link,omitempty"`
+	Data      map[string]interface{} `gorm:"type:text;serializer:json" json:"data,omitempty"`
+	ReadAt    *time.Time             `gorm:"index:idx_notification_user_read,priority:2" json:"read_at,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// NotificationPreference guarda los canales elegidos por un usuario para un tipo de
+// notificación. Si no hay fila se usan los valores por defecto del catálogo.
+type NotificationPreference struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_notification_pref_user_type,priority:1" json:"-"`
+	Type      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_notification_pref_user_type,priority:2" json:"type"`
+	InApp     bool      `gorm:"not null" json:"in_app"`
+	Email     bool      `gorm:"not null" json:"email"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Department es una unidad organizativa. Puede colgar de otro departamento (ParentID),
+// formando una jerarquía sin ciclos.
+type Department struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	Description string         `gorm:"size:255" json:"description,omitempty"`
+	ParentID    *uint          `gorm:"index" json:"parent_id,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// Team es un equipo de trabajo dentro de un departamento.
+type Team struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	Name         string         `gorm:"size:100;not null;uniqueIndex:idx_team_department_name,priority:2" json:"name"`
+	Description  string         `gorm:"size:255" json:"description,omitempty"`
+	DepartmentID uint           `gorm:"not null;index;uniqueIndex:idx_team_department_name,priority:1" json:"department_id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
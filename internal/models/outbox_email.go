@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Estados de un correo en la bandeja de salida.
+const (
+	OutboxEmailPending = "pending"
+	OutboxEmailSent    = "sent"
+	OutboxEmailFailed  = "failed" // Agotó los reintentos
+)
+
+// OutboxEmail es un correo pendiente de envío. Se inserta en la misma transacción que
+// el cambio que lo origina (p. ej. una invitación), de modo que el correo se envía si y
+// solo si el cambio se confirma; un proceso en segundo plano lo entrega con reintentos.
+type OutboxEmail struct {
+	ID         uint     `gorm:"primaryKey" json:"id"`
+	Recipients []string `gorm:"type:text;serializer:json;not null" json:"recipients"`
+	Subject    string   `gorm:"size:255;not null" json:"subject"`
+	TextBody   string   `gorm:"type:mediumtext;not null" json:"text_body"`
+	HTMLBody   string   `gorm:"type:mediumtext" json:"html_body,omitempty"`
+	Status     string   `gorm:"type:varchar(20);not null;default:pending;index:idx_outbox_due,priority:1" json:"status"`
+	Attempts   int      `gorm:"not null;default:0" json:"attempts"`
+	// NextAttemptAt es el momento a partir del cual puede intentarse el envío. Mientras un
+	// proceso lo está enviando se adelanta un margen para que otro no lo tome a la vez.
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_due,priority:2" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
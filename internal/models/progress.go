@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// LessonProgressStatus es el avance de un usuario en una lección.
+type LessonProgressStatus string
+
+const (
+	LessonNotStarted LessonProgressStatus = "not_started"
+	LessonInProgress LessonProgressStatus = "in_progress"
+	LessonCompleted  LessonProgressStatus = "completed"
+)
+
+// LessonProgress guarda el avance de un usuario en una lección. Los clientes envían
+// actualizaciones periódicas que pueden llegar repetidas o desordenadas, así que los
+// valores acumulados (porcentaje, tiempo) solo crecen y una lección completada no vuelve
+// atrás; la posición de reanudación es la del envío más reciente según el reloj del cliente.
+type LessonProgress struct {
+	ID       uint `gorm:"primaryKey" json:"id"`
+	UserID   uint `gorm:"not null;uniqueIndex:idx_progress_user_lesson,priority:1;index:idx_progress_user_course,priority:1" json:"user_id"`
+	LessonID uint `gorm:"not null;uniqueIndex:idx_progress_user_lesson,priority:2" json:"lesson_id"`
+	// CourseID se copia de la lección para calcular el avance del curso sin recorrer módulos.
+	CourseID uint                 `gorm:"not null;index:idx_progress_user_course,priority:2" json:"course_id"`
+	Status   LessonProgressStatus `gorm:"type:varchar(20);not null;default:not_started" json:"status"`
+	Percent  int                  `gorm:"not null;default:0" json:"percent"` // 0-100
+	// TimeSpentSeconds es el tiempo total dedicado a la lección que informa el cliente.
+	TimeSpentSeconds int `gorm:"not null;default:0" json:"time_spent_seconds"`
+	// ResumePosition es dónde retomar (segundo del video, página del documento...); el
+	// formato lo decide el cliente según el tipo de lección.
+	ResumePosition string `gorm:"size:100" json:"resume_position,omitempty"`
+	// LastEventAt es la hora (del cliente) del envío que fijó ResumePosition.
+	LastEventAt *time.Time `json:"last_event_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName usa "lesson_progress" en lugar del plural automático.
+func (LessonProgress) TableName() string {
+	return "lesson_progress"
+}
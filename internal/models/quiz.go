@@ -0,0 +1,234 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Quiz es una evaluación de un curso, opcionalmente asociada a una de sus lecciones.
+type Quiz struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	CourseID    uint   `gorm:"not null;index" json:"course_id"`
+	LessonID    *uint  `gorm:"index" json:"lesson_id,omitempty"`
+	Title       string `gorm:"size:200;not null" json:"title"`
+	Description string `gorm:"type:text" json:"description,omitempty"`
+	// PassingScore es el porcentaje mínimo (0-100) para aprobar.
+	PassingScore int `gorm:"not null;default:70" json:"passing_score"`
+	// MaxAttempts limita los intentos por usuario; 0 = sin límite.
+	MaxAttempts int `gorm:"not null;default:0" json:"max_attempts"`
+	// TimeLimitSeconds es la duración máxima de un intento; 0 = sin límite.
+	TimeLimitSeconds int            `gorm:"not null;default:0" json:"time_limit_seconds"`
+	ShuffleQuestions bool           `gorm:"not null;default:false" json:"shuffle_questions"`
+	ShuffleOptions   bool           `gorm:"not null;default:false" json:"shuffle_options"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Questions []Question     `gorm:"foreignKey:QuizID" json:"questions,omitempty"`
+	Draws     []QuizDrawRule `gorm:"foreignKey:QuizID" json:"draws,omitempty"`
+}
+
+// QuestionType es el tipo de una pregunta.
+type QuestionType string
+
+const (
+	QuestionSingleChoice   QuestionType = "single_choice"   // Una opción correcta
+	QuestionMultipleChoice QuestionType = "multiple_choice" // Una o más opciones correctas; hay que marcar exactamente esas
+	QuestionTrueFalse      QuestionType = "true_false"      // Dos opciones: Verdadero y Falso
+	QuestionShortText      QuestionType = "short_text"      // Respuesta escrita comparada con las aceptadas
+	QuestionOrdering       QuestionType = "ordering"        // Ordenar las opciones; el orden correcto es Position
+)
+
+// ParseQuestionType convierte una cadena a QuestionType.
+func ParseQuestionType(s string) (QuestionType, error) {
+	switch t := QuestionType(strings.ToLower(strings.TrimSpace(s))); t {
+	case QuestionSingleChoice, QuestionMultipleChoice, QuestionTrueFalse, QuestionShortText, QuestionOrdering:
+		return t, nil
+	default:
+		return "", fmt.Errorf("tipo de pregunta inválido: '%s'", s)
+	}
+}
+
+// QuestionDifficulty es la dificultad de una pregunta, usada para sortear de un banco.
+type QuestionDifficulty string
+
+const (
+	DifficultyEasy   QuestionDifficulty = "easy"
+	DifficultyMedium QuestionDifficulty = "medium"
+	DifficultyHard   QuestionDifficulty = "hard"
+)
+
+// QuestionDifficulties son las dificultades en su orden natural.
+var QuestionDifficulties = []QuestionDifficulty{DifficultyEasy, DifficultyMedium, DifficultyHard}
+
+// ParseQuestionDifficulty convierte una cadena a QuestionDifficulty.
+func ParseQuestionDifficulty(s string) (QuestionDifficulty, error) {
+	switch d := QuestionDifficulty(strings.ToLower(strings.TrimSpace(s))); d {
+	case DifficultyEasy, DifficultyMedium, DifficultyHard:
+		return d, nil
+	default:
+		return "", fmt.Errorf("dificultad inválida: '%s'", s)
+	}
+}
+
+// Question es una pregunta de un cuestionario o de un banco de preguntas (exactamente uno de
+// QuizID y BankID). Las respuestas correctas (IsCorrect de las opciones, AcceptedAnswers)
+// nunca se envían a quien responde antes de entregar.
+type Question struct {
+	ID          uint               `gorm:"primaryKey" json:"id"`
+	QuizID      *uint              `gorm:"index" json:"quiz_id,omitempty"`
+	BankID      *uint              `gorm:"index:idx_question_bank_topic,priority:1" json:"bank_id,omitempty"`
+	Topic       string             `gorm:"size:100;index:idx_question_bank_topic,priority:2" json:"topic,omitempty"`
+	Difficulty  QuestionDifficulty `gorm:"type:varchar(10);not null;default:'medium'" json:"difficulty"`
+	Type        QuestionType       `gorm:"type:varchar(20);not null" json:"type"`
+	Prompt      string             `gorm:"type:text;not null" json:"prompt"`
+	Explanation string             `gorm:"type:text" json:"explanation,omitempty"` // Se muestra tras entregar
+	Points      int                `gorm:"not null;default:1" json:"points"`
+	Position    int                `gorm:"not null;default:0" json:"position"`
+	// AcceptedAnswers son las respuestas válidas de short_text (JSON []string). Se comparan
+	// sin distinguir mayúsculas ni espacios sobrantes.
+	AcceptedAnswers string         `gorm:"type:text" json:"-"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Options []QuestionOption `gorm:"foreignKey:QuestionID" json:"options,omitempty"`
+}
+
+// AcceptedAnswerList decodifica AcceptedAnswers.
+func (q *Question) AcceptedAnswerList() []string {
+	var answers []string
+	if q.AcceptedAnswers != "" {
+		_ = json.Unmarshal([]byte(q.AcceptedAnswers), &answers)
+	}
+	return answers
+}
+
+// QuestionOption es una opción de respuesta. En las preguntas de ordenar, Position es el
+// lugar correcto de la opción.
+type QuestionOption struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	QuestionID uint   `gorm:"not null;index" json:"question_id"`
+	Text       string `gorm:"size:500;not null" json:"text"`
+	IsCorrect  bool   `gorm:"not null;default:false" json:"is_correct"`
+	Position   int    `gorm:"not null;default:0" json:"position"`
+}
+
+// QuestionBank es un conjunto de preguntas reutilizable desde varios cuestionarios.
+type QuestionBank struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Title       string         `gorm:"size:200;not null" json:"title"`
+	Description string         `gorm:"type:text" json:"description,omitempty"`
+	CreatedByID *uint          `gorm:"index" json:"created_by_id,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Questions []Question `gorm:"foreignKey:BankID" json:"questions,omitempty"`
+}
+
+// QuizDrawRule indica que cada intento de un cuestionario incluye Count preguntas sorteadas
+// de un banco, opcionalmente de un tema y con una distribución de dificultad (porcentajes
+// que suman 100, o todos 0 para cualquier dificultad).
+type QuizDrawRule struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	QuizID        uint           `gorm:"not null;index" json:"quiz_id"`
+	BankID        uint           `gorm:"not null;index" json:"bank_id"`
+	Topic         string         `gorm:"size:100" json:"topic,omitempty"`
+	Count         int            `gorm:"not null" json:"count"`
+	EasyPercent   int            `gorm:"not null;default:0" json:"easy_percent"`
+	MediumPercent int            `gorm:"not null;default:0" json:"medium_percent"`
+	HardPercent   int            `gorm:"not null;default:0" json:"hard_percent"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Bank *QuestionBank `gorm:"foreignKey:BankID" json:"bank,omitempty"`
+}
+
+// DifficultyCounts reparte Count entre las dificultades según los porcentajes (método del
+// mayor resto). Devuelve nil si la regla no fija distribución.
+func (r *QuizDrawRule) DifficultyCounts() map[QuestionDifficulty]int {
+	percents := []int{r.EasyPercent, r.MediumPercent, r.HardPercent}
+	if percents[0]+percents[1]+percents[2] == 0 {
+		return nil
+	}
+	counts := make(map[QuestionDifficulty]int, len(percents))
+	remainders := make([]int, len(percents))
+	assigned := 0
+	for i, d := range QuestionDifficulties {
+		counts[d] = r.Count * percents[i] / 100
+		remainders[i] = r.Count * percents[i] % 100
+		assigned += counts[d]
+	}
+	for ; assigned < r.Count; assigned++ {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		counts[QuestionDifficulties[best]]++
+		remainders[best] = -1
+	}
+	return counts
+}
+
+// Estados de un intento de cuestionario.
+const (
+	AttemptInProgress = "in_progress"
+	AttemptSubmitted  = "submitted"
+	AttemptExpired    = "expired" // Se acabó el tiempo: se califica con las respuestas guardadas hasta el límite
+)
+
+// QuizAttempt es un intento de un usuario. Las preguntas (propias del cuestionario y
+// sorteadas de bancos) y su orden se derivan de Seed y se fijan al empezar en QuestionIDs,
+// para que un intento retomado muestre siempre lo mismo.
+type QuizAttempt struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	QuizID        uint   `gorm:"not null;index:idx_attempt_quiz_user,priority:1" json:"quiz_id"`
+	UserID        uint   `gorm:"not null;index:idx_attempt_quiz_user,priority:2" json:"user_id"`
+	AttemptNumber int    `gorm:"not null" json:"attempt_number"` // Dentro de cada vuelta de certificación
+	Status        string `gorm:"type:varchar(20);not null;index" json:"status"`
+	// Seed determina el orden aleatorio de preguntas y opciones del intento.
+	Seed int64 `gorm:"not null" json:"-"`
+	// QuestionIDs son las preguntas del intento en el orden en que se muestran (JSON []uint).
+	QuestionIDs string     `gorm:"type:text;not null" json:"-"`
+	StartedAt   time.Time  `gorm:"not null" json:"started_at"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+	Score       int        `gorm:"not null;default:0" json:"score"`
+	MaxScore    int        `gorm:"not null;default:0" json:"max_score"`
+	Percent     int        `gorm:"not null;default:0" json:"percent"`
+	Passed      *bool      `json:"passed,omitempty"`                // Nil mientras no se califica
+	Cycle       int        `gorm:"not null;default:1" json:"cycle"` // Vuelta de certificación de la inscripción
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// QuestionIDList decodifica QuestionIDs.
+func (a *QuizAttempt) QuestionIDList() []uint {
+	var ids []uint
+	if a.QuestionIDs != "" {
+		_ = json.Unmarshal([]byte(a.QuestionIDs), &ids)
+	}
+	return ids
+}
+
+// QuizAttemptAnswer es la respuesta a una pregunta dentro de un intento. Se guarda a medida
+// que el usuario responde y se califica al entregar.
+type QuizAttemptAnswer struct {
+	ID         uint `gorm:"primaryKey" json:"id"`
+	AttemptID  uint `gorm:"not null;uniqueIndex:idx_answer_attempt_question,priority:1" json:"attempt_id"`
+	QuestionID uint `gorm:"not null;uniqueIndex:idx_answer_attempt_question,priority:2;index" json:"question_id"`
+	// Response es la respuesta tal cual (JSON {"option_ids": [...], "text": "..."}).
+	Response  string    `gorm:"type:text" json:"-"`
+	Correct   bool      `gorm:"not null;default:false" json:"correct"`
+	Points    int       `gorm:"not null;default:0" json:"points"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// ScormVersion es la versión del estándar SCORM de un paquete.
+type ScormVersion string
+
+const (
+	Scorm12   ScormVersion = "1.2"
+	Scorm2004 ScormVersion = "2004"
+)
+
+// ScormPackage es el paquete SCORM de una lección de tipo "scorm". Sus archivos se guardan
+// descomprimidos en el almacenamiento bajo "scorm/<Token>/"; el token, aleatorio, forma
+// parte de la URL del contenido. Subir otro paquete a la lección reemplaza este.
+type ScormPackage struct {
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	LessonID   uint         `gorm:"not null;uniqueIndex" json:"lesson_id"`
+	Version    ScormVersion `gorm:"type:varchar(10);not null" json:"version"`
+	Identifier string       `gorm:"size:200" json:"identifier,omitempty"`
+	Title      string       `gorm:"size:200" json:"title,omitempty"`
+	Token      string       `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	// LaunchPath es el archivo que abre el SCO, relativo a la raíz del paquete, con los
+	// parámetros del manifiesto si los hay.
+	LaunchPath string `gorm:"size:500;not null" json:"launch_path"`
+	// LaunchData es el valor de cmi.launch_data (adlcp:datafromlms / adlcp:dataFromLMS).
+	LaunchData string `gorm:"type:text" json:"launch_data,omitempty"`
+	// MasteryScore es la nota mínima de aprobación de SCORM 1.2 (adlcp:masteryscore).
+	MasteryScore *float64 `json:"mastery_score,omitempty"`
+	// Files son las rutas de los archivos del paquete, para borrarlos al reemplazarlo.
+	Files        []string  `gorm:"type:mediumtext;serializer:json;not null" json:"-"`
+	FileCount    int       `gorm:"not null" json:"file_count"`
+	TotalBytes   int64     `gorm:"not null" json:"total_bytes"`
+	UploadedByID *uint     `json:"uploaded_by_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ScormRuntime guarda el modelo de datos de ejecución (cmi.*) de un SCO para una
+// inscripción. Los elementos con reglas propias tienen columna; el resto (objetivos,
+// interacciones, preferencias...) se guarda tal cual en Data. LessonStatus solo se usa en
+// SCORM 1.2, y CompletionStatus y SuccessStatus solo en SCORM 2004.
+type ScormRuntime struct {
+	ID           uint `gorm:"primaryKey" json:"id"`
+	EnrollmentID uint `gorm:"not null;uniqueIndex:idx_scorm_runtime_enrollment_lesson,priority:1" json:"enrollment_id"`
+	LessonID     uint `gorm:"not null;uniqueIndex:idx_scorm_runtime_enrollment_lesson,priority:2" json:"lesson_id"`
+	UserID       uint `gorm:"not null;index" json:"user_id"`
+
+	LessonStatus     string   `gorm:"type:varchar(20);not null;default:'not attempted'" json:"lesson_status,omitempty"`
+	CompletionStatus string   `gorm:"type:varchar(20);not null;default:'not attempted'" json:"completion_status,omitempty"`
+	SuccessStatus    string   `gorm:"type:varchar(20);not null;default:unknown" json:"success_status,omitempty"`
+	ScoreRaw         *float64 `json:"score_raw,omitempty"`
+	ScoreMin         *float64 `json:"score_min,omitempty"`
+	ScoreMax         *float64 `json:"score_max,omitempty"`
+	ScoreScaled      *float64 `json:"score_scaled,omitempty"`
+	ProgressMeasure  *float64 `json:"progress_measure,omitempty"`
+	Location         string   `gorm:"size:1000" json:"location,omitempty"`
+	SuspendData      string   `gorm:"type:mediumtext" json:"suspend_data,omitempty"`
+	// Exit es el último cmi.exit (cmi.core.exit en 1.2): "suspend" hace que la próxima
+	// sesión empiece con entry = "resume".
+	Exit             string            `gorm:"type:varchar(20)" json:"exit,omitempty"`
+	TotalTimeSeconds float64           `gorm:"not null;default:0" json:"total_time_seconds"`
+	Sessions         int               `gorm:"not null;default:0" json:"sessions"`
+	Data             map[string]string `gorm:"type:mediumtext;serializer:json" json:"data,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
@@ -12,8 +12,9 @@ import (
 type Role string
 
 const (
-	RoleAdmin    Role = "ADMIN"
-	RoleEmployee Role = "EMPLOYEE"
+	RoleAdmin      Role = "ADMIN"
+	RoleEmployee   Role = "EMPLOYEE"
+	RoleInstructor Role = "INSTRUCTOR" // Gestiona los cursos que tiene asignados
 )
 
 func (r Role) String() string {
@@ -29,6 +30,8 @@ func ParseRole(s string) (Role, error) {
 		return RoleAdmin, nil
 	case string(RoleEmployee):
 		return RoleEmployee, nil
+	case string(RoleInstructor):
+		return RoleInstructor, nil
 	default:
 		return "", fmt.Errorf("rol inválido: '%s'", s)
 	}
@@ -44,6 +47,12 @@ type User struct {
 	Username     string `gorm:"type:varchar(50);uniqueIndex;not null" json:"username"`
 	PasswordHash string `gorm:"type:varchar(255);not null" json:"-"` // No exponer en JSON por defecto
 	Role         Role   `gorm:"type:varchar(20);not null" json:"role"`
+	// Status es el estado del ciclo de vida; solo las cuentas activas o de licencia pueden iniciar sesión.
+	Status UserStatus `gorm:"type:varchar(20);not null;default:active;index" json:"status"`
+
+	// Version se incrementa en cada actualización y sirve para el control de concurrencia
+	// optimista (ETag / If-Match) cuando dos administradores editan el mismo usuario.
+	Version uint `gorm:"not null;default:1" json:"version"`
 
 	// Relación One-to-One con EmployeeDetail
 	// El UserID en EmployeeDetail apuntará a este User.
@@ -61,6 +70,17 @@ type User struct {
 	// IsActive  bool   `gorm:"default:true"`
 }
 
+// BeforeCreate asegura que todo usuario nuevo comience en la versión 1 y con un estado.
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.Version == 0 {
+		u.Version = 1
+	}
+	if u.Status == "" {
+		u.Status = StatusActive
+	}
+	return nil
+}
+
 // Puedes añadir métodos al modelo User aquí si es necesario, por ejemplo,
 // para validar la contraseña (aunque eso usualmente va en un paquete de servicio/handler).
 
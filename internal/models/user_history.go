@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// UserHistory guarda una versión de los datos de un usuario y de su EmployeeDetail
+// (rol, cargo, email, etc.) junto con el intervalo en que estuvo vigente.
+// La versión actual es la que tiene ValidTo en NULL. Permite responder preguntas del
+// tipo "¿qué cargo tenía este empleado el 31 de marzo?" para nómina y cumplimiento.
+type UserHistory struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	UserID          uint       `gorm:"not null;index:idx_user_history_validity,priority:1" json:"user_id"`
+	UserVersion     uint       `json:"user_version"`
+	DetailVersion   uint       `json:"detail_version"`
+	Username        string     `gorm:"type:varchar(50)" json:"username"`
+	Role            Role       `gorm:"type:varchar(20)" json:"role"`
+	Status          UserStatus `gorm:"type:varchar(20)" json:"status"`
+	Name            string     `gorm:"size:100" json:"name"`
+	LastName        string     `gorm:"size:100" json:"last_name"`
+	Email           string     `gorm:"size:100" json:"email"`
+	PhoneNumber     string     `gorm:"size:20" json:"phone_number,omitempty"`
+	Position        string     `gorm:"size:100" json:"position,omitempty"`
+	DepartmentID    *uint      `json:"department_id,omitempty"`
+	TeamID          *uint      `json:"team_id,omitempty"`
+	ManagerID       *uint      `json:"manager_id,omitempty"`
+	HireDate        *time.Time `gorm:"type:date" json:"hire_date,omitempty"`
+	TerminationDate *time.Time `gorm:"type:date" json:"termination_date,omitempty"`
+	// Deleted marca la versión que registra la eliminación del usuario.
+	Deleted     bool       `gorm:"not null;default:false" json:"deleted"`
+	ValidFrom   time.Time  `gorm:"not null;index:idx_user_history_validity,priority:2" json:"valid_from"`
+	ValidTo     *time.Time `json:"valid_to,omitempty"`
+	ChangedByID *uint      `json:"changed_by_id,omitempty"`
+	Action      string     `gorm:"type:varchar(50)" json:"action"` // Misma acción que en la auditoría
+}
+
+// SameContentAs indica si dos versiones tienen los mismos datos de negocio
+// (ignorando versiones, vigencia y autor del cambio).
+func (h *UserHistory) SameContentAs(other *UserHistory) bool {
+	return h.Username == other.Username &&
+		h.Role == other.Role &&
+		h.Status == other.Status &&
+		h.Name == other.Name &&
+		h.LastName == other.LastName &&
+		h.Email == other.Email &&
+		h.PhoneNumber == other.PhoneNumber &&
+		h.Position == other.Position &&
+		EqualOptionalID(h.DepartmentID, other.DepartmentID) &&
+		EqualOptionalID(h.TeamID, other.TeamID) &&
+		EqualOptionalID(h.ManagerID, other.ManagerID) &&
+		EqualOptionalDate(h.HireDate, other.HireDate) &&
+		EqualOptionalDate(h.TerminationDate, other.TerminationDate) &&
+		h.Deleted == other.Deleted
+}
+
+// EqualOptionalID compara dos IDs opcionales (nil equivale a "sin asignar").
+func EqualOptionalID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// EqualOptionalDate compara dos fechas opcionales por día (nil equivale a "sin fecha").
+func EqualOptionalDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
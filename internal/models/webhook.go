@@ -0,0 +1,103 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tipos de evento que pueden recibir los webhooks salientes.
+const (
+	WebhookEventUserCreated       = "user.created"
+	WebhookEventUserUpdated       = "user.updated"
+	WebhookEventUserRoleChanged   = "user.role_changed" // Se envía además de user.updated
+	WebhookEventUserStatusChanged = "user.status_changed"
+	WebhookEventUserSuspended     = "user.suspended" // La cuenta pierde el acceso (baja); se envía además de user.status_changed
+	WebhookEventUserDeleted       = "user.deleted"
+)
+
+// WebhookEventTypes es el catálogo de eventos a los que puede suscribirse un webhook.
+var WebhookEventTypes = []string{
+	WebhookEventUserCreated,
+	WebhookEventUserUpdated,
+	WebhookEventUserRoleChanged,
+	WebhookEventUserStatusChanged,
+	WebhookEventUserSuspended,
+	WebhookEventUserDeleted,
+}
+
+// IsWebhookEventType indica si eventType está en el catálogo.
+func IsWebhookEventType(eventType string) bool {
+	for _, known := range WebhookEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscription es un sistema externo (nómina, RR. HH.) que recibe por HTTP los
+// eventos elegidos. Cada envío va firmado con HMAC-SHA256 usando Secret.
+type WebhookSubscription struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"size:100;not null" json:"name"`
+	URL         string         `gorm:"size:500;not null" json:"url"`
+	Secret      string         `gorm:"type:varchar(100);not null" json:"-"`
+	EventTypes  []string       `gorm:"type:text;serializer:json;not null" json:"event_types"`
+	Active      bool           `gorm:"not null" json:"active"`
+	CreatedByID *uint          `json:"created_by_id,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Subscribes indica si la suscripción recibe eventType.
+func (w *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, subscribed := range w.EventTypes {
+		if subscribed == eventType || subscribed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Estados de una entrega de webhook.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead" // Agotó los reintentos o la suscripción ya no existe
+)
+
+// WebhookDelivery es el envío de un evento a una suscripción. Se crea en la misma
+// transacción que el cambio que lo origina y se entrega en segundo plano con reintentos.
+type WebhookDelivery struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint            `gorm:"not null;index" json:"subscription_id"`
+	EventID        string          `gorm:"type:varchar(64);not null;index" json:"event_id"` // Igual en todas las suscripciones que reciben el evento
+	EventType      string          `gorm:"type:varchar(50);not null" json:"event_type"`
+	Payload        json.RawMessage `gorm:"type:mediumtext;not null" json:"payload"` // Cuerpo exacto que se firma y envía
+	Status         string          `gorm:"type:varchar(20);not null;default:pending;index:idx_webhook_delivery_due,priority:1" json:"status"`
+	Attempts       int             `gorm:"not null;default:0" json:"attempts"`
+	// NextAttemptAt funciona como en OutboxEmail: se adelanta mientras la entrega está en curso.
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_webhook_delivery_due,priority:2" json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	RedeliveryOfID *uint      `json:"redelivery_of_id,omitempty"` // Entrega original si se reenvió a mano
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	AttemptLog []WebhookDeliveryAttempt `gorm:"foreignKey:DeliveryID" json:"attempt_log,omitempty"`
+}
+
+// WebhookDeliveryAttempt registra cada intento de entrega con la respuesta obtenida.
+type WebhookDeliveryAttempt struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	DeliveryID   uint      `gorm:"not null;index" json:"delivery_id"`
+	AttemptedAt  time.Time `gorm:"not null" json:"attempted_at"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	Error        string    `gorm:"type:text" json:"error,omitempty"`
+	ResponseBody string    `gorm:"type:text" json:"response_body,omitempty"` // Recortado
+}
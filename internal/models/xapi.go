@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// XAPIVersion es la versión de xAPI que implementa el LRS y que se informa en el
+// encabezado X-Experience-API-Version de cada respuesta.
+const XAPIVersion = "1.0.3"
+
+// Tipos de documento del LRS.
+const (
+	XAPIDocumentState           = "state"
+	XAPIDocumentActivityProfile = "activity_profile"
+)
+
+// XAPIClient es un proveedor de contenido que envía declaraciones xAPI al LRS. Se
+// autentica con HTTP Basic usando AccessKey y un secreto del que solo se guarda el hash.
+type XAPIClient struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"size:100;not null" json:"name"`
+	AccessKey   string         `gorm:"type:varchar(40);not null;uniqueIndex" json:"key"`
+	SecretHash  string         `gorm:"type:char(64);not null" json:"-"`
+	Active      bool           `gorm:"not null" json:"active"`
+	CreatedByID *uint          `json:"created_by_id,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName fija "xapi_clients"; el nombre automático separaría la sigla ("x_api_...").
+func (XAPIClient) TableName() string {
+	return "xapi_clients"
+}
+
+// XAPIActivityLink asocia una actividad (IRI) del contenido de un cliente a una lección:
+// las declaraciones de avance sobre esa actividad se aplican al avance de la lección.
+type XAPIActivityLink struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ClientID    uint      `gorm:"not null;uniqueIndex:idx_xapi_activity_link,priority:1" json:"client_id"`
+	ActivityID  string    `gorm:"size:500;not null;uniqueIndex:idx_xapi_activity_link,priority:2" json:"activity_id"`
+	LessonID    uint      `gorm:"not null;index" json:"lesson_id"`
+	CreatedByID *uint     `json:"created_by_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName fija "xapi_activity_links".
+func (XAPIActivityLink) TableName() string {
+	return "xapi_activity_links"
+}
+
+// XAPIStatement es una declaración guardada por el LRS. Statement es el JSON completo tal
+// como se devuelve en las consultas (con id, timestamp, stored, authority y version); las
+// demás columnas existen para filtrar. Las declaraciones no se modifican salvo Voided.
+type XAPIStatement struct {
+	ID           uint            `gorm:"primaryKey" json:"-"`
+	StatementID  string          `gorm:"type:char(36);not null;uniqueIndex" json:"id"`
+	ClientID     uint            `gorm:"not null;index:idx_xapi_statement_client,priority:1" json:"client_id"`
+	VerbID       string          `gorm:"size:500;not null;index" json:"verb_id"`
+	Registration string          `gorm:"type:char(36);index" json:"registration,omitempty"`
+	Voided       bool            `gorm:"not null;default:false" json:"voided"`
+	Voiding      bool            `gorm:"not null;default:false" json:"voiding"` // Anula a otra declaración
+	UserID       *uint           `gorm:"index" json:"user_id,omitempty"`        // Empleado reconocido en el actor
+	LessonID     *uint           `json:"lesson_id,omitempty"`                   // Lección cuyo avance actualizó
+	Timestamp    time.Time       `gorm:"type:datetime(3);not null" json:"timestamp"`
+	Stored       time.Time       `gorm:"type:datetime(3);not null;index:idx_xapi_statement_client,priority:2" json:"stored"`
+	Statement    json.RawMessage `gorm:"type:mediumtext;not null" json:"statement"`
+}
+
+// TableName fija "xapi_statements".
+func (XAPIStatement) TableName() string {
+	return "xapi_statements"
+}
+
+// XAPIStatementRef indexa los agentes y actividades de una declaración para los filtros
+// agent y activity. IsPrimary marca el actor o el objeto; el resto (contexto, autoridad,
+// subdeclaraciones) solo se usa con related_agents y related_activities.
+type XAPIStatementRef struct {
+	ID          uint   `gorm:"primaryKey"`
+	StatementID uint   `gorm:"not null;index"`
+	Kind        string `gorm:"type:varchar(10);not null;index:idx_xapi_statement_ref,priority:1"` // "agent" o "activity"
+	Value       string `gorm:"size:500;not null;index:idx_xapi_statement_ref,priority:2"`
+	IsPrimary   bool   `gorm:"not null"`
+}
+
+// TableName fija "xapi_statement_refs".
+func (XAPIStatementRef) TableName() string {
+	return "xapi_statement_refs"
+}
+
+// XAPIDocument es un documento del estado de una actividad para un agente o del perfil de
+// una actividad. KeyHash es el hash de la clave compuesta (cliente, tipo, actividad, agente,
+// registro e identificador), demasiado larga para un índice único.
+type XAPIDocument struct {
+	ID           uint      `gorm:"primaryKey"`
+	KeyHash      string    `gorm:"type:char(64);not null;uniqueIndex"`
+	ClientID     uint      `gorm:"not null;index:idx_xapi_document_lookup,priority:1"`
+	Kind         string    `gorm:"type:varchar(20);not null;index:idx_xapi_document_lookup,priority:2"`
+	ActivityID   string    `gorm:"size:500;not null;index:idx_xapi_document_lookup,priority:3"`
+	Agent        string    `gorm:"size:500"` // Agente canónico; vacío en los perfiles
+	Registration string    `gorm:"type:char(36)"`
+	DocumentID   string    `gorm:"size:255;not null"`
+	ContentType  string    `gorm:"size:255;not null"`
+	Content      []byte    `gorm:"type:mediumblob;not null"`
+	ETag         string    `gorm:"type:char(40);not null"`
+	CreatedAt    time.Time `gorm:"type:datetime(3)"`
+	UpdatedAt    time.Time `gorm:"type:datetime(3)"`
+}
+
+// TableName fija "xapi_documents".
+func (XAPIDocument) TableName() string {
+	return "xapi_documents"
+}
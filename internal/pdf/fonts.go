@@ -0,0 +1,147 @@
+package pdf
+
+// Font es una de las fuentes estándar de PDF, que todo lector trae incorporadas y por eso
+// no hace falta embeber. Solo se ofrece la familia Helvetica (las oblicuas comparten las
+// métricas de la normal y de la negrita).
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+	HelveticaOblique
+	HelveticaBoldOblique
+)
+
+var fontNames = map[Font]string{
+	Helvetica:            "Helvetica",
+	HelveticaBold:        "Helvetica-Bold",
+	HelveticaOblique:     "Helvetica-Oblique",
+	HelveticaBoldOblique: "Helvetica-BoldOblique",
+}
+
+// FontFor elige la variante de Helvetica según negrita y cursiva.
+func FontFor(bold, italic bool) Font {
+	switch {
+	case bold && italic:
+		return HelveticaBoldOblique
+	case bold:
+		return HelveticaBold
+	case italic:
+		return HelveticaOblique
+	default:
+		return Helvetica
+	}
+}
+
+func (f Font) bold() bool { return f == HelveticaBold || f == HelveticaBoldOblique }
+
+// Anchos (en milésimas del tamaño de la fuente) de los caracteres 32-126 y 160-255 de
+// WinAnsiEncoding, según las métricas AFM de Adobe.
+var helveticaASCII = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaLatin1 = [96]int{
+	278, 333, 556, 556, 556, 556, 260, 556, 333, 737, 370, 556, 584, 333, 737, 333,
+	400, 584, 333, 333, 333, 556, 537, 278, 333, 333, 365, 556, 834, 834, 834, 611,
+	667, 667, 667, 667, 667, 667, 1000, 722, 667, 667, 667, 667, 278, 278, 278, 278,
+	722, 722, 778, 778, 778, 778, 778, 584, 778, 722, 722, 722, 722, 667, 667, 611,
+	556, 556, 556, 556, 556, 556, 889, 500, 556, 556, 556, 556, 278, 278, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 584, 611, 556, 556, 556, 556, 500, 556, 500,
+}
+
+var helveticaBoldASCII = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+var helveticaBoldLatin1 = [96]int{
+	278, 333, 556, 556, 556, 556, 280, 556, 333, 737, 370, 556, 584, 333, 737, 333,
+	400, 584, 333, 333, 333, 611, 556, 278, 333, 333, 365, 556, 834, 834, 834, 611,
+	722, 722, 722, 722, 722, 722, 1000, 722, 667, 667, 667, 667, 278, 278, 278, 278,
+	722, 722, 778, 778, 778, 778, 778, 584, 778, 722, 722, 722, 722, 667, 667, 611,
+	556, 556, 556, 556, 556, 556, 889, 556, 556, 556, 556, 556, 278, 278, 278, 278,
+	611, 611, 611, 611, 611, 611, 611, 584, 611, 611, 611, 611, 611, 556, 611, 556,
+}
+
+// winAnsiExtras son los caracteres de WinAnsiEncoding fuera de Latin-1 (rango 0x80-0x9F)
+// que pueden aparecer en textos en español: byte y anchos normal/negrita.
+var winAnsiExtras = map[rune]struct {
+	code          byte
+	regular, bold int
+}{
+	'€': {0x80, 556, 556},
+	'‘': {0x91, 222, 278},
+	'’': {0x92, 222, 278},
+	'“': {0x93, 333, 500},
+	'”': {0x94, 333, 500},
+	'•': {0x95, 350, 350},
+	'–': {0x96, 556, 556},
+	'—': {0x97, 1000, 1000},
+	'…': {0x85, 1000, 1000},
+}
+
+// encodeWinAnsi convierte s a WinAnsiEncoding; los caracteres sin representación se
+// reemplazan por '?'.
+func encodeWinAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 32 && r <= 126, r >= 160 && r <= 255:
+			out = append(out, byte(r))
+		case r == '\t':
+			out = append(out, ' ')
+		default:
+			if extra, ok := winAnsiExtras[r]; ok {
+				out = append(out, extra.code)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// charWidth devuelve el ancho de un byte WinAnsi en milésimas.
+func charWidth(f Font, c byte) int {
+	bold := f.bold()
+	switch {
+	case c >= 32 && c <= 126:
+		if bold {
+			return helveticaBoldASCII[c-32]
+		}
+		return helveticaASCII[c-32]
+	case c >= 160:
+		if bold {
+			return helveticaBoldLatin1[c-160]
+		}
+		return helveticaLatin1[c-160]
+	}
+	for _, extra := range winAnsiExtras {
+		if extra.code == c {
+			if bold {
+				return extra.bold
+			}
+			return extra.regular
+		}
+	}
+	return 556
+}
+
+// TextWidth devuelve el ancho en puntos de s escrito con la fuente y el tamaño indicados.
+func TextWidth(f Font, size float64, s string) float64 {
+	total := 0
+	for _, c := range encodeWinAnsi(s) {
+		total += charWidth(f, c)
+	}
+	return float64(total) * size / 1000
+}
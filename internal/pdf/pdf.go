@@ -0,0 +1,261 @@
+// Package pdf genera documentos PDF sencillos de una página (texto con las fuentes estándar,
+// rectángulos, líneas e imágenes JPEG) usando solo la biblioteca estándar. Basta para
+// certificados y constancias; no pretende ser un motor de maquetación.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"strconv"
+	"strings"
+)
+
+// Tamaños de página en puntos (1 pt = 1/72 de pulgada).
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// MMToPt convierte milímetros a puntos.
+func MMToPt(mm float64) float64 { return mm * 72 / 25.4 }
+
+// Color es un color RGB con componentes entre 0 y 1.
+type Color struct{ R, G, B float64 }
+
+// Black es el color por defecto del texto.
+var Black = Color{}
+
+// ParseHexColor convierte "#RRGGBB" (o "RRGGBB") a Color.
+func ParseHexColor(s string) (Color, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 {
+		return Color{}, fmt.Errorf("color inválido: '%s'", s)
+	}
+	value, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("color inválido: '%s'", s)
+	}
+	return Color{
+		R: float64(value>>16&0xFF) / 255,
+		G: float64(value>>8&0xFF) / 255,
+		B: float64(value&0xFF) / 255,
+	}, nil
+}
+
+type jpegImage struct {
+	data          []byte
+	width, height int
+	colorSpace    string
+}
+
+// Document es una página PDF en construcción. Todas las coordenadas se expresan en puntos
+// desde la esquina superior izquierda, como en un diseño en pantalla.
+type Document struct {
+	width, height float64
+	title         string
+	content       bytes.Buffer
+	fonts         []Font
+	images        []jpegImage
+}
+
+// New crea un documento de una página del tamaño indicado.
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// Width devuelve el ancho de la página.
+func (d *Document) Width() float64 { return d.width }
+
+// Height devuelve el alto de la página.
+func (d *Document) Height() float64 { return d.height }
+
+// SetTitle fija el título de los metadatos del documento.
+func (d *Document) SetTitle(title string) { d.title = title }
+
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-" {
+		return "0"
+	}
+	return s
+}
+
+func (d *Document) op(format string, args ...interface{}) {
+	fmt.Fprintf(&d.content, format, args...)
+	d.content.WriteByte('\n')
+}
+
+// FillRect pinta un rectángulo relleno.
+func (d *Document) FillRect(x, y, w, h float64, c Color) {
+	d.op("%s %s %s rg %s %s %s %s re f", num(c.R), num(c.G), num(c.B), num(x), num(d.height-y-h), num(w), num(h))
+}
+
+// StrokeRect dibuja el borde de un rectángulo.
+func (d *Document) StrokeRect(x, y, w, h, lineWidth float64, c Color) {
+	d.op("%s w %s %s %s RG %s %s %s %s re S", num(lineWidth), num(c.R), num(c.G), num(c.B), num(x), num(d.height-y-h), num(w), num(h))
+}
+
+// Line dibuja una línea recta.
+func (d *Document) Line(x1, y1, x2, y2, lineWidth float64, c Color) {
+	d.op("%s w %s %s %s RG %s %s m %s %s l S", num(lineWidth), num(c.R), num(c.G), num(c.B),
+		num(x1), num(d.height-y1), num(x2), num(d.height-y2))
+}
+
+func (d *Document) fontRef(f Font) string {
+	for i, used := range d.fonts {
+		if used == f {
+			return fmt.Sprintf("F%d", i+1)
+		}
+	}
+	d.fonts = append(d.fonts, f)
+	return fmt.Sprintf("F%d", len(d.fonts))
+}
+
+// escapeString escribe s (ya en WinAnsi) como cadena literal de PDF.
+func escapeString(s []byte) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range s {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// Text escribe s con la línea base en y, empezando en x.
+func (d *Document) Text(x, y float64, f Font, size float64, c Color, s string) {
+	d.op("BT /%s %s Tf %s %s %s rg %s %s Td %s Tj ET", d.fontRef(f), num(size), num(c.R), num(c.G), num(c.B),
+		num(x), num(d.height-y), escapeString(encodeWinAnsi(s)))
+}
+
+// Alignment es la alineación horizontal de un texto respecto de su x.
+type Alignment string
+
+const (
+	AlignLeft   Alignment = "left"
+	AlignCenter Alignment = "center"
+	AlignRight  Alignment = "right"
+)
+
+// TextAligned escribe s alineado respecto de x: a la izquierda, centrado o a la derecha.
+func (d *Document) TextAligned(x, y float64, align Alignment, f Font, size float64, c Color, s string) {
+	switch align {
+	case AlignCenter:
+		x -= TextWidth(f, size, s) / 2
+	case AlignRight:
+		x -= TextWidth(f, size, s)
+	}
+	d.Text(x, y, f, size, c, s)
+}
+
+// JPEG dibuja una imagen JPEG ajustada (sin deformarla) y centrada en la caja indicada.
+func (d *Document) JPEG(data []byte, x, y, boxW, boxH float64) error {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("imagen JPEG inválida: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return errors.New("imagen JPEG vacía")
+	}
+	var colorSpace string
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		colorSpace = "DeviceGray"
+	case color.YCbCrModel, color.RGBAModel:
+		colorSpace = "DeviceRGB"
+	default:
+		return errors.New("solo se admiten imágenes JPEG en escala de grises o RGB")
+	}
+	d.images = append(d.images, jpegImage{data: data, width: cfg.Width, height: cfg.Height, colorSpace: colorSpace})
+
+	scale := boxW / float64(cfg.Width)
+	if s := boxH / float64(cfg.Height); s < scale {
+		scale = s
+	}
+	w, h := float64(cfg.Width)*scale, float64(cfg.Height)*scale
+	left := x + (boxW-w)/2
+	top := y + (boxH-h)/2
+	d.op("q %s 0 0 %s %s %s cm /Im%d Do Q", num(w), num(h), num(left), num(d.height-top-h), len(d.images))
+	return nil
+}
+
+// Bytes genera el archivo PDF.
+func (d *Document) Bytes() ([]byte, error) {
+	var content bytes.Buffer
+	zw := zlib.NewWriter(&content)
+	if _, err := zw.Write(d.content.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	// Los objetos se numeran en el orden en que se escriben: 1 catálogo, 2 páginas,
+	// 3 página, 4 contenido, 5 metadatos, luego fuentes e imágenes.
+	object := func(body string, stream []byte) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s", len(offsets), body)
+		if stream != nil {
+			out.WriteString("\nstream\n")
+			out.Write(stream)
+			out.WriteString("\nendstream")
+		}
+		out.WriteString("\nendobj\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	firstFont := 6
+	firstImage := firstFont + len(d.fonts)
+
+	var resources strings.Builder
+	resources.WriteString("<< /Font <<")
+	for i := range d.fonts {
+		fmt.Fprintf(&resources, " /F%d %d 0 R", i+1, firstFont+i)
+	}
+	resources.WriteString(" >>")
+	if len(d.images) > 0 {
+		resources.WriteString(" /XObject <<")
+		for i := range d.images {
+			fmt.Fprintf(&resources, " /Im%d %d 0 R", i+1, firstImage+i)
+		}
+		resources.WriteString(" >>")
+	}
+	resources.WriteString(" >>")
+
+	object("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>", nil)
+	object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents 4 0 R >>",
+		num(d.width), num(d.height), resources.String()), nil)
+	object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>", content.Len()), content.Bytes())
+	object(fmt.Sprintf("<< /Title %s /Producer (Yamerito) >>", escapeString(encodeWinAnsi(d.title))), nil)
+	for _, f := range d.fonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", fontNames[f]), nil)
+	}
+	for _, img := range d.images {
+		object(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>",
+			img.width, img.height, img.colorSpace, len(img.data)), img.data)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes(), nil
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RequestActor identifica quién origina una operación y desde dónde.
+// Los handlers lo construyen a partir de los claims JWT y de la solicitud HTTP.
+type RequestActor struct {
+	UserID    uint // 0 si la operación es anónima (p. ej. un login fallido)
+	Username  string
+	Role      models.Role
+	IP        string
+	RequestID string
+}
+
+// auditRecord agrupa los datos de un evento antes de encadenarlo.
+type auditRecord struct {
+	Action     string
+	TargetType string
+	TargetID   uint
+	Before     map[string]interface{}
+	After      map[string]interface{}
+}
+
+// recordAudit agrega un evento a la cadena de auditoría dentro de la transacción tx.
+// Debe llamarse en la misma transacción que el cambio auditado: si el evento no se
+// puede registrar, el cambio tampoco se confirma.
+func recordAudit(tx *gorm.DB, actor RequestActor, rec auditRecord) error {
+	event := models.AuditEvent{
+		OccurredAt:    time.Now().UTC().Truncate(time.Millisecond),
+		ActorUsername: actor.Username,
+		Action:        rec.Action,
+		TargetType:    rec.TargetType,
+		IP:            actor.IP,
+		RequestID:     actor.RequestID,
+	}
+	if actor.UserID != 0 {
+		actorID := actor.UserID
+		event.ActorID = &actorID
+	}
+	if rec.TargetID != 0 {
+		targetID := rec.TargetID
+		event.TargetID = &targetID
+	}
+
+	var err error
+	if event.Before, err = marshalSnapshot(rec.Before); err != nil {
+		return err
+	}
+	if event.After, err = marshalSnapshot(rec.After); err != nil {
+		return err
+	}
+	if diff := diffSnapshots(rec.Before, rec.After); len(diff) > 0 {
+		if event.Diff, err = json.Marshal(diff); err != nil {
+			return err
+		}
+	}
+
+	// Bloquear el último eslabón serializa a los escritores concurrentes; el índice único
+	// sobre prev_hash cubre el caso de la tabla vacía.
+	var last models.AuditEvent
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+		return fmt.Errorf("no se pudo leer el último evento de auditoría: %w", err)
+	}
+	event.PrevHash = last.Hash
+	event.Hash = event.ComputeHash()
+
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("no se pudo registrar el evento de auditoría: %w", err)
+	}
+	return nil
+}
+
+// recordAuditStandalone registra un evento que no acompaña a ningún otro cambio
+// (p. ej. intentos de login). Los errores solo se registran en el log.
+func recordAuditStandalone(db *gorm.DB, actor RequestActor, rec auditRecord) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return recordAudit(tx, actor, rec)
+	})
+	if err != nil {
+		log.Printf("Error al registrar evento de auditoría '%s': %v", rec.Action, err)
+	}
+}
+
+func marshalSnapshot(snapshot map[string]interface{}) (json.RawMessage, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	return json.Marshal(snapshot)
+}
+
+// userAuditSnapshot representa un usuario (y su EmployeeDetail) en la auditoría.
+// El hash de la contraseña nunca se guarda: se reemplaza por una huella corta que
+// solo sirve para detectar que cambió.
+func userAuditSnapshot(user *models.User) map[string]interface{} {
+	fingerprint := sha256.Sum256([]byte(user.PasswordHash))
+	snapshot := map[string]interface{}{
+		"id":       user.ID,
+		"username": user.Username,
+		"role":     string(user.Role),
+		"status":   string(user.Status),
+		"version":  user.Version,
+		"password": "[oculto:" + hex.EncodeToString(fingerprint[:4]) + "]",
+	}
+	if user.EmployeeDetail.ID != 0 {
+		d := user.EmployeeDetail
+		snapshot["employee_details"] = map[string]interface{}{
+			"id":               d.ID,
+			"name":             d.Name,
+			"last_name":        d.LastName,
+			"email":            d.Email,
+			"phone_number":     d.PhoneNumber,
+			"position":         d.Position,
+			"department_id":    optionalIDValue(d.DepartmentID),
+			"team_id":          optionalIDValue(d.TeamID),
+			"manager_id":       optionalIDValue(d.ManagerID),
+			"hire_date":        optionalDateValue(d.HireDate),
+			"termination_date": optionalDateValue(d.TerminationDate),
+			"version":          d.Version,
+		}
+	}
+	return snapshot
+}
+
+// optionalDateValue representa una fecha opcional en una instantánea ("AAAA-MM-DD" o nil).
+func optionalDateValue(date *time.Time) interface{} {
+	if date == nil {
+		return nil
+	}
+	return date.Format("2006-01-02")
+}
+
+// optionalIDValue representa un ID opcional en una instantánea (nil si no está asignado).
+func optionalIDValue(id *uint) interface{} {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+// diffSnapshots devuelve, por cada campo (con notación de puntos para objetos anidados),
+// el valor anterior y el nuevo. Solo incluye los campos que cambiaron.
+func diffSnapshots(before, after map[string]interface{}) map[string]map[string]interface{} {
+	flatBefore := map[string]interface{}{}
+	flatAfter := map[string]interface{}{}
+	flattenSnapshot("", before, flatBefore)
+	flattenSnapshot("", after, flatAfter)
+
+	keys := map[string]bool{}
+	for k := range flatBefore {
+		keys[k] = true
+	}
+	for k := range flatAfter {
+		keys[k] = true
+	}
+
+	diff := map[string]map[string]interface{}{}
+	for k := range keys {
+		from, to := flatBefore[k], flatAfter[k]
+		if !reflect.DeepEqual(from, to) {
+			diff[k] = map[string]interface{}{"from": from, "to": to}
+		}
+	}
+	return diff
+}
+
+func flattenSnapshot(prefix string, value map[string]interface{}, out map[string]interface{}) {
+	for k, v := range value {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok {
+			flattenSnapshot(key, nested, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+// AuditFilter define los filtros de GET /api/v1/admin/audit.
+type AuditFilter struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   *uint
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	PageSize   int
+}
+
+// AuditPage es una página de resultados de auditoría.
+type AuditPage struct {
+	Items    []models.AuditEvent `json:"items"`
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+}
+
+// AuditChainVerification es el resultado de recorrer y verificar la cadena de hashes.
+type AuditChainVerification struct {
+	Valid          bool   `json:"valid"`
+	CheckedEvents  int    `json:"checked_events"`
+	FirstInvalidID uint   `json:"first_invalid_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// AuditServiceInterface define la consulta y verificación del registro de auditoría.
+// La escritura no forma parte de la interfaz: solo los servicios la realizan, dentro de sus transacciones.
+type AuditServiceInterface interface {
+	ListEvents(filter AuditFilter) (*AuditPage, error)
+	VerifyChain() (*AuditChainVerification, error)
+}
+
+// AuditService implementa AuditServiceInterface.
+type AuditService struct {
+	DB *gorm.DB
+}
+
+// NewAuditService crea una nueva instancia de AuditService.
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{DB: db}
+}
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+	auditVerifyBatchSize = 500
+)
+
+// ListEvents devuelve los eventos que cumplen el filtro, del más reciente al más antiguo.
+func (s *AuditService) ListEvents(filter AuditFilter) (*AuditPage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultAuditPageSize
+	}
+	if filter.PageSize > maxAuditPageSize {
+		filter.PageSize = maxAuditPageSize
+	}
+
+	query := s.DB.Model(&models.AuditEvent{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		query = query.Where("target_id = ?", *filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		query = query.Where("occurred_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("occurred_at < ?", *filter.To)
+	}
+
+	page := &AuditPage{Page: filter.Page, PageSize: filter.PageSize}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		log.Printf("Error al contar eventos de auditoría: %v", err)
+		return nil, errors.New("no se pudo consultar la auditoría")
+	}
+	if err := query.Order("id DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&page.Items).Error; err != nil {
+		log.Printf("Error al listar eventos de auditoría: %v", err)
+		return nil, errors.New("no se pudo consultar la auditoría")
+	}
+	return page, nil
+}
+
+// VerifyChain recorre todos los eventos en orden y comprueba que cada hash sea correcto
+// y que cada evento apunte al hash del anterior.
+func (s *AuditService) VerifyChain() (*AuditChainVerification, error) {
+	result := &AuditChainVerification{Valid: true}
+	prevHash := ""
+	var lastID uint
+
+	for {
+		var batch []models.AuditEvent
+		if err := s.DB.Where("id > ?", lastID).Order("id ASC").Limit(auditVerifyBatchSize).Find(&batch).Error; err != nil {
+			log.Printf("Error al leer eventos de auditoría para verificación: %v", err)
+			return nil, errors.New("no se pudo verificar la auditoría")
+		}
+		var ok bool
+		if prevHash, ok = verifyAuditEvents(batch, prevHash, result); !ok {
+			return result, nil
+		}
+		if len(batch) > 0 {
+			lastID = batch[len(batch)-1].ID
+		}
+		if len(batch) < auditVerifyBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// verifyAuditEvents comprueba un tramo consecutivo de la cadena a partir de prevHash y
+// devuelve el hash del último evento. Si encuentra un evento inválido lo anota en result
+// y devuelve false.
+func verifyAuditEvents(events []models.AuditEvent, prevHash string, result *AuditChainVerification) (string, bool) {
+	for i := range events {
+		event := &events[i]
+		result.CheckedEvents++
+		if event.PrevHash != prevHash {
+			result.Valid = false
+			result.FirstInvalidID = event.ID
+			result.Reason = "el evento no apunta al hash del evento anterior (falta o se insertó un evento)"
+			return prevHash, false
+		}
+		if event.ComputeHash() != event.Hash {
+			result.Valid = false
+			result.FirstInvalidID = event.ID
+			result.Reason = "el contenido del evento no coincide con su hash (fue modificado)"
+			return prevHash, false
+		}
+		prevHash = event.Hash
+	}
+	return prevHash, true
+}
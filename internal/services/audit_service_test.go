@@ -0,0 +1,126 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+)
+
+// buildAuditChain encadena los eventos como lo hace el registro al insertarlos.
+func buildAuditChain(events []models.AuditEvent) []models.AuditEvent {
+	prev := ""
+	for i := range events {
+		events[i].ID = uint(i + 1)
+		events[i].PrevHash = prev
+		events[i].Hash = events[i].ComputeHash()
+		prev = events[i].Hash
+	}
+	return events
+}
+
+func sampleAuditChain() []models.AuditEvent {
+	actor, target := uint(1), uint(42)
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	return buildAuditChain([]models.AuditEvent{
+		{OccurredAt: at, Action: models.AuditActionLoginSucceeded, ActorID: &actor, ActorUsername: "admin", IP: "10.0.0.1"},
+		{
+			OccurredAt: at.Add(time.Minute), ActorID: &actor, ActorUsername: "admin",
+			Action: models.AuditActionUserUpdated, TargetType: models.AuditTargetUser, TargetID: &target,
+			Before: []byte(`{"role":"employee"}`), After: []byte(`{"role":"admin"}`), Diff: []byte(`{"role":["employee","admin"]}`),
+			IP: "10.0.0.1", RequestID: "req-1",
+		},
+		{OccurredAt: at.Add(2 * time.Minute), Action: models.AuditActionLoginFailed, ActorUsername: "mallory", IP: "10.0.0.9"},
+	})
+}
+
+func TestVerifyAuditEventsAcceptsIntactChain(t *testing.T) {
+	result := &AuditChainVerification{Valid: true}
+	if _, ok := verifyAuditEvents(sampleAuditChain(), "", result); !ok || !result.Valid || result.CheckedEvents != 3 {
+		t.Fatalf("cadena intacta rechazada: %+v", result)
+	}
+}
+
+// La verificación por lotes debe continuar desde el hash del lote anterior.
+func TestVerifyAuditEventsAcrossBatches(t *testing.T) {
+	chain := sampleAuditChain()
+	result := &AuditChainVerification{Valid: true}
+	prev, ok := verifyAuditEvents(chain[:2], "", result)
+	if !ok {
+		t.Fatalf("primer lote rechazado: %+v", result)
+	}
+	if _, ok := verifyAuditEvents(chain[2:], prev, result); !ok || result.CheckedEvents != 3 {
+		t.Fatalf("segundo lote rechazado: %+v", result)
+	}
+}
+
+func TestVerifyAuditEventsDetectsTampering(t *testing.T) {
+	other := uint(99)
+	tests := []struct {
+		name   string
+		tamper func(e *models.AuditEvent)
+	}{
+		{"occurred_at", func(e *models.AuditEvent) { e.OccurredAt = e.OccurredAt.Add(time.Millisecond) }},
+		{"actor_id", func(e *models.AuditEvent) { e.ActorID = &other }},
+		{"actor_id nulo", func(e *models.AuditEvent) { e.ActorID = nil }},
+		{"actor_username", func(e *models.AuditEvent) { e.ActorUsername = "root" }},
+		{"action", func(e *models.AuditEvent) { e.Action = models.AuditActionUserCreated }},
+		{"target_type", func(e *models.AuditEvent) { e.TargetType = models.AuditTargetTeam }},
+		{"target_id", func(e *models.AuditEvent) { e.TargetID = &other }},
+		{"before", func(e *models.AuditEvent) { e.Before = []byte(`{"role":"admin"}`) }},
+		{"after", func(e *models.AuditEvent) { e.After = []byte(`{"role":"employee"}`) }},
+		{"diff", func(e *models.AuditEvent) { e.Diff = nil }},
+		{"ip", func(e *models.AuditEvent) { e.IP = "127.0.0.1" }},
+		{"request_id", func(e *models.AuditEvent) { e.RequestID = "req-2" }},
+		{"prev_hash", func(e *models.AuditEvent) { e.PrevHash = e.Hash }},
+		{"hash", func(e *models.AuditEvent) { e.Hash = e.PrevHash }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := sampleAuditChain()
+			tt.tamper(&chain[1])
+			result := &AuditChainVerification{Valid: true}
+			if _, ok := verifyAuditEvents(chain, "", result); ok || result.Valid {
+				t.Fatal("la alteración no fue detectada")
+			}
+			if result.FirstInvalidID != 2 {
+				t.Errorf("FirstInvalidID = %d, se esperaba 2", result.FirstInvalidID)
+			}
+		})
+	}
+}
+
+// Recalcular el hash de la fila alterada no basta: el evento siguiente deja de apuntar a ella.
+func TestVerifyAuditEventsDetectsRehashedRow(t *testing.T) {
+	chain := sampleAuditChain()
+	chain[1].IP = "127.0.0.1"
+	chain[1].Hash = chain[1].ComputeHash()
+	result := &AuditChainVerification{Valid: true}
+	if _, ok := verifyAuditEvents(chain, "", result); ok || result.FirstInvalidID != 3 {
+		t.Fatalf("se esperaba detectar la ruptura en el evento 3: %+v", result)
+	}
+}
+
+func TestVerifyAuditEventsDetectsRemovedRow(t *testing.T) {
+	tests := []struct {
+		name      string
+		remove    int
+		invalidID uint
+	}{
+		{"primero", 0, 2},
+		{"intermedio", 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := sampleAuditChain()
+			chain = append(chain[:tt.remove], chain[tt.remove+1:]...)
+			result := &AuditChainVerification{Valid: true}
+			if _, ok := verifyAuditEvents(chain, "", result); ok || result.Valid {
+				t.Fatal("la eliminación no fue detectada")
+			}
+			if result.FirstInvalidID != tt.invalidID {
+				t.Errorf("FirstInvalidID = %d, se esperaba %d", result.FirstInvalidID, tt.invalidID)
+			}
+		})
+	}
+}
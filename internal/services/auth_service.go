@@ -18,7 +18,7 @@ type LoginRequestDTO struct {
 
 // AuthServiceInterface define la interfaz para operaciones de autenticación.
 type AuthServiceInterface interface {
-	LoginUser(dto LoginRequestDTO) (string, *models.User, error)
+	LoginUser(actor RequestActor, dto LoginRequestDTO) (string, *models.User, error)
 }
 
 // AuthService implementa AuthServiceInterface.
@@ -32,12 +32,15 @@ func NewAuthService(db *gorm.DB) AuthServiceInterface {
 }
 
 // LoginUser autentica a un usuario y devuelve un token JWT y los detalles del usuario.
-func (s *AuthService) LoginUser(dto LoginRequestDTO) (string, *models.User, error) {
+// actor solo aporta IP e ID de solicitud; tanto los logins exitosos como los fallidos
+// quedan registrados en la auditoría.
+func (s *AuthService) LoginUser(actor RequestActor, dto LoginRequestDTO) (string, *models.User, error) {
 	var user models.User
 	// Buscar usuario por nombre de usuario
 	if err := s.DB.Where("username = ?", dto.Username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.Printf("Intento de login para usuario no encontrado: %s", dto.Username)
+			s.auditLoginFailed(actor, dto.Username, 0, "usuario no encontrado")
 			return "", nil, errors.New("usuario o contraseña incorrectos")
 		}
 		log.Printf("Error al buscar usuario '%s' durante login: %v", dto.Username, err)
@@ -52,9 +55,18 @@ func (s *AuthService) LoginUser(dto LoginRequestDTO) (string, *models.User, erro
 	}
 	if !passwordMatch {
 		log.Printf("Contraseña incorrecta para usuario: %s", dto.Username)
+		s.auditLoginFailed(actor, dto.Username, user.ID, "contraseña incorrecta")
 		return "", nil, errors.New("usuario o contraseña incorrectos")
 	}
 
+	// Solo las cuentas activas o de licencia pueden entrar. Se comprueba después de la
+	// contraseña para no revelar el estado de una cuenta a quien no conoce sus credenciales.
+	if !user.Status.CanLogin() {
+		log.Printf("Intento de login de la cuenta '%s' en estado '%s'", user.Username, user.Status)
+		s.auditLoginFailed(actor, dto.Username, user.ID, "cuenta en estado "+user.Status.String())
+		return "", nil, errors.New("la cuenta no está activa")
+	}
+
 	// Generar token JWT
 	// La firma es: GenerateJWT(userID uint, username string, role models.Role)
 	token, err := auth.GenerateJWT(user.ID, user.Username, user.Role) // Corregido el orden de los argumentos
@@ -63,8 +75,32 @@ func (s *AuthService) LoginUser(dto LoginRequestDTO) (string, *models.User, erro
 		return "", nil, errors.New("error al generar token de sesión")
 	}
 
+	actor.UserID = user.ID
+	actor.Username = user.Username
+	recordAuditStandalone(s.DB, actor, auditRecord{
+		Action:     models.AuditActionLoginSucceeded,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+	})
+
 	log.Printf("Usuario '%s' logueado exitosamente.", user.Username)
 	// No devolver la contraseña hasheada
 	user.PasswordHash = ""
 	return token, &user, nil
 }
+
+// auditLoginFailed registra un intento de login fallido. El actor es anónimo; el nombre
+// de usuario intentado se guarda igualmente para detectar ataques de fuerza bruta.
+func (s *AuthService) auditLoginFailed(actor RequestActor, attemptedUsername string, userID uint, reason string) {
+	// Ajustar al tamaño de la columna actor_username. Se recorta por caracteres: cortar un
+	// carácter multibyte a la mitad haría que MySQL rechace el registro.
+	attemptedUsername = truncateRunes(attemptedUsername, 50)
+	actor.UserID = 0
+	actor.Username = attemptedUsername
+	recordAuditStandalone(s.DB, actor, auditRecord{
+		Action:     models.AuditActionLoginFailed,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID,
+		After:      map[string]interface{}{"reason": reason},
+	})
+}
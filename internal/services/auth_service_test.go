@@ -0,0 +1,30 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// El nombre intentado en un login fallido se guarda recortado en actor_username
+// (varchar(50)): el recorte no puede partir un carácter multibyte.
+func TestTruncateRunesKeepsValidUTF8(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"ana", "ana"},
+		{strings.Repeat("a", 49) + "ñandú", strings.Repeat("a", 49) + "ñ"},
+		{strings.Repeat("é", 60), strings.Repeat("é", 50)},
+		{strings.Repeat("😀", 51), strings.Repeat("😀", 50)},
+	}
+	for _, tt := range tests {
+		got := truncateRunes(tt.in, 50)
+		if got != tt.want {
+			t.Errorf("truncateRunes(%q, 50) = %q, se esperaba %q", tt.in, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncateRunes(%q, 50) = %q no es UTF-8 válido", tt.in, got)
+		}
+	}
+}
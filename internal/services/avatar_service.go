@@ -0,0 +1,239 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/imaging"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/Unikyri/yamerito-mvp/internal/storage"
+	"gorm.io/gorm"
+)
+
+// AvatarSizes son los tamaños (lado en píxeles) que se generan para cada foto de perfil.
+var AvatarSizes = map[string]int{
+	"small":  64,
+	"medium": 256,
+	"large":  512,
+}
+
+// DefaultAvatarSize es el tamaño que se sirve si la solicitud no indica ninguno.
+const DefaultAvatarSize = "medium"
+
+const avatarJPEGQuality = 85
+
+// AvatarObject identifica una variante de la foto de perfil de un usuario.
+type AvatarObject struct {
+	Key       string
+	ETag      string // Cambia con cada foto nueva, por lo que se puede cachear sin límite
+	UpdatedAt time.Time
+}
+
+// AvatarServiceInterface define la gestión de fotos de perfil de los empleados.
+type AvatarServiceInterface interface {
+	SetAvatar(actor RequestActor, userID uint, data []byte) (*UserDetailDTO, error)
+	DeleteAvatar(actor RequestActor, userID uint) error
+	GetAvatar(userID uint, size string) (*AvatarObject, error)
+	OpenAvatar(ctx context.Context, obj *AvatarObject) (io.ReadCloser, *storage.ObjectInfo, error)
+}
+
+// AvatarService implementa AvatarServiceInterface.
+type AvatarService struct {
+	DB    *gorm.DB
+	Store storage.BlobStore
+}
+
+// NewAvatarService crea una nueva instancia de AvatarService.
+func NewAvatarService(db *gorm.DB, store storage.BlobStore) *AvatarService {
+	return &AvatarService{DB: db, Store: store}
+}
+
+func avatarBlobKey(prefix, size string) string {
+	return prefix + "/" + size + ".jpg"
+}
+
+// deleteAvatarBlobs elimina todas las variantes de una foto. Es de mejor esfuerzo:
+// un archivo huérfano no afecta al funcionamiento, así que los errores solo se registran.
+func (s *AvatarService) deleteAvatarBlobs(prefix string) {
+	if prefix == "" {
+		return
+	}
+	for size := range AvatarSizes {
+		if err := s.Store.Delete(context.Background(), avatarBlobKey(prefix, size)); err != nil {
+			log.Printf("Error al eliminar la foto de perfil '%s' (%s): %v", prefix, size, err)
+		}
+	}
+}
+
+// SetAvatar procesa la imagen subida (detección del tipo real, orientación EXIF,
+// recorte cuadrado y reducción a cada tamaño de AvatarSizes), guarda las variantes y
+// reemplaza la foto vigente. Las variantes se vuelven a codificar como JPEG, lo que
+// descarta los metadatos EXIF originales (incluida la ubicación GPS).
+func (s *AvatarService) SetAvatar(actor RequestActor, userID uint, data []byte) (*UserDetailDTO, error) {
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return nil, errors.New("no se pudo procesar la imagen")
+	}
+	prefix := fmt.Sprintf("avatars/%d/%s", userID, hex.EncodeToString(token))
+
+	for size, side := range AvatarSizes {
+		encoded, err := imaging.EncodeJPEG(imaging.Thumbnail(img, side), avatarJPEGQuality)
+		if err != nil {
+			log.Printf("Error al codificar la foto de perfil (%s): %v", size, err)
+			s.deleteAvatarBlobs(prefix)
+			return nil, errors.New("no se pudo procesar la imagen")
+		}
+		if err := s.Store.Put(context.Background(), avatarBlobKey(prefix, size), bytes.NewReader(encoded), int64(len(encoded)), "image/jpeg"); err != nil {
+			log.Printf("Error al guardar la foto de perfil (%s): %v", size, err)
+			s.deleteAvatarBlobs(prefix)
+			return nil, errors.New("no se pudo guardar la imagen")
+		}
+	}
+
+	var user models.User
+	tx := s.DB.Begin()
+	if err := tx.Preload("EmployeeDetail").First(&user, userID).Error; err != nil {
+		tx.Rollback()
+		s.deleteAvatarBlobs(prefix)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("usuario no encontrado")
+		}
+		log.Printf("Error al buscar usuario %d para actualizar su foto: %v", userID, err)
+		return nil, errors.New("no se pudo guardar la imagen")
+	}
+	if user.EmployeeDetail.ID == 0 {
+		tx.Rollback()
+		s.deleteAvatarBlobs(prefix)
+		return nil, errors.New("el usuario no tiene detalles de empleado")
+	}
+
+	previous := user.EmployeeDetail.AvatarKey
+	now := time.Now()
+	user.EmployeeDetail.AvatarKey = prefix
+	user.EmployeeDetail.AvatarUpdatedAt = &now
+	if err := saveUserVersioned(tx, &user, false, true); err != nil {
+		tx.Rollback()
+		s.deleteAvatarBlobs(prefix)
+		log.Printf("Error al guardar la foto de perfil del usuario %d: %v", userID, err)
+		if errors.Is(err, errVersionConflict) {
+			return nil, errors.New("el usuario fue modificado al mismo tiempo; intente de nuevo")
+		}
+		return nil, errors.New("no se pudo guardar la imagen")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionUserAvatarUpdated,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]interface{}{"avatar": previous},
+		After:      map[string]interface{}{"avatar": prefix},
+	}); err != nil {
+		tx.Rollback()
+		s.deleteAvatarBlobs(prefix)
+		log.Printf("Error al auditar la foto de perfil del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudo guardar la imagen")
+	}
+	tx.Commit()
+
+	s.deleteAvatarBlobs(previous)
+	return userDetailDTOWithCustomFields(s.DB, &user), nil
+}
+
+// DeleteAvatar quita la foto de perfil del usuario.
+func (s *AvatarService) DeleteAvatar(actor RequestActor, userID uint) error {
+	var user models.User
+	tx := s.DB.Begin()
+	if err := tx.Preload("EmployeeDetail").First(&user, userID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("usuario no encontrado")
+		}
+		log.Printf("Error al buscar usuario %d para quitar su foto: %v", userID, err)
+		return errors.New("no se pudo eliminar la imagen")
+	}
+	previous := user.EmployeeDetail.AvatarKey
+	if previous == "" {
+		tx.Rollback()
+		return errors.New("el usuario no tiene foto de perfil")
+	}
+
+	user.EmployeeDetail.AvatarKey = ""
+	user.EmployeeDetail.AvatarUpdatedAt = nil
+	if err := saveUserVersioned(tx, &user, false, true); err != nil {
+		tx.Rollback()
+		log.Printf("Error al quitar la foto de perfil del usuario %d: %v", userID, err)
+		return errors.New("no se pudo eliminar la imagen")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionUserAvatarDeleted,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]interface{}{"avatar": previous},
+		After:      map[string]interface{}{"avatar": ""},
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la eliminación de la foto del usuario %d: %v", userID, err)
+		return errors.New("no se pudo eliminar la imagen")
+	}
+	tx.Commit()
+
+	s.deleteAvatarBlobs(previous)
+	return nil
+}
+
+// GetAvatar resuelve qué objeto corresponde a la foto vigente del usuario en el tamaño pedido.
+func (s *AvatarService) GetAvatar(userID uint, size string) (*AvatarObject, error) {
+	if size == "" {
+		size = DefaultAvatarSize
+	}
+	if _, ok := AvatarSizes[size]; !ok {
+		return nil, errors.New("tamaño de foto inválido")
+	}
+
+	var detail models.EmployeeDetail
+	err := s.DB.Select("avatar_key", "avatar_updated_at").
+		Joins("JOIN users ON users.id = employee_details.user_id AND users.deleted_at IS NULL").
+		Where("employee_details.user_id = ?", userID).
+		Limit(1).Find(&detail).Error
+	if err != nil {
+		log.Printf("Error al buscar la foto de perfil del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudo obtener la imagen")
+	}
+	if detail.AvatarKey == "" {
+		return nil, errors.New("el usuario no tiene foto de perfil")
+	}
+
+	obj := &AvatarObject{
+		Key:  avatarBlobKey(detail.AvatarKey, size),
+		ETag: fmt.Sprintf("\"%s-%s\"", path.Base(detail.AvatarKey), size), // El último segmento del prefijo es aleatorio
+	}
+	if detail.AvatarUpdatedAt != nil {
+		obj.UpdatedAt = *detail.AvatarUpdatedAt
+	}
+	return obj, nil
+}
+
+// OpenAvatar abre el contenido de la variante indicada.
+func (s *AvatarService) OpenAvatar(ctx context.Context, obj *AvatarObject) (io.ReadCloser, *storage.ObjectInfo, error) {
+	rc, info, err := s.Store.Get(ctx, obj.Key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, errors.New("el usuario no tiene foto de perfil")
+		}
+		log.Printf("Error al leer la foto de perfil '%s': %v", obj.Key, err)
+		return nil, nil, errors.New("no se pudo obtener la imagen")
+	}
+	return rc, info, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/events"
+	"github.com/Unikyri/yamerito-mvp/internal/jobs"
+	"github.com/Unikyri/yamerito-mvp/internal/mailer"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// Tipos de trabajo en segundo plano.
+const (
+	JobTypeApplyLifecycleTransitions = "lifecycle.apply_due_transitions"
+	JobTypePurgeDomainEvents         = "maintenance.purge_domain_events"
+	JobTypeExpireQuizAttempts        = "quizzes.expire_attempts"
+	JobTypeIssueCertificate          = "certificates.issue"
+	JobTypeIssuePathCertificate      = "certificates.issue_path"
+	JobTypeReissueCertificates       = "certificates.reissue"
+)
+
+// PurgeDomainEventsPayload configura la limpieza de eventos de dominio ya entregados.
+type PurgeDomainEventsPayload struct {
+	RetentionDays int `json:"retention_days"`
+}
+
+// defaultEventRetentionDays es cuánto se conservan los eventos ya entregados.
+const defaultEventRetentionDays = 30
+
+// RegisterEventSubscribers suscribe al bus los consumidores de eventos de dominio de la
+// aplicación. Lo usan cmd/server y cmd/worker, de modo que cualquiera de los dos reparte
+// los eventos a los mismos suscriptores.
+func RegisterEventSubscribers(bus *events.Bus, notifications *NotificationService) {
+	bus.Subscribe(WebhookEventSubscriber{})
+	bus.Subscribe(NotificationEventSubscriber{Notifications: notifications})
+	bus.Subscribe(SearchIndexSubscriber{})
+	bus.Subscribe(EnrollmentRuleSubscriber{})
+	bus.Subscribe(CertificateIssueSubscriber{})
+	bus.Subscribe(CertificationNotificationSubscriber{Notifications: notifications})
+	bus.Subscribe(LearningPathSubscriber{})
+}
+
+// RunDeliveryLoops entrega la bandeja de salida de correo, los webhooks salientes y los
+// eventos de dominio hasta que ctx se cancele, y vuelve cuando los tres terminaron. Los
+// tres reclaman cada fila de forma atómica, así que pueden ejecutarse en varios procesos.
+func RunDeliveryLoops(ctx context.Context, outbox *mailer.Outbox, webhooks *WebhookService, bus *events.Bus) {
+	var wg sync.WaitGroup
+	wg.Add(3)
+	// Correos pendientes, reintentando los que fallen
+	go func() { defer wg.Done(); outbox.Run(ctx, 15*time.Second) }()
+	// Webhooks salientes, reintentando con espera exponencial
+	go func() { defer wg.Done(); webhooks.Run(ctx, 10*time.Second) }()
+	// Eventos de dominio para cada suscriptor
+	go func() { defer wg.Done(); bus.Run(ctx, 2*time.Second) }()
+	wg.Wait()
+}
+
+// RegisterJobs registra los tipos de trabajo y las programaciones de la aplicación en w.
+// Lo usan tanto cmd/server como cmd/worker, para que cualquiera de los dos pueda
+// ejecutar cualquier trabajo.
+func RegisterJobs(w *jobs.Worker, db *gorm.DB, lifecycle *LifecycleService, certificates *CertificateService) error {
+	// Una sola ejecución a la vez: las transiciones de un mismo usuario no deben aplicarse en paralelo.
+	jobs.Register(w, JobTypeApplyLifecycleTransitions, jobs.HandlerOptions{Concurrency: 1, MaxAttempts: 1, Timeout: 5 * time.Minute},
+		func(ctx context.Context, _ struct{}) error {
+			applied, err := lifecycle.ApplyDueTransitions(time.Now())
+			if applied > 0 {
+				log.Printf("Ciclo de vida: %d transición(es) aplicadas.", applied)
+			}
+			return err
+		})
+	jobs.Register(w, JobTypePurgeDomainEvents, jobs.HandlerOptions{Concurrency: 1},
+		func(ctx context.Context, payload PurgeDomainEventsPayload) error {
+			return purgeDomainEvents(ctx, db, payload)
+		})
+	jobs.Register(w, JobTypeExpireQuizAttempts, jobs.HandlerOptions{Concurrency: 1, MaxAttempts: 1},
+		func(ctx context.Context, _ struct{}) error {
+			return finalizeExpiredAttempts(ctx, db)
+		})
+	jobs.Register(w, JobTypeIssueCertificate, jobs.HandlerOptions{Concurrency: 2, Timeout: time.Minute},
+		func(ctx context.Context, payload IssueCertificatePayload) error {
+			return certificates.IssueCertificate(ctx, payload.EnrollmentID)
+		})
+	jobs.Register(w, JobTypeIssuePathCertificate, jobs.HandlerOptions{Concurrency: 2, Timeout: time.Minute},
+		func(ctx context.Context, payload IssuePathCertificatePayload) error {
+			return certificates.IssuePathCertificate(ctx, payload.PathEnrollmentID)
+		})
+	// Una reemisión por vez: dos cambios seguidos de una plantilla no deben regenerar lo mismo en paralelo.
+	jobs.Register(w, JobTypeReissueCertificates, jobs.HandlerOptions{Concurrency: 1, Timeout: 30 * time.Minute},
+		func(ctx context.Context, payload ReissueCertificatesPayload) error {
+			return certificates.ReissueOutdated(ctx, payload.TemplateID)
+		})
+	jobs.Register(w, JobTypeRecertify, jobs.HandlerOptions{Concurrency: 1, MaxAttempts: 1, Timeout: 30 * time.Minute},
+		func(ctx context.Context, _ struct{}) error {
+			return certificates.ProcessExpirations(ctx, time.Now())
+		})
+	jobs.Register(w, JobTypeSyncLearningPath, jobs.HandlerOptions{Concurrency: 1, Timeout: 30 * time.Minute},
+		func(ctx context.Context, payload SyncLearningPathPayload) error {
+			return syncLearningPath(ctx, db, payload.PathID)
+		})
+
+	// Las transiciones programadas (ingresos, bajas, licencias) se aplican cada minuto.
+	if err := w.Schedule("lifecycle-transitions", "* * * * *", JobTypeApplyLifecycleTransitions, nil); err != nil {
+		return err
+	}
+	// Los intentos de cuestionario abandonados después del límite de tiempo se califican con
+	// lo que se guardó; al abrirlos también se cierran, esto cubre los que nadie vuelve a abrir.
+	if err := w.Schedule("expire-quiz-attempts", "*/5 * * * *", JobTypeExpireQuizAttempts, nil); err != nil {
+		return err
+	}
+	// Reinscripciones y recordatorios de las certificaciones por vencer, una vez al día.
+	if err := w.Schedule("recertify", "0 6 * * *", JobTypeRecertify, nil); err != nil {
+		return err
+	}
+	return w.Schedule("purge-domain-events", "30 3 * * *", JobTypePurgeDomainEvents,
+		PurgeDomainEventsPayload{RetentionDays: defaultEventRetentionDays})
+}
+
+// purgeBatchSize limita cuántos eventos se borran por transacción.
+const purgeBatchSize = 1000
+
+// purgeDomainEvents borra los eventos de dominio antiguos que ya se entregaron a todos sus
+// suscriptores. Los que tienen entregas pendientes o fallidas se conservan para revisarlos.
+func purgeDomainEvents(ctx context.Context, db *gorm.DB, payload PurgeDomainEventsPayload) error {
+	if payload.RetentionDays <= 0 {
+		payload.RetentionDays = defaultEventRetentionDays
+	}
+	cutoff := time.Now().AddDate(0, 0, -payload.RetentionDays)
+	db = db.WithContext(ctx)
+
+	purged := 0
+	for ctx.Err() == nil {
+		var ids []uint
+		undelivered := db.Model(&models.DomainEventDelivery{}).Select("event_id").
+			Where("status <> ?", models.DomainEventDeliveryDone)
+		if err := db.Model(&models.DomainEvent{}).
+			Where("occurred_at < ? AND dispatched_at IS NOT NULL AND id NOT IN (?)", cutoff, undelivered).
+			Order("id").Limit(purgeBatchSize).Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("no se pudieron buscar los eventos a purgar: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		tx := db.Begin()
+		if err := tx.Where("event_id IN ?", ids).Delete(&models.DomainEventDelivery{}).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("no se pudieron purgar las entregas de eventos: %w", err)
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&models.DomainEvent{}).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("no se pudieron purgar los eventos: %w", err)
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+		purged += len(ids)
+		if len(ids) < purgeBatchSize {
+			break
+		}
+	}
+	if purged > 0 {
+		log.Printf("Purgados %d evento(s) de dominio anteriores a %s.", purged, cutoff.Format("2006-01-02"))
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/Unikyri/yamerito-mvp/internal/pdf"
+)
+
+// certificatePlaceholder reconoce los marcadores {{nombre}} de los textos de una plantilla.
+var certificatePlaceholder = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// CertificatePlaceholders son los marcadores que se pueden usar en los textos de una plantilla.
+var CertificatePlaceholders = []string{
+	"employee_name",
+	"course_title",
+	"completion_date",
+	"expiration_date",
+	"score",
+	"verification_code",
+	"verification_url",
+}
+
+var spanishMonths = [...]string{
+	"enero", "febrero", "marzo", "abril", "mayo", "junio",
+	"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre",
+}
+
+// formatSpanishDate da formato "15 de marzo de 2025" a una fecha.
+func formatSpanishDate(t time.Time) string {
+	return fmt.Sprintf("%d de %s de %d", t.Day(), spanishMonths[t.Month()-1], t.Year())
+}
+
+// certificateData son los datos que se imprimen en un certificado.
+type certificateData struct {
+	RecipientName string
+	CourseTitle   string
+	CompletedAt   time.Time
+	ExpiresAt     *time.Time
+	Score         *int
+	Code          string
+	VerifyURL     string
+}
+
+func (d certificateData) values() map[string]string {
+	score := "-"
+	if d.Score != nil {
+		score = strconv.Itoa(*d.Score) + "%"
+	}
+	expiration := "Sin vencimiento"
+	if d.ExpiresAt != nil {
+		expiration = formatSpanishDate(*d.ExpiresAt)
+	}
+	return map[string]string{
+		"employee_name":     d.RecipientName,
+		"course_title":      d.CourseTitle,
+		"completion_date":   formatSpanishDate(d.CompletedAt),
+		"expiration_date":   expiration,
+		"score":             score,
+		"verification_code": d.Code,
+		"verification_url":  d.VerifyURL,
+	}
+}
+
+// sampleCertificateData son los datos de ejemplo de la vista previa de una plantilla.
+func sampleCertificateData(verifyBaseURL string) certificateData {
+	score := 92
+	expiresAt := time.Now().AddDate(1, 0, 0)
+	return certificateData{
+		RecipientName: "María Fernanda López",
+		CourseTitle:   "Curso de ejemplo",
+		CompletedAt:   time.Now(),
+		ExpiresAt:     &expiresAt,
+		Score:         &score,
+		Code:          "ABCD-EFGH-JKMN",
+		VerifyURL:     strings.TrimRight(verifyBaseURL, "/") + "/ABCD-EFGH-JKMN",
+	}
+}
+
+// validateCertificateTemplate comprueba el diseño de una plantilla antes de guardarlo.
+func validateCertificateTemplate(template *models.CertificateTemplate) error {
+	if strings.TrimSpace(template.Name) == "" {
+		return errors.New("el nombre de la plantilla no puede estar vacío")
+	}
+	switch template.Orientation {
+	case models.CertificateLandscape, models.CertificatePortrait:
+	default:
+		return errors.New("orientación de plantilla inválida")
+	}
+	for _, value := range []string{template.BackgroundColor, template.BorderColor} {
+		if value == "" {
+			continue
+		}
+		if _, err := pdf.ParseHexColor(value); err != nil {
+			return fmt.Errorf("la plantilla tiene un color inválido: '%s'", value)
+		}
+	}
+	if len(template.Elements) == 0 {
+		return errors.New("la plantilla debe tener al menos un elemento")
+	}
+
+	known := make(map[string]bool, len(CertificatePlaceholders))
+	for _, name := range CertificatePlaceholders {
+		known[name] = true
+	}
+	for i, element := range template.Elements {
+		position := i + 1
+		if element.X < 0 || element.Y < 0 || element.Width < 0 || element.Height < 0 {
+			return fmt.Errorf("la plantilla tiene medidas negativas en el elemento %d", position)
+		}
+		if element.Color != "" {
+			if _, err := pdf.ParseHexColor(element.Color); err != nil {
+				return fmt.Errorf("la plantilla tiene un color inválido en el elemento %d", position)
+			}
+		}
+		switch element.Type {
+		case models.CertificateElementText:
+			if strings.TrimSpace(element.Text) == "" {
+				return fmt.Errorf("la plantilla tiene un texto vacío en el elemento %d", position)
+			}
+			if element.FontSize < 0 || element.FontSize > 200 {
+				return fmt.Errorf("la plantilla tiene un tamaño de letra inválido en el elemento %d", position)
+			}
+			switch pdf.Alignment(element.Align) {
+			case "", pdf.AlignLeft, pdf.AlignCenter, pdf.AlignRight:
+			default:
+				return fmt.Errorf("la plantilla tiene una alineación inválida en el elemento %d", position)
+			}
+			for _, match := range certificatePlaceholder.FindAllStringSubmatch(element.Text, -1) {
+				if !known[match[1]] {
+					return fmt.Errorf("la plantilla usa un marcador desconocido: {{%s}}", match[1])
+				}
+			}
+		case models.CertificateElementSignature:
+			if element.Width <= 0 || element.Height <= 0 {
+				return fmt.Errorf("la plantilla necesita ancho y alto para la firma del elemento %d", position)
+			}
+		case models.CertificateElementLine:
+			if element.Width <= 0 {
+				return fmt.Errorf("la plantilla necesita el ancho de la línea del elemento %d", position)
+			}
+		default:
+			return fmt.Errorf("la plantilla tiene un tipo de elemento inválido en el elemento %d", position)
+		}
+	}
+	return nil
+}
+
+// defaultCertificateTemplate es el diseño que se usa si no hay ninguna plantilla definida.
+func defaultCertificateTemplate() *models.CertificateTemplate {
+	center := 148.5 // Mitad del ancho de una página A4 apaisada, en mm
+	return &models.CertificateTemplate{
+		Name:        "Predeterminada",
+		Orientation: models.CertificateLandscape,
+		BorderColor: "#1F3A5F",
+		Elements: []models.CertificateElement{
+			{Type: models.CertificateElementText, Text: "CERTIFICADO DE FINALIZACIÓN", X: center, Y: 50, FontSize: 30, Bold: true, Align: "center", Color: "#1F3A5F"},
+			{Type: models.CertificateElementText, Text: "Se certifica que", X: center, Y: 75, FontSize: 14, Align: "center"},
+			{Type: models.CertificateElementText, Text: "{{employee_name}}", X: center, Y: 95, FontSize: 26, Bold: true, Align: "center"},
+			{Type: models.CertificateElementText, Text: "completó el curso", X: center, Y: 112, FontSize: 14, Align: "center"},
+			{Type: models.CertificateElementText, Text: "{{course_title}}", X: center, Y: 128, FontSize: 20, Italic: true, Align: "center"},
+			{Type: models.CertificateElementText, Text: "el {{completion_date}} · Calificación: {{score}}", X: center, Y: 145, FontSize: 12, Align: "center"},
+			{Type: models.CertificateElementText, Text: "Código de verificación: {{verification_code}} · {{verification_url}}", X: center, Y: 190, FontSize: 9, Align: "center", Color: "#555555"},
+		},
+	}
+}
+
+func elementColor(value string) pdf.Color {
+	c, err := pdf.ParseHexColor(value)
+	if err != nil {
+		return pdf.Black
+	}
+	return c
+}
+
+// renderCertificate genera el PDF de un certificado con la plantilla indicada. signature es
+// la imagen JPEG de la firma; si falta, los elementos de firma se omiten.
+func renderCertificate(template *models.CertificateTemplate, signature []byte, data certificateData) ([]byte, error) {
+	width, height := pdf.A4Height, pdf.A4Width
+	if template.Orientation == models.CertificatePortrait {
+		width, height = pdf.A4Width, pdf.A4Height
+	}
+	doc := pdf.New(width, height)
+	doc.SetTitle("Certificado - " + data.CourseTitle)
+
+	if template.BackgroundColor != "" {
+		doc.FillRect(0, 0, width, height, elementColor(template.BackgroundColor))
+	}
+	if template.BorderColor != "" {
+		margin := pdf.MMToPt(8)
+		doc.StrokeRect(margin, margin, width-2*margin, height-2*margin, 3, elementColor(template.BorderColor))
+	}
+
+	values := data.values()
+	for _, element := range template.Elements {
+		x, y := pdf.MMToPt(element.X), pdf.MMToPt(element.Y)
+		switch element.Type {
+		case models.CertificateElementText:
+			text := certificatePlaceholder.ReplaceAllStringFunc(element.Text, func(match string) string {
+				return values[certificatePlaceholder.FindStringSubmatch(match)[1]]
+			})
+			size := element.FontSize
+			if size == 0 {
+				size = 14
+			}
+			align := pdf.Alignment(element.Align)
+			if align == "" {
+				align = pdf.AlignLeft
+			}
+			doc.TextAligned(x, y, align, pdf.FontFor(element.Bold, element.Italic), size, elementColor(element.Color), text)
+		case models.CertificateElementSignature:
+			if len(signature) == 0 {
+				continue
+			}
+			if err := doc.JPEG(signature, x, y, pdf.MMToPt(element.Width), pdf.MMToPt(element.Height)); err != nil {
+				return nil, err
+			}
+		case models.CertificateElementLine:
+			doc.Line(x, y, x+pdf.MMToPt(element.Width), y, 0.8, elementColor(element.Color))
+		}
+	}
+	return doc.Bytes()
+}
@@ -0,0 +1,1087 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/imaging"
+	"github.com/Unikyri/yamerito-mvp/internal/jobs"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/Unikyri/yamerito-mvp/internal/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultCertificatePageSize = 50
+	maxCertificatePageSize     = 200
+	// reissueBatchSize limita cuántos certificados se regeneran por consulta.
+	reissueBatchSize = 50
+	// certificateCodeAttempts es cuántas veces se genera otro código si el primero ya existe.
+	certificateCodeAttempts = 5
+	signatureJPEGQuality    = 90
+)
+
+// certificateCodeAlphabet es el alfabeto Base32 de Crockford: sin I, L, O ni U, para que
+// un código dictado o copiado a mano no se confunda.
+const certificateCodeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// certificateActor es el actor de los certificados que emite el sistema al completar un curso.
+var certificateActor = RequestActor{Username: "sistema"}
+
+// CertificateTemplateDTO define el cuerpo de POST y PUT /api/v1/admin/certificate-templates.
+type CertificateTemplateDTO struct {
+	Name            string                      `json:"name" binding:"required"`
+	Orientation     string                      `json:"orientation"` // landscape (por defecto) o portrait
+	BackgroundColor string                      `json:"background_color"`
+	BorderColor     string                      `json:"border_color"`
+	Elements        []models.CertificateElement `json:"elements" binding:"required"`
+	IsDefault       bool                        `json:"is_default"`
+}
+
+// SetCourseTemplateDTO define el cuerpo de PUT /api/v1/admin/courses/:id/certificate-template.
+type SetCourseTemplateDTO struct {
+	TemplateID *uint `json:"template_id"` // Nil vuelve a la plantilla predeterminada
+}
+
+// RevokeCertificateDTO define el cuerpo de POST /api/v1/admin/certificates/:id/revoke.
+type RevokeCertificateDTO struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// CertificateFilter define los filtros de GET /api/v1/admin/certificates.
+type CertificateFilter struct {
+	CourseID uint
+	PathID   uint
+	UserID   uint
+	Revoked  *bool
+	Page     int
+	PageSize int
+}
+
+// CertificatePage es una página de certificados.
+type CertificatePage struct {
+	Items    []models.Certificate `json:"items"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
+
+// CertificateVerification es la respuesta pública de la verificación de un certificado.
+type CertificateVerification struct {
+	Valid         bool       `json:"valid"` // Falso si el certificado fue revocado o venció
+	Code          string     `json:"code"`
+	RecipientName string     `json:"recipient_name"`
+	CourseTitle   string     `json:"course_title"`
+	CompletedAt   time.Time  `json:"completed_at"`
+	Score         *int       `json:"score,omitempty"`
+	IssuedAt      time.Time  `json:"issued_at"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Expired       bool       `json:"expired"`
+	Revoked       bool       `json:"revoked"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ReissueCertificatesPayload es el trabajo que regenera los certificados de una plantilla.
+type ReissueCertificatesPayload struct {
+	TemplateID uint `json:"template_id"`
+}
+
+// IssueCertificatePayload es el trabajo que emite el certificado de una inscripción completada.
+type IssueCertificatePayload struct {
+	EnrollmentID uint `json:"enrollment_id"`
+}
+
+// IssuePathCertificatePayload es el trabajo que emite el certificado de una ruta completada.
+type IssuePathCertificatePayload struct {
+	PathEnrollmentID uint `json:"path_enrollment_id"`
+}
+
+// CertificateServiceInterface define las plantillas, la emisión y la verificación de
+// certificados de finalización.
+type CertificateServiceInterface interface {
+	ListTemplates() ([]models.CertificateTemplate, error)
+	GetTemplate(id uint) (*models.CertificateTemplate, error)
+	CreateTemplate(actor RequestActor, dto CertificateTemplateDTO) (*models.CertificateTemplate, error)
+	UpdateTemplate(actor RequestActor, id uint, dto CertificateTemplateDTO) (*models.CertificateTemplate, error)
+	DeleteTemplate(actor RequestActor, id uint) error
+	SetTemplateSignature(actor RequestActor, id uint, data []byte) (*models.CertificateTemplate, error)
+	PreviewTemplate(ctx context.Context, id uint) ([]byte, error)
+	ReissueTemplate(actor RequestActor, id uint) (int64, error)
+	SetCourseTemplate(actor RequestActor, courseID uint, templateID *uint) (*models.Course, error)
+
+	ListCertificates(filter CertificateFilter) (*CertificatePage, error)
+	RevokeCertificate(actor RequestActor, id uint, reason string) (*models.Certificate, error)
+	ListMyCertificates(userID uint) ([]models.Certificate, error)
+	OpenCertificate(ctx context.Context, actor RequestActor, id uint) (*models.Certificate, io.ReadCloser, *storage.ObjectInfo, error)
+	VerifyCertificate(code string) (*CertificateVerification, error)
+	GetUserCompliance(userID uint) (*UserCompliance, error)
+}
+
+// CertificateService implementa CertificateServiceInterface. VerifyURL es la base pública
+// de la verificación: cada certificado imprime <VerifyURL>/<código>.
+type CertificateService struct {
+	DB        *gorm.DB
+	Store     storage.BlobStore
+	VerifyURL string
+}
+
+// NewCertificateService crea una nueva instancia de CertificateService.
+func NewCertificateService(db *gorm.DB, store storage.BlobStore, verifyURL string) *CertificateService {
+	return &CertificateService{DB: db, Store: store, VerifyURL: verifyURL}
+}
+
+var (
+	errCertificateTemplateNotFound = errors.New("plantilla de certificado no encontrada")
+	errCertificateNotFound         = errors.New("certificado no encontrado")
+)
+
+// newCertificateCode genera un código de verificación de la forma XXXX-XXXX-XXXX (60 bits).
+func newCertificateCode() (string, error) {
+	raw := make([]byte, 12)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for i, value := range raw {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(certificateCodeAlphabet[value&31])
+	}
+	return b.String(), nil
+}
+
+// NormalizeCertificateCode lleva un código escrito a mano a su forma canónica: mayúsculas,
+// sin espacios, con O→0 e I/L→1 como en Crockford, y con los guiones en su sitio.
+func NormalizeCertificateCode(code string) string {
+	var compact strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		switch r {
+		case '-', ' ':
+			continue
+		case 'O':
+			r = '0'
+		case 'I', 'L':
+			r = '1'
+		}
+		compact.WriteRune(r)
+	}
+	s := compact.String()
+	if len(s) != 12 {
+		return s
+	}
+	return s[0:4] + "-" + s[4:8] + "-" + s[8:12]
+}
+
+func (s *CertificateService) verificationURL(code string) string {
+	return strings.TrimRight(s.VerifyURL, "/") + "/" + code
+}
+
+// --- Plantillas ---
+
+// ListTemplates devuelve las plantillas de certificado.
+func (s *CertificateService) ListTemplates() ([]models.CertificateTemplate, error) {
+	templates := make([]models.CertificateTemplate, 0)
+	if err := s.DB.Order("name").Find(&templates).Error; err != nil {
+		log.Printf("Error al listar plantillas de certificado: %v", err)
+		return nil, errors.New("no se pudieron obtener las plantillas de certificado")
+	}
+	return templates, nil
+}
+
+func findCertificateTemplate(db *gorm.DB, id uint) (*models.CertificateTemplate, error) {
+	var template models.CertificateTemplate
+	if err := db.First(&template, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errCertificateTemplateNotFound
+		}
+		log.Printf("Error al buscar la plantilla de certificado %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener la plantilla de certificado")
+	}
+	return &template, nil
+}
+
+// GetTemplate devuelve una plantilla de certificado.
+func (s *CertificateService) GetTemplate(id uint) (*models.CertificateTemplate, error) {
+	return findCertificateTemplate(s.DB, id)
+}
+
+func applyCertificateTemplateDTO(template *models.CertificateTemplate, dto CertificateTemplateDTO) {
+	template.Name = strings.TrimSpace(dto.Name)
+	template.Orientation = strings.ToLower(strings.TrimSpace(dto.Orientation))
+	if template.Orientation == "" {
+		template.Orientation = models.CertificateLandscape
+	}
+	template.BackgroundColor = strings.TrimSpace(dto.BackgroundColor)
+	template.BorderColor = strings.TrimSpace(dto.BorderColor)
+	template.Elements = dto.Elements
+	template.IsDefault = dto.IsDefault
+}
+
+func certificateTemplateSnapshot(template *models.CertificateTemplate) map[string]interface{} {
+	return map[string]interface{}{
+		"name":             template.Name,
+		"orientation":      template.Orientation,
+		"background_color": template.BackgroundColor,
+		"border_color":     template.BorderColor,
+		"elements":         len(template.Elements),
+		"is_default":       template.IsDefault,
+		"version":          template.Version,
+	}
+}
+
+// clearDefaultTemplate quita la marca de predeterminada a las demás plantillas.
+func clearDefaultTemplate(tx *gorm.DB, keepID uint) error {
+	return tx.Model(&models.CertificateTemplate{}).Where("is_default = ? AND id <> ?", true, keepID).
+		Update("is_default", false).Error
+}
+
+// CreateTemplate crea una plantilla de certificado.
+func (s *CertificateService) CreateTemplate(actor RequestActor, dto CertificateTemplateDTO) (*models.CertificateTemplate, error) {
+	template := models.CertificateTemplate{Version: 1}
+	applyCertificateTemplateDTO(&template, dto)
+	if err := validateCertificateTemplate(&template); err != nil {
+		return nil, err
+	}
+	if actor.UserID != 0 {
+		template.CreatedByID = &actor.UserID
+	}
+
+	tx := s.DB.Begin()
+	if err := tx.Create(&template).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear plantilla de certificado: %v", err)
+		return nil, errors.New("no se pudo crear la plantilla de certificado")
+	}
+	if template.IsDefault {
+		if err := clearDefaultTemplate(tx, template.ID); err != nil {
+			tx.Rollback()
+			log.Printf("Error al cambiar la plantilla predeterminada: %v", err)
+			return nil, errors.New("no se pudo crear la plantilla de certificado")
+		}
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCertTemplateCreated,
+		TargetType: models.AuditTargetCertTemplate,
+		TargetID:   template.ID,
+		After:      certificateTemplateSnapshot(&template),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la creación de la plantilla de certificado: %v", err)
+		return nil, errors.New("no se pudo crear la plantilla de certificado")
+	}
+	tx.Commit()
+	return &template, nil
+}
+
+// lockCertificateTemplate busca y bloquea una plantilla dentro de una transacción.
+func lockCertificateTemplate(tx *gorm.DB, id uint) (*models.CertificateTemplate, error) {
+	return findCertificateTemplate(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+// bumpTemplateVersion guarda una nueva versión del diseño y encola la regeneración de los
+// certificados emitidos con las anteriores. El trabajo solo existe si tx se confirma.
+func bumpTemplateVersion(tx *gorm.DB, template *models.CertificateTemplate) error {
+	template.Version++
+	if err := tx.Save(template).Error; err != nil {
+		return err
+	}
+	_, err := jobs.Enqueue(tx, JobTypeReissueCertificates, ReissueCertificatesPayload{TemplateID: template.ID}, jobs.EnqueueOptions{})
+	return err
+}
+
+// UpdateTemplate reemplaza el diseño de una plantilla. Los certificados ya emitidos con ella
+// se regeneran en segundo plano con el diseño nuevo.
+func (s *CertificateService) UpdateTemplate(actor RequestActor, id uint, dto CertificateTemplateDTO) (*models.CertificateTemplate, error) {
+	tx := s.DB.Begin()
+	template, err := lockCertificateTemplate(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	before := certificateTemplateSnapshot(template)
+	wasDefault := template.IsDefault
+	applyCertificateTemplateDTO(template, dto)
+	if err := validateCertificateTemplate(template); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := bumpTemplateVersion(tx, template); err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar la plantilla de certificado %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar la plantilla de certificado")
+	}
+	if template.IsDefault && !wasDefault {
+		if err := clearDefaultTemplate(tx, template.ID); err != nil {
+			tx.Rollback()
+			log.Printf("Error al cambiar la plantilla predeterminada: %v", err)
+			return nil, errors.New("no se pudo actualizar la plantilla de certificado")
+		}
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCertTemplateUpdated,
+		TargetType: models.AuditTargetCertTemplate,
+		TargetID:   template.ID,
+		Before:     before,
+		After:      certificateTemplateSnapshot(template),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la plantilla de certificado %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar la plantilla de certificado")
+	}
+	tx.Commit()
+	return template, nil
+}
+
+// DeleteTemplate elimina una plantilla que ningún curso use. Los certificados emitidos con
+// ella conservan su PDF.
+func (s *CertificateService) DeleteTemplate(actor RequestActor, id uint) error {
+	tx := s.DB.Begin()
+	template, err := lockCertificateTemplate(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if template.IsDefault {
+		tx.Rollback()
+		return errors.New("no se puede eliminar la plantilla predeterminada")
+	}
+	var courses int64
+	if err := tx.Model(&models.Course{}).Where("certificate_template_id = ?", id).Count(&courses).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al comprobar el uso de la plantilla de certificado %d: %v", id, err)
+		return errors.New("no se pudo eliminar la plantilla de certificado")
+	}
+	if courses == 0 {
+		if err := tx.Model(&models.LearningPath{}).Where("certificate_template_id = ?", id).Count(&courses).Error; err != nil {
+			tx.Rollback()
+			log.Printf("Error al comprobar el uso de la plantilla de certificado %d: %v", id, err)
+			return errors.New("no se pudo eliminar la plantilla de certificado")
+		}
+	}
+	if courses > 0 {
+		tx.Rollback()
+		return errors.New("la plantilla de certificado está asignada a algún curso o ruta")
+	}
+	if err := tx.Delete(template).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar la plantilla de certificado %d: %v", id, err)
+		return errors.New("no se pudo eliminar la plantilla de certificado")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCertTemplateDeleted,
+		TargetType: models.AuditTargetCertTemplate,
+		TargetID:   template.ID,
+		Before:     certificateTemplateSnapshot(template),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la eliminación de la plantilla de certificado %d: %v", id, err)
+		return errors.New("no se pudo eliminar la plantilla de certificado")
+	}
+	tx.Commit()
+	return nil
+}
+
+// deleteCertificateBlob elimina un archivo de certificado o firma. Es de mejor esfuerzo: un
+// archivo huérfano no afecta al funcionamiento, así que los errores solo se registran.
+func (s *CertificateService) deleteCertificateBlob(key string) {
+	if key == "" {
+		return
+	}
+	if err := s.Store.Delete(context.Background(), key); err != nil {
+		log.Printf("Error al eliminar el archivo '%s': %v", key, err)
+	}
+}
+
+func randomBlobToken() (string, error) {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// SetTemplateSignature reemplaza la imagen de la firma de una plantilla. La imagen se vuelve
+// a codificar como JPEG sobre fondo blanco, que es lo que admite el PDF.
+func (s *CertificateService) SetTemplateSignature(actor RequestActor, id uint, data []byte) (*models.CertificateTemplate, error) {
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := imaging.EncodeJPEG(img, signatureJPEGQuality)
+	if err != nil {
+		log.Printf("Error al codificar la firma de la plantilla %d: %v", id, err)
+		return nil, errors.New("no se pudo procesar la imagen")
+	}
+	token, err := randomBlobToken()
+	if err != nil {
+		return nil, errors.New("no se pudo procesar la imagen")
+	}
+	key := fmt.Sprintf("certificate-signatures/%d/%s.jpg", id, token)
+	if err := s.Store.Put(context.Background(), key, bytes.NewReader(encoded), int64(len(encoded)), "image/jpeg"); err != nil {
+		log.Printf("Error al guardar la firma de la plantilla %d: %v", id, err)
+		return nil, errors.New("no se pudo guardar la imagen")
+	}
+
+	tx := s.DB.Begin()
+	template, err := lockCertificateTemplate(tx, id)
+	if err != nil {
+		tx.Rollback()
+		s.deleteCertificateBlob(key)
+		return nil, err
+	}
+	previous := template.SignatureKey
+	now := time.Now()
+	template.SignatureKey = key
+	template.SignatureUpdatedAt = &now
+	if err := bumpTemplateVersion(tx, template); err != nil {
+		tx.Rollback()
+		s.deleteCertificateBlob(key)
+		log.Printf("Error al guardar la firma de la plantilla %d: %v", id, err)
+		return nil, errors.New("no se pudo guardar la imagen")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCertTemplateUpdated,
+		TargetType: models.AuditTargetCertTemplate,
+		TargetID:   template.ID,
+		Before:     map[string]interface{}{"signature": previous},
+		After:      map[string]interface{}{"signature": key},
+	}); err != nil {
+		tx.Rollback()
+		s.deleteCertificateBlob(key)
+		log.Printf("Error al auditar la firma de la plantilla %d: %v", id, err)
+		return nil, errors.New("no se pudo guardar la imagen")
+	}
+	tx.Commit()
+
+	s.deleteCertificateBlob(previous)
+	return template, nil
+}
+
+// loadSignature lee la imagen de la firma de una plantilla, si tiene.
+func (s *CertificateService) loadSignature(ctx context.Context, template *models.CertificateTemplate) ([]byte, error) {
+	if template.SignatureKey == "" {
+		return nil, nil
+	}
+	rc, _, err := s.Store.Get(ctx, template.SignatureKey)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer la firma '%s': %w", template.SignatureKey, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// PreviewTemplate genera un certificado de ejemplo con la plantilla.
+func (s *CertificateService) PreviewTemplate(ctx context.Context, id uint) ([]byte, error) {
+	template, err := findCertificateTemplate(s.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := s.loadSignature(ctx, template)
+	if err != nil {
+		log.Printf("Error al generar la vista previa de la plantilla %d: %v", id, err)
+		return nil, errors.New("no se pudo generar el certificado")
+	}
+	doc, err := renderCertificate(template, signature, sampleCertificateData(s.VerifyURL))
+	if err != nil {
+		log.Printf("Error al generar la vista previa de la plantilla %d: %v", id, err)
+		return nil, errors.New("no se pudo generar el certificado")
+	}
+	return doc, nil
+}
+
+// ReissueTemplate encola la regeneración de los certificados desactualizados de una plantilla
+// (por ejemplo, si un trabajo anterior falló) y devuelve cuántos hay.
+func (s *CertificateService) ReissueTemplate(actor RequestActor, id uint) (int64, error) {
+	template, err := findCertificateTemplate(s.DB, id)
+	if err != nil {
+		return 0, err
+	}
+	var outdated int64
+	if err := s.DB.Model(&models.Certificate{}).
+		Where("template_id = ? AND template_version < ? AND revoked_at IS NULL", template.ID, template.Version).
+		Count(&outdated).Error; err != nil {
+		log.Printf("Error al contar los certificados de la plantilla %d: %v", id, err)
+		return 0, errors.New("no se pudo programar la reemisión")
+	}
+	if outdated == 0 {
+		return 0, nil
+	}
+	if _, err := jobs.Enqueue(s.DB, JobTypeReissueCertificates, ReissueCertificatesPayload{TemplateID: template.ID}, jobs.EnqueueOptions{}); err != nil {
+		log.Printf("Error al encolar la reemisión de la plantilla %d: %v", id, err)
+		return 0, errors.New("no se pudo programar la reemisión")
+	}
+	return outdated, nil
+}
+
+// SetCourseTemplate asigna (o quita, con nil) la plantilla de certificado propia de un curso.
+// Solo afecta a los certificados que se emitan después.
+func (s *CertificateService) SetCourseTemplate(actor RequestActor, courseID uint, templateID *uint) (*models.Course, error) {
+	tx := s.DB.Begin()
+	var course models.Course
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&course, courseID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errCourseNotFound
+		}
+		log.Printf("Error al buscar curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudo asignar la plantilla de certificado")
+	}
+	if templateID != nil {
+		if _, err := findCertificateTemplate(tx, *templateID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	before := course.CertificateTemplateID
+	if err := tx.Model(&course).Update("certificate_template_id", templateID).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al asignar la plantilla de certificado al curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudo asignar la plantilla de certificado")
+	}
+	course.CertificateTemplateID = templateID
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCourseUpdated,
+		TargetType: models.AuditTargetCourse,
+		TargetID:   course.ID,
+		Before:     map[string]interface{}{"certificate_template_id": before},
+		After:      map[string]interface{}{"certificate_template_id": templateID},
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la plantilla de certificado del curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudo asignar la plantilla de certificado")
+	}
+	tx.Commit()
+	return &course, nil
+}
+
+// --- Certificados ---
+
+// ListCertificates lista los certificados emitidos.
+func (s *CertificateService) ListCertificates(filter CertificateFilter) (*CertificatePage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultCertificatePageSize
+	}
+	if filter.PageSize > maxCertificatePageSize {
+		filter.PageSize = maxCertificatePageSize
+	}
+	query := s.DB.Model(&models.Certificate{})
+	if filter.CourseID != 0 {
+		query = query.Where("course_id = ?", filter.CourseID)
+	}
+	if filter.PathID != 0 {
+		query = query.Where("path_id = ?", filter.PathID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Revoked != nil {
+		if *filter.Revoked {
+			query = query.Where("revoked_at IS NOT NULL")
+		} else {
+			query = query.Where("revoked_at IS NULL")
+		}
+	}
+	page := &CertificatePage{Items: make([]models.Certificate, 0), Page: filter.Page, PageSize: filter.PageSize}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		log.Printf("Error al contar certificados: %v", err)
+		return nil, errors.New("no se pudieron obtener los certificados")
+	}
+	if err := query.Order("id DESC").Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&page.Items).Error; err != nil {
+		log.Printf("Error al listar certificados: %v", err)
+		return nil, errors.New("no se pudieron obtener los certificados")
+	}
+	return page, nil
+}
+
+// RevokeCertificate revoca un certificado: la verificación pública lo mostrará como no válido.
+func (s *CertificateService) RevokeCertificate(actor RequestActor, id uint, reason string) (*models.Certificate, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("el motivo de la revocación no puede estar vacío")
+	}
+	tx := s.DB.Begin()
+	var certificate models.Certificate
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&certificate, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errCertificateNotFound
+		}
+		log.Printf("Error al buscar el certificado %d: %v", id, err)
+		return nil, errors.New("no se pudo revocar el certificado")
+	}
+	if certificate.RevokedAt != nil {
+		tx.Rollback()
+		return nil, errors.New("el certificado ya está revocado")
+	}
+	now := time.Now()
+	if err := tx.Model(&certificate).Updates(map[string]interface{}{
+		"revoked_at":     now,
+		"revoked_reason": reason,
+	}).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al revocar el certificado %d: %v", id, err)
+		return nil, errors.New("no se pudo revocar el certificado")
+	}
+	certificate.RevokedAt = &now
+	certificate.RevokedReason = reason
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCertificateRevoked,
+		TargetType: models.AuditTargetCertificate,
+		TargetID:   certificate.ID,
+		After:      map[string]interface{}{"code": certificate.Code, "reason": reason},
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la revocación del certificado %d: %v", id, err)
+		return nil, errors.New("no se pudo revocar el certificado")
+	}
+	tx.Commit()
+	return &certificate, nil
+}
+
+// ListMyCertificates devuelve los certificados de un usuario, incluidos los revocados.
+func (s *CertificateService) ListMyCertificates(userID uint) ([]models.Certificate, error) {
+	certificates := make([]models.Certificate, 0)
+	if err := s.DB.Where("user_id = ?", userID).Order("issued_at DESC").Find(&certificates).Error; err != nil {
+		log.Printf("Error al listar los certificados del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudieron obtener los certificados")
+	}
+	return certificates, nil
+}
+
+// OpenCertificate abre el PDF de un certificado. Solo pueden descargarlo su titular y los
+// administradores; al resto se le responde como si no existiera.
+func (s *CertificateService) OpenCertificate(ctx context.Context, actor RequestActor, id uint) (*models.Certificate, io.ReadCloser, *storage.ObjectInfo, error) {
+	var certificate models.Certificate
+	if err := s.DB.First(&certificate, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil, errCertificateNotFound
+		}
+		log.Printf("Error al buscar el certificado %d: %v", id, err)
+		return nil, nil, nil, errors.New("no se pudo obtener el certificado")
+	}
+	if actor.Role != models.RoleAdmin && certificate.UserID != actor.UserID {
+		return nil, nil, nil, errCertificateNotFound
+	}
+	rc, info, err := s.Store.Get(ctx, certificate.BlobKey)
+	if err != nil {
+		log.Printf("Error al leer el certificado '%s': %v", certificate.BlobKey, err)
+		return nil, nil, nil, errors.New("no se pudo obtener el certificado")
+	}
+	return &certificate, rc, info, nil
+}
+
+// VerifyCertificate confirma la autenticidad de un certificado a partir de su código.
+func (s *CertificateService) VerifyCertificate(code string) (*CertificateVerification, error) {
+	code = NormalizeCertificateCode(code)
+	var certificate models.Certificate
+	if err := s.DB.Where("code = ?", code).First(&certificate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errCertificateNotFound
+		}
+		log.Printf("Error al verificar el certificado '%s': %v", code, err)
+		return nil, errors.New("no se pudo verificar el certificado")
+	}
+	expired := certificate.ExpiresAt != nil && !certificate.ExpiresAt.After(time.Now())
+	return &CertificateVerification{
+		Valid:         certificate.RevokedAt == nil && !expired,
+		Code:          certificate.Code,
+		RecipientName: certificate.RecipientName,
+		CourseTitle:   certificate.CourseTitle,
+		CompletedAt:   certificate.CompletedAt,
+		Score:         certificate.Score,
+		IssuedAt:      certificate.IssuedAt,
+		ExpiresAt:     certificate.ExpiresAt,
+		Expired:       expired,
+		Revoked:       certificate.RevokedAt != nil,
+		RevokedAt:     certificate.RevokedAt,
+	}, nil
+}
+
+// --- Emisión ---
+
+// issueTemplate devuelve la plantilla con la que se emiten los certificados de un curso o
+// una ruta: la propia (templateID), la predeterminada o, si no hay ninguna, el diseño incorporado.
+func issueTemplate(db *gorm.DB, templateID *uint) (*models.CertificateTemplate, error) {
+	var template models.CertificateTemplate
+	if templateID != nil {
+		err := db.First(&template, *templateID).Error
+		if err == nil {
+			return &template, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	err := db.Where("is_default = ?", true).First(&template).Error
+	if err == nil {
+		return &template, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return defaultCertificateTemplate(), nil
+}
+
+// recipientName es el nombre del empleado tal como se imprime; si no tiene detalles de
+// empleado, se usa su nombre de usuario.
+func recipientName(db *gorm.DB, userID uint) (string, error) {
+	var user models.User
+	if err := db.Preload("EmployeeDetail").First(&user, userID).Error; err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(user.EmployeeDetail.Name + " " + user.EmployeeDetail.LastName)
+	if name == "" {
+		name = user.Username
+	}
+	return name, nil
+}
+
+// courseScore es el promedio, entre los cuestionarios del curso que el usuario intentó en la
+// vuelta de certificación indicada, de su mejor porcentaje calificado. Nil si no hay ninguno.
+func courseScore(db *gorm.DB, userID, courseID uint, cycle int) (*int, error) {
+	var best []int
+	if err := db.Model(&models.QuizAttempt{}).
+		Joins("JOIN quizzes ON quizzes.id = quiz_attempts.quiz_id AND quizzes.deleted_at IS NULL").
+		Where("quizzes.course_id = ? AND quiz_attempts.user_id = ? AND quiz_attempts.cycle = ? AND quiz_attempts.status IN ?",
+			courseID, userID, cycle, []string{models.AttemptSubmitted, models.AttemptExpired}).
+		Group("quiz_attempts.quiz_id").Pluck("MAX(quiz_attempts.percent)", &best).Error; err != nil {
+		return nil, err
+	}
+	if len(best) == 0 {
+		return nil, nil
+	}
+	total := 0
+	for _, percent := range best {
+		total += percent
+	}
+	score := int(math.Round(float64(total) / float64(len(best))))
+	return &score, nil
+}
+
+// renderAndStore genera el PDF de un certificado con la plantilla y lo guarda con una clave
+// nueva, que devuelve. El llamador debe borrar el archivo si después no lo usa.
+func (s *CertificateService) renderAndStore(ctx context.Context, certificate *models.Certificate, template *models.CertificateTemplate) (string, error) {
+	signature, err := s.loadSignature(ctx, template)
+	if err != nil {
+		return "", err
+	}
+	doc, err := renderCertificate(template, signature, certificateData{
+		RecipientName: certificate.RecipientName,
+		CourseTitle:   certificate.CourseTitle,
+		CompletedAt:   certificate.CompletedAt,
+		ExpiresAt:     certificate.ExpiresAt,
+		Score:         certificate.Score,
+		Code:          certificate.Code,
+		VerifyURL:     s.verificationURL(certificate.Code),
+	})
+	if err != nil {
+		return "", err
+	}
+	token, err := randomBlobToken()
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("certificates/%d/%s.pdf", certificate.UserID, token)
+	if err := s.Store.Put(ctx, key, bytes.NewReader(doc), int64(len(doc)), "application/pdf"); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// IssueCertificate emite el certificado de una inscripción completada. Es idempotente: si la
+// inscripción ya tiene certificado en su vuelta actual, o ya no está completada, no hace nada.
+// Tampoco lo emite si algún cuestionario del curso no tiene un intento aprobado en la vuelta.
+// Si el curso tiene vigencia, el certificado vence ValidityDays después de completarlo.
+func (s *CertificateService) IssueCertificate(ctx context.Context, enrollmentID uint) error {
+	db := s.DB.WithContext(ctx)
+	var enrollment models.Enrollment
+	if err := db.Preload("Course").First(&enrollment, enrollmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("no se pudo buscar la inscripción %d: %w", enrollmentID, err)
+	}
+	if enrollment.Status != models.EnrollmentCompleted || enrollment.Course == nil {
+		return nil
+	}
+	pendingQuizzes, err := countPendingQuizzes(db, &enrollment)
+	if err != nil {
+		return fmt.Errorf("no se pudieron comprobar los cuestionarios de la inscripción %d: %w", enrollmentID, err)
+	}
+	if pendingQuizzes > 0 {
+		log.Printf("Certificados: la inscripción %d está completada pero tiene %d cuestionario(s) sin aprobar; no se emite.", enrollmentID, pendingQuizzes)
+		return nil
+	}
+	var existing int64
+	if err := db.Model(&models.Certificate{}).Where("enrollment_id = ? AND cycle = ?", enrollmentID, enrollment.Cycle).Count(&existing).Error; err != nil {
+		return fmt.Errorf("no se pudo comprobar el certificado de la inscripción %d: %w", enrollmentID, err)
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	name, err := recipientName(db, enrollment.UserID)
+	if err != nil {
+		return fmt.Errorf("no se pudo obtener el nombre del usuario %d: %w", enrollment.UserID, err)
+	}
+	score, err := courseScore(db, enrollment.UserID, enrollment.CourseID, enrollment.Cycle)
+	if err != nil {
+		return fmt.Errorf("no se pudo calcular la calificación del usuario %d: %w", enrollment.UserID, err)
+	}
+	template, err := issueTemplate(db, enrollment.Course.CertificateTemplateID)
+	if err != nil {
+		return fmt.Errorf("no se pudo obtener la plantilla del curso %d: %w", enrollment.CourseID, err)
+	}
+	completedAt := time.Now()
+	if enrollment.CompletedAt != nil {
+		completedAt = *enrollment.CompletedAt
+	}
+	certificate := models.Certificate{
+		UserID:          enrollment.UserID,
+		CourseID:        &enrollment.CourseID,
+		EnrollmentID:    &enrollment.ID,
+		Cycle:           enrollment.Cycle,
+		RecipientName:   name,
+		CourseTitle:     enrollment.Course.Title,
+		Score:           score,
+		CompletedAt:     completedAt,
+		TemplateVersion: template.Version,
+	}
+	if template.ID != 0 {
+		certificate.TemplateID = &template.ID
+	}
+	if enrollment.Course.ValidityDays > 0 {
+		expiresAt := completedAt.AddDate(0, 0, enrollment.Course.ValidityDays)
+		certificate.ExpiresAt = &expiresAt
+	}
+
+	return s.saveNewCertificate(ctx, &certificate, template, fmt.Sprintf("la inscripción %d", enrollmentID), func() (bool, error) {
+		err := db.Model(&models.Certificate{}).Where("enrollment_id = ? AND cycle = ?", enrollmentID, enrollment.Cycle).Count(&existing).Error
+		return existing > 0, err
+	})
+}
+
+// IssuePathCertificate emite el certificado de una ruta completada, con el título de la ruta y
+// el promedio de las calificaciones de sus cursos. Es idempotente como IssueCertificate. Los
+// certificados de ruta no vencen: la vigencia se controla en cada curso.
+func (s *CertificateService) IssuePathCertificate(ctx context.Context, pathEnrollmentID uint) error {
+	db := s.DB.WithContext(ctx)
+	var pathEnrollment models.LearningPathEnrollment
+	if err := db.Preload("Path", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		First(&pathEnrollment, pathEnrollmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("no se pudo buscar la asignación de ruta %d: %w", pathEnrollmentID, err)
+	}
+	if pathEnrollment.Status != models.EnrollmentCompleted || pathEnrollment.Path == nil {
+		return nil
+	}
+	issued := func() (bool, error) {
+		var existing int64
+		err := db.Model(&models.Certificate{}).Where("path_enrollment_id = ?", pathEnrollmentID).Count(&existing).Error
+		return existing > 0, err
+	}
+	done, err := issued()
+	if err != nil {
+		return fmt.Errorf("no se pudo comprobar el certificado de la asignación de ruta %d: %w", pathEnrollmentID, err)
+	}
+	if done {
+		return nil
+	}
+
+	path, err := findLearningPath(db.Unscoped(), pathEnrollment.PathID)
+	if err != nil {
+		return fmt.Errorf("no se pudo obtener la ruta %d: %w", pathEnrollment.PathID, err)
+	}
+	name, err := recipientName(db, pathEnrollment.UserID)
+	if err != nil {
+		return fmt.Errorf("no se pudo obtener el nombre del usuario %d: %w", pathEnrollment.UserID, err)
+	}
+	results, err := loadPathCourseResults(db, pathEnrollment.UserID, path)
+	if err != nil {
+		return fmt.Errorf("no se pudo calcular la calificación del usuario %d: %w", pathEnrollment.UserID, err)
+	}
+	var score *int
+	total, scored := 0, 0
+	for _, result := range results {
+		if result.Passed && result.Score != nil {
+			total += *result.Score
+			scored++
+		}
+	}
+	if scored > 0 {
+		average := int(math.Round(float64(total) / float64(scored)))
+		score = &average
+	}
+	template, err := issueTemplate(db, path.CertificateTemplateID)
+	if err != nil {
+		return fmt.Errorf("no se pudo obtener la plantilla de la ruta %d: %w", path.ID, err)
+	}
+	completedAt := time.Now()
+	if pathEnrollment.CompletedAt != nil {
+		completedAt = *pathEnrollment.CompletedAt
+	}
+	certificate := models.Certificate{
+		UserID:           pathEnrollment.UserID,
+		PathID:           &pathEnrollment.PathID,
+		PathEnrollmentID: &pathEnrollment.ID,
+		RecipientName:    name,
+		CourseTitle:      path.Title,
+		Score:            score,
+		CompletedAt:      completedAt,
+		TemplateVersion:  template.Version,
+	}
+	if template.ID != 0 {
+		certificate.TemplateID = &template.ID
+	}
+	return s.saveNewCertificate(ctx, &certificate, template, fmt.Sprintf("la asignación de ruta %d", pathEnrollmentID), issued)
+}
+
+// saveNewCertificate imprime y guarda un certificado nuevo. El código va impreso en el PDF,
+// así que se genera antes de guardar; si coincide con uno existente se genera otro y se
+// vuelve a imprimir. issued indica, tras un duplicado, si otro proceso ya emitió el mismo
+// certificado, en cuyo caso no se guarda nada. subject describe el origen en los mensajes.
+func (s *CertificateService) saveNewCertificate(ctx context.Context, certificate *models.Certificate, template *models.CertificateTemplate,
+	subject string, issued func() (bool, error)) error {
+	db := s.DB.WithContext(ctx)
+	for attempt := 0; attempt < certificateCodeAttempts; attempt++ {
+		code, err := newCertificateCode()
+		if err != nil {
+			return err
+		}
+		certificate.Code = code
+		key, err := s.renderAndStore(ctx, certificate, template)
+		if err != nil {
+			return fmt.Errorf("no se pudo generar el certificado de %s: %w", subject, err)
+		}
+		certificate.ID = 0
+		certificate.BlobKey = key
+		certificate.IssuedAt = time.Now()
+
+		tx := db.Begin()
+		if err := tx.Create(certificate).Error; err != nil {
+			tx.Rollback()
+			s.deleteCertificateBlob(key)
+			if isDuplicateKeyError(err) {
+				// Puede ser el código o que otro proceso emitió el certificado a la vez.
+				if done, err := issued(); err == nil && done {
+					return nil
+				}
+				continue
+			}
+			return fmt.Errorf("no se pudo guardar el certificado de %s: %w", subject, err)
+		}
+		if err := recordDomainEvent(tx, certificateActor, models.DomainEventCertificateIssued, models.AggregateCertificate, certificate.ID,
+			map[string]interface{}{"certificate": certificate}); err != nil {
+			tx.Rollback()
+			s.deleteCertificateBlob(key)
+			return fmt.Errorf("no se pudo registrar la emisión del certificado: %w", err)
+		}
+		if err := tx.Commit().Error; err != nil {
+			s.deleteCertificateBlob(key)
+			return err
+		}
+		log.Printf("Certificado %s emitido para %s.", certificate.Code, subject)
+		return nil
+	}
+	return fmt.Errorf("no se pudo generar un código de verificación único para %s", subject)
+}
+
+// ReissueOutdated regenera con la versión vigente de la plantilla los certificados no
+// revocados que se emitieron con una anterior. Los datos impresos no cambian, solo el diseño.
+func (s *CertificateService) ReissueOutdated(ctx context.Context, templateID uint) error {
+	db := s.DB.WithContext(ctx)
+	template, err := findCertificateTemplate(db, templateID)
+	if err != nil {
+		if errors.Is(err, errCertificateTemplateNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	reissued, failed := 0, 0
+	var lastID uint
+	for ctx.Err() == nil {
+		var certificates []models.Certificate
+		if err := db.Where("template_id = ? AND template_version < ? AND revoked_at IS NULL AND id > ?", template.ID, template.Version, lastID).
+			Order("id").Limit(reissueBatchSize).Find(&certificates).Error; err != nil {
+			return fmt.Errorf("no se pudieron buscar los certificados a reemitir: %w", err)
+		}
+		for i := range certificates {
+			certificate := &certificates[i]
+			lastID = certificate.ID
+			key, err := s.renderAndStore(ctx, certificate, template)
+			if err != nil {
+				log.Printf("Error al reemitir el certificado %d: %v", certificate.ID, err)
+				failed++
+				continue
+			}
+			// Solo se reemplaza si nadie lo cambió mientras tanto (revocación u otra reemisión).
+			result := db.Model(&models.Certificate{}).
+				Where("id = ? AND blob_key = ? AND revoked_at IS NULL", certificate.ID, certificate.BlobKey).
+				Updates(map[string]interface{}{
+					"blob_key":         key,
+					"template_version": template.Version,
+					"reissued_at":      time.Now(),
+				})
+			if result.Error != nil || result.RowsAffected == 0 {
+				if result.Error != nil {
+					log.Printf("Error al guardar el certificado reemitido %d: %v", certificate.ID, result.Error)
+					failed++
+				}
+				s.deleteCertificateBlob(key)
+				continue
+			}
+			s.deleteCertificateBlob(certificate.BlobKey)
+			reissued++
+		}
+		if len(certificates) < reissueBatchSize {
+			break
+		}
+	}
+	if reissued > 0 {
+		log.Printf("Plantilla %d: %d certificado(s) reemitidos.", template.ID, reissued)
+	}
+	if failed > 0 {
+		return fmt.Errorf("no se pudieron reemitir %d certificado(s) de la plantilla %d", failed, template.ID)
+	}
+	return ctx.Err()
+}
+
+// CertificateIssueSubscriber encola la emisión del certificado cuando se completa una
+// inscripción o una ruta. La emisión es idempotente, así que reprocesar el evento no duplica nada.
+type CertificateIssueSubscriber struct{}
+
+// Name implementa events.Subscriber.
+func (CertificateIssueSubscriber) Name() string { return "certificates" }
+
+// Handles implementa events.Subscriber.
+func (CertificateIssueSubscriber) Handles(eventType string) bool {
+	return eventType == models.DomainEventEnrollmentCompleted || eventType == models.DomainEventLearningPathCompleted
+}
+
+// Handle implementa events.Subscriber.
+func (CertificateIssueSubscriber) Handle(tx *gorm.DB, event *models.DomainEvent) error {
+	if event.Type == models.DomainEventLearningPathCompleted {
+		_, err := jobs.Enqueue(tx, JobTypeIssuePathCertificate, IssuePathCertificatePayload{PathEnrollmentID: event.AggregateID}, jobs.EnqueueOptions{})
+		return err
+	}
+	_, err := jobs.Enqueue(tx, JobTypeIssueCertificate, IssueCertificatePayload{EnrollmentID: event.AggregateID}, jobs.EnqueueOptions{})
+	return err
+}
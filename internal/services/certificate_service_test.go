@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB abre una conexión GORM contra sqlmock. Una consulta no esperada hace fallar la
+// operación, así que ExpectationsWereMet también comprueba que no se escribió nada más.
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}),
+		&gorm.Config{SkipDefaultTransaction: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+// Una inscripción completada con un cuestionario sin aprobar en su vuelta no recibe certificado.
+func TestIssueCertificateSkipsFailedQuiz(t *testing.T) {
+	db, mock := newMockDB(t)
+	completedAt := time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT \\* FROM `enrollments` WHERE `enrollments`.`id` = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "course_id", "status", "cycle", "completed_at"}).
+			AddRow(5, 7, 3, models.EnrollmentCompleted, 2, completedAt))
+	mock.ExpectQuery("SELECT \\* FROM `courses` WHERE `courses`.`id` = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(3, "Seguridad industrial"))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `quizzes` WHERE .*NOT EXISTS .*quiz_attempts.passed = \\?").
+		WithArgs(3, 7, 2, true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	service := &CertificateService{DB: db}
+	if err := service.IssueCertificate(context.Background(), 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
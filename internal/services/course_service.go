@@ -0,0 +1,1010 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultCoursePageSize = 20
+	maxCoursePageSize     = 100
+)
+
+// CourseFilter define los filtros de los listados de cursos.
+type CourseFilter struct {
+	Status   string // Solo en la gestión; el catálogo de empleados muestra solo publicados
+	Category string
+	Query    string // Búsqueda por título
+	Page     int
+	PageSize int
+}
+
+// CoursePage es una página de cursos (sin módulos).
+type CoursePage struct {
+	Items    []models.Course `json:"items"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
+
+// CreateCourseDTO define el cuerpo de POST /api/v1/manage/courses.
+type CreateCourseDTO struct {
+	Title       string `json:"title" binding:"required,max=200"`
+	Summary     string `json:"summary" binding:"max=500"`
+	Description string `json:"description"`
+	Category    string `json:"category" binding:"max=100"`
+	// InstructorID solo lo pueden indicar los administradores; un instructor siempre crea
+	// cursos a su nombre.
+	InstructorID *uint `json:"instructor_id"`
+	// ValidityDays es la vigencia en días de los certificados (0 = no vencen) y
+	// RecertifyLeadDays la anticipación de la reinscripción (30 por defecto).
+	ValidityDays      int  `json:"validity_days" binding:"min=0"`
+	RecertifyLeadDays *int `json:"recertify_lead_days" binding:"omitempty,min=0"`
+}
+
+// UpdateCourseDTO define el cuerpo de PUT /api/v1/manage/courses/:id.
+type UpdateCourseDTO struct {
+	Title        *string `json:"title" binding:"omitempty,max=200"`
+	Summary      *string `json:"summary" binding:"omitempty,max=500"`
+	Description  *string `json:"description"`
+	Category     *string `json:"category" binding:"omitempty,max=100"`
+	InstructorID *uint   `json:"instructor_id"` // Solo administradores
+	// Los cambios de vigencia solo afectan a los certificados que se emitan después.
+	ValidityDays      *int `json:"validity_days" binding:"omitempty,min=0"`
+	RecertifyLeadDays *int `json:"recertify_lead_days" binding:"omitempty,min=0"`
+}
+
+// ChangeCourseStatusDTO define el cuerpo de POST /api/v1/manage/courses/:id/status.
+type ChangeCourseStatusDTO struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// ModuleDTO define el cuerpo de la creación de un módulo.
+type ModuleDTO struct {
+	Title       string `json:"title" binding:"required,max=200"`
+	Description string `json:"description"`
+}
+
+// UpdateModuleDTO define el cuerpo de la edición de un módulo.
+type UpdateModuleDTO struct {
+	Title       *string `json:"title" binding:"omitempty,max=200"`
+	Description *string `json:"description"`
+}
+
+// LessonDTO define el cuerpo de la creación de una lección.
+type LessonDTO struct {
+	Title           string `json:"title" binding:"required,max=200"`
+	Type            string `json:"type" binding:"required"`
+	Content         string `json:"content"`
+	ContentURL      string `json:"content_url" binding:"omitempty,url,max=500"`
+	DurationMinutes int    `json:"duration_minutes" binding:"min=0"`
+}
+
+// UpdateLessonDTO define el cuerpo de la edición de una lección.
+type UpdateLessonDTO struct {
+	Title           *string `json:"title" binding:"omitempty,max=200"`
+	Type            *string `json:"type"`
+	Content         *string `json:"content"`
+	ContentURL      *string `json:"content_url" binding:"omitempty,max=500"`
+	DurationMinutes *int    `json:"duration_minutes" binding:"omitempty,min=0"`
+}
+
+// ReorderDTO define el nuevo orden de los módulos de un curso o de las lecciones de un módulo.
+type ReorderDTO struct {
+	IDs []uint `json:"ids" binding:"required,min=1"`
+}
+
+// CourseServiceInterface define la gestión del catálogo de cursos (administradores e
+// instructores) y su consulta por parte de los empleados.
+type CourseServiceInterface interface {
+	ListManagedCourses(actor RequestActor, filter CourseFilter) (*CoursePage, error)
+	GetManagedCourse(actor RequestActor, id uint) (*models.Course, error)
+	CreateCourse(actor RequestActor, dto CreateCourseDTO) (*models.Course, error)
+	UpdateCourse(actor RequestActor, id uint, dto UpdateCourseDTO) (*models.Course, error)
+	DeleteCourse(actor RequestActor, id uint) error
+	ChangeCourseStatus(actor RequestActor, id uint, dto ChangeCourseStatusDTO) (*models.Course, error)
+
+	AddModule(actor RequestActor, courseID uint, dto ModuleDTO) (*models.Module, error)
+	UpdateModule(actor RequestActor, courseID, moduleID uint, dto UpdateModuleDTO) (*models.Module, error)
+	DeleteModule(actor RequestActor, courseID, moduleID uint) error
+	ReorderModules(actor RequestActor, courseID uint, dto ReorderDTO) (*models.Course, error)
+
+	AddLesson(actor RequestActor, courseID, moduleID uint, dto LessonDTO) (*models.Lesson, error)
+	UpdateLesson(actor RequestActor, courseID, moduleID, lessonID uint, dto UpdateLessonDTO) (*models.Lesson, error)
+	DeleteLesson(actor RequestActor, courseID, moduleID, lessonID uint) error
+	ReorderLessons(actor RequestActor, courseID, moduleID uint, dto ReorderDTO) (*models.Module, error)
+
+	ListPublishedCourses(filter CourseFilter) (*CoursePage, error)
+	GetPublishedCourse(id uint) (*models.Course, error)
+}
+
+// CourseService implementa CourseServiceInterface.
+type CourseService struct {
+	DB *gorm.DB
+}
+
+// NewCourseService crea una nueva instancia de CourseService.
+func NewCourseService(db *gorm.DB) *CourseService {
+	return &CourseService{DB: db}
+}
+
+var (
+	errCourseNotFound    = errors.New("curso no encontrado")
+	errModuleNotFound    = errors.New("módulo no encontrado")
+	errLessonNotFound    = errors.New("lección no encontrada")
+	errCourseForbidden   = errors.New("no tienes permiso para modificar este curso")
+	errCourseArchived    = errors.New("no se puede editar un curso archivado")
+	errInvalidInstructor = errors.New("el instructor indicado no existe o no tiene rol de instructor")
+)
+
+func courseAuditSnapshot(course *models.Course) map[string]interface{} {
+	return map[string]interface{}{
+		"title":         course.Title,
+		"category":      course.Category,
+		"status":        course.Status,
+		"instructor_id": course.InstructorID,
+		"validity_days": course.ValidityDays,
+		"lead_days":     course.RecertifyLeadDays,
+	}
+}
+
+// validateCourseValidity comprueba que la reinscripción ocurra dentro de la vigencia.
+func validateCourseValidity(course *models.Course) error {
+	if course.ValidityDays > 0 && course.RecertifyLeadDays >= course.ValidityDays {
+		return errors.New("la anticipación de la recertificación debe ser menor que la vigencia")
+	}
+	return nil
+}
+
+// preloadCourseContent carga los módulos y lecciones de un curso en su orden.
+func preloadCourseContent(db *gorm.DB) *gorm.DB {
+	return db.Preload("Modules", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Preload("Modules.Lessons", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	})
+}
+
+// applyCourseFilter aplica los filtros comunes y la paginación de los listados de cursos.
+func applyCourseFilter(query *gorm.DB, filter CourseFilter) (*CoursePage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultCoursePageSize
+	}
+	if filter.PageSize > maxCoursePageSize {
+		filter.PageSize = maxCoursePageSize
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		query = query.Where("title LIKE ?", "%"+escapeLike(q)+"%")
+	}
+
+	page := &CoursePage{Items: make([]models.Course, 0), Page: filter.Page, PageSize: filter.PageSize}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		log.Printf("Error al contar cursos: %v", err)
+		return nil, errors.New("no se pudieron obtener los cursos")
+	}
+	if err := query.Order("title, id").Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&page.Items).Error; err != nil {
+		log.Printf("Error al listar cursos: %v", err)
+		return nil, errors.New("no se pudieron obtener los cursos")
+	}
+	return page, nil
+}
+
+// canManageCourse indica si el actor puede modificar el curso: los administradores pueden
+// gestionar cualquiera; los instructores, solo los que tienen asignados.
+func canManageCourse(actor RequestActor, course *models.Course) bool {
+	if actor.Role == models.RoleAdmin {
+		return true
+	}
+	return actor.Role == models.RoleInstructor && course.InstructorID != nil && *course.InstructorID == actor.UserID
+}
+
+// findManagedCourse busca un curso y comprueba que el actor pueda gestionarlo. Dentro de
+// una transacción de escritura bloquea la fila para serializar los cambios de estructura
+// (posiciones de módulos y lecciones).
+func findManagedCourse(db *gorm.DB, actor RequestActor, id uint, lock bool) (*models.Course, error) {
+	var course models.Course
+	query := db
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := query.First(&course, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errCourseNotFound
+		}
+		log.Printf("Error al buscar curso %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener el curso")
+	}
+	if !canManageCourse(actor, &course) {
+		return nil, errCourseForbidden
+	}
+	return &course, nil
+}
+
+// findEditableCourse es findManagedCourse con bloqueo y rechazando los cursos archivados.
+func findEditableCourse(tx *gorm.DB, actor RequestActor, id uint) (*models.Course, error) {
+	course, err := findManagedCourse(tx, actor, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status == models.CourseArchived {
+		return nil, errCourseArchived
+	}
+	return course, nil
+}
+
+func findCourseModule(db *gorm.DB, courseID, moduleID uint) (*models.Module, error) {
+	var module models.Module
+	if err := db.Where("id = ? AND course_id = ?", moduleID, courseID).First(&module).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errModuleNotFound
+		}
+		log.Printf("Error al buscar módulo %d: %v", moduleID, err)
+		return nil, errors.New("no se pudo obtener el módulo")
+	}
+	return &module, nil
+}
+
+func findModuleLesson(db *gorm.DB, moduleID, lessonID uint) (*models.Lesson, error) {
+	var lesson models.Lesson
+	if err := db.Where("id = ? AND module_id = ?", lessonID, moduleID).First(&lesson).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errLessonNotFound
+		}
+		log.Printf("Error al buscar lección %d: %v", lessonID, err)
+		return nil, errors.New("no se pudo obtener la lección")
+	}
+	return &lesson, nil
+}
+
+// validateInstructor comprueba que instructorID sea un usuario con rol de instructor.
+func validateInstructor(db *gorm.DB, instructorID uint) error {
+	var count int64
+	if err := db.Model(&models.User{}).Where("id = ? AND role = ?", instructorID, models.RoleInstructor).
+		Count(&count).Error; err != nil {
+		log.Printf("Error al comprobar el instructor %d: %v", instructorID, err)
+		return errors.New("no se pudo comprobar el instructor")
+	}
+	if count == 0 {
+		return errInvalidInstructor
+	}
+	return nil
+}
+
+// validateLesson comprueba que la lección tenga el contenido que su tipo requiere. El
+// paquete de las lecciones SCORM se sube aparte, después de crearlas.
+func validateLesson(lesson *models.Lesson) error {
+	if lesson.Type == models.LessonScorm {
+		return nil
+	}
+	if lesson.Type == models.LessonText {
+		if strings.TrimSpace(lesson.Content) == "" {
+			return errors.New("las lecciones de texto requieren contenido")
+		}
+		return nil
+	}
+	if strings.TrimSpace(lesson.ContentURL) == "" {
+		return errors.New("las lecciones de video, documento o enlace requieren content_url")
+	}
+	return nil
+}
+
+// touchCourse audita un cambio en la estructura de un curso (módulos o lecciones) como una
+// modificación del curso.
+func touchCourse(tx *gorm.DB, actor RequestActor, course *models.Course, change map[string]interface{}) error {
+	if err := tx.Model(course).Update("updated_at", time.Now()).Error; err != nil {
+		return err
+	}
+	return recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCourseUpdated,
+		TargetType: models.AuditTargetCourse,
+		TargetID:   course.ID,
+		After:      change,
+	})
+}
+
+// ListManagedCourses lista los cursos que el actor puede gestionar.
+func (s *CourseService) ListManagedCourses(actor RequestActor, filter CourseFilter) (*CoursePage, error) {
+	query := s.DB.Model(&models.Course{})
+	if filter.Status != "" {
+		status, err := models.ParseCourseStatus(filter.Status)
+		if err != nil {
+			return nil, errors.New("estado de curso inválido")
+		}
+		query = query.Where("status = ?", status)
+	}
+	if actor.Role != models.RoleAdmin {
+		query = query.Where("instructor_id = ?", actor.UserID)
+	}
+	return applyCourseFilter(query, filter)
+}
+
+// GetManagedCourse devuelve un curso gestionable por el actor con sus módulos y lecciones.
+func (s *CourseService) GetManagedCourse(actor RequestActor, id uint) (*models.Course, error) {
+	course, err := findManagedCourse(s.DB, actor, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := preloadCourseContent(s.DB).First(course, id).Error; err != nil {
+		log.Printf("Error al cargar el contenido del curso %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener el curso")
+	}
+	return course, nil
+}
+
+// CreateCourse crea un curso en borrador.
+func (s *CourseService) CreateCourse(actor RequestActor, dto CreateCourseDTO) (*models.Course, error) {
+	course := models.Course{
+		Title:       strings.TrimSpace(dto.Title),
+		Summary:     dto.Summary,
+		Description: dto.Description,
+		Category:    strings.TrimSpace(dto.Category),
+		Status:      models.CourseDraft,
+
+		ValidityDays:      dto.ValidityDays,
+		RecertifyLeadDays: 30,
+	}
+	if dto.RecertifyLeadDays != nil {
+		course.RecertifyLeadDays = *dto.RecertifyLeadDays
+	}
+	if err := validateCourseValidity(&course); err != nil {
+		return nil, err
+	}
+	if actor.UserID != 0 {
+		createdBy := actor.UserID
+		course.CreatedByID = &createdBy
+	}
+	switch {
+	case actor.Role == models.RoleInstructor:
+		instructorID := actor.UserID
+		course.InstructorID = &instructorID
+	case dto.InstructorID != nil:
+		if err := validateInstructor(s.DB, *dto.InstructorID); err != nil {
+			return nil, err
+		}
+		course.InstructorID = dto.InstructorID
+	}
+
+	tx := s.DB.Begin()
+	if err := tx.Create(&course).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear curso: %v", err)
+		return nil, errors.New("no se pudo crear el curso")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCourseCreated,
+		TargetType: models.AuditTargetCourse,
+		TargetID:   course.ID,
+		After:      courseAuditSnapshot(&course),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la creación del curso: %v", err)
+		return nil, errors.New("no se pudo crear el curso")
+	}
+	tx.Commit()
+	return &course, nil
+}
+
+// UpdateCourse modifica los datos generales de un curso no archivado.
+func (s *CourseService) UpdateCourse(actor RequestActor, id uint, dto UpdateCourseDTO) (*models.Course, error) {
+	tx := s.DB.Begin()
+	course, err := findEditableCourse(tx, actor, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	before := courseAuditSnapshot(course)
+
+	if dto.Title != nil {
+		if strings.TrimSpace(*dto.Title) == "" {
+			tx.Rollback()
+			return nil, errors.New("el título del curso no puede estar vacío")
+		}
+		course.Title = strings.TrimSpace(*dto.Title)
+	}
+	if dto.Summary != nil {
+		course.Summary = *dto.Summary
+	}
+	if dto.Description != nil {
+		course.Description = *dto.Description
+	}
+	if dto.Category != nil {
+		course.Category = strings.TrimSpace(*dto.Category)
+	}
+	if dto.InstructorID != nil {
+		if actor.Role != models.RoleAdmin {
+			tx.Rollback()
+			return nil, errors.New("solo un administrador puede reasignar el instructor")
+		}
+		if err := validateInstructor(tx, *dto.InstructorID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		course.InstructorID = dto.InstructorID
+	}
+	if dto.ValidityDays != nil {
+		course.ValidityDays = *dto.ValidityDays
+	}
+	if dto.RecertifyLeadDays != nil {
+		course.RecertifyLeadDays = *dto.RecertifyLeadDays
+	}
+	if err := validateCourseValidity(course); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Save(course).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar curso %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el curso")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCourseUpdated,
+		TargetType: models.AuditTargetCourse,
+		TargetID:   course.ID,
+		Before:     before,
+		After:      courseAuditSnapshot(course),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la actualización del curso %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el curso")
+	}
+	tx.Commit()
+	return course, nil
+}
+
+// DeleteCourse elimina (borrado lógico) un curso en borrador o archivado. Un curso
+// publicado debe archivarse antes, para que no desaparezca del catálogo sin aviso.
+func (s *CourseService) DeleteCourse(actor RequestActor, id uint) error {
+	tx := s.DB.Begin()
+	course, err := findManagedCourse(tx, actor, id, true)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if course.Status == models.CoursePublished {
+		tx.Rollback()
+		return errors.New("solo se pueden eliminar cursos en borrador o archivados")
+	}
+	if err := tx.Delete(course).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar curso %d: %v", id, err)
+		return errors.New("no se pudo eliminar el curso")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCourseDeleted,
+		TargetType: models.AuditTargetCourse,
+		TargetID:   course.ID,
+		Before:     courseAuditSnapshot(course),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la eliminación del curso %d: %v", id, err)
+		return errors.New("no se pudo eliminar el curso")
+	}
+	tx.Commit()
+	return nil
+}
+
+// ChangeCourseStatus publica, archiva o devuelve a borrador un curso. Para publicarse, el
+// curso necesita al menos un módulo con alguna lección.
+func (s *CourseService) ChangeCourseStatus(actor RequestActor, id uint, dto ChangeCourseStatusDTO) (*models.Course, error) {
+	to, err := models.ParseCourseStatus(dto.Status)
+	if err != nil {
+		return nil, errors.New("estado de curso inválido")
+	}
+
+	tx := s.DB.Begin()
+	course, err := findManagedCourse(tx, actor, id, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if course.Status == to {
+		tx.Rollback()
+		return course, nil
+	}
+	if !course.Status.CanTransitionTo(to) {
+		tx.Rollback()
+		return nil, errors.New("transición de estado de curso no permitida")
+	}
+	if to == models.CoursePublished {
+		var withLessons int64
+		if err := tx.Model(&models.Module{}).
+			Where("course_id = ? AND EXISTS (?)", id, tx.Model(&models.Lesson{}).Select("1").
+				Where("lessons.module_id = course_modules.id")).
+			Count(&withLessons).Error; err != nil {
+			tx.Rollback()
+			log.Printf("Error al comprobar el contenido del curso %d: %v", id, err)
+			return nil, errors.New("no se pudo cambiar el estado del curso")
+		}
+		if withLessons == 0 {
+			tx.Rollback()
+			return nil, errors.New("el curso necesita al menos un módulo con lecciones para publicarse")
+		}
+		var withoutPackage int64
+		if err := tx.Model(&models.Lesson{}).
+			Joins("JOIN course_modules ON course_modules.id = lessons.module_id AND course_modules.deleted_at IS NULL").
+			Where("course_modules.course_id = ? AND lessons.type = ?", id, models.LessonScorm).
+			Where("NOT EXISTS (SELECT 1 FROM scorm_packages WHERE scorm_packages.lesson_id = lessons.id)").
+			Count(&withoutPackage).Error; err != nil {
+			tx.Rollback()
+			log.Printf("Error al comprobar el contenido del curso %d: %v", id, err)
+			return nil, errors.New("no se pudo cambiar el estado del curso")
+		}
+		if withoutPackage > 0 {
+			tx.Rollback()
+			return nil, errors.New("las lecciones SCORM necesitan un paquete para publicar el curso")
+		}
+	}
+
+	before := courseAuditSnapshot(course)
+	now := time.Now()
+	updates := map[string]interface{}{"status": to}
+	switch to {
+	case models.CoursePublished:
+		updates["published_at"] = now
+		updates["archived_at"] = nil
+	case models.CourseArchived:
+		updates["archived_at"] = now
+	case models.CourseDraft:
+		updates["archived_at"] = nil
+	}
+	if err := tx.Model(course).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al cambiar el estado del curso %d: %v", id, err)
+		return nil, errors.New("no se pudo cambiar el estado del curso")
+	}
+	if err := tx.First(course, id).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("no se pudo cambiar el estado del curso")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCourseStatusChanged,
+		TargetType: models.AuditTargetCourse,
+		TargetID:   course.ID,
+		Before:     before,
+		After:      courseAuditSnapshot(course),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar el cambio de estado del curso %d: %v", id, err)
+		return nil, errors.New("no se pudo cambiar el estado del curso")
+	}
+	var eventType string
+	switch to {
+	case models.CoursePublished:
+		eventType = models.DomainEventCoursePublished
+	case models.CourseArchived:
+		eventType = models.DomainEventCourseArchived
+	}
+	if eventType != "" {
+		if err := recordDomainEvent(tx, actor, eventType, models.AggregateCourse, course.ID, map[string]interface{}{"course": course}); err != nil {
+			tx.Rollback()
+			log.Printf("Error al registrar el evento del curso %d: %v", id, err)
+			return nil, errors.New("no se pudo cambiar el estado del curso")
+		}
+	}
+	tx.Commit()
+	return course, nil
+}
+
+// AddModule agrega un módulo al final del curso.
+func (s *CourseService) AddModule(actor RequestActor, courseID uint, dto ModuleDTO) (*models.Module, error) {
+	tx := s.DB.Begin()
+	course, err := findEditableCourse(tx, actor, courseID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	var last int
+	if err := tx.Model(&models.Module{}).Where("course_id = ?", courseID).
+		Select("COALESCE(MAX(position), 0)").Scan(&last).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al calcular la posición del módulo: %v", err)
+		return nil, errors.New("no se pudo crear el módulo")
+	}
+	module := models.Module{
+		CourseID:    courseID,
+		Title:       strings.TrimSpace(dto.Title),
+		Description: dto.Description,
+		Position:    last + 1,
+	}
+	if err := tx.Create(&module).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear módulo en el curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudo crear el módulo")
+	}
+	if err := touchCourse(tx, actor, course, map[string]interface{}{"module_added": module.ID, "title": module.Title}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar el módulo nuevo del curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudo crear el módulo")
+	}
+	tx.Commit()
+	return &module, nil
+}
+
+// UpdateModule modifica el título o la descripción de un módulo.
+func (s *CourseService) UpdateModule(actor RequestActor, courseID, moduleID uint, dto UpdateModuleDTO) (*models.Module, error) {
+	tx := s.DB.Begin()
+	course, err := findEditableCourse(tx, actor, courseID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	module, err := findCourseModule(tx, courseID, moduleID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if dto.Title != nil {
+		if strings.TrimSpace(*dto.Title) == "" {
+			tx.Rollback()
+			return nil, errors.New("el título del módulo no puede estar vacío")
+		}
+		module.Title = strings.TrimSpace(*dto.Title)
+	}
+	if dto.Description != nil {
+		module.Description = *dto.Description
+	}
+	if err := tx.Save(module).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar módulo %d: %v", moduleID, err)
+		return nil, errors.New("no se pudo actualizar el módulo")
+	}
+	if err := touchCourse(tx, actor, course, map[string]interface{}{"module_updated": module.ID, "title": module.Title}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar el módulo %d: %v", moduleID, err)
+		return nil, errors.New("no se pudo actualizar el módulo")
+	}
+	tx.Commit()
+	return module, nil
+}
+
+// DeleteModule elimina un módulo con sus lecciones y compacta las posiciones del resto.
+func (s *CourseService) DeleteModule(actor RequestActor, courseID, moduleID uint) error {
+	tx := s.DB.Begin()
+	course, err := findEditableCourse(tx, actor, courseID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	module, err := findCourseModule(tx, courseID, moduleID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if course.Status == models.CoursePublished {
+		if err := ensurePublishableWithout(tx, courseID, moduleID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Where("module_id = ?", moduleID).Delete(&models.Lesson{}).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar las lecciones del módulo %d: %v", moduleID, err)
+		return errors.New("no se pudo eliminar el módulo")
+	}
+	if err := tx.Delete(module).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar módulo %d: %v", moduleID, err)
+		return errors.New("no se pudo eliminar el módulo")
+	}
+	if err := tx.Model(&models.Module{}).Where("course_id = ? AND position > ?", courseID, module.Position).
+		Update("position", gorm.Expr("position - 1")).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al reordenar los módulos del curso %d: %v", courseID, err)
+		return errors.New("no se pudo eliminar el módulo")
+	}
+	if err := touchCourse(tx, actor, course, map[string]interface{}{"module_deleted": module.ID, "title": module.Title}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la eliminación del módulo %d: %v", moduleID, err)
+		return errors.New("no se pudo eliminar el módulo")
+	}
+	tx.Commit()
+	return nil
+}
+
+// ensurePublishableWithout evita que un curso publicado se quede sin contenido al eliminar
+// el módulo moduleID (o, con lessonID distinto de 0, solo esa lección).
+func ensurePublishableWithout(tx *gorm.DB, courseID, moduleID uint, lessonID ...uint) error {
+	lessons := tx.Model(&models.Lesson{}).
+		Joins("JOIN course_modules ON course_modules.id = lessons.module_id AND course_modules.deleted_at IS NULL").
+		Where("course_modules.course_id = ?", courseID)
+	if len(lessonID) > 0 {
+		lessons = lessons.Where("lessons.id <> ?", lessonID[0])
+	} else {
+		lessons = lessons.Where("lessons.module_id <> ?", moduleID)
+	}
+	var remaining int64
+	if err := lessons.Count(&remaining).Error; err != nil {
+		log.Printf("Error al comprobar el contenido del curso %d: %v", courseID, err)
+		return errors.New("no se pudo comprobar el contenido del curso")
+	}
+	if remaining == 0 {
+		return errors.New("un curso publicado debe conservar al menos una lección")
+	}
+	return nil
+}
+
+// reorderPositions asigna posiciones 1..n según ids, que debe contener exactamente los
+// elementos actuales (current).
+func reorderPositions(tx *gorm.DB, model interface{}, current []uint, ids []uint, mismatch string) error {
+	if len(ids) != len(current) {
+		return errors.New(mismatch)
+	}
+	existing := make(map[uint]bool, len(current))
+	for _, id := range current {
+		existing[id] = true
+	}
+	for _, id := range ids {
+		if !existing[id] {
+			return errors.New(mismatch)
+		}
+		delete(existing, id) // Detecta IDs repetidos
+	}
+	for i, id := range ids {
+		if err := tx.Model(model).Where("id = ?", id).Update("position", i+1).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReorderModules reordena los módulos de un curso. dto.IDs debe incluir todos sus módulos.
+func (s *CourseService) ReorderModules(actor RequestActor, courseID uint, dto ReorderDTO) (*models.Course, error) {
+	tx := s.DB.Begin()
+	course, err := findEditableCourse(tx, actor, courseID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	var current []uint
+	if err := tx.Model(&models.Module{}).Where("course_id = ?", courseID).Pluck("id", &current).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al obtener los módulos del curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudieron reordenar los módulos")
+	}
+	mismatch := "la lista debe incluir exactamente los módulos del curso"
+	if err := reorderPositions(tx, &models.Module{}, current, dto.IDs, mismatch); err != nil {
+		tx.Rollback()
+		if err.Error() == mismatch {
+			return nil, err
+		}
+		log.Printf("Error al reordenar los módulos del curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudieron reordenar los módulos")
+	}
+	if err := touchCourse(tx, actor, course, map[string]interface{}{"modules_order": dto.IDs}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar el orden de módulos del curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudieron reordenar los módulos")
+	}
+	tx.Commit()
+	return s.GetManagedCourse(actor, courseID)
+}
+
+// AddLesson agrega una lección al final de un módulo.
+func (s *CourseService) AddLesson(actor RequestActor, courseID, moduleID uint, dto LessonDTO) (*models.Lesson, error) {
+	lessonType, err := models.ParseLessonType(dto.Type)
+	if err != nil {
+		return nil, errors.New("tipo de lección inválido")
+	}
+	lesson := models.Lesson{
+		ModuleID:        moduleID,
+		Title:           strings.TrimSpace(dto.Title),
+		Type:            lessonType,
+		Content:         dto.Content,
+		ContentURL:      strings.TrimSpace(dto.ContentURL),
+		DurationMinutes: dto.DurationMinutes,
+	}
+	if err := validateLesson(&lesson); err != nil {
+		return nil, err
+	}
+
+	tx := s.DB.Begin()
+	course, err := findEditableCourse(tx, actor, courseID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if _, err := findCourseModule(tx, courseID, moduleID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	var last int
+	if err := tx.Model(&models.Lesson{}).Where("module_id = ?", moduleID).
+		Select("COALESCE(MAX(position), 0)").Scan(&last).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al calcular la posición de la lección: %v", err)
+		return nil, errors.New("no se pudo crear la lección")
+	}
+	lesson.Position = last + 1
+	if err := tx.Create(&lesson).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear lección en el módulo %d: %v", moduleID, err)
+		return nil, errors.New("no se pudo crear la lección")
+	}
+	if err := touchCourse(tx, actor, course, map[string]interface{}{"lesson_added": lesson.ID, "module_id": moduleID, "title": lesson.Title}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la lección nueva del curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudo crear la lección")
+	}
+	tx.Commit()
+	return &lesson, nil
+}
+
+// UpdateLesson modifica una lección.
+func (s *CourseService) UpdateLesson(actor RequestActor, courseID, moduleID, lessonID uint, dto UpdateLessonDTO) (*models.Lesson, error) {
+	tx := s.DB.Begin()
+	course, err := findEditableCourse(tx, actor, courseID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if _, err := findCourseModule(tx, courseID, moduleID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	lesson, err := findModuleLesson(tx, moduleID, lessonID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if dto.Title != nil {
+		if strings.TrimSpace(*dto.Title) == "" {
+			tx.Rollback()
+			return nil, errors.New("el título de la lección no puede estar vacío")
+		}
+		lesson.Title = strings.TrimSpace(*dto.Title)
+	}
+	if dto.Type != nil {
+		lessonType, err := models.ParseLessonType(*dto.Type)
+		if err != nil {
+			tx.Rollback()
+			return nil, errors.New("tipo de lección inválido")
+		}
+		lesson.Type = lessonType
+	}
+	if dto.Content != nil {
+		lesson.Content = *dto.Content
+	}
+	if dto.ContentURL != nil {
+		lesson.ContentURL = strings.TrimSpace(*dto.ContentURL)
+	}
+	if dto.DurationMinutes != nil {
+		lesson.DurationMinutes = *dto.DurationMinutes
+	}
+	if err := validateLesson(lesson); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Save(lesson).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar lección %d: %v", lessonID, err)
+		return nil, errors.New("no se pudo actualizar la lección")
+	}
+	if err := touchCourse(tx, actor, course, map[string]interface{}{"lesson_updated": lesson.ID, "module_id": moduleID, "title": lesson.Title}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la lección %d: %v", lessonID, err)
+		return nil, errors.New("no se pudo actualizar la lección")
+	}
+	tx.Commit()
+	return lesson, nil
+}
+
+// DeleteLesson elimina una lección y compacta las posiciones del resto del módulo.
+func (s *CourseService) DeleteLesson(actor RequestActor, courseID, moduleID, lessonID uint) error {
+	tx := s.DB.Begin()
+	course, err := findEditableCourse(tx, actor, courseID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := findCourseModule(tx, courseID, moduleID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	lesson, err := findModuleLesson(tx, moduleID, lessonID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if course.Status == models.CoursePublished {
+		if err := ensurePublishableWithout(tx, courseID, moduleID, lessonID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Delete(lesson).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar lección %d: %v", lessonID, err)
+		return errors.New("no se pudo eliminar la lección")
+	}
+	if err := tx.Model(&models.Lesson{}).Where("module_id = ? AND position > ?", moduleID, lesson.Position).
+		Update("position", gorm.Expr("position - 1")).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al reordenar las lecciones del módulo %d: %v", moduleID, err)
+		return errors.New("no se pudo eliminar la lección")
+	}
+	if err := touchCourse(tx, actor, course, map[string]interface{}{"lesson_deleted": lesson.ID, "module_id": moduleID, "title": lesson.Title}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la eliminación de la lección %d: %v", lessonID, err)
+		return errors.New("no se pudo eliminar la lección")
+	}
+	tx.Commit()
+	return nil
+}
+
+// ReorderLessons reordena las lecciones de un módulo. dto.IDs debe incluir todas sus lecciones.
+func (s *CourseService) ReorderLessons(actor RequestActor, courseID, moduleID uint, dto ReorderDTO) (*models.Module, error) {
+	tx := s.DB.Begin()
+	course, err := findEditableCourse(tx, actor, courseID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	module, err := findCourseModule(tx, courseID, moduleID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	var current []uint
+	if err := tx.Model(&models.Lesson{}).Where("module_id = ?", moduleID).Pluck("id", &current).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al obtener las lecciones del módulo %d: %v", moduleID, err)
+		return nil, errors.New("no se pudieron reordenar las lecciones")
+	}
+	mismatch := "la lista debe incluir exactamente las lecciones del módulo"
+	if err := reorderPositions(tx, &models.Lesson{}, current, dto.IDs, mismatch); err != nil {
+		tx.Rollback()
+		if err.Error() == mismatch {
+			return nil, err
+		}
+		log.Printf("Error al reordenar las lecciones del módulo %d: %v", moduleID, err)
+		return nil, errors.New("no se pudieron reordenar las lecciones")
+	}
+	if err := touchCourse(tx, actor, course, map[string]interface{}{"lessons_order": dto.IDs, "module_id": moduleID}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar el orden de lecciones del módulo %d: %v", moduleID, err)
+		return nil, errors.New("no se pudieron reordenar las lecciones")
+	}
+	if err := tx.Preload("Lessons", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).First(module, moduleID).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("no se pudieron reordenar las lecciones")
+	}
+	tx.Commit()
+	return module, nil
+}
+
+// ListPublishedCourses lista el catálogo visible para los empleados.
+func (s *CourseService) ListPublishedCourses(filter CourseFilter) (*CoursePage, error) {
+	query := s.DB.Model(&models.Course{}).Where("status = ?", models.CoursePublished)
+	return applyCourseFilter(query, filter)
+}
+
+// GetPublishedCourse devuelve un curso publicado con sus módulos y lecciones.
+func (s *CourseService) GetPublishedCourse(id uint) (*models.Course, error) {
+	var course models.Course
+	if err := preloadCourseContent(s.DB).Where("status = ?", models.CoursePublished).First(&course, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errCourseNotFound
+		}
+		log.Printf("Error al obtener curso publicado %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener el curso")
+	}
+	return &course, nil
+}
@@ -0,0 +1,541 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxCustomFieldValueLength coincide con el tamaño de la columna custom_field_values.value.
+const maxCustomFieldValueLength = 500
+
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// CustomFieldDefinitionDTO define los datos para crear o actualizar un campo personalizado.
+// Key y Type no pueden cambiar una vez creado el campo.
+type CustomFieldDefinitionDTO struct {
+	Key             string   `json:"key" binding:"required,max=50"`
+	Label           string   `json:"label" binding:"required,min=1,max=100"`
+	Type            string   `json:"type" binding:"required,oneof=text number date boolean enum"`
+	Required        bool     `json:"required"`
+	Options         []string `json:"options,omitempty"`
+	ValidationRegex string   `json:"validation_regex,omitempty" binding:"max=255"`
+	Visibility      string   `json:"visibility,omitempty" binding:"omitempty,oneof=admin_only employee_visible employee_editable"`
+	SortOrder       int      `json:"sort_order"`
+}
+
+// CustomFieldValidationError indica que un valor (o una definición) de campo personalizado no es válido.
+type CustomFieldValidationError struct {
+	Key    string
+	Reason string
+}
+
+func (e *CustomFieldValidationError) Error() string {
+	if e.Key == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("campo personalizado '%s': %s", e.Key, e.Reason)
+}
+
+// CustomFieldServiceInterface define la administración del esquema de campos personalizados.
+type CustomFieldServiceInterface interface {
+	ListDefinitions() ([]models.CustomFieldDefinition, error)
+	CreateDefinition(actor RequestActor, dto CustomFieldDefinitionDTO) (*models.CustomFieldDefinition, error)
+	UpdateDefinition(actor RequestActor, id uint, dto CustomFieldDefinitionDTO) (*models.CustomFieldDefinition, error)
+	DeleteDefinition(actor RequestActor, id uint) error
+}
+
+// CustomFieldService implementa CustomFieldServiceInterface.
+type CustomFieldService struct {
+	DB *gorm.DB
+}
+
+// NewCustomFieldService crea una nueva instancia de CustomFieldService.
+func NewCustomFieldService(db *gorm.DB) *CustomFieldService {
+	return &CustomFieldService{DB: db}
+}
+
+func customFieldDefinitionSnapshot(d *models.CustomFieldDefinition) map[string]interface{} {
+	options := make([]interface{}, 0, len(d.Options))
+	for _, o := range d.Options {
+		options = append(options, o)
+	}
+	return map[string]interface{}{
+		"id":               d.ID,
+		"key":              d.Key,
+		"label":            d.Label,
+		"type":             d.Type,
+		"required":         d.Required,
+		"options":          options,
+		"validation_regex": d.ValidationRegex,
+		"visibility":       d.Visibility,
+		"sort_order":       d.SortOrder,
+	}
+}
+
+// applyDefinitionDTO valida el DTO y lo copia sobre la definición.
+func applyDefinitionDTO(def *models.CustomFieldDefinition, dto CustomFieldDefinitionDTO) error {
+	if !customFieldKeyPattern.MatchString(dto.Key) {
+		return &CustomFieldValidationError{Reason: "la clave debe empezar con una letra minúscula y contener solo a-z, 0-9 y '_'"}
+	}
+
+	var options []string
+	if dto.Type == models.CustomFieldTypeEnum {
+		seen := map[string]bool{}
+		for _, o := range dto.Options {
+			o = strings.TrimSpace(o)
+			if o == "" || seen[o] {
+				continue
+			}
+			if len(o) > maxCustomFieldValueLength {
+				return &CustomFieldValidationError{Key: dto.Key, Reason: "opción demasiado larga"}
+			}
+			seen[o] = true
+			options = append(options, o)
+		}
+		if len(options) == 0 {
+			return &CustomFieldValidationError{Key: dto.Key, Reason: "un campo enum requiere al menos una opción"}
+		}
+	} else if len(dto.Options) > 0 {
+		return &CustomFieldValidationError{Key: dto.Key, Reason: "solo los campos enum admiten opciones"}
+	}
+
+	if dto.ValidationRegex != "" {
+		if dto.Type != models.CustomFieldTypeText {
+			return &CustomFieldValidationError{Key: dto.Key, Reason: "solo los campos de texto admiten expresión regular"}
+		}
+		if _, err := regexp.Compile(dto.ValidationRegex); err != nil {
+			return &CustomFieldValidationError{Key: dto.Key, Reason: "expresión regular inválida: " + err.Error()}
+		}
+	}
+
+	visibility := dto.Visibility
+	if visibility == "" {
+		visibility = models.CustomFieldAdminOnly
+	}
+
+	def.Key = dto.Key
+	def.Label = strings.TrimSpace(dto.Label)
+	def.Type = dto.Type
+	def.Required = dto.Required
+	def.Options = options
+	def.ValidationRegex = dto.ValidationRegex
+	def.Visibility = visibility
+	def.SortOrder = dto.SortOrder
+	return nil
+}
+
+// ListDefinitions devuelve todas las definiciones en su orden de presentación.
+func (s *CustomFieldService) ListDefinitions() ([]models.CustomFieldDefinition, error) {
+	defs, err := loadCustomFieldDefinitions(s.DB)
+	if err != nil {
+		log.Printf("Error al listar campos personalizados: %v", err)
+		return nil, errors.New("no se pudo obtener la lista de campos personalizados")
+	}
+	return defs, nil
+}
+
+// CreateDefinition crea un campo personalizado.
+func (s *CustomFieldService) CreateDefinition(actor RequestActor, dto CustomFieldDefinitionDTO) (*models.CustomFieldDefinition, error) {
+	var def models.CustomFieldDefinition
+	if err := applyDefinitionDTO(&def, dto); err != nil {
+		return nil, err
+	}
+
+	tx := s.DB.Begin()
+	if err := tx.Create(&def).Error; err != nil {
+		tx.Rollback()
+		if isDuplicateKeyError(err) {
+			return nil, errors.New("ya existe un campo personalizado con esa clave")
+		}
+		log.Printf("Error al crear campo personalizado: %v", err)
+		return nil, errors.New("no se pudo crear el campo personalizado")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCustomFieldCreated,
+		TargetType: models.AuditTargetCustomField,
+		TargetID:   def.ID,
+		After:      customFieldDefinitionSnapshot(&def),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar creación del campo personalizado: %v", err)
+		return nil, errors.New("no se pudo crear el campo personalizado")
+	}
+	tx.Commit()
+	return &def, nil
+}
+
+// UpdateDefinition actualiza un campo personalizado. La clave y el tipo son inmutables
+// porque los valores ya guardados dependen de ellos.
+func (s *CustomFieldService) UpdateDefinition(actor RequestActor, id uint, dto CustomFieldDefinitionDTO) (*models.CustomFieldDefinition, error) {
+	var def models.CustomFieldDefinition
+	tx := s.DB.Begin()
+	if err := tx.First(&def, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("campo personalizado no encontrado")
+		}
+		log.Printf("Error al buscar campo personalizado %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el campo personalizado")
+	}
+	if dto.Key != def.Key || dto.Type != def.Type {
+		tx.Rollback()
+		return nil, &CustomFieldValidationError{Key: def.Key, Reason: "la clave y el tipo no se pueden modificar"}
+	}
+	before := customFieldDefinitionSnapshot(&def)
+
+	if err := applyDefinitionDTO(&def, dto); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Save(&def).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar campo personalizado %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el campo personalizado")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCustomFieldUpdated,
+		TargetType: models.AuditTargetCustomField,
+		TargetID:   def.ID,
+		Before:     before,
+		After:      customFieldDefinitionSnapshot(&def),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar actualización del campo personalizado %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el campo personalizado")
+	}
+	tx.Commit()
+	return &def, nil
+}
+
+// DeleteDefinition elimina un campo personalizado junto con todos sus valores.
+func (s *CustomFieldService) DeleteDefinition(actor RequestActor, id uint) error {
+	var def models.CustomFieldDefinition
+	tx := s.DB.Begin()
+	if err := tx.First(&def, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("campo personalizado no encontrado")
+		}
+		log.Printf("Error al buscar campo personalizado %d: %v", id, err)
+		return errors.New("no se pudo eliminar el campo personalizado")
+	}
+	if err := tx.Where("definition_id = ?", def.ID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar valores del campo personalizado %d: %v", id, err)
+		return errors.New("no se pudo eliminar el campo personalizado")
+	}
+	if err := tx.Delete(&def).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar campo personalizado %d: %v", id, err)
+		return errors.New("no se pudo eliminar el campo personalizado")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionCustomFieldDeleted,
+		TargetType: models.AuditTargetCustomField,
+		TargetID:   def.ID,
+		Before:     customFieldDefinitionSnapshot(&def),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar eliminación del campo personalizado %d: %v", id, err)
+		return errors.New("no se pudo eliminar el campo personalizado")
+	}
+	tx.Commit()
+	return nil
+}
+
+// --- Valores de campos personalizados (usados por UserService y ProfileService) ---
+
+func loadCustomFieldDefinitions(db *gorm.DB) ([]models.CustomFieldDefinition, error) {
+	var defs []models.CustomFieldDefinition
+	err := db.Order("sort_order ASC, id ASC").Find(&defs).Error
+	return defs, err
+}
+
+// canonicalCustomValue valida un valor recibido en JSON y lo convierte a su forma canónica.
+func canonicalCustomValue(def *models.CustomFieldDefinition, raw interface{}) (string, error) {
+	invalid := func(reason string) error {
+		return &CustomFieldValidationError{Key: def.Key, Reason: reason}
+	}
+
+	var value string
+	switch def.Type {
+	case models.CustomFieldTypeNumber:
+		var f float64
+		switch v := raw.(type) {
+		case float64:
+			f = v
+		case json.Number:
+			parsed, err := v.Float64()
+			if err != nil {
+				return "", invalid("se esperaba un número")
+			}
+			f = parsed
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return "", invalid("se esperaba un número")
+			}
+			f = parsed
+		default:
+			return "", invalid("se esperaba un número")
+		}
+		value = strconv.FormatFloat(f, 'f', -1, 64)
+	case models.CustomFieldTypeBoolean:
+		switch v := raw.(type) {
+		case bool:
+			value = strconv.FormatBool(v)
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return "", invalid("se esperaba true o false")
+			}
+			value = strconv.FormatBool(b)
+		default:
+			return "", invalid("se esperaba true o false")
+		}
+	default:
+		s, ok := raw.(string)
+		if !ok {
+			return "", invalid("se esperaba un texto")
+		}
+		value = s
+	}
+
+	switch def.Type {
+	case models.CustomFieldTypeDate:
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+		if err != nil {
+			return "", invalid("se esperaba una fecha YYYY-MM-DD")
+		}
+		value = d.Format("2006-01-02")
+	case models.CustomFieldTypeEnum:
+		found := false
+		for _, o := range def.Options {
+			if o == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", invalid(fmt.Sprintf("valor no permitido; opciones: %s", strings.Join(def.Options, ", ")))
+		}
+	case models.CustomFieldTypeText:
+		if def.ValidationRegex != "" {
+			re, err := regexp.Compile(def.ValidationRegex)
+			if err != nil || !re.MatchString(value) {
+				return "", invalid("el valor no cumple el formato requerido")
+			}
+		}
+	}
+
+	if value == "" {
+		return "", invalid("el valor no puede estar vacío; use null para quitarlo")
+	}
+	if len(value) > maxCustomFieldValueLength {
+		return "", invalid("valor demasiado largo")
+	}
+	return value, nil
+}
+
+// typedCustomValue convierte el valor canónico almacenado al tipo JSON correspondiente.
+func typedCustomValue(def *models.CustomFieldDefinition, stored string) interface{} {
+	switch def.Type {
+	case models.CustomFieldTypeNumber:
+		if f, err := strconv.ParseFloat(stored, 64); err == nil {
+			return f
+		}
+	case models.CustomFieldTypeBoolean:
+		return stored == "true"
+	}
+	return stored
+}
+
+// customFieldEditor indica si quien edita puede modificar un campo; nil significa "todos" (administrador).
+type customFieldEditor func(def *models.CustomFieldDefinition) error
+
+// applyCustomFieldValues valida y guarda los valores de campos personalizados de un usuario
+// dentro de tx. Un valor null elimina el campo (salvo que sea obligatorio). Con creating
+// se exige además que todos los campos obligatorios estén informados. Devuelve si algo cambió.
+func applyCustomFieldValues(tx *gorm.DB, userID uint, input map[string]interface{}, creating bool, canEdit customFieldEditor) (bool, error) {
+	if len(input) == 0 && !creating {
+		return false, nil
+	}
+	defs, err := loadCustomFieldDefinitions(tx)
+	if err != nil {
+		return false, err
+	}
+	byKey := make(map[string]*models.CustomFieldDefinition, len(defs))
+	for i := range defs {
+		byKey[defs[i].Key] = &defs[i]
+	}
+	for key := range input {
+		def := byKey[key]
+		if def == nil {
+			return false, &CustomFieldValidationError{Key: key, Reason: "campo desconocido"}
+		}
+		// El permiso se comprueba aunque el valor no cambie, para no revelar valores ocultos.
+		if canEdit != nil {
+			if err := canEdit(def); err != nil {
+				return false, err
+			}
+		}
+	}
+	if creating {
+		for _, def := range defs {
+			if def.Required && input[def.Key] == nil {
+				return false, &CustomFieldValidationError{Key: def.Key, Reason: "es obligatorio"}
+			}
+		}
+	}
+
+	var existing []models.CustomFieldValue
+	if err := tx.Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+		return false, err
+	}
+	current := make(map[uint]*models.CustomFieldValue, len(existing))
+	for i := range existing {
+		current[existing[i].DefinitionID] = &existing[i]
+	}
+
+	changed := false
+	for _, def := range defs {
+		raw, present := input[def.Key]
+		if !present {
+			continue
+		}
+		stored := current[def.ID]
+
+		if raw == nil {
+			if stored == nil {
+				continue
+			}
+			if def.Required {
+				return false, &CustomFieldValidationError{Key: def.Key, Reason: "es obligatorio y no se puede quitar"}
+			}
+			if err := tx.Delete(stored).Error; err != nil {
+				return false, err
+			}
+			changed = true
+			continue
+		}
+
+		value, err := canonicalCustomValue(&def, raw)
+		if err != nil {
+			return false, err
+		}
+		if stored != nil && stored.Value == value {
+			continue
+		}
+		if stored == nil {
+			err = tx.Create(&models.CustomFieldValue{UserID: userID, DefinitionID: def.ID, Value: value}).Error
+		} else {
+			stored.Value = value
+			err = tx.Save(stored).Error
+		}
+		if err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// loadCustomFieldValues devuelve, por usuario, sus campos personalizados ya tipados.
+// visible filtra las definiciones que se incluyen; nil incluye todas.
+func loadCustomFieldValues(db *gorm.DB, userIDs []uint, visible func(def *models.CustomFieldDefinition) bool) (map[uint]map[string]interface{}, error) {
+	result := make(map[uint]map[string]interface{}, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+	defs, err := loadCustomFieldDefinitions(db)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.CustomFieldDefinition, len(defs))
+	for i := range defs {
+		if visible == nil || visible(&defs[i]) {
+			byID[defs[i].ID] = &defs[i]
+		}
+	}
+	if len(byID) == 0 {
+		return result, nil
+	}
+
+	var values []models.CustomFieldValue
+	if err := db.Where("user_id IN ?", userIDs).Find(&values).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		def := byID[v.DefinitionID]
+		if def == nil {
+			continue
+		}
+		if result[v.UserID] == nil {
+			result[v.UserID] = map[string]interface{}{}
+		}
+		result[v.UserID][def.Key] = typedCustomValue(def, v.Value)
+	}
+	return result, nil
+}
+
+// attachCustomFields completa el campo CustomFields de los DTOs indicados.
+func attachCustomFields(db *gorm.DB, visible func(def *models.CustomFieldDefinition) bool, dtos ...*UserDetailDTO) error {
+	ids := make([]uint, 0, len(dtos))
+	for _, dto := range dtos {
+		ids = append(ids, dto.ID)
+	}
+	values, err := loadCustomFieldValues(db, ids, visible)
+	if err != nil {
+		return err
+	}
+	for _, dto := range dtos {
+		dto.CustomFields = values[dto.ID]
+	}
+	return nil
+}
+
+// customFieldsVisibleToEmployee filtra los campos que un empleado puede ver en su propio perfil.
+func customFieldsVisibleToEmployee(def *models.CustomFieldDefinition) bool {
+	return def.Visibility == models.CustomFieldEmployeeVisible || def.Visibility == models.CustomFieldEmployeeEditable
+}
+
+// customFieldFilterCondition devuelve la condición SQL para filtrar usuarios cuyo campo
+// personalizado key tenga el valor indicado (comparado en forma canónica).
+func customFieldFilterCondition(db *gorm.DB, key, raw string) (string, []interface{}, error) {
+	var def models.CustomFieldDefinition
+	if err := db.Where("`key` = ?", key).First(&def).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, &CustomFieldValidationError{Key: key, Reason: "campo desconocido"}
+		}
+		return "", nil, err
+	}
+	var value string
+	if def.Type == models.CustomFieldTypeText {
+		value = raw // En el filtro no se exige el formato de la expresión regular
+	} else {
+		var err error
+		if value, err = canonicalCustomValue(&def, raw); err != nil {
+			return "", nil, err
+		}
+	}
+	return "EXISTS (SELECT 1 FROM custom_field_values cfv WHERE cfv.user_id = users.id AND cfv.definition_id = ? AND cfv.value = ?)",
+		[]interface{}{def.ID, value}, nil
+}
+
+// customFieldAuditSnapshot devuelve los valores personalizados de un usuario para la auditoría.
+func customFieldAuditSnapshot(db *gorm.DB, userID uint) (map[string]interface{}, error) {
+	values, err := loadCustomFieldValues(db, []uint{userID}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return values[userID], nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"github.com/Unikyri/yamerito-mvp/internal/events"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// statusChangePayload son los datos adicionales de UserStatusChanged.
+type statusChangePayload struct {
+	From models.UserStatus `json:"from"`
+	To   models.UserStatus `json:"to"`
+}
+
+// roleChangePayload son los datos adicionales de UserRoleChanged.
+type roleChangePayload struct {
+	From models.Role `json:"from"`
+	To   models.Role `json:"to"`
+}
+
+// recordDomainEvent registra un evento de dominio en tx, la transacción del cambio.
+func recordDomainEvent(tx *gorm.DB, actor RequestActor, eventType, aggregateType string, aggregateID uint, data interface{}) error {
+	event := models.DomainEvent{
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		ActorUsername: actor.Username,
+		RequestID:     actor.RequestID,
+	}
+	if actor.UserID != 0 {
+		actorID := actor.UserID
+		event.ActorID = &actorID
+	}
+	return events.Record(tx, &event, data)
+}
+
+// recordUserEvent registra un evento de usuario con su representación completa (la misma
+// que devuelve la API, con campos personalizados) más los datos de extra.
+func recordUserEvent(tx *gorm.DB, actor RequestActor, eventType string, user *models.User, extra map[string]interface{}) error {
+	data := map[string]interface{}{"user": userDetailDTOWithCustomFields(tx, user)}
+	for k, v := range extra {
+		data[k] = v
+	}
+	return recordDomainEvent(tx, actor, eventType, models.AggregateUser, user.ID, data)
+}
+
+// recordUserUpdateEvents registra UserUpdated y, si el rol cambió, también UserRoleChanged.
+func recordUserUpdateEvents(tx *gorm.DB, actor RequestActor, user *models.User, previousRole models.Role) error {
+	if err := recordUserEvent(tx, actor, models.DomainEventUserUpdated, user, nil); err != nil {
+		return err
+	}
+	if user.Role == previousRole {
+		return nil
+	}
+	extra := map[string]interface{}{"from": previousRole, "to": user.Role}
+	return recordUserEvent(tx, actor, models.DomainEventUserRoleChanged, user, extra)
+}
@@ -0,0 +1,653 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultEnrollmentPageSize = 50
+	maxEnrollmentPageSize     = 200
+)
+
+// AssignmentCriteria selecciona usuarios por rol, puesto y/o departamento. Los criterios
+// indicados se combinan (todos deben cumplirse); los vacíos no filtran.
+type AssignmentCriteria struct {
+	Role         string `json:"role" binding:"omitempty,oneof=Admin Employee Instructor"`
+	Position     string `json:"position" binding:"omitempty,max=100"`
+	DepartmentID *uint  `json:"department_id"`
+}
+
+func (c AssignmentCriteria) isEmpty() bool {
+	return c.Role == "" && strings.TrimSpace(c.Position) == "" && c.DepartmentID == nil
+}
+
+// AssignCourseDTO define el cuerpo de POST /api/v1/admin/courses/:id/enrollments. Se
+// inscribe a los usuarios de UserIDs más los que cumplan Criteria, si se indica.
+type AssignCourseDTO struct {
+	UserIDs  []uint              `json:"user_ids"`
+	Criteria *AssignmentCriteria `json:"criteria"`
+	DueAt    *time.Time          `json:"due_at"`
+}
+
+// AssignmentResult resume una asignación masiva.
+type AssignmentResult struct {
+	Enrolled    int `json:"enrolled"`    // Inscripciones nuevas
+	Reactivated int `json:"reactivated"` // Inscripciones canceladas que se reactivaron
+	Skipped     int `json:"skipped"`     // Usuarios que ya tenían el curso asignado
+}
+
+// EnrollmentFilter define los filtros de GET /api/v1/admin/courses/:id/enrollments.
+type EnrollmentFilter struct {
+	Status   string
+	Page     int
+	PageSize int
+}
+
+// EnrollmentPage es una página de inscripciones.
+type EnrollmentPage struct {
+	Items    []models.Enrollment `json:"items"`
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+}
+
+// CreateAssignmentRuleDTO define el cuerpo de POST /api/v1/admin/assignment-rules.
+type CreateAssignmentRuleDTO struct {
+	CourseID uint `json:"course_id" binding:"required"`
+	AssignmentCriteria
+	DueInDays *int `json:"due_in_days" binding:"omitempty,min=1"`
+	// ApplyToExisting inscribe también a los usuarios que ya cumplen la regla.
+	ApplyToExisting bool `json:"apply_to_existing"`
+}
+
+// UpdateAssignmentRuleDTO define el cuerpo de PUT /api/v1/admin/assignment-rules/:id.
+type UpdateAssignmentRuleDTO struct {
+	Active    *bool `json:"active"`
+	DueInDays *int  `json:"due_in_days" binding:"omitempty,min=0"` // 0 quita el vencimiento
+}
+
+// AssignmentRuleResult es una regla recién creada con el resultado de aplicarla a los
+// usuarios existentes (nil si no se pidió).
+type AssignmentRuleResult struct {
+	Rule    *models.AssignmentRule `json:"rule"`
+	Applied *AssignmentResult      `json:"applied,omitempty"`
+}
+
+// EnrollmentServiceInterface define la asignación de cursos a usuarios.
+type EnrollmentServiceInterface interface {
+	AssignCourse(actor RequestActor, courseID uint, dto AssignCourseDTO) (*AssignmentResult, error)
+	ListCourseEnrollments(courseID uint, filter EnrollmentFilter) (*EnrollmentPage, error)
+	CancelEnrollment(actor RequestActor, id uint) (*models.Enrollment, error)
+	ListMyEnrollments(userID uint) ([]models.Enrollment, error)
+
+	ListRules() ([]models.AssignmentRule, error)
+	CreateRule(actor RequestActor, dto CreateAssignmentRuleDTO) (*AssignmentRuleResult, error)
+	UpdateRule(actor RequestActor, id uint, dto UpdateAssignmentRuleDTO) (*models.AssignmentRule, error)
+	DeleteRule(actor RequestActor, id uint) error
+}
+
+// EnrollmentService implementa EnrollmentServiceInterface.
+type EnrollmentService struct {
+	DB *gorm.DB
+}
+
+// NewEnrollmentService crea una nueva instancia de EnrollmentService.
+func NewEnrollmentService(db *gorm.DB) *EnrollmentService {
+	return &EnrollmentService{DB: db}
+}
+
+// enrollmentRulesActor es el actor de las inscripciones creadas por reglas automáticas.
+var enrollmentRulesActor = RequestActor{Username: "sistema"}
+
+// findAssignableCourse busca un curso y comprueba que esté publicado.
+func findAssignableCourse(db *gorm.DB, courseID uint) (*models.Course, error) {
+	var course models.Course
+	if err := db.First(&course, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errCourseNotFound
+		}
+		log.Printf("Error al buscar curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudo obtener el curso")
+	}
+	if course.Status != models.CoursePublished {
+		return nil, errors.New("solo se pueden asignar cursos publicados")
+	}
+	return &course, nil
+}
+
+// normalizeCriteria valida el rol y limpia los criterios.
+func normalizeCriteria(c AssignmentCriteria) (AssignmentCriteria, models.Role, error) {
+	c.Position = strings.TrimSpace(c.Position)
+	var role models.Role
+	if c.Role != "" {
+		parsed, err := models.ParseRole(c.Role)
+		if err != nil {
+			return c, "", errors.New("rol inválido")
+		}
+		role = parsed
+	}
+	return c, role, nil
+}
+
+// matchingUsersQuery devuelve la consulta de usuarios (no dados de baja) que cumplen los
+// criterios. El puesto se compara con la colación de la base de datos (sin distinguir
+// mayúsculas en MySQL).
+func matchingUsersQuery(db *gorm.DB, role models.Role, position string, departmentID *uint) *gorm.DB {
+	query := db.Model(&models.User{}).
+		Joins("LEFT JOIN employee_details ON employee_details.user_id = users.id AND employee_details.deleted_at IS NULL").
+		Where("users.status <> ?", models.StatusOffboarded)
+	if role != "" {
+		query = query.Where("users.role = ?", role)
+	}
+	if position != "" {
+		query = query.Where("employee_details.position = ?", position)
+	}
+	if departmentID != nil {
+		query = query.Where("employee_details.department_id = ?", *departmentID)
+	}
+	return query
+}
+
+// enrollUsers inscribe a userIDs en el curso. Los usuarios con el curso ya asignado se
+// omiten y los que lo tenían cancelado se reactivan.
+func enrollUsers(tx *gorm.DB, courseID uint, userIDs []uint, template models.Enrollment) (*AssignmentResult, []uint, error) {
+	result := &AssignmentResult{}
+	if len(userIDs) == 0 {
+		return result, nil, nil
+	}
+	var existing []models.Enrollment
+	if err := tx.Where("course_id = ? AND user_id IN ?", courseID, userIDs).Find(&existing).Error; err != nil {
+		return nil, nil, err
+	}
+	byUser := make(map[uint]*models.Enrollment, len(existing))
+	for i := range existing {
+		byUser[existing[i].UserID] = &existing[i]
+	}
+
+	now := time.Now()
+	var created []models.Enrollment
+	var touched []uint
+	for _, userID := range userIDs {
+		current, ok := byUser[userID]
+		if ok && current.Status != models.EnrollmentCancelled {
+			result.Skipped++
+			continue
+		}
+		if ok {
+			if err := tx.Model(current).Updates(map[string]interface{}{
+				"status":         models.EnrollmentAssigned,
+				"source":         template.Source,
+				"rule_id":        template.RuleID,
+				"assigned_by_id": template.AssignedByID,
+				"due_at":         template.DueAt,
+				"assigned_at":    now,
+				"started_at":     nil,
+				"completed_at":   nil,
+				"cancelled_at":   nil,
+			}).Error; err != nil {
+				return nil, nil, err
+			}
+			result.Reactivated++
+			touched = append(touched, userID)
+			continue
+		}
+		enrollment := template
+		enrollment.UserID = userID
+		enrollment.CourseID = courseID
+		enrollment.Status = models.EnrollmentAssigned
+		enrollment.AssignedAt = now
+		created = append(created, enrollment)
+		touched = append(touched, userID)
+	}
+	if len(created) > 0 {
+		if err := tx.CreateInBatches(&created, 500).Error; err != nil {
+			return nil, nil, err
+		}
+		result.Enrolled = len(created)
+	}
+	return result, touched, nil
+}
+
+// dedupeIDs quita los IDs repetidos conservando el orden.
+func dedupeIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// AssignCourse inscribe en un curso publicado a los usuarios indicados y/o a los que
+// cumplan los criterios.
+func (s *EnrollmentService) AssignCourse(actor RequestActor, courseID uint, dto AssignCourseDTO) (*AssignmentResult, error) {
+	if len(dto.UserIDs) == 0 && (dto.Criteria == nil || dto.Criteria.isEmpty()) {
+		return nil, errors.New("indica los usuarios o los criterios de la asignación")
+	}
+	if dto.DueAt != nil && !dto.DueAt.After(time.Now()) {
+		return nil, errors.New("la fecha de vencimiento debe ser futura")
+	}
+
+	tx := s.DB.Begin()
+	if _, err := findAssignableCourse(tx, courseID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var userIDs []uint
+	if len(dto.UserIDs) > 0 {
+		requested := dedupeIDs(dto.UserIDs)
+		if err := matchingUsersQuery(tx, "", "", nil).Where("users.id IN ?", requested).
+			Pluck("users.id", &userIDs).Error; err != nil {
+			tx.Rollback()
+			log.Printf("Error al comprobar los usuarios de la asignación: %v", err)
+			return nil, errors.New("no se pudo asignar el curso")
+		}
+		if len(userIDs) != len(requested) {
+			tx.Rollback()
+			return nil, errors.New("algún usuario no existe o está dado de baja")
+		}
+	}
+	if dto.Criteria != nil && !dto.Criteria.isEmpty() {
+		criteria, role, err := normalizeCriteria(*dto.Criteria)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		var matched []uint
+		if err := matchingUsersQuery(tx, role, criteria.Position, criteria.DepartmentID).
+			Pluck("users.id", &matched).Error; err != nil {
+			tx.Rollback()
+			log.Printf("Error al buscar los usuarios de la asignación: %v", err)
+			return nil, errors.New("no se pudo asignar el curso")
+		}
+		userIDs = dedupeIDs(append(userIDs, matched...))
+	}
+
+	template := models.Enrollment{Source: models.EnrollmentSourceManual, DueAt: dto.DueAt}
+	if actor.UserID != 0 {
+		assignedBy := actor.UserID
+		template.AssignedByID = &assignedBy
+	}
+	result, touched, err := enrollUsers(tx, courseID, userIDs, template)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al inscribir usuarios en el curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudo asignar el curso")
+	}
+	if len(touched) > 0 {
+		if err := recordAudit(tx, actor, auditRecord{
+			Action:     models.AuditActionCourseAssigned,
+			TargetType: models.AuditTargetCourse,
+			TargetID:   courseID,
+			After:      map[string]interface{}{"user_ids": touched, "due_at": dto.DueAt},
+		}); err != nil {
+			tx.Rollback()
+			log.Printf("Error al auditar la asignación del curso %d: %v", courseID, err)
+			return nil, errors.New("no se pudo asignar el curso")
+		}
+	}
+	tx.Commit()
+	return result, nil
+}
+
+// ListCourseEnrollments lista las inscripciones de un curso.
+func (s *EnrollmentService) ListCourseEnrollments(courseID uint, filter EnrollmentFilter) (*EnrollmentPage, error) {
+	switch models.EnrollmentStatus(filter.Status) {
+	case "", models.EnrollmentAssigned, models.EnrollmentInProgress, models.EnrollmentCompleted, models.EnrollmentCancelled:
+	default:
+		return nil, errors.New("estado de inscripción inválido")
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultEnrollmentPageSize
+	}
+	if filter.PageSize > maxEnrollmentPageSize {
+		filter.PageSize = maxEnrollmentPageSize
+	}
+	var count int64
+	if err := s.DB.Model(&models.Course{}).Where("id = ?", courseID).Count(&count).Error; err != nil {
+		log.Printf("Error al buscar curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudieron obtener las inscripciones")
+	}
+	if count == 0 {
+		return nil, errCourseNotFound
+	}
+
+	query := s.DB.Model(&models.Enrollment{}).Where("course_id = ?", courseID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	page := &EnrollmentPage{Items: make([]models.Enrollment, 0), Page: filter.Page, PageSize: filter.PageSize}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		log.Printf("Error al contar inscripciones: %v", err)
+		return nil, errors.New("no se pudieron obtener las inscripciones")
+	}
+	if err := query.Order("id DESC").Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&page.Items).Error; err != nil {
+		log.Printf("Error al listar inscripciones: %v", err)
+		return nil, errors.New("no se pudieron obtener las inscripciones")
+	}
+	return page, nil
+}
+
+// CancelEnrollment retira una asignación que todavía no se completó.
+func (s *EnrollmentService) CancelEnrollment(actor RequestActor, id uint) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	tx := s.DB.Begin()
+	if err := tx.First(&enrollment, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("inscripción no encontrada")
+		}
+		log.Printf("Error al buscar inscripción %d: %v", id, err)
+		return nil, errors.New("no se pudo cancelar la inscripción")
+	}
+	if !enrollment.IsOpen() {
+		tx.Rollback()
+		return nil, errors.New("solo se pueden cancelar inscripciones pendientes")
+	}
+	before := map[string]interface{}{"user_id": enrollment.UserID, "course_id": enrollment.CourseID, "status": enrollment.Status}
+	now := time.Now()
+	if err := tx.Model(&enrollment).Updates(map[string]interface{}{
+		"status":       models.EnrollmentCancelled,
+		"cancelled_at": now,
+	}).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al cancelar inscripción %d: %v", id, err)
+		return nil, errors.New("no se pudo cancelar la inscripción")
+	}
+	enrollment.Status = models.EnrollmentCancelled
+	enrollment.CancelledAt = &now
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionEnrollmentCancelled,
+		TargetType: models.AuditTargetEnrollment,
+		TargetID:   enrollment.ID,
+		Before:     before,
+		After:      map[string]interface{}{"status": enrollment.Status},
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la cancelación de la inscripción %d: %v", id, err)
+		return nil, errors.New("no se pudo cancelar la inscripción")
+	}
+	tx.Commit()
+	return &enrollment, nil
+}
+
+// ListMyEnrollments lista los cursos asignados a un usuario (sin los cancelados), con los
+// pendientes primero y por fecha de vencimiento.
+func (s *EnrollmentService) ListMyEnrollments(userID uint) ([]models.Enrollment, error) {
+	enrollments := make([]models.Enrollment, 0)
+	if err := s.DB.Preload("Course").
+		Where("user_id = ? AND status <> ?", userID, models.EnrollmentCancelled).
+		Order("status = 'completed', due_at IS NULL, due_at, id").
+		Find(&enrollments).Error; err != nil {
+		log.Printf("Error al listar las inscripciones del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudieron obtener tus cursos")
+	}
+	return enrollments, nil
+}
+
+func assignmentRuleSnapshot(rule *models.AssignmentRule) map[string]interface{} {
+	return map[string]interface{}{
+		"course_id":     rule.CourseID,
+		"role":          rule.Role,
+		"position":      rule.Position,
+		"department_id": rule.DepartmentID,
+		"due_in_days":   rule.DueInDays,
+		"active":        rule.Active,
+	}
+}
+
+// ruleDueAt calcula el vencimiento de una inscripción creada ahora por la regla.
+func ruleDueAt(rule *models.AssignmentRule, now time.Time) *time.Time {
+	if rule.DueInDays == nil || *rule.DueInDays <= 0 {
+		return nil
+	}
+	due := now.AddDate(0, 0, *rule.DueInDays)
+	return &due
+}
+
+// ruleEnrollmentTemplate es la inscripción base que crea una regla.
+func ruleEnrollmentTemplate(rule *models.AssignmentRule) models.Enrollment {
+	ruleID := rule.ID
+	return models.Enrollment{
+		Source: models.EnrollmentSourceRule,
+		RuleID: &ruleID,
+		DueAt:  ruleDueAt(rule, time.Now()),
+	}
+}
+
+// ListRules lista las reglas de asignación automática.
+func (s *EnrollmentService) ListRules() ([]models.AssignmentRule, error) {
+	rules := make([]models.AssignmentRule, 0)
+	if err := s.DB.Preload("Course").Order("id").Find(&rules).Error; err != nil {
+		log.Printf("Error al listar reglas de asignación: %v", err)
+		return nil, errors.New("no se pudieron obtener las reglas de asignación")
+	}
+	return rules, nil
+}
+
+// CreateRule crea una regla de asignación automática y, si se pide, la aplica a los
+// usuarios existentes.
+func (s *EnrollmentService) CreateRule(actor RequestActor, dto CreateAssignmentRuleDTO) (*AssignmentRuleResult, error) {
+	if dto.AssignmentCriteria.isEmpty() {
+		return nil, errors.New("la regla necesita al menos un criterio (rol, puesto o departamento)")
+	}
+	criteria, role, err := normalizeCriteria(dto.AssignmentCriteria)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := s.DB.Begin()
+	if _, err := findAssignableCourse(tx, dto.CourseID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	rule := models.AssignmentRule{
+		CourseID:     dto.CourseID,
+		Role:         role,
+		Position:     criteria.Position,
+		DepartmentID: criteria.DepartmentID,
+		DueInDays:    dto.DueInDays,
+		Active:       true,
+	}
+	if actor.UserID != 0 {
+		createdBy := actor.UserID
+		rule.CreatedByID = &createdBy
+	}
+	if err := tx.Create(&rule).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear regla de asignación: %v", err)
+		return nil, errors.New("no se pudo crear la regla de asignación")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionAssignmentRuleCreated,
+		TargetType: models.AuditTargetAssignmentRule,
+		TargetID:   rule.ID,
+		After:      assignmentRuleSnapshot(&rule),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la regla de asignación: %v", err)
+		return nil, errors.New("no se pudo crear la regla de asignación")
+	}
+
+	out := &AssignmentRuleResult{Rule: &rule}
+	if dto.ApplyToExisting {
+		var userIDs []uint
+		if err := matchingUsersQuery(tx, rule.Role, rule.Position, rule.DepartmentID).
+			Pluck("users.id", &userIDs).Error; err != nil {
+			tx.Rollback()
+			log.Printf("Error al buscar los usuarios de la regla %d: %v", rule.ID, err)
+			return nil, errors.New("no se pudo crear la regla de asignación")
+		}
+		applied, touched, err := enrollUsers(tx, rule.CourseID, userIDs, ruleEnrollmentTemplate(&rule))
+		if err != nil {
+			tx.Rollback()
+			log.Printf("Error al aplicar la regla %d: %v", rule.ID, err)
+			return nil, errors.New("no se pudo crear la regla de asignación")
+		}
+		if len(touched) > 0 {
+			if err := recordAudit(tx, actor, auditRecord{
+				Action:     models.AuditActionCourseAssigned,
+				TargetType: models.AuditTargetCourse,
+				TargetID:   rule.CourseID,
+				After:      map[string]interface{}{"user_ids": touched, "rule_id": rule.ID},
+			}); err != nil {
+				tx.Rollback()
+				log.Printf("Error al auditar la aplicación de la regla %d: %v", rule.ID, err)
+				return nil, errors.New("no se pudo crear la regla de asignación")
+			}
+		}
+		out.Applied = applied
+	}
+	tx.Commit()
+	return out, nil
+}
+
+func findAssignmentRule(db *gorm.DB, id uint) (*models.AssignmentRule, error) {
+	var rule models.AssignmentRule
+	if err := db.First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("regla de asignación no encontrada")
+		}
+		log.Printf("Error al buscar regla de asignación %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener la regla de asignación")
+	}
+	return &rule, nil
+}
+
+// UpdateRule activa o desactiva una regla o cambia su plazo. Los criterios no se editan:
+// para cambiarlos se crea otra regla, así cada inscripción sigue apuntando a la regla que
+// realmente la creó.
+func (s *EnrollmentService) UpdateRule(actor RequestActor, id uint, dto UpdateAssignmentRuleDTO) (*models.AssignmentRule, error) {
+	tx := s.DB.Begin()
+	rule, err := findAssignmentRule(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	before := assignmentRuleSnapshot(rule)
+	if dto.Active != nil {
+		rule.Active = *dto.Active
+	}
+	if dto.DueInDays != nil {
+		if *dto.DueInDays == 0 {
+			rule.DueInDays = nil
+		} else {
+			days := *dto.DueInDays
+			rule.DueInDays = &days
+		}
+	}
+	if err := tx.Save(rule).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar regla de asignación %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar la regla de asignación")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionAssignmentRuleUpdated,
+		TargetType: models.AuditTargetAssignmentRule,
+		TargetID:   rule.ID,
+		Before:     before,
+		After:      assignmentRuleSnapshot(rule),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la regla de asignación %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar la regla de asignación")
+	}
+	tx.Commit()
+	return rule, nil
+}
+
+// DeleteRule elimina una regla. Las inscripciones que ya creó se conservan.
+func (s *EnrollmentService) DeleteRule(actor RequestActor, id uint) error {
+	tx := s.DB.Begin()
+	rule, err := findAssignmentRule(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(rule).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar regla de asignación %d: %v", id, err)
+		return errors.New("no se pudo eliminar la regla de asignación")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionAssignmentRuleDeleted,
+		TargetType: models.AuditTargetAssignmentRule,
+		TargetID:   rule.ID,
+		Before:     assignmentRuleSnapshot(rule),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la eliminación de la regla %d: %v", id, err)
+		return errors.New("no se pudo eliminar la regla de asignación")
+	}
+	tx.Commit()
+	return nil
+}
+
+// applyAssignmentRules inscribe a un usuario en los cursos de las reglas activas que cumple.
+func applyAssignmentRules(tx *gorm.DB, userID uint) error {
+	var rules []models.AssignmentRule
+	if err := tx.Joins("JOIN courses ON courses.id = assignment_rules.course_id AND courses.deleted_at IS NULL").
+		Where("assignment_rules.active = ? AND courses.status = ?", true, models.CoursePublished).
+		Order("assignment_rules.id").Find(&rules).Error; err != nil {
+		return err
+	}
+	for i := range rules {
+		rule := &rules[i]
+		var count int64
+		if err := matchingUsersQuery(tx, rule.Role, rule.Position, rule.DepartmentID).
+			Where("users.id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			continue
+		}
+		_, touched, err := enrollUsers(tx, rule.CourseID, []uint{userID}, ruleEnrollmentTemplate(rule))
+		if err != nil {
+			return err
+		}
+		if len(touched) == 0 {
+			continue
+		}
+		if err := recordAudit(tx, enrollmentRulesActor, auditRecord{
+			Action:     models.AuditActionEnrollmentCreated,
+			TargetType: models.AuditTargetUser,
+			TargetID:   userID,
+			After:      map[string]interface{}{"course_id": rule.CourseID, "rule_id": rule.ID},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnrollmentRuleSubscriber aplica las reglas de asignación automática a los usuarios nuevos,
+// tanto los creados por un administrador como los que aceptan una invitación. Como las
+// inscripciones existentes se omiten, reprocesar un evento no duplica nada.
+type EnrollmentRuleSubscriber struct{}
+
+// Name implementa events.Subscriber.
+func (EnrollmentRuleSubscriber) Name() string { return "enrollment_rules" }
+
+// Handles implementa events.Subscriber.
+func (EnrollmentRuleSubscriber) Handles(eventType string) bool {
+	return eventType == models.DomainEventUserCreated
+}
+
+// Handle implementa events.Subscriber.
+func (EnrollmentRuleSubscriber) Handle(tx *gorm.DB, event *models.DomainEvent) error {
+	return applyAssignmentRules(tx, event.AggregateID)
+}
@@ -0,0 +1,607 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/auth"
+	"github.com/Unikyri/yamerito-mvp/internal/config"
+	"github.com/Unikyri/yamerito-mvp/internal/mailer"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// CreateInvitationDTO define el cuerpo de POST /api/v1/admin/invitations.
+// Solo el email es obligatorio; el invitado completa el resto al aceptar.
+type CreateInvitationDTO struct {
+	Email    string  `json:"email" binding:"required,email,max=100"`
+	Role     string  `json:"role" binding:"omitempty,oneof=Admin Employee Instructor"`
+	Username *string `json:"username,omitempty" binding:"omitempty,min=3,max=50"` // Si se omite, lo elige el invitado
+	Name     *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	LastName *string `json:"last_name,omitempty" binding:"omitempty,min=1,max=100"`
+	HireDate *string `json:"hire_date,omitempty"`
+	Language string  `json:"language,omitempty" binding:"omitempty,max=10"` // Idioma del correo (es, en); por defecto el configurado
+}
+
+// AcceptInvitationDTO define el cuerpo de POST /api/v1/invitations/accept.
+type AcceptInvitationDTO struct {
+	Token       string  `json:"token" binding:"required"`
+	Username    *string `json:"username,omitempty" binding:"omitempty,min=3,max=50"` // Obligatorio si la invitación no lo fijó
+	Password    string  `json:"password" binding:"required,min=8,max=72"`
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	LastName    *string `json:"last_name,omitempty" binding:"omitempty,min=1,max=100"`
+	PhoneNumber *string `json:"phone_number,omitempty" binding:"omitempty,max=20"`
+}
+
+// InvitationDTO es la representación de una invitación para los administradores.
+type InvitationDTO struct {
+	models.Invitation
+	Status string `json:"status"`
+}
+
+// InvitationPreviewDTO son los datos que ve el invitado antes de aceptar.
+type InvitationPreviewDTO struct {
+	Email           string    `json:"email"`
+	Name            string    `json:"name,omitempty"`
+	LastName        string    `json:"last_name,omitempty"`
+	Username        string    `json:"username,omitempty"`
+	UsernamePending bool      `json:"username_pending"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// InvitationServiceInterface define el alta de usuarios por invitación.
+type InvitationServiceInterface interface {
+	CreateInvitation(actor RequestActor, dto CreateInvitationDTO) (*InvitationDTO, error)
+	ListInvitations(status string) ([]InvitationDTO, error)
+	ResendInvitation(actor RequestActor, id uint) (*InvitationDTO, error)
+	RevokeInvitation(actor RequestActor, id uint) error
+
+	// Endpoints públicos (el token es la credencial)
+	PreviewInvitation(token string) (*InvitationPreviewDTO, error)
+	AcceptInvitation(actor RequestActor, dto AcceptInvitationDTO) (*UserDetailDTO, error)
+}
+
+// InvitationService implementa InvitationServiceInterface.
+type InvitationService struct {
+	DB        *gorm.DB
+	Outbox    *mailer.Outbox
+	Templates *mailer.Templates
+	Lifecycle *LifecycleService
+	Config    config.InvitationConfig
+}
+
+// NewInvitationService crea una nueva instancia de InvitationService.
+func NewInvitationService(db *gorm.DB, outbox *mailer.Outbox, templates *mailer.Templates, lifecycle *LifecycleService, cfg config.InvitationConfig) *InvitationService {
+	return &InvitationService{DB: db, Outbox: outbox, Templates: templates, Lifecycle: lifecycle, Config: cfg}
+}
+
+// randomHex devuelve n bytes aleatorios codificados en hexadecimal.
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func newInvitationDTO(inv *models.Invitation) *InvitationDTO {
+	return &InvitationDTO{Invitation: *inv, Status: inv.Status(time.Now())}
+}
+
+func invitationAuditSnapshot(inv *models.Invitation) map[string]interface{} {
+	return map[string]interface{}{
+		"invitation_id": inv.ID,
+		"user_id":       inv.UserID,
+		"email":         inv.Email,
+		"expires_at":    inv.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+// CreateInvitation crea el usuario en estado invited (con una contraseña aleatoria que nadie
+// conoce) y la invitación, y deja el correo con el enlace en la bandeja de salida.
+func (s *InvitationService) CreateInvitation(actor RequestActor, dto CreateInvitationDTO) (*InvitationDTO, error) {
+	role := models.RoleEmployee
+	if dto.Role != "" {
+		parsed, err := models.ParseRole(dto.Role)
+		if err != nil {
+			return nil, fmt.Errorf("rol inválido: %s", dto.Role)
+		}
+		role = parsed
+	}
+	email := strings.ToLower(strings.TrimSpace(dto.Email))
+
+	detail := models.EmployeeDetail{Email: email}
+	if dto.Name != nil {
+		detail.Name = *dto.Name
+	}
+	if dto.LastName != nil {
+		detail.LastName = *dto.LastName
+	}
+	if _, _, err := applyEmploymentDates(&detail, dto.HireDate, nil); err != nil {
+		return nil, err
+	}
+
+	username := ""
+	if dto.Username != nil {
+		username = strings.TrimSpace(*dto.Username)
+	}
+	usernamePending := username == ""
+	nonce, err := randomHex(16)
+	if err != nil {
+		return nil, errors.New("no se pudo crear la invitación")
+	}
+	if usernamePending {
+		// Marcador único hasta que el invitado elija su nombre de usuario.
+		suffix, err := randomHex(6)
+		if err != nil {
+			return nil, errors.New("no se pudo crear la invitación")
+		}
+		username = "invitado-" + suffix
+	}
+	unusablePassword, err := randomHex(32)
+	if err != nil {
+		return nil, errors.New("no se pudo crear la invitación")
+	}
+	passwordHash, err := auth.HashPassword(unusablePassword, nil)
+	if err != nil {
+		log.Printf("Error al generar contraseña provisional para invitación: %v", err)
+		return nil, errors.New("no se pudo crear la invitación")
+	}
+
+	user := models.User{
+		Username:       username,
+		PasswordHash:   passwordHash,
+		Role:           role,
+		Status:         models.StatusInvited,
+		EmployeeDetail: detail,
+	}
+
+	tx := s.DB.Begin()
+	var taken int64
+	if err := tx.Unscoped().Model(&models.EmployeeDetail{}).Where("email = ?", email).Count(&taken).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al verificar email de invitación: %v", err)
+		return nil, errors.New("no se pudo crear la invitación")
+	}
+	if taken > 0 {
+		tx.Rollback()
+		return nil, errors.New("ya existe un usuario con ese email")
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		tx.Rollback()
+		if isDuplicateKeyError(err) {
+			return nil, errors.New("el nombre de usuario ya está en uso")
+		}
+		log.Printf("Error al crear usuario invitado: %v", err)
+		return nil, errors.New("no se pudo crear la invitación")
+	}
+
+	invitation := models.Invitation{
+		UserID:          user.ID,
+		Email:           email,
+		Nonce:           nonce,
+		UsernamePending: usernamePending,
+		Language:        mailer.NormalizeLanguage(dto.Language, s.Templates.DefaultLanguage()),
+		ExpiresAt:       time.Now().Add(s.Config.TTL),
+	}
+	if actor.UserID != 0 {
+		createdBy := actor.UserID
+		invitation.CreatedByID = &createdBy
+	}
+	if err := tx.Create(&invitation).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear invitación para '%s': %v", email, err)
+		return nil, errors.New("no se pudo crear la invitación")
+	}
+	if err := s.enqueueInvitationEmail(tx, &invitation, &user.EmployeeDetail); err != nil {
+		tx.Rollback()
+		log.Printf("Error al encolar el correo de la invitación para '%s': %v", email, err)
+		return nil, errors.New("no se pudo crear la invitación")
+	}
+
+	if err := recordUserHistory(tx, actor, &user, models.AuditActionUserCreated, false); err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar historial del usuario invitado: %v", err)
+		return nil, errors.New("no se pudo crear la invitación")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionUserCreated,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		After:      fullUserAuditSnapshot(tx, &user),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar creación del usuario invitado: %v", err)
+		return nil, errors.New("no se pudo crear la invitación")
+	}
+	if err := recordUserEvent(tx, actor, models.DomainEventUserCreated, &user, nil); err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar el evento del usuario invitado: %v", err)
+		return nil, errors.New("no se pudo crear la invitación")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionInvitationCreated,
+		TargetType: models.AuditTargetInvitation,
+		TargetID:   invitation.ID,
+		After:      invitationAuditSnapshot(&invitation),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar invitación para '%s': %v", email, err)
+		return nil, errors.New("no se pudo crear la invitación")
+	}
+	tx.Commit()
+
+	return newInvitationDTO(&invitation), nil
+}
+
+// invitationEmailData son los datos que reciben las plantillas "invitation".
+type invitationEmailData struct {
+	Name      string
+	Link      string
+	ExpiresAt time.Time
+}
+
+// enqueueInvitationEmail firma el enlace y deja el correo en la bandeja de salida dentro
+// de tx: si la transacción se deshace, el correo no se envía.
+func (s *InvitationService) enqueueInvitationEmail(tx *gorm.DB, inv *models.Invitation, detail *models.EmployeeDetail) error {
+	token, err := auth.GenerateInvitationToken(inv.ID, inv.Nonce, inv.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("no se pudo firmar la invitación: %w", err)
+	}
+	data := invitationEmailData{
+		Link:      s.Config.AcceptURL + "?token=" + url.QueryEscape(token),
+		ExpiresAt: inv.ExpiresAt,
+	}
+	if detail != nil {
+		data.Name = detail.Name
+	}
+	msg, err := s.Templates.Render("invitation", inv.Language, data)
+	if err != nil {
+		return err
+	}
+	msg.To = []string{inv.Email}
+	if _, err := s.Outbox.Enqueue(tx, msg); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tx.Model(inv).Updates(map[string]interface{}{
+		"sent_count":   gorm.Expr("sent_count + 1"),
+		"last_sent_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	inv.SentCount++
+	inv.LastSentAt = &now
+	return nil
+}
+
+// ListInvitations lista las invitaciones, opcionalmente filtradas por estado.
+func (s *InvitationService) ListInvitations(status string) ([]InvitationDTO, error) {
+	now := time.Now()
+	query := s.DB.Model(&models.Invitation{})
+	switch status {
+	case "":
+	case models.InvitationAccepted:
+		query = query.Where("accepted_at IS NOT NULL")
+	case models.InvitationRevoked:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NOT NULL")
+	case models.InvitationExpired:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at <= ?", now)
+	case models.InvitationPending:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+	default:
+		return nil, errors.New("estado de invitación inválido")
+	}
+
+	var invitations []models.Invitation
+	if err := query.Order("created_at DESC, id DESC").Find(&invitations).Error; err != nil {
+		log.Printf("Error al listar invitaciones: %v", err)
+		return nil, errors.New("no se pudieron obtener las invitaciones")
+	}
+	dtos := make([]InvitationDTO, 0, len(invitations))
+	for i := range invitations {
+		dtos = append(dtos, InvitationDTO{Invitation: invitations[i], Status: invitations[i].Status(now)})
+	}
+	return dtos, nil
+}
+
+// findOpenInvitation busca una invitación que todavía pueda reenviarse o revocarse
+// (no aceptada ni revocada; puede estar vencida).
+func findOpenInvitation(tx *gorm.DB, id uint) (*models.Invitation, error) {
+	var inv models.Invitation
+	if err := tx.First(&inv, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invitación no encontrada")
+		}
+		return nil, err
+	}
+	if inv.AcceptedAt != nil || inv.RevokedAt != nil {
+		return nil, errors.New("la invitación ya no está pendiente")
+	}
+	return &inv, nil
+}
+
+// ResendInvitation genera un enlace nuevo con una vigencia completa y lo encola para su envío.
+// El enlace anterior deja de ser válido.
+func (s *InvitationService) ResendInvitation(actor RequestActor, id uint) (*InvitationDTO, error) {
+	nonce, err := randomHex(16)
+	if err != nil {
+		return nil, errors.New("no se pudo reenviar la invitación")
+	}
+
+	tx := s.DB.Begin()
+	inv, err := findOpenInvitation(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, invitationFailure("reenviar la invitación", err)
+	}
+	inv.Nonce = nonce
+	inv.ExpiresAt = time.Now().Add(s.Config.TTL)
+	result := tx.Model(inv).Where("accepted_at IS NULL AND revoked_at IS NULL").
+		Updates(map[string]interface{}{"nonce": inv.Nonce, "expires_at": inv.ExpiresAt})
+	if result.Error != nil || result.RowsAffected == 0 {
+		tx.Rollback()
+		if result.Error != nil {
+			log.Printf("Error al renovar la invitación %d: %v", id, result.Error)
+			return nil, errors.New("no se pudo reenviar la invitación")
+		}
+		return nil, errors.New("la invitación ya no está pendiente")
+	}
+	var detail models.EmployeeDetail
+	if err := tx.Where("user_id = ?", inv.UserID).Limit(1).Find(&detail).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al buscar el perfil de la invitación %d: %v", id, err)
+		return nil, errors.New("no se pudo reenviar la invitación")
+	}
+	if err := s.enqueueInvitationEmail(tx, inv, &detail); err != nil {
+		tx.Rollback()
+		log.Printf("Error al encolar el correo de la invitación %d: %v", id, err)
+		return nil, errors.New("no se pudo reenviar la invitación")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionInvitationResent,
+		TargetType: models.AuditTargetInvitation,
+		TargetID:   inv.ID,
+		After:      invitationAuditSnapshot(inv),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar reenvío de la invitación %d: %v", id, err)
+		return nil, errors.New("no se pudo reenviar la invitación")
+	}
+	tx.Commit()
+
+	return newInvitationDTO(inv), nil
+}
+
+// RevokeInvitation anula la invitación y da de baja la cuenta invitada.
+func (s *InvitationService) RevokeInvitation(actor RequestActor, id uint) error {
+	tx := s.DB.Begin()
+	inv, err := findOpenInvitation(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return invitationFailure("revocar la invitación", err)
+	}
+	now := time.Now()
+	result := tx.Model(inv).Where("accepted_at IS NULL AND revoked_at IS NULL").Update("revoked_at", now)
+	if result.Error != nil {
+		tx.Rollback()
+		log.Printf("Error al revocar la invitación %d: %v", id, result.Error)
+		return errors.New("no se pudo revocar la invitación")
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return errors.New("la invitación ya no está pendiente")
+	}
+
+	var user models.User
+	if err := tx.Preload("EmployeeDetail").First(&user, inv.UserID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		tx.Rollback()
+		log.Printf("Error al buscar el usuario de la invitación %d: %v", id, err)
+		return errors.New("no se pudo revocar la invitación")
+	}
+	if user.ID != 0 && user.Status == models.StatusInvited {
+		if err := s.Lifecycle.transitionUser(tx, actor, &user, models.StatusOffboarded, "invitación revocada"); err != nil {
+			tx.Rollback()
+			log.Printf("Error al dar de baja la cuenta de la invitación %d: %v", id, err)
+			return errors.New("no se pudo revocar la invitación")
+		}
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionInvitationRevoked,
+		TargetType: models.AuditTargetInvitation,
+		TargetID:   inv.ID,
+		Before:     invitationAuditSnapshot(inv),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar revocación de la invitación %d: %v", id, err)
+		return errors.New("no se pudo revocar la invitación")
+	}
+	tx.Commit()
+	return nil
+}
+
+// invitationFailure deja pasar los errores esperados de invitaciones y registra los de BD.
+func invitationFailure(operation string, err error) error {
+	switch err.Error() {
+	case "invitación no encontrada", "la invitación ya no está pendiente":
+		return err
+	}
+	log.Printf("Error al %s: %v", operation, err)
+	return fmt.Errorf("no se pudo %s", operation)
+}
+
+// resolveInvitationToken valida el token y devuelve la invitación si sigue pendiente.
+func resolveInvitationToken(db *gorm.DB, token string) (*models.Invitation, error) {
+	claims, err := auth.ValidateInvitationToken(token)
+	if err != nil {
+		return nil, err
+	}
+	var inv models.Invitation
+	if err := db.Where("id = ?", claims.InvitationID).Limit(1).Find(&inv).Error; err != nil {
+		log.Printf("Error al buscar la invitación %d: %v", claims.InvitationID, err)
+		return nil, errors.New("no se pudo procesar la invitación")
+	}
+	if inv.ID == 0 || subtle.ConstantTimeCompare([]byte(inv.Nonce), []byte(claims.Nonce)) != 1 {
+		// Nonce distinto: el enlace fue reemplazado por un reenvío posterior.
+		return nil, errors.New("invitación inválida")
+	}
+	switch inv.Status(time.Now()) {
+	case models.InvitationAccepted:
+		return nil, errors.New("la invitación ya fue utilizada")
+	case models.InvitationRevoked:
+		return nil, errors.New("la invitación fue revocada")
+	case models.InvitationExpired:
+		return nil, errors.New("la invitación expiró")
+	}
+	return &inv, nil
+}
+
+// PreviewInvitation devuelve los datos precargados de una invitación pendiente.
+func (s *InvitationService) PreviewInvitation(token string) (*InvitationPreviewDTO, error) {
+	inv, err := resolveInvitationToken(s.DB, token)
+	if err != nil {
+		return nil, err
+	}
+	var user models.User
+	if err := s.DB.Preload("EmployeeDetail").First(&user, inv.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invitación inválida")
+		}
+		log.Printf("Error al buscar el usuario de la invitación %d: %v", inv.ID, err)
+		return nil, errors.New("no se pudo procesar la invitación")
+	}
+	preview := &InvitationPreviewDTO{
+		Email:           inv.Email,
+		Name:            user.EmployeeDetail.Name,
+		LastName:        user.EmployeeDetail.LastName,
+		UsernamePending: inv.UsernamePending,
+		ExpiresAt:       inv.ExpiresAt,
+	}
+	if !inv.UsernamePending {
+		preview.Username = user.Username
+	}
+	return preview, nil
+}
+
+// AcceptInvitation fija la contraseña (y el nombre de usuario si estaba pendiente), completa
+// el perfil y activa la cuenta. Si la fecha de ingreso es futura, la cuenta sigue invitada
+// y se activará ese día.
+func (s *InvitationService) AcceptInvitation(actor RequestActor, dto AcceptInvitationDTO) (*UserDetailDTO, error) {
+	inv, err := resolveInvitationToken(s.DB, dto.Token)
+	if err != nil {
+		return nil, err
+	}
+	username := ""
+	if dto.Username != nil {
+		username = strings.TrimSpace(*dto.Username)
+	}
+	if inv.UsernamePending && username == "" {
+		return nil, errors.New("debe elegir un nombre de usuario")
+	}
+	passwordHash, err := auth.HashPassword(dto.Password, nil)
+	if err != nil {
+		log.Printf("Error al hashear contraseña al aceptar la invitación %d: %v", inv.ID, err)
+		return nil, errors.New("error interno al procesar la contraseña")
+	}
+
+	tx := s.DB.Begin()
+	now := time.Now()
+	// Reclamar la invitación: si otra solicitud la aceptó o revocó antes, no afecta filas.
+	claim := tx.Model(&models.Invitation{}).
+		Where("id = ? AND nonce = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", inv.ID, inv.Nonce, now).
+		Update("accepted_at", now)
+	if claim.Error != nil {
+		tx.Rollback()
+		log.Printf("Error al aceptar la invitación %d: %v", inv.ID, claim.Error)
+		return nil, errors.New("no se pudo procesar la invitación")
+	}
+	if claim.RowsAffected == 0 {
+		tx.Rollback()
+		return nil, errors.New("la invitación ya fue utilizada")
+	}
+
+	var user models.User
+	if err := tx.Preload("EmployeeDetail").First(&user, inv.UserID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invitación inválida")
+		}
+		log.Printf("Error al buscar el usuario de la invitación %d: %v", inv.ID, err)
+		return nil, errors.New("no se pudo procesar la invitación")
+	}
+	if user.Status != models.StatusInvited {
+		tx.Rollback()
+		return nil, errors.New("invitación inválida")
+	}
+
+	if inv.UsernamePending {
+		user.Username = username
+	}
+	user.PasswordHash = passwordHash
+	detailChanged := false
+	for _, f := range []struct {
+		target *string
+		value  *string
+	}{
+		{&user.EmployeeDetail.Name, dto.Name},
+		{&user.EmployeeDetail.LastName, dto.LastName},
+		{&user.EmployeeDetail.PhoneNumber, dto.PhoneNumber},
+	} {
+		if f.value != nil && *f.target != *f.value {
+			*f.target = *f.value
+			detailChanged = true
+		}
+	}
+	if err := saveUserVersioned(tx, &user, true, detailChanged); err != nil {
+		tx.Rollback()
+		if isDuplicateKeyError(err) {
+			return nil, errors.New("el nombre de usuario ya está en uso")
+		}
+		log.Printf("Error al guardar el usuario de la invitación %d: %v", inv.ID, err)
+		return nil, errors.New("no se pudo procesar la invitación")
+	}
+
+	// Desde aquí el actor es el propio invitado.
+	actor.UserID = user.ID
+	actor.Username = user.Username
+
+	hireDate := user.EmployeeDetail.HireDate
+	if hireDate == nil || !hireDate.After(now) {
+		err = s.Lifecycle.transitionUser(tx, actor, &user, models.StatusActive, "invitación aceptada")
+	} else {
+		err = scheduleDateTransitions(tx, actor, &user, true, false)
+		if err == nil {
+			err = recordUserHistory(tx, actor, &user, models.AuditActionInvitationAccepted, false)
+		}
+		if err == nil {
+			err = recordUserEvent(tx, actor, models.DomainEventUserUpdated, &user, nil)
+		}
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al activar la cuenta de la invitación %d: %v", inv.ID, err)
+		return nil, errors.New("no se pudo procesar la invitación")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionInvitationAccepted,
+		TargetType: models.AuditTargetInvitation,
+		TargetID:   inv.ID,
+		After:      map[string]interface{}{"user_id": user.ID, "username": user.Username, "status": string(user.Status)},
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar aceptación de la invitación %d: %v", inv.ID, err)
+		return nil, errors.New("no se pudo procesar la invitación")
+	}
+	tx.Commit()
+
+	log.Printf("Invitación %d aceptada por el usuario '%s'.", inv.ID, user.Username)
+	return newUserDetailDTO(&user), nil
+}
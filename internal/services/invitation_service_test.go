@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Unikyri/yamerito-mvp/internal/auth"
+)
+
+// Tras un reenvío solo vale el enlace con el nonce guardado en la invitación.
+func TestResolveInvitationTokenNonce(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "clave-de-prueba")
+	expiresAt := time.Now().Add(72 * time.Hour)
+	tests := []struct {
+		name    string
+		nonce   string
+		wantErr string
+	}{
+		{"enlace vigente", "nonce-2", ""},
+		{"enlace reemplazado", "nonce-1", "invitación inválida"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := auth.GenerateInvitationToken(15, tt.nonce, expiresAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			db, mock := newMockDB(t)
+			mock.ExpectQuery("SELECT \\* FROM `invitations` WHERE id = \\? LIMIT \\?").
+				WithArgs(15, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "email", "nonce", "expires_at"}).
+					AddRow(15, 7, "ana@example.com", "nonce-2", expiresAt))
+
+			inv, err := resolveInvitationToken(db, token)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("resolveInvitationToken() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("resolveInvitationToken() error = %v, se esperaba %q", err, tt.wantErr)
+			case tt.wantErr == "" && inv.ID != 15:
+				t.Errorf("invitación = %+v, se esperaba la 15", inv)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// itemStatsMinResponses es el mínimo de intentos con la pregunta para calcular su índice de
+// discriminación; con menos, los grupos superior e inferior no son representativos.
+const itemStatsMinResponses = 10
+
+// itemStatsGroupFraction es la fracción de intentos de cada grupo extremo (el 27 % clásico).
+const itemStatsGroupFraction = 0.27
+
+// itemStatsChunkSize limita los IDs por consulta al cargar respuestas.
+const itemStatsChunkSize = 1000
+
+// ItemStat son las estadísticas de una pregunta calculadas sobre los intentos calificados
+// que la incluyeron (una pregunta sin responder cuenta como incorrecta).
+type ItemStat struct {
+	QuestionID uint                      `json:"question_id"`
+	Prompt     string                    `json:"prompt"`
+	Type       models.QuestionType       `json:"type"`
+	Topic      string                    `json:"topic,omitempty"`
+	Difficulty models.QuestionDifficulty `json:"difficulty"`
+	Deleted    bool                      `json:"deleted"`
+	Responses  int                       `json:"responses"`
+	Correct    int                       `json:"correct"`
+	// Facility es la proporción de aciertos (0-1): cuanto más alta, más fácil la pregunta.
+	Facility *float64 `json:"facility,omitempty"`
+	// Discrimination es la diferencia de aciertos entre el 27 % de intentos con mejor nota y
+	// el 27 % con peor (-1 a 1): valores bajos o negativos señalan preguntas a revisar.
+	Discrimination *float64 `json:"discrimination,omitempty"`
+}
+
+type itemObservation struct {
+	percent int
+	correct bool
+}
+
+func roundStat(value float64) *float64 {
+	rounded := math.Round(value*1000) / 1000
+	return &rounded
+}
+
+// discriminationIndex calcula el índice de discriminación por grupos extremos. Los empates de
+// nota conservan el orden de observations, para que el reparto entre grupos sea estable.
+func discriminationIndex(observations []itemObservation) *float64 {
+	n := len(observations)
+	if n < itemStatsMinResponses {
+		return nil
+	}
+	sorted := append([]itemObservation(nil), observations...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].percent > sorted[j].percent })
+	group := int(math.Ceil(float64(n) * itemStatsGroupFraction))
+	upper, lower := 0, 0
+	for i := 0; i < group; i++ {
+		if sorted[i].correct {
+			upper++
+		}
+		if sorted[n-1-i].correct {
+			lower++
+		}
+	}
+	return roundStat(float64(upper-lower) / float64(group))
+}
+
+// summarizeItem completa los recuentos, la facilidad y la discriminación de stat a partir
+// de las observaciones de la pregunta.
+func summarizeItem(stat *ItemStat, observations []itemObservation) {
+	stat.Responses = len(observations)
+	stat.Correct = 0
+	for _, o := range observations {
+		if o.correct {
+			stat.Correct++
+		}
+	}
+	if stat.Responses > 0 {
+		stat.Facility = roundStat(float64(stat.Correct) / float64(stat.Responses))
+	}
+	stat.Discrimination = discriminationIndex(observations)
+}
+
+// computeItemStats calcula las estadísticas por pregunta de los intentos calificados de
+// attempts (una consulta ya filtrada sobre quiz_attempts). Si only no es nil, se limita a
+// esas preguntas. La nota de referencia de cada intento es su porcentaje total.
+func computeItemStats(db, attempts *gorm.DB, only map[uint]bool) ([]ItemStat, error) {
+	var graded []models.QuizAttempt
+	if err := attempts.Model(&models.QuizAttempt{}).Select("id, percent, question_ids").
+		Where("status IN ?", []string{models.AttemptSubmitted, models.AttemptExpired}).
+		Order("id").Find(&graded).Error; err != nil {
+		return nil, err
+	}
+
+	type inclusion struct {
+		attemptID uint
+		percent   int
+	}
+	included := make(map[uint][]inclusion)
+	attemptIDs := make([]uint, 0, len(graded))
+	for i := range graded {
+		attemptIDs = append(attemptIDs, graded[i].ID)
+		for _, questionID := range graded[i].QuestionIDList() {
+			if only == nil || only[questionID] {
+				included[questionID] = append(included[questionID], inclusion{graded[i].ID, graded[i].Percent})
+			}
+		}
+	}
+
+	type answerKey struct{ attemptID, questionID uint }
+	correct := make(map[answerKey]bool)
+	for start := 0; start < len(attemptIDs); start += itemStatsChunkSize {
+		end := start + itemStatsChunkSize
+		if end > len(attemptIDs) {
+			end = len(attemptIDs)
+		}
+		var answers []models.QuizAttemptAnswer
+		if err := db.Select("attempt_id, question_id").
+			Where("attempt_id IN ? AND correct = ?", attemptIDs[start:end], true).
+			Find(&answers).Error; err != nil {
+			return nil, err
+		}
+		for _, answer := range answers {
+			correct[answerKey{answer.AttemptID, answer.QuestionID}] = true
+		}
+	}
+
+	questionIDs := make([]uint, 0, len(included)+len(only))
+	for id := range included {
+		questionIDs = append(questionIDs, id)
+	}
+	for id := range only {
+		if _, ok := included[id]; !ok {
+			questionIDs = append(questionIDs, id)
+		}
+	}
+	var questions []models.Question
+	if len(questionIDs) > 0 {
+		if err := db.Unscoped().Where("id IN ?", questionIDs).Order("id").Find(&questions).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	stats := make([]ItemStat, 0, len(questions))
+	for _, question := range questions {
+		stat := ItemStat{
+			QuestionID: question.ID,
+			Prompt:     question.Prompt,
+			Type:       question.Type,
+			Topic:      question.Topic,
+			Difficulty: question.Difficulty,
+			Deleted:    question.DeletedAt.Valid,
+		}
+		observations := make([]itemObservation, 0, len(included[question.ID]))
+		for _, inc := range included[question.ID] {
+			observations = append(observations, itemObservation{percent: inc.percent, correct: correct[answerKey{inc.attemptID, question.ID}]})
+		}
+		summarizeItem(&stat, observations)
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// GetQuizItemStats calcula las estadísticas de las preguntas (propias y sorteadas de bancos)
+// que aparecieron en los intentos calificados del cuestionario.
+func (s *QuizService) GetQuizItemStats(actor RequestActor, quizID uint) ([]ItemStat, error) {
+	if _, _, err := findManagedQuiz(s.DB, actor, quizID, false); err != nil {
+		return nil, err
+	}
+	stats, err := computeItemStats(s.DB, s.DB.Where("quiz_id = ?", quizID), nil)
+	if err != nil {
+		log.Printf("Error al calcular las estadísticas del cuestionario %d: %v", quizID, err)
+		return nil, errors.New("no se pudieron calcular las estadísticas")
+	}
+	return stats, nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import "testing"
+
+// obs arma observaciones a partir de notas y aciertos en paralelo.
+func obs(percents []int, correct string) []itemObservation {
+	out := make([]itemObservation, len(percents))
+	for i, p := range percents {
+		out[i] = itemObservation{percent: p, correct: correct[i] == '1'}
+	}
+	return out
+}
+
+func TestSummarizeItem(t *testing.T) {
+	tenPercents := []int{100, 90, 80, 70, 60, 50, 40, 30, 20, 10}
+	tests := []struct {
+		name             string
+		observations     []itemObservation
+		wantCorrect      int
+		wantFacility     *float64
+		wantDiscriminate *float64
+	}{
+		{
+			// n=10 → grupos de ceil(2,7)=3. Superior (100,90,80): 1,1,0 → 2.
+			// Inferior (10,20,30): 0,1,0 → 1. (2-1)/3 = 0,333. Facilidad 5/10.
+			name:             "reparto 27 % calculado a mano",
+			observations:     obs(tenPercents, "1101100010"),
+			wantCorrect:      5,
+			wantFacility:     roundStat(0.5),
+			wantDiscriminate: roundStat(1.0 / 3),
+		},
+		{
+			// n=12 → grupos de ceil(3,24)=4. Superior: 1,1,1,1 → 4. Inferior: 0,0,0,1 → 1.
+			// (4-1)/4 = 0,75. Facilidad 6/12.
+			name:             "doce intentos",
+			observations:     obs([]int{95, 90, 85, 80, 75, 70, 65, 60, 55, 50, 45, 40}, "111100011000"),
+			wantCorrect:      6,
+			wantFacility:     roundStat(0.5),
+			wantDiscriminate: roundStat(0.75),
+		},
+		{
+			name:             "discriminación perfecta",
+			observations:     obs(tenPercents, "1110000000"),
+			wantCorrect:      3,
+			wantFacility:     roundStat(0.3),
+			wantDiscriminate: roundStat(1),
+		},
+		{
+			name:             "discriminación negativa",
+			observations:     obs(tenPercents, "0000000111"),
+			wantCorrect:      3,
+			wantFacility:     roundStat(0.3),
+			wantDiscriminate: roundStat(-1),
+		},
+		{
+			name:             "todos aciertan",
+			observations:     obs(tenPercents, "1111111111"),
+			wantCorrect:      10,
+			wantFacility:     roundStat(1),
+			wantDiscriminate: roundStat(0),
+		},
+		{
+			// Empates en el corte: entre las notas iguales, entran al grupo superior las
+			// primeras en el orden recibido. Superior: 80,80,80 (1,0,1) → 2.
+			// Inferior: 20,20,50 (0,0,1) → 1. (2-1)/3 = 0,333.
+			name:             "empates en el corte",
+			observations:     obs([]int{80, 80, 80, 80, 50, 50, 50, 50, 20, 20}, "1011000100"),
+			wantCorrect:      4,
+			wantFacility:     roundStat(0.4),
+			wantDiscriminate: roundStat(1.0 / 3),
+		},
+		{
+			// Sin diferencias de nota, los grupos son los tres primeros (1,1,1) y los tres
+			// últimos (0,0,1) recibidos: (3-1)/3 = 0,667.
+			name:             "todas las notas iguales",
+			observations:     obs([]int{60, 60, 60, 60, 60, 60, 60, 60, 60, 60}, "1110000001"),
+			wantCorrect:      4,
+			wantFacility:     roundStat(0.4),
+			wantDiscriminate: roundStat(2.0 / 3),
+		},
+		{
+			name:         "menos intentos que el mínimo",
+			observations: obs(tenPercents[:itemStatsMinResponses-1], "111000111"),
+			wantCorrect:  6,
+			wantFacility: roundStat(2.0 / 3),
+		},
+		{
+			name: "sin intentos",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stat ItemStat
+			summarizeItem(&stat, tt.observations)
+			if stat.Responses != len(tt.observations) {
+				t.Errorf("responses = %d, se esperaba %d", stat.Responses, len(tt.observations))
+			}
+			if stat.Correct != tt.wantCorrect {
+				t.Errorf("correct = %d, se esperaba %d", stat.Correct, tt.wantCorrect)
+			}
+			assertStat(t, "facility", stat.Facility, tt.wantFacility)
+			assertStat(t, "discrimination", stat.Discrimination, tt.wantDiscriminate)
+		})
+	}
+}
+
+func assertStat(t *testing.T, name string, got, want *float64) {
+	t.Helper()
+	switch {
+	case want == nil && got != nil:
+		t.Errorf("%s = %v, se esperaba nil", name, *got)
+	case want != nil && got == nil:
+		t.Errorf("%s = nil, se esperaba %v", name, *want)
+	case want != nil && *got != *want:
+		t.Errorf("%s = %v, se esperaba %v", name, *got, *want)
+	}
+}
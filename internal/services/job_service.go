@@ -0,0 +1,241 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultJobPageSize = 50
+	maxJobPageSize     = 200
+)
+
+// JobFilter define los filtros de GET /api/v1/admin/jobs.
+type JobFilter struct {
+	Status   string
+	Type     string
+	Page     int
+	PageSize int
+}
+
+// JobPage es una página de trabajos.
+type JobPage struct {
+	Items    []models.Job `json:"items"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
+
+// JobStat es el número de trabajos de un tipo en un estado.
+type JobStat struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// UpdateJobScheduleDTO define el cuerpo de PUT /api/v1/admin/jobs/schedules/:id.
+type UpdateJobScheduleDTO struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// JobServiceInterface define la consulta y administración de los trabajos en segundo plano.
+type JobServiceInterface interface {
+	ListJobs(filter JobFilter) (*JobPage, error)
+	GetJob(id uint) (*models.Job, error)
+	JobStats() ([]JobStat, error)
+	RetryJob(actor RequestActor, id uint) (*models.Job, error)
+	CancelJob(actor RequestActor, id uint) (*models.Job, error)
+
+	ListSchedules() ([]models.JobSchedule, error)
+	UpdateSchedule(actor RequestActor, id uint, dto UpdateJobScheduleDTO) (*models.JobSchedule, error)
+}
+
+// JobService implementa JobServiceInterface.
+type JobService struct {
+	DB *gorm.DB
+}
+
+// NewJobService crea una nueva instancia de JobService.
+func NewJobService(db *gorm.DB) *JobService {
+	return &JobService{DB: db}
+}
+
+func jobAuditSnapshot(job *models.Job) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     job.Type,
+		"status":   job.Status,
+		"attempts": job.Attempts,
+	}
+}
+
+// ListJobs lista los trabajos, del más reciente al más antiguo.
+func (s *JobService) ListJobs(filter JobFilter) (*JobPage, error) {
+	switch filter.Status {
+	case "", models.JobQueued, models.JobRunning, models.JobSucceeded, models.JobFailed, models.JobCancelled:
+	default:
+		return nil, errors.New("estado de trabajo inválido")
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultJobPageSize
+	}
+	if filter.PageSize > maxJobPageSize {
+		filter.PageSize = maxJobPageSize
+	}
+
+	query := s.DB.Model(&models.Job{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	page := &JobPage{Page: filter.Page, PageSize: filter.PageSize}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		log.Printf("Error al contar trabajos: %v", err)
+		return nil, errors.New("no se pudieron obtener los trabajos")
+	}
+	if err := query.Order("id DESC").Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&page.Items).Error; err != nil {
+		log.Printf("Error al listar trabajos: %v", err)
+		return nil, errors.New("no se pudieron obtener los trabajos")
+	}
+	return page, nil
+}
+
+func findJob(db *gorm.DB, id uint) (*models.Job, error) {
+	var job models.Job
+	if err := db.First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("trabajo no encontrado")
+		}
+		log.Printf("Error al buscar trabajo %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener el trabajo")
+	}
+	return &job, nil
+}
+
+// GetJob devuelve un trabajo.
+func (s *JobService) GetJob(id uint) (*models.Job, error) {
+	return findJob(s.DB, id)
+}
+
+// JobStats cuenta los trabajos por tipo y estado.
+func (s *JobService) JobStats() ([]JobStat, error) {
+	stats := make([]JobStat, 0)
+	if err := s.DB.Model(&models.Job{}).Select("type, status, COUNT(*) AS count").
+		Group("type, status").Order("type, status").Scan(&stats).Error; err != nil {
+		log.Printf("Error al calcular estadísticas de trabajos: %v", err)
+		return nil, errors.New("no se pudieron obtener las estadísticas de trabajos")
+	}
+	return stats, nil
+}
+
+// changeJobStatus mueve un trabajo de uno de los estados from a un nuevo estado y lo audita.
+func (s *JobService) changeJobStatus(actor RequestActor, id uint, from []string, updates map[string]interface{}, action, conflict string) (*models.Job, error) {
+	tx := s.DB.Begin()
+	job, err := findJob(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	before := jobAuditSnapshot(job)
+	result := tx.Model(&models.Job{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if result.Error != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar el trabajo %d: %v", id, result.Error)
+		return nil, errors.New("no se pudo actualizar el trabajo")
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return nil, errors.New(conflict)
+	}
+	if err := tx.First(job, id).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("no se pudo actualizar el trabajo")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     action,
+		TargetType: models.AuditTargetJob,
+		TargetID:   job.ID,
+		Before:     before,
+		After:      jobAuditSnapshot(job),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar el cambio del trabajo %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el trabajo")
+	}
+	tx.Commit()
+	return job, nil
+}
+
+// RetryJob vuelve a encolar un trabajo fallido o cancelado con los intentos a cero.
+func (s *JobService) RetryJob(actor RequestActor, id uint) (*models.Job, error) {
+	return s.changeJobStatus(actor, id, []string{models.JobFailed, models.JobCancelled}, map[string]interface{}{
+		"status":      models.JobQueued,
+		"attempts":    0,
+		"run_at":      time.Now(),
+		"finished_at": nil,
+	}, models.AuditActionJobRetried, "solo se pueden reintentar trabajos fallidos o cancelados")
+}
+
+// CancelJob cancela un trabajo que todavía no empezó.
+func (s *JobService) CancelJob(actor RequestActor, id uint) (*models.Job, error) {
+	return s.changeJobStatus(actor, id, []string{models.JobQueued}, map[string]interface{}{
+		"status":      models.JobCancelled,
+		"finished_at": time.Now(),
+	}, models.AuditActionJobCancelled, "solo se pueden cancelar trabajos en cola")
+}
+
+// ListSchedules lista las programaciones recurrentes.
+func (s *JobService) ListSchedules() ([]models.JobSchedule, error) {
+	schedules := make([]models.JobSchedule, 0)
+	if err := s.DB.Order("name").Find(&schedules).Error; err != nil {
+		log.Printf("Error al listar programaciones de trabajos: %v", err)
+		return nil, errors.New("no se pudieron obtener las programaciones")
+	}
+	return schedules, nil
+}
+
+// UpdateSchedule activa o desactiva una programación.
+func (s *JobService) UpdateSchedule(actor RequestActor, id uint, dto UpdateJobScheduleDTO) (*models.JobSchedule, error) {
+	var schedule models.JobSchedule
+	tx := s.DB.Begin()
+	if err := tx.First(&schedule, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("programación no encontrada")
+		}
+		log.Printf("Error al buscar programación %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar la programación")
+	}
+	if schedule.Enabled == *dto.Enabled {
+		tx.Rollback()
+		return &schedule, nil
+	}
+	before := map[string]interface{}{"name": schedule.Name, "enabled": schedule.Enabled}
+	if err := tx.Model(&schedule).Update("enabled", *dto.Enabled).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar programación %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar la programación")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionJobScheduleUpdated,
+		TargetType: models.AuditTargetJobSchedule,
+		TargetID:   schedule.ID,
+		Before:     before,
+		After:      map[string]interface{}{"name": schedule.Name, "enabled": schedule.Enabled},
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la programación %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar la programación")
+	}
+	tx.Commit()
+	return &schedule, nil
+}
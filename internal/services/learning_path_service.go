@@ -0,0 +1,1077 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/jobs"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobTypeSyncLearningPath vuelve a calcular los desbloqueos de las asignaciones abiertas de
+// una ruta después de editarla.
+const JobTypeSyncLearningPath = "learning_paths.sync"
+
+// Estados de un curso dentro de la ruta de un usuario.
+const (
+	PathCourseLocked     = "locked"    // Le falta algún prerrequisito
+	PathCourseAvailable  = "available" // Desbloqueado, todavía sin empezar
+	PathCourseInProgress = "in_progress"
+	PathCourseCompleted  = "completed"
+)
+
+// learningPathActor es el actor de los cambios que hace el sistema al avanzar una ruta.
+var learningPathActor = RequestActor{Username: "sistema"}
+
+// syncPathBatchSize limita cuántas asignaciones se recalculan por consulta.
+const syncPathBatchSize = 200
+
+// LearningPathPrerequisiteDTO es un prerrequisito de un curso de la ruta: hay que aprobar
+// CourseID y, si se indica, con al menos MinScore.
+type LearningPathPrerequisiteDTO struct {
+	CourseID uint `json:"course_id" binding:"required"`
+	MinScore *int `json:"min_score" binding:"omitempty,min=0,max=100"`
+}
+
+// LearningPathCourseDTO es un curso de la ruta con sus prerrequisitos. En una ruta secuencial,
+// un curso sin prerrequisitos requiere el anterior.
+type LearningPathCourseDTO struct {
+	CourseID      uint                          `json:"course_id" binding:"required"`
+	Prerequisites []LearningPathPrerequisiteDTO `json:"prerequisites" binding:"dive"`
+}
+
+// LearningPathDTO define el cuerpo de POST y PUT /api/v1/admin/learning-paths. Los cursos
+// se guardan en el orden recibido.
+type LearningPathDTO struct {
+	Title                 string                  `json:"title" binding:"required,max=200"`
+	Description           string                  `json:"description"`
+	Sequential            bool                    `json:"sequential"`
+	CertificateTemplateID *uint                   `json:"certificate_template_id"`
+	Courses               []LearningPathCourseDTO `json:"courses" binding:"required,min=1,dive"`
+}
+
+// AssignLearningPathDTO define el cuerpo de POST /api/v1/admin/learning-paths/:id/enrollments.
+type AssignLearningPathDTO struct {
+	UserIDs []uint     `json:"user_ids" binding:"required,min=1"`
+	DueAt   *time.Time `json:"due_at"`
+}
+
+// SyncLearningPathPayload es el trabajo que recalcula las asignaciones de una ruta.
+type SyncLearningPathPayload struct {
+	PathID uint `json:"path_id"`
+}
+
+// LearningPathEnrollmentPage es una página de asignaciones de una ruta.
+type LearningPathEnrollmentPage struct {
+	Items    []models.LearningPathEnrollment `json:"items"`
+	Total    int64                           `json:"total"`
+	Page     int                             `json:"page"`
+	PageSize int                             `json:"page_size"`
+}
+
+// PathPrerequisiteState indica si el usuario ya cumple un prerrequisito.
+type PathPrerequisiteState struct {
+	RequiredCourseID uint `json:"required_course_id"`
+	MinScore         *int `json:"min_score,omitempty"`
+	Met              bool `json:"met"`
+}
+
+// PathCourseState es la situación de un curso de la ruta para un usuario.
+type PathCourseState struct {
+	CourseID      uint                    `json:"course_id"`
+	Title         string                  `json:"title"`
+	Position      int                     `json:"position"`
+	State         string                  `json:"state"`
+	Score         *int                    `json:"score,omitempty"`
+	EnrollmentID  *uint                   `json:"enrollment_id,omitempty"`
+	Prerequisites []PathPrerequisiteState `json:"prerequisites"`
+}
+
+// LearningPathProgress es el avance de un usuario en una ruta. Enrollment es nil si la ruta
+// no le fue asignada; CertificateID, el certificado de la ruta si ya se emitió.
+type LearningPathProgress struct {
+	PathID           uint                           `json:"path_id"`
+	Title            string                         `json:"title"`
+	Description      string                         `json:"description,omitempty"`
+	Sequential       bool                           `json:"sequential"`
+	Enrollment       *models.LearningPathEnrollment `json:"enrollment,omitempty"`
+	Courses          []PathCourseState              `json:"courses"`
+	CompletedCourses int                            `json:"completed_courses"`
+	TotalCourses     int                            `json:"total_courses"`
+	Percent          int                            `json:"percent"`
+	CertificateID    *uint                          `json:"certificate_id,omitempty"`
+}
+
+// LearningPathServiceInterface define la gestión de rutas de aprendizaje y el avance de cada
+// empleado en ellas.
+type LearningPathServiceInterface interface {
+	ListPaths() ([]models.LearningPath, error)
+	GetPath(id uint) (*models.LearningPath, error)
+	CreatePath(actor RequestActor, dto LearningPathDTO) (*models.LearningPath, error)
+	UpdatePath(actor RequestActor, id uint, dto LearningPathDTO) (*models.LearningPath, error)
+	DeletePath(actor RequestActor, id uint) error
+
+	AssignPath(actor RequestActor, id uint, dto AssignLearningPathDTO) (*AssignmentResult, error)
+	ListPathEnrollments(id uint, filter EnrollmentFilter) (*LearningPathEnrollmentPage, error)
+	GetUserPathProgress(userID, pathID uint) (*LearningPathProgress, error)
+
+	ListMyPaths(userID uint) ([]LearningPathProgress, error)
+	GetMyPathProgress(userID, pathID uint) (*LearningPathProgress, error)
+	StartPathCourse(actor RequestActor, pathID, courseID uint) (*models.Enrollment, error)
+}
+
+// LearningPathService implementa LearningPathServiceInterface.
+type LearningPathService struct {
+	DB *gorm.DB
+}
+
+// NewLearningPathService crea una nueva instancia de LearningPathService.
+func NewLearningPathService(db *gorm.DB) *LearningPathService {
+	return &LearningPathService{DB: db}
+}
+
+var errLearningPathNotFound = errors.New("ruta de aprendizaje no encontrada")
+
+// findLearningPath busca una ruta con sus cursos (en orden) y sus prerrequisitos.
+func findLearningPath(db *gorm.DB, id uint) (*models.LearningPath, error) {
+	var path models.LearningPath
+	err := db.Preload("Courses", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Courses.Course").
+		Preload("Prerequisites", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&path, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errLearningPathNotFound
+		}
+		log.Printf("Error al buscar ruta de aprendizaje %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener la ruta de aprendizaje")
+	}
+	return &path, nil
+}
+
+func learningPathSnapshot(path *models.LearningPath) map[string]interface{} {
+	courseIDs := make([]uint, 0, len(path.Courses))
+	for _, course := range path.Courses {
+		courseIDs = append(courseIDs, course.CourseID)
+	}
+	prerequisites := make([]map[string]interface{}, 0, len(path.Prerequisites))
+	for _, prerequisite := range path.Prerequisites {
+		prerequisites = append(prerequisites, map[string]interface{}{
+			"course_id":          prerequisite.CourseID,
+			"required_course_id": prerequisite.RequiredCourseID,
+			"min_score":          prerequisite.MinScore,
+		})
+	}
+	return map[string]interface{}{
+		"title":                   path.Title,
+		"description":             path.Description,
+		"sequential":              path.Sequential,
+		"certificate_template_id": path.CertificateTemplateID,
+		"course_ids":              courseIDs,
+		"prerequisites":           prerequisites,
+	}
+}
+
+// findPrerequisiteCycle busca un ciclo en el grafo de prerrequisitos (curso -> cursos que
+// requiere) y devuelve los cursos que lo forman, o nil si no hay.
+func findPrerequisiteCycle(courseIDs []uint, requires map[uint][]uint) []uint {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[uint]int, len(courseIDs))
+	var stack, cycle []uint
+	var visit func(id uint) bool
+	visit = func(id uint) bool {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, next := range requires[id] {
+			switch state[next] {
+			case visiting:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == next {
+						cycle = append([]uint(nil), stack[i:]...)
+						return true
+					}
+				}
+			case unvisited:
+				if visit(next) {
+					return true
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+		return false
+	}
+	for _, id := range courseIDs {
+		if state[id] == unvisited && visit(id) {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// buildPathStructure valida los cursos y prerrequisitos de dto y los devuelve listos para
+// guardar, sin PathID.
+func buildPathStructure(tx *gorm.DB, dto LearningPathDTO) ([]models.LearningPathCourse, []models.LearningPathPrerequisite, error) {
+	inPath := make(map[uint]bool, len(dto.Courses))
+	courseIDs := make([]uint, 0, len(dto.Courses))
+	for _, course := range dto.Courses {
+		if inPath[course.CourseID] {
+			return nil, nil, errors.New("la ruta tiene cursos repetidos")
+		}
+		inPath[course.CourseID] = true
+		courseIDs = append(courseIDs, course.CourseID)
+	}
+	var found int64
+	if err := tx.Model(&models.Course{}).Where("id IN ?", courseIDs).Count(&found).Error; err != nil {
+		log.Printf("Error al comprobar los cursos de la ruta: %v", err)
+		return nil, nil, errors.New("no se pudo guardar la ruta de aprendizaje")
+	}
+	if int(found) != len(courseIDs) {
+		return nil, nil, errors.New("la ruta tiene cursos que no existen")
+	}
+
+	courses := make([]models.LearningPathCourse, 0, len(dto.Courses))
+	var prerequisites []models.LearningPathPrerequisite
+	requires := make(map[uint][]uint, len(dto.Courses))
+	for i, course := range dto.Courses {
+		courses = append(courses, models.LearningPathCourse{CourseID: course.CourseID, Position: i + 1})
+		required := course.Prerequisites
+		if dto.Sequential && len(required) == 0 && i > 0 {
+			required = []LearningPathPrerequisiteDTO{{CourseID: dto.Courses[i-1].CourseID}}
+		}
+		seen := make(map[uint]bool, len(required))
+		for _, prerequisite := range required {
+			switch {
+			case !inPath[prerequisite.CourseID]:
+				return nil, nil, fmt.Errorf("la ruta tiene un prerrequisito que no es un curso de la ruta: %d", prerequisite.CourseID)
+			case prerequisite.CourseID == course.CourseID:
+				return nil, nil, fmt.Errorf("la ruta tiene un curso que es prerrequisito de sí mismo: %d", course.CourseID)
+			case seen[prerequisite.CourseID]:
+				return nil, nil, fmt.Errorf("la ruta tiene un prerrequisito repetido en el curso %d", course.CourseID)
+			}
+			seen[prerequisite.CourseID] = true
+			requires[course.CourseID] = append(requires[course.CourseID], prerequisite.CourseID)
+			prerequisites = append(prerequisites, models.LearningPathPrerequisite{
+				CourseID:         course.CourseID,
+				RequiredCourseID: prerequisite.CourseID,
+				MinScore:         prerequisite.MinScore,
+			})
+		}
+	}
+	if cycle := findPrerequisiteCycle(courseIDs, requires); cycle != nil {
+		ids := make([]string, 0, len(cycle))
+		for _, id := range cycle {
+			ids = append(ids, strconv.FormatUint(uint64(id), 10))
+		}
+		return nil, nil, fmt.Errorf("la ruta tiene prerrequisitos circulares entre los cursos %s", strings.Join(ids, ", "))
+	}
+	return courses, prerequisites, nil
+}
+
+// checkPathTemplate comprueba que exista la plantilla de certificado elegida para la ruta.
+func checkPathTemplate(tx *gorm.DB, templateID *uint) error {
+	if templateID == nil {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.CertificateTemplate{}).Where("id = ?", *templateID).Count(&count).Error; err != nil {
+		log.Printf("Error al buscar la plantilla de certificado %d: %v", *templateID, err)
+		return errors.New("no se pudo guardar la ruta de aprendizaje")
+	}
+	if count == 0 {
+		return errors.New("la ruta usa una plantilla de certificado que no existe")
+	}
+	return nil
+}
+
+// ListPaths lista las rutas de aprendizaje con sus cursos.
+func (s *LearningPathService) ListPaths() ([]models.LearningPath, error) {
+	paths := make([]models.LearningPath, 0)
+	if err := s.DB.Preload("Courses", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Courses.Course").Preload("Prerequisites").
+		Order("title").Find(&paths).Error; err != nil {
+		log.Printf("Error al listar rutas de aprendizaje: %v", err)
+		return nil, errors.New("no se pudieron obtener las rutas de aprendizaje")
+	}
+	return paths, nil
+}
+
+// GetPath devuelve una ruta con sus cursos y prerrequisitos.
+func (s *LearningPathService) GetPath(id uint) (*models.LearningPath, error) {
+	return findLearningPath(s.DB, id)
+}
+
+// CreatePath crea una ruta de aprendizaje. Los prerrequisitos no pueden formar ciclos.
+func (s *LearningPathService) CreatePath(actor RequestActor, dto LearningPathDTO) (*models.LearningPath, error) {
+	dto.Title = strings.TrimSpace(dto.Title)
+	if dto.Title == "" {
+		return nil, errors.New("el título de la ruta no puede estar vacío")
+	}
+	tx := s.DB.Begin()
+	courses, prerequisites, err := buildPathStructure(tx, dto)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := checkPathTemplate(tx, dto.CertificateTemplateID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	path := models.LearningPath{
+		Title:                 dto.Title,
+		Description:           strings.TrimSpace(dto.Description),
+		Sequential:            dto.Sequential,
+		CertificateTemplateID: dto.CertificateTemplateID,
+		Courses:               courses,
+		Prerequisites:         prerequisites,
+	}
+	if actor.UserID != 0 {
+		createdBy := actor.UserID
+		path.CreatedByID = &createdBy
+	}
+	if err := tx.Create(&path).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear ruta de aprendizaje: %v", err)
+		return nil, errors.New("no se pudo crear la ruta de aprendizaje")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionLearningPathCreated,
+		TargetType: models.AuditTargetLearningPath,
+		TargetID:   path.ID,
+		After:      learningPathSnapshot(&path),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la ruta de aprendizaje %d: %v", path.ID, err)
+		return nil, errors.New("no se pudo crear la ruta de aprendizaje")
+	}
+	tx.Commit()
+	return findLearningPath(s.DB, path.ID)
+}
+
+// UpdatePath reemplaza los datos, cursos y prerrequisitos de una ruta. Las asignaciones
+// abiertas se recalculan en segundo plano: los cursos que queden desbloqueados se inscriben.
+func (s *LearningPathService) UpdatePath(actor RequestActor, id uint, dto LearningPathDTO) (*models.LearningPath, error) {
+	dto.Title = strings.TrimSpace(dto.Title)
+	if dto.Title == "" {
+		return nil, errors.New("el título de la ruta no puede estar vacío")
+	}
+	tx := s.DB.Begin()
+	path, err := findLearningPath(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	before := learningPathSnapshot(path)
+	courses, prerequisites, err := buildPathStructure(tx, dto)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := checkPathTemplate(tx, dto.CertificateTemplateID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	fail := func(err error) (*models.LearningPath, error) {
+		tx.Rollback()
+		log.Printf("Error al actualizar ruta de aprendizaje %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar la ruta de aprendizaje")
+	}
+	if err := tx.Where("path_id = ?", id).Delete(&models.LearningPathPrerequisite{}).Error; err != nil {
+		return fail(err)
+	}
+	if err := tx.Where("path_id = ?", id).Delete(&models.LearningPathCourse{}).Error; err != nil {
+		return fail(err)
+	}
+	for i := range courses {
+		courses[i].PathID = id
+	}
+	for i := range prerequisites {
+		prerequisites[i].PathID = id
+	}
+	if err := tx.Create(&courses).Error; err != nil {
+		return fail(err)
+	}
+	if len(prerequisites) > 0 {
+		if err := tx.Create(&prerequisites).Error; err != nil {
+			return fail(err)
+		}
+	}
+	path.Title = dto.Title
+	path.Description = strings.TrimSpace(dto.Description)
+	path.Sequential = dto.Sequential
+	path.CertificateTemplateID = dto.CertificateTemplateID
+	path.Courses = courses
+	path.Prerequisites = prerequisites
+	if err := tx.Omit(clause.Associations).Save(path).Error; err != nil {
+		return fail(err)
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionLearningPathUpdated,
+		TargetType: models.AuditTargetLearningPath,
+		TargetID:   path.ID,
+		Before:     before,
+		After:      learningPathSnapshot(path),
+	}); err != nil {
+		return fail(err)
+	}
+	var open int64
+	if err := tx.Model(&models.LearningPathEnrollment{}).
+		Where("path_id = ? AND status IN ?", id, []models.EnrollmentStatus{models.EnrollmentAssigned, models.EnrollmentInProgress}).
+		Count(&open).Error; err != nil {
+		return fail(err)
+	}
+	if open > 0 {
+		if _, err := jobs.Enqueue(tx, JobTypeSyncLearningPath, SyncLearningPathPayload{PathID: id}, jobs.EnqueueOptions{}); err != nil {
+			return fail(err)
+		}
+	}
+	tx.Commit()
+	return findLearningPath(s.DB, id)
+}
+
+// DeletePath elimina una ruta y cancela sus asignaciones abiertas. Las inscripciones en
+// los cursos y los certificados ya emitidos se conservan.
+func (s *LearningPathService) DeletePath(actor RequestActor, id uint) error {
+	tx := s.DB.Begin()
+	path, err := findLearningPath(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	fail := func(err error) error {
+		tx.Rollback()
+		log.Printf("Error al eliminar ruta de aprendizaje %d: %v", id, err)
+		return errors.New("no se pudo eliminar la ruta de aprendizaje")
+	}
+	if err := tx.Model(&models.LearningPathEnrollment{}).
+		Where("path_id = ? AND status IN ?", id, []models.EnrollmentStatus{models.EnrollmentAssigned, models.EnrollmentInProgress}).
+		Updates(map[string]interface{}{"status": models.EnrollmentCancelled, "cancelled_at": time.Now()}).Error; err != nil {
+		return fail(err)
+	}
+	if err := tx.Delete(path).Error; err != nil {
+		return fail(err)
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionLearningPathDeleted,
+		TargetType: models.AuditTargetLearningPath,
+		TargetID:   path.ID,
+		Before:     learningPathSnapshot(path),
+	}); err != nil {
+		return fail(err)
+	}
+	tx.Commit()
+	return nil
+}
+
+// --- Avance ---
+
+// pathCourseResult es lo que el usuario lleva hecho en un curso de la ruta.
+type pathCourseResult struct {
+	Passed     bool
+	Score      *int
+	Enrollment *models.Enrollment
+}
+
+// loadPathCourseResults obtiene el resultado del usuario en cada curso de la ruta. Un curso
+// está aprobado si su inscripción está completada con todos los cuestionarios aprobados (con
+// la calificación de la vuelta actual) o si tiene un certificado vigente del curso, sin
+// revocar ni vencer, por ejemplo mientras lo recertifica.
+func loadPathCourseResults(db *gorm.DB, userID uint, path *models.LearningPath) (map[uint]*pathCourseResult, error) {
+	results := make(map[uint]*pathCourseResult, len(path.Courses))
+	if len(path.Courses) == 0 {
+		return results, nil
+	}
+	courseIDs := make([]uint, 0, len(path.Courses))
+	for _, course := range path.Courses {
+		courseIDs = append(courseIDs, course.CourseID)
+	}
+
+	var enrollments []models.Enrollment
+	if err := db.Where("user_id = ? AND course_id IN ?", userID, courseIDs).Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	for i := range enrollments {
+		enrollment := &enrollments[i]
+		result := &pathCourseResult{Enrollment: enrollment}
+		if enrollment.Status == models.EnrollmentCompleted {
+			pendingQuizzes, err := countPendingQuizzes(db, enrollment)
+			if err != nil {
+				return nil, err
+			}
+			score, err := courseScore(db, userID, enrollment.CourseID, enrollment.Cycle)
+			if err != nil {
+				return nil, err
+			}
+			result.Passed = pendingQuizzes == 0
+			result.Score = score
+		}
+		results[enrollment.CourseID] = result
+	}
+
+	var certificates []models.Certificate
+	if err := db.Where("user_id = ? AND course_id IN ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+		userID, courseIDs, time.Now()).
+		Order("id").Find(&certificates).Error; err != nil {
+		return nil, err
+	}
+	for _, certificate := range certificates {
+		result, ok := results[*certificate.CourseID]
+		if !ok {
+			result = &pathCourseResult{}
+			results[*certificate.CourseID] = result
+		}
+		if result.Passed {
+			continue // Ya se aprobó en la vuelta actual
+		}
+		result.Passed = true
+		result.Score = certificate.Score
+	}
+	return results, nil
+}
+
+// evaluatePathCourses calcula el estado de cada curso de la ruta a partir de los resultados
+// del usuario. Un prerrequisito con calificación mínima no se cumple si el curso no tiene nota.
+func evaluatePathCourses(path *models.LearningPath, results map[uint]*pathCourseResult) []PathCourseState {
+	requires := make(map[uint][]models.LearningPathPrerequisite, len(path.Courses))
+	for _, prerequisite := range path.Prerequisites {
+		requires[prerequisite.CourseID] = append(requires[prerequisite.CourseID], prerequisite)
+	}
+	states := make([]PathCourseState, 0, len(path.Courses))
+	for _, course := range path.Courses {
+		state := PathCourseState{
+			CourseID:      course.CourseID,
+			Position:      course.Position,
+			Prerequisites: make([]PathPrerequisiteState, 0, len(requires[course.CourseID])),
+		}
+		if course.Course != nil {
+			state.Title = course.Course.Title
+		}
+		locked := false
+		for _, prerequisite := range requires[course.CourseID] {
+			required := results[prerequisite.RequiredCourseID]
+			met := required != nil && required.Passed &&
+				(prerequisite.MinScore == nil || (required.Score != nil && *required.Score >= *prerequisite.MinScore))
+			locked = locked || !met
+			state.Prerequisites = append(state.Prerequisites, PathPrerequisiteState{
+				RequiredCourseID: prerequisite.RequiredCourseID,
+				MinScore:         prerequisite.MinScore,
+				Met:              met,
+			})
+		}
+		result := results[course.CourseID]
+		if result != nil {
+			state.Score = result.Score
+			if result.Enrollment != nil {
+				enrollmentID := result.Enrollment.ID
+				state.EnrollmentID = &enrollmentID
+			}
+		}
+		switch {
+		case result != nil && result.Passed:
+			state.State = PathCourseCompleted
+		case locked:
+			state.State = PathCourseLocked
+		case result != nil && result.Enrollment != nil && result.Enrollment.Status == models.EnrollmentInProgress:
+			state.State = PathCourseInProgress
+		default:
+			state.State = PathCourseAvailable
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// advancePath inscribe al usuario de la asignación en los cursos publicados que ya tiene
+// desbloqueados y actualiza el estado de la asignación. Al aprobar todos los cursos la
+// completa (una sola vez) y registra LearningPathCompleted.
+func advancePath(tx *gorm.DB, actor RequestActor, pathEnrollment *models.LearningPathEnrollment, path *models.LearningPath) error {
+	results, err := loadPathCourseResults(tx, pathEnrollment.UserID, path)
+	if err != nil {
+		return err
+	}
+	states := evaluatePathCourses(path, results)
+	published := make(map[uint]bool, len(path.Courses))
+	for _, course := range path.Courses {
+		published[course.CourseID] = course.Course != nil && course.Course.Status == models.CoursePublished
+	}
+
+	passed, started := 0, false
+	for _, state := range states {
+		switch state.State {
+		case PathCourseCompleted:
+			passed++
+			started = true
+		case PathCourseInProgress:
+			started = true
+		case PathCourseAvailable:
+			if !published[state.CourseID] {
+				continue
+			}
+			_, touched, err := enrollUsers(tx, state.CourseID, []uint{pathEnrollment.UserID},
+				models.Enrollment{Source: models.EnrollmentSourcePath, DueAt: pathEnrollment.DueAt})
+			if err != nil {
+				return err
+			}
+			if len(touched) > 0 {
+				if err := recordAudit(tx, actor, auditRecord{
+					Action:     models.AuditActionEnrollmentCreated,
+					TargetType: models.AuditTargetUser,
+					TargetID:   pathEnrollment.UserID,
+					After:      map[string]interface{}{"course_id": state.CourseID, "path_id": path.ID},
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	open := []models.EnrollmentStatus{models.EnrollmentAssigned, models.EnrollmentInProgress}
+	if len(states) > 0 && passed == len(states) {
+		now := time.Now()
+		result := tx.Model(&models.LearningPathEnrollment{}).Where("id = ? AND status IN ?", pathEnrollment.ID, open).
+			Updates(map[string]interface{}{"status": models.EnrollmentCompleted, "completed_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil // Otro proceso ya la completó
+		}
+		pathEnrollment.Status = models.EnrollmentCompleted
+		pathEnrollment.CompletedAt = &now
+		return recordDomainEvent(tx, actor, models.DomainEventLearningPathCompleted, models.AggregatePathEnrollment, pathEnrollment.ID,
+			map[string]interface{}{"path_enrollment": pathEnrollment, "path_title": path.Title})
+	}
+	if started && pathEnrollment.Status == models.EnrollmentAssigned {
+		if err := tx.Model(pathEnrollment).Update("status", models.EnrollmentInProgress).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathProgress arma el avance de un usuario en una ruta.
+func pathProgress(db *gorm.DB, userID uint, path *models.LearningPath, pathEnrollment *models.LearningPathEnrollment) (*LearningPathProgress, error) {
+	results, err := loadPathCourseResults(db, userID, path)
+	if err != nil {
+		return nil, err
+	}
+	progress := &LearningPathProgress{
+		PathID:       path.ID,
+		Title:        path.Title,
+		Description:  path.Description,
+		Sequential:   path.Sequential,
+		Enrollment:   pathEnrollment,
+		Courses:      evaluatePathCourses(path, results),
+		TotalCourses: len(path.Courses),
+	}
+	for _, course := range progress.Courses {
+		if course.State == PathCourseCompleted {
+			progress.CompletedCourses++
+		}
+	}
+	if progress.TotalCourses > 0 {
+		progress.Percent = progress.CompletedCourses * 100 / progress.TotalCourses
+	}
+	if pathEnrollment != nil {
+		var certificate models.Certificate
+		if err := db.Where("path_enrollment_id = ? AND revoked_at IS NULL", pathEnrollment.ID).
+			Limit(1).Find(&certificate).Error; err != nil {
+			return nil, err
+		}
+		if certificate.ID != 0 {
+			progress.CertificateID = &certificate.ID
+		}
+	}
+	return progress, nil
+}
+
+// findPathEnrollment busca la asignación (no cancelada) de una ruta a un usuario; nil si no hay.
+func findPathEnrollment(db *gorm.DB, userID, pathID uint) (*models.LearningPathEnrollment, error) {
+	var pathEnrollment models.LearningPathEnrollment
+	if err := db.Where("user_id = ? AND path_id = ? AND status <> ?", userID, pathID, models.EnrollmentCancelled).
+		Limit(1).Find(&pathEnrollment).Error; err != nil {
+		return nil, err
+	}
+	if pathEnrollment.ID == 0 {
+		return nil, nil
+	}
+	return &pathEnrollment, nil
+}
+
+// AssignPath asigna una ruta a los usuarios indicados y los inscribe en los cursos que ya
+// tienen desbloqueados. Las asignaciones canceladas se reactivan; las demás se omiten.
+func (s *LearningPathService) AssignPath(actor RequestActor, id uint, dto AssignLearningPathDTO) (*AssignmentResult, error) {
+	if dto.DueAt != nil && !dto.DueAt.After(time.Now()) {
+		return nil, errors.New("la fecha de vencimiento debe ser futura")
+	}
+	tx := s.DB.Begin()
+	path, err := findLearningPath(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for _, course := range path.Courses {
+		if course.Course == nil || course.Course.Status != models.CoursePublished {
+			tx.Rollback()
+			return nil, errors.New("todos los cursos de la ruta deben estar publicados para asignarla")
+		}
+	}
+	fail := func(err error) (*AssignmentResult, error) {
+		tx.Rollback()
+		log.Printf("Error al asignar la ruta de aprendizaje %d: %v", id, err)
+		return nil, errors.New("no se pudo asignar la ruta de aprendizaje")
+	}
+
+	requested := dedupeIDs(dto.UserIDs)
+	var userIDs []uint
+	if err := matchingUsersQuery(tx, "", "", nil).Where("users.id IN ?", requested).
+		Pluck("users.id", &userIDs).Error; err != nil {
+		return fail(err)
+	}
+	if len(userIDs) != len(requested) {
+		tx.Rollback()
+		return nil, errors.New("algún usuario no existe o está dado de baja")
+	}
+
+	var existing []models.LearningPathEnrollment
+	if err := tx.Where("path_id = ? AND user_id IN ?", id, userIDs).Find(&existing).Error; err != nil {
+		return fail(err)
+	}
+	byUser := make(map[uint]*models.LearningPathEnrollment, len(existing))
+	for i := range existing {
+		byUser[existing[i].UserID] = &existing[i]
+	}
+	var assignedBy *uint
+	if actor.UserID != 0 {
+		actorID := actor.UserID
+		assignedBy = &actorID
+	}
+
+	result := &AssignmentResult{}
+	var touched []uint
+	now := time.Now()
+	for _, userID := range userIDs {
+		pathEnrollment, ok := byUser[userID]
+		switch {
+		case ok && pathEnrollment.Status != models.EnrollmentCancelled:
+			result.Skipped++
+			continue
+		case ok:
+			if err := tx.Model(pathEnrollment).Updates(map[string]interface{}{
+				"status":         models.EnrollmentAssigned,
+				"assigned_by_id": assignedBy,
+				"due_at":         dto.DueAt,
+				"assigned_at":    now,
+				"completed_at":   nil,
+				"cancelled_at":   nil,
+			}).Error; err != nil {
+				return fail(err)
+			}
+			result.Reactivated++
+		default:
+			pathEnrollment = &models.LearningPathEnrollment{
+				UserID:       userID,
+				PathID:       id,
+				Status:       models.EnrollmentAssigned,
+				AssignedByID: assignedBy,
+				DueAt:        dto.DueAt,
+				AssignedAt:   now,
+			}
+			if err := tx.Create(pathEnrollment).Error; err != nil {
+				return fail(err)
+			}
+			result.Enrolled++
+		}
+		if err := advancePath(tx, actor, pathEnrollment, path); err != nil {
+			return fail(err)
+		}
+		touched = append(touched, userID)
+	}
+	if len(touched) > 0 {
+		if err := recordAudit(tx, actor, auditRecord{
+			Action:     models.AuditActionLearningPathAssigned,
+			TargetType: models.AuditTargetLearningPath,
+			TargetID:   id,
+			After:      map[string]interface{}{"user_ids": touched, "due_at": dto.DueAt},
+		}); err != nil {
+			return fail(err)
+		}
+	}
+	tx.Commit()
+	return result, nil
+}
+
+// ListPathEnrollments lista las asignaciones de una ruta.
+func (s *LearningPathService) ListPathEnrollments(id uint, filter EnrollmentFilter) (*LearningPathEnrollmentPage, error) {
+	switch models.EnrollmentStatus(filter.Status) {
+	case "", models.EnrollmentAssigned, models.EnrollmentInProgress, models.EnrollmentCompleted, models.EnrollmentCancelled:
+	default:
+		return nil, errors.New("estado de inscripción inválido")
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultEnrollmentPageSize
+	}
+	if filter.PageSize > maxEnrollmentPageSize {
+		filter.PageSize = maxEnrollmentPageSize
+	}
+	var count int64
+	if err := s.DB.Model(&models.LearningPath{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		log.Printf("Error al buscar ruta de aprendizaje %d: %v", id, err)
+		return nil, errors.New("no se pudieron obtener las asignaciones")
+	}
+	if count == 0 {
+		return nil, errLearningPathNotFound
+	}
+
+	query := s.DB.Model(&models.LearningPathEnrollment{}).Where("path_id = ?", id)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	page := &LearningPathEnrollmentPage{Items: make([]models.LearningPathEnrollment, 0), Page: filter.Page, PageSize: filter.PageSize}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		log.Printf("Error al contar asignaciones de la ruta %d: %v", id, err)
+		return nil, errors.New("no se pudieron obtener las asignaciones")
+	}
+	if err := query.Order("id DESC").Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&page.Items).Error; err != nil {
+		log.Printf("Error al listar asignaciones de la ruta %d: %v", id, err)
+		return nil, errors.New("no se pudieron obtener las asignaciones")
+	}
+	return page, nil
+}
+
+// GetUserPathProgress calcula el avance de cualquier usuario en una ruta, la tenga asignada o no.
+func (s *LearningPathService) GetUserPathProgress(userID, pathID uint) (*LearningPathProgress, error) {
+	var count int64
+	if err := s.DB.Model(&models.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		log.Printf("Error al buscar el usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudo obtener el avance de la ruta")
+	}
+	if count == 0 {
+		return nil, errors.New("usuario no encontrado")
+	}
+	path, err := findLearningPath(s.DB, pathID)
+	if err != nil {
+		return nil, err
+	}
+	pathEnrollment, err := findPathEnrollment(s.DB, userID, pathID)
+	if err != nil {
+		log.Printf("Error al buscar la asignación de la ruta %d: %v", pathID, err)
+		return nil, errors.New("no se pudo obtener el avance de la ruta")
+	}
+	progress, err := pathProgress(s.DB, userID, path, pathEnrollment)
+	if err != nil {
+		log.Printf("Error al calcular el avance del usuario %d en la ruta %d: %v", userID, pathID, err)
+		return nil, errors.New("no se pudo obtener el avance de la ruta")
+	}
+	return progress, nil
+}
+
+// ListMyPaths lista las rutas asignadas a un usuario (sin las canceladas) con su avance.
+func (s *LearningPathService) ListMyPaths(userID uint) ([]LearningPathProgress, error) {
+	var pathEnrollments []models.LearningPathEnrollment
+	if err := s.DB.Joins("JOIN learning_paths ON learning_paths.id = learning_path_enrollments.path_id AND learning_paths.deleted_at IS NULL").
+		Where("learning_path_enrollments.user_id = ? AND learning_path_enrollments.status <> ?", userID, models.EnrollmentCancelled).
+		Order("learning_path_enrollments.status = 'completed', learning_path_enrollments.due_at IS NULL, learning_path_enrollments.due_at, learning_path_enrollments.id").
+		Find(&pathEnrollments).Error; err != nil {
+		log.Printf("Error al listar las rutas del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudieron obtener tus rutas de aprendizaje")
+	}
+	out := make([]LearningPathProgress, 0, len(pathEnrollments))
+	for i := range pathEnrollments {
+		path, err := findLearningPath(s.DB, pathEnrollments[i].PathID)
+		if err != nil {
+			return nil, errors.New("no se pudieron obtener tus rutas de aprendizaje")
+		}
+		progress, err := pathProgress(s.DB, userID, path, &pathEnrollments[i])
+		if err != nil {
+			log.Printf("Error al calcular el avance del usuario %d en la ruta %d: %v", userID, path.ID, err)
+			return nil, errors.New("no se pudieron obtener tus rutas de aprendizaje")
+		}
+		out = append(out, *progress)
+	}
+	return out, nil
+}
+
+// GetMyPathProgress devuelve el avance del usuario en una ruta que tiene asignada.
+func (s *LearningPathService) GetMyPathProgress(userID, pathID uint) (*LearningPathProgress, error) {
+	pathEnrollment, err := findPathEnrollment(s.DB, userID, pathID)
+	if err != nil {
+		log.Printf("Error al buscar la asignación de la ruta %d: %v", pathID, err)
+		return nil, errors.New("no se pudo obtener el avance de la ruta")
+	}
+	if pathEnrollment == nil {
+		return nil, errors.New("ruta de aprendizaje no asignada")
+	}
+	path, err := findLearningPath(s.DB, pathID)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := pathProgress(s.DB, userID, path, pathEnrollment)
+	if err != nil {
+		log.Printf("Error al calcular el avance del usuario %d en la ruta %d: %v", userID, pathID, err)
+		return nil, errors.New("no se pudo obtener el avance de la ruta")
+	}
+	return progress, nil
+}
+
+// StartPathCourse inscribe al usuario en un curso desbloqueado de una ruta que tiene
+// asignada. Los cursos se inscriben solos al desbloquearse al completar otro; esto cubre los
+// que se desbloquean después, por ejemplo al mejorar la nota de un cuestionario.
+func (s *LearningPathService) StartPathCourse(actor RequestActor, pathID, courseID uint) (*models.Enrollment, error) {
+	tx := s.DB.Begin()
+	pathEnrollment, err := findPathEnrollment(tx.Clauses(clause.Locking{Strength: "UPDATE"}), actor.UserID, pathID)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al buscar la asignación de la ruta %d: %v", pathID, err)
+		return nil, errors.New("no se pudo empezar el curso")
+	}
+	if pathEnrollment == nil {
+		tx.Rollback()
+		return nil, errors.New("ruta de aprendizaje no asignada")
+	}
+	path, err := findLearningPath(tx, pathID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	results, err := loadPathCourseResults(tx, actor.UserID, path)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al calcular el avance de la ruta %d: %v", pathID, err)
+		return nil, errors.New("no se pudo empezar el curso")
+	}
+	var state *PathCourseState
+	for _, course := range evaluatePathCourses(path, results) {
+		if course.CourseID == courseID {
+			course := course
+			state = &course
+			break
+		}
+	}
+	switch {
+	case state == nil:
+		tx.Rollback()
+		return nil, errors.New("el curso no forma parte de la ruta")
+	case state.State == PathCourseLocked:
+		tx.Rollback()
+		return nil, errors.New("el curso todavía está bloqueado")
+	}
+	if state.State == PathCourseAvailable {
+		if _, err := findAssignableCourse(tx, courseID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if pathEnrollment.Status != models.EnrollmentCompleted {
+			err = advancePath(tx, actor, pathEnrollment, path)
+		} else {
+			_, _, err = enrollUsers(tx, courseID, []uint{actor.UserID}, models.Enrollment{Source: models.EnrollmentSourcePath})
+		}
+		if err != nil {
+			tx.Rollback()
+			log.Printf("Error al inscribir al usuario %d en el curso %d de la ruta %d: %v", actor.UserID, courseID, pathID, err)
+			return nil, errors.New("no se pudo empezar el curso")
+		}
+	}
+	enrollment, err := findUserEnrollment(tx, actor.UserID, courseID, false)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	tx.Commit()
+	return enrollment, nil
+}
+
+// syncLearningPath recalcula las asignaciones abiertas de una ruta, por ejemplo después de
+// cambiar sus prerrequisitos.
+func syncLearningPath(ctx context.Context, db *gorm.DB, pathID uint) error {
+	db = db.WithContext(ctx)
+	path, err := findLearningPath(db, pathID)
+	if err != nil {
+		if errors.Is(err, errLearningPathNotFound) {
+			return nil
+		}
+		return err
+	}
+	var lastID uint
+	for ctx.Err() == nil {
+		var pathEnrollments []models.LearningPathEnrollment
+		if err := db.Where("path_id = ? AND status IN ? AND id > ?", pathID,
+			[]models.EnrollmentStatus{models.EnrollmentAssigned, models.EnrollmentInProgress}, lastID).
+			Order("id").Limit(syncPathBatchSize).Find(&pathEnrollments).Error; err != nil {
+			return fmt.Errorf("no se pudieron buscar las asignaciones de la ruta %d: %w", pathID, err)
+		}
+		for i := range pathEnrollments {
+			lastID = pathEnrollments[i].ID
+			tx := db.Begin()
+			if err := advancePath(tx, learningPathActor, &pathEnrollments[i], path); err != nil {
+				tx.Rollback()
+				log.Printf("Error al recalcular la asignación %d de la ruta %d: %v", lastID, pathID, err)
+				continue
+			}
+			if err := tx.Commit().Error; err != nil {
+				return err
+			}
+		}
+		if len(pathEnrollments) < syncPathBatchSize {
+			break
+		}
+	}
+	return ctx.Err()
+}
+
+// LearningPathSubscriber hace avanzar las rutas asignadas cuando el usuario completa uno de
+// sus cursos: inscribe los que se desbloquean y completa la ruta al aprobarlos todos.
+type LearningPathSubscriber struct{}
+
+// Name implementa events.Subscriber.
+func (LearningPathSubscriber) Name() string { return "learning_paths" }
+
+// Handles implementa events.Subscriber.
+func (LearningPathSubscriber) Handles(eventType string) bool {
+	return eventType == models.DomainEventEnrollmentCompleted
+}
+
+// Handle implementa events.Subscriber.
+func (LearningPathSubscriber) Handle(tx *gorm.DB, event *models.DomainEvent) error {
+	var enrollment models.Enrollment
+	if err := tx.Limit(1).Find(&enrollment, event.AggregateID).Error; err != nil {
+		return err
+	}
+	if enrollment.ID == 0 {
+		return nil
+	}
+	var pathEnrollments []models.LearningPathEnrollment
+	if err := tx.Joins("JOIN learning_paths ON learning_paths.id = learning_path_enrollments.path_id AND learning_paths.deleted_at IS NULL").
+		Where("learning_path_enrollments.user_id = ? AND learning_path_enrollments.status IN ?", enrollment.UserID,
+			[]models.EnrollmentStatus{models.EnrollmentAssigned, models.EnrollmentInProgress}).
+		Where("EXISTS (SELECT 1 FROM learning_path_courses WHERE learning_path_courses.path_id = learning_path_enrollments.path_id AND learning_path_courses.course_id = ?)", enrollment.CourseID).
+		Find(&pathEnrollments).Error; err != nil {
+		return err
+	}
+	for i := range pathEnrollments {
+		path, err := findLearningPath(tx, pathEnrollments[i].PathID)
+		if err != nil {
+			return err
+		}
+		if err := advancePath(tx, learningPathActor, &pathEnrollments[i], path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestFindPrerequisiteCycle(t *testing.T) {
+	tests := []struct {
+		name      string
+		courseIDs []uint
+		requires  map[uint][]uint
+		want      []uint
+	}{
+		{
+			name:      "sin ciclos",
+			courseIDs: []uint{1, 2, 3, 4},
+			requires:  map[uint][]uint{2: {1}, 3: {1}, 4: {2, 3}},
+			want:      nil,
+		},
+		{
+			name:      "sin prerrequisitos",
+			courseIDs: []uint{1, 2},
+			requires:  map[uint][]uint{},
+			want:      nil,
+		},
+		{
+			name:      "curso que se requiere a sí mismo",
+			courseIDs: []uint{1, 2},
+			requires:  map[uint][]uint{2: {2}},
+			want:      []uint{2},
+		},
+		{
+			name:      "ciclo entre dos",
+			courseIDs: []uint{1, 2},
+			requires:  map[uint][]uint{1: {2}, 2: {1}},
+			want:      []uint{1, 2},
+		},
+		{
+			name:      "ciclo largo colgado de una rama",
+			courseIDs: []uint{1, 2, 3, 4},
+			requires:  map[uint][]uint{1: {2}, 2: {3}, 3: {4}, 4: {2}},
+			want:      []uint{2, 3, 4},
+		},
+		{
+			name:      "ciclo que no alcanza el primer curso",
+			courseIDs: []uint{1, 2, 3},
+			requires:  map[uint][]uint{2: {3}, 3: {2}},
+			want:      []uint{2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findPrerequisiteCycle(tt.courseIDs, tt.requires); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findPrerequisiteCycle = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPathStructurePrerequisites(t *testing.T) {
+	requires := func(ids ...uint) []LearningPathPrerequisiteDTO {
+		out := make([]LearningPathPrerequisiteDTO, 0, len(ids))
+		for _, id := range ids {
+			out = append(out, LearningPathPrerequisiteDTO{CourseID: id})
+		}
+		return out
+	}
+	tests := []struct {
+		name    string
+		dto     LearningPathDTO
+		wantErr string
+		want    map[uint][]uint // curso -> cursos requeridos guardados
+	}{
+		{
+			name: "secuencial sin prerrequisitos explícitos",
+			dto: LearningPathDTO{Sequential: true, Courses: []LearningPathCourseDTO{
+				{CourseID: 10}, {CourseID: 20}, {CourseID: 30},
+			}},
+			want: map[uint][]uint{20: {10}, 30: {20}},
+		},
+		{
+			name: "curso que se requiere a sí mismo",
+			dto: LearningPathDTO{Courses: []LearningPathCourseDTO{
+				{CourseID: 10, Prerequisites: requires(10)}, {CourseID: 20},
+			}},
+			wantErr: "prerrequisito de sí mismo",
+		},
+		{
+			name: "ciclo entre dos",
+			dto: LearningPathDTO{Courses: []LearningPathCourseDTO{
+				{CourseID: 10, Prerequisites: requires(20)}, {CourseID: 20, Prerequisites: requires(10)},
+			}},
+			wantErr: "circulares entre los cursos 10, 20",
+		},
+		{
+			// 20 y 30 requieren al anterior de forma implícita; 10 requiere explícitamente a
+			// 30 y cierra el ciclo 10 -> 30 -> 20 -> 10.
+			name: "secuencial con un prerrequisito explícito que cierra el ciclo",
+			dto: LearningPathDTO{Sequential: true, Courses: []LearningPathCourseDTO{
+				{CourseID: 10, Prerequisites: requires(30)}, {CourseID: 20}, {CourseID: 30},
+			}},
+			wantErr: "circulares entre los cursos 10, 30, 20",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.ExpectQuery("SELECT count\\(\\*\\) FROM `courses` WHERE id IN").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(len(tt.dto.Courses)))
+
+			_, prerequisites, err := buildPathStructure(db, tt.dto)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, se esperaba uno con %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[uint][]uint)
+			for _, p := range prerequisites {
+				got[p.CourseID] = append(got[p.CourseID], p.RequiredCourseID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prerrequisitos = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,487 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// LifecycleError indica que un cambio de estado o de fechas laborales no es válido
+// (transición no permitida, fecha mal formada, baja anterior al ingreso...).
+type LifecycleError struct {
+	Reason string
+}
+
+func (e *LifecycleError) Error() string {
+	return e.Reason
+}
+
+// LifecycleHook se ejecuta dentro de la transacción de cada cambio de estado, después de
+// guardar el nuevo estado del usuario. Si devuelve un error el cambio no se confirma.
+// Permite que otros módulos reaccionen al ingreso o a la baja de un empleado (asignar la
+// formación de bienvenida, retirar accesos, etc.) sin que este servicio los conozca.
+type LifecycleHook interface {
+	OnStatusChange(tx *gorm.DB, actor RequestActor, user *models.User, from, to models.UserStatus) error
+}
+
+// ChangeStatusDTO define el cuerpo de POST /api/v1/admin/users/:id/status.
+type ChangeStatusDTO struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason,omitempty" binding:"omitempty,max=255"`
+}
+
+// ScheduleTransitionDTO define el cuerpo de POST /api/v1/admin/users/:id/lifecycle-transitions.
+type ScheduleTransitionDTO struct {
+	Status      string    `json:"status" binding:"required"`
+	EffectiveAt time.Time `json:"effective_at" binding:"required"`
+	Reason      string    `json:"reason,omitempty" binding:"omitempty,max=255"`
+}
+
+// LifecycleServiceInterface define la gestión del ciclo de vida de las cuentas.
+type LifecycleServiceInterface interface {
+	ChangeStatus(actor RequestActor, userID uint, dto ChangeStatusDTO) (*UserDetailDTO, error)
+	ScheduleTransition(actor RequestActor, userID uint, dto ScheduleTransitionDTO) (*models.LifecycleTransition, error)
+	ListTransitions(userID uint) ([]models.LifecycleTransition, error)
+	CancelTransition(actor RequestActor, userID, transitionID uint) error
+	ApplyDueTransitions(now time.Time) (int, error)
+}
+
+// LifecycleService implementa LifecycleServiceInterface.
+type LifecycleService struct {
+	DB    *gorm.DB
+	hooks []LifecycleHook
+}
+
+// NewLifecycleService crea una nueva instancia de LifecycleService con el hook de retiro
+// de accesos ya registrado.
+func NewLifecycleService(db *gorm.DB) *LifecycleService {
+	return &LifecycleService{DB: db, hooks: []LifecycleHook{RevokeAccessHook{}}}
+}
+
+// RegisterHook agrega un hook que se ejecutará en cada cambio de estado, en orden de registro.
+func (s *LifecycleService) RegisterHook(hook LifecycleHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// lifecycleSchedulerActor es el actor de los cambios que aplica el programador.
+var lifecycleSchedulerActor = RequestActor{Username: "sistema"}
+
+// lifecycleBatchSize limita cuántas transiciones vencidas se procesan por ejecución.
+const lifecycleBatchSize = 100
+
+// lifecycleDateLayout es el formato de las fechas de ingreso y de baja.
+const lifecycleDateLayout = "2006-01-02"
+
+// parseLifecycleDate interpreta una fecha "AAAA-MM-DD" en la zona horaria del servidor.
+// La cadena vacía quita la fecha.
+func parseLifecycleDate(field, value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.ParseInLocation(lifecycleDateLayout, value, time.Local)
+	if err != nil {
+		return nil, &LifecycleError{Reason: fmt.Sprintf("%s debe tener el formato AAAA-MM-DD", field)}
+	}
+	return &date, nil
+}
+
+// applyEmploymentDates actualiza las fechas de ingreso y de baja del empleado. Un puntero
+// nil deja la fecha como está y una cadena vacía la quita. Devuelve qué fechas cambiaron.
+func applyEmploymentDates(detail *models.EmployeeDetail, hireDate, terminationDate *string) (hireChanged, terminationChanged bool, err error) {
+	if hireDate != nil {
+		date, err := parseLifecycleDate("hire_date", *hireDate)
+		if err != nil {
+			return false, false, err
+		}
+		if !models.EqualOptionalDate(detail.HireDate, date) {
+			detail.HireDate = date
+			hireChanged = true
+		}
+	}
+	if terminationDate != nil {
+		date, err := parseLifecycleDate("termination_date", *terminationDate)
+		if err != nil {
+			return false, false, err
+		}
+		if !models.EqualOptionalDate(detail.TerminationDate, date) {
+			detail.TerminationDate = date
+			terminationChanged = true
+		}
+	}
+	if detail.HireDate != nil && detail.TerminationDate != nil && detail.TerminationDate.Before(*detail.HireDate) {
+		return false, false, &LifecycleError{Reason: "la fecha de baja no puede ser anterior a la de ingreso"}
+	}
+	return hireChanged, terminationChanged, nil
+}
+
+// initialStatus decide el estado de una cuenta nueva: el indicado explícitamente o,
+// si no se indicó, "invited" cuando la fecha de ingreso es futura y "active" en otro caso.
+func initialStatus(requested string, detail *models.EmployeeDetail, now time.Time) (models.UserStatus, error) {
+	if requested != "" {
+		status, err := models.ParseUserStatus(requested)
+		if err != nil {
+			return "", &LifecycleError{Reason: err.Error()}
+		}
+		if status != models.StatusInvited && status != models.StatusActive {
+			return "", &LifecycleError{Reason: "un usuario nuevo solo puede crearse como invited o active"}
+		}
+		return status, nil
+	}
+	if detail != nil && detail.HireDate != nil && detail.HireDate.After(now) {
+		return models.StatusInvited, nil
+	}
+	return models.StatusActive, nil
+}
+
+// scheduleDateTransitions reprograma las transiciones que dependen de las fechas laborales:
+// la activación el día de ingreso (solo si la cuenta sigue invitada) y la baja al terminar
+// el último día de trabajo. Las transiciones pendientes generadas por una fecha anterior
+// se cancelan. Se llama dentro de la transacción que modificó las fechas.
+func scheduleDateTransitions(tx *gorm.DB, actor RequestActor, user *models.User, hireChanged, terminationChanged bool) error {
+	detail := &user.EmployeeDetail
+	type dateRule struct {
+		changed bool
+		source  string
+		to      models.UserStatus
+		at      func() *time.Time
+	}
+	rules := []dateRule{
+		{hireChanged, models.TransitionSourceHireDate, models.StatusActive, func() *time.Time {
+			if detail.HireDate == nil || user.Status != models.StatusInvited {
+				return nil
+			}
+			return detail.HireDate
+		}},
+		{terminationChanged, models.TransitionSourceTerminationDate, models.StatusOffboarded, func() *time.Time {
+			if detail.TerminationDate == nil || user.Status == models.StatusOffboarded {
+				return nil
+			}
+			// El acceso se mantiene durante todo el último día de trabajo.
+			endOfLastDay := detail.TerminationDate.AddDate(0, 0, 1)
+			return &endOfLastDay
+		}},
+	}
+
+	for _, rule := range rules {
+		if !rule.changed {
+			continue
+		}
+		if err := tx.Model(&models.LifecycleTransition{}).
+			Where("user_id = ? AND source = ? AND state = ?", user.ID, rule.source, models.TransitionPending).
+			Update("state", models.TransitionCancelled).Error; err != nil {
+			return fmt.Errorf("no se pudieron cancelar las transiciones anteriores: %w", err)
+		}
+		at := rule.at()
+		if at == nil {
+			continue
+		}
+		transition := models.LifecycleTransition{
+			UserID:      user.ID,
+			ToStatus:    rule.to,
+			EffectiveAt: *at,
+			State:       models.TransitionPending,
+			Source:      rule.source,
+		}
+		if actor.UserID != 0 {
+			createdBy := actor.UserID
+			transition.CreatedByID = &createdBy
+		}
+		if err := tx.Create(&transition).Error; err != nil {
+			return fmt.Errorf("no se pudo programar la transición: %w", err)
+		}
+	}
+	return nil
+}
+
+// lifecycleFailure deja pasar los errores de validación del ciclo de vida y registra los de BD.
+func lifecycleFailure(operation string, err error) error {
+	var lifecycleErr *LifecycleError
+	if errors.As(err, &lifecycleErr) {
+		return err
+	}
+	log.Printf("Error en el ciclo de vida del usuario (%s): %v", operation, err)
+	return fmt.Errorf("no se pudo %s", operation)
+}
+
+// transitionUser cambia el estado del usuario dentro de tx: valida la transición, guarda
+// con control de versión, ejecuta los hooks y registra historial, auditoría y el evento
+// UserStatusChanged.
+func (s *LifecycleService) transitionUser(tx *gorm.DB, actor RequestActor, user *models.User, to models.UserStatus, reason string) error {
+	from := user.Status
+	if from == to {
+		return &LifecycleError{Reason: fmt.Sprintf("el usuario ya está en estado %s", to)}
+	}
+	if !from.CanTransitionTo(to) {
+		return &LifecycleError{Reason: fmt.Sprintf("no se puede pasar del estado %s a %s", from, to)}
+	}
+
+	user.Status = to
+	if err := saveUserVersioned(tx, user, true, false); err != nil {
+		user.Status = from
+		return err
+	}
+	for _, hook := range s.hooks {
+		if err := hook.OnStatusChange(tx, actor, user, from, to); err != nil {
+			return err
+		}
+	}
+	if err := recordUserHistory(tx, actor, user, models.AuditActionUserStatusChanged, false); err != nil {
+		return err
+	}
+	extra := map[string]interface{}{"from": from, "to": to}
+	if err := recordUserEvent(tx, actor, models.DomainEventUserStatusChanged, user, extra); err != nil {
+		return err
+	}
+	after := map[string]interface{}{"status": string(to)}
+	if reason != "" {
+		after["reason"] = reason
+	}
+	return recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionUserStatusChanged,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]interface{}{"status": string(from)},
+		After:      after,
+	})
+}
+
+// ChangeStatus aplica un cambio de estado inmediato.
+func (s *LifecycleService) ChangeStatus(actor RequestActor, userID uint, dto ChangeStatusDTO) (*UserDetailDTO, error) {
+	to, err := models.ParseUserStatus(dto.Status)
+	if err != nil {
+		return nil, &LifecycleError{Reason: err.Error()}
+	}
+
+	var user models.User
+	tx := s.DB.Begin()
+	if err := tx.Preload("EmployeeDetail").First(&user, userID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("usuario no encontrado")
+		}
+		log.Printf("Error al buscar usuario %d para cambiar su estado: %v", userID, err)
+		return nil, errors.New("no se pudo cambiar el estado del usuario")
+	}
+	if err := s.transitionUser(tx, actor, &user, to, dto.Reason); err != nil {
+		tx.Rollback()
+		if errors.Is(err, errVersionConflict) {
+			return nil, errors.New("el usuario fue modificado al mismo tiempo; intente de nuevo")
+		}
+		return nil, lifecycleFailure("cambiar el estado del usuario", err)
+	}
+	tx.Commit()
+
+	log.Printf("Usuario %d pasó al estado '%s'.", user.ID, to)
+	return userDetailDTOWithCustomFields(s.DB, &user), nil
+}
+
+// ScheduleTransition programa un cambio de estado para una fecha futura.
+func (s *LifecycleService) ScheduleTransition(actor RequestActor, userID uint, dto ScheduleTransitionDTO) (*models.LifecycleTransition, error) {
+	to, err := models.ParseUserStatus(dto.Status)
+	if err != nil {
+		return nil, &LifecycleError{Reason: err.Error()}
+	}
+	if !dto.EffectiveAt.After(time.Now()) {
+		return nil, &LifecycleError{Reason: "la fecha efectiva debe ser futura"}
+	}
+
+	var user models.User
+	if err := s.DB.Select("id").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("usuario no encontrado")
+		}
+		log.Printf("Error al buscar usuario %d para programar una transición: %v", userID, err)
+		return nil, errors.New("no se pudo programar la transición")
+	}
+
+	transition := models.LifecycleTransition{
+		UserID:      userID,
+		ToStatus:    to,
+		EffectiveAt: dto.EffectiveAt,
+		State:       models.TransitionPending,
+		Source:      models.TransitionSourceManual,
+		Reason:      dto.Reason,
+	}
+	if actor.UserID != 0 {
+		createdBy := actor.UserID
+		transition.CreatedByID = &createdBy
+	}
+
+	tx := s.DB.Begin()
+	if err := tx.Create(&transition).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al programar transición del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudo programar la transición")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionTransitionScheduled,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID,
+		After:      transitionAuditSnapshot(&transition),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar transición programada del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudo programar la transición")
+	}
+	tx.Commit()
+	return &transition, nil
+}
+
+// ListTransitions devuelve las transiciones del usuario, de la más reciente a la más antigua.
+func (s *LifecycleService) ListTransitions(userID uint) ([]models.LifecycleTransition, error) {
+	var transitions []models.LifecycleTransition
+	if err := s.DB.Where("user_id = ?", userID).Order("effective_at DESC, id DESC").Find(&transitions).Error; err != nil {
+		log.Printf("Error al listar transiciones del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudieron obtener las transiciones")
+	}
+	return transitions, nil
+}
+
+// CancelTransition cancela una transición pendiente.
+func (s *LifecycleService) CancelTransition(actor RequestActor, userID, transitionID uint) error {
+	var transition models.LifecycleTransition
+	tx := s.DB.Begin()
+	if err := tx.Where("id = ? AND user_id = ?", transitionID, userID).First(&transition).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("transición no encontrada")
+		}
+		log.Printf("Error al buscar transición %d: %v", transitionID, err)
+		return errors.New("no se pudo cancelar la transición")
+	}
+	result := tx.Model(&transition).Where("state = ?", models.TransitionPending).Update("state", models.TransitionCancelled)
+	if result.Error != nil {
+		tx.Rollback()
+		log.Printf("Error al cancelar transición %d: %v", transitionID, result.Error)
+		return errors.New("no se pudo cancelar la transición")
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return errors.New("la transición ya no está pendiente")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionTransitionCancelled,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID,
+		Before:     transitionAuditSnapshot(&transition),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar cancelación de la transición %d: %v", transitionID, err)
+		return errors.New("no se pudo cancelar la transición")
+	}
+	tx.Commit()
+	return nil
+}
+
+func transitionAuditSnapshot(t *models.LifecycleTransition) map[string]interface{} {
+	return map[string]interface{}{
+		"transition_id": t.ID,
+		"to_status":     string(t.ToStatus),
+		"effective_at":  t.EffectiveAt.Format(time.RFC3339),
+		"source":        t.Source,
+		"reason":        t.Reason,
+	}
+}
+
+// ApplyDueTransitions aplica las transiciones pendientes cuya fecha efectiva ya pasó y
+// devuelve cuántas se aplicaron. Es seguro ejecutarlo desde varias instancias a la vez:
+// cada transición se reclama con un UPDATE condicionado a que siga pendiente.
+func (s *LifecycleService) ApplyDueTransitions(now time.Time) (int, error) {
+	var due []models.LifecycleTransition
+	if err := s.DB.Where("state = ? AND effective_at <= ?", models.TransitionPending, now).
+		Order("effective_at ASC, id ASC").Limit(lifecycleBatchSize).Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("no se pudieron leer las transiciones vencidas: %w", err)
+	}
+
+	applied := 0
+	for i := range due {
+		ok, err := s.applyTransition(&due[i], now)
+		if err != nil {
+			log.Printf("Error al aplicar la transición %d del usuario %d: %v", due[i].ID, due[i].UserID, err)
+			continue
+		}
+		if ok {
+			applied++
+		}
+	}
+	return applied, nil
+}
+
+// applyTransition aplica una transición vencida. Si la transición ya no es válida (el
+// usuario fue eliminado o su estado actual no lo permite) se marca como fallida; los
+// errores transitorios la dejan pendiente para el siguiente intento.
+func (s *LifecycleService) applyTransition(transition *models.LifecycleTransition, now time.Time) (bool, error) {
+	tx := s.DB.Begin()
+	claim := tx.Model(transition).Where("state = ?", models.TransitionPending).
+		Updates(map[string]interface{}{"state": models.TransitionApplied, "applied_at": now})
+	if claim.Error != nil {
+		tx.Rollback()
+		return false, claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		tx.Rollback() // Otra instancia la aplicó o se canceló mientras tanto
+		return false, nil
+	}
+
+	var user models.User
+	err := tx.Preload("EmployeeDetail").First(&user, transition.UserID).Error
+	if err == nil {
+		if user.Status == transition.ToStatus {
+			tx.Commit() // Ya estaba en el estado deseado; no hay nada que hacer
+			return true, nil
+		}
+		err = s.transitionUser(tx, lifecycleSchedulerActor, &user, transition.ToStatus, transition.Reason)
+	}
+	if err == nil {
+		tx.Commit()
+		log.Printf("Transición programada %d aplicada: usuario %d pasó al estado '%s'.", transition.ID, user.ID, transition.ToStatus)
+		return true, nil
+	}
+	tx.Rollback()
+
+	var lifecycleErr *LifecycleError
+	if !errors.Is(err, gorm.ErrRecordNotFound) && !errors.As(err, &lifecycleErr) {
+		return false, err
+	}
+	reason := err.Error()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		reason = "usuario no encontrado"
+	}
+	if markErr := s.DB.Model(transition).Where("state = ?", models.TransitionPending).
+		Updates(map[string]interface{}{"state": models.TransitionFailed, "error": reason}).Error; markErr != nil {
+		return false, markErr
+	}
+	log.Printf("Transición programada %d descartada: %s", transition.ID, reason)
+	return false, nil
+}
+
+// RevokeAccessHook retira el acceso de un empleado dado de baja. El estado offboarded ya
+// impide iniciar sesión y usar tokens emitidos antes (ver middleware.AuthMiddleware);
+// además se cancelan las transiciones pendientes que reactivarían la cuenta, para que una
+// activación programada no deshaga la baja.
+type RevokeAccessHook struct{}
+
+// OnStatusChange implementa LifecycleHook.
+func (RevokeAccessHook) OnStatusChange(tx *gorm.DB, actor RequestActor, user *models.User, from, to models.UserStatus) error {
+	if to != models.StatusOffboarded {
+		return nil
+	}
+	result := tx.Model(&models.LifecycleTransition{}).
+		Where("user_id = ? AND state = ? AND to_status <> ?", user.ID, models.TransitionPending, models.StatusOffboarded).
+		Update("state", models.TransitionCancelled)
+	if result.Error != nil {
+		return fmt.Errorf("no se pudieron cancelar las transiciones pendientes: %w", result.Error)
+	}
+	return recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionUserAccessRevoked,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		After:      map[string]interface{}{"cancelled_transitions": result.RowsAffected},
+	})
+}
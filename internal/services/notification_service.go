@@ -0,0 +1,452 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/mailer"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationInput describe un aviso a enviar a un usuario.
+type NotificationInput struct {
+	Type  string
+	Title string
+	Body  string
+	Link  string // Ruta del frontend, p. ej. /cursos/12
+	Data  map[string]interface{}
+}
+
+// NotificationFilter define los filtros de GET /api/v1/me/notifications.
+type NotificationFilter struct {
+	UnreadOnly bool
+	Page       int
+	PageSize   int
+}
+
+// NotificationPage es una página del centro de notificaciones.
+type NotificationPage struct {
+	Items       []models.Notification `json:"items"`
+	Total       int64                 `json:"total"`
+	UnreadCount int64                 `json:"unread_count"`
+	Page        int                   `json:"page"`
+	PageSize    int                   `json:"page_size"`
+}
+
+// NotificationPreferenceDTO son los canales activos de un tipo de notificación.
+type NotificationPreferenceDTO struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	InApp       bool   `json:"in_app"`
+	Email       bool   `json:"email"`
+}
+
+// UpdateNotificationPreferencesDTO define el cuerpo de PUT /api/v1/me/notification-preferences.
+type UpdateNotificationPreferencesDTO struct {
+	Preferences []NotificationPreferenceDTO `json:"preferences" binding:"required,dive"`
+}
+
+// NotificationServiceInterface define el centro de notificaciones de cada usuario.
+type NotificationServiceInterface interface {
+	ListNotifications(userID uint, filter NotificationFilter) (*NotificationPage, error)
+	UnreadCount(userID uint) (int64, error)
+	MarkRead(userID, notificationID uint) (*models.Notification, error)
+	MarkAllRead(userID uint) (int64, error)
+	GetPreferences(userID uint) ([]NotificationPreferenceDTO, error)
+	UpdatePreferences(userID uint, dto UpdateNotificationPreferencesDTO) ([]NotificationPreferenceDTO, error)
+
+	// Stream: avisos posteriores a afterID y suscripción a avisos nuevos
+	NotificationsAfter(userID, afterID uint) ([]models.Notification, error)
+	LatestNotificationID(userID uint) (uint, error)
+	AccountActive(userID uint) (bool, error)
+	Subscribe(userID uint) (<-chan struct{}, func())
+}
+
+// NotificationHub avisa a las conexiones abiertas (Server-Sent Events) de un usuario que
+// tiene notificaciones nuevas. No transporta el contenido: cada conexión lo lee de la BD,
+// así que un aviso perdido solo retrasa la entrega hasta la siguiente consulta periódica.
+type NotificationHub struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan struct{}]struct{}
+}
+
+// NewNotificationHub crea un hub vacío.
+func NewNotificationHub() *NotificationHub {
+	return &NotificationHub{subscribers: map[uint]map[chan struct{}]struct{}{}}
+}
+
+// Subscribe registra una conexión del usuario. La función devuelta la da de baja.
+func (h *NotificationHub) Subscribe(userID uint) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = map[chan struct{}]struct{}{}
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers[userID], ch)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Publish despierta a las conexiones del usuario sin bloquear.
+func (h *NotificationHub) Publish(userID uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[userID] {
+		select {
+		case ch <- struct{}{}:
+		default: // Ya tiene un aviso pendiente
+		}
+	}
+}
+
+// NotificationService implementa NotificationServiceInterface y es el punto de entrada de
+// los demás servicios para avisar a un usuario (ver Notify).
+type NotificationService struct {
+	DB        *gorm.DB
+	Hub       *NotificationHub
+	Outbox    *mailer.Outbox
+	Templates *mailer.Templates
+	AppURL    string
+}
+
+// NewNotificationService crea una nueva instancia de NotificationService.
+func NewNotificationService(db *gorm.DB, hub *NotificationHub, outbox *mailer.Outbox, templates *mailer.Templates, appURL string) *NotificationService {
+	if hub == nil {
+		hub = NewNotificationHub()
+	}
+	return &NotificationService{DB: db, Hub: hub, Outbox: outbox, Templates: templates, AppURL: strings.TrimRight(appURL, "/")}
+}
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+	maxNotificationStreamBatch  = 100
+)
+
+// preferenceFor devuelve los canales del usuario para un tipo, con los valores del
+// catálogo si el usuario no los cambió.
+func preferenceFor(tx *gorm.DB, userID uint, notificationType string) (inApp, email bool, err error) {
+	info, ok := models.FindNotificationType(notificationType)
+	if !ok {
+		return false, false, fmt.Errorf("tipo de notificación desconocido: %s", notificationType)
+	}
+	var pref models.NotificationPreference
+	if err := tx.Where("user_id = ? AND type = ?", userID, notificationType).Limit(1).Find(&pref).Error; err != nil {
+		return false, false, err
+	}
+	if pref.ID == 0 {
+		return info.DefaultInApp, info.DefaultEmail, nil
+	}
+	return pref.InApp, pref.Email, nil
+}
+
+// notificationEmailData son los datos que reciben las plantillas "notification".
+type notificationEmailData struct {
+	Title string
+	Body  string
+	URL   string
+}
+
+// Notify entrega un aviso al usuario por los canales que tenga activos, usando tx (que
+// puede ser la transacción del cambio que lo origina): crea la notificación en la
+// aplicación y/o deja el correo en la bandeja de salida. Devuelve la notificación creada,
+// o nil si el usuario desactivó el canal in-app.
+//
+// Las conexiones abiertas se despiertan de inmediato; si tx aún no se confirmó, la
+// notificación les llega en su siguiente consulta periódica. Quien llama fuera de una
+// transacción puede usar NotifyNow.
+func (s *NotificationService) Notify(tx *gorm.DB, userID uint, in NotificationInput) (*models.Notification, error) {
+	inApp, email, err := preferenceFor(tx, userID, in.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	var notification *models.Notification
+	if inApp {
+		notification = &models.Notification{
+			UserID: userID,
+			Type:   in.Type,
+			Title:  in.Title,
+			Body:   in.Body,
+			Link:   in.Link,
+			Data:   in.Data,
+		}
+		if err := tx.Create(notification).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if email && s.Outbox != nil && s.Templates != nil {
+		var detail models.EmployeeDetail
+		if err := tx.Select("email").Where("user_id = ?", userID).Limit(1).Find(&detail).Error; err != nil {
+			return nil, err
+		}
+		if detail.Email != "" {
+			data := notificationEmailData{Title: in.Title, Body: in.Body}
+			if in.Link != "" {
+				data.URL = s.AppURL + in.Link
+			}
+			msg, err := s.Templates.Render("notification", "", data)
+			if err != nil {
+				return nil, err
+			}
+			msg.To = []string{detail.Email}
+			if _, err := s.Outbox.Enqueue(tx, msg); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if notification != nil {
+		s.Hub.Publish(userID)
+	}
+	return notification, nil
+}
+
+// NotifyNow es Notify en su propia transacción.
+func (s *NotificationService) NotifyNow(userID uint, in NotificationInput) (*models.Notification, error) {
+	tx := s.DB.Begin()
+	notification, err := s.Notify(tx, userID, in)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	if notification != nil {
+		s.Hub.Publish(userID) // Ya confirmada: las conexiones la ven de inmediato
+	}
+	return notification, nil
+}
+
+// ListNotifications devuelve las notificaciones del usuario, de la más reciente a la más antigua.
+func (s *NotificationService) ListNotifications(userID uint, filter NotificationFilter) (*NotificationPage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultNotificationPageSize
+	}
+	if filter.PageSize > maxNotificationPageSize {
+		filter.PageSize = maxNotificationPageSize
+	}
+
+	query := s.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if filter.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	page := &NotificationPage{Page: filter.Page, PageSize: filter.PageSize}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		log.Printf("Error al contar notificaciones del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudieron obtener las notificaciones")
+	}
+	if err := query.Order("id DESC").Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&page.Items).Error; err != nil {
+		log.Printf("Error al listar notificaciones del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudieron obtener las notificaciones")
+	}
+	unread, err := s.UnreadCount(userID)
+	if err != nil {
+		return nil, err
+	}
+	page.UnreadCount = unread
+	return page, nil
+}
+
+// UnreadCount cuenta las notificaciones sin leer del usuario.
+func (s *NotificationService) UnreadCount(userID uint) (int64, error) {
+	var count int64
+	if err := s.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error; err != nil {
+		log.Printf("Error al contar notificaciones sin leer del usuario %d: %v", userID, err)
+		return 0, errors.New("no se pudieron obtener las notificaciones")
+	}
+	return count, nil
+}
+
+// MarkRead marca una notificación propia como leída. Es idempotente.
+func (s *NotificationService) MarkRead(userID, notificationID uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := s.DB.Where("id = ? AND user_id = ?", notificationID, userID).Limit(1).Find(&notification).Error; err != nil {
+		log.Printf("Error al buscar la notificación %d: %v", notificationID, err)
+		return nil, errors.New("no se pudo actualizar la notificación")
+	}
+	if notification.ID == 0 {
+		return nil, errors.New("notificación no encontrada")
+	}
+	if notification.ReadAt != nil {
+		return &notification, nil
+	}
+	now := time.Now()
+	if err := s.DB.Model(&notification).Where("read_at IS NULL").Update("read_at", now).Error; err != nil {
+		log.Printf("Error al marcar como leída la notificación %d: %v", notificationID, err)
+		return nil, errors.New("no se pudo actualizar la notificación")
+	}
+	notification.ReadAt = &now
+	// Las demás conexiones del usuario actualizan su contador de no leídas.
+	s.Hub.Publish(userID)
+	return &notification, nil
+}
+
+// MarkAllRead marca como leídas todas las notificaciones del usuario y devuelve cuántas cambiaron.
+func (s *NotificationService) MarkAllRead(userID uint) (int64, error) {
+	result := s.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", time.Now())
+	if result.Error != nil {
+		log.Printf("Error al marcar como leídas las notificaciones del usuario %d: %v", userID, result.Error)
+		return 0, errors.New("no se pudieron actualizar las notificaciones")
+	}
+	if result.RowsAffected > 0 {
+		s.Hub.Publish(userID)
+	}
+	return result.RowsAffected, nil
+}
+
+// GetPreferences devuelve los canales de todos los tipos del catálogo para el usuario.
+func (s *NotificationService) GetPreferences(userID uint) ([]NotificationPreferenceDTO, error) {
+	var stored []models.NotificationPreference
+	if err := s.DB.Where("user_id = ?", userID).Find(&stored).Error; err != nil {
+		log.Printf("Error al obtener preferencias de notificación del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudieron obtener las preferencias")
+	}
+	byType := make(map[string]models.NotificationPreference, len(stored))
+	for _, pref := range stored {
+		byType[pref.Type] = pref
+	}
+	prefs := make([]NotificationPreferenceDTO, 0, len(models.NotificationTypes))
+	for _, info := range models.NotificationTypes {
+		dto := NotificationPreferenceDTO{Type: info.Type, Description: info.Description, InApp: info.DefaultInApp, Email: info.DefaultEmail}
+		if pref, ok := byType[info.Type]; ok {
+			dto.InApp, dto.Email = pref.InApp, pref.Email
+		}
+		prefs = append(prefs, dto)
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences guarda los canales de los tipos indicados; los demás no cambian.
+func (s *NotificationService) UpdatePreferences(userID uint, dto UpdateNotificationPreferencesDTO) ([]NotificationPreferenceDTO, error) {
+	rows := make([]models.NotificationPreference, 0, len(dto.Preferences))
+	for _, pref := range dto.Preferences {
+		if _, ok := models.FindNotificationType(pref.Type); !ok {
+			return nil, fmt.Errorf("tipo de notificación desconocido: %s", pref.Type)
+		}
+		rows = append(rows, models.NotificationPreference{UserID: userID, Type: pref.Type, InApp: pref.InApp, Email: pref.Email})
+	}
+	if len(rows) > 0 {
+		err := s.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "updated_at"}),
+		}).Create(&rows).Error
+		if err != nil {
+			log.Printf("Error al guardar preferencias de notificación del usuario %d: %v", userID, err)
+			return nil, errors.New("no se pudieron guardar las preferencias")
+		}
+	}
+	return s.GetPreferences(userID)
+}
+
+// NotificationsAfter devuelve las notificaciones del usuario con ID mayor que afterID, en
+// orden de creación.
+func (s *NotificationService) NotificationsAfter(userID, afterID uint) ([]models.Notification, error) {
+	var notifications []models.Notification
+	if err := s.DB.Where("user_id = ? AND id > ?", userID, afterID).Order("id").
+		Limit(maxNotificationStreamBatch).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// LatestNotificationID devuelve el ID de la última notificación del usuario (0 si no tiene).
+func (s *NotificationService) LatestNotificationID(userID uint) (uint, error) {
+	var latest models.Notification
+	if err := s.DB.Select("id").Where("user_id = ?", userID).Order("id DESC").Limit(1).Find(&latest).Error; err != nil {
+		return 0, err
+	}
+	return latest.ID, nil
+}
+
+// AccountActive indica si el usuario todavía puede usar la aplicación. Las conexiones en
+// tiempo real lo consultan periódicamente para cortarse tras una baja.
+func (s *NotificationService) AccountActive(userID uint) (bool, error) {
+	var user models.User
+	if err := s.DB.Select("status").Where("id = ?", userID).Limit(1).Find(&user).Error; err != nil {
+		return false, err
+	}
+	return user.Status.CanLogin(), nil
+}
+
+// Subscribe registra una conexión en tiempo real del usuario.
+func (s *NotificationService) Subscribe(userID uint) (<-chan struct{}, func()) {
+	return s.Hub.Subscribe(userID)
+}
+
+// NotificationEventSubscriber avisa al empleado de los cambios en su cuenta a partir de
+// los eventos de dominio: cuando entra o vuelve de una licencia y cuando cambia su rol.
+// Las altas y bajas no se notifican: en ambos casos el usuario no puede ver el aviso
+// (todavía no o ya no tiene acceso).
+type NotificationEventSubscriber struct {
+	Notifications *NotificationService
+}
+
+// Name implementa events.Subscriber.
+func (NotificationEventSubscriber) Name() string { return "notifications" }
+
+// Handles implementa events.Subscriber.
+func (NotificationEventSubscriber) Handles(eventType string) bool {
+	return eventType == models.DomainEventUserStatusChanged || eventType == models.DomainEventUserRoleChanged
+}
+
+// Handle implementa events.Subscriber.
+func (h NotificationEventSubscriber) Handle(tx *gorm.DB, event *models.DomainEvent) error {
+	var in NotificationInput
+	switch event.Type {
+	case models.DomainEventUserStatusChanged:
+		var change statusChangePayload
+		if err := json.Unmarshal(event.Payload, &change); err != nil {
+			return fmt.Errorf("evento %s mal formado: %w", event.EventID, err)
+		}
+		switch {
+		case change.To == models.StatusOnLeave:
+			in.Title = "Tu cuenta quedó en licencia"
+		case change.From == models.StatusOnLeave && change.To == models.StatusActive:
+			in.Title = "Tu licencia terminó y tu cuenta vuelve a estar activa"
+		default:
+			return nil
+		}
+		in.Type = models.NotificationAccountStatus
+		in.Data = map[string]interface{}{"from": string(change.From), "to": string(change.To)}
+	case models.DomainEventUserRoleChanged:
+		var change roleChangePayload
+		if err := json.Unmarshal(event.Payload, &change); err != nil {
+			return fmt.Errorf("evento %s mal formado: %w", event.EventID, err)
+		}
+		in.Type = models.NotificationAccountRole
+		in.Title = fmt.Sprintf("Tu rol cambió a %s", change.To)
+		in.Data = map[string]interface{}{"from": string(change.From), "to": string(change.To)}
+	default:
+		return nil
+	}
+	in.Link = "/perfil"
+	_, err := h.Notifications.Notify(tx, event.AggregateID, in)
+	return err
+}
+
+// Committed implementa events.CommitObserver: la notificación ya es visible, así que se
+// despiertan las conexiones abiertas del usuario.
+func (h NotificationEventSubscriber) Committed(event *models.DomainEvent) {
+	h.Notifications.Hub.Publish(event.AggregateID)
+}
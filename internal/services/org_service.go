@@ -0,0 +1,733 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DepartmentInputDTO define los datos para crear o actualizar un departamento.
+// ParentID en 0 deja al departamento en la raíz de la jerarquía.
+type DepartmentInputDTO struct {
+	Name        string `json:"name" binding:"required,min=1,max=100"`
+	Description string `json:"description" binding:"max=255"`
+	ParentID    *uint  `json:"parent_id"`
+}
+
+// TeamInputDTO define los datos para crear o actualizar un equipo.
+type TeamInputDTO struct {
+	Name         string `json:"name" binding:"required,min=1,max=100"`
+	Description  string `json:"description" binding:"max=255"`
+	DepartmentID uint   `json:"department_id" binding:"required"`
+}
+
+// OrgChartNode es un empleado dentro del organigrama, con sus reportes directos.
+type OrgChartNode struct {
+	UserID       uint            `json:"user_id"`
+	Username     string          `json:"username"`
+	Name         string          `json:"name"`
+	LastName     string          `json:"last_name"`
+	Position     string          `json:"position,omitempty"`
+	DepartmentID *uint           `json:"department_id,omitempty"`
+	TeamID       *uint           `json:"team_id,omitempty"`
+	Reports      []*OrgChartNode `json:"reports"`
+}
+
+// OrgAssignmentError indica que la asignación de departamento, equipo o responsable
+// de un empleado no es válida (referencia inexistente, inconsistente o que crea un ciclo).
+type OrgAssignmentError struct {
+	Reason string
+}
+
+func (e *OrgAssignmentError) Error() string {
+	return e.Reason
+}
+
+// OrgServiceInterface define la gestión de la estructura organizativa.
+type OrgServiceInterface interface {
+	ListDepartments() ([]models.Department, error)
+	GetDepartment(id uint) (*models.Department, error)
+	CreateDepartment(actor RequestActor, dto DepartmentInputDTO) (*models.Department, error)
+	UpdateDepartment(actor RequestActor, id uint, dto DepartmentInputDTO) (*models.Department, error)
+	DeleteDepartment(actor RequestActor, id uint) error
+	// DepartmentMemberIDs devuelve los usuarios del departamento (y opcionalmente de sus
+	// subdepartamentos), p. ej. para asignar formaciones o generar reportes.
+	DepartmentMemberIDs(id uint, includeSubdepartments bool) ([]uint, error)
+
+	ListTeams(departmentID *uint) ([]models.Team, error)
+	GetTeam(id uint) (*models.Team, error)
+	CreateTeam(actor RequestActor, dto TeamInputDTO) (*models.Team, error)
+	UpdateTeam(actor RequestActor, id uint, dto TeamInputDTO) (*models.Team, error)
+	DeleteTeam(actor RequestActor, id uint) error
+
+	// GetOrgChart devuelve el organigrama completo, o el subárbol que cuelga de rootUserID.
+	GetOrgChart(rootUserID *uint) ([]*OrgChartNode, error)
+}
+
+// OrgService implementa OrgServiceInterface.
+type OrgService struct {
+	DB *gorm.DB
+}
+
+// NewOrgService crea una nueva instancia de OrgService.
+func NewOrgService(db *gorm.DB) *OrgService {
+	return &OrgService{DB: db}
+}
+
+func departmentAuditSnapshot(d *models.Department) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          d.ID,
+		"name":        d.Name,
+		"description": d.Description,
+		"parent_id":   optionalIDValue(d.ParentID),
+	}
+}
+
+func teamAuditSnapshot(t *models.Team) map[string]interface{} {
+	return map[string]interface{}{
+		"id":            t.ID,
+		"name":          t.Name,
+		"description":   t.Description,
+		"department_id": t.DepartmentID,
+	}
+}
+
+// isDuplicateKeyError detecta violaciones de índice único en MySQL (error 1062).
+func isDuplicateKeyError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "1062")
+}
+
+// ListDepartments devuelve todos los departamentos ordenados por nombre.
+func (s *OrgService) ListDepartments() ([]models.Department, error) {
+	var departments []models.Department
+	if err := s.DB.Order("name ASC").Find(&departments).Error; err != nil {
+		log.Printf("Error al listar departamentos: %v", err)
+		return nil, errors.New("no se pudo obtener la lista de departamentos")
+	}
+	return departments, nil
+}
+
+// GetDepartment recupera un departamento por su ID.
+func (s *OrgService) GetDepartment(id uint) (*models.Department, error) {
+	var department models.Department
+	if err := s.DB.First(&department, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("departamento no encontrado")
+		}
+		log.Printf("Error al obtener departamento %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener el departamento")
+	}
+	return &department, nil
+}
+
+// validateDepartmentParent comprueba que el padre exista y que asignarlo a id no cree
+// un ciclo (el departamento no puede colgar de sí mismo ni de uno de sus descendientes).
+func validateDepartmentParent(tx *gorm.DB, id uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	exists, err := departmentExists(tx, *parentID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return &OrgAssignmentError{Reason: "departamento padre no encontrado"}
+	}
+
+	visited := map[uint]bool{}
+	current := *parentID
+	for {
+		if current == id {
+			return &OrgAssignmentError{Reason: "el departamento padre crea un ciclo en la jerarquía"}
+		}
+		if visited[current] {
+			return nil
+		}
+		visited[current] = true
+
+		var parent models.Department
+		if err := tx.Select("parent_id").Where("id = ?", current).Limit(1).Find(&parent).Error; err != nil {
+			return err
+		}
+		if parent.ParentID == nil {
+			return nil
+		}
+		current = *parent.ParentID
+	}
+}
+
+// normalizeOptionalID convierte un ID 0 en nil ("sin asignar").
+func normalizeOptionalID(id *uint) *uint {
+	if id == nil || *id == 0 {
+		return nil
+	}
+	value := *id
+	return &value
+}
+
+// CreateDepartment crea un departamento.
+func (s *OrgService) CreateDepartment(actor RequestActor, dto DepartmentInputDTO) (*models.Department, error) {
+	department := models.Department{
+		Name:        strings.TrimSpace(dto.Name),
+		Description: dto.Description,
+		ParentID:    normalizeOptionalID(dto.ParentID),
+	}
+
+	tx := s.DB.Begin()
+	if err := validateDepartmentParent(tx, 0, department.ParentID); err != nil {
+		tx.Rollback()
+		return nil, orgValidationFailure("crear departamento", err)
+	}
+	if err := tx.Create(&department).Error; err != nil {
+		tx.Rollback()
+		if isDuplicateKeyError(err) {
+			return nil, errors.New("ya existe un departamento con ese nombre")
+		}
+		log.Printf("Error al crear departamento: %v", err)
+		return nil, errors.New("no se pudo crear el departamento")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionDepartmentCreated,
+		TargetType: models.AuditTargetDepartment,
+		TargetID:   department.ID,
+		After:      departmentAuditSnapshot(&department),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar creación del departamento: %v", err)
+		return nil, errors.New("no se pudo crear el departamento")
+	}
+	tx.Commit()
+	return &department, nil
+}
+
+// UpdateDepartment actualiza un departamento existente.
+func (s *OrgService) UpdateDepartment(actor RequestActor, id uint, dto DepartmentInputDTO) (*models.Department, error) {
+	var department models.Department
+	tx := s.DB.Begin()
+	if err := tx.First(&department, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("departamento no encontrado")
+		}
+		log.Printf("Error al buscar departamento %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el departamento")
+	}
+	before := departmentAuditSnapshot(&department)
+
+	department.Name = strings.TrimSpace(dto.Name)
+	department.Description = dto.Description
+	department.ParentID = normalizeOptionalID(dto.ParentID)
+	if err := validateDepartmentParent(tx, department.ID, department.ParentID); err != nil {
+		tx.Rollback()
+		return nil, orgValidationFailure("actualizar departamento", err)
+	}
+
+	if err := tx.Select("name", "description", "parent_id", "updated_at").Updates(&department).Error; err != nil {
+		tx.Rollback()
+		if isDuplicateKeyError(err) {
+			return nil, errors.New("ya existe un departamento con ese nombre")
+		}
+		log.Printf("Error al actualizar departamento %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el departamento")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionDepartmentUpdated,
+		TargetType: models.AuditTargetDepartment,
+		TargetID:   department.ID,
+		Before:     before,
+		After:      departmentAuditSnapshot(&department),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar actualización del departamento %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el departamento")
+	}
+	tx.Commit()
+	return &department, nil
+}
+
+// DeleteDepartment elimina un departamento vacío (sin subdepartamentos, equipos ni empleados).
+func (s *OrgService) DeleteDepartment(actor RequestActor, id uint) error {
+	var department models.Department
+	tx := s.DB.Begin()
+	if err := tx.First(&department, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("departamento no encontrado")
+		}
+		log.Printf("Error al buscar departamento %d: %v", id, err)
+		return errors.New("no se pudo eliminar el departamento")
+	}
+
+	checks := []struct {
+		model  interface{}
+		column string
+		reason string
+	}{
+		{&models.Department{}, "parent_id", "el departamento tiene subdepartamentos"},
+		{&models.Team{}, "department_id", "el departamento tiene equipos"},
+		{&models.EmployeeDetail{}, "department_id", "el departamento tiene empleados asignados"},
+	}
+	for _, check := range checks {
+		var count int64
+		if err := tx.Model(check.model).Where(check.column+" = ?", id).Count(&count).Error; err != nil {
+			tx.Rollback()
+			log.Printf("Error al comprobar dependencias del departamento %d: %v", id, err)
+			return errors.New("no se pudo eliminar el departamento")
+		}
+		if count > 0 {
+			tx.Rollback()
+			return &OrgAssignmentError{Reason: check.reason}
+		}
+	}
+
+	if err := tx.Delete(&department).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar departamento %d: %v", id, err)
+		return errors.New("no se pudo eliminar el departamento")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionDepartmentDeleted,
+		TargetType: models.AuditTargetDepartment,
+		TargetID:   department.ID,
+		Before:     departmentAuditSnapshot(&department),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar eliminación del departamento %d: %v", id, err)
+		return errors.New("no se pudo eliminar el departamento")
+	}
+	tx.Commit()
+	return nil
+}
+
+// DepartmentMemberIDs devuelve los IDs de usuario asignados al departamento y,
+// si se indica, a todos sus subdepartamentos.
+func (s *OrgService) DepartmentMemberIDs(id uint, includeSubdepartments bool) ([]uint, error) {
+	if _, err := s.GetDepartment(id); err != nil {
+		return nil, err
+	}
+
+	departmentIDs := []uint{id}
+	if includeSubdepartments {
+		var all []models.Department
+		if err := s.DB.Select("id", "parent_id").Find(&all).Error; err != nil {
+			log.Printf("Error al leer departamentos: %v", err)
+			return nil, errors.New("no se pudo obtener los miembros del departamento")
+		}
+		children := map[uint][]uint{}
+		for _, d := range all {
+			if d.ParentID != nil {
+				children[*d.ParentID] = append(children[*d.ParentID], d.ID)
+			}
+		}
+		for i := 0; i < len(departmentIDs); i++ {
+			departmentIDs = append(departmentIDs, children[departmentIDs[i]]...)
+		}
+	}
+
+	var userIDs []uint
+	err := s.DB.Model(&models.EmployeeDetail{}).
+		Joins("JOIN users ON users.id = employee_details.user_id AND users.deleted_at IS NULL").
+		Where("employee_details.department_id IN ?", departmentIDs).
+		Order("employee_details.user_id").
+		Pluck("employee_details.user_id", &userIDs).Error
+	if err != nil {
+		log.Printf("Error al obtener miembros del departamento %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener los miembros del departamento")
+	}
+	return userIDs, nil
+}
+
+// ListTeams devuelve los equipos, opcionalmente filtrados por departamento.
+func (s *OrgService) ListTeams(departmentID *uint) ([]models.Team, error) {
+	query := s.DB.Order("name ASC")
+	if departmentID != nil {
+		query = query.Where("department_id = ?", *departmentID)
+	}
+	var teams []models.Team
+	if err := query.Find(&teams).Error; err != nil {
+		log.Printf("Error al listar equipos: %v", err)
+		return nil, errors.New("no se pudo obtener la lista de equipos")
+	}
+	return teams, nil
+}
+
+// GetTeam recupera un equipo por su ID.
+func (s *OrgService) GetTeam(id uint) (*models.Team, error) {
+	var team models.Team
+	if err := s.DB.First(&team, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("equipo no encontrado")
+		}
+		log.Printf("Error al obtener equipo %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener el equipo")
+	}
+	return &team, nil
+}
+
+func departmentExists(tx *gorm.DB, id uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Department{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// CreateTeam crea un equipo dentro de un departamento.
+func (s *OrgService) CreateTeam(actor RequestActor, dto TeamInputDTO) (*models.Team, error) {
+	team := models.Team{
+		Name:         strings.TrimSpace(dto.Name),
+		Description:  dto.Description,
+		DepartmentID: dto.DepartmentID,
+	}
+
+	tx := s.DB.Begin()
+	exists, err := departmentExists(tx, team.DepartmentID)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al comprobar departamento %d: %v", team.DepartmentID, err)
+		return nil, errors.New("no se pudo crear el equipo")
+	}
+	if !exists {
+		tx.Rollback()
+		return nil, &OrgAssignmentError{Reason: "departamento no encontrado"}
+	}
+	if err := tx.Create(&team).Error; err != nil {
+		tx.Rollback()
+		if isDuplicateKeyError(err) {
+			return nil, errors.New("ya existe un equipo con ese nombre en el departamento")
+		}
+		log.Printf("Error al crear equipo: %v", err)
+		return nil, errors.New("no se pudo crear el equipo")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionTeamCreated,
+		TargetType: models.AuditTargetTeam,
+		TargetID:   team.ID,
+		After:      teamAuditSnapshot(&team),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar creación del equipo: %v", err)
+		return nil, errors.New("no se pudo crear el equipo")
+	}
+	tx.Commit()
+	return &team, nil
+}
+
+// UpdateTeam actualiza un equipo. Solo puede cambiar de departamento si no tiene
+// miembros, para que ningún empleado quede con un equipo de otro departamento.
+func (s *OrgService) UpdateTeam(actor RequestActor, id uint, dto TeamInputDTO) (*models.Team, error) {
+	var team models.Team
+	tx := s.DB.Begin()
+	if err := tx.First(&team, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("equipo no encontrado")
+		}
+		log.Printf("Error al buscar equipo %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el equipo")
+	}
+	before := teamAuditSnapshot(&team)
+
+	movedDepartment := dto.DepartmentID != team.DepartmentID
+	if movedDepartment {
+		exists, err := departmentExists(tx, dto.DepartmentID)
+		if err != nil {
+			tx.Rollback()
+			log.Printf("Error al comprobar departamento %d: %v", dto.DepartmentID, err)
+			return nil, errors.New("no se pudo actualizar el equipo")
+		}
+		if !exists {
+			tx.Rollback()
+			return nil, &OrgAssignmentError{Reason: "departamento no encontrado"}
+		}
+		var members int64
+		if err := tx.Model(&models.EmployeeDetail{}).Where("team_id = ?", team.ID).Count(&members).Error; err != nil {
+			tx.Rollback()
+			log.Printf("Error al contar miembros del equipo %d: %v", id, err)
+			return nil, errors.New("no se pudo actualizar el equipo")
+		}
+		if members > 0 {
+			tx.Rollback()
+			return nil, &OrgAssignmentError{Reason: "el equipo tiene empleados asignados; reasígnelos antes de cambiarlo de departamento"}
+		}
+	}
+
+	team.Name = strings.TrimSpace(dto.Name)
+	team.Description = dto.Description
+	team.DepartmentID = dto.DepartmentID
+	if err := tx.Select("name", "description", "department_id", "updated_at").Updates(&team).Error; err != nil {
+		tx.Rollback()
+		if isDuplicateKeyError(err) {
+			return nil, errors.New("ya existe un equipo con ese nombre en el departamento")
+		}
+		log.Printf("Error al actualizar equipo %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el equipo")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionTeamUpdated,
+		TargetType: models.AuditTargetTeam,
+		TargetID:   team.ID,
+		Before:     before,
+		After:      teamAuditSnapshot(&team),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar actualización del equipo %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el equipo")
+	}
+	tx.Commit()
+	return &team, nil
+}
+
+// DeleteTeam elimina un equipo sin miembros.
+func (s *OrgService) DeleteTeam(actor RequestActor, id uint) error {
+	var team models.Team
+	tx := s.DB.Begin()
+	if err := tx.First(&team, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("equipo no encontrado")
+		}
+		log.Printf("Error al buscar equipo %d: %v", id, err)
+		return errors.New("no se pudo eliminar el equipo")
+	}
+
+	var members int64
+	if err := tx.Model(&models.EmployeeDetail{}).Where("team_id = ?", id).Count(&members).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al contar miembros del equipo %d: %v", id, err)
+		return errors.New("no se pudo eliminar el equipo")
+	}
+	if members > 0 {
+		tx.Rollback()
+		return &OrgAssignmentError{Reason: "el equipo tiene empleados asignados"}
+	}
+
+	if err := tx.Delete(&team).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar equipo %d: %v", id, err)
+		return errors.New("no se pudo eliminar el equipo")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionTeamDeleted,
+		TargetType: models.AuditTargetTeam,
+		TargetID:   team.ID,
+		Before:     teamAuditSnapshot(&team),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar eliminación del equipo %d: %v", id, err)
+		return errors.New("no se pudo eliminar el equipo")
+	}
+	tx.Commit()
+	return nil
+}
+
+// GetOrgChart arma el organigrama a partir de la relación empleado → responsable.
+// Sin rootUserID devuelve un árbol por cada empleado sin responsable (o cuyo responsable
+// ya no existe); con rootUserID devuelve solo el subárbol de ese empleado. Si los datos
+// tienen un ciclo de reporte, se corta (ver breakReportingCycles): el resultado siempre es
+// un árbol y se puede serializar.
+func (s *OrgService) GetOrgChart(rootUserID *uint) ([]*OrgChartNode, error) {
+	var users []models.User
+	if err := s.DB.Preload("EmployeeDetail").Order("id ASC").Find(&users).Error; err != nil {
+		log.Printf("Error al leer usuarios para el organigrama: %v", err)
+		return nil, errors.New("no se pudo generar el organigrama")
+	}
+
+	nodes := make(map[uint]*OrgChartNode, len(users))
+	for _, u := range users {
+		d := u.EmployeeDetail
+		nodes[u.ID] = &OrgChartNode{
+			UserID:       u.ID,
+			Username:     u.Username,
+			Name:         d.Name,
+			LastName:     d.LastName,
+			Position:     d.Position,
+			DepartmentID: d.DepartmentID,
+			TeamID:       d.TeamID,
+			Reports:      []*OrgChartNode{},
+		}
+	}
+
+	parents := make(map[uint]uint, len(users))
+	order := make([]uint, 0, len(users))
+	for _, u := range users {
+		order = append(order, u.ID)
+		managerID := u.EmployeeDetail.ManagerID
+		if managerID != nil && *managerID != u.ID {
+			if _, ok := nodes[*managerID]; ok {
+				parents[u.ID] = *managerID
+			}
+		}
+	}
+	breakReportingCycles(parents, order)
+
+	var roots []*OrgChartNode
+	for _, id := range order {
+		node := nodes[id]
+		if managerID, ok := parents[id]; ok {
+			nodes[managerID].Reports = append(nodes[managerID].Reports, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+	for _, node := range nodes {
+		sort.Slice(node.Reports, func(i, j int) bool {
+			return node.Reports[i].UserID < node.Reports[j].UserID
+		})
+	}
+
+	if rootUserID != nil {
+		root, ok := nodes[*rootUserID]
+		if !ok {
+			return nil, errors.New("usuario no encontrado")
+		}
+		return []*OrgChartNode{root}, nil
+	}
+	if roots == nil {
+		roots = []*OrgChartNode{}
+	}
+	return roots, nil
+}
+
+// breakReportingCycles corta los ciclos de la relación empleado → responsable (parents) para
+// que el organigrama sea un bosque: la API no los permite, pero pueden venir de datos
+// cargados por fuera. En cada ciclo, el empleado de menor ID queda como raíz. order fija
+// el orden de recorrido para que el resultado sea estable.
+func breakReportingCycles(parents map[uint]uint, order []uint) {
+	const (
+		unvisited = iota
+		inPath
+		done
+	)
+	state := make(map[uint]int, len(order))
+	for _, start := range order {
+		var path []uint
+		id := start
+		for {
+			if state[id] == inPath {
+				// id ya está en el camino actual: desde él hasta el final hay un ciclo.
+				cycle := path
+				for i, v := range path {
+					if v == id {
+						cycle = path[i:]
+						break
+					}
+				}
+				root := cycle[0]
+				for _, v := range cycle {
+					if v < root {
+						root = v
+					}
+				}
+				delete(parents, root)
+				log.Printf("Ciclo de reporte en el organigrama %v: el usuario %d se muestra como raíz", cycle, root)
+				break
+			}
+			if state[id] == done {
+				break
+			}
+			state[id] = inPath
+			path = append(path, id)
+			next, ok := parents[id]
+			if !ok {
+				break
+			}
+			id = next
+		}
+		for _, v := range path {
+			state[v] = done
+		}
+	}
+}
+
+// validateOrgAssignment comprueba el departamento, equipo y responsable de un empleado.
+// Si solo se indicó el equipo, el departamento se completa a partir de él. userID es 0
+// para un usuario que aún no existe (que no puede formar parte de ningún ciclo).
+func validateOrgAssignment(tx *gorm.DB, userID uint, detail *models.EmployeeDetail) error {
+	if detail.DepartmentID != nil {
+		exists, err := departmentExists(tx, *detail.DepartmentID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return &OrgAssignmentError{Reason: "departamento no encontrado"}
+		}
+	}
+
+	if detail.TeamID != nil {
+		var team models.Team
+		if err := tx.First(&team, *detail.TeamID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &OrgAssignmentError{Reason: "equipo no encontrado"}
+			}
+			return err
+		}
+		if detail.DepartmentID == nil {
+			departmentID := team.DepartmentID
+			detail.DepartmentID = &departmentID
+		} else if *detail.DepartmentID != team.DepartmentID {
+			return &OrgAssignmentError{Reason: "el equipo no pertenece al departamento indicado"}
+		}
+	}
+
+	if detail.ManagerID == nil {
+		return nil
+	}
+	if userID != 0 && *detail.ManagerID == userID {
+		return &OrgAssignmentError{Reason: "un empleado no puede ser su propio responsable"}
+	}
+	var manager models.User
+	if err := tx.Select("id").First(&manager, *detail.ManagerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &OrgAssignmentError{Reason: "responsable no encontrado"}
+		}
+		return err
+	}
+	if userID == 0 {
+		return nil
+	}
+
+	// Subir por la cadena de responsables del nuevo responsable: si se llega al propio
+	// empleado, la asignación cerraría un ciclo de reporte. Las filas de la cadena (y la del
+	// empleado) se bloquean hasta el final de la transacción: así dos cambios de responsable
+	// simultáneos no pueden cerrar un ciclo entre los dos sin que uno vea al otro.
+	locked := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	var own models.EmployeeDetail
+	if err := locked.Select("id").Where("user_id = ?", userID).Limit(1).Find(&own).Error; err != nil {
+		return err
+	}
+	visited := map[uint]bool{}
+	current := *detail.ManagerID
+	for {
+		if current == userID {
+			return &OrgAssignmentError{Reason: "la asignación de responsable crea un ciclo de reporte"}
+		}
+		if visited[current] {
+			return &OrgAssignmentError{Reason: "la cadena de responsables del responsable indicado contiene un ciclo"}
+		}
+		visited[current] = true
+
+		var next models.EmployeeDetail
+		if err := locked.Select("manager_id").Where("user_id = ?", current).Limit(1).Find(&next).Error; err != nil {
+			return err
+		}
+		if next.ManagerID == nil {
+			return nil
+		}
+		current = *next.ManagerID
+	}
+}
+
+// orgValidationFailure deja pasar los errores de validación y registra los de BD.
+func orgValidationFailure(operation string, err error) error {
+	var assignmentErr *OrgAssignmentError
+	if errors.As(err, &assignmentErr) {
+		return err
+	}
+	log.Printf("Error al validar estructura organizativa (%s): %v", operation, err)
+	return fmt.Errorf("no se pudo %s", operation)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBreakReportingCycles(t *testing.T) {
+	tests := []struct {
+		name    string
+		parents map[uint]uint
+		order   []uint
+		want    map[uint]uint
+	}{
+		{
+			name:    "árbol sin ciclos",
+			parents: map[uint]uint{2: 1, 3: 1, 4: 2},
+			order:   []uint{1, 2, 3, 4},
+			want:    map[uint]uint{2: 1, 3: 1, 4: 2},
+		},
+		{
+			name:    "ciclo entre dos",
+			parents: map[uint]uint{1: 2, 2: 1, 3: 2},
+			order:   []uint{1, 2, 3},
+			want:    map[uint]uint{2: 1, 3: 2},
+		},
+		{
+			name:    "ciclo colgado de una rama",
+			parents: map[uint]uint{5: 4, 4: 6, 6: 5, 7: 5},
+			order:   []uint{4, 5, 6, 7},
+			want:    map[uint]uint{5: 4, 6: 5, 7: 5},
+		},
+		{
+			name:    "el resultado no depende del orden",
+			parents: map[uint]uint{5: 4, 4: 6, 6: 5, 7: 5},
+			order:   []uint{7, 6, 5, 4},
+			want:    map[uint]uint{5: 4, 6: 5, 7: 5},
+		},
+		{
+			name:    "dos ciclos",
+			parents: map[uint]uint{1: 2, 2: 1, 3: 4, 4: 5, 5: 3},
+			order:   []uint{1, 2, 3, 4, 5},
+			want:    map[uint]uint{2: 1, 4: 5, 5: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakReportingCycles(tt.parents, tt.order)
+			if !reflect.DeepEqual(tt.parents, tt.want) {
+				t.Fatalf("responsables = %v, se esperaba %v", tt.parents, tt.want)
+			}
+			// Desde cualquier empleado se llega a una raíz en a lo sumo len(order) pasos.
+			for _, id := range tt.order {
+				current, steps := id, 0
+				for {
+					next, ok := tt.parents[current]
+					if !ok {
+						break
+					}
+					if steps++; steps > len(tt.order) {
+						t.Fatalf("el usuario %d sigue en un ciclo", id)
+					}
+					current = next
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// SelfEditableProfileFieldCandidates son los únicos campos del perfil que un administrador
+// puede habilitar para autoedición. Campos como el cargo (position) o el rol quedan
+// siempre reservados a los administradores.
+var SelfEditableProfileFieldCandidates = []string{"name", "last_name", "email", "phone_number"}
+
+// defaultSelfEditableProfileFields se usa mientras ningún administrador haya configurado la lista.
+var defaultSelfEditableProfileFields = []string{"phone_number"}
+
+// ProfileServiceInterface define las operaciones de autoservicio sobre el perfil del usuario autenticado.
+type ProfileServiceInterface interface {
+	GetOwnProfile(userID uint) (*UserDetailDTO, error)
+	UpdateOwnProfile(actor RequestActor, ifMatch string, dto SelfUpdateProfileDTO) (*UserDetailDTO, error)
+
+	// Configuración administrable de los campos autoeditables
+	GetSelfEditableFields() ([]string, error)
+	SetSelfEditableFields(actor RequestActor, fields []string) ([]string, error)
+}
+
+// ProfileService implementa ProfileServiceInterface.
+type ProfileService struct {
+	DB *gorm.DB
+}
+
+// NewProfileService crea una nueva instancia de ProfileService.
+func NewProfileService(db *gorm.DB) *ProfileService {
+	return &ProfileService{DB: db}
+}
+
+// SelfUpdateProfileDTO define los campos que un empleado puede intentar modificar de su propio perfil.
+// Que un campo exista aquí no significa que se pueda editar: el servicio lo contrasta con
+// la lista configurada por el administrador.
+type SelfUpdateProfileDTO struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	LastName    *string `json:"last_name,omitempty" binding:"omitempty,min=1,max=100"`
+	Email       *string `json:"email,omitempty" binding:"omitempty,email,max=100"`
+	PhoneNumber *string `json:"phone_number,omitempty" binding:"omitempty,max=20"`
+	// CustomFields solo admite campos personalizados con visibilidad employee_editable.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// SelfEditableFieldsDTO es el cuerpo de GET/PUT /api/v1/admin/settings/profile-fields.
+type SelfEditableFieldsDTO struct {
+	Fields []string `json:"fields" binding:"required"`
+}
+
+// FieldNotEditableError indica que el empleado intentó modificar un campo que el administrador no habilitó.
+type FieldNotEditableError struct {
+	Field string
+}
+
+func (e *FieldNotEditableError) Error() string {
+	return fmt.Sprintf("el campo '%s' no puede ser modificado por el propio usuario", e.Field)
+}
+
+// GetOwnProfile devuelve el perfil completo (desde la base de datos) del usuario autenticado.
+func (s *ProfileService) GetOwnProfile(userID uint) (*UserDetailDTO, error) {
+	var user models.User
+	if err := s.DB.Preload("EmployeeDetail").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("usuario no encontrado")
+		}
+		log.Printf("Error al obtener perfil propio del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudo obtener el perfil")
+	}
+	return s.ownProfileDTO(&user), nil
+}
+
+// ownProfileDTO construye el perfil incluyendo solo los campos personalizados visibles para el empleado.
+func (s *ProfileService) ownProfileDTO(user *models.User) *UserDetailDTO {
+	dto := newUserDetailDTO(user)
+	if err := attachCustomFields(s.DB, customFieldsVisibleToEmployee, dto); err != nil {
+		log.Printf("Error al cargar campos personalizados del perfil del usuario %d: %v", user.ID, err)
+	}
+	return dto
+}
+
+// employeeCanEditCustomField impide que el empleado modifique campos que no son employee_editable.
+// Los campos admin_only se reportan como desconocidos para no revelar su existencia.
+func employeeCanEditCustomField(def *models.CustomFieldDefinition) error {
+	switch def.Visibility {
+	case models.CustomFieldEmployeeEditable:
+		return nil
+	case models.CustomFieldEmployeeVisible:
+		return &FieldNotEditableError{Field: "custom_fields." + def.Key}
+	default:
+		return &CustomFieldValidationError{Key: def.Key, Reason: "campo desconocido"}
+	}
+}
+
+// UpdateOwnProfile aplica los cambios que el propio usuario hace a su perfil,
+// rechazando cualquier campo que no esté habilitado para autoedición.
+// ifMatch es opcional aquí: si viene vacío no se verifica la versión previa.
+// El usuario afectado es siempre actor.UserID.
+func (s *ProfileService) UpdateOwnProfile(actor RequestActor, ifMatch string, dto SelfUpdateProfileDTO) (*UserDetailDTO, error) {
+	userID := actor.UserID
+	allowed, err := s.GetSelfEditableFields()
+	if err != nil {
+		return nil, err
+	}
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, f := range allowed {
+		allowedSet[f] = true
+	}
+
+	requested := map[string]*string{
+		"name":         dto.Name,
+		"last_name":    dto.LastName,
+		"email":        dto.Email,
+		"phone_number": dto.PhoneNumber,
+	}
+	for _, field := range SelfEditableProfileFieldCandidates {
+		if requested[field] != nil && !allowedSet[field] {
+			return nil, &FieldNotEditableError{Field: field}
+		}
+	}
+
+	var user models.User
+	tx := s.DB.Begin()
+	if err := tx.Preload("EmployeeDetail").First(&user, userID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("usuario no encontrado")
+		}
+		log.Printf("Error al buscar usuario %d para actualizar su perfil: %v", userID, err)
+		return nil, errors.New("error al buscar usuario")
+	}
+
+	if current := newUserDetailDTO(&user); ifMatch != "" && !etagMatches(ifMatch, current.ETag()) {
+		tx.Rollback()
+		return nil, &PreconditionFailedError{Current: s.ownProfileDTO(&user)}
+	}
+	before := fullUserAuditSnapshot(tx, &user)
+
+	if user.EmployeeDetail.ID == 0 {
+		user.EmployeeDetail = models.EmployeeDetail{UserID: user.ID}
+	}
+	updated := false
+	if dto.Name != nil {
+		user.EmployeeDetail.Name = *dto.Name
+		updated = true
+	}
+	if dto.LastName != nil {
+		user.EmployeeDetail.LastName = *dto.LastName
+		updated = true
+	}
+	if dto.Email != nil {
+		user.EmployeeDetail.Email = *dto.Email
+		updated = true
+	}
+	if dto.PhoneNumber != nil {
+		user.EmployeeDetail.PhoneNumber = *dto.PhoneNumber
+		updated = true
+	}
+
+	customChanged, err := applyCustomFieldValues(tx, user.ID, dto.CustomFields, false, employeeCanEditCustomField)
+	if err != nil {
+		tx.Rollback()
+		var notEditable *FieldNotEditableError
+		if errors.As(err, &notEditable) {
+			return nil, err
+		}
+		return nil, customFieldFailure("actualizar el perfil", err)
+	}
+
+	if !updated && !customChanged {
+		tx.Rollback()
+		return s.ownProfileDTO(&user), nil
+	}
+
+	if dto.Email != nil {
+		if err := checkEmployeeEmailAvailable(tx, user.ID, *dto.Email); err != nil {
+			tx.Rollback()
+			if errors.Is(err, errEmployeeEmailTaken) {
+				return nil, err
+			}
+			log.Printf("Error al comprobar el email del perfil del usuario %d: %v", userID, err)
+			return nil, errors.New("no se pudo actualizar el perfil")
+		}
+	}
+	if err := saveUserVersioned(tx, &user, customChanged, updated); err != nil {
+		tx.Rollback()
+		if errors.Is(err, errVersionConflict) {
+			if current, getErr := s.GetOwnProfile(userID); getErr == nil {
+				return nil, &PreconditionFailedError{Current: current}
+			}
+		}
+		if conflict := uniquenessConflict(err); conflict != nil {
+			return nil, conflict
+		}
+		log.Printf("Error al actualizar perfil propio del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudo actualizar el perfil")
+	}
+
+	if err := recordUserHistory(tx, actor, &user, models.AuditActionUserProfileSelfEdit, false); err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar historial del perfil autoeditado: %v", err)
+		return nil, errors.New("no se pudo actualizar el perfil")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionUserProfileSelfEdit,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     before,
+		After:      fullUserAuditSnapshot(tx, &user),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar autoedición del perfil del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudo actualizar el perfil")
+	}
+	if err := recordUserEvent(tx, actor, models.DomainEventUserUpdated, &user, nil); err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar el evento del perfil autoeditado del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudo actualizar el perfil")
+	}
+	tx.Commit()
+
+	log.Printf("Usuario %d actualizó su propio perfil.", userID)
+	return s.ownProfileDTO(&user), nil
+}
+
+// GetSelfEditableFields devuelve la lista de campos que los empleados pueden editar de su perfil.
+func (s *ProfileService) GetSelfEditableFields() ([]string, error) {
+	var setting models.AppSetting
+	err := s.DB.Where("`key` = ?", models.SettingSelfEditableProfileFields).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return append([]string(nil), defaultSelfEditableProfileFields...), nil
+	}
+	if err != nil {
+		log.Printf("Error al leer configuración de campos autoeditables: %v", err)
+		return nil, errors.New("no se pudo obtener la configuración del perfil")
+	}
+
+	var fields []string
+	if err := json.Unmarshal([]byte(setting.Value), &fields); err != nil {
+		log.Printf("Configuración de campos autoeditables corrupta (%q): %v", setting.Value, err)
+		return append([]string(nil), defaultSelfEditableProfileFields...), nil
+	}
+	return fields, nil
+}
+
+// SetSelfEditableFields reemplaza la lista de campos autoeditables. Solo se aceptan
+// campos de SelfEditableProfileFieldCandidates.
+func (s *ProfileService) SetSelfEditableFields(actor RequestActor, fields []string) ([]string, error) {
+	candidates := make(map[string]bool, len(SelfEditableProfileFieldCandidates))
+	for _, f := range SelfEditableProfileFieldCandidates {
+		candidates[f] = true
+	}
+
+	seen := make(map[string]bool, len(fields))
+	normalized := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if !candidates[f] {
+			return nil, fmt.Errorf("campo no autoeditable: '%s'", f)
+		}
+		if !seen[f] {
+			seen[f] = true
+			normalized = append(normalized, f)
+		}
+	}
+
+	previous, err := s.GetSelfEditableFields()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, errors.New("no se pudo serializar la configuración")
+	}
+	setting := models.AppSetting{Key: models.SettingSelfEditableProfileFields, Value: string(value)}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&setting).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, auditRecord{
+			Action:     models.AuditActionSettingsUpdated,
+			TargetType: models.AuditTargetSetting,
+			Before:     map[string]interface{}{setting.Key: previous},
+			After:      map[string]interface{}{setting.Key: normalized},
+		})
+	})
+	if err != nil {
+		log.Printf("Error al guardar configuración de campos autoeditables: %v", err)
+		return nil, errors.New("no se pudo guardar la configuración del perfil")
+	}
+	return normalized, nil
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpdateLessonProgressDTO define el cuerpo de PUT /api/v1/me/courses/:id/lessons/:lessonId/progress.
+// Percent y TimeSpentSeconds son totales acumulados (no incrementos), de modo que repetir
+// un envío no cambia el resultado.
+type UpdateLessonProgressDTO struct {
+	Percent          *int    `json:"percent" binding:"omitempty,min=0,max=100"`
+	TimeSpentSeconds *int    `json:"time_spent_seconds" binding:"omitempty,min=0"`
+	ResumePosition   *string `json:"resume_position" binding:"omitempty,max=100"`
+	Completed        bool    `json:"completed"`
+	// OccurredAt es la hora del cliente en que se tomó la medida; por defecto, ahora.
+	OccurredAt *time.Time `json:"occurred_at"`
+}
+
+// LessonProgressSummary es el avance en una lección dentro del resumen de un curso.
+type LessonProgressSummary struct {
+	LessonID         uint                        `json:"lesson_id"`
+	Status           models.LessonProgressStatus `json:"status"`
+	Percent          int                         `json:"percent"`
+	TimeSpentSeconds int                         `json:"time_spent_seconds"`
+	ResumePosition   string                      `json:"resume_position,omitempty"`
+}
+
+// ModuleProgress es el avance en un módulo: la media del avance de sus lecciones.
+type ModuleProgress struct {
+	ModuleID         uint                    `json:"module_id"`
+	Percent          int                     `json:"percent"`
+	CompletedLessons int                     `json:"completed_lessons"`
+	TotalLessons     int                     `json:"total_lessons"`
+	Lessons          []LessonProgressSummary `json:"lessons"`
+}
+
+// ResumePoint indica dónde retomar el curso.
+type ResumePoint struct {
+	ModuleID       uint   `json:"module_id"`
+	LessonID       uint   `json:"lesson_id"`
+	ResumePosition string `json:"resume_position,omitempty"`
+}
+
+// CourseProgress es el avance de un usuario en un curso, agregado por módulos.
+type CourseProgress struct {
+	CourseID         uint                    `json:"course_id"`
+	EnrollmentID     uint                    `json:"enrollment_id"`
+	EnrollmentStatus models.EnrollmentStatus `json:"enrollment_status"`
+	Percent          int                     `json:"percent"`
+	CompletedLessons int                     `json:"completed_lessons"`
+	TotalLessons     int                     `json:"total_lessons"`
+	TimeSpentSeconds int                     `json:"time_spent_seconds"`
+	// PendingQuizzes son los cuestionarios del curso sin un intento aprobado en la vuelta de
+	// certificación actual. La inscripción no se completa mientras quede alguno.
+	PendingQuizzes int `json:"pending_quizzes"`
+	// Resume es la última lección sin terminar que el usuario tocó o, si no hay ninguna,
+	// la primera pendiente. Nil si ya completó todas.
+	Resume  *ResumePoint     `json:"resume,omitempty"`
+	Modules []ModuleProgress `json:"modules"`
+}
+
+// ProgressServiceInterface define el seguimiento del avance de los usuarios en sus cursos.
+type ProgressServiceInterface interface {
+	UpdateLessonProgress(actor RequestActor, courseID, lessonID uint, dto UpdateLessonProgressDTO) (*CourseProgress, error)
+	GetCourseProgress(userID, courseID uint) (*CourseProgress, error)
+}
+
+// ProgressService implementa ProgressServiceInterface.
+type ProgressService struct {
+	DB *gorm.DB
+}
+
+// NewProgressService crea una nueva instancia de ProgressService.
+func NewProgressService(db *gorm.DB) *ProgressService {
+	return &ProgressService{DB: db}
+}
+
+var errNotEnrolled = errors.New("no estás inscrito en este curso")
+
+// findUserEnrollment busca la inscripción vigente (no cancelada) de un usuario en un curso.
+// Con lock bloquea la fila: así se serializan los envíos de progreso de un mismo usuario
+// en el curso y el paso a completado ocurre una sola vez.
+func findUserEnrollment(db *gorm.DB, userID, courseID uint, lock bool) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	query := db
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := query.Where("user_id = ? AND course_id = ? AND status <> ?", userID, courseID, models.EnrollmentCancelled).
+		First(&enrollment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errNotEnrolled
+		}
+		log.Printf("Error al buscar la inscripción del usuario %d en el curso %d: %v", userID, courseID, err)
+		return nil, errors.New("no se pudo obtener el avance del curso")
+	}
+	return &enrollment, nil
+}
+
+// progressRow es una lección del curso con el avance del usuario, si lo hay.
+type progressRow struct {
+	ModuleID         uint
+	LessonID         uint
+	Status           *models.LessonProgressStatus
+	Percent          int
+	TimeSpentSeconds int
+	ResumePosition   string
+	LastEventAt      *time.Time
+}
+
+// countPendingQuizzes cuenta los cuestionarios vigentes del curso de la inscripción que el
+// usuario aún no aprobó en la vuelta de certificación de la inscripción.
+func countPendingQuizzes(db *gorm.DB, enrollment *models.Enrollment) (int, error) {
+	var pending int64
+	err := db.Model(&models.Quiz{}).
+		Where("course_id = ? AND NOT EXISTS (SELECT 1 FROM quiz_attempts WHERE quiz_attempts.quiz_id = quizzes.id "+
+			"AND quiz_attempts.user_id = ? AND quiz_attempts.cycle = ? AND quiz_attempts.passed = ?)",
+			enrollment.CourseID, enrollment.UserID, enrollment.Cycle, true).
+		Count(&pending).Error
+	return int(pending), err
+}
+
+// computeCourseProgress agrega el avance del usuario en las lecciones actuales del curso.
+// Las lecciones eliminadas no cuentan.
+func computeCourseProgress(db *gorm.DB, enrollment *models.Enrollment) (*CourseProgress, error) {
+	var rows []progressRow
+	if err := db.Table("lessons").
+		Select("course_modules.id AS module_id, lessons.id AS lesson_id, lesson_progress.status, "+
+			"COALESCE(lesson_progress.percent, 0) AS percent, COALESCE(lesson_progress.time_spent_seconds, 0) AS time_spent_seconds, "+
+			"COALESCE(lesson_progress.resume_position, '') AS resume_position, lesson_progress.last_event_at").
+		Joins("JOIN course_modules ON course_modules.id = lessons.module_id AND course_modules.deleted_at IS NULL").
+		Joins("LEFT JOIN lesson_progress ON lesson_progress.lesson_id = lessons.id AND lesson_progress.user_id = ?", enrollment.UserID).
+		Where("course_modules.course_id = ? AND lessons.deleted_at IS NULL", enrollment.CourseID).
+		Order("course_modules.position, lessons.position").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	pendingQuizzes, err := countPendingQuizzes(db, enrollment)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &CourseProgress{
+		CourseID:         enrollment.CourseID,
+		EnrollmentID:     enrollment.ID,
+		EnrollmentStatus: enrollment.Status,
+		PendingQuizzes:   pendingQuizzes,
+		Modules:          make([]ModuleProgress, 0),
+	}
+	var lastTouched *progressRow
+	var firstPending *progressRow
+	totalPercent := 0
+	modulePercent := 0
+	for i := range rows {
+		row := &rows[i]
+		status := models.LessonNotStarted
+		if row.Status != nil {
+			status = *row.Status
+		}
+		percent := row.Percent
+		if status == models.LessonCompleted {
+			percent = 100
+		}
+
+		if len(progress.Modules) == 0 || progress.Modules[len(progress.Modules)-1].ModuleID != row.ModuleID {
+			if len(progress.Modules) > 0 {
+				closeModuleProgress(&progress.Modules[len(progress.Modules)-1], modulePercent)
+			}
+			progress.Modules = append(progress.Modules, ModuleProgress{ModuleID: row.ModuleID, Lessons: make([]LessonProgressSummary, 0)})
+			modulePercent = 0
+		}
+		module := &progress.Modules[len(progress.Modules)-1]
+		module.Lessons = append(module.Lessons, LessonProgressSummary{
+			LessonID:         row.LessonID,
+			Status:           status,
+			Percent:          percent,
+			TimeSpentSeconds: row.TimeSpentSeconds,
+			ResumePosition:   row.ResumePosition,
+		})
+		module.TotalLessons++
+		modulePercent += percent
+		progress.TotalLessons++
+		totalPercent += percent
+		progress.TimeSpentSeconds += row.TimeSpentSeconds
+
+		if status == models.LessonCompleted {
+			module.CompletedLessons++
+			progress.CompletedLessons++
+			continue
+		}
+		if firstPending == nil {
+			firstPending = row
+		}
+		if row.LastEventAt != nil && (lastTouched == nil || row.LastEventAt.After(*lastTouched.LastEventAt)) {
+			lastTouched = row
+		}
+	}
+	if len(progress.Modules) > 0 {
+		closeModuleProgress(&progress.Modules[len(progress.Modules)-1], modulePercent)
+	}
+	if progress.TotalLessons > 0 {
+		progress.Percent = totalPercent / progress.TotalLessons
+	}
+
+	resume := lastTouched
+	if resume == nil {
+		resume = firstPending
+	}
+	if resume != nil {
+		progress.Resume = &ResumePoint{ModuleID: resume.ModuleID, LessonID: resume.LessonID, ResumePosition: resume.ResumePosition}
+	}
+	return progress, nil
+}
+
+func closeModuleProgress(module *ModuleProgress, percentSum int) {
+	if module.TotalLessons > 0 {
+		module.Percent = percentSum / module.TotalLessons
+	}
+}
+
+// mergeLessonProgress aplica un envío del cliente sobre el avance guardado. Es idempotente
+// y tolera envíos desordenados: los acumulados toman el máximo, una lección completada no
+// vuelve atrás y la posición solo avanza con envíos más recientes que el último aplicado.
+func mergeLessonProgress(progress *models.LessonProgress, dto UpdateLessonProgressDTO, now time.Time) {
+	occurredAt := now
+	if dto.OccurredAt != nil && dto.OccurredAt.Before(now) {
+		occurredAt = *dto.OccurredAt
+	}
+	if dto.Percent != nil && *dto.Percent > progress.Percent {
+		progress.Percent = *dto.Percent
+	}
+	if dto.TimeSpentSeconds != nil && *dto.TimeSpentSeconds > progress.TimeSpentSeconds {
+		progress.TimeSpentSeconds = *dto.TimeSpentSeconds
+	}
+	if dto.ResumePosition != nil && (progress.LastEventAt == nil || !occurredAt.Before(*progress.LastEventAt)) {
+		progress.ResumePosition = *dto.ResumePosition
+		progress.LastEventAt = &occurredAt
+	}
+	if progress.LastEventAt == nil {
+		progress.LastEventAt = &occurredAt
+	}
+
+	switch {
+	case progress.Status == models.LessonCompleted:
+		progress.Percent = 100
+	case dto.Completed || progress.Percent >= 100:
+		progress.Status = models.LessonCompleted
+		progress.Percent = 100
+		progress.CompletedAt = &now
+	default:
+		progress.Status = models.LessonInProgress
+	}
+}
+
+// saveLessonProgress aplica un envío de avance sobre una lección del curso de la inscripción
+// (bloqueada por el llamador) y devuelve el avance del curso, actualizado con advanceEnrollment.
+// Los errores se devuelven tal cual para que el llamador los registre.
+func saveLessonProgress(tx *gorm.DB, actor RequestActor, enrollment *models.Enrollment, lessonID uint, dto UpdateLessonProgressDTO, now time.Time) (*CourseProgress, error) {
+	progress := models.LessonProgress{UserID: enrollment.UserID, LessonID: lessonID, CourseID: enrollment.CourseID, Status: models.LessonNotStarted}
+	if err := tx.Where("user_id = ? AND lesson_id = ?", enrollment.UserID, lessonID).FirstOrInit(&progress).Error; err != nil {
+		return nil, err
+	}
+	mergeLessonProgress(&progress, dto, now)
+	if err := tx.Save(&progress).Error; err != nil {
+		return nil, err
+	}
+	return advanceEnrollment(tx, actor, enrollment, now)
+}
+
+// advanceEnrollment actualiza el estado de la inscripción (bloqueada por el llamador) tras un
+// avance y devuelve el avance del curso. La inscripción pasa a en curso con el primer avance
+// y, cuando todas las lecciones están completadas y todos los cuestionarios aprobados, a
+// completada (una sola vez) con el evento EnrollmentCompleted.
+func advanceEnrollment(tx *gorm.DB, actor RequestActor, enrollment *models.Enrollment, now time.Time) (*CourseProgress, error) {
+	if enrollment.Status == models.EnrollmentAssigned {
+		if err := tx.Model(enrollment).Updates(map[string]interface{}{
+			"status":     models.EnrollmentInProgress,
+			"started_at": now,
+		}).Error; err != nil {
+			return nil, err
+		}
+		enrollment.Status = models.EnrollmentInProgress
+		enrollment.StartedAt = &now
+	}
+	courseProgress, err := computeCourseProgress(tx, enrollment)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.Status != models.EnrollmentCompleted && courseProgress.TotalLessons > 0 &&
+		courseProgress.CompletedLessons == courseProgress.TotalLessons && courseProgress.PendingQuizzes == 0 {
+		if err := tx.Model(enrollment).Updates(map[string]interface{}{
+			"status":       models.EnrollmentCompleted,
+			"completed_at": now,
+		}).Error; err != nil {
+			return nil, err
+		}
+		enrollment.Status = models.EnrollmentCompleted
+		enrollment.CompletedAt = &now
+		if err := recordDomainEvent(tx, actor, models.DomainEventEnrollmentCompleted, models.AggregateEnrollment, enrollment.ID,
+			map[string]interface{}{"enrollment": enrollment}); err != nil {
+			return nil, err
+		}
+		courseProgress.EnrollmentStatus = enrollment.Status
+	}
+	return courseProgress, nil
+}
+
+// UpdateLessonProgress registra el avance del usuario autenticado en una lección y
+// devuelve el avance del curso. Las lecciones SCORM solo aceptan la posición y el tiempo:
+// se completan con lo que informa el paquete.
+func (s *ProgressService) UpdateLessonProgress(actor RequestActor, courseID, lessonID uint, dto UpdateLessonProgressDTO) (*CourseProgress, error) {
+	tx := s.DB.Begin()
+	enrollment, err := findUserEnrollment(tx, actor.UserID, courseID, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	var lessonTypes []models.LessonType
+	if err := tx.Model(&models.Lesson{}).
+		Joins("JOIN course_modules ON course_modules.id = lessons.module_id AND course_modules.deleted_at IS NULL").
+		Where("lessons.id = ? AND course_modules.course_id = ?", lessonID, courseID).
+		Pluck("lessons.type", &lessonTypes).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al buscar la lección %d: %v", lessonID, err)
+		return nil, errors.New("no se pudo guardar el avance")
+	}
+	if len(lessonTypes) == 0 {
+		tx.Rollback()
+		return nil, errLessonNotFound
+	}
+	if lessonTypes[0] == models.LessonScorm && (dto.Completed || dto.Percent != nil) {
+		tx.Rollback()
+		return nil, errors.New("el avance de las lecciones SCORM lo informa el paquete")
+	}
+
+	courseProgress, err := saveLessonProgress(tx, actor, enrollment, lessonID, dto, time.Now())
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al guardar el avance de la lección %d: %v", lessonID, err)
+		return nil, errors.New("no se pudo guardar el avance")
+	}
+	tx.Commit()
+	return courseProgress, nil
+}
+
+// GetCourseProgress devuelve el avance de un usuario en un curso en el que está inscrito.
+func (s *ProgressService) GetCourseProgress(userID, courseID uint) (*CourseProgress, error) {
+	enrollment, err := findUserEnrollment(s.DB, userID, courseID, false)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := computeCourseProgress(s.DB, enrollment)
+	if err != nil {
+		log.Printf("Error al calcular el avance del curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudo obtener el avance del curso")
+	}
+	return progress, nil
+}
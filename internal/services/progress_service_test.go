@@ -0,0 +1,158 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+)
+
+func TestMergeLessonProgress(t *testing.T) {
+	now := time.Date(2026, 4, 10, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-10 * time.Minute)
+	completedAt := now.Add(-time.Hour)
+	intPtr := func(v int) *int { return &v }
+	strPtr := func(v string) *string { return &v }
+	timePtr := func(v time.Time) *time.Time { return &v }
+
+	tests := []struct {
+		name   string
+		stored models.LessonProgress
+		dto    UpdateLessonProgressDTO
+
+		wantStatus      models.LessonProgressStatus
+		wantPercent     int
+		wantTime        int
+		wantResume      string
+		wantLastEventAt time.Time
+		wantCompletedAt *time.Time
+	}{
+		{
+			name:            "primer envío",
+			stored:          models.LessonProgress{Status: models.LessonNotStarted},
+			dto:             UpdateLessonProgressDTO{Percent: intPtr(20), TimeSpentSeconds: intPtr(30), ResumePosition: strPtr("00:30"), OccurredAt: timePtr(earlier)},
+			wantStatus:      models.LessonInProgress,
+			wantPercent:     20,
+			wantTime:        30,
+			wantResume:      "00:30",
+			wantLastEventAt: earlier,
+		},
+		{
+			name: "envío atrasado no pisa la posición",
+			stored: models.LessonProgress{Status: models.LessonInProgress, Percent: 40, TimeSpentSeconds: 120,
+				ResumePosition: "02:00", LastEventAt: timePtr(now.Add(-time.Minute))},
+			dto:             UpdateLessonProgressDTO{ResumePosition: strPtr("00:30"), OccurredAt: timePtr(earlier)},
+			wantStatus:      models.LessonInProgress,
+			wantPercent:     40,
+			wantTime:        120,
+			wantResume:      "02:00",
+			wantLastEventAt: now.Add(-time.Minute),
+		},
+		{
+			name: "envío atrasado sí sube los acumulados",
+			stored: models.LessonProgress{Status: models.LessonInProgress, Percent: 40, TimeSpentSeconds: 120,
+				ResumePosition: "02:00", LastEventAt: timePtr(now.Add(-time.Minute))},
+			dto:             UpdateLessonProgressDTO{Percent: intPtr(55), TimeSpentSeconds: intPtr(150), ResumePosition: strPtr("00:30"), OccurredAt: timePtr(earlier)},
+			wantStatus:      models.LessonInProgress,
+			wantPercent:     55,
+			wantTime:        150,
+			wantResume:      "02:00",
+			wantLastEventAt: now.Add(-time.Minute),
+		},
+		{
+			name: "percent y tiempo nunca bajan",
+			stored: models.LessonProgress{Status: models.LessonInProgress, Percent: 70, TimeSpentSeconds: 300,
+				ResumePosition: "05:00", LastEventAt: timePtr(earlier)},
+			dto:             UpdateLessonProgressDTO{Percent: intPtr(10), TimeSpentSeconds: intPtr(20), ResumePosition: strPtr("05:30")},
+			wantStatus:      models.LessonInProgress,
+			wantPercent:     70,
+			wantTime:        300,
+			wantResume:      "05:30",
+			wantLastEventAt: now,
+		},
+		{
+			name: "occurred_at futuro se limita a ahora",
+			stored: models.LessonProgress{Status: models.LessonInProgress, Percent: 10,
+				ResumePosition: "01:00", LastEventAt: timePtr(earlier)},
+			dto:             UpdateLessonProgressDTO{ResumePosition: strPtr("01:30"), OccurredAt: timePtr(now.Add(24 * time.Hour))},
+			wantStatus:      models.LessonInProgress,
+			wantPercent:     10,
+			wantResume:      "01:30",
+			wantLastEventAt: now,
+		},
+		{
+			name:            "completar la lección",
+			stored:          models.LessonProgress{Status: models.LessonInProgress, Percent: 90, LastEventAt: timePtr(earlier)},
+			dto:             UpdateLessonProgressDTO{Completed: true},
+			wantStatus:      models.LessonCompleted,
+			wantPercent:     100,
+			wantLastEventAt: earlier,
+			wantCompletedAt: &now,
+		},
+		{
+			name:            "llegar al 100% completa la lección",
+			stored:          models.LessonProgress{Status: models.LessonInProgress, Percent: 90, LastEventAt: timePtr(earlier)},
+			dto:             UpdateLessonProgressDTO{Percent: intPtr(100)},
+			wantStatus:      models.LessonCompleted,
+			wantPercent:     100,
+			wantLastEventAt: earlier,
+			wantCompletedAt: &now,
+		},
+		{
+			name: "completada sigue completada",
+			stored: models.LessonProgress{Status: models.LessonCompleted, Percent: 100, TimeSpentSeconds: 600,
+				ResumePosition: "10:00", LastEventAt: timePtr(earlier), CompletedAt: &completedAt},
+			dto:             UpdateLessonProgressDTO{Percent: intPtr(5), TimeSpentSeconds: intPtr(700), ResumePosition: strPtr("00:10")},
+			wantStatus:      models.LessonCompleted,
+			wantPercent:     100,
+			wantTime:        700,
+			wantResume:      "00:10",
+			wantLastEventAt: now,
+			wantCompletedAt: &completedAt,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := tt.stored
+			mergeLessonProgress(&progress, tt.dto, now)
+			if progress.Status != tt.wantStatus {
+				t.Errorf("status = %s, se esperaba %s", progress.Status, tt.wantStatus)
+			}
+			if progress.Percent != tt.wantPercent {
+				t.Errorf("percent = %d, se esperaba %d", progress.Percent, tt.wantPercent)
+			}
+			if progress.TimeSpentSeconds != tt.wantTime {
+				t.Errorf("time_spent_seconds = %d, se esperaba %d", progress.TimeSpentSeconds, tt.wantTime)
+			}
+			if progress.ResumePosition != tt.wantResume {
+				t.Errorf("resume_position = %q, se esperaba %q", progress.ResumePosition, tt.wantResume)
+			}
+			if progress.LastEventAt == nil || !progress.LastEventAt.Equal(tt.wantLastEventAt) {
+				t.Errorf("last_event_at = %v, se esperaba %v", progress.LastEventAt, tt.wantLastEventAt)
+			}
+			switch {
+			case tt.wantCompletedAt == nil && progress.CompletedAt != nil:
+				t.Errorf("completed_at = %v, se esperaba nil", progress.CompletedAt)
+			case tt.wantCompletedAt != nil && (progress.CompletedAt == nil || !progress.CompletedAt.Equal(*tt.wantCompletedAt)):
+				t.Errorf("completed_at = %v, se esperaba %v", progress.CompletedAt, *tt.wantCompletedAt)
+			}
+		})
+	}
+}
+
+// Aplicar el mismo envío dos veces da el mismo resultado.
+func TestMergeLessonProgressIsIdempotent(t *testing.T) {
+	now := time.Date(2026, 4, 10, 12, 0, 0, 0, time.UTC)
+	percent, spent, pos := 35, 90, "01:30"
+	occurred := now.Add(-time.Minute)
+	dto := UpdateLessonProgressDTO{Percent: &percent, TimeSpentSeconds: &spent, ResumePosition: &pos, OccurredAt: &occurred}
+
+	once := models.LessonProgress{Status: models.LessonNotStarted}
+	mergeLessonProgress(&once, dto, now)
+	twice := once
+	mergeLessonProgress(&twice, dto, now.Add(time.Second))
+	if twice.Status != once.Status || twice.Percent != once.Percent || twice.TimeSpentSeconds != once.TimeSpentSeconds ||
+		twice.ResumePosition != once.ResumePosition || !twice.LastEventAt.Equal(*once.LastEventAt) {
+		t.Errorf("repetir el envío cambió el avance: %+v → %+v", once, twice)
+	}
+}
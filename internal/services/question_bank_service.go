@@ -0,0 +1,366 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuestionBankDTO define el cuerpo de la creación y edición de un banco de preguntas.
+type QuestionBankDTO struct {
+	Title       string `json:"title" binding:"required,max=200"`
+	Description string `json:"description"`
+}
+
+// QuestionBankSummary es un banco sin preguntas, con cuántas tiene.
+type QuestionBankSummary struct {
+	models.QuestionBank
+	QuestionCount int64 `json:"question_count"`
+}
+
+// BankQuestionFilter filtra las preguntas de un banco.
+type BankQuestionFilter struct {
+	Topic      string
+	Difficulty string
+}
+
+// QuestionBankServiceInterface define la gestión de bancos de preguntas. Un administrador
+// gestiona todos; un instructor, los que creó.
+type QuestionBankServiceInterface interface {
+	ListBanks(actor RequestActor) ([]QuestionBankSummary, error)
+	GetBank(actor RequestActor, id uint, filter BankQuestionFilter) (*models.QuestionBank, error)
+	CreateBank(actor RequestActor, dto QuestionBankDTO) (*models.QuestionBank, error)
+	UpdateBank(actor RequestActor, id uint, dto QuestionBankDTO) (*models.QuestionBank, error)
+	DeleteBank(actor RequestActor, id uint) error
+	AddBankQuestion(actor RequestActor, bankID uint, dto QuestionDTO) (*models.Question, error)
+	UpdateBankQuestion(actor RequestActor, bankID, questionID uint, dto QuestionDTO) (*models.Question, error)
+	DeleteBankQuestion(actor RequestActor, bankID, questionID uint) error
+	GetBankItemStats(actor RequestActor, id uint) ([]ItemStat, error)
+}
+
+// QuestionBankService implementa QuestionBankServiceInterface.
+type QuestionBankService struct {
+	DB *gorm.DB
+}
+
+// NewQuestionBankService crea una nueva instancia de QuestionBankService.
+func NewQuestionBankService(db *gorm.DB) *QuestionBankService {
+	return &QuestionBankService{DB: db}
+}
+
+var (
+	errBankNotFound  = errors.New("banco de preguntas no encontrado")
+	errBankForbidden = errors.New("no tienes permiso para modificar este banco de preguntas")
+)
+
+// canManageBank indica si el actor puede editar y usar en sus cuestionarios el banco.
+func canManageBank(actor RequestActor, bank *models.QuestionBank) bool {
+	if actor.Role == models.RoleAdmin {
+		return true
+	}
+	return actor.Role == models.RoleInstructor && bank.CreatedByID != nil && *bank.CreatedByID == actor.UserID
+}
+
+// findManagedBank busca un banco y comprueba que el actor pueda gestionarlo.
+func findManagedBank(db *gorm.DB, actor RequestActor, id uint, lock bool) (*models.QuestionBank, error) {
+	var bank models.QuestionBank
+	query := db
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := query.First(&bank, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errBankNotFound
+		}
+		log.Printf("Error al buscar banco de preguntas %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener el banco de preguntas")
+	}
+	if !canManageBank(actor, &bank) {
+		return nil, errBankForbidden
+	}
+	return &bank, nil
+}
+
+// findBankQuestion busca una pregunta de un banco.
+func findBankQuestion(db *gorm.DB, bankID, questionID uint) (*models.Question, error) {
+	var question models.Question
+	if err := db.Where("id = ? AND bank_id = ?", questionID, bankID).First(&question).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errQuestionNotFound
+		}
+		log.Printf("Error al buscar pregunta %d: %v", questionID, err)
+		return nil, errors.New("no se pudo obtener la pregunta")
+	}
+	return &question, nil
+}
+
+func bankAuditSnapshot(bank *models.QuestionBank) map[string]interface{} {
+	return map[string]interface{}{"title": bank.Title}
+}
+
+// auditBank registra un cambio en un banco de preguntas.
+func auditBank(tx *gorm.DB, actor RequestActor, action string, bank *models.QuestionBank, before, after map[string]interface{}) error {
+	return recordAudit(tx, actor, auditRecord{
+		Action:     action,
+		TargetType: models.AuditTargetQuestionBank,
+		TargetID:   bank.ID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// ListBanks lista los bancos que el actor puede gestionar.
+func (s *QuestionBankService) ListBanks(actor RequestActor) ([]QuestionBankSummary, error) {
+	query := s.DB.Model(&models.QuestionBank{}).
+		Select("question_banks.*, (SELECT COUNT(*) FROM questions WHERE questions.bank_id = question_banks.id AND questions.deleted_at IS NULL) AS question_count")
+	if actor.Role != models.RoleAdmin {
+		query = query.Where("created_by_id = ?", actor.UserID)
+	}
+	banks := make([]QuestionBankSummary, 0)
+	if err := query.Order("title").Scan(&banks).Error; err != nil {
+		log.Printf("Error al listar bancos de preguntas: %v", err)
+		return nil, errors.New("no se pudieron obtener los bancos de preguntas")
+	}
+	return banks, nil
+}
+
+// GetBank devuelve un banco con sus preguntas (y respuestas correctas), filtradas por tema
+// y dificultad.
+func (s *QuestionBankService) GetBank(actor RequestActor, id uint, filter BankQuestionFilter) (*models.QuestionBank, error) {
+	bank, err := findManagedBank(s.DB, actor, id, false)
+	if err != nil {
+		return nil, err
+	}
+	var difficulty models.QuestionDifficulty
+	if filter.Difficulty != "" {
+		if difficulty, err = models.ParseQuestionDifficulty(filter.Difficulty); err != nil {
+			return nil, errors.New("dificultad de pregunta inválida")
+		}
+	}
+	if err := s.DB.Preload("Questions", func(db *gorm.DB) *gorm.DB {
+		if filter.Topic != "" {
+			db = db.Where("topic = ?", strings.TrimSpace(filter.Topic))
+		}
+		if difficulty != "" {
+			db = db.Where("difficulty = ?", difficulty)
+		}
+		return db.Order("topic, id")
+	}).Preload("Questions.Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).First(bank, id).Error; err != nil {
+		log.Printf("Error al cargar las preguntas del banco %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener el banco de preguntas")
+	}
+	return bank, nil
+}
+
+// CreateBank crea un banco de preguntas vacío.
+func (s *QuestionBankService) CreateBank(actor RequestActor, dto QuestionBankDTO) (*models.QuestionBank, error) {
+	if actor.Role != models.RoleAdmin && actor.Role != models.RoleInstructor {
+		return nil, errBankForbidden
+	}
+	if strings.TrimSpace(dto.Title) == "" {
+		return nil, errors.New("el título del banco de preguntas no puede estar vacío")
+	}
+	createdBy := actor.UserID
+	bank := models.QuestionBank{
+		Title:       strings.TrimSpace(dto.Title),
+		Description: dto.Description,
+		CreatedByID: &createdBy,
+	}
+	tx := s.DB.Begin()
+	if err := tx.Create(&bank).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear banco de preguntas: %v", err)
+		return nil, errors.New("no se pudo crear el banco de preguntas")
+	}
+	if err := auditBank(tx, actor, models.AuditActionQuestionBankCreated, &bank, nil, bankAuditSnapshot(&bank)); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la creación del banco de preguntas: %v", err)
+		return nil, errors.New("no se pudo crear el banco de preguntas")
+	}
+	tx.Commit()
+	return &bank, nil
+}
+
+// UpdateBank modifica el título y la descripción de un banco.
+func (s *QuestionBankService) UpdateBank(actor RequestActor, id uint, dto QuestionBankDTO) (*models.QuestionBank, error) {
+	if strings.TrimSpace(dto.Title) == "" {
+		return nil, errors.New("el título del banco de preguntas no puede estar vacío")
+	}
+	tx := s.DB.Begin()
+	bank, err := findManagedBank(tx, actor, id, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	before := bankAuditSnapshot(bank)
+	bank.Title = strings.TrimSpace(dto.Title)
+	bank.Description = dto.Description
+	if err := tx.Save(bank).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar banco de preguntas %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el banco de preguntas")
+	}
+	if err := auditBank(tx, actor, models.AuditActionQuestionBankUpdated, bank, before, bankAuditSnapshot(bank)); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar el banco de preguntas %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el banco de preguntas")
+	}
+	tx.Commit()
+	return bank, nil
+}
+
+// DeleteBank elimina (borrado lógico) un banco que ningún cuestionario vigente use.
+func (s *QuestionBankService) DeleteBank(actor RequestActor, id uint) error {
+	tx := s.DB.Begin()
+	bank, err := findManagedBank(tx, actor, id, true)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	var inUse int64
+	if err := tx.Model(&models.QuizDrawRule{}).
+		Joins("JOIN quizzes ON quizzes.id = quiz_draw_rules.quiz_id AND quizzes.deleted_at IS NULL").
+		Where("quiz_draw_rules.bank_id = ?", id).Count(&inUse).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al comprobar el uso del banco %d: %v", id, err)
+		return errors.New("no se pudo eliminar el banco de preguntas")
+	}
+	if inUse > 0 {
+		tx.Rollback()
+		return errors.New("el banco de preguntas se usa en algún cuestionario")
+	}
+	if err := tx.Delete(bank).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar banco de preguntas %d: %v", id, err)
+		return errors.New("no se pudo eliminar el banco de preguntas")
+	}
+	if err := auditBank(tx, actor, models.AuditActionQuestionBankDeleted, bank, bankAuditSnapshot(bank), nil); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la eliminación del banco de preguntas %d: %v", id, err)
+		return errors.New("no se pudo eliminar el banco de preguntas")
+	}
+	tx.Commit()
+	return nil
+}
+
+// AddBankQuestion agrega una pregunta a un banco.
+func (s *QuestionBankService) AddBankQuestion(actor RequestActor, bankID uint, dto QuestionDTO) (*models.Question, error) {
+	question, err := buildQuestion(dto)
+	if err != nil {
+		return nil, err
+	}
+	tx := s.DB.Begin()
+	bank, err := findManagedBank(tx, actor, bankID, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	question.BankID = &bankID
+	if err := tx.Create(question).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear pregunta en el banco %d: %v", bankID, err)
+		return nil, errors.New("no se pudo crear la pregunta")
+	}
+	if err := auditBank(tx, actor, models.AuditActionQuestionBankUpdated, bank, nil,
+		map[string]interface{}{"question_added": question.ID, "topic": question.Topic, "difficulty": question.Difficulty}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la pregunta nueva del banco %d: %v", bankID, err)
+		return nil, errors.New("no se pudo crear la pregunta")
+	}
+	tx.Commit()
+	return question, nil
+}
+
+// UpdateBankQuestion reemplaza una pregunta de un banco. Los intentos ya entregados que la
+// sortearon conservan su calificación.
+func (s *QuestionBankService) UpdateBankQuestion(actor RequestActor, bankID, questionID uint, dto QuestionDTO) (*models.Question, error) {
+	updated, err := buildQuestion(dto)
+	if err != nil {
+		return nil, err
+	}
+	tx := s.DB.Begin()
+	bank, err := findManagedBank(tx, actor, bankID, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	question, err := findBankQuestion(tx, bankID, questionID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := replaceQuestion(tx, question, updated); err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar pregunta %d: %v", questionID, err)
+		return nil, errors.New("no se pudo actualizar la pregunta")
+	}
+	if err := auditBank(tx, actor, models.AuditActionQuestionBankUpdated, bank, nil,
+		map[string]interface{}{"question_updated": question.ID, "topic": question.Topic, "difficulty": question.Difficulty}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la pregunta %d: %v", questionID, err)
+		return nil, errors.New("no se pudo actualizar la pregunta")
+	}
+	tx.Commit()
+	return question, nil
+}
+
+// DeleteBankQuestion elimina (borrado lógico) una pregunta de un banco; deja de sortearse
+// pero los intentos que ya la incluían la siguen mostrando.
+func (s *QuestionBankService) DeleteBankQuestion(actor RequestActor, bankID, questionID uint) error {
+	tx := s.DB.Begin()
+	bank, err := findManagedBank(tx, actor, bankID, true)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	question, err := findBankQuestion(tx, bankID, questionID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(question).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar pregunta %d: %v", questionID, err)
+		return errors.New("no se pudo eliminar la pregunta")
+	}
+	if err := auditBank(tx, actor, models.AuditActionQuestionBankUpdated, bank, nil,
+		map[string]interface{}{"question_deleted": question.ID}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la eliminación de la pregunta %d: %v", questionID, err)
+		return errors.New("no se pudo eliminar la pregunta")
+	}
+	tx.Commit()
+	return nil
+}
+
+// GetBankItemStats calcula las estadísticas de las preguntas del banco a partir de los
+// intentos calificados de todos los cuestionarios que sortean de él.
+func (s *QuestionBankService) GetBankItemStats(actor RequestActor, id uint) ([]ItemStat, error) {
+	if _, err := findManagedBank(s.DB, actor, id, false); err != nil {
+		return nil, err
+	}
+	var questionIDs []uint
+	if err := s.DB.Unscoped().Model(&models.Question{}).Where("bank_id = ?", id).
+		Pluck("id", &questionIDs).Error; err != nil {
+		log.Printf("Error al obtener las preguntas del banco %d: %v", id, err)
+		return nil, errors.New("no se pudieron calcular las estadísticas")
+	}
+	only := make(map[uint]bool, len(questionIDs))
+	for _, questionID := range questionIDs {
+		only[questionID] = true
+	}
+	// Incluye las reglas eliminadas: sus intentos siguen siendo datos válidos de las preguntas.
+	quizIDs := s.DB.Unscoped().Model(&models.QuizDrawRule{}).Select("quiz_id").Where("bank_id = ?", id)
+	stats, err := computeItemStats(s.DB, s.DB.Where("quiz_id IN (?)", quizIDs), only)
+	if err != nil {
+		log.Printf("Error al calcular las estadísticas del banco %d: %v", id, err)
+		return nil, errors.New("no se pudieron calcular las estadísticas")
+	}
+	return stats, nil
+}
@@ -0,0 +1,864 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	mathrand "math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// quizSubmitGrace es el margen tras el límite de tiempo en el que todavía se acepta la
+// entrega de lo ya guardado, para absorber la latencia de red del cliente. En ese margen no
+// se aceptan respuestas nuevas.
+const quizSubmitGrace = 30 * time.Second
+
+// AnswerDTO es la respuesta a una pregunta: OptionIDs para las de opciones (en orden, para
+// las de ordenar) y Text para las de respuesta corta.
+type AnswerDTO struct {
+	QuestionID uint   `json:"question_id" binding:"required"`
+	OptionIDs  []uint `json:"option_ids,omitempty"`
+	Text       string `json:"text,omitempty" binding:"max=1000"`
+}
+
+// SaveAnswersDTO define el cuerpo del guardado y la entrega de respuestas.
+type SaveAnswersDTO struct {
+	Answers []AnswerDTO `json:"answers" binding:"dive"`
+}
+
+// OptionView es una opción tal como se muestra a quien responde.
+type OptionView struct {
+	ID   uint   `json:"id"`
+	Text string `json:"text"`
+}
+
+// QuestionView es una pregunta de un intento. Mientras el intento está en curso no incluye
+// nada que revele la respuesta correcta; los campos de corrección solo se rellenan cuando
+// el intento ya se calificó.
+type QuestionView struct {
+	ID      uint                `json:"id"`
+	Type    models.QuestionType `json:"type"`
+	Prompt  string              `json:"prompt"`
+	Points  int                 `json:"points"`
+	Options []OptionView        `json:"options,omitempty"`
+	Answer  *AnswerDTO          `json:"answer,omitempty"` // Respuesta guardada
+
+	Correct          *bool    `json:"correct,omitempty"`
+	PointsAwarded    *int     `json:"points_awarded,omitempty"`
+	Explanation      string   `json:"explanation,omitempty"`
+	CorrectOptionIDs []uint   `json:"correct_option_ids,omitempty"` // En orden, para las de ordenar
+	AcceptedAnswers  []string `json:"accepted_answers,omitempty"`
+}
+
+// AttemptView es un intento con sus preguntas.
+type AttemptView struct {
+	models.QuizAttempt
+	// RemainingSeconds es el tiempo que le queda a un intento en curso con límite.
+	RemainingSeconds *int           `json:"remaining_seconds,omitempty"`
+	Questions        []QuestionView `json:"questions"`
+}
+
+// QuizInfo es un cuestionario visto por un usuario inscrito, con su situación.
+type QuizInfo struct {
+	ID               uint   `json:"id"`
+	CourseID         uint   `json:"course_id"`
+	LessonID         *uint  `json:"lesson_id,omitempty"`
+	Title            string `json:"title"`
+	Description      string `json:"description,omitempty"`
+	PassingScore     int    `json:"passing_score"`
+	MaxAttempts      int    `json:"max_attempts"`
+	TimeLimitSeconds int    `json:"time_limit_seconds"`
+	QuestionCount    int    `json:"question_count"`
+
+	AttemptsUsed      int   `json:"attempts_used"`
+	AttemptsRemaining *int  `json:"attempts_remaining,omitempty"` // Nil si no hay límite
+	BestPercent       *int  `json:"best_percent,omitempty"`
+	Passed            bool  `json:"passed"`
+	InProgressAttempt *uint `json:"in_progress_attempt,omitempty"`
+}
+
+// attemptResponse es el formato de QuizAttemptAnswer.Response.
+type attemptResponse struct {
+	OptionIDs []uint `json:"option_ids,omitempty"`
+	Text      string `json:"text,omitempty"`
+}
+
+var (
+	errAttemptNotFound = errors.New("intento no encontrado")
+	errAttemptClosed   = errors.New("el intento ya fue entregado")
+	errAttemptTimeUp   = errors.New("se acabó el tiempo del intento")
+)
+
+// quizExpiryActor figura como autor de los cambios que provoca cerrar un intento vencido.
+var quizExpiryActor = RequestActor{Username: "sistema"}
+
+// newAttemptSeed genera la semilla aleatoria de un intento.
+func newAttemptSeed() (int64, error) {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(buf[:]) >> 1), nil
+}
+
+// normalizeTextAnswer normaliza una respuesta corta para compararla: minúsculas y espacios
+// simples.
+func normalizeTextAnswer(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// selectAttemptQuestions elige las preguntas de un intento nuevo y su orden a partir de la
+// semilla: las propias del cuestionario y las sorteadas de bancos según sus reglas. Con la
+// misma semilla y el mismo contenido el resultado es el mismo.
+func selectAttemptQuestions(tx *gorm.DB, quiz *models.Quiz, seed int64) ([]uint, error) {
+	var ids []uint
+	if err := tx.Model(&models.Question{}).Where("quiz_id = ?", quiz.ID).
+		Order("position, id").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	var rules []models.QuizDrawRule
+	if err := tx.Where("quiz_id = ?", quiz.ID).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	chosen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		chosen[id] = true
+	}
+	for i := range rules {
+		drawn, err := drawFromBank(tx, &rules[i], seed, chosen)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range drawn {
+			chosen[id] = true
+		}
+		ids = append(ids, drawn...)
+	}
+	if quiz.ShuffleQuestions {
+		rng := mathrand.New(mathrand.NewSource(seed))
+		rng.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	}
+	return ids, nil
+}
+
+// optionOrder devuelve las opciones de una pregunta en el orden en que se muestran en el
+// intento. Las de ordenar siempre se mezclan (si no, el orden revelaría la respuesta); las
+// de verdadero/falso nunca.
+func optionOrder(question *models.Question, quiz *models.Quiz, seed int64) []models.QuestionOption {
+	options := append([]models.QuestionOption(nil), question.Options...)
+	sort.Slice(options, func(i, j int) bool {
+		if options[i].Position != options[j].Position {
+			return options[i].Position < options[j].Position
+		}
+		return options[i].ID < options[j].ID
+	})
+	shuffle := question.Type == models.QuestionOrdering ||
+		(quiz.ShuffleOptions && question.Type != models.QuestionTrueFalse)
+	if shuffle {
+		// Semilla propia por pregunta: el orden no depende de qué otras preguntas haya.
+		rng := mathrand.New(mathrand.NewSource(seed ^ int64(question.ID)*7919))
+		rng.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+	}
+	return options
+}
+
+// loadAttemptQuestions carga las preguntas de un intento con sus opciones, incluidas las
+// que se eliminaron después de empezar.
+func loadAttemptQuestions(db *gorm.DB, attempt *models.QuizAttempt) (map[uint]*models.Question, error) {
+	var questions []models.Question
+	ids := attempt.QuestionIDList()
+	if len(ids) > 0 {
+		if err := db.Unscoped().Preload("Options").Where("id IN ?", ids).Find(&questions).Error; err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[uint]*models.Question, len(questions))
+	for i := range questions {
+		byID[questions[i].ID] = &questions[i]
+	}
+	return byID, nil
+}
+
+// loadAttemptAnswers carga las respuestas guardadas de un intento por pregunta.
+func loadAttemptAnswers(db *gorm.DB, attemptID uint) (map[uint]*models.QuizAttemptAnswer, error) {
+	var answers []models.QuizAttemptAnswer
+	if err := db.Where("attempt_id = ?", attemptID).Find(&answers).Error; err != nil {
+		return nil, err
+	}
+	byQuestion := make(map[uint]*models.QuizAttemptAnswer, len(answers))
+	for i := range answers {
+		byQuestion[answers[i].QuestionID] = &answers[i]
+	}
+	return byQuestion, nil
+}
+
+func decodeResponse(answer *models.QuizAttemptAnswer) attemptResponse {
+	var response attemptResponse
+	if answer != nil && answer.Response != "" {
+		_ = json.Unmarshal([]byte(answer.Response), &response)
+	}
+	return response
+}
+
+// gradeResponse indica si la respuesta es correcta. No hay puntuación parcial.
+func gradeResponse(question *models.Question, response attemptResponse) bool {
+	switch question.Type {
+	case models.QuestionShortText:
+		given := normalizeTextAnswer(response.Text)
+		if given == "" {
+			return false
+		}
+		for _, accepted := range question.AcceptedAnswerList() {
+			if normalizeTextAnswer(accepted) == given {
+				return true
+			}
+		}
+		return false
+	case models.QuestionOrdering:
+		if len(response.OptionIDs) != len(question.Options) {
+			return false
+		}
+		expected := correctOptionIDs(question)
+		for i := range expected {
+			if response.OptionIDs[i] != expected[i] {
+				return false
+			}
+		}
+		return true
+	default:
+		correct := make(map[uint]bool)
+		for _, id := range correctOptionIDs(question) {
+			correct[id] = true
+		}
+		chosen := make(map[uint]bool)
+		for _, id := range response.OptionIDs {
+			chosen[id] = true
+		}
+		if len(chosen) != len(correct) || len(response.OptionIDs) != len(chosen) {
+			return false
+		}
+		for id := range chosen {
+			if !correct[id] {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// correctOptionIDs devuelve las opciones correctas; para las de ordenar, todas en el orden correcto.
+func correctOptionIDs(question *models.Question) []uint {
+	options := append([]models.QuestionOption(nil), question.Options...)
+	sort.Slice(options, func(i, j int) bool {
+		if options[i].Position != options[j].Position {
+			return options[i].Position < options[j].Position
+		}
+		return options[i].ID < options[j].ID
+	})
+	ids := make([]uint, 0, len(options))
+	for _, option := range options {
+		if question.Type == models.QuestionOrdering || option.IsCorrect {
+			ids = append(ids, option.ID)
+		}
+	}
+	return ids
+}
+
+// attemptTimeUp indica si se acabó el tiempo del intento: desde entonces no se aceptan
+// respuestas nuevas.
+func attemptTimeUp(attempt *models.QuizAttempt, now time.Time) bool {
+	return attempt.ExpiresAt != nil && now.After(*attempt.ExpiresAt)
+}
+
+// attemptDeadlinePassed indica si ya pasó también el margen de entrega: el intento solo
+// puede cerrarse como vencido.
+func attemptDeadlinePassed(attempt *models.QuizAttempt, now time.Time) bool {
+	return attempt.ExpiresAt != nil && now.After(attempt.ExpiresAt.Add(quizSubmitGrace))
+}
+
+// attemptLimitReached indica si el usuario ya usó todos los intentos permitidos.
+func attemptLimitReached(quiz *models.Quiz, used int64) bool {
+	return quiz.MaxAttempts > 0 && used >= int64(quiz.MaxAttempts)
+}
+
+// finalizeAttempt califica las respuestas guardadas de un intento y lo cierra con status.
+// Si queda aprobado, lo refleja en el avance del curso con applyQuizPass.
+func finalizeAttempt(tx *gorm.DB, actor RequestActor, attempt *models.QuizAttempt, status string, now time.Time) error {
+	var quiz models.Quiz
+	if err := tx.Unscoped().First(&quiz, attempt.QuizID).Error; err != nil {
+		return err
+	}
+	questions, err := loadAttemptQuestions(tx, attempt)
+	if err != nil {
+		return err
+	}
+	answers, err := loadAttemptAnswers(tx, attempt.ID)
+	if err != nil {
+		return err
+	}
+
+	score, maxScore := 0, 0
+	for _, id := range attempt.QuestionIDList() {
+		question, ok := questions[id]
+		if !ok {
+			continue
+		}
+		maxScore += question.Points
+		answer, answered := answers[id]
+		if !answered {
+			continue
+		}
+		correct := gradeResponse(question, decodeResponse(answer))
+		points := 0
+		if correct {
+			points = question.Points
+		}
+		score += points
+		if err := tx.Model(answer).Updates(map[string]interface{}{"correct": correct, "points": points}).Error; err != nil {
+			return err
+		}
+	}
+
+	percent := 0
+	if maxScore > 0 {
+		percent = score * 100 / maxScore
+	}
+	passed := percent >= quiz.PassingScore
+	submittedAt := now
+	if status == models.AttemptExpired && attempt.ExpiresAt != nil {
+		submittedAt = *attempt.ExpiresAt
+	}
+	if err := tx.Model(attempt).Updates(map[string]interface{}{
+		"status":       status,
+		"submitted_at": submittedAt,
+		"score":        score,
+		"max_score":    maxScore,
+		"percent":      percent,
+		"passed":       passed,
+	}).Error; err != nil {
+		return err
+	}
+	attempt.Status = status
+	attempt.SubmittedAt = &submittedAt
+	attempt.Score = score
+	attempt.MaxScore = maxScore
+	attempt.Percent = percent
+	attempt.Passed = &passed
+	if passed {
+		return applyQuizPass(tx, actor, attempt, &quiz, now)
+	}
+	return nil
+}
+
+// applyQuizPass refleja un intento aprobado en la inscripción del usuario: completa la
+// lección asociada al cuestionario, si la hay, y vuelve a comprobar si el curso quedó
+// completado. No hace nada si la inscripción se canceló o ya está en otra vuelta.
+func applyQuizPass(tx *gorm.DB, actor RequestActor, attempt *models.QuizAttempt, quiz *models.Quiz, now time.Time) error {
+	if quiz.DeletedAt.Valid {
+		return nil
+	}
+	enrollment, err := findUserEnrollment(tx, attempt.UserID, quiz.CourseID, true)
+	if errors.Is(err, errNotEnrolled) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if enrollment.Cycle != attempt.Cycle {
+		return nil
+	}
+	if quiz.LessonID != nil {
+		_, err = saveLessonProgress(tx, actor, enrollment, *quiz.LessonID, UpdateLessonProgressDTO{Completed: true}, now)
+	} else {
+		_, err = advanceEnrollment(tx, actor, enrollment, now)
+	}
+	return err
+}
+
+// buildAttemptView arma la vista de un intento. Las respuestas correctas solo se incluyen
+// si el intento ya está calificado.
+func buildAttemptView(db *gorm.DB, attempt *models.QuizAttempt, now time.Time) (*AttemptView, error) {
+	var quiz models.Quiz
+	if err := db.Unscoped().First(&quiz, attempt.QuizID).Error; err != nil {
+		return nil, err
+	}
+	questions, err := loadAttemptQuestions(db, attempt)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := loadAttemptAnswers(db, attempt.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	graded := attempt.Status != models.AttemptInProgress
+	view := &AttemptView{QuizAttempt: *attempt, Questions: make([]QuestionView, 0, len(questions))}
+	if !graded && attempt.ExpiresAt != nil {
+		remaining := int(attempt.ExpiresAt.Sub(now).Seconds())
+		if remaining < 0 {
+			remaining = 0
+		}
+		view.RemainingSeconds = &remaining
+	}
+	for _, id := range attempt.QuestionIDList() {
+		question, ok := questions[id]
+		if !ok {
+			continue
+		}
+		qv := QuestionView{ID: question.ID, Type: question.Type, Prompt: question.Prompt, Points: question.Points}
+		if question.Type != models.QuestionShortText {
+			for _, option := range optionOrder(question, &quiz, attempt.Seed) {
+				qv.Options = append(qv.Options, OptionView{ID: option.ID, Text: option.Text})
+			}
+		}
+		answer := answers[id]
+		if answer != nil {
+			response := decodeResponse(answer)
+			qv.Answer = &AnswerDTO{QuestionID: id, OptionIDs: response.OptionIDs, Text: response.Text}
+		}
+		if graded {
+			correct := answer != nil && answer.Correct
+			points := 0
+			if answer != nil {
+				points = answer.Points
+			}
+			qv.Correct = &correct
+			qv.PointsAwarded = &points
+			qv.Explanation = question.Explanation
+			if question.Type == models.QuestionShortText {
+				qv.AcceptedAnswers = question.AcceptedAnswerList()
+			} else {
+				qv.CorrectOptionIDs = correctOptionIDs(question)
+			}
+		}
+		view.Questions = append(view.Questions, qv)
+	}
+	return view, nil
+}
+
+// findUserAttempt busca un intento del usuario; con lock bloquea la fila.
+func findUserAttempt(db *gorm.DB, userID, attemptID uint, lock bool) (*models.QuizAttempt, error) {
+	var attempt models.QuizAttempt
+	query := db
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := query.Where("id = ? AND user_id = ?", attemptID, userID).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errAttemptNotFound
+		}
+		log.Printf("Error al buscar intento %d: %v", attemptID, err)
+		return nil, errors.New("no se pudo obtener el intento")
+	}
+	return &attempt, nil
+}
+
+// saveAttemptAnswers guarda (o reemplaza) las respuestas de un intento en curso. Solo se
+// aceptan preguntas del intento y opciones de cada pregunta.
+func saveAttemptAnswers(tx *gorm.DB, attempt *models.QuizAttempt, answers []AnswerDTO) error {
+	if len(answers) == 0 {
+		return nil
+	}
+	questions, err := loadAttemptQuestions(tx, attempt)
+	if err != nil {
+		return err
+	}
+	for _, answer := range answers {
+		question, ok := questions[answer.QuestionID]
+		if !ok {
+			return fmt.Errorf("la pregunta %d no pertenece al intento", answer.QuestionID)
+		}
+		valid := make(map[uint]bool, len(question.Options))
+		for _, option := range question.Options {
+			valid[option.ID] = true
+		}
+		for _, id := range answer.OptionIDs {
+			if !valid[id] {
+				return fmt.Errorf("la opción %d no pertenece a la pregunta %d", id, answer.QuestionID)
+			}
+		}
+		raw, _ := json.Marshal(attemptResponse{OptionIDs: answer.OptionIDs, Text: answer.Text})
+		row := models.QuizAttemptAnswer{AttemptID: attempt.ID, QuestionID: answer.QuestionID, Response: string(raw)}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "attempt_id"}, {Name: "question_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"response", "updated_at"}),
+		}).Create(&row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isAnswerValidationError distingue los errores de respuestas inválidas de los de base de datos.
+func isAnswerValidationError(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "la pregunta ") || strings.HasPrefix(msg, "la opción ")
+}
+
+// GetQuizInfo devuelve un cuestionario y la situación del usuario en él.
+func (s *QuizService) GetQuizInfo(userID, quizID uint) (*QuizInfo, error) {
+	quiz, err := findQuiz(s.DB, quizID)
+	if err != nil {
+		return nil, err
+	}
+	enrollment, err := findUserEnrollment(s.DB, userID, quiz.CourseID, false)
+	if err != nil {
+		return nil, err
+	}
+	return s.quizInfo(enrollment, quiz)
+}
+
+// quizInfo resume la situación del usuario de la inscripción en el cuestionario; solo
+// cuentan los intentos de la vuelta de certificación actual.
+func (s *QuizService) quizInfo(enrollment *models.Enrollment, quiz *models.Quiz) (*QuizInfo, error) {
+	userID := enrollment.UserID
+	var questionCount int64
+	if err := s.DB.Model(&models.Quiz{}).Select(quizQuestionCountSQL).Where("id = ?", quiz.ID).
+		Scan(&questionCount).Error; err != nil {
+		log.Printf("Error al contar las preguntas del cuestionario %d: %v", quiz.ID, err)
+		return nil, errors.New("no se pudo obtener el cuestionario")
+	}
+	var attempts []models.QuizAttempt
+	if err := s.DB.Where("quiz_id = ? AND user_id = ? AND cycle = ?", quiz.ID, userID, enrollment.Cycle).Find(&attempts).Error; err != nil {
+		log.Printf("Error al obtener los intentos del cuestionario %d: %v", quiz.ID, err)
+		return nil, errors.New("no se pudo obtener el cuestionario")
+	}
+	info := &QuizInfo{
+		ID:               quiz.ID,
+		CourseID:         quiz.CourseID,
+		LessonID:         quiz.LessonID,
+		Title:            quiz.Title,
+		Description:      quiz.Description,
+		PassingScore:     quiz.PassingScore,
+		MaxAttempts:      quiz.MaxAttempts,
+		TimeLimitSeconds: quiz.TimeLimitSeconds,
+		QuestionCount:    int(questionCount),
+		AttemptsUsed:     len(attempts),
+	}
+	for i := range attempts {
+		attempt := &attempts[i]
+		if attempt.Status == models.AttemptInProgress {
+			id := attempt.ID
+			info.InProgressAttempt = &id
+			continue
+		}
+		if info.BestPercent == nil || attempt.Percent > *info.BestPercent {
+			best := attempt.Percent
+			info.BestPercent = &best
+		}
+		if attempt.Passed != nil && *attempt.Passed {
+			info.Passed = true
+		}
+	}
+	if quiz.MaxAttempts > 0 {
+		remaining := quiz.MaxAttempts - len(attempts)
+		if remaining < 0 {
+			remaining = 0
+		}
+		info.AttemptsRemaining = &remaining
+	}
+	return info, nil
+}
+
+// ListMyCourseQuizzes lista los cuestionarios de un curso en el que el usuario está inscrito.
+func (s *QuizService) ListMyCourseQuizzes(userID, courseID uint) ([]QuizInfo, error) {
+	enrollment, err := findUserEnrollment(s.DB, userID, courseID, false)
+	if err != nil {
+		return nil, err
+	}
+	var quizzes []models.Quiz
+	if err := s.DB.Where("course_id = ?", courseID).Order("id").Find(&quizzes).Error; err != nil {
+		log.Printf("Error al listar los cuestionarios del curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudieron obtener los cuestionarios")
+	}
+	infos := make([]QuizInfo, 0, len(quizzes))
+	for i := range quizzes {
+		info, err := s.quizInfo(enrollment, &quizzes[i])
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, *info)
+	}
+	return infos, nil
+}
+
+// StartAttempt empieza un intento o, si el usuario tiene uno en curso dentro de plazo, lo
+// devuelve para retomarlo.
+func (s *QuizService) StartAttempt(actor RequestActor, quizID uint) (*AttemptView, error) {
+	now := time.Now()
+	tx := s.DB.Begin()
+	quiz, err := findQuiz(tx, quizID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	// Bloquear la inscripción serializa los inicios de intento del usuario en el curso, así
+	// dos peticiones simultáneas no pueden superar el límite de intentos.
+	enrollment, err := findUserEnrollment(tx, actor.UserID, quiz.CourseID, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var current models.QuizAttempt
+	err = tx.Where("quiz_id = ? AND user_id = ? AND cycle = ? AND status = ?", quizID, actor.UserID, enrollment.Cycle, models.AttemptInProgress).
+		First(&current).Error
+	switch {
+	case err == nil && !attemptDeadlinePassed(&current, now):
+		view, err := buildAttemptView(tx, &current, now)
+		tx.Rollback()
+		if err != nil {
+			log.Printf("Error al retomar el intento %d: %v", current.ID, err)
+			return nil, errors.New("no se pudo empezar el intento")
+		}
+		return view, nil
+	case err == nil:
+		if err := finalizeAttempt(tx, actor, &current, models.AttemptExpired, now); err != nil {
+			tx.Rollback()
+			log.Printf("Error al cerrar el intento vencido %d: %v", current.ID, err)
+			return nil, errors.New("no se pudo empezar el intento")
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		tx.Rollback()
+		log.Printf("Error al buscar el intento en curso: %v", err)
+		return nil, errors.New("no se pudo empezar el intento")
+	}
+
+	var used int64
+	if err := tx.Model(&models.QuizAttempt{}).Where("quiz_id = ? AND user_id = ? AND cycle = ?", quizID, actor.UserID, enrollment.Cycle).
+		Count(&used).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al contar los intentos: %v", err)
+		return nil, errors.New("no se pudo empezar el intento")
+	}
+	if attemptLimitReached(quiz, used) {
+		tx.Commit() // Conserva el cierre del intento vencido, si lo hubo
+		return nil, errors.New("alcanzaste el número máximo de intentos")
+	}
+
+	seed, err := newAttemptSeed()
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al generar la semilla del intento: %v", err)
+		return nil, errors.New("no se pudo empezar el intento")
+	}
+	questionIDs, err := selectAttemptQuestions(tx, quiz, seed)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al elegir las preguntas del intento: %v", err)
+		return nil, errors.New("no se pudo empezar el intento")
+	}
+	if len(questionIDs) == 0 {
+		tx.Rollback()
+		return nil, errors.New("el cuestionario no tiene preguntas")
+	}
+	rawIDs, _ := json.Marshal(questionIDs)
+	attempt := models.QuizAttempt{
+		QuizID:        quizID,
+		UserID:        actor.UserID,
+		AttemptNumber: int(used) + 1,
+		Cycle:         enrollment.Cycle,
+		Status:        models.AttemptInProgress,
+		Seed:          seed,
+		QuestionIDs:   string(rawIDs),
+		StartedAt:     now,
+	}
+	if quiz.TimeLimitSeconds > 0 {
+		expiresAt := now.Add(time.Duration(quiz.TimeLimitSeconds) * time.Second)
+		attempt.ExpiresAt = &expiresAt
+	}
+	if err := tx.Create(&attempt).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear el intento: %v", err)
+		return nil, errors.New("no se pudo empezar el intento")
+	}
+	view, err := buildAttemptView(tx, &attempt, now)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al armar el intento %d: %v", attempt.ID, err)
+		return nil, errors.New("no se pudo empezar el intento")
+	}
+	tx.Commit()
+	return view, nil
+}
+
+// GetAttempt devuelve un intento del usuario. Si se le acabó el tiempo, antes lo cierra.
+func (s *QuizService) GetAttempt(userID, attemptID uint) (*AttemptView, error) {
+	now := time.Now()
+	tx := s.DB.Begin()
+	attempt, err := findUserAttempt(tx, userID, attemptID, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if attempt.Status == models.AttemptInProgress && attemptDeadlinePassed(attempt, now) {
+		if err := finalizeAttempt(tx, quizExpiryActor, attempt, models.AttemptExpired, now); err != nil {
+			tx.Rollback()
+			log.Printf("Error al cerrar el intento vencido %d: %v", attemptID, err)
+			return nil, errors.New("no se pudo obtener el intento")
+		}
+	}
+	view, err := buildAttemptView(tx, attempt, now)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al armar el intento %d: %v", attemptID, err)
+		return nil, errors.New("no se pudo obtener el intento")
+	}
+	tx.Commit()
+	return view, nil
+}
+
+// SaveAnswers guarda respuestas de un intento en curso sin entregarlo.
+func (s *QuizService) SaveAnswers(userID, attemptID uint, dto SaveAnswersDTO) (*AttemptView, error) {
+	now := time.Now()
+	tx := s.DB.Begin()
+	attempt, err := findUserAttempt(tx, userID, attemptID, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if attempt.Status != models.AttemptInProgress {
+		tx.Rollback()
+		return nil, errAttemptClosed
+	}
+	if attemptDeadlinePassed(attempt, now) {
+		if err := finalizeAttempt(tx, quizExpiryActor, attempt, models.AttemptExpired, now); err != nil {
+			tx.Rollback()
+			log.Printf("Error al cerrar el intento vencido %d: %v", attemptID, err)
+			return nil, errors.New("no se pudieron guardar las respuestas")
+		}
+		tx.Commit()
+		return nil, errAttemptTimeUp
+	}
+	if attemptTimeUp(attempt, now) {
+		tx.Rollback()
+		return nil, errAttemptTimeUp
+	}
+	if err := saveAttemptAnswers(tx, attempt, dto.Answers); err != nil {
+		tx.Rollback()
+		if isAnswerValidationError(err) {
+			return nil, err
+		}
+		log.Printf("Error al guardar las respuestas del intento %d: %v", attemptID, err)
+		return nil, errors.New("no se pudieron guardar las respuestas")
+	}
+	view, err := buildAttemptView(tx, attempt, now)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al armar el intento %d: %v", attemptID, err)
+		return nil, errors.New("no se pudieron guardar las respuestas")
+	}
+	tx.Commit()
+	return view, nil
+}
+
+// SubmitAttempt guarda las últimas respuestas, califica el intento y devuelve el resultado
+// con las respuestas correctas. Dentro del margen de entrega se califica solo lo guardado
+// antes del límite; pasado el margen, además, el intento queda como vencido. Un intento aprobado completa la lección asociada
+// y puede completar la inscripción.
+func (s *QuizService) SubmitAttempt(actor RequestActor, attemptID uint, dto SaveAnswersDTO) (*AttemptView, error) {
+	now := time.Now()
+	tx := s.DB.Begin()
+	attempt, err := findUserAttempt(tx, actor.UserID, attemptID, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if attempt.Status != models.AttemptInProgress {
+		tx.Rollback()
+		return nil, errAttemptClosed
+	}
+	status := models.AttemptSubmitted
+	if attemptDeadlinePassed(attempt, now) {
+		status = models.AttemptExpired
+	}
+	// En el margen de entrega se califica lo guardado, sin las respuestas de este envío.
+	if !attemptTimeUp(attempt, now) {
+		if err := saveAttemptAnswers(tx, attempt, dto.Answers); err != nil {
+			tx.Rollback()
+			if isAnswerValidationError(err) {
+				return nil, err
+			}
+			log.Printf("Error al guardar las respuestas del intento %d: %v", attemptID, err)
+			return nil, errors.New("no se pudo entregar el intento")
+		}
+	}
+	if err := finalizeAttempt(tx, actor, attempt, status, now); err != nil {
+		tx.Rollback()
+		log.Printf("Error al calificar el intento %d: %v", attemptID, err)
+		return nil, errors.New("no se pudo entregar el intento")
+	}
+	view, err := buildAttemptView(tx, attempt, now)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al armar el resultado del intento %d: %v", attemptID, err)
+		return nil, errors.New("no se pudo entregar el intento")
+	}
+	tx.Commit()
+	return view, nil
+}
+
+// ListMyAttempts lista los intentos del usuario en un cuestionario.
+func (s *QuizService) ListMyAttempts(userID, quizID uint) ([]models.QuizAttempt, error) {
+	attempts := make([]models.QuizAttempt, 0)
+	if err := s.DB.Where("quiz_id = ? AND user_id = ?", quizID, userID).Order("cycle, attempt_number").
+		Find(&attempts).Error; err != nil {
+		log.Printf("Error al listar los intentos del cuestionario %d: %v", quizID, err)
+		return nil, errors.New("no se pudieron obtener los intentos")
+	}
+	return attempts, nil
+}
+
+// expireAttemptsBatchSize limita cuántos intentos vencidos se cierran por ejecución.
+const expireAttemptsBatchSize = 200
+
+// finalizeExpiredAttempts cierra y califica los intentos abandonados cuyo plazo ya pasó.
+func finalizeExpiredAttempts(ctx context.Context, db *gorm.DB) error {
+	now := time.Now()
+	db = db.WithContext(ctx)
+	var ids []uint
+	if err := db.Model(&models.QuizAttempt{}).
+		Where("status = ? AND expires_at < ?", models.AttemptInProgress, now.Add(-quizSubmitGrace)).
+		Order("id").Limit(expireAttemptsBatchSize).Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("no se pudieron buscar los intentos vencidos: %w", err)
+	}
+	closed := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		tx := db.Begin()
+		var attempt models.QuizAttempt
+		// Releer con bloqueo: el usuario pudo haberlo entregado mientras tanto.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status = ?", id, models.AttemptInProgress).First(&attempt).Error; err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+		if err := finalizeAttempt(tx, quizExpiryActor, &attempt, models.AttemptExpired, now); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("no se pudo cerrar el intento %d: %w", id, err)
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+		closed++
+	}
+	if closed > 0 {
+		log.Printf("Cuestionarios: %d intento(s) vencido(s) cerrados.", closed)
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+)
+
+func TestGradeResponse(t *testing.T) {
+	single := &models.Question{Type: models.QuestionSingleChoice, Options: []models.QuestionOption{
+		{ID: 1, Position: 1}, {ID: 2, Position: 2, IsCorrect: true}, {ID: 3, Position: 3},
+	}}
+	multiple := &models.Question{Type: models.QuestionMultipleChoice, Options: []models.QuestionOption{
+		{ID: 11, Position: 1, IsCorrect: true}, {ID: 12, Position: 2}, {ID: 13, Position: 3, IsCorrect: true}, {ID: 14, Position: 4},
+	}}
+	trueFalse := &models.Question{Type: models.QuestionTrueFalse, Options: []models.QuestionOption{
+		{ID: 21, Position: 1, IsCorrect: true}, {ID: 22, Position: 2},
+	}}
+	ordering := &models.Question{Type: models.QuestionOrdering, Options: []models.QuestionOption{
+		// El orden correcto es el de Position, no el de los IDs.
+		{ID: 33, Position: 1}, {ID: 31, Position: 2}, {ID: 32, Position: 3},
+	}}
+	shortText := &models.Question{Type: models.QuestionShortText, AcceptedAnswers: `["Ciudad de México","CDMX"]`}
+
+	tests := []struct {
+		name     string
+		question *models.Question
+		response attemptResponse
+		want     bool
+	}{
+		{"única: correcta", single, attemptResponse{OptionIDs: []uint{2}}, true},
+		{"única: incorrecta", single, attemptResponse{OptionIDs: []uint{1}}, false},
+		{"única: sin responder", single, attemptResponse{}, false},
+		{"única: correcta más otra", single, attemptResponse{OptionIDs: []uint{2, 3}}, false},
+		{"única: correcta repetida", single, attemptResponse{OptionIDs: []uint{2, 2}}, false},
+
+		{"múltiple: exacta", multiple, attemptResponse{OptionIDs: []uint{13, 11}}, true},
+		{"múltiple: selección parcial", multiple, attemptResponse{OptionIDs: []uint{11}}, false},
+		{"múltiple: opción de más", multiple, attemptResponse{OptionIDs: []uint{11, 13, 14}}, false},
+		{"múltiple: mismo número, otra opción", multiple, attemptResponse{OptionIDs: []uint{11, 12}}, false},
+		{"múltiple: repetida para completar", multiple, attemptResponse{OptionIDs: []uint{11, 11}}, false},
+		{"múltiple: opción ajena", multiple, attemptResponse{OptionIDs: []uint{11, 99}}, false},
+
+		{"verdadero/falso: correcta", trueFalse, attemptResponse{OptionIDs: []uint{21}}, true},
+		{"verdadero/falso: incorrecta", trueFalse, attemptResponse{OptionIDs: []uint{22}}, false},
+		{"verdadero/falso: ambas", trueFalse, attemptResponse{OptionIDs: []uint{21, 22}}, false},
+
+		{"ordenar: orden correcto", ordering, attemptResponse{OptionIDs: []uint{33, 31, 32}}, true},
+		{"ordenar: orden de IDs", ordering, attemptResponse{OptionIDs: []uint{31, 32, 33}}, false},
+		{"ordenar: incompleto", ordering, attemptResponse{OptionIDs: []uint{33, 31}}, false},
+		{"ordenar: elemento de más", ordering, attemptResponse{OptionIDs: []uint{33, 31, 32, 32}}, false},
+
+		{"corta: exacta", shortText, attemptResponse{Text: "CDMX"}, true},
+		{"corta: mayúsculas y espacios", shortText, attemptResponse{Text: "  ciudad   de MÉXICO "}, true},
+		{"corta: otra respuesta aceptada", shortText, attemptResponse{Text: "cdmx"}, true},
+		{"corta: incorrecta", shortText, attemptResponse{Text: "Guadalajara"}, false},
+		{"corta: parcial", shortText, attemptResponse{Text: "Ciudad"}, false},
+		{"corta: vacía", shortText, attemptResponse{Text: "   "}, false},
+		{"corta: sin respuestas aceptadas", &models.Question{Type: models.QuestionShortText}, attemptResponse{Text: "x"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gradeResponse(tt.question, tt.response); got != tt.want {
+				t.Errorf("gradeResponse = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttemptTimeBoundaries(t *testing.T) {
+	expiresAt := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	timed := &models.QuizAttempt{ExpiresAt: &expiresAt}
+	untimed := &models.QuizAttempt{}
+	tests := []struct {
+		name             string
+		attempt          *models.QuizAttempt
+		now              time.Time
+		wantTimeUp       bool
+		wantDeadlinePast bool
+	}{
+		{"antes del límite", timed, expiresAt.Add(-time.Second), false, false},
+		{"justo en el límite", timed, expiresAt, false, false},
+		{"recién pasado el límite", timed, expiresAt.Add(time.Millisecond), true, false},
+		{"al final del margen", timed, expiresAt.Add(quizSubmitGrace), true, false},
+		{"pasado el margen", timed, expiresAt.Add(quizSubmitGrace + time.Millisecond), true, true},
+		{"sin límite de tiempo", untimed, expiresAt.Add(24 * time.Hour), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attemptTimeUp(tt.attempt, tt.now); got != tt.wantTimeUp {
+				t.Errorf("attemptTimeUp = %v, se esperaba %v", got, tt.wantTimeUp)
+			}
+			if got := attemptDeadlinePassed(tt.attempt, tt.now); got != tt.wantDeadlinePast {
+				t.Errorf("attemptDeadlinePassed = %v, se esperaba %v", got, tt.wantDeadlinePast)
+			}
+		})
+	}
+}
+
+func TestAttemptLimitReached(t *testing.T) {
+	tests := []struct {
+		maxAttempts int
+		used        int64
+		want        bool
+	}{
+		{0, 0, false},
+		{0, 100, false}, // Sin límite
+		{1, 0, false},
+		{1, 1, true},
+		{3, 2, false},
+		{3, 3, true},
+		{3, 4, true},
+	}
+	for _, tt := range tests {
+		if got := attemptLimitReached(&models.Quiz{MaxAttempts: tt.maxAttempts}, tt.used); got != tt.want {
+			t.Errorf("attemptLimitReached(max=%d, usados=%d) = %v, se esperaba %v", tt.maxAttempts, tt.used, got, tt.want)
+		}
+	}
+}
+
+// En el margen de entrega el autoguardado ya no acepta respuestas, y tampoco cierra el
+// intento: la entrega final todavía puede llegar.
+func TestSaveAnswersRejectedOnceTimeIsUp(t *testing.T) {
+	db, mock := newMockDB(t)
+	expiresAt := time.Now().Add(-5 * time.Second)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `quiz_attempts` WHERE id = \\? AND user_id = \\?.*FOR UPDATE").
+		WithArgs(9, 4, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quiz_id", "user_id", "status", "expires_at", "question_ids"}).
+			AddRow(9, 2, 4, models.AttemptInProgress, expiresAt, "[1]"))
+	mock.ExpectRollback()
+
+	service := &QuizService{DB: db}
+	_, err := service.SaveAnswers(4, 9, SaveAnswersDTO{Answers: []AnswerDTO{{QuestionID: 1, OptionIDs: []uint{1}}}})
+	if !errors.Is(err, errAttemptTimeUp) {
+		t.Fatalf("SaveAnswers = %v, se esperaba %v", err, errAttemptTimeUp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
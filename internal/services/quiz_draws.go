@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"log"
+	mathrand "math/rand"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// DrawRuleDTO define el cuerpo de la creación y edición de una regla de sorteo: Count
+// preguntas del banco BankID (del tema Topic, si se indica) repartidas según los porcentajes
+// de dificultad, que deben sumar 100 o ser todos 0 (cualquier dificultad).
+type DrawRuleDTO struct {
+	BankID        uint   `json:"bank_id" binding:"required"`
+	Topic         string `json:"topic" binding:"max=100"`
+	Count         int    `json:"count" binding:"required,min=1,max=200"`
+	EasyPercent   int    `json:"easy_percent" binding:"min=0,max=100"`
+	MediumPercent int    `json:"medium_percent" binding:"min=0,max=100"`
+	HardPercent   int    `json:"hard_percent" binding:"min=0,max=100"`
+}
+
+var errDrawRuleNotFound = errors.New("regla de sorteo no encontrada")
+
+// bankCandidates devuelve las preguntas vigentes del banco que cumplen el tema de la regla,
+// ordenadas por ID para que el sorteo con una misma semilla sea reproducible.
+func bankCandidates(db *gorm.DB, rule *models.QuizDrawRule) ([]models.Question, error) {
+	query := db.Model(&models.Question{}).Select("id, difficulty").Where("bank_id = ?", rule.BankID)
+	if rule.Topic != "" {
+		query = query.Where("topic = ?", rule.Topic)
+	}
+	var candidates []models.Question
+	if err := query.Order("id").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// drawFromBank sortea las preguntas de una regla con un generador derivado de la semilla del
+// intento, sin repetir las de exclude. Si el banco cambió y falta alguna dificultad, se
+// completa con preguntas de las demás.
+func drawFromBank(db *gorm.DB, rule *models.QuizDrawRule, seed int64, exclude map[uint]bool) ([]uint, error) {
+	candidates, err := bankCandidates(db, rule)
+	if err != nil {
+		return nil, err
+	}
+	rng := mathrand.New(mathrand.NewSource(seed ^ int64(rule.ID)*104729))
+	shuffle := func(ids []uint) {
+		rng.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	}
+
+	byDifficulty := make(map[models.QuestionDifficulty][]uint)
+	var all []uint
+	for _, candidate := range candidates {
+		if exclude[candidate.ID] {
+			continue
+		}
+		byDifficulty[candidate.Difficulty] = append(byDifficulty[candidate.Difficulty], candidate.ID)
+		all = append(all, candidate.ID)
+	}
+
+	counts := rule.DifficultyCounts()
+	if counts == nil {
+		shuffle(all)
+		if len(all) > rule.Count {
+			all = all[:rule.Count]
+		}
+		return all, nil
+	}
+	drawn := make([]uint, 0, rule.Count)
+	var leftover []uint
+	shortfall := 0
+	for _, difficulty := range models.QuestionDifficulties {
+		group := byDifficulty[difficulty]
+		shuffle(group)
+		want := counts[difficulty]
+		if want > len(group) {
+			shortfall += want - len(group)
+			want = len(group)
+		}
+		drawn = append(drawn, group[:want]...)
+		leftover = append(leftover, group[want:]...)
+	}
+	if shortfall > 0 {
+		log.Printf("Sorteo: al banco %d le faltan %d pregunta(s) para la distribución de la regla %d; se completan con otras dificultades", rule.BankID, shortfall, rule.ID)
+		shuffle(leftover)
+		if shortfall > len(leftover) {
+			shortfall = len(leftover)
+		}
+		drawn = append(drawn, leftover[:shortfall]...)
+	}
+	return drawn, nil
+}
+
+// checkBankSupply comprueba que el banco tenga hoy preguntas suficientes para la regla.
+func checkBankSupply(db *gorm.DB, rule *models.QuizDrawRule) error {
+	candidates, err := bankCandidates(db, rule)
+	if err != nil {
+		log.Printf("Error al contar las preguntas del banco %d: %v", rule.BankID, err)
+		return errors.New("no se pudo comprobar el banco de preguntas")
+	}
+	available := make(map[models.QuestionDifficulty]int)
+	for _, candidate := range candidates {
+		available[candidate.Difficulty]++
+	}
+	counts := rule.DifficultyCounts()
+	if counts == nil {
+		if len(candidates) < rule.Count {
+			return errors.New("el banco no tiene suficientes preguntas para la regla de sorteo")
+		}
+		return nil
+	}
+	for difficulty, want := range counts {
+		if available[difficulty] < want {
+			return errors.New("el banco no tiene suficientes preguntas para la regla de sorteo")
+		}
+	}
+	return nil
+}
+
+// applyDrawRuleDTO valida dto y lo copia en rule, comprobando que el actor pueda usar el banco.
+func applyDrawRuleDTO(tx *gorm.DB, actor RequestActor, rule *models.QuizDrawRule, dto DrawRuleDTO) error {
+	if dto.EasyPercent+dto.MediumPercent+dto.HardPercent != 0 &&
+		dto.EasyPercent+dto.MediumPercent+dto.HardPercent != 100 {
+		return errors.New("los porcentajes de dificultad deben sumar 100 (o ser todos 0)")
+	}
+	if _, err := findManagedBank(tx, actor, dto.BankID, false); err != nil {
+		return err
+	}
+	rule.BankID = dto.BankID
+	rule.Topic = strings.TrimSpace(dto.Topic)
+	rule.Count = dto.Count
+	rule.EasyPercent = dto.EasyPercent
+	rule.MediumPercent = dto.MediumPercent
+	rule.HardPercent = dto.HardPercent
+	return checkBankSupply(tx, rule)
+}
+
+func drawRuleAudit(rule *models.QuizDrawRule) map[string]interface{} {
+	return map[string]interface{}{
+		"draw_rule":      rule.ID,
+		"bank_id":        rule.BankID,
+		"topic":          rule.Topic,
+		"count":          rule.Count,
+		"easy_percent":   rule.EasyPercent,
+		"medium_percent": rule.MediumPercent,
+		"hard_percent":   rule.HardPercent,
+	}
+}
+
+// findQuizDrawRule busca una regla de sorteo de un cuestionario.
+func findQuizDrawRule(db *gorm.DB, quizID, ruleID uint) (*models.QuizDrawRule, error) {
+	var rule models.QuizDrawRule
+	if err := db.Where("id = ? AND quiz_id = ?", ruleID, quizID).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errDrawRuleNotFound
+		}
+		log.Printf("Error al buscar regla de sorteo %d: %v", ruleID, err)
+		return nil, errors.New("no se pudo obtener la regla de sorteo")
+	}
+	return &rule, nil
+}
+
+// AddDrawRule agrega al cuestionario una regla de sorteo desde un banco de preguntas.
+func (s *QuizService) AddDrawRule(actor RequestActor, quizID uint, dto DrawRuleDTO) (*models.QuizDrawRule, error) {
+	tx := s.DB.Begin()
+	quiz, err := findEditableQuiz(tx, actor, quizID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	rule := models.QuizDrawRule{QuizID: quizID}
+	if err := applyDrawRuleDTO(tx, actor, &rule, dto); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Create(&rule).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear regla de sorteo en el cuestionario %d: %v", quizID, err)
+		return nil, errors.New("no se pudo crear la regla de sorteo")
+	}
+	if err := auditQuiz(tx, actor, models.AuditActionQuizUpdated, quiz, nil, drawRuleAudit(&rule)); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la regla de sorteo del cuestionario %d: %v", quizID, err)
+		return nil, errors.New("no se pudo crear la regla de sorteo")
+	}
+	tx.Commit()
+	return &rule, nil
+}
+
+// UpdateDrawRule modifica una regla de sorteo. Afecta solo a los intentos que empiecen después.
+func (s *QuizService) UpdateDrawRule(actor RequestActor, quizID, ruleID uint, dto DrawRuleDTO) (*models.QuizDrawRule, error) {
+	tx := s.DB.Begin()
+	quiz, err := findEditableQuiz(tx, actor, quizID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	rule, err := findQuizDrawRule(tx, quizID, ruleID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	before := drawRuleAudit(rule)
+	if err := applyDrawRuleDTO(tx, actor, rule, dto); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Save(rule).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar regla de sorteo %d: %v", ruleID, err)
+		return nil, errors.New("no se pudo actualizar la regla de sorteo")
+	}
+	if err := auditQuiz(tx, actor, models.AuditActionQuizUpdated, quiz, before, drawRuleAudit(rule)); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la regla de sorteo %d: %v", ruleID, err)
+		return nil, errors.New("no se pudo actualizar la regla de sorteo")
+	}
+	tx.Commit()
+	return rule, nil
+}
+
+// DeleteDrawRule elimina (borrado lógico) una regla de sorteo.
+func (s *QuizService) DeleteDrawRule(actor RequestActor, quizID, ruleID uint) error {
+	tx := s.DB.Begin()
+	quiz, err := findEditableQuiz(tx, actor, quizID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rule, err := findQuizDrawRule(tx, quizID, ruleID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(rule).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar regla de sorteo %d: %v", ruleID, err)
+		return errors.New("no se pudo eliminar la regla de sorteo")
+	}
+	if err := auditQuiz(tx, actor, models.AuditActionQuizUpdated, quiz, drawRuleAudit(rule), nil); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la eliminación de la regla de sorteo %d: %v", ruleID, err)
+		return errors.New("no se pudo eliminar la regla de sorteo")
+	}
+	tx.Commit()
+	return nil
+}
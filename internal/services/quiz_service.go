@@ -0,0 +1,525 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// QuizDTO define el cuerpo de la creación y edición (completa) de un cuestionario.
+type QuizDTO struct {
+	LessonID         *uint  `json:"lesson_id"`
+	Title            string `json:"title" binding:"required,max=200"`
+	Description      string `json:"description"`
+	PassingScore     *int   `json:"passing_score" binding:"omitempty,min=0,max=100"`
+	MaxAttempts      int    `json:"max_attempts" binding:"min=0"`
+	TimeLimitSeconds int    `json:"time_limit_seconds" binding:"min=0"`
+	ShuffleQuestions bool   `json:"shuffle_questions"`
+	ShuffleOptions   bool   `json:"shuffle_options"`
+}
+
+// QuestionOptionDTO es una opción de respuesta. En las preguntas de ordenar, el orden de
+// la lista es el orden correcto y Correct se ignora.
+type QuestionOptionDTO struct {
+	Text    string `json:"text" binding:"required,max=500"`
+	Correct bool   `json:"correct"`
+}
+
+// QuestionDTO define el cuerpo de la creación y edición (completa) de una pregunta.
+type QuestionDTO struct {
+	Type        string              `json:"type" binding:"required"`
+	Prompt      string              `json:"prompt" binding:"required"`
+	Explanation string              `json:"explanation"`
+	Points      *int                `json:"points" binding:"omitempty,min=0"`
+	Options     []QuestionOptionDTO `json:"options" binding:"omitempty,dive"`
+	// CorrectBoolean es la respuesta de las preguntas true_false.
+	CorrectBoolean *bool `json:"correct_boolean"`
+	// AcceptedAnswers son las respuestas válidas de las preguntas short_text.
+	AcceptedAnswers []string `json:"accepted_answers"`
+	// Topic y Difficulty clasifican las preguntas de los bancos para sortearlas.
+	Topic      string `json:"topic" binding:"max=100"`
+	Difficulty string `json:"difficulty"`
+}
+
+// quizQuestionCountSQL cuenta las preguntas de cada intento de un cuestionario: las propias
+// más las que sortean sus reglas.
+const quizQuestionCountSQL = "(SELECT COUNT(*) FROM questions WHERE questions.quiz_id = quizzes.id AND questions.deleted_at IS NULL)" +
+	" + (SELECT COALESCE(SUM(quiz_draw_rules.count), 0) FROM quiz_draw_rules WHERE quiz_draw_rules.quiz_id = quizzes.id AND quiz_draw_rules.deleted_at IS NULL)"
+
+// QuizSummary es un cuestionario sin preguntas, con cuántas tiene cada intento.
+type QuizSummary struct {
+	models.Quiz
+	QuestionCount int64 `json:"question_count"`
+}
+
+// QuizServiceInterface define la gestión de cuestionarios (administradores e instructores del
+// curso) y su realización por los usuarios inscritos.
+type QuizServiceInterface interface {
+	ListCourseQuizzes(actor RequestActor, courseID uint) ([]QuizSummary, error)
+	GetManagedQuiz(actor RequestActor, id uint) (*models.Quiz, error)
+	CreateQuiz(actor RequestActor, courseID uint, dto QuizDTO) (*models.Quiz, error)
+	UpdateQuiz(actor RequestActor, id uint, dto QuizDTO) (*models.Quiz, error)
+	DeleteQuiz(actor RequestActor, id uint) error
+	AddQuestion(actor RequestActor, quizID uint, dto QuestionDTO) (*models.Question, error)
+	UpdateQuestion(actor RequestActor, quizID, questionID uint, dto QuestionDTO) (*models.Question, error)
+	DeleteQuestion(actor RequestActor, quizID, questionID uint) error
+	ListQuizAttempts(actor RequestActor, quizID uint) ([]models.QuizAttempt, error)
+	AddDrawRule(actor RequestActor, quizID uint, dto DrawRuleDTO) (*models.QuizDrawRule, error)
+	UpdateDrawRule(actor RequestActor, quizID, ruleID uint, dto DrawRuleDTO) (*models.QuizDrawRule, error)
+	DeleteDrawRule(actor RequestActor, quizID, ruleID uint) error
+	GetQuizItemStats(actor RequestActor, quizID uint) ([]ItemStat, error)
+
+	ListMyCourseQuizzes(userID, courseID uint) ([]QuizInfo, error)
+	GetQuizInfo(userID, quizID uint) (*QuizInfo, error)
+	StartAttempt(actor RequestActor, quizID uint) (*AttemptView, error)
+	GetAttempt(userID, attemptID uint) (*AttemptView, error)
+	SaveAnswers(userID, attemptID uint, dto SaveAnswersDTO) (*AttemptView, error)
+	SubmitAttempt(actor RequestActor, attemptID uint, dto SaveAnswersDTO) (*AttemptView, error)
+	ListMyAttempts(userID, quizID uint) ([]models.QuizAttempt, error)
+}
+
+// QuizService implementa QuizServiceInterface.
+type QuizService struct {
+	DB *gorm.DB
+}
+
+// NewQuizService crea una nueva instancia de QuizService.
+func NewQuizService(db *gorm.DB) *QuizService {
+	return &QuizService{DB: db}
+}
+
+var (
+	errQuizNotFound     = errors.New("cuestionario no encontrado")
+	errQuestionNotFound = errors.New("pregunta no encontrada")
+)
+
+func quizAuditSnapshot(quiz *models.Quiz) map[string]interface{} {
+	return map[string]interface{}{
+		"course_id":          quiz.CourseID,
+		"lesson_id":          quiz.LessonID,
+		"title":              quiz.Title,
+		"passing_score":      quiz.PassingScore,
+		"max_attempts":       quiz.MaxAttempts,
+		"time_limit_seconds": quiz.TimeLimitSeconds,
+	}
+}
+
+// findQuiz busca un cuestionario.
+func findQuiz(db *gorm.DB, id uint) (*models.Quiz, error) {
+	var quiz models.Quiz
+	if err := db.First(&quiz, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errQuizNotFound
+		}
+		log.Printf("Error al buscar cuestionario %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener el cuestionario")
+	}
+	return &quiz, nil
+}
+
+// findManagedQuiz busca un cuestionario y comprueba que el actor pueda gestionar su curso.
+func findManagedQuiz(db *gorm.DB, actor RequestActor, id uint, lock bool) (*models.Quiz, *models.Course, error) {
+	quiz, err := findQuiz(db, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	course, err := findManagedCourse(db, actor, quiz.CourseID, lock)
+	if err != nil {
+		return nil, nil, err
+	}
+	return quiz, course, nil
+}
+
+// applyQuizDTO copia dto en quiz comprobando que la lección, si se indica, sea del curso.
+func applyQuizDTO(db *gorm.DB, quiz *models.Quiz, dto QuizDTO) error {
+	if dto.LessonID != nil {
+		var count int64
+		if err := db.Model(&models.Lesson{}).
+			Joins("JOIN course_modules ON course_modules.id = lessons.module_id AND course_modules.deleted_at IS NULL").
+			Where("lessons.id = ? AND course_modules.course_id = ?", *dto.LessonID, quiz.CourseID).
+			Count(&count).Error; err != nil {
+			log.Printf("Error al comprobar la lección %d: %v", *dto.LessonID, err)
+			return errors.New("no se pudo comprobar la lección")
+		}
+		if count == 0 {
+			return errors.New("la lección no pertenece al curso")
+		}
+	}
+	if strings.TrimSpace(dto.Title) == "" {
+		return errors.New("el título del cuestionario no puede estar vacío")
+	}
+	quiz.LessonID = dto.LessonID
+	quiz.Title = strings.TrimSpace(dto.Title)
+	quiz.Description = dto.Description
+	quiz.PassingScore = 70
+	if dto.PassingScore != nil {
+		quiz.PassingScore = *dto.PassingScore
+	}
+	quiz.MaxAttempts = dto.MaxAttempts
+	quiz.TimeLimitSeconds = dto.TimeLimitSeconds
+	quiz.ShuffleQuestions = dto.ShuffleQuestions
+	quiz.ShuffleOptions = dto.ShuffleOptions
+	return nil
+}
+
+// auditQuiz registra un cambio en un cuestionario.
+func auditQuiz(tx *gorm.DB, actor RequestActor, action string, quiz *models.Quiz, before, after map[string]interface{}) error {
+	return recordAudit(tx, actor, auditRecord{
+		Action:     action,
+		TargetType: models.AuditTargetQuiz,
+		TargetID:   quiz.ID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// ListCourseQuizzes lista los cuestionarios de un curso gestionable por el actor.
+func (s *QuizService) ListCourseQuizzes(actor RequestActor, courseID uint) ([]QuizSummary, error) {
+	if _, err := findManagedCourse(s.DB, actor, courseID, false); err != nil {
+		return nil, err
+	}
+	quizzes := make([]QuizSummary, 0)
+	if err := s.DB.Model(&models.Quiz{}).
+		Select("quizzes.*, "+quizQuestionCountSQL+" AS question_count").
+		Where("course_id = ?", courseID).Order("id").Scan(&quizzes).Error; err != nil {
+		log.Printf("Error al listar cuestionarios del curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudieron obtener los cuestionarios")
+	}
+	return quizzes, nil
+}
+
+// GetManagedQuiz devuelve un cuestionario con sus preguntas y respuestas correctas y sus
+// reglas de sorteo.
+func (s *QuizService) GetManagedQuiz(actor RequestActor, id uint) (*models.Quiz, error) {
+	quiz, _, err := findManagedQuiz(s.DB, actor, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DB.Preload("Questions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).Preload("Questions.Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).Preload("Draws", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Draws.Bank").First(quiz, id).Error; err != nil {
+		log.Printf("Error al cargar las preguntas del cuestionario %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener el cuestionario")
+	}
+	return quiz, nil
+}
+
+// CreateQuiz crea un cuestionario en un curso no archivado.
+func (s *QuizService) CreateQuiz(actor RequestActor, courseID uint, dto QuizDTO) (*models.Quiz, error) {
+	tx := s.DB.Begin()
+	if _, err := findEditableCourse(tx, actor, courseID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	quiz := models.Quiz{CourseID: courseID}
+	if err := applyQuizDTO(tx, &quiz, dto); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Create(&quiz).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear cuestionario en el curso %d: %v", courseID, err)
+		return nil, errors.New("no se pudo crear el cuestionario")
+	}
+	if err := auditQuiz(tx, actor, models.AuditActionQuizCreated, &quiz, nil, quizAuditSnapshot(&quiz)); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la creación del cuestionario: %v", err)
+		return nil, errors.New("no se pudo crear el cuestionario")
+	}
+	tx.Commit()
+	return &quiz, nil
+}
+
+// UpdateQuiz modifica la configuración de un cuestionario. Los intentos ya calificados
+// conservan su resultado.
+func (s *QuizService) UpdateQuiz(actor RequestActor, id uint, dto QuizDTO) (*models.Quiz, error) {
+	tx := s.DB.Begin()
+	quiz, course, err := findManagedQuiz(tx, actor, id, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if course.Status == models.CourseArchived {
+		tx.Rollback()
+		return nil, errCourseArchived
+	}
+	before := quizAuditSnapshot(quiz)
+	if err := applyQuizDTO(tx, quiz, dto); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Save(quiz).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar cuestionario %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el cuestionario")
+	}
+	if err := auditQuiz(tx, actor, models.AuditActionQuizUpdated, quiz, before, quizAuditSnapshot(quiz)); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar el cuestionario %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el cuestionario")
+	}
+	tx.Commit()
+	return quiz, nil
+}
+
+// DeleteQuiz elimina (borrado lógico) un cuestionario; los intentos se conservan.
+func (s *QuizService) DeleteQuiz(actor RequestActor, id uint) error {
+	tx := s.DB.Begin()
+	quiz, _, err := findManagedQuiz(tx, actor, id, true)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(quiz).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar cuestionario %d: %v", id, err)
+		return errors.New("no se pudo eliminar el cuestionario")
+	}
+	if err := auditQuiz(tx, actor, models.AuditActionQuizDeleted, quiz, quizAuditSnapshot(quiz), nil); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la eliminación del cuestionario %d: %v", id, err)
+		return errors.New("no se pudo eliminar el cuestionario")
+	}
+	tx.Commit()
+	return nil
+}
+
+// buildQuestion valida dto según el tipo de pregunta y arma la pregunta con sus opciones.
+func buildQuestion(dto QuestionDTO) (*models.Question, error) {
+	questionType, err := models.ParseQuestionType(dto.Type)
+	if err != nil {
+		return nil, errors.New("tipo de pregunta inválido")
+	}
+	if strings.TrimSpace(dto.Prompt) == "" {
+		return nil, errors.New("el enunciado de la pregunta no puede estar vacío")
+	}
+	difficulty := models.DifficultyMedium
+	if dto.Difficulty != "" {
+		if difficulty, err = models.ParseQuestionDifficulty(dto.Difficulty); err != nil {
+			return nil, errors.New("dificultad de pregunta inválida")
+		}
+	}
+	question := &models.Question{
+		Type:        questionType,
+		Prompt:      strings.TrimSpace(dto.Prompt),
+		Explanation: dto.Explanation,
+		Topic:       strings.TrimSpace(dto.Topic),
+		Difficulty:  difficulty,
+		Points:      1,
+	}
+	if dto.Points != nil {
+		question.Points = *dto.Points
+	}
+
+	correct := 0
+	for _, option := range dto.Options {
+		if option.Correct {
+			correct++
+		}
+	}
+	switch questionType {
+	case models.QuestionSingleChoice:
+		if len(dto.Options) < 2 || correct != 1 {
+			return nil, errors.New("las preguntas de opción única necesitan al menos dos opciones y exactamente una correcta")
+		}
+	case models.QuestionMultipleChoice:
+		if len(dto.Options) < 2 || correct < 1 {
+			return nil, errors.New("las preguntas de opción múltiple necesitan al menos dos opciones y alguna correcta")
+		}
+	case models.QuestionOrdering:
+		if len(dto.Options) < 2 {
+			return nil, errors.New("las preguntas de ordenar necesitan al menos dos elementos")
+		}
+	case models.QuestionTrueFalse:
+		if dto.CorrectBoolean == nil {
+			return nil, errors.New("las preguntas de verdadero/falso requieren correct_boolean")
+		}
+		question.Options = []models.QuestionOption{
+			{Text: "Verdadero", IsCorrect: *dto.CorrectBoolean, Position: 1},
+			{Text: "Falso", IsCorrect: !*dto.CorrectBoolean, Position: 2},
+		}
+		return question, nil
+	case models.QuestionShortText:
+		accepted := make([]string, 0, len(dto.AcceptedAnswers))
+		for _, answer := range dto.AcceptedAnswers {
+			if normalized := normalizeTextAnswer(answer); normalized != "" {
+				accepted = append(accepted, strings.TrimSpace(answer))
+			}
+		}
+		if len(accepted) == 0 {
+			return nil, errors.New("las preguntas de respuesta corta requieren al menos una respuesta aceptada")
+		}
+		raw, _ := json.Marshal(accepted)
+		question.AcceptedAnswers = string(raw)
+		return question, nil
+	}
+
+	for i, option := range dto.Options {
+		question.Options = append(question.Options, models.QuestionOption{
+			Text:      strings.TrimSpace(option.Text),
+			IsCorrect: option.Correct && questionType != models.QuestionOrdering,
+			Position:  i + 1,
+		})
+	}
+	return question, nil
+}
+
+// findQuizQuestion busca una pregunta de un cuestionario.
+func findQuizQuestion(db *gorm.DB, quizID, questionID uint) (*models.Question, error) {
+	var question models.Question
+	if err := db.Where("id = ? AND quiz_id = ?", questionID, quizID).First(&question).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errQuestionNotFound
+		}
+		log.Printf("Error al buscar pregunta %d: %v", questionID, err)
+		return nil, errors.New("no se pudo obtener la pregunta")
+	}
+	return &question, nil
+}
+
+// replaceQuestion reemplaza el contenido y las opciones de question por los de updated.
+func replaceQuestion(tx *gorm.DB, question, updated *models.Question) error {
+	question.Type = updated.Type
+	question.Prompt = updated.Prompt
+	question.Explanation = updated.Explanation
+	question.Topic = updated.Topic
+	question.Difficulty = updated.Difficulty
+	question.Points = updated.Points
+	question.AcceptedAnswers = updated.AcceptedAnswers
+	if err := tx.Where("question_id = ?", question.ID).Delete(&models.QuestionOption{}).Error; err != nil {
+		return err
+	}
+	question.Options = updated.Options
+	return tx.Save(question).Error
+}
+
+// findEditableQuiz busca un cuestionario gestionable cuyo curso no esté archivado.
+func findEditableQuiz(tx *gorm.DB, actor RequestActor, id uint) (*models.Quiz, error) {
+	quiz, course, err := findManagedQuiz(tx, actor, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status == models.CourseArchived {
+		return nil, errCourseArchived
+	}
+	return quiz, nil
+}
+
+// AddQuestion agrega una pregunta al final del cuestionario.
+func (s *QuizService) AddQuestion(actor RequestActor, quizID uint, dto QuestionDTO) (*models.Question, error) {
+	question, err := buildQuestion(dto)
+	if err != nil {
+		return nil, err
+	}
+	tx := s.DB.Begin()
+	quiz, err := findEditableQuiz(tx, actor, quizID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	var last int
+	if err := tx.Model(&models.Question{}).Where("quiz_id = ?", quizID).
+		Select("COALESCE(MAX(position), 0)").Scan(&last).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al calcular la posición de la pregunta: %v", err)
+		return nil, errors.New("no se pudo crear la pregunta")
+	}
+	question.QuizID = &quizID
+	question.Position = last + 1
+	if err := tx.Create(question).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear pregunta en el cuestionario %d: %v", quizID, err)
+		return nil, errors.New("no se pudo crear la pregunta")
+	}
+	if err := auditQuiz(tx, actor, models.AuditActionQuizUpdated, quiz, nil,
+		map[string]interface{}{"question_added": question.ID, "type": question.Type}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la pregunta nueva del cuestionario %d: %v", quizID, err)
+		return nil, errors.New("no se pudo crear la pregunta")
+	}
+	tx.Commit()
+	return question, nil
+}
+
+// UpdateQuestion reemplaza una pregunta y sus opciones. Los intentos ya entregados conservan
+// su calificación; los que estén en curso se califican con la versión nueva.
+func (s *QuizService) UpdateQuestion(actor RequestActor, quizID, questionID uint, dto QuestionDTO) (*models.Question, error) {
+	updated, err := buildQuestion(dto)
+	if err != nil {
+		return nil, err
+	}
+	tx := s.DB.Begin()
+	quiz, err := findEditableQuiz(tx, actor, quizID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	question, err := findQuizQuestion(tx, quizID, questionID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := replaceQuestion(tx, question, updated); err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar pregunta %d: %v", questionID, err)
+		return nil, errors.New("no se pudo actualizar la pregunta")
+	}
+	if err := auditQuiz(tx, actor, models.AuditActionQuizUpdated, quiz, nil,
+		map[string]interface{}{"question_updated": question.ID, "type": question.Type}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la pregunta %d: %v", questionID, err)
+		return nil, errors.New("no se pudo actualizar la pregunta")
+	}
+	tx.Commit()
+	return question, nil
+}
+
+// DeleteQuestion elimina (borrado lógico) una pregunta. Los intentos que ya la incluían la
+// siguen mostrando y calificando.
+func (s *QuizService) DeleteQuestion(actor RequestActor, quizID, questionID uint) error {
+	tx := s.DB.Begin()
+	quiz, err := findEditableQuiz(tx, actor, quizID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	question, err := findQuizQuestion(tx, quizID, questionID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(question).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar pregunta %d: %v", questionID, err)
+		return errors.New("no se pudo eliminar la pregunta")
+	}
+	if err := auditQuiz(tx, actor, models.AuditActionQuizUpdated, quiz, nil,
+		map[string]interface{}{"question_deleted": question.ID}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar la eliminación de la pregunta %d: %v", questionID, err)
+		return errors.New("no se pudo eliminar la pregunta")
+	}
+	tx.Commit()
+	return nil
+}
+
+// ListQuizAttempts lista los intentos de todos los usuarios en un cuestionario.
+func (s *QuizService) ListQuizAttempts(actor RequestActor, quizID uint) ([]models.QuizAttempt, error) {
+	if _, _, err := findManagedQuiz(s.DB, actor, quizID, false); err != nil {
+		return nil, err
+	}
+	attempts := make([]models.QuizAttempt, 0)
+	if err := s.DB.Where("quiz_id = ?", quizID).Order("id DESC").Find(&attempts).Error; err != nil {
+		log.Printf("Error al listar intentos del cuestionario %d: %v", quizID, err)
+		return nil, errors.New("no se pudieron obtener los intentos")
+	}
+	return attempts, nil
+}
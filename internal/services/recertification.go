@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobTypeRecertify revisa cada día los certificados que están por vencer.
+const JobTypeRecertify = "certificates.recertify"
+
+// certificateReminderDays son los días restantes en los que se recuerda el vencimiento;
+// 0 es el aviso del mismo día (o de una certificación ya vencida).
+var certificateReminderDays = []int{7, 1, 0}
+
+// recertifyBatchSize limita cuántos certificados se leen por consulta.
+const recertifyBatchSize = 200
+
+// recertificationActor es el actor de las reinscripciones que hace el sistema.
+var recertificationActor = RequestActor{Username: "sistema"}
+
+// currentCertificateCondition selecciona los certificados vigentes que vencen: no revocados,
+// con fecha de vencimiento y sin otro más nuevo del mismo usuario y curso (la recertificación
+// deja el anterior como historial).
+const currentCertificateCondition = "certificates.revoked_at IS NULL AND certificates.expires_at IS NOT NULL AND " +
+	"NOT EXISTS (SELECT 1 FROM certificates newer WHERE newer.user_id = certificates.user_id AND " +
+	"newer.course_id = certificates.course_id AND newer.revoked_at IS NULL AND newer.id > certificates.id)"
+
+// CourseCompliance es el estado de una certificación de un empleado.
+type CourseCompliance struct {
+	CourseID      uint                    `json:"course_id"`
+	CourseTitle   string                  `json:"course_title"`
+	CertificateID uint                    `json:"certificate_id"`
+	ExpiresAt     time.Time               `json:"expires_at"`
+	DaysLeft      int                     `json:"days_left"` // Negativo si ya venció
+	Status        models.ComplianceStatus `json:"status"`
+}
+
+// UserCompliance es el estado de cumplimiento de un empleado: el peor de sus
+// certificaciones. Status queda vacío si no tiene ninguna certificación que venza.
+type UserCompliance struct {
+	UserID  uint                    `json:"user_id"`
+	Status  models.ComplianceStatus `json:"status,omitempty"`
+	Courses []CourseCompliance      `json:"courses"`
+}
+
+// certificationEventPayload son los datos de los eventos de vencimiento y recertificación.
+type certificationEventPayload struct {
+	UserID        uint      `json:"user_id"`
+	CourseID      uint      `json:"course_id"`
+	CourseTitle   string    `json:"course_title"`
+	CertificateID uint      `json:"certificate_id"`
+	EnrollmentID  uint      `json:"enrollment_id"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	DaysLeft      int       `json:"days_left"`
+	Expired       bool      `json:"expired"`
+}
+
+// daysUntil cuenta los días de calendario que faltan hasta t; es negativo si ya pasó.
+func daysUntil(t, now time.Time) int {
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	to := t.In(now.Location())
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, now.Location())
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// complianceStatusAt calcula el estado de una certificación que vence en expiresAt; entra
+// en "por vencer" cuando empieza la anticipación de la recertificación del curso.
+func complianceStatusAt(expiresAt time.Time, leadDays int, now time.Time) models.ComplianceStatus {
+	switch {
+	case !expiresAt.After(now):
+		return models.ComplianceExpired
+	case !expiresAt.AddDate(0, 0, -leadDays).After(now):
+		return models.ComplianceExpiringSoon
+	default:
+		return models.ComplianceCompliant
+	}
+}
+
+// complianceRank ordena los estados de mejor a peor.
+func complianceRank(status models.ComplianceStatus) int {
+	switch status {
+	case models.ComplianceExpired:
+		return 3
+	case models.ComplianceExpiringSoon:
+		return 2
+	case models.ComplianceCompliant:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// currentCertificatesQuery arma la consulta de los certificados vigentes que vencen, con
+// su curso (que no debe estar eliminado).
+func currentCertificatesQuery(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.Certificate{}).
+		Joins("JOIN courses ON courses.id = certificates.course_id AND courses.deleted_at IS NULL").
+		Where(currentCertificateCondition)
+}
+
+// complianceUserCondition devuelve la condición SQL sobre users.id que filtra a los usuarios
+// con el estado de cumplimiento indicado, junto con sus argumentos.
+func complianceUserCondition(db *gorm.DB, status models.ComplianceStatus, now time.Time) (string, []interface{}) {
+	userCertificates := func() *gorm.DB {
+		return currentCertificatesQuery(db.Session(&gorm.Session{NewDB: true})).Select("1").
+			Where("certificates.user_id = users.id")
+	}
+	expired := userCertificates().Where("certificates.expires_at <= ?", now)
+	expiring := userCertificates().Where("certificates.expires_at <= DATE_ADD(?, INTERVAL courses.recertify_lead_days DAY)", now)
+	switch status {
+	case models.ComplianceExpired:
+		return "EXISTS (?)", []interface{}{expired}
+	case models.ComplianceExpiringSoon:
+		return "NOT EXISTS (?) AND EXISTS (?)", []interface{}{expired, expiring}
+	default:
+		return "EXISTS (?) AND NOT EXISTS (?)", []interface{}{userCertificates(), expiring}
+	}
+}
+
+// GetUserCompliance devuelve el estado de cumplimiento de un usuario y el de cada una de
+// sus certificaciones con vencimiento.
+func (s *CertificateService) GetUserCompliance(userID uint) (*UserCompliance, error) {
+	var count int64
+	if err := s.DB.Model(&models.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		log.Printf("Error al buscar el usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudo obtener el estado de cumplimiento")
+	}
+	if count == 0 {
+		return nil, errors.New("usuario no encontrado")
+	}
+
+	var rows []struct {
+		ID                uint
+		CourseID          uint
+		CourseTitle       string
+		ExpiresAt         time.Time
+		RecertifyLeadDays int
+	}
+	if err := currentCertificatesQuery(s.DB).
+		Select("certificates.id, certificates.course_id, courses.title AS course_title, certificates.expires_at, courses.recertify_lead_days").
+		Where("certificates.user_id = ?", userID).Order("certificates.expires_at").Scan(&rows).Error; err != nil {
+		log.Printf("Error al obtener las certificaciones del usuario %d: %v", userID, err)
+		return nil, errors.New("no se pudo obtener el estado de cumplimiento")
+	}
+
+	now := time.Now()
+	compliance := &UserCompliance{UserID: userID, Courses: make([]CourseCompliance, 0, len(rows))}
+	for _, row := range rows {
+		status := complianceStatusAt(row.ExpiresAt, row.RecertifyLeadDays, now)
+		compliance.Courses = append(compliance.Courses, CourseCompliance{
+			CourseID:      row.CourseID,
+			CourseTitle:   row.CourseTitle,
+			CertificateID: row.ID,
+			ExpiresAt:     row.ExpiresAt,
+			DaysLeft:      daysUntil(row.ExpiresAt, now),
+			Status:        status,
+		})
+		if complianceRank(status) > complianceRank(compliance.Status) {
+			compliance.Status = status
+		}
+	}
+	return compliance, nil
+}
+
+// ProcessExpirations revisa los certificados cuya recertificación ya empezó: vuelve a
+// inscribir al usuario en el curso (una vez por certificado) y le recuerda el vencimiento a
+// 7 días, 1 día y el mismo día. Solo se consideran los cursos publicados que siguen teniendo
+// vigencia y las inscripciones no canceladas.
+func (s *CertificateService) ProcessExpirations(ctx context.Context, now time.Time) error {
+	db := s.DB.WithContext(ctx)
+	renewed, reminded, failed := 0, 0, 0
+	var lastID uint
+	for ctx.Err() == nil {
+		var ids []uint
+		if err := currentCertificatesQuery(db).
+			Joins("JOIN enrollments ON enrollments.id = certificates.enrollment_id").
+			Where("courses.status = ? AND courses.validity_days > 0 AND enrollments.status <> ?", models.CoursePublished, models.EnrollmentCancelled).
+			Where("certificates.expires_at <= DATE_ADD(?, INTERVAL courses.recertify_lead_days DAY)", now).
+			Where("certificates.reminder_days IS NULL OR certificates.reminder_days > 0").
+			Where("certificates.id > ?", lastID).
+			Order("certificates.id").Limit(recertifyBatchSize).Pluck("certificates.id", &ids).Error; err != nil {
+			return fmt.Errorf("no se pudieron buscar los certificados por vencer: %w", err)
+		}
+		for _, id := range ids {
+			lastID = id
+			action, err := s.processExpiration(db, id, now)
+			switch {
+			case err != nil:
+				log.Printf("Error al procesar el vencimiento del certificado %d: %v", id, err)
+				failed++
+			case action == models.DomainEventRecertificationDue:
+				renewed++
+			case action == models.DomainEventCertificateExpiring:
+				reminded++
+			}
+		}
+		if len(ids) < recertifyBatchSize {
+			break
+		}
+	}
+	if renewed > 0 || reminded > 0 || failed > 0 {
+		log.Printf("Recertificación: %d reinscripción(es), %d recordatorio(s), %d error(es).", renewed, reminded, failed)
+	}
+	return ctx.Err()
+}
+
+// processExpiration reinscribe o avisa al usuario de un certificado por vencer. Devuelve el
+// tipo de evento registrado, o "" si no había nada que hacer.
+func (s *CertificateService) processExpiration(db *gorm.DB, certificateID uint, now time.Time) (string, error) {
+	tx := db.Begin()
+	var certificate models.Certificate
+	if err := tx.Preload("Course").First(&certificate, certificateID).Error; err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	if certificate.ExpiresAt == nil || certificate.Course == nil || certificate.EnrollmentID == nil {
+		tx.Rollback()
+		return "", nil
+	}
+	var enrollment models.Enrollment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&enrollment, *certificate.EnrollmentID).Error; err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	daysLeft := daysUntil(*certificate.ExpiresAt, now)
+	payload := certificationEventPayload{
+		UserID:        certificate.UserID,
+		CourseID:      certificate.Course.ID,
+		CourseTitle:   certificate.Course.Title,
+		CertificateID: certificate.ID,
+		EnrollmentID:  enrollment.ID,
+		ExpiresAt:     *certificate.ExpiresAt,
+		DaysLeft:      daysLeft,
+		Expired:       !certificate.ExpiresAt.After(now),
+	}
+
+	var eventType string
+	if enrollment.Status == models.EnrollmentCompleted && enrollment.Cycle == certificate.Cycle {
+		// Primera pasada del período de recertificación: se abre una nueva vuelta del curso
+		// con vencimiento en la fecha en que caduca el certificado.
+		if err := startRecertification(tx, &enrollment, &certificate, now); err != nil {
+			tx.Rollback()
+			return "", err
+		}
+		eventType = models.DomainEventRecertificationDue
+	} else {
+		threshold := -1
+		for _, days := range certificateReminderDays {
+			if days >= daysLeft && (certificate.ReminderDays == nil || days < *certificate.ReminderDays) {
+				threshold = days
+			}
+		}
+		if threshold < 0 {
+			tx.Rollback()
+			return "", nil
+		}
+		if err := tx.Model(&certificate).Update("reminder_days", threshold).Error; err != nil {
+			tx.Rollback()
+			return "", err
+		}
+		eventType = models.DomainEventCertificateExpiring
+	}
+
+	aggregateType, aggregateID := models.AggregateCertificate, certificate.ID
+	if eventType == models.DomainEventRecertificationDue {
+		aggregateType, aggregateID = models.AggregateEnrollment, enrollment.ID
+	}
+	if err := recordDomainEvent(tx, recertificationActor, eventType, aggregateType, aggregateID, payload); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	return eventType, tx.Commit().Error
+}
+
+// startRecertification vuelve a inscribir al usuario en el curso del certificado: la
+// inscripción pasa a la siguiente vuelta y el avance de las lecciones vuelve a cero (el
+// tiempo dedicado se conserva). El certificado anterior sigue válido hasta su vencimiento.
+func startRecertification(tx *gorm.DB, enrollment *models.Enrollment, certificate *models.Certificate, now time.Time) error {
+	before := map[string]interface{}{"status": enrollment.Status, "cycle": enrollment.Cycle}
+	if err := tx.Model(enrollment).Updates(map[string]interface{}{
+		"status":       models.EnrollmentAssigned,
+		"cycle":        enrollment.Cycle + 1,
+		"due_at":       *certificate.ExpiresAt,
+		"assigned_at":  now,
+		"started_at":   nil,
+		"completed_at": nil,
+	}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.LessonProgress{}).
+		Where("user_id = ? AND course_id = ?", enrollment.UserID, enrollment.CourseID).
+		Updates(map[string]interface{}{
+			"status":          models.LessonNotStarted,
+			"percent":         0,
+			"resume_position": "",
+			"last_event_at":   nil,
+			"completed_at":    nil,
+		}).Error; err != nil {
+		return err
+	}
+	// Los datos de los SCO también empiezan de cero en la nueva vuelta.
+	if err := tx.Where("enrollment_id = ?", enrollment.ID).Delete(&models.ScormRuntime{}).Error; err != nil {
+		return err
+	}
+	// Los recordatorios se cuentan desde aquí: solo los umbrales menores que la anticipación.
+	if err := tx.Model(certificate).Update("reminder_days", certificate.Course.RecertifyLeadDays).Error; err != nil {
+		return err
+	}
+	return recordAudit(tx, recertificationActor, auditRecord{
+		Action:     models.AuditActionCourseAssigned,
+		TargetType: models.AuditTargetEnrollment,
+		TargetID:   enrollment.ID,
+		Before:     before,
+		After: map[string]interface{}{
+			"status":         models.EnrollmentAssigned,
+			"cycle":          enrollment.Cycle + 1,
+			"due_at":         certificate.ExpiresAt,
+			"certificate_id": certificate.ID,
+		},
+	})
+}
+
+// CertificationNotificationSubscriber avisa al empleado de las recertificaciones que se le
+// asignan y de los vencimientos próximos de sus certificaciones.
+type CertificationNotificationSubscriber struct {
+	Notifications *NotificationService
+}
+
+// Name implementa events.Subscriber.
+func (CertificationNotificationSubscriber) Name() string { return "certification_notifications" }
+
+// Handles implementa events.Subscriber.
+func (CertificationNotificationSubscriber) Handles(eventType string) bool {
+	return eventType == models.DomainEventRecertificationDue || eventType == models.DomainEventCertificateExpiring
+}
+
+// Handle implementa events.Subscriber.
+func (h CertificationNotificationSubscriber) Handle(tx *gorm.DB, event *models.DomainEvent) error {
+	var payload certificationEventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return fmt.Errorf("evento %s mal formado: %w", event.EventID, err)
+	}
+	in := NotificationInput{
+		Type: models.NotificationCertification,
+		Link: fmt.Sprintf("/cursos/%d", payload.CourseID),
+		Data: map[string]interface{}{
+			"course_id":      payload.CourseID,
+			"certificate_id": payload.CertificateID,
+			"expires_at":     payload.ExpiresAt,
+			"days_left":      payload.DaysLeft,
+		},
+	}
+	expiration := formatSpanishDate(payload.ExpiresAt)
+	switch event.Type {
+	case models.DomainEventRecertificationDue:
+		in.Title = fmt.Sprintf("Tienes que renovar la certificación de %s", payload.CourseTitle)
+		if payload.Expired {
+			in.Body = fmt.Sprintf("Tu certificación venció el %s. Vuelve a completar el curso para renovarla.", expiration)
+		} else {
+			in.Body = fmt.Sprintf("Tu certificación vence el %s. Vuelve a completar el curso antes de esa fecha para renovarla.", expiration)
+		}
+	case models.DomainEventCertificateExpiring:
+		switch {
+		case payload.Expired:
+			in.Title = fmt.Sprintf("Tu certificación de %s venció", payload.CourseTitle)
+		case payload.DaysLeft == 0:
+			in.Title = fmt.Sprintf("Tu certificación de %s vence hoy", payload.CourseTitle)
+		case payload.DaysLeft == 1:
+			in.Title = fmt.Sprintf("Tu certificación de %s vence mañana", payload.CourseTitle)
+		default:
+			in.Title = fmt.Sprintf("Tu certificación de %s vence en %d días", payload.CourseTitle, payload.DaysLeft)
+		}
+		in.Body = "Completa el curso para renovarla."
+	default:
+		return nil
+	}
+	_, err := h.Notifications.Notify(tx, payload.UserID, in)
+	return err
+}
+
+// Committed implementa events.CommitObserver: despierta las conexiones abiertas del usuario.
+func (h CertificationNotificationSubscriber) Committed(event *models.DomainEvent) {
+	var payload certificationEventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err == nil {
+		h.Notifications.Hub.Publish(payload.UserID)
+	}
+}
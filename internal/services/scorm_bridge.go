@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+)
+
+// ScormContentSecurityPolicy se envía con cada archivo de un paquete. sandbox sin
+// allow-same-origin da a cada documento del SCO un origen opaco: aunque se sirva desde el
+// dominio de la API, el HTML y el JavaScript que sube un instructor no pueden leer el JWT ni
+// el almacenamiento de la aplicación, ni llamar a la API con las credenciales del usuario.
+const ScormContentSecurityPolicy = "sandbox allow-scripts allow-forms"
+
+// scormBridgeScript define la API de ejecución dentro del SCO y la conecta con la
+// aplicación por postMessage (ver el comentario de scorm_bridge.js).
+//
+//go:embed scorm_bridge.js
+var scormBridgeScript []byte
+
+// maxScormPageBytes limita las páginas HTML que se cargan en memoria para insertar el puente.
+const maxScormPageBytes = 8 << 20
+
+var (
+	scormHeadTag = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+	scormHTMLTag = regexp.MustCompile(`(?i)<html(\s[^>]*)?>`)
+)
+
+// isScormPage indica si un archivo del paquete es una página en la que hay que insertar el
+// puente.
+func isScormPage(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// injectScormBridge inserta el puente al principio de la página, antes que cualquier script
+// del SCO, para que window.API exista cuando el SCO la busque.
+func injectScormBridge(page []byte) []byte {
+	script := make([]byte, 0, len(scormBridgeScript)+20)
+	script = append(script, "<script>"...)
+	script = append(script, scormBridgeScript...)
+	script = append(script, "</script>"...)
+
+	at := 0
+	if loc := scormHeadTag.FindIndex(page); loc != nil {
+		at = loc[1]
+	} else if loc := scormHTMLTag.FindIndex(page); loc != nil {
+		at = loc[1]
+	}
+	out := make([]byte, 0, len(page)+len(script))
+	out = append(out, page[:at]...)
+	out = append(out, script...)
+	return append(out, page[at:]...)
+}
+
+// readScormPage lee una página del paquete e inserta el puente.
+func readScormPage(rc io.ReadCloser) ([]byte, error) {
+	defer rc.Close()
+	page, err := io.ReadAll(io.LimitReader(rc, maxScormPageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(page) > maxScormPageBytes {
+		return nil, errors.New("la página supera el tamaño máximo")
+	}
+	return injectScormBridge(page), nil
+}
+
+// scormLaunchFragment codifica la versión y los valores iniciales para el puente de la
+// página de inicio. Viajan en el fragmento, que el navegador no envía al servidor.
+func scormLaunchFragment(version models.ScormVersion, values map[string]string) (string, error) {
+	payload, err := json.Marshal(struct {
+		Version models.ScormVersion `json:"version"`
+		Values  map[string]string   `json:"values"`
+	}{version, values})
+	if err != nil {
+		return "", fmt.Errorf("no se pudieron codificar los valores SCORM: %w", err)
+	}
+	return "scorm=" + base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+// newScormPageReader devuelve la página ya modificada como io.ReadCloser.
+func newScormPageReader(page []byte) io.ReadCloser {
+	return io.NopCloser(bytes.NewReader(page))
+}
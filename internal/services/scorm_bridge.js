@@ -0,0 +1,205 @@
+/*
+ * Puente de la API de ejecución SCORM de Yamerito.
+ *
+ * El contenido de los paquetes se sirve con "Content-Security-Policy: sandbox allow-scripts
+ * allow-forms": cada documento del SCO tiene un origen opaco y no puede leer el JWT, el
+ * almacenamiento de la aplicación ni la ventana que lo contiene. Este script se inserta al
+ * principio de cada página HTML del paquete y define window.API (SCORM 1.2) y
+ * window.API_1484_11 (SCORM 2004) en la propia ventana del SCO; los cambios viajan a la
+ * aplicación con postMessage y es la aplicación la que llama a la API con su sesión.
+ *
+ * Mensajes (todos con source: "yamerito-scorm"):
+ *   SCO -> aplicación (window.top): {type: "init-request"}, {type: "initialize"},
+ *     {type: "commit", values: {...}, finish: false|true}
+ *   aplicación -> SCO: {type: "init", version, values} y, tras guardar, {type: "committed", values}
+ *
+ * La página de inicio recibe los valores iniciales en el fragmento de launch_url
+ * (#scorm=<base64url de {"version","values"}>), de modo que GetValue funciona de inmediato;
+ * las demás páginas del paquete los piden con "init-request".
+ */
+(function () {
+  "use strict";
+  if (window.API || window.API_1484_11) {
+    return;
+  }
+  var SOURCE = "yamerito-scorm";
+  var version = "";
+  var values = {};
+  var pending = {};
+  var state = 0; // 0: sin iniciar, 1: iniciado, 2: terminado
+  var lastError = "0";
+
+  var errors12 = {
+    "0": "No error", "101": "General exception", "201": "Invalid argument error",
+    "301": "Not initialized", "401": "Not implemented error", "403": "Element is read only"
+  };
+  var errors2004 = {
+    "0": "No error", "101": "General Exception", "103": "Already Initialized",
+    "104": "Content Instance Terminated", "112": "Termination Before Initialization",
+    "113": "Termination After Termination", "122": "Retrieve Data Before Initialization",
+    "123": "Retrieve Data After Termination", "132": "Store Data Before Initialization",
+    "133": "Store Data After Termination", "142": "Commit Before Initialization",
+    "143": "Commit After Termination", "403": "Data Model Element Value Not Initialized"
+  };
+
+  function post(message) {
+    message.source = SOURCE;
+    try {
+      window.top.postMessage(message, "*");
+    } catch (e) {
+      // Sin ventana contenedora no hay dónde guardar los datos.
+    }
+  }
+
+  function load(payload) {
+    if (!payload || typeof payload !== "object") {
+      return;
+    }
+    if (typeof payload.version === "string") {
+      version = payload.version;
+    }
+    if (payload.values && typeof payload.values === "object") {
+      values = {};
+      for (var key in payload.values) {
+        if (Object.prototype.hasOwnProperty.call(payload.values, key)) {
+          values[key] = String(payload.values[key]);
+        }
+      }
+      for (var name in pending) {
+        if (Object.prototype.hasOwnProperty.call(pending, name)) {
+          values[name] = pending[name];
+        }
+      }
+    }
+  }
+
+  function readFragment() {
+    var match = /[#&]scorm=([A-Za-z0-9_-]+)/.exec(window.location.hash);
+    if (!match) {
+      return false;
+    }
+    try {
+      var encoded = match[1].replace(/-/g, "+").replace(/_/g, "/");
+      while (encoded.length % 4) {
+        encoded += "=";
+      }
+      load(JSON.parse(decodeURIComponent(escape(window.atob(encoded)))));
+      return true;
+    } catch (e) {
+      return false;
+    }
+  }
+
+  window.addEventListener("message", function (event) {
+    var data = event.data;
+    if (event.source !== window.top || !data || data.source !== SOURCE) {
+      return;
+    }
+    if (data.type === "init" || data.type === "committed") {
+      load(data);
+    }
+  });
+
+  function is12() {
+    return version === "1.2";
+  }
+
+  function fail(code12, code2004) {
+    lastError = is12() ? code12 : code2004;
+    return "false";
+  }
+
+  function initialize() {
+    if (state === 1) {
+      return fail("101", "103");
+    }
+    if (state === 2) {
+      return fail("101", "104");
+    }
+    state = 1;
+    lastError = "0";
+    post({ type: "initialize" });
+    return "true";
+  }
+
+  function getValue(element) {
+    if (state !== 1) {
+      lastError = is12() ? "301" : state === 0 ? "122" : "123";
+      return "";
+    }
+    element = String(element);
+    if (!Object.prototype.hasOwnProperty.call(values, element)) {
+      lastError = is12() ? "0" : "403";
+      return "";
+    }
+    lastError = "0";
+    return values[element];
+  }
+
+  function setValue(element, value) {
+    if (state !== 1) {
+      return fail("301", state === 0 ? "132" : "133");
+    }
+    element = String(element);
+    value = String(value);
+    values[element] = value;
+    pending[element] = value;
+    lastError = "0";
+    return "true";
+  }
+
+  function flush(finish) {
+    var batch = pending;
+    pending = {};
+    post({ type: "commit", values: batch, finish: finish });
+  }
+
+  function commit() {
+    if (state !== 1) {
+      return fail("301", state === 0 ? "142" : "143");
+    }
+    flush(false);
+    lastError = "0";
+    return "true";
+  }
+
+  function terminate() {
+    if (state !== 1) {
+      return fail("301", state === 0 ? "112" : "113");
+    }
+    flush(true);
+    state = 2;
+    lastError = "0";
+    return "true";
+  }
+
+  function errorString(code) {
+    var table = is12() ? errors12 : errors2004;
+    return table[String(code)] || "";
+  }
+
+  window.API = {
+    LMSInitialize: initialize,
+    LMSFinish: terminate,
+    LMSGetValue: getValue,
+    LMSSetValue: setValue,
+    LMSCommit: commit,
+    LMSGetLastError: function () { return lastError; },
+    LMSGetErrorString: errorString,
+    LMSGetDiagnostic: function () { return ""; }
+  };
+  window.API_1484_11 = {
+    Initialize: initialize,
+    Terminate: terminate,
+    GetValue: getValue,
+    SetValue: setValue,
+    Commit: commit,
+    GetLastError: function () { return lastError; },
+    GetErrorString: errorString,
+    GetDiagnostic: function () { return ""; }
+  };
+
+  if (!readFragment()) {
+    post({ type: "init-request" });
+  }
+})();
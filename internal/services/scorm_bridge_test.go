@@ -0,0 +1,88 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+)
+
+func TestInjectScormBridge(t *testing.T) {
+	script := "<script>" + string(scormBridgeScript) + "</script>"
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"después de head", `<!DOCTYPE html><html><head><script src="sco.js"></script></head></html>`,
+			`<!DOCTYPE html><html><head>` + script + `<script src="sco.js"></script></head></html>`},
+		{"head con atributos y mayúsculas", `<HTML><HEAD lang="es"><title>x</title></HEAD></HTML>`,
+			`<HTML><HEAD lang="es">` + script + `<title>x</title></HEAD></HTML>`},
+		{"no confunde header con head", `<html><body><header>x</header></body></html>`,
+			`<html>` + script + `<body><header>x</header></body></html>`},
+		{"sin head ni html", `<p>hola</p>`, script + `<p>hola</p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(injectScormBridge([]byte(tt.page))); got != tt.want {
+				t.Errorf("injectScormBridge(%q) =\n%s\nse esperaba\n%s", tt.page, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsScormPage(t *testing.T) {
+	for contentType, want := range map[string]bool{
+		"text/html; charset=utf-8": true,
+		"TEXT/HTML":                true,
+		"application/xhtml+xml":    true,
+		"application/javascript":   false,
+		"image/png":                false,
+		"":                         false,
+	} {
+		if got := isScormPage(contentType); got != want {
+			t.Errorf("isScormPage(%q) = %v, se esperaba %v", contentType, got, want)
+		}
+	}
+}
+
+func TestReadScormPageLimit(t *testing.T) {
+	page, err := readScormPage(io.NopCloser(strings.NewReader("<html></html>")))
+	if err != nil || !strings.Contains(string(page), "window.API_1484_11") {
+		t.Fatalf("readScormPage = %q, %v", page, err)
+	}
+	big := io.NopCloser(strings.NewReader(strings.Repeat("a", maxScormPageBytes+1)))
+	if _, err := readScormPage(big); err == nil {
+		t.Error("se esperaba un error para una página demasiado grande")
+	}
+}
+
+func TestScormLaunchURL(t *testing.T) {
+	pkg := &models.ScormPackage{Token: "abc", LaunchPath: "sco/index.html?lang=es", Version: models.Scorm2004}
+	values := map[string]string{"cmi.learner_name": "Peña, Ana", "cmi.location": "p&2#3"}
+	got, err := scormLaunchURL(pkg, values)
+	if err != nil {
+		t.Fatalf("scormLaunchURL: %v", err)
+	}
+	prefix := ScormContentPath + "/abc/sco/index.html?lang=es#scorm="
+	if !strings.HasPrefix(got, prefix) {
+		t.Fatalf("scormLaunchURL = %q, se esperaba el prefijo %q", got, prefix)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(got, prefix))
+	if err != nil {
+		t.Fatalf("el fragmento no es base64url: %v", err)
+	}
+	var payload struct {
+		Version models.ScormVersion `json:"version"`
+		Values  map[string]string   `json:"values"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		t.Fatalf("el fragmento no es JSON: %v", err)
+	}
+	if payload.Version != models.Scorm2004 || payload.Values["cmi.learner_name"] != "Peña, Ana" || payload.Values["cmi.location"] != "p&2#3" {
+		t.Errorf("fragmento = %+v", payload)
+	}
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+)
+
+// Límites de un paquete SCORM al descomprimirlo. El tamaño declarado en el zip no es
+// confiable, así que también se controla lo que realmente se lee.
+const (
+	maxScormFiles             = 10000
+	maxScormUncompressedBytes = 2 << 30
+	maxScormManifestBytes     = 5 << 20
+	scormManifestName         = "imsmanifest.xml"
+)
+
+// scormManifest es lo que se usa de imsmanifest.xml. Los elementos y atributos se
+// reconocen por su nombre local, sin importar el espacio de nombres (adlcp, imscp...).
+type scormManifest struct {
+	Identifier    string     `xml:"identifier,attr"`
+	Attrs         []xml.Attr `xml:",any,attr"`
+	SchemaVersion string     `xml:"metadata>schemaversion"`
+	Organizations struct {
+		Default       string              `xml:"default,attr"`
+		Organizations []scormOrganization `xml:"organization"`
+	} `xml:"organizations"`
+	Resources struct {
+		Base      string          `xml:"base,attr"`
+		Resources []scormResource `xml:"resource"`
+	} `xml:"resources"`
+}
+
+type scormOrganization struct {
+	Identifier string      `xml:"identifier,attr"`
+	Title      string      `xml:"title"`
+	Items      []scormItem `xml:"item"`
+}
+
+type scormItem struct {
+	Identifier    string      `xml:"identifier,attr"`
+	IdentifierRef string      `xml:"identifierref,attr"`
+	Parameters    string      `xml:"parameters,attr"`
+	Title         string      `xml:"title"`
+	MasteryScore  string      `xml:"masteryscore"`
+	DataFromLMS   string      `xml:"datafromlms"` // SCORM 1.2
+	DataFromLMS04 string      `xml:"dataFromLMS"` // SCORM 2004
+	Items         []scormItem `xml:"item"`
+}
+
+type scormResource struct {
+	Identifier string `xml:"identifier,attr"`
+	Href       string `xml:"href,attr"`
+	Base       string `xml:"base,attr"`
+	Files      []struct {
+		Href string `xml:"href,attr"`
+	} `xml:"file"`
+	Dependencies []struct {
+		IdentifierRef string `xml:"identifierref,attr"`
+	} `xml:"dependency"`
+}
+
+// scormLaunch es el SCO que abre la lección según el manifiesto.
+type scormLaunch struct {
+	Version      models.ScormVersion
+	Identifier   string
+	Title        string
+	LaunchPath   string
+	LaunchData   string
+	MasteryScore *float64
+}
+
+// safeScormPath normaliza la ruta de una entrada del zip o de un href del manifiesto y
+// rechaza las que podrían salir del directorio del paquete (zip-slip): absolutas, con
+// unidad de Windows, con barras invertidas o con segmentos "..".
+func safeScormPath(name string) (string, error) {
+	if name == "" || !utf8.ValidString(name) || strings.ContainsAny(name, "\\\x00") ||
+		strings.HasPrefix(name, "/") || (len(name) >= 2 && name[1] == ':') {
+		return "", fmt.Errorf("el paquete SCORM contiene una ruta no permitida: %q", name)
+	}
+	for _, part := range strings.Split(strings.TrimSuffix(name, "/"), "/") {
+		if part == ".." {
+			return "", fmt.Errorf("el paquete SCORM contiene una ruta no permitida: %q", name)
+		}
+	}
+	clean := path.Clean(name)
+	if clean == "." {
+		return "", fmt.Errorf("el paquete SCORM contiene una ruta no permitida: %q", name)
+	}
+	return clean, nil
+}
+
+// scormArchiveFiles recorre el zip y devuelve sus archivos (sin directorios) por ruta
+// normalizada. Rechaza rutas inseguras, enlaces simbólicos, rutas repetidas y paquetes que
+// superen los límites de archivos o de tamaño declarado.
+func scormArchiveFiles(archive *zip.Reader) (map[string]*zip.File, error) {
+	files := make(map[string]*zip.File, len(archive.File))
+	var total uint64
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		if !file.Mode().IsRegular() {
+			return nil, fmt.Errorf("el paquete SCORM contiene un archivo que no es regular: %q", file.Name)
+		}
+		name, err := safeScormPath(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("el paquete SCORM contiene una ruta repetida: %q", name)
+		}
+		files[name] = file
+		total += file.UncompressedSize64
+		if len(files) > maxScormFiles || total > maxScormUncompressedBytes {
+			return nil, errors.New("el paquete SCORM supera el tamaño o la cantidad de archivos permitidos")
+		}
+	}
+	return files, nil
+}
+
+// resolveScormHref convierte un href del manifiesto (relativo a los xml:base de resources y
+// del recurso) en la ruta de un archivo del paquete, sin la consulta ni el fragmento.
+func resolveScormHref(bases []string, href string) (string, string, error) {
+	query := ""
+	if i := strings.IndexAny(href, "?#"); i >= 0 {
+		href, query = href[:i], href[i:]
+	}
+	if strings.Contains(href, "://") {
+		return "", "", fmt.Errorf("el paquete SCORM tiene un recurso externo: %q", href)
+	}
+	clean, err := safeScormPath(path.Join(append(append([]string{}, bases...), href)...))
+	if err != nil {
+		return "", "", err
+	}
+	return clean, query, nil
+}
+
+// scormVersionOf deduce la versión del paquete de metadata/schemaversion o, si falta, del
+// espacio de nombres adlcp declarado en el manifiesto.
+func scormVersionOf(manifest *scormManifest) (models.ScormVersion, error) {
+	version := strings.TrimSpace(manifest.SchemaVersion)
+	switch {
+	case version == "1.2":
+		return models.Scorm12, nil
+	case strings.Contains(version, "2004") || strings.Contains(version, "CAM 1.3"):
+		return models.Scorm2004, nil
+	case version != "":
+		return "", fmt.Errorf("versión de SCORM no soportada: %s", version)
+	}
+	for _, attr := range manifest.Attrs {
+		switch {
+		case strings.Contains(attr.Value, "adlcp_rootv1p2"):
+			return models.Scorm12, nil
+		case strings.Contains(attr.Value, "adlcp_v1p3"):
+			return models.Scorm2004, nil
+		}
+	}
+	return "", errors.New("el manifiesto no indica la versión de SCORM")
+}
+
+// firstLaunchItem busca, en orden del documento, el primer ítem que referencia un recurso.
+func firstLaunchItem(items []scormItem) *scormItem {
+	for i := range items {
+		if items[i].IdentifierRef != "" {
+			return &items[i]
+		}
+		if item := firstLaunchItem(items[i].Items); item != nil {
+			return item
+		}
+	}
+	return nil
+}
+
+// parseScormManifest lee imsmanifest.xml, comprueba que todos los archivos declarados por
+// los recursos existan en el paquete y devuelve el SCO a abrir: el primer ítem de la
+// organización predeterminada. Los paquetes con varios SCO se abren por el primero; la
+// secuenciación entre SCO queda a cargo del propio contenido.
+func parseScormManifest(files map[string]*zip.File) (*scormLaunch, error) {
+	file, ok := files[scormManifestName]
+	if !ok {
+		return nil, errors.New("el paquete SCORM no tiene imsmanifest.xml en la raíz")
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("el paquete SCORM no se puede leer: %w", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxScormManifestBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("el paquete SCORM no se puede leer: %w", err)
+	}
+	if len(data) > maxScormManifestBytes {
+		return nil, errors.New("el manifiesto del paquete SCORM es demasiado grande")
+	}
+	var manifest scormManifest
+	if err := xml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("el manifiesto del paquete SCORM no es válido: %v", err)
+	}
+	version, err := scormVersionOf(&manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make(map[string]*scormResource, len(manifest.Resources.Resources))
+	for i := range manifest.Resources.Resources {
+		resource := &manifest.Resources.Resources[i]
+		if resource.Identifier == "" {
+			return nil, errors.New("el manifiesto tiene un recurso sin identificador")
+		}
+		resources[resource.Identifier] = resource
+	}
+	for _, resource := range resources {
+		bases := []string{manifest.Resources.Base, resource.Base}
+		for _, declared := range resource.Files {
+			name, _, err := resolveScormHref(bases, declared.Href)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := files[name]; !ok {
+				return nil, fmt.Errorf("el paquete SCORM no contiene el archivo %s declarado en el manifiesto", name)
+			}
+		}
+		for _, dependency := range resource.Dependencies {
+			if _, ok := resources[dependency.IdentifierRef]; !ok {
+				return nil, fmt.Errorf("el manifiesto tiene una dependencia a un recurso inexistente: %s", dependency.IdentifierRef)
+			}
+		}
+	}
+
+	organizations := manifest.Organizations.Organizations
+	if len(organizations) == 0 {
+		return nil, errors.New("el manifiesto no tiene organizaciones")
+	}
+	organization := &organizations[0]
+	for i := range organizations {
+		if organizations[i].Identifier == manifest.Organizations.Default {
+			organization = &organizations[i]
+			break
+		}
+	}
+	item := firstLaunchItem(organization.Items)
+	if item == nil {
+		return nil, errors.New("el manifiesto no tiene ningún ítem que abrir")
+	}
+	resource, ok := resources[item.IdentifierRef]
+	if !ok {
+		return nil, fmt.Errorf("el manifiesto tiene un ítem que referencia un recurso inexistente: %s", item.IdentifierRef)
+	}
+	if resource.Href == "" {
+		return nil, fmt.Errorf("el recurso %s del manifiesto no indica qué archivo abrir", resource.Identifier)
+	}
+	launchPath, query, err := resolveScormHref([]string{manifest.Resources.Base, resource.Base}, resource.Href)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := files[launchPath]; !ok {
+		return nil, fmt.Errorf("el paquete SCORM no contiene el archivo %s declarado en el manifiesto", launchPath)
+	}
+	if parameters := strings.TrimSpace(item.Parameters); parameters != "" {
+		// Los parámetros del ítem se agregan a la consulta del href (IMS CP, sección 2.3.3).
+		parameters = strings.TrimLeft(parameters, "?&")
+		if strings.HasPrefix(query, "?") {
+			query += "&" + parameters
+		} else if !strings.HasPrefix(parameters, "#") {
+			query = "?" + parameters + query
+		} else {
+			query += parameters
+		}
+	}
+
+	launch := &scormLaunch{
+		Version:    version,
+		Identifier: manifest.Identifier,
+		Title:      strings.TrimSpace(organization.Title),
+		LaunchPath: launchPath + query,
+		LaunchData: strings.TrimSpace(item.DataFromLMS + item.DataFromLMS04),
+	}
+	if launch.Title == "" {
+		launch.Title = strings.TrimSpace(item.Title)
+	}
+	if version == models.Scorm12 && strings.TrimSpace(item.MasteryScore) != "" {
+		score, err := strconv.ParseFloat(strings.TrimSpace(item.MasteryScore), 64)
+		if err != nil || score < 0 || score > 100 {
+			return nil, fmt.Errorf("el manifiesto tiene una nota de aprobación inválida: %s", item.MasteryScore)
+		}
+		launch.MasteryScore = &score
+	}
+	return launch, nil
+}
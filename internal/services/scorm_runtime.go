@@ -0,0 +1,392 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+)
+
+// Límites de longitud del modelo de datos (SPM) de cada versión.
+const (
+	scorm12SuspendDataMax   = 4096
+	scorm2004SuspendDataMax = 64000
+	scormLocationMax        = 1000
+	scormGenericValueMax    = 4096
+	// scormMaxDataEntries limita los elementos sin columna propia (interacciones, objetivos...).
+	scormMaxDataEntries = 2000
+)
+
+var (
+	// CMITimespan de SCORM 1.2: HHHH:MM:SS.SS
+	scorm12TimespanPattern = regexp.MustCompile(`^(\d{2,4}):(\d{2}):(\d{2})(\.\d{1,2})?$`)
+	// timeinterval (ISO 8601) de SCORM 2004: P[yY][mM][dD][T[hH][nM][s[.s]S]]
+	scorm2004DurationPattern = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d{1,2})?)S)?)?$`)
+	// scormIndexedPattern reconoce las colecciones: cmi.interactions.3.id, cmi.objectives.0.score.raw...
+	scormIndexedPattern = regexp.MustCompile(`^(cmi\.(?:objectives|interactions|comments_from_learner))\.(\d+)\.`)
+)
+
+// Vocabularios de los elementos de estado.
+var (
+	scorm12LessonStatuses       = []string{"passed", "completed", "failed", "incomplete", "browsed"}
+	scorm12Exits                = []string{"time-out", "suspend", "logout", ""}
+	scorm2004CompletionStatuses = []string{"completed", "incomplete", "not attempted", "unknown"}
+	scorm2004SuccessStatuses    = []string{"passed", "failed", "unknown"}
+	scorm2004Exits              = []string{"time-out", "suspend", "logout", "normal", ""}
+)
+
+// Prefijos de los elementos que se guardan tal cual en ScormRuntime.Data.
+var (
+	scorm12GenericPrefixes   = []string{"cmi.objectives.", "cmi.interactions.", "cmi.student_preference.", "cmi.comments"}
+	scorm2004GenericPrefixes = []string{"cmi.objectives.", "cmi.interactions.", "cmi.learner_preference.", "cmi.comments_from_learner.", "adl.nav.request"}
+)
+
+func inVocabulary(value string, vocabulary []string) bool {
+	for _, allowed := range vocabulary {
+		if value == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func errScormReadOnly(element string) error {
+	return fmt.Errorf("elemento SCORM de solo lectura: %s", element)
+}
+
+func errScormValue(element string) error {
+	return fmt.Errorf("valor SCORM inválido para %s", element)
+}
+
+// parseScormDecimal interpreta un número del modelo de datos; "" borra el valor (nil).
+func parseScormDecimal(element, value string, min, max float64) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) || number < min || number > max {
+		return nil, errScormValue(element)
+	}
+	return &number, nil
+}
+
+// parseScormDuration convierte session_time a segundos según la versión.
+func parseScormDuration(version models.ScormVersion, value string) (float64, bool) {
+	if version == models.Scorm12 {
+		match := scorm12TimespanPattern.FindStringSubmatch(value)
+		if match == nil {
+			return 0, false
+		}
+		hours, _ := strconv.Atoi(match[1])
+		minutes, _ := strconv.Atoi(match[2])
+		seconds, _ := strconv.ParseFloat(match[3]+match[4], 64)
+		if minutes > 59 || seconds >= 60 {
+			return 0, false
+		}
+		return float64(hours*3600+minutes*60) + seconds, true
+	}
+	match := scorm2004DurationPattern.FindStringSubmatch(value)
+	if match == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, false
+	}
+	// Años y meses no tienen una duración fija: se toman de 365 y 30 días.
+	units := []float64{365 * 86400, 30 * 86400, 86400, 3600, 60, 1}
+	total := 0.0
+	for i, unit := range units {
+		if match[i+1] != "" {
+			amount, _ := strconv.ParseFloat(match[i+1], 64)
+			total += amount * unit
+		}
+	}
+	return total, true
+}
+
+// formatScormDuration es la inversa de parseScormDuration, para cmi.total_time.
+func formatScormDuration(version models.ScormVersion, seconds float64) string {
+	whole := int(seconds)
+	hours, minutes := whole/3600, whole%3600/60
+	rest := seconds - float64(hours*3600+minutes*60)
+	if version == models.Scorm12 {
+		if hours > 9999 {
+			hours = 9999
+		}
+		return fmt.Sprintf("%04d:%02d:%05.2f", hours, minutes, rest)
+	}
+	return fmt.Sprintf("PT%dH%dM%.2fS", hours, minutes, rest)
+}
+
+func formatScormDecimal(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+// scormEntry es cmi.entry al empezar una sesión: "ab-initio" la primera vez, "resume" si la
+// anterior terminó suspendida y "" en los demás casos.
+func scormEntry(runtime *models.ScormRuntime) string {
+	switch {
+	case runtime.Sessions == 0:
+		return "ab-initio"
+	case runtime.Exit == "suspend":
+		return "resume"
+	default:
+		return ""
+	}
+}
+
+// scormCollectionCounts calcula los _count de las colecciones guardadas en Data.
+func scormCollectionCounts(data map[string]string) map[string]int {
+	counts := make(map[string]int)
+	for key := range data {
+		match := scormIndexedPattern.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+		index, err := strconv.Atoi(match[2])
+		if err == nil && index+1 > counts[match[1]] {
+			counts[match[1]] = index + 1
+		}
+	}
+	return counts
+}
+
+// scormRuntimeValues arma los valores con los que el adaptador del frontend inicializa la
+// API del SCO (LMSInitialize / Initialize). Los elementos de solo escritura no se incluyen.
+func scormRuntimeValues(pkg *models.ScormPackage, runtime *models.ScormRuntime, learnerID uint, learnerName string) map[string]string {
+	values := make(map[string]string, len(runtime.Data)+20)
+	for key, value := range runtime.Data {
+		values[key] = value
+	}
+	for collection, count := range scormCollectionCounts(runtime.Data) {
+		values[collection+"._count"] = strconv.Itoa(count)
+	}
+	id := strconv.FormatUint(uint64(learnerID), 10)
+	total := formatScormDuration(pkg.Version, runtime.TotalTimeSeconds)
+	if pkg.Version == models.Scorm12 {
+		values["cmi.core.student_id"] = id
+		values["cmi.core.student_name"] = learnerName
+		values["cmi.core.lesson_location"] = runtime.Location
+		values["cmi.core.credit"] = "credit"
+		values["cmi.core.lesson_status"] = runtime.LessonStatus
+		values["cmi.core.entry"] = scormEntry(runtime)
+		values["cmi.core.score.raw"] = formatScormDecimal(runtime.ScoreRaw)
+		values["cmi.core.score.min"] = formatScormDecimal(runtime.ScoreMin)
+		values["cmi.core.score.max"] = formatScormDecimal(runtime.ScoreMax)
+		values["cmi.core.total_time"] = total
+		values["cmi.core.lesson_mode"] = "normal"
+		values["cmi.suspend_data"] = runtime.SuspendData
+		values["cmi.launch_data"] = pkg.LaunchData
+		values["cmi.student_data.mastery_score"] = formatScormDecimal(pkg.MasteryScore)
+		return values
+	}
+	values["cmi.learner_id"] = id
+	values["cmi.learner_name"] = learnerName
+	values["cmi.location"] = runtime.Location
+	values["cmi.credit"] = "credit"
+	values["cmi.mode"] = "normal"
+	values["cmi.completion_status"] = runtime.CompletionStatus
+	values["cmi.success_status"] = runtime.SuccessStatus
+	values["cmi.entry"] = scormEntry(runtime)
+	values["cmi.score.raw"] = formatScormDecimal(runtime.ScoreRaw)
+	values["cmi.score.min"] = formatScormDecimal(runtime.ScoreMin)
+	values["cmi.score.max"] = formatScormDecimal(runtime.ScoreMax)
+	values["cmi.score.scaled"] = formatScormDecimal(runtime.ScoreScaled)
+	values["cmi.progress_measure"] = formatScormDecimal(runtime.ProgressMeasure)
+	values["cmi.total_time"] = total
+	values["cmi.suspend_data"] = runtime.SuspendData
+	values["cmi.launch_data"] = pkg.LaunchData
+	return values
+}
+
+// applyScormValue valida un SetValue del SCO y lo aplica sobre el modelo de datos. Devuelve
+// los segundos de session_time, si el elemento es ese.
+func applyScormValue(version models.ScormVersion, runtime *models.ScormRuntime, element, value string) (float64, error) {
+	if version == models.Scorm12 {
+		return applyScorm12Value(runtime, element, value)
+	}
+	return applyScorm2004Value(runtime, element, value)
+}
+
+func applyScorm12Value(runtime *models.ScormRuntime, element, value string) (float64, error) {
+	var err error
+	switch element {
+	case "cmi.core.student_id", "cmi.core.student_name", "cmi.core.credit", "cmi.core.entry",
+		"cmi.core.total_time", "cmi.core.lesson_mode", "cmi.launch_data", "cmi.comments_from_lms",
+		"cmi.student_data.mastery_score", "cmi.student_data.max_time_allowed", "cmi.student_data.time_limit_action":
+		return 0, errScormReadOnly(element)
+	case "cmi.core.lesson_location":
+		if len(value) > 255 {
+			return 0, errScormValue(element)
+		}
+		runtime.Location = value
+	case "cmi.core.lesson_status":
+		if !inVocabulary(value, scorm12LessonStatuses) {
+			return 0, errScormValue(element)
+		}
+		runtime.LessonStatus = value
+	case "cmi.core.exit":
+		if !inVocabulary(value, scorm12Exits) {
+			return 0, errScormValue(element)
+		}
+		runtime.Exit = value
+	case "cmi.core.session_time":
+		seconds, ok := parseScormDuration(models.Scorm12, value)
+		if !ok {
+			return 0, errScormValue(element)
+		}
+		return seconds, nil
+	case "cmi.core.score.raw":
+		runtime.ScoreRaw, err = parseScormDecimal(element, value, 0, 100)
+	case "cmi.core.score.min":
+		runtime.ScoreMin, err = parseScormDecimal(element, value, 0, 100)
+	case "cmi.core.score.max":
+		runtime.ScoreMax, err = parseScormDecimal(element, value, 0, 100)
+	case "cmi.suspend_data":
+		if len(value) > scorm12SuspendDataMax {
+			return 0, errScormValue(element)
+		}
+		runtime.SuspendData = value
+	default:
+		return 0, setScormGenericValue(runtime, scorm12GenericPrefixes, element, value)
+	}
+	return 0, err
+}
+
+func applyScorm2004Value(runtime *models.ScormRuntime, element, value string) (float64, error) {
+	var err error
+	switch element {
+	case "cmi.learner_id", "cmi.learner_name", "cmi.credit", "cmi.entry", "cmi.mode", "cmi.total_time",
+		"cmi.launch_data", "cmi.completion_threshold", "cmi.scaled_passing_score", "cmi.max_time_allowed",
+		"cmi.time_limit_action":
+		return 0, errScormReadOnly(element)
+	case "cmi.location":
+		if len(value) > scormLocationMax {
+			return 0, errScormValue(element)
+		}
+		runtime.Location = value
+	case "cmi.completion_status":
+		if !inVocabulary(value, scorm2004CompletionStatuses) {
+			return 0, errScormValue(element)
+		}
+		runtime.CompletionStatus = value
+	case "cmi.success_status":
+		if !inVocabulary(value, scorm2004SuccessStatuses) {
+			return 0, errScormValue(element)
+		}
+		runtime.SuccessStatus = value
+	case "cmi.exit":
+		if !inVocabulary(value, scorm2004Exits) {
+			return 0, errScormValue(element)
+		}
+		runtime.Exit = value
+	case "cmi.session_time":
+		seconds, ok := parseScormDuration(models.Scorm2004, value)
+		if !ok {
+			return 0, errScormValue(element)
+		}
+		return seconds, nil
+	case "cmi.score.raw":
+		runtime.ScoreRaw, err = parseScormDecimal(element, value, -math.MaxFloat64, math.MaxFloat64)
+	case "cmi.score.min":
+		runtime.ScoreMin, err = parseScormDecimal(element, value, -math.MaxFloat64, math.MaxFloat64)
+	case "cmi.score.max":
+		runtime.ScoreMax, err = parseScormDecimal(element, value, -math.MaxFloat64, math.MaxFloat64)
+	case "cmi.score.scaled":
+		runtime.ScoreScaled, err = parseScormDecimal(element, value, -1, 1)
+	case "cmi.progress_measure":
+		runtime.ProgressMeasure, err = parseScormDecimal(element, value, 0, 1)
+	case "cmi.suspend_data":
+		if len(value) > scorm2004SuspendDataMax {
+			return 0, errScormValue(element)
+		}
+		runtime.SuspendData = value
+	default:
+		return 0, setScormGenericValue(runtime, scorm2004GenericPrefixes, element, value)
+	}
+	return 0, err
+}
+
+// setScormGenericValue guarda en Data un elemento sin reglas propias. Los "_count" y
+// "_children" los calcula el LMS y no se pueden escribir.
+func setScormGenericValue(runtime *models.ScormRuntime, prefixes []string, element, value string) error {
+	if !hasAnyPrefix(element, prefixes) {
+		return fmt.Errorf("elemento SCORM desconocido: %s", element)
+	}
+	if strings.HasSuffix(element, "._count") || strings.HasSuffix(element, "._children") {
+		return errScormReadOnly(element)
+	}
+	if len(element) > 255 || len(value) > scormGenericValueMax {
+		return errScormValue(element)
+	}
+	if runtime.Data == nil {
+		runtime.Data = make(map[string]string)
+	}
+	if _, ok := runtime.Data[element]; !ok && len(runtime.Data) >= scormMaxDataEntries {
+		return errScormValue(element)
+	}
+	runtime.Data[element] = value
+	return nil
+}
+
+// applyScormValues aplica un lote de SetValue en orden de elemento, para que el resultado no
+// dependa del orden del mapa. Devuelve la suma de los session_time recibidos.
+func applyScormValues(version models.ScormVersion, runtime *models.ScormRuntime, values map[string]string) (float64, error) {
+	elements := make([]string, 0, len(values))
+	for element := range values {
+		elements = append(elements, element)
+	}
+	sort.Strings(elements)
+	session := 0.0
+	for _, element := range elements {
+		seconds, err := applyScormValue(version, runtime, element, values[element])
+		if err != nil {
+			return 0, err
+		}
+		session += seconds
+	}
+	return session, nil
+}
+
+// scormLessonResult traduce el estado del SCO al avance de la lección: si está completada
+// y, con SCORM 2004, el porcentaje según cmi.progress_measure.
+func scormLessonResult(version models.ScormVersion, runtime *models.ScormRuntime) (completed bool, percent *int) {
+	if version == models.Scorm12 {
+		return runtime.LessonStatus == "completed" || runtime.LessonStatus == "passed", nil
+	}
+	if runtime.ProgressMeasure != nil {
+		value := int(math.Round(*runtime.ProgressMeasure * 100))
+		percent = &value
+	}
+	return runtime.CompletionStatus == "completed" || runtime.SuccessStatus == "passed", percent
+}
+
+// finishScorm12Status aplica las reglas de SCORM 1.2 al cerrar una sesión: con nota de
+// aprobación y cmi.core.score.raw, el LMS decide passed/failed; si el SCO no informó
+// ningún estado, la lección queda completada.
+func finishScorm12Status(pkg *models.ScormPackage, runtime *models.ScormRuntime) {
+	if pkg.MasteryScore != nil && runtime.ScoreRaw != nil &&
+		inVocabulary(runtime.LessonStatus, []string{"completed", "passed", "failed"}) {
+		if *runtime.ScoreRaw >= *pkg.MasteryScore {
+			runtime.LessonStatus = "passed"
+		} else {
+			runtime.LessonStatus = "failed"
+		}
+	}
+	if runtime.LessonStatus == "not attempted" {
+		runtime.LessonStatus = "completed"
+	}
+}
@@ -0,0 +1,486 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/Unikyri/yamerito-mvp/internal/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScormContentPath es la ruta pública desde la que se sirven los archivos de los paquetes:
+// <ScormContentPath>/<token>/<archivo>. El SCO se abre en un iframe y sus archivos se piden
+// con rutas relativas, sin el JWT, así que el token aleatorio del paquete es la credencial.
+// Se sirven aislados con ScormContentSecurityPolicy.
+const ScormContentPath = "/api/v1/scorm-content"
+
+// ScormCommitDTO define el cuerpo de PUT /api/v1/me/courses/:id/lessons/:lessonId/scorm.
+// Values son los SetValue acumulados por el adaptador desde el último Commit; Finish indica
+// que el SCO cerró la sesión (LMSFinish / Terminate).
+type ScormCommitDTO struct {
+	Values map[string]string `json:"values"`
+	Finish bool              `json:"finish"`
+}
+
+// ScormLaunch es lo que necesita el frontend para abrir un SCO: la URL del contenido, la
+// versión de la API y los valores iniciales del modelo de datos. El SCO corre en un origen
+// opaco y no puede ver la ventana de la aplicación: la API la define el puente que se
+// inserta en sus páginas, que recibe la versión y los valores en el fragmento de LaunchURL.
+// El frontend escucha los mensajes del puente (source "yamerito-scorm"), comprueba que
+// vienen de su iframe, guarda los "commit" con PUT y responde con los valores devueltos;
+// a un "init-request" responde con {type: "init", version, values}.
+type ScormLaunch struct {
+	LessonID  uint                `json:"lesson_id"`
+	Version   models.ScormVersion `json:"version"`
+	Title     string              `json:"title,omitempty"`
+	LaunchURL string              `json:"launch_url"`
+	Values    map[string]string   `json:"values"`
+}
+
+// ScormCommitResult es el resultado de un Commit: el modelo de datos actualizado y el avance
+// del curso, que cambia si el SCO completó la lección.
+type ScormCommitResult struct {
+	Values   map[string]string `json:"values"`
+	Progress *CourseProgress   `json:"progress"`
+}
+
+// ScormServiceInterface define la importación de paquetes SCORM y el backend de su API de
+// ejecución.
+type ScormServiceInterface interface {
+	ImportPackage(ctx context.Context, actor RequestActor, courseID, moduleID, lessonID uint, r io.ReaderAt, size int64) (*models.ScormPackage, error)
+	GetPackage(actor RequestActor, courseID, moduleID, lessonID uint) (*models.ScormPackage, error)
+
+	Launch(actor RequestActor, courseID, lessonID uint) (*ScormLaunch, error)
+	Commit(actor RequestActor, courseID, lessonID uint, dto ScormCommitDTO) (*ScormCommitResult, error)
+	OpenContent(ctx context.Context, token, name string) (io.ReadCloser, *storage.ObjectInfo, error)
+}
+
+// ScormService implementa ScormServiceInterface.
+type ScormService struct {
+	DB    *gorm.DB
+	Store storage.BlobStore
+}
+
+// NewScormService crea una nueva instancia de ScormService.
+func NewScormService(db *gorm.DB, store storage.BlobStore) *ScormService {
+	return &ScormService{DB: db, Store: store}
+}
+
+var (
+	errScormPackageNotFound = errors.New("la lección no tiene paquete SCORM")
+	errNotScormLesson       = errors.New("la lección no es de tipo SCORM")
+	errScormContentNotFound = errors.New("archivo SCORM no encontrado")
+)
+
+func scormBlobKey(token, name string) string {
+	return "scorm/" + token + "/" + name
+}
+
+// findScormLesson busca una lección de tipo SCORM de un módulo del curso.
+func findScormLesson(db *gorm.DB, courseID, moduleID, lessonID uint) (*models.Lesson, error) {
+	if _, err := findCourseModule(db, courseID, moduleID); err != nil {
+		return nil, err
+	}
+	lesson, err := findModuleLesson(db, moduleID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+	if lesson.Type != models.LessonScorm {
+		return nil, errNotScormLesson
+	}
+	return lesson, nil
+}
+
+// deleteScormFiles borra los archivos de un paquete. Los errores solo se registran: un
+// archivo huérfano no afecta a nadie, el token ya no está en la base de datos.
+func (s *ScormService) deleteScormFiles(token string, files []string) {
+	for _, name := range files {
+		if err := s.Store.Delete(context.Background(), scormBlobKey(token, name)); err != nil {
+			log.Printf("Error al eliminar el archivo SCORM '%s': %v", name, err)
+		}
+	}
+}
+
+// storeScormFile copia un archivo del zip al almacenamiento. El tamaño declarado en el zip
+// no es confiable: si el contenido real no coincide, el paquete se rechaza.
+func (s *ScormService) storeScormFile(ctx context.Context, token, name string, file *zip.File) error {
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("el paquete SCORM no se puede leer: %w", err)
+	}
+	defer rc.Close()
+	size := int64(file.UncompressedSize64)
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	counter := &countingReader{r: io.LimitReader(rc, size)}
+	if err := s.Store.Put(ctx, scormBlobKey(token, name), counter, size, contentType); err != nil {
+		if errors.Is(err, zip.ErrChecksum) || errors.Is(err, zip.ErrFormat) {
+			return fmt.Errorf("el paquete SCORM no se puede leer: %w", err)
+		}
+		return err
+	}
+	if counter.n != size {
+		return fmt.Errorf("el paquete SCORM no se puede leer: el tamaño de %s no coincide", name)
+	}
+	if n, _ := rc.Read(make([]byte, 1)); n > 0 {
+		return fmt.Errorf("el paquete SCORM no se puede leer: el tamaño de %s no coincide", name)
+	}
+	return nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ImportPackage valida un paquete SCORM (zip con imsmanifest.xml), descomprime sus archivos
+// en el almacenamiento y lo asigna a la lección, reemplazando el anterior. Los archivos se
+// suben antes de abrir la transacción; si algo falla, se borran.
+func (s *ScormService) ImportPackage(ctx context.Context, actor RequestActor, courseID, moduleID, lessonID uint, r io.ReaderAt, size int64) (*models.ScormPackage, error) {
+	course, err := findManagedCourse(s.DB, actor, courseID, false)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status == models.CourseArchived {
+		return nil, errCourseArchived
+	}
+	if _, err := findScormLesson(s.DB, courseID, moduleID, lessonID); err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.New("el paquete SCORM no es un archivo zip válido")
+	}
+	files, err := scormArchiveFiles(archive)
+	if err != nil {
+		return nil, err
+	}
+	launch, err := parseScormManifest(files)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(launch.LaunchPath) > 500 {
+		return nil, errors.New("el paquete SCORM tiene una ruta de inicio demasiado larga")
+	}
+
+	token, err := randomHex(24)
+	if err != nil {
+		log.Printf("Error al generar el token del paquete SCORM: %v", err)
+		return nil, errors.New("no se pudo importar el paquete SCORM")
+	}
+	names := make([]string, 0, len(files))
+	var total int64
+	for name, file := range files {
+		if err := s.storeScormFile(ctx, token, name, file); err != nil {
+			s.deleteScormFiles(token, append(names, name))
+			if strings.HasPrefix(err.Error(), "el paquete SCORM") {
+				return nil, err
+			}
+			log.Printf("Error al guardar el archivo SCORM '%s' de la lección %d: %v", name, lessonID, err)
+			return nil, errors.New("no se pudo importar el paquete SCORM")
+		}
+		names = append(names, name)
+		total += int64(file.UncompressedSize64)
+	}
+
+	pkg := models.ScormPackage{
+		LessonID:     lessonID,
+		Version:      launch.Version,
+		Identifier:   truncateRunes(launch.Identifier, 200),
+		Title:        truncateRunes(launch.Title, 200),
+		Token:        token,
+		LaunchPath:   launch.LaunchPath,
+		LaunchData:   launch.LaunchData,
+		MasteryScore: launch.MasteryScore,
+		Files:        names,
+		FileCount:    len(names),
+		TotalBytes:   total,
+	}
+	if actor.UserID != 0 {
+		uploadedBy := actor.UserID
+		pkg.UploadedByID = &uploadedBy
+	}
+	tx := s.DB.Begin()
+	// Se vuelven a comprobar curso y lección con el curso bloqueado: pudieron cambiar
+	// mientras se subían los archivos.
+	course, err = findEditableCourse(tx, actor, courseID)
+	if err == nil {
+		_, err = findScormLesson(tx, courseID, moduleID, lessonID)
+	}
+	if err != nil {
+		tx.Rollback()
+		s.deleteScormFiles(token, names)
+		return nil, err
+	}
+	fail := func(err error) (*models.ScormPackage, error) {
+		tx.Rollback()
+		s.deleteScormFiles(token, names)
+		log.Printf("Error al guardar el paquete SCORM de la lección %d: %v", lessonID, err)
+		return nil, errors.New("no se pudo importar el paquete SCORM")
+	}
+	var previous models.ScormPackage
+	if err := tx.Where("lesson_id = ?", lessonID).Limit(1).Find(&previous).Error; err != nil {
+		return fail(err)
+	}
+	if previous.ID != 0 {
+		pkg.ID = previous.ID
+		pkg.CreatedAt = previous.CreatedAt
+	}
+	if err := tx.Save(&pkg).Error; err != nil {
+		return fail(err)
+	}
+	if err := touchCourse(tx, actor, course, map[string]interface{}{
+		"scorm_package": lessonID, "module_id": moduleID, "version": pkg.Version, "file_count": pkg.FileCount,
+	}); err != nil {
+		return fail(err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return fail(err)
+	}
+	if previous.ID != 0 {
+		s.deleteScormFiles(previous.Token, previous.Files)
+	}
+	return &pkg, nil
+}
+
+// truncateRunes recorta s a como mucho n caracteres.
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// GetPackage devuelve el paquete SCORM de una lección.
+func (s *ScormService) GetPackage(actor RequestActor, courseID, moduleID, lessonID uint) (*models.ScormPackage, error) {
+	if _, err := findManagedCourse(s.DB, actor, courseID, false); err != nil {
+		return nil, err
+	}
+	if _, err := findScormLesson(s.DB, courseID, moduleID, lessonID); err != nil {
+		return nil, err
+	}
+	var pkg models.ScormPackage
+	if err := s.DB.Where("lesson_id = ?", lessonID).First(&pkg).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errScormPackageNotFound
+		}
+		log.Printf("Error al buscar el paquete SCORM de la lección %d: %v", lessonID, err)
+		return nil, errors.New("no se pudo obtener el paquete SCORM")
+	}
+	return &pkg, nil
+}
+
+// findLaunchablePackage busca el paquete de una lección SCORM del curso.
+func findLaunchablePackage(db *gorm.DB, courseID, lessonID uint) (*models.ScormPackage, error) {
+	var lessons []models.Lesson
+	if err := db.Joins("JOIN course_modules ON course_modules.id = lessons.module_id AND course_modules.deleted_at IS NULL").
+		Where("lessons.id = ? AND course_modules.course_id = ?", lessonID, courseID).Limit(1).Find(&lessons).Error; err != nil {
+		return nil, err
+	}
+	if len(lessons) == 0 {
+		return nil, errLessonNotFound
+	}
+	if lessons[0].Type != models.LessonScorm {
+		return nil, errNotScormLesson
+	}
+	var pkg models.ScormPackage
+	if err := db.Where("lesson_id = ?", lessonID).Limit(1).Find(&pkg).Error; err != nil {
+		return nil, err
+	}
+	if pkg.ID == 0 {
+		return nil, errScormPackageNotFound
+	}
+	return &pkg, nil
+}
+
+// findScormRuntime busca el modelo de datos del SCO para la inscripción o lo inicializa.
+func findScormRuntime(db *gorm.DB, enrollment *models.Enrollment, lessonID uint) (*models.ScormRuntime, error) {
+	runtime := models.ScormRuntime{
+		EnrollmentID:     enrollment.ID,
+		LessonID:         lessonID,
+		UserID:           enrollment.UserID,
+		LessonStatus:     "not attempted",
+		CompletionStatus: "not attempted",
+		SuccessStatus:    "unknown",
+	}
+	if err := db.Where("enrollment_id = ? AND lesson_id = ?", enrollment.ID, lessonID).FirstOrInit(&runtime).Error; err != nil {
+		return nil, err
+	}
+	return &runtime, nil
+}
+
+func isScormClientError(err error) bool {
+	switch err {
+	case errNotEnrolled, errLessonNotFound, errNotScormLesson, errScormPackageNotFound:
+		return true
+	}
+	return false
+}
+
+// scormLaunchURL arma la URL de la página de inicio con los valores iniciales en el fragmento.
+func scormLaunchURL(pkg *models.ScormPackage, values map[string]string) (string, error) {
+	fragment, err := scormLaunchFragment(pkg.Version, values)
+	if err != nil {
+		return "", err
+	}
+	return ScormContentPath + "/" + pkg.Token + "/" + pkg.LaunchPath + "#" + fragment, nil
+}
+
+// Launch prepara la apertura del SCO de una lección para el usuario autenticado.
+func (s *ScormService) Launch(actor RequestActor, courseID, lessonID uint) (*ScormLaunch, error) {
+	enrollment, err := findUserEnrollment(s.DB, actor.UserID, courseID, false)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := findLaunchablePackage(s.DB, courseID, lessonID)
+	if err != nil {
+		if isScormClientError(err) {
+			return nil, err
+		}
+		log.Printf("Error al buscar el paquete SCORM de la lección %d: %v", lessonID, err)
+		return nil, errors.New("no se pudo abrir la lección SCORM")
+	}
+	runtime, err := findScormRuntime(s.DB, enrollment, lessonID)
+	if err != nil {
+		log.Printf("Error al obtener los datos SCORM de la lección %d: %v", lessonID, err)
+		return nil, errors.New("no se pudo abrir la lección SCORM")
+	}
+	name, err := recipientName(s.DB, actor.UserID)
+	if err != nil {
+		log.Printf("Error al obtener el nombre del usuario %d: %v", actor.UserID, err)
+		return nil, errors.New("no se pudo abrir la lección SCORM")
+	}
+	values := scormRuntimeValues(pkg, runtime, actor.UserID, name)
+	launchURL, err := scormLaunchURL(pkg, values)
+	if err != nil {
+		log.Printf("Error al preparar la lección SCORM %d: %v", lessonID, err)
+		return nil, errors.New("no se pudo abrir la lección SCORM")
+	}
+	return &ScormLaunch{
+		LessonID:  lessonID,
+		Version:   pkg.Version,
+		Title:     pkg.Title,
+		LaunchURL: launchURL,
+		Values:    values,
+	}, nil
+}
+
+// Commit guarda los valores que el SCO escribió y traslada su estado al avance de la
+// lección: la lección se completa cuando el SCO la informa completada o aprobada. El
+// tiempo de la sesión (session_time) se suma al total al cerrarla.
+func (s *ScormService) Commit(actor RequestActor, courseID, lessonID uint, dto ScormCommitDTO) (*ScormCommitResult, error) {
+	tx := s.DB.Begin()
+	enrollment, err := findUserEnrollment(tx, actor.UserID, courseID, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	fail := func(err error) (*ScormCommitResult, error) {
+		tx.Rollback()
+		log.Printf("Error al guardar los datos SCORM de la lección %d: %v", lessonID, err)
+		return nil, errors.New("no se pudieron guardar los datos SCORM")
+	}
+	pkg, err := findLaunchablePackage(tx, courseID, lessonID)
+	if err != nil {
+		if isScormClientError(err) {
+			tx.Rollback()
+			return nil, err
+		}
+		return fail(err)
+	}
+	runtime, err := findScormRuntime(tx.Clauses(clause.Locking{Strength: "UPDATE"}), enrollment, lessonID)
+	if err != nil {
+		return fail(err)
+	}
+	session, err := applyScormValues(pkg.Version, runtime, dto.Values)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if dto.Finish {
+		if pkg.Version == models.Scorm12 {
+			finishScorm12Status(pkg, runtime)
+		}
+		runtime.TotalTimeSeconds += session
+		runtime.Sessions++
+	}
+	if err := tx.Save(runtime).Error; err != nil {
+		return fail(err)
+	}
+
+	completed, percent := scormLessonResult(pkg.Version, runtime)
+	timeSpent := int(runtime.TotalTimeSeconds)
+	progress, err := saveLessonProgress(tx, actor, enrollment, lessonID, UpdateLessonProgressDTO{
+		Percent:          percent,
+		TimeSpentSeconds: &timeSpent,
+		Completed:        completed,
+	}, time.Now())
+	if err != nil {
+		return fail(err)
+	}
+	tx.Commit()
+
+	name, err := recipientName(s.DB, actor.UserID)
+	if err != nil {
+		name = actor.Username
+	}
+	return &ScormCommitResult{Values: scormRuntimeValues(pkg, runtime, actor.UserID, name), Progress: progress}, nil
+}
+
+// OpenContent abre un archivo de un paquete por su token. Las rutas se normalizan igual que
+// al importar, así que no se puede salir del paquete. En las páginas HTML se inserta el
+// puente de la API de ejecución.
+func (s *ScormService) OpenContent(ctx context.Context, token, name string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	clean, err := safeScormPath(strings.TrimPrefix(name, "/"))
+	if err != nil || token == "" {
+		return nil, nil, errScormContentNotFound
+	}
+	var count int64
+	if err := s.DB.Model(&models.ScormPackage{}).Where("token = ?", token).Count(&count).Error; err != nil {
+		log.Printf("Error al buscar el paquete SCORM: %v", err)
+		return nil, nil, errors.New("no se pudo obtener el archivo SCORM")
+	}
+	if count == 0 {
+		return nil, nil, errScormContentNotFound
+	}
+	rc, info, err := s.Store.Get(ctx, scormBlobKey(token, clean))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, errScormContentNotFound
+		}
+		log.Printf("Error al abrir el archivo SCORM '%s': %v", clean, err)
+		return nil, nil, errors.New("no se pudo obtener el archivo SCORM")
+	}
+	if !isScormPage(info.ContentType) {
+		return rc, info, nil
+	}
+	page, err := readScormPage(rc)
+	if err != nil {
+		log.Printf("Error al leer la página SCORM '%s': %v", clean, err)
+		return nil, nil, errors.New("no se pudo obtener el archivo SCORM")
+	}
+	pageInfo := *info
+	pageInfo.Size = int64(len(page))
+	return newScormPageReader(page), &pageInfo, nil
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// newUserHistoryEntry construye una versión del historial a partir del estado actual del usuario.
+func newUserHistoryEntry(user *models.User) models.UserHistory {
+	entry := models.UserHistory{
+		UserID:      user.ID,
+		UserVersion: user.Version,
+		Username:    user.Username,
+		Role:        user.Role,
+		Status:      user.Status,
+	}
+	if user.EmployeeDetail.ID != 0 {
+		d := user.EmployeeDetail
+		entry.DetailVersion = d.Version
+		entry.Name = d.Name
+		entry.LastName = d.LastName
+		entry.Email = d.Email
+		entry.PhoneNumber = d.PhoneNumber
+		entry.Position = d.Position
+		entry.DepartmentID = d.DepartmentID
+		entry.TeamID = d.TeamID
+		entry.ManagerID = d.ManagerID
+		entry.HireDate = d.HireDate
+		entry.TerminationDate = d.TerminationDate
+	}
+	return entry
+}
+
+// recordUserHistory cierra la versión vigente del usuario y abre una nueva con su estado
+// actual, dentro de la transacción del cambio. Si los datos historiados no cambiaron
+// (p. ej. solo se cambió la contraseña) no se crea una versión nueva.
+func recordUserHistory(tx *gorm.DB, actor RequestActor, user *models.User, action string, deleted bool) error {
+	entry := newUserHistoryEntry(user)
+	entry.Deleted = deleted
+	entry.Action = action
+	if actor.UserID != 0 {
+		changedBy := actor.UserID
+		entry.ChangedByID = &changedBy
+	}
+
+	var current models.UserHistory
+	err := tx.Where("user_id = ? AND valid_to IS NULL", user.ID).Order("id DESC").Limit(1).Find(&current).Error
+	if err != nil {
+		return fmt.Errorf("no se pudo leer la versión vigente del historial: %w", err)
+	}
+	if current.ID != 0 && current.SameContentAs(&entry) {
+		return nil
+	}
+
+	now := time.Now()
+	if current.ID != 0 {
+		if err := tx.Model(&models.UserHistory{}).
+			Where("user_id = ? AND valid_to IS NULL", user.ID).
+			Update("valid_to", now).Error; err != nil {
+			return fmt.Errorf("no se pudo cerrar la versión vigente del historial: %w", err)
+		}
+	}
+
+	entry.ValidFrom = now
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("no se pudo registrar la versión en el historial: %w", err)
+	}
+	return nil
+}
+
+// GetUserHistory devuelve todas las versiones de un usuario, de la más reciente a la más antigua.
+// Funciona también para usuarios eliminados.
+func (s *UserService) GetUserHistory(id uint) ([]models.UserHistory, error) {
+	var entries []models.UserHistory
+	if err := s.DB.Where("user_id = ?", id).Order("valid_from DESC, id DESC").Find(&entries).Error; err != nil {
+		log.Printf("Error al obtener historial del usuario %d: %v", id, err)
+		return nil, errors.New("no se pudo obtener el historial del usuario")
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("usuario no encontrado")
+	}
+	return entries, nil
+}
+
+// GetUserAsOf devuelve la versión del usuario vigente en el instante indicado.
+func (s *UserService) GetUserAsOf(id uint, at time.Time) (*models.UserHistory, error) {
+	var entry models.UserHistory
+	err := s.DB.Where("user_id = ? AND valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)", id, at, at).
+		Order("valid_from DESC, id DESC").
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("el usuario no existía en la fecha indicada")
+		}
+		log.Printf("Error al obtener usuario %d a la fecha %s: %v", id, at.Format(time.RFC3339), err)
+		return nil, errors.New("no se pudo obtener el historial del usuario")
+	}
+	return &entry, nil
+}
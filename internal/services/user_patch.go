@@ -0,0 +1,373 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Unikyri/yamerito-mvp/internal/auth"
+	"github.com/Unikyri/yamerito-mvp/internal/jsonpatch"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// UserPatchDocument es la representación JSON de un usuario sobre la que se aplican
+// los parches de PATCH /api/v1/admin/users/:id. A diferencia de AdminUpdateUserDTO,
+// un campo ausente o null en el documento resultante significa "vacío", por lo que
+// los campos opcionales sí se pueden borrar.
+type UserPatchDocument struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Role     string `json:"role" binding:"required"`
+	// Password es de solo escritura: nunca aparece en el documento original,
+	// pero un parche puede añadirla para cambiar la contraseña.
+	Password        *string                      `json:"password,omitempty" binding:"omitempty,min=8,max=72"`
+	EmployeeDetails *EmployeeDetailPatchDocument `json:"employee_details"`
+	// CustomFields contiene los campos personalizados; quitar una clave elimina su valor.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// EmployeeDetailPatchDocument es la parte de UserPatchDocument que corresponde a EmployeeDetail.
+type EmployeeDetailPatchDocument struct {
+	Name         *string `json:"name,omitempty" binding:"omitempty,max=100"`
+	LastName     *string `json:"last_name,omitempty" binding:"omitempty,max=100"`
+	Email        *string `json:"email,omitempty" binding:"omitempty,email,max=100"`
+	PhoneNumber  *string `json:"phone_number,omitempty" binding:"omitempty,max=20"`
+	Position     *string `json:"position,omitempty" binding:"omitempty,max=100"`
+	DepartmentID *uint   `json:"department_id,omitempty"`
+	TeamID       *uint   `json:"team_id,omitempty"`
+	ManagerID    *uint   `json:"manager_id,omitempty"`
+	// Fechas laborales en formato AAAA-MM-DD.
+	HireDate        *string `json:"hire_date,omitempty"`
+	TerminationDate *string `json:"termination_date,omitempty"`
+}
+
+// Tipos de fallo de un parche; el handler los traduce a 400, 409 y 422 respectivamente.
+const (
+	PatchMalformed     = "malformed"
+	PatchConflict      = "conflict"
+	PatchInvalidResult = "invalid_result"
+)
+
+// PatchError describe por qué no se pudo aplicar un parche a un usuario.
+type PatchError struct {
+	Kind string
+	Err  error
+}
+
+func (e *PatchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// optionalString convierte un string en puntero, usando nil para el valor vacío
+// (que en el documento se representa como miembro ausente).
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func stringValue(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+// newUserPatchDocument construye el documento parcheable a partir del modelo.
+func newUserPatchDocument(user *models.User) UserPatchDocument {
+	doc := UserPatchDocument{
+		Username: user.Username,
+		Role:     string(user.Role),
+	}
+	if user.EmployeeDetail.ID != 0 {
+		d := user.EmployeeDetail
+		doc.EmployeeDetails = &EmployeeDetailPatchDocument{
+			Name:         optionalString(d.Name),
+			LastName:     optionalString(d.LastName),
+			Email:        optionalString(d.Email),
+			PhoneNumber:  optionalString(d.PhoneNumber),
+			Position:     optionalString(d.Position),
+			DepartmentID: d.DepartmentID,
+			TeamID:       d.TeamID,
+			ManagerID:    d.ManagerID,
+		}
+		if d.HireDate != nil {
+			doc.EmployeeDetails.HireDate = optionalString(d.HireDate.Format(lifecycleDateLayout))
+		}
+		if d.TerminationDate != nil {
+			doc.EmployeeDetails.TerminationDate = optionalString(d.TerminationDate.Format(lifecycleDateLayout))
+		}
+	}
+	return doc
+}
+
+// validatePatchedEmail impide vaciar el email de un empleado que lo tiene: la columna es
+// única, así que dos empleados con el email vacío chocarían.
+func validatePatchedEmail(user *models.User, doc UserPatchDocument) error {
+	if user.EmployeeDetail.Email == "" || doc.EmployeeDetails == nil {
+		return nil
+	}
+	if stringValue(doc.EmployeeDetails.Email) == "" {
+		return errors.New("employee_details.email no puede quedar vacío")
+	}
+	return nil
+}
+
+// applyPatchDocument aplica el documento ya parcheado y validado sobre el modelo.
+// Devuelve qué partes cambiaron para que saveUserVersioned solo toque lo necesario.
+func applyPatchDocument(tx *gorm.DB, actor RequestActor, user *models.User, doc UserPatchDocument, role models.Role) (userChanged, detailChanged bool, err error) {
+	if doc.Username != user.Username {
+		user.Username = doc.Username
+		userChanged = true
+	}
+	if role != user.Role {
+		user.Role = role
+		userChanged = true
+	}
+	if doc.Password != nil {
+		hashedPassword, err := auth.HashPassword(*doc.Password, nil)
+		if err != nil {
+			log.Printf("Error al hashear contraseña durante parche por admin: %v", err)
+			return false, false, errors.New("error interno al procesar la contraseña")
+		}
+		user.PasswordHash = hashedPassword
+		userChanged = true
+	}
+
+	if doc.EmployeeDetails != nil {
+		d := &user.EmployeeDetail
+		if d.ID == 0 {
+			*d = models.EmployeeDetail{UserID: user.ID}
+		}
+		fields := []struct {
+			target *string
+			value  string
+		}{
+			{&d.Name, stringValue(doc.EmployeeDetails.Name)},
+			{&d.LastName, stringValue(doc.EmployeeDetails.LastName)},
+			{&d.Email, stringValue(doc.EmployeeDetails.Email)},
+			{&d.PhoneNumber, stringValue(doc.EmployeeDetails.PhoneNumber)},
+			{&d.Position, stringValue(doc.EmployeeDetails.Position)},
+		}
+		for _, f := range fields {
+			if *f.target != f.value {
+				*f.target = f.value
+				detailChanged = true
+			}
+		}
+
+		ids := []struct {
+			target **uint
+			value  *uint
+		}{
+			{&d.DepartmentID, normalizeOptionalID(doc.EmployeeDetails.DepartmentID)},
+			{&d.TeamID, normalizeOptionalID(doc.EmployeeDetails.TeamID)},
+			{&d.ManagerID, normalizeOptionalID(doc.EmployeeDetails.ManagerID)},
+		}
+		orgChanged := false
+		for _, f := range ids {
+			if !models.EqualOptionalID(*f.target, f.value) {
+				*f.target = f.value
+				orgChanged = true
+			}
+		}
+		if orgChanged {
+			if err := validateOrgAssignment(tx, user.ID, d); err != nil {
+				return false, false, err
+			}
+			detailChanged = true
+		}
+
+		hireDate := stringValue(doc.EmployeeDetails.HireDate)
+		terminationDate := stringValue(doc.EmployeeDetails.TerminationDate)
+		hireChanged, terminationChanged, err := applyEmploymentDates(d, &hireDate, &terminationDate)
+		if err != nil {
+			return false, false, err
+		}
+		if hireChanged || terminationChanged {
+			if err := scheduleDateTransitions(tx, actor, user, hireChanged, terminationChanged); err != nil {
+				return false, false, err
+			}
+			detailChanged = true
+		}
+	}
+	return userChanged, detailChanged, nil
+}
+
+// PatchUserByAdmin aplica un JSON Merge Patch (RFC 7396) o un JSON Patch (RFC 6902)
+// sobre la representación del usuario, valida el documento resultante y lo persiste
+// con la misma verificación de versión que UpdateUserByAdmin.
+func (s *UserService) PatchUserByAdmin(actor RequestActor, id uint, ifMatch string, mediaType string, patch []byte) (*UserDetailDTO, error) {
+	if mediaType != jsonpatch.MediaTypeMergePatch && mediaType != jsonpatch.MediaTypeJSONPatch {
+		return nil, &PatchError{Kind: PatchMalformed, Err: fmt.Errorf("tipo de parche no soportado: %s", mediaType)}
+	}
+
+	var user models.User
+	tx := s.DB.Begin()
+	if err := tx.Preload("EmployeeDetail").First(&user, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("usuario no encontrado para actualizar")
+		}
+		log.Printf("Error al buscar usuario %d para aplicar parche: %v", id, err)
+		return nil, errors.New("error al buscar usuario")
+	}
+
+	if current := newUserDetailDTO(&user); !etagMatches(ifMatch, current.ETag()) {
+		tx.Rollback()
+		return nil, &PreconditionFailedError{Current: userDetailDTOWithCustomFields(s.DB, &user)}
+	}
+	before := fullUserAuditSnapshot(tx, &user)
+	previousRole := user.Role
+
+	originalDoc := newUserPatchDocument(&user)
+	customFields, err := customFieldAuditSnapshot(tx, user.ID)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error al leer campos personalizados del usuario %d para aplicar parche: %v", id, err)
+		return nil, errors.New("error al buscar usuario")
+	}
+	originalDoc.CustomFields = customFields
+	original, err := json.Marshal(originalDoc)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New("no se pudo serializar el usuario")
+	}
+
+	var patched []byte
+	if mediaType == jsonpatch.MediaTypeMergePatch {
+		patched, err = jsonpatch.MergePatch(original, patch)
+	} else {
+		patched, err = jsonpatch.Apply(original, patch)
+	}
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, jsonpatch.ErrInvalidPatch) {
+			return nil, &PatchError{Kind: PatchMalformed, Err: err}
+		}
+		return nil, &PatchError{Kind: PatchConflict, Err: err}
+	}
+
+	// El documento resultante se decodifica de forma estricta: cualquier miembro
+	// desconocido (p. ej. "/employee_details/salary") invalida el parche.
+	var doc UserPatchDocument
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		tx.Rollback()
+		return nil, &PatchError{Kind: PatchInvalidResult, Err: fmt.Errorf("documento resultante inválido: %w", err)}
+	}
+	if err := binding.Validator.ValidateStruct(&doc); err != nil {
+		tx.Rollback()
+		return nil, &PatchError{Kind: PatchInvalidResult, Err: fmt.Errorf("documento resultante inválido: %w", err)}
+	}
+	role, err := models.ParseRole(doc.Role)
+	if err != nil {
+		tx.Rollback()
+		return nil, &PatchError{Kind: PatchInvalidResult, Err: err}
+	}
+	if doc.EmployeeDetails == nil && user.EmployeeDetail.ID != 0 {
+		tx.Rollback()
+		return nil, &PatchError{Kind: PatchInvalidResult, Err: errors.New("employee_details no puede eliminarse; use null en los campos individuales")}
+	}
+
+	if err := validatePatchedEmail(&user, doc); err != nil {
+		tx.Rollback()
+		return nil, &PatchError{Kind: PatchInvalidResult, Err: err}
+	}
+
+	userChanged, detailChanged, err := applyPatchDocument(tx, actor, &user, doc, role)
+	if err != nil {
+		tx.Rollback()
+		var assignmentErr *OrgAssignmentError
+		var lifecycleErr *LifecycleError
+		if errors.As(err, &assignmentErr) || errors.As(err, &lifecycleErr) {
+			return nil, &PatchError{Kind: PatchInvalidResult, Err: err}
+		}
+		if err.Error() == "error interno al procesar la contraseña" {
+			return nil, err
+		}
+		return nil, orgValidationFailure("actualizar el usuario", err)
+	}
+
+	// Las claves que desaparecieron del documento se envían como null para eliminarlas.
+	customInput := make(map[string]interface{}, len(doc.CustomFields)+len(originalDoc.CustomFields))
+	for key := range originalDoc.CustomFields {
+		customInput[key] = nil
+	}
+	for key, value := range doc.CustomFields {
+		customInput[key] = value
+	}
+	customChanged, err := applyCustomFieldValues(tx, user.ID, customInput, false, nil)
+	if err != nil {
+		tx.Rollback()
+		var validationErr *CustomFieldValidationError
+		if errors.As(err, &validationErr) {
+			return nil, &PatchError{Kind: PatchInvalidResult, Err: err}
+		}
+		return nil, customFieldFailure("actualizar el usuario", err)
+	}
+	if customChanged {
+		userChanged = true
+	}
+
+	if !userChanged && !detailChanged {
+		tx.Rollback()
+		return userDetailDTOWithCustomFields(s.DB, &user), nil
+	}
+
+	if err := checkUserUniqueness(tx, &user); err != nil {
+		tx.Rollback()
+		if errors.Is(err, errUsernameTaken) || errors.Is(err, errEmployeeEmailTaken) {
+			return nil, err
+		}
+		log.Printf("Error al comprobar la unicidad del usuario %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el usuario")
+	}
+	if err := saveUserVersioned(tx, &user, userChanged, detailChanged); err != nil {
+		tx.Rollback()
+		if errors.Is(err, errVersionConflict) {
+			if current, getErr := s.GetUserByID(id); getErr == nil {
+				return nil, &PreconditionFailedError{Current: current}
+			}
+		}
+		if conflict := uniquenessConflict(err); conflict != nil {
+			return nil, conflict
+		}
+		log.Printf("Error al aplicar parche al usuario %d en DB: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el usuario")
+	}
+
+	if err := recordUserHistory(tx, actor, &user, models.AuditActionUserUpdated, false); err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar historial del usuario parcheado: %v", err)
+		return nil, errors.New("no se pudo actualizar el usuario")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionUserUpdated,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     before,
+		After:      fullUserAuditSnapshot(tx, &user),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar parche del usuario %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el usuario")
+	}
+	if err := recordUserUpdateEvents(tx, actor, &user, previousRole); err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar los eventos del parche del usuario %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el usuario")
+	}
+	tx.Commit()
+
+	return userDetailDTOWithCustomFields(s.DB, &user), nil
+}
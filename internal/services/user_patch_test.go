@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+)
+
+func TestValidatePatchedEmail(t *testing.T) {
+	empty, other := "", "nuevo@example.com"
+	tests := []struct {
+		name    string
+		stored  string
+		details *EmployeeDetailPatchDocument
+		wantErr bool
+	}{
+		{"null sobre un email existente", "ana@example.com", &EmployeeDetailPatchDocument{}, true},
+		{"vacío sobre un email existente", "ana@example.com", &EmployeeDetailPatchDocument{Email: &empty}, true},
+		{"cambio de email", "ana@example.com", &EmployeeDetailPatchDocument{Email: &other}, false},
+		{"sigue sin email", "", &EmployeeDetailPatchDocument{}, false},
+		{"sin datos de empleado", "", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{EmployeeDetail: models.EmployeeDetail{ID: 1, Email: tt.stored}}
+			err := validatePatchedEmail(user, UserPatchDocument{EmployeeDetails: tt.details})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validatePatchedEmail = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckUserUniquenessUsernameTaken(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE username = \\? AND id <> \\?").
+		WithArgs("ana", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	user := &models.User{ID: 7, Username: "ana"}
+	if err := checkUserUniqueness(db, user); !errors.Is(err, errUsernameTaken) {
+		t.Fatalf("checkUserUniqueness = %v, se esperaba %v", err, errUsernameTaken)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckUserUniquenessEmailTaken(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE username = \\? AND id <> \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `employee_details` WHERE email = \\? AND user_id <> \\?").
+		WithArgs("ana@example.com", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	user := &models.User{ID: 7, Username: "ana", EmployeeDetail: models.EmployeeDetail{Email: "ana@example.com"}}
+	if err := checkUserUniqueness(db, user); !errors.Is(err, errEmployeeEmailTaken) {
+		t.Fatalf("checkUserUniqueness = %v, se esperaba %v", err, errEmployeeEmailTaken)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// Una escritura concurrente que gana la carrera a la comprobación previa también se
+// responde como conflicto y no como error interno.
+func TestUniquenessConflict(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{errors.New("Error 1062 (23000): Duplicate entry 'ana' for key 'users.idx_users_username'"), errUsernameTaken},
+		{errors.New("Error 1062 (23000): Duplicate entry 'a@b.c' for key 'employee_details.idx_employee_details_email'"), errEmployeeEmailTaken},
+		{errors.New("Error 1213: Deadlock found"), nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := uniquenessConflict(tt.err); got != tt.want {
+			t.Errorf("uniquenessConflict(%v) = %v, se esperaba %v", tt.err, got, tt.want)
+		}
+	}
+}
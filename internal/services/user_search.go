@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// userSearchContent arma el texto por el que se busca a un usuario. Debe coincidir con la
+// migración que rellena el índice para los usuarios existentes.
+func userSearchContent(user *models.User) string {
+	parts := []string{user.Username}
+	d := user.EmployeeDetail
+	for _, part := range []string{d.Name, d.LastName, d.Email, d.Position} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.ToLower(strings.Join(parts, " "))
+}
+
+// escapeLike protege los comodines de LIKE en un término de búsqueda.
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+// userSearchCondition filtra users por los términos de q: cada término debe aparecer en
+// el documento de búsqueda del usuario.
+func userSearchCondition(query *gorm.DB, q string) *gorm.DB {
+	terms := strings.Fields(strings.ToLower(q))
+	if len(terms) == 0 {
+		return query
+	}
+	query = query.Joins("JOIN user_search_documents ON user_search_documents.user_id = users.id")
+	for _, term := range terms {
+		query = query.Where("user_search_documents.content LIKE ?", "%"+escapeLike(term)+"%")
+	}
+	return query
+}
+
+// SearchIndexSubscriber mantiene el índice de búsqueda de usuarios. No usa la foto del
+// evento sino el estado actual del usuario, así que el resultado no depende del orden en
+// que lleguen los eventos ni de si alguno se repite.
+type SearchIndexSubscriber struct{}
+
+// Name implementa events.Subscriber.
+func (SearchIndexSubscriber) Name() string { return "search_index" }
+
+// Handles implementa events.Subscriber.
+func (SearchIndexSubscriber) Handles(eventType string) bool {
+	switch eventType {
+	case models.DomainEventUserCreated, models.DomainEventUserUpdated, models.DomainEventUserDeleted:
+		return true
+	}
+	return false
+}
+
+// Handle implementa events.Subscriber.
+func (SearchIndexSubscriber) Handle(tx *gorm.DB, event *models.DomainEvent) error {
+	var user models.User
+	err := tx.Preload("EmployeeDetail").First(&user, event.AggregateID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Where("user_id = ?", event.AggregateID).Delete(&models.UserSearchDocument{}).Error
+	}
+	if err != nil {
+		return err
+	}
+	doc := models.UserSearchDocument{UserID: user.ID, Content: userSearchContent(&user)}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"content", "updated_at"}),
+	}).Create(&doc).Error
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/Unikyri/yamerito-mvp/internal/auth"
 	"github.com/Unikyri/yamerito-mvp/internal/models"
@@ -15,11 +17,16 @@ type UserServiceInterface interface {
 	//LoginUser(dto LoginRequestDTO) (string, *models.User, error)
 
 	// Admin User Management
-	CreateUserByAdmin(dto AdminCreateUserDTO) (*UserDetailDTO, error)
-	ListUsers() ([]UserDetailDTO, error) // Devolver DTO para no exponer hash
+	CreateUserByAdmin(actor RequestActor, dto AdminCreateUserDTO) (*UserDetailDTO, error)
+	ListUsers(filter UserListFilter) ([]UserDetailDTO, error) // Devolver DTO para no exponer hash
 	GetUserByID(id uint) (*UserDetailDTO, error)    // Devolver DTO
-	UpdateUserByAdmin(id uint, dto AdminUpdateUserDTO) (*UserDetailDTO, error) // Devolver DTO
-	DeleteUser(id uint) error
+	UpdateUserByAdmin(actor RequestActor, id uint, ifMatch string, dto AdminUpdateUserDTO) (*UserDetailDTO, error) // Devolver DTO; ifMatch es el ETag esperado
+	PatchUserByAdmin(actor RequestActor, id uint, ifMatch string, mediaType string, patch []byte) (*UserDetailDTO, error) // Merge Patch o JSON Patch
+	DeleteUser(actor RequestActor, id uint) error
+
+	// Historial de cambios y vista a una fecha
+	GetUserHistory(id uint) ([]models.UserHistory, error)
+	GetUserAsOf(id uint, at time.Time) (*models.UserHistory, error)
 }
 
 // UserService implementa UserServiceInterface.
@@ -53,6 +60,15 @@ type EmployeeDetailInputDTO struct {
 	Email       *string `json:"email" binding:"omitempty,email,max=100"`
 	PhoneNumber *string `json:"phone_number,omitempty" binding:"omitempty,max=20"`
 	Position    *string `json:"position,omitempty" binding:"omitempty,max=100"`
+	// Estructura organizativa: un valor 0 quita la asignación. Si solo se envía team_id,
+	// el departamento se toma del equipo.
+	DepartmentID *uint `json:"department_id,omitempty"`
+	TeamID       *uint `json:"team_id,omitempty"`
+	ManagerID    *uint `json:"manager_id,omitempty"`
+	// Fechas laborales en formato AAAA-MM-DD; una cadena vacía quita la fecha. Cambiarlas
+	// reprograma la activación el día de ingreso y la baja tras el último día.
+	HireDate        *string `json:"hire_date,omitempty"`
+	TerminationDate *string `json:"termination_date,omitempty"`
 }
 
 // AdminCreateUserDTO define la estructura para que un administrador cree un nuevo usuario.
@@ -60,8 +76,12 @@ type EmployeeDetailInputDTO struct {
 type AdminCreateUserDTO struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Password string `json:"password" binding:"required,min=8,max=72"`
-	Role     string `json:"role" binding:"omitempty,oneof=Admin Employee"` // Default a Employee si está vacío, y validación a PascalCase
+	Role     string `json:"role" binding:"omitempty,oneof=Admin Employee Instructor"` // Default a Employee si está vacío, y validación a PascalCase
+	// Status es invited o active; si se omite, invited cuando la fecha de ingreso es futura.
+	Status string `json:"status,omitempty"`
 	EmployeeDetails *EmployeeDetailInputDTO `json:"employee_details,omitempty"`
+	// CustomFields son los valores de los campos personalizados, indexados por su clave.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 // AdminUpdateUserDTO define la estructura para que un admin actualice un usuario.
@@ -69,8 +89,22 @@ type AdminCreateUserDTO struct {
 type AdminUpdateUserDTO struct {
 	Username *string `json:"username,omitempty" binding:"omitempty,min=3,max=50"` // Puntero para distinguir entre no enviado y vacío
 	Password *string `json:"password,omitempty" binding:"omitempty,min=8,max=72"` // Puntero para cambio opcional
-	Role     *string `json:"role,omitempty" binding:"omitempty,oneof=Admin Employee"` // Puntero, validación a PascalCase
+	Role     *string `json:"role,omitempty" binding:"omitempty,oneof=Admin Employee Instructor"` // Puntero, validación a PascalCase
 	EmployeeDetails *EmployeeDetailInputDTO `json:"employee_details,omitempty"` // Para actualizar detalles del empleado
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"` // Solo se modifican las claves enviadas; null quita el valor
+}
+
+// UserListFilter define los filtros de GET /api/v1/admin/users.
+// CustomFields compara por igualdad el valor de cada campo personalizado (?cf.<clave>=valor).
+// Query busca por nombre de usuario, nombre, apellido, email o cargo (?q=).
+// Compliance filtra por el estado de las certificaciones con vencimiento (?compliance=).
+type UserListFilter struct {
+	Query        string
+	DepartmentID *uint
+	TeamID       *uint
+	Status       *models.UserStatus
+	Compliance   *models.ComplianceStatus
+	CustomFields map[string]string
 }
 
 // UserDetailDTO define la estructura de datos detallada de un usuario para respuestas de API.
@@ -79,7 +113,185 @@ type UserDetailDTO struct {
 	ID       uint                `json:"id"`
 	Username string              `json:"username"`
 	Role     string              `json:"role"`
+	Status   string              `json:"status"`
+	Version  uint                `json:"version"`
 	EmployeeDetails *models.EmployeeDetail `json:"employee_details,omitempty"` // Mostrar detalles del empleado
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// ETag devuelve la etiqueta de entidad (fuerte) que identifica la versión actual del usuario
+// y de sus detalles de empleado. Cambia cada vez que cualquiera de los dos se modifica.
+func (d *UserDetailDTO) ETag() string {
+	var detailVersion uint
+	if d.EmployeeDetails != nil {
+		detailVersion = d.EmployeeDetails.Version
+	}
+	return fmt.Sprintf("\"%d.%d\"", d.Version, detailVersion)
+}
+
+// PreconditionFailedError indica que el ETag enviado en If-Match ya no corresponde
+// a la versión almacenada. Current lleva la representación vigente para que el cliente
+// pueda mostrarla y reintentar.
+type PreconditionFailedError struct {
+	Current *UserDetailDTO
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return "el usuario fue modificado por otra persona; recargue los datos e intente de nuevo"
+}
+
+// errVersionConflict se usa internamente cuando el UPDATE condicionado por versión no afecta filas.
+var errVersionConflict = errors.New("conflicto de versión")
+
+// Conflictos de unicidad al modificar un usuario; los handlers los responden con 409.
+var (
+	errUsernameTaken      = errors.New("el nuevo nombre de usuario ya está en uso por otro usuario")
+	errEmployeeEmailTaken = errors.New("el email ya está en uso por otro empleado")
+)
+
+// checkUserUniqueness comprueba que el nombre de usuario y el email del empleado no los use
+// otro usuario, incluidos los eliminados lógicamente (los índices únicos también los cuentan).
+func checkUserUniqueness(tx *gorm.DB, user *models.User) error {
+	var count int64
+	if err := tx.Unscoped().Model(&models.User{}).Where("username = ? AND id <> ?", user.Username, user.ID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errUsernameTaken
+	}
+	return checkEmployeeEmailAvailable(tx, user.ID, user.EmployeeDetail.Email)
+}
+
+// checkEmployeeEmailAvailable comprueba que ningún otro empleado use email. Un email vacío
+// no se comprueba.
+func checkEmployeeEmailAvailable(tx *gorm.DB, userID uint, email string) error {
+	if email == "" {
+		return nil
+	}
+	var count int64
+	if err := tx.Unscoped().Model(&models.EmployeeDetail{}).Where("email = ? AND user_id <> ?", email, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errEmployeeEmailTaken
+	}
+	return nil
+}
+
+// uniquenessConflict traduce la violación de índice único de una escritura que se adelantó a
+// checkUserUniqueness al error de conflicto correspondiente, o devuelve nil si err no lo es.
+func uniquenessConflict(err error) error {
+	if !isDuplicateKeyError(err) {
+		return nil
+	}
+	if strings.Contains(err.Error(), "email") {
+		return errEmployeeEmailTaken
+	}
+	return errUsernameTaken
+}
+
+// newUserDetailDTO construye el DTO de respuesta a partir del modelo.
+func newUserDetailDTO(user *models.User) *UserDetailDTO {
+	dto := &UserDetailDTO{
+		ID:       user.ID,
+		Username: user.Username,
+		Role:     string(user.Role),
+		Status:   string(user.Status),
+		Version:  user.Version,
+	}
+	if user.EmployeeDetail.ID != 0 {
+		dto.EmployeeDetails = &user.EmployeeDetail
+	}
+	return dto
+}
+
+// userDetailDTOWithCustomFields construye el DTO e incluye todos los campos personalizados del usuario.
+func userDetailDTOWithCustomFields(db *gorm.DB, user *models.User) *UserDetailDTO {
+	dto := newUserDetailDTO(user)
+	if err := attachCustomFields(db, nil, dto); err != nil {
+		log.Printf("Error al cargar campos personalizados del usuario %d: %v", user.ID, err)
+	}
+	return dto
+}
+
+// fullUserAuditSnapshot amplía userAuditSnapshot con los campos personalizados del usuario.
+func fullUserAuditSnapshot(db *gorm.DB, user *models.User) map[string]interface{} {
+	snapshot := userAuditSnapshot(user)
+	customFields, err := customFieldAuditSnapshot(db, user.ID)
+	if err != nil {
+		log.Printf("Error al leer campos personalizados del usuario %d para auditoría: %v", user.ID, err)
+	}
+	if len(customFields) > 0 {
+		snapshot["custom_fields"] = customFields
+	}
+	return snapshot
+}
+
+// customFieldFailure deja pasar los errores de validación de campos personalizados y registra los de BD.
+func customFieldFailure(operation string, err error) error {
+	var validationErr *CustomFieldValidationError
+	if errors.As(err, &validationErr) {
+		return err
+	}
+	log.Printf("Error al guardar campos personalizados (%s): %v", operation, err)
+	return fmt.Errorf("no se pudo %s", operation)
+}
+
+// etagMatches evalúa un encabezado If-Match (RFC 9110) contra el ETag actual.
+// Acepta "*" y listas separadas por comas; los ETags débiles nunca coinciden.
+func etagMatches(ifMatch, current string) bool {
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == current {
+			return true
+		}
+	}
+	return false
+}
+
+// saveUserVersioned persiste los cambios de un usuario y/o de su EmployeeDetail
+// condicionando cada UPDATE a la versión leída. Si otra transacción escribió antes,
+// no se afecta ninguna fila y se devuelve errVersionConflict.
+func saveUserVersioned(tx *gorm.DB, user *models.User, userChanged, detailChanged bool) error {
+	if userChanged {
+		prev := user.Version
+		user.Version = prev + 1
+		result := tx.Model(user).Where("version = ?", prev).
+			Select("*").Omit("id", "created_at", "EmployeeDetail").
+			Updates(user)
+		if result.Error != nil {
+			user.Version = prev
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			user.Version = prev
+			return errVersionConflict
+		}
+	}
+
+	if detailChanged {
+		detail := &user.EmployeeDetail
+		if detail.ID == 0 {
+			detail.UserID = user.ID
+			return tx.Create(detail).Error
+		}
+		prev := detail.Version
+		detail.Version = prev + 1
+		result := tx.Model(detail).Where("version = ?", prev).
+			Select("*").Omit("id", "created_at").
+			Updates(detail)
+		if result.Error != nil {
+			detail.Version = prev
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			detail.Version = prev
+			return errVersionConflict
+		}
+	}
+	return nil
 }
 
 // LoginUser maneja la lógica de inicio de sesión de un usuario.
@@ -122,7 +334,7 @@ type UserDetailDTO struct {
 }*/
 
 // CreateUserByAdmin crea un nuevo usuario con rol y detalles especificados por un administrador.
-func (s *UserService) CreateUserByAdmin(dto AdminCreateUserDTO) (*UserDetailDTO, error) {
+func (s *UserService) CreateUserByAdmin(actor RequestActor, dto AdminCreateUserDTO) (*UserDetailDTO, error) {
 	userRole, err := models.ParseRole(dto.Role)
 	if err != nil {
 		if dto.Role == "" { // Si el rol está vacío en el DTO, asignamos EMPLOYEE por defecto
@@ -162,50 +374,118 @@ func (s *UserService) CreateUserByAdmin(dto AdminCreateUserDTO) (*UserDetailDTO,
 		if dto.EmployeeDetails.Position != nil {
 			empDetail.Position = *dto.EmployeeDetails.Position
 		}
+		empDetail.DepartmentID = normalizeOptionalID(dto.EmployeeDetails.DepartmentID)
+		empDetail.TeamID = normalizeOptionalID(dto.EmployeeDetails.TeamID)
+		empDetail.ManagerID = normalizeOptionalID(dto.EmployeeDetails.ManagerID)
+		if _, _, err := applyEmploymentDates(&empDetail, dto.EmployeeDetails.HireDate, dto.EmployeeDetails.TerminationDate); err != nil {
+			return nil, err
+		}
 		newUser.EmployeeDetail = empDetail // Asignar al campo singular 'EmployeeDetail'
 	}
 
+	var detailForStatus *models.EmployeeDetail
+	if dto.EmployeeDetails != nil {
+		detailForStatus = &newUser.EmployeeDetail
+	}
+	if newUser.Status, err = initialStatus(dto.Status, detailForStatus, time.Now()); err != nil {
+		return nil, err
+	}
+
 	// Usar una transacción para asegurar que User y EmployeeDetail se creen atómicamente
 	tx := s.DB.Begin()
+	if dto.EmployeeDetails != nil {
+		if err := validateOrgAssignment(tx, 0, &newUser.EmployeeDetail); err != nil {
+			tx.Rollback()
+			return nil, orgValidationFailure("crear el usuario", err)
+		}
+	}
 	if err := tx.Create(&newUser).Error; err != nil {
 		tx.Rollback()
 		log.Printf("Error al crear usuario por admin en DB: %v", err)
 		return nil, errors.New("no se pudo crear el usuario")
 	}
-
-	tx.Commit()
-
-	detailDTO := UserDetailDTO{
-		ID:       newUser.ID,
-		Username: newUser.Username,
-		Role:     string(newUser.Role),
+	if _, err := applyCustomFieldValues(tx, newUser.ID, dto.CustomFields, true, nil); err != nil {
+		tx.Rollback()
+		return nil, customFieldFailure("crear el usuario", err)
 	}
 	if newUser.EmployeeDetail.ID != 0 {
-		detailDTO.EmployeeDetails = &newUser.EmployeeDetail
+		if err := scheduleDateTransitions(tx, actor, &newUser, true, true); err != nil {
+			tx.Rollback()
+			return nil, lifecycleFailure("crear el usuario", err)
+		}
+	}
+
+	if err := recordUserHistory(tx, actor, &newUser, models.AuditActionUserCreated, false); err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar historial del usuario creado: %v", err)
+		return nil, errors.New("no se pudo crear el usuario")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionUserCreated,
+		TargetType: models.AuditTargetUser,
+		TargetID:   newUser.ID,
+		After:      fullUserAuditSnapshot(tx, &newUser),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar creación del usuario '%s': %v", newUser.Username, err)
+		return nil, errors.New("no se pudo crear el usuario")
+	}
+	if err := recordUserEvent(tx, actor, models.DomainEventUserCreated, &newUser, nil); err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar el evento de creación del usuario '%s': %v", newUser.Username, err)
+		return nil, errors.New("no se pudo crear el usuario")
 	}
 
-	return &detailDTO, nil
+	tx.Commit()
+
+	return userDetailDTOWithCustomFields(s.DB, &newUser), nil
 }
 
-// ListUsers recupera una lista de todos los usuarios.
-func (s *UserService) ListUsers() ([]UserDetailDTO, error) {
+// ListUsers recupera una lista de los usuarios que cumplen el filtro.
+func (s *UserService) ListUsers(filter UserListFilter) ([]UserDetailDTO, error) {
+	query := s.DB.Preload("EmployeeDetail")
+	if filter.DepartmentID != nil || filter.TeamID != nil {
+		query = query.Joins("JOIN employee_details ON employee_details.user_id = users.id AND employee_details.deleted_at IS NULL")
+		if filter.DepartmentID != nil {
+			query = query.Where("employee_details.department_id = ?", *filter.DepartmentID)
+		}
+		if filter.TeamID != nil {
+			query = query.Where("employee_details.team_id = ?", *filter.TeamID)
+		}
+	}
+	if filter.Status != nil {
+		query = query.Where("users.status = ?", *filter.Status)
+	}
+	if filter.Compliance != nil {
+		condition, args := complianceUserCondition(s.DB, *filter.Compliance, time.Now())
+		query = query.Where(condition, args...)
+	}
+	query = userSearchCondition(query, filter.Query)
+	for key, value := range filter.CustomFields {
+		condition, args, err := customFieldFilterCondition(s.DB, key, value)
+		if err != nil {
+			return nil, customFieldFailure("obtener la lista de usuarios", err)
+		}
+		query = query.Where(condition, args...)
+	}
+
 	var users []models.User
-	if err := s.DB.Preload("EmployeeDetail").Find(&users).Error; err != nil {
+	if err := query.Order("users.id ASC").Find(&users).Error; err != nil {
 		log.Printf("Error al listar usuarios: %v", err)
 		return nil, errors.New("no se pudo obtener la lista de usuarios")
 	}
 
 	userDTOs := make([]UserDetailDTO, 0, len(users))
-	for _, u := range users {
-		dto := UserDetailDTO{
-			ID:       u.ID,
-			Username: u.Username,
-			Role:     string(u.Role),
-		}
-		if u.EmployeeDetail.ID != 0 { 
-			dto.EmployeeDetails = &u.EmployeeDetail
-		}
-		userDTOs = append(userDTOs, dto)
+	refs := make([]*UserDetailDTO, 0, len(users))
+	for i := range users {
+		userDTOs = append(userDTOs, *newUserDetailDTO(&users[i]))
+	}
+	for i := range userDTOs {
+		refs = append(refs, &userDTOs[i])
+	}
+	if err := attachCustomFields(s.DB, nil, refs...); err != nil {
+		log.Printf("Error al cargar campos personalizados de la lista de usuarios: %v", err)
+		return nil, errors.New("no se pudo obtener la lista de usuarios")
 	}
 	return userDTOs, nil
 }
@@ -221,20 +501,13 @@ func (s *UserService) GetUserByID(id uint) (*UserDetailDTO, error) {
 		return nil, errors.New("no se pudo obtener el usuario")
 	}
 
-	detailDTO := UserDetailDTO{
-		ID:       user.ID,
-		Username: user.Username,
-		Role:     string(user.Role),
-	}
-	if user.EmployeeDetail.ID != 0 { 
-		detailDTO.EmployeeDetails = &user.EmployeeDetail
-	}
-
-	return &detailDTO, nil
+	return userDetailDTOWithCustomFields(s.DB, &user), nil
 }
 
 // UpdateUserByAdmin actualiza los datos de un usuario existente.
-func (s *UserService) UpdateUserByAdmin(id uint, dto AdminUpdateUserDTO) (*UserDetailDTO, error) {
+// ifMatch debe contener el ETag obtenido de GetUserByID; si no coincide con la versión
+// almacenada se devuelve *PreconditionFailedError con la representación actual.
+func (s *UserService) UpdateUserByAdmin(actor RequestActor, id uint, ifMatch string, dto AdminUpdateUserDTO) (*UserDetailDTO, error) {
 	var user models.User
 	tx := s.DB.Begin()
 
@@ -247,7 +520,15 @@ func (s *UserService) UpdateUserByAdmin(id uint, dto AdminUpdateUserDTO) (*UserD
 		return nil, errors.New("error al buscar usuario")
 	}
 
+	if current := newUserDetailDTO(&user); !etagMatches(ifMatch, current.ETag()) {
+		tx.Rollback()
+		return nil, &PreconditionFailedError{Current: userDetailDTOWithCustomFields(s.DB, &user)}
+	}
+	before := fullUserAuditSnapshot(tx, &user)
+	previousRole := user.Role
+
 	updated := false
+	detailUpdated := false
 
 	if dto.Username != nil && *dto.Username != "" && *dto.Username != user.Username {
 		user.Username = *dto.Username
@@ -282,67 +563,170 @@ func (s *UserService) UpdateUserByAdmin(id uint, dto AdminUpdateUserDTO) (*UserD
 
 		if dto.EmployeeDetails.Name != nil {
 			user.EmployeeDetail.Name = *dto.EmployeeDetails.Name
-			updated = true
+			detailUpdated = true
 		}
 		if dto.EmployeeDetails.LastName != nil {
 			user.EmployeeDetail.LastName = *dto.EmployeeDetails.LastName
-			updated = true
+			detailUpdated = true
 		}
 		if dto.EmployeeDetails.Email != nil {
 			user.EmployeeDetail.Email = *dto.EmployeeDetails.Email
-			updated = true
+			detailUpdated = true
 		}
 		if dto.EmployeeDetails.PhoneNumber != nil {
 			user.EmployeeDetail.PhoneNumber = *dto.EmployeeDetails.PhoneNumber
-			updated = true
+			detailUpdated = true
 		}
 		if dto.EmployeeDetails.Position != nil {
 			user.EmployeeDetail.Position = *dto.EmployeeDetails.Position
-			updated = true
+			detailUpdated = true
+		}
+
+		orgChanged := false
+		if dto.EmployeeDetails.DepartmentID != nil {
+			user.EmployeeDetail.DepartmentID = normalizeOptionalID(dto.EmployeeDetails.DepartmentID)
+			orgChanged = true
+		}
+		if dto.EmployeeDetails.TeamID != nil {
+			user.EmployeeDetail.TeamID = normalizeOptionalID(dto.EmployeeDetails.TeamID)
+			orgChanged = true
+		}
+		if dto.EmployeeDetails.ManagerID != nil {
+			user.EmployeeDetail.ManagerID = normalizeOptionalID(dto.EmployeeDetails.ManagerID)
+			orgChanged = true
+		}
+		if orgChanged {
+			if err := validateOrgAssignment(tx, user.ID, &user.EmployeeDetail); err != nil {
+				tx.Rollback()
+				return nil, orgValidationFailure("actualizar el usuario", err)
+			}
+			detailUpdated = true
+		}
+
+		hireChanged, terminationChanged, err := applyEmploymentDates(&user.EmployeeDetail, dto.EmployeeDetails.HireDate, dto.EmployeeDetails.TerminationDate)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
 		}
+		if hireChanged || terminationChanged {
+			if err := scheduleDateTransitions(tx, actor, &user, hireChanged, terminationChanged); err != nil {
+				tx.Rollback()
+				return nil, lifecycleFailure("actualizar el usuario", err)
+			}
+			detailUpdated = true
+		}
+	}
+
+	// Los campos personalizados no tienen versión propia: un cambio incrementa la del usuario.
+	customChanged, err := applyCustomFieldValues(tx, user.ID, dto.CustomFields, false, nil)
+	if err != nil {
+		tx.Rollback()
+		return nil, customFieldFailure("actualizar el usuario", err)
+	}
+	if customChanged {
+		updated = true
+	}
+
+	if !updated && !detailUpdated {
+		tx.Rollback()
+		return userDetailDTOWithCustomFields(s.DB, &user), nil
 	}
 
-	if !updated {
+	if err := checkUserUniqueness(tx, &user); err != nil {
+		tx.Rollback()
+		if errors.Is(err, errUsernameTaken) || errors.Is(err, errEmployeeEmailTaken) {
+			return nil, err
+		}
+		log.Printf("Error al comprobar la unicidad del usuario %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el usuario")
+	}
+	if err := saveUserVersioned(tx, &user, updated, detailUpdated); err != nil {
 		tx.Rollback()
-		currentDetailDTO := UserDetailDTO{
-			ID:       user.ID,
-			Username: user.Username,
-			Role:     string(user.Role),
+		if errors.Is(err, errVersionConflict) {
+			// Otro administrador guardó entre nuestra lectura y la escritura.
+			if current, getErr := s.GetUserByID(id); getErr == nil {
+				return nil, &PreconditionFailedError{Current: current}
+			}
 		}
-		if user.EmployeeDetail.ID != 0 {
-			currentDetailDTO.EmployeeDetails = &user.EmployeeDetail
+		if conflict := uniquenessConflict(err); conflict != nil {
+			return nil, conflict
 		}
-		return &currentDetailDTO, nil
+		log.Printf("Error al actualizar usuario %d en DB: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el usuario")
 	}
 
-	if err := tx.Save(&user).Error; err != nil {
+	if err := recordUserHistory(tx, actor, &user, models.AuditActionUserUpdated, false); err != nil {
 		tx.Rollback()
-		log.Printf("Error al actualizar usuario %d en DB: %v", id, err)
+		log.Printf("Error al registrar historial del usuario actualizado: %v", err)
+		return nil, errors.New("no se pudo actualizar el usuario")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionUserUpdated,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     before,
+		After:      fullUserAuditSnapshot(tx, &user),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar actualización del usuario %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el usuario")
+	}
+	if err := recordUserUpdateEvents(tx, actor, &user, previousRole); err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar los eventos de actualización del usuario %d: %v", id, err)
 		return nil, errors.New("no se pudo actualizar el usuario")
 	}
 
 	tx.Commit()
 
-	finalDetailDTO := UserDetailDTO{
-		ID:       user.ID,
-		Username: user.Username,
-		Role:     string(user.Role),
-	}
-	if user.EmployeeDetail.ID != 0 {
-		finalDetailDTO.EmployeeDetails = &user.EmployeeDetail
-	}
-	return &finalDetailDTO, nil
+	return userDetailDTOWithCustomFields(s.DB, &user), nil
 }
 
 // DeleteUser elimina un usuario por su ID (borrado lógico si DeletedAt está configurado).
-func (s *UserService) DeleteUser(id uint) error {
-	result := s.DB.Delete(&models.User{}, id)
+func (s *UserService) DeleteUser(actor RequestActor, id uint) error {
+	var user models.User
+	tx := s.DB.Begin()
+	if err := tx.Preload("EmployeeDetail").First(&user, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("usuario no encontrado para eliminar")
+		}
+		log.Printf("Error al buscar usuario %d para eliminar: %v", id, err)
+		return errors.New("error al eliminar el usuario")
+	}
+
+	result := tx.Delete(&models.User{}, id)
 	if result.Error != nil {
+		tx.Rollback()
 		log.Printf("Error al eliminar usuario %d: %v", id, result.Error)
 		return errors.New("error al eliminar el usuario")
 	}
 	if result.RowsAffected == 0 {
+		tx.Rollback()
 		return errors.New("usuario no encontrado para eliminar")
 	}
+
+	if err := recordUserHistory(tx, actor, &user, models.AuditActionUserDeleted, true); err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar historial del usuario eliminado: %v", err)
+		return errors.New("error al eliminar el usuario")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionUserDeleted,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     fullUserAuditSnapshot(tx, &user),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar eliminación del usuario %d: %v", id, err)
+		return errors.New("error al eliminar el usuario")
+	}
+	if err := recordUserEvent(tx, actor, models.DomainEventUserDeleted, &user, nil); err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar el evento de eliminación del usuario %d: %v", id, err)
+		return errors.New("error al eliminar el usuario")
+	}
+
+	tx.Commit()
 	return nil
 }
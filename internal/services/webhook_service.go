@@ -0,0 +1,796 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// WebhookDTO define el cuerpo de POST y PUT /api/v1/admin/webhooks.
+type WebhookDTO struct {
+	Name       string   `json:"name" binding:"required,min=1,max=100"`
+	URL        string   `json:"url" binding:"required,url,max=500"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,required"` // "*" recibe todos
+	Active     *bool    `json:"active,omitempty"`                                   // Por defecto true
+}
+
+// WebhookSecretDTO devuelve la suscripción junto con su secreto. El secreto solo se muestra
+// al crear la suscripción o al rotarlo.
+type WebhookSecretDTO struct {
+	models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// WebhookDeliveryFilter define los filtros del registro de entregas.
+type WebhookDeliveryFilter struct {
+	Status   string
+	Page     int
+	PageSize int
+}
+
+// WebhookDeliveryPage es una página del registro de entregas de una suscripción.
+type WebhookDeliveryPage struct {
+	Items    []models.WebhookDelivery `json:"items"`
+	Total    int64                    `json:"total"`
+	Page     int                      `json:"page"`
+	PageSize int                      `json:"page_size"`
+}
+
+// WebhookServiceInterface define la administración de webhooks salientes.
+type WebhookServiceInterface interface {
+	ListEventTypes() []string
+	ListWebhooks() ([]models.WebhookSubscription, error)
+	GetWebhook(id uint) (*models.WebhookSubscription, error)
+	CreateWebhook(actor RequestActor, dto WebhookDTO) (*WebhookSecretDTO, error)
+	UpdateWebhook(actor RequestActor, id uint, dto WebhookDTO) (*models.WebhookSubscription, error)
+	DeleteWebhook(actor RequestActor, id uint) error
+	RotateSecret(actor RequestActor, id uint) (*WebhookSecretDTO, error)
+
+	ListDeliveries(webhookID uint, filter WebhookDeliveryFilter) (*WebhookDeliveryPage, error)
+	GetDelivery(webhookID, deliveryID uint) (*models.WebhookDelivery, error)
+	Redeliver(actor RequestActor, webhookID, deliveryID uint) (*models.WebhookDelivery, error)
+}
+
+// WebhookService implementa WebhookServiceInterface y entrega en segundo plano los
+// eventos encolados (ver Run).
+type WebhookService struct {
+	DB          *gorm.DB
+	Client      *http.Client
+	MaxAttempts int
+	// AllowHTTP acepta destinos http además de https; solo debe usarse en desarrollo.
+	AllowHTTP bool
+	// LookupIP resuelve el host de las URLs de destino (por defecto, el resolvedor del sistema).
+	LookupIP func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// NewWebhookService crea una nueva instancia de WebhookService. allowHTTP acepta destinos
+// sin TLS (entorno de desarrollo).
+func NewWebhookService(db *gorm.DB, allowHTTP bool) *WebhookService {
+	// La dirección se vuelve a comprobar al conectar: el DNS del destino puede cambiar
+	// después de validarlo y apuntar a la red interna.
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: webhookDialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // A través de un proxy, el control de la conexión no vería el destino real
+	transport.DialContext = dialer.DialContext
+	return &WebhookService{
+		DB: db,
+		Client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+			// Una redirección podría llevar el cuerpo firmado a otro destino: se trata como fallo.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		MaxAttempts: defaultWebhookMaxAttempts,
+		AllowHTTP:   allowHTTP,
+		LookupIP:    net.DefaultResolver.LookupIPAddr,
+	}
+}
+
+const (
+	defaultWebhookMaxAttempts = 12 // Con la espera exponencial, unas 17 horas de reintentos
+	webhookBatchSize          = 20
+	webhookLease              = 2 * time.Minute
+	webhookBaseBackoff        = 30 * time.Second
+	webhookMaxBackoff         = 12 * time.Hour
+	webhookMaxResponseLog     = 2 << 10
+	defaultWebhookPageSize    = 50
+	maxWebhookPageSize        = 200
+
+	// Encabezados de cada entrega. La firma es HMAC-SHA256(secreto, "<t>.<cuerpo>") en
+	// hexadecimal; incluir la marca de tiempo permite al receptor rechazar reenvíos viejos.
+	webhookSignatureHeader = "X-Yamerito-Signature" // t=<unix>,v1=<firma>
+	webhookEventHeader     = "X-Yamerito-Event"
+	webhookEventIDHeader   = "X-Yamerito-Event-ID"
+	webhookDeliveryHeader  = "X-Yamerito-Delivery"
+)
+
+// webhookEvent es el cuerpo JSON que recibe cada suscripción.
+type webhookEvent struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// enqueueWebhookEvent crea, dentro de tx, una entrega por cada suscripción activa que
+// recibe eventType. eventID y occurredAt identifican el evento ante los receptores, que
+// pueden usarlos para descartar duplicados.
+func enqueueWebhookEvent(tx *gorm.DB, eventID, eventType string, occurredAt time.Time, data interface{}) error {
+	var subscriptions []models.WebhookSubscription
+	if err := tx.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+		return fmt.Errorf("no se pudieron leer las suscripciones de webhooks: %w", err)
+	}
+	var targets []models.WebhookSubscription
+	for _, sub := range subscriptions {
+		if sub.Subscribes(eventType) {
+			targets = append(targets, sub)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(webhookEvent{ID: eventID, Type: eventType, OccurredAt: occurredAt.UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("no se pudo serializar el evento %s: %w", eventType, err)
+	}
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, 0, len(targets))
+	for _, sub := range targets {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Payload:        payload,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+	if err := tx.Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("no se pudieron encolar las entregas del evento %s: %w", eventType, err)
+	}
+	return nil
+}
+
+// webhookEventsFor traduce un evento de dominio a los eventos de webhook que genera.
+var webhookEventsFor = map[string]string{
+	models.DomainEventUserCreated:       models.WebhookEventUserCreated,
+	models.DomainEventUserUpdated:       models.WebhookEventUserUpdated,
+	models.DomainEventUserRoleChanged:   models.WebhookEventUserRoleChanged,
+	models.DomainEventUserStatusChanged: models.WebhookEventUserStatusChanged,
+	models.DomainEventUserDeleted:       models.WebhookEventUserDeleted,
+}
+
+// WebhookEventSubscriber encola los webhooks salientes a partir de los eventos de dominio.
+// El cuerpo del webhook lleva los datos del evento tal como estaban al ocurrir el cambio.
+type WebhookEventSubscriber struct{}
+
+// Name implementa events.Subscriber.
+func (WebhookEventSubscriber) Name() string { return "webhooks" }
+
+// Handles implementa events.Subscriber.
+func (WebhookEventSubscriber) Handles(eventType string) bool {
+	_, ok := webhookEventsFor[eventType]
+	return ok
+}
+
+// Handle implementa events.Subscriber. Un cambio de estado que deja la cuenta sin acceso
+// genera además user.suspended, con un ID de evento propio.
+func (WebhookEventSubscriber) Handle(tx *gorm.DB, event *models.DomainEvent) error {
+	eventType := webhookEventsFor[event.Type]
+	if err := enqueueWebhookEvent(tx, event.EventID, eventType, event.OccurredAt, event.Payload); err != nil {
+		return err
+	}
+	if event.Type != models.DomainEventUserStatusChanged {
+		return nil
+	}
+	var change statusChangePayload
+	if err := json.Unmarshal(event.Payload, &change); err != nil {
+		return fmt.Errorf("evento %s mal formado: %w", event.EventID, err)
+	}
+	if change.From.CanLogin() && !change.To.CanLogin() {
+		return enqueueWebhookEvent(tx, event.EventID+"-suspended", models.WebhookEventUserSuspended, event.OccurredAt, event.Payload)
+	}
+	return nil
+}
+
+// signWebhookPayload calcula el valor del encabezado X-Yamerito-Signature.
+func signWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func newWebhookSecret() (string, error) {
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", err
+	}
+	return "whsec_" + secret, nil
+}
+
+func webhookAuditSnapshot(sub *models.WebhookSubscription) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        sub.Name,
+		"url":         sub.URL,
+		"event_types": sub.EventTypes,
+		"active":      sub.Active,
+	}
+}
+
+// WebhookValidationError indica una suscripción mal definida.
+type WebhookValidationError struct {
+	Reason string
+}
+
+func (e *WebhookValidationError) Error() string {
+	return e.Reason
+}
+
+// applyWebhookDTO valida el DTO y lo copia en sub.
+func applyWebhookDTO(sub *models.WebhookSubscription, dto WebhookDTO) error {
+	parsed, err := url.Parse(dto.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return &WebhookValidationError{Reason: "la URL del webhook debe ser http o https"}
+	}
+	eventTypes := make([]string, 0, len(dto.EventTypes))
+	seen := map[string]bool{}
+	for _, eventType := range dto.EventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if eventType != "*" && !models.IsWebhookEventType(eventType) {
+			return &WebhookValidationError{Reason: fmt.Sprintf("tipo de evento desconocido: %s", eventType)}
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	sub.Name = strings.TrimSpace(dto.Name)
+	sub.URL = dto.URL
+	sub.EventTypes = eventTypes
+	sub.Active = dto.Active == nil || *dto.Active
+	return nil
+}
+
+// webhookBlockedNets son rangos internos que net.IP no clasifica por sí solo: "esta red"
+// y el espacio compartido de los proveedores (donde algunas nubes publican sus metadatos).
+var webhookBlockedNets = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// blockedWebhookIP indica si ip es un destino interno: loopback, enlace local (incluido el
+// servicio de metadatos de la nube, 169.254.169.254), red privada, sin especificar o multicast.
+func blockedWebhookIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsPrivate() ||
+		ip.IsUnspecified() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range webhookBlockedNets {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	errWebhookInternalAddress = &WebhookValidationError{Reason: "la URL del webhook apunta a una dirección interna"}
+	errWebhookUnresolved      = &WebhookValidationError{Reason: "no se pudo resolver el host de la URL del webhook"}
+)
+
+// webhookDialControl rechaza la conexión si la dirección ya resuelta es interna.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || blockedWebhookIP(ip) {
+		return errWebhookInternalAddress
+	}
+	return nil
+}
+
+// checkWebhookDestination exige https (salvo AllowHTTP) y que todas las direcciones del
+// host sean públicas, para que un webhook no sirva para alcanzar la red interna.
+func (s *WebhookService) checkWebhookDestination(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return &WebhookValidationError{Reason: "la URL del webhook debe ser http o https"}
+	}
+	if parsed.Scheme != "https" && !(s.AllowHTTP && parsed.Scheme == "http") {
+		return &WebhookValidationError{Reason: "la URL del webhook debe usar https"}
+	}
+
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if blockedWebhookIP(ip) {
+			return errWebhookInternalAddress
+		}
+		return nil
+	}
+	lookup := s.LookupIP
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupIPAddr
+	}
+	addrs, err := lookup(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return errWebhookUnresolved
+	}
+	for _, addr := range addrs {
+		if blockedWebhookIP(addr.IP) {
+			return errWebhookInternalAddress
+		}
+	}
+	return nil
+}
+
+// checkWebhookDTODestination valida el destino de una suscripción al crearla o cambiarla.
+func (s *WebhookService) checkWebhookDTODestination(dto WebhookDTO) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.checkWebhookDestination(ctx, dto.URL)
+}
+
+// webhookFailure deja pasar los errores esperados de webhooks y registra los de BD.
+func webhookFailure(operation string, err error) error {
+	var validationErr *WebhookValidationError
+	if errors.As(err, &validationErr) {
+		return err
+	}
+	switch err.Error() {
+	case "webhook no encontrado", "entrega no encontrada":
+		return err
+	}
+	log.Printf("Error al %s: %v", operation, err)
+	return fmt.Errorf("no se pudo %s", operation)
+}
+
+func findWebhook(tx *gorm.DB, id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := tx.First(&sub, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("webhook no encontrado")
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ListEventTypes devuelve el catálogo de eventos.
+func (s *WebhookService) ListEventTypes() []string {
+	return models.WebhookEventTypes
+}
+
+// ListWebhooks lista las suscripciones.
+func (s *WebhookService) ListWebhooks() ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	if err := s.DB.Order("name, id").Find(&subs).Error; err != nil {
+		log.Printf("Error al listar webhooks: %v", err)
+		return nil, errors.New("no se pudieron obtener los webhooks")
+	}
+	return subs, nil
+}
+
+// GetWebhook devuelve una suscripción.
+func (s *WebhookService) GetWebhook(id uint) (*models.WebhookSubscription, error) {
+	sub, err := findWebhook(s.DB, id)
+	if err != nil {
+		return nil, webhookFailure("obtener el webhook", err)
+	}
+	return sub, nil
+}
+
+// CreateWebhook crea una suscripción con un secreto nuevo.
+func (s *WebhookService) CreateWebhook(actor RequestActor, dto WebhookDTO) (*WebhookSecretDTO, error) {
+	var sub models.WebhookSubscription
+	if err := applyWebhookDTO(&sub, dto); err != nil {
+		return nil, err
+	}
+	if err := s.checkWebhookDTODestination(dto); err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, errors.New("no se pudo crear el webhook")
+	}
+	sub.Secret = secret
+	if actor.UserID != 0 {
+		createdBy := actor.UserID
+		sub.CreatedByID = &createdBy
+	}
+
+	tx := s.DB.Begin()
+	if err := tx.Create(&sub).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear webhook: %v", err)
+		return nil, errors.New("no se pudo crear el webhook")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionWebhookCreated,
+		TargetType: models.AuditTargetWebhook,
+		TargetID:   sub.ID,
+		After:      webhookAuditSnapshot(&sub),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar creación del webhook: %v", err)
+		return nil, errors.New("no se pudo crear el webhook")
+	}
+	tx.Commit()
+	return &WebhookSecretDTO{WebhookSubscription: sub, Secret: secret}, nil
+}
+
+// UpdateWebhook cambia nombre, URL, eventos o estado de una suscripción. Las entregas
+// ya encoladas conservan la URL vigente al momento del envío.
+func (s *WebhookService) UpdateWebhook(actor RequestActor, id uint, dto WebhookDTO) (*models.WebhookSubscription, error) {
+	// Se resuelve el host antes de abrir la transacción para no retenerla durante la consulta DNS.
+	if err := s.checkWebhookDTODestination(dto); err != nil {
+		return nil, err
+	}
+	tx := s.DB.Begin()
+	sub, err := findWebhook(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, webhookFailure("actualizar el webhook", err)
+	}
+	before := webhookAuditSnapshot(sub)
+	if err := applyWebhookDTO(sub, dto); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Save(sub).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar webhook %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el webhook")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionWebhookUpdated,
+		TargetType: models.AuditTargetWebhook,
+		TargetID:   sub.ID,
+		Before:     before,
+		After:      webhookAuditSnapshot(sub),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar actualización del webhook %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el webhook")
+	}
+	tx.Commit()
+	return sub, nil
+}
+
+// DeleteWebhook elimina una suscripción. Sus entregas pendientes se descartan al
+// intentar enviarlas; el registro de entregas se conserva.
+func (s *WebhookService) DeleteWebhook(actor RequestActor, id uint) error {
+	tx := s.DB.Begin()
+	sub, err := findWebhook(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return webhookFailure("eliminar el webhook", err)
+	}
+	if err := tx.Delete(sub).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar webhook %d: %v", id, err)
+		return errors.New("no se pudo eliminar el webhook")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionWebhookDeleted,
+		TargetType: models.AuditTargetWebhook,
+		TargetID:   sub.ID,
+		Before:     webhookAuditSnapshot(sub),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar eliminación del webhook %d: %v", id, err)
+		return errors.New("no se pudo eliminar el webhook")
+	}
+	tx.Commit()
+	return nil
+}
+
+// RotateSecret reemplaza el secreto de firma. Las entregas posteriores usan el nuevo.
+func (s *WebhookService) RotateSecret(actor RequestActor, id uint) (*WebhookSecretDTO, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, errors.New("no se pudo rotar el secreto del webhook")
+	}
+	tx := s.DB.Begin()
+	sub, err := findWebhook(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, webhookFailure("rotar el secreto del webhook", err)
+	}
+	if err := tx.Model(sub).Update("secret", secret).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al rotar el secreto del webhook %d: %v", id, err)
+		return nil, errors.New("no se pudo rotar el secreto del webhook")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionWebhookSecretRotated,
+		TargetType: models.AuditTargetWebhook,
+		TargetID:   sub.ID,
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar rotación del secreto del webhook %d: %v", id, err)
+		return nil, errors.New("no se pudo rotar el secreto del webhook")
+	}
+	tx.Commit()
+	return &WebhookSecretDTO{WebhookSubscription: *sub, Secret: secret}, nil
+}
+
+// ListDeliveries devuelve el registro de entregas de una suscripción, de la más reciente
+// a la más antigua.
+func (s *WebhookService) ListDeliveries(webhookID uint, filter WebhookDeliveryFilter) (*WebhookDeliveryPage, error) {
+	if _, err := findWebhook(s.DB.Unscoped(), webhookID); err != nil {
+		return nil, webhookFailure("obtener las entregas del webhook", err)
+	}
+	switch filter.Status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryDead:
+	default:
+		return nil, &WebhookValidationError{Reason: "estado de entrega inválido"}
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultWebhookPageSize
+	}
+	if filter.PageSize > maxWebhookPageSize {
+		filter.PageSize = maxWebhookPageSize
+	}
+
+	query := s.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", webhookID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	page := &WebhookDeliveryPage{Page: filter.Page, PageSize: filter.PageSize}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		log.Printf("Error al contar entregas del webhook %d: %v", webhookID, err)
+		return nil, errors.New("no se pudieron obtener las entregas del webhook")
+	}
+	if err := query.Order("id DESC").Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&page.Items).Error; err != nil {
+		log.Printf("Error al listar entregas del webhook %d: %v", webhookID, err)
+		return nil, errors.New("no se pudieron obtener las entregas del webhook")
+	}
+	return page, nil
+}
+
+// GetDelivery devuelve una entrega con todos sus intentos.
+func (s *WebhookService) GetDelivery(webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := s.DB.Preload("AttemptLog", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("id = ? AND subscription_id = ?", deliveryID, webhookID).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("entrega no encontrada")
+		}
+		log.Printf("Error al obtener la entrega %d: %v", deliveryID, err)
+		return nil, errors.New("no se pudo obtener la entrega")
+	}
+	return &delivery, nil
+}
+
+// Redeliver vuelve a enviar el mismo evento (mismo cuerpo e ID de evento) como una
+// entrega nueva, con sus propios reintentos. Sirve para recuperar entregas descartadas.
+func (s *WebhookService) Redeliver(actor RequestActor, webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	tx := s.DB.Begin()
+	if _, err := findWebhook(tx, webhookID); err != nil {
+		tx.Rollback()
+		return nil, webhookFailure("reenviar la entrega", err)
+	}
+	var original models.WebhookDelivery
+	if err := tx.Where("id = ? AND subscription_id = ?", deliveryID, webhookID).First(&original).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("entrega no encontrada")
+		}
+		log.Printf("Error al buscar la entrega %d: %v", deliveryID, err)
+		return nil, errors.New("no se pudo reenviar la entrega")
+	}
+	redelivery := models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+		RedeliveryOfID: &original.ID,
+	}
+	if err := tx.Create(&redelivery).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear el reenvío de la entrega %d: %v", deliveryID, err)
+		return nil, errors.New("no se pudo reenviar la entrega")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionWebhookRedelivered,
+		TargetType: models.AuditTargetWebhook,
+		TargetID:   webhookID,
+		After:      map[string]interface{}{"delivery_id": redelivery.ID, "original_delivery_id": original.ID, "event_id": original.EventID},
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar el reenvío de la entrega %d: %v", deliveryID, err)
+		return nil, errors.New("no se pudo reenviar la entrega")
+	}
+	tx.Commit()
+	return &redelivery, nil
+}
+
+// webhookBackoff devuelve la espera antes del intento número attempts+1.
+func webhookBackoff(attempts int) time.Duration {
+	wait := webhookBaseBackoff
+	for i := 1; i < attempts && wait < webhookMaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > webhookMaxBackoff {
+		wait = webhookMaxBackoff
+	}
+	return wait
+}
+
+// DispatchPending envía las entregas vencidas y devuelve cuántas tuvieron éxito. Cada
+// entrega se reclama con una actualización condicional, así que es seguro ejecutarlo
+// desde varios procesos a la vez.
+func (s *WebhookService) DispatchPending(ctx context.Context) (int, error) {
+	now := time.Now()
+	var due []models.WebhookDelivery
+	if err := s.DB.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at, id").Limit(webhookBatchSize).Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	succeeded := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		delivery := &due[i]
+		claim := s.DB.WithContext(ctx).Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.WebhookDeliveryPending, delivery.NextAttemptAt).
+			Update("next_attempt_at", now.Add(webhookLease))
+		if claim.Error != nil {
+			return succeeded, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue // Otro proceso la tomó
+		}
+		if s.deliver(ctx, delivery) {
+			succeeded++
+		}
+	}
+	return succeeded, nil
+}
+
+// deliver hace un intento de entrega ya reclamado y registra el resultado.
+func (s *WebhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) bool {
+	attempt := models.WebhookDeliveryAttempt{DeliveryID: delivery.ID, AttemptedAt: time.Now()}
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+
+	var sub models.WebhookSubscription
+	err := s.DB.WithContext(ctx).Where("id = ?", delivery.SubscriptionID).Limit(1).Find(&sub).Error
+	switch {
+	case err != nil:
+		attempt.Error = err.Error()
+	case sub.ID == 0 || !sub.Active:
+		// Suscripción eliminada o desactivada: no tiene sentido reintentar.
+		attempt.Error = "la suscripción fue eliminada o desactivada"
+		updates["status"] = models.WebhookDeliveryDead
+	default:
+		// La URL se validó al guardarla, pero su DNS o la configuración pueden haber cambiado.
+		if err := s.checkWebhookDestination(ctx, sub.URL); err != nil {
+			attempt.Error = err.Error()
+			// Un fallo de DNS puede ser pasajero; un destino interno o sin TLS no se corrige reintentando.
+			if err != errWebhookUnresolved {
+				updates["status"] = models.WebhookDeliveryDead
+			}
+			break
+		}
+		attempt.StatusCode, attempt.ResponseBody, err = s.post(ctx, &sub, delivery)
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+	}
+	attempt.DurationMs = time.Since(attempt.AttemptedAt).Milliseconds()
+
+	success := attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300
+	if attempt.Error == "" && !success {
+		attempt.Error = fmt.Sprintf("respuesta HTTP %d", attempt.StatusCode)
+	}
+	updates["last_status_code"] = attempt.StatusCode
+	updates["last_error"] = attempt.Error
+	if success {
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = time.Now()
+	} else if _, dead := updates["status"]; !dead {
+		maxAttempts := s.MaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = defaultWebhookMaxAttempts
+		}
+		if attempts >= maxAttempts {
+			updates["status"] = models.WebhookDeliveryDead
+			log.Printf("Entrega de webhook %d descartada tras %d intentos: %s", delivery.ID, attempts, attempt.Error)
+		} else {
+			updates["next_attempt_at"] = time.Now().Add(webhookBackoff(attempts))
+		}
+	}
+
+	tx := s.DB.Begin()
+	if err := tx.Create(&attempt).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al registrar el intento de la entrega de webhook %d: %v", delivery.ID, err)
+		return success
+	}
+	if err := tx.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		// La entrega volverá a estar disponible al vencer la reserva.
+		log.Printf("Error al registrar el resultado de la entrega de webhook %d: %v", delivery.ID, err)
+		return success
+	}
+	tx.Commit()
+	return success
+}
+
+// post envía el cuerpo firmado y devuelve el código HTTP y el comienzo de la respuesta.
+func (s *WebhookService) post(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Yamerito-Webhooks/1.0")
+	req.Header.Set(webhookEventHeader, delivery.EventType)
+	req.Header.Set(webhookEventIDHeader, delivery.EventID)
+	req.Header.Set(webhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(webhookSignatureHeader, signWebhookPayload(sub.Secret, time.Now().Unix(), delivery.Payload))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxResponseLog))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // Permite reutilizar la conexión
+	return resp.StatusCode, strings.ToValidUTF8(string(body), ""), nil
+}
+
+// Run entrega los webhooks pendientes cada interval hasta que ctx se cancele.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.DispatchPending(ctx); err != nil {
+			log.Printf("Error al procesar las entregas de webhooks: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
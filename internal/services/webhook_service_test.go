@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Los receptores verifican la firma byte a byte: el formato t=<unix>,v1=<hex> y el
+// contenido firmado ("<t>.<cuerpo>") no pueden cambiar sin romperlos.
+func TestSignWebhookPayload(t *testing.T) {
+	const (
+		secret    = "whsec_test"
+		timestamp = int64(1700000000)
+		body      = `{"event":"user.created","data":{"id":1}}`
+		// printf '%s' '1700000000.{"event":"user.created","data":{"id":1}}' | openssl dgst -sha256 -hmac whsec_test
+		want = "t=1700000000,v1=0f4adfed50c652fd34408b79a569d785f8d0e9824717b9e8616dc75c8383b3a5"
+	)
+	if got := signWebhookPayload(secret, timestamp, []byte(body)); got != want {
+		t.Fatalf("signWebhookPayload = %q, se esperaba %q", got, want)
+	}
+	if got := signWebhookPayload(secret, timestamp+1, []byte(body)); got == want {
+		t.Error("la firma no depende de la marca de tiempo")
+	}
+	if got := signWebhookPayload("otro", timestamp, []byte(body)); got == want {
+		t.Error("la firma no depende del secreto")
+	}
+}
+
+func TestCheckWebhookDestination(t *testing.T) {
+	resolved := map[string][]string{
+		"hooks.example.com":    {"93.184.216.34"},
+		"interno.example.com":  {"10.0.0.5"},
+		"mixto.example.com":    {"93.184.216.34", "192.168.1.10"},
+		"metadata.example.com": {"169.254.169.254"},
+		"v6.example.com":       {"::1"},
+	}
+	lookup := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		ips, ok := resolved[host]
+		if !ok {
+			return nil, errors.New("no such host")
+		}
+		addrs := make([]net.IPAddr, 0, len(ips))
+		for _, ip := range ips {
+			addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+		}
+		return addrs, nil
+	}
+	tests := []struct {
+		name      string
+		url       string
+		allowHTTP bool
+		want      error // Error concreto esperado, cuando importa cuál
+		wantErr   bool
+	}{
+		{"https público", "https://hooks.example.com/yamerito", false, nil, false},
+		{"IP pública", "https://93.184.216.34/hook", false, nil, false},
+		{"http en producción", "http://hooks.example.com/yamerito", false, nil, true},
+		{"http en desarrollo", "http://hooks.example.com/yamerito", true, nil, false},
+		{"esquema ftp", "ftp://hooks.example.com/yamerito", true, nil, true},
+		{"loopback", "https://127.0.0.1:8080/hook", false, errWebhookInternalAddress, true},
+		{"localhost IPv6", "https://[::1]/hook", false, errWebhookInternalAddress, true},
+		{"metadatos de la nube", "http://169.254.169.254/latest/meta-data/", true, errWebhookInternalAddress, true},
+		{"red privada 10/8", "https://10.1.2.3/hook", false, errWebhookInternalAddress, true},
+		{"red privada 172.16/12", "https://172.20.0.1/hook", false, errWebhookInternalAddress, true},
+		{"red privada 192.168/16", "https://192.168.0.1/hook", false, errWebhookInternalAddress, true},
+		{"espacio compartido", "https://100.100.100.200/hook", false, errWebhookInternalAddress, true},
+		{"sin especificar", "https://0.0.0.0/hook", false, errWebhookInternalAddress, true},
+		{"IPv4 mapeada en IPv6", "https://[::ffff:127.0.0.1]/hook", false, errWebhookInternalAddress, true},
+		{"host que resuelve a red privada", "https://interno.example.com/hook", false, errWebhookInternalAddress, true},
+		{"host con una dirección privada entre varias", "https://mixto.example.com/hook", false, errWebhookInternalAddress, true},
+		{"host que resuelve a metadatos", "https://metadata.example.com/hook", false, errWebhookInternalAddress, true},
+		{"host que resuelve a loopback IPv6", "https://v6.example.com/hook", false, errWebhookInternalAddress, true},
+		{"host que no resuelve", "https://nadie.example.com/hook", false, errWebhookUnresolved, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &WebhookService{AllowHTTP: tt.allowHTTP, LookupIP: lookup}
+			err := s.checkWebhookDestination(context.Background(), tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkWebhookDestination(%q) = %v, se esperaba error: %v", tt.url, err, tt.wantErr)
+			}
+			if tt.want != nil && err != tt.want {
+				t.Errorf("checkWebhookDestination(%q) = %v, se esperaba %v", tt.url, err, tt.want)
+			}
+			var validationErr *WebhookValidationError
+			if err != nil && !errors.As(err, &validationErr) {
+				t.Errorf("se esperaba un WebhookValidationError, se obtuvo %T", err)
+			}
+		})
+	}
+}
+
+// Aunque el DNS cambie después de validar la URL, el cliente de entregas no se conecta a
+// una dirección interna.
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	reached := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer server.Close()
+
+	s := NewWebhookService(nil, true)
+	resp, err := s.Client.Post(server.URL, "application/json", nil)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("el cliente de webhooks se conectó a una dirección de loopback")
+	}
+	if reached {
+		t.Error("la petición llegó al servidor interno")
+	}
+}
+
+// Crear o cambiar una suscripción hacia la red interna se rechaza antes de escribir nada.
+func TestCreateAndUpdateWebhookRejectInternalURL(t *testing.T) {
+	db, mock := newMockDB(t)
+	s := &WebhookService{DB: db}
+	dto := WebhookDTO{Name: "Metadatos", URL: "https://169.254.169.254/latest/meta-data/", EventTypes: []string{"*"}}
+	if _, err := s.CreateWebhook(RequestActor{UserID: 1}, dto); err != errWebhookInternalAddress {
+		t.Errorf("CreateWebhook() error = %v, se esperaba %v", err, errWebhookInternalAddress)
+	}
+	if _, err := s.UpdateWebhook(RequestActor{UserID: 1}, 3, dto); err != errWebhookInternalAddress {
+		t.Errorf("UpdateWebhook() error = %v, se esperaba %v", err, errWebhookInternalAddress)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
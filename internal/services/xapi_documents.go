@@ -0,0 +1,294 @@
+package services
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// XAPIDocumentKey identifica un documento del LRS. Agent (JSON) y Registration solo se usan
+// en los documentos de estado. DocumentID es stateId o profileId; vacío, se refiere a todos
+// los documentos de la actividad (listado o borrado del estado).
+type XAPIDocumentKey struct {
+	Kind         string
+	ActivityID   string
+	Agent        string
+	Registration string
+	DocumentID   string
+}
+
+// XAPIPrecondition son los encabezados If-Match e If-None-Match de una escritura.
+type XAPIPrecondition struct {
+	IfMatch     string
+	IfNoneMatch string
+}
+
+var (
+	errXAPIDocumentNotFound = errors.New("documento no encontrado")
+	errXAPIPrecondition     = errors.New("el documento cambió: la condición If-Match o If-None-Match no se cumple")
+	errXAPIDocumentExists   = errors.New("el documento ya existe: envíe If-Match o If-None-Match para modificarlo")
+)
+
+// xapiDocumentLocator es la clave de un documento ya validada.
+type xapiDocumentLocator struct {
+	kind         string
+	activityID   string
+	agent        string
+	registration string
+	documentID   string
+}
+
+func (l xapiDocumentLocator) hash(clientID uint) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{strconv.FormatUint(uint64(clientID), 10), l.kind, l.activityID,
+		l.agent, l.registration, l.documentID}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// locateXAPIDocument valida los parámetros de la clave según el tipo de documento.
+func locateXAPIDocument(key XAPIDocumentKey, requireID bool) (xapiDocumentLocator, error) {
+	locator := xapiDocumentLocator{kind: key.Kind, activityID: key.ActivityID, documentID: key.DocumentID}
+	if !isXAPIIRI(key.ActivityID) || len(key.ActivityID) > 500 {
+		return locator, xapiInvalid("parámetro inválido: activityId debe ser un IRI de hasta 500 caracteres")
+	}
+	if requireID && key.DocumentID == "" {
+		return locator, xapiInvalid("parámetro inválido: falta el identificador del documento")
+	}
+	if len(key.DocumentID) > 255 {
+		return locator, xapiInvalid("parámetro inválido: el identificador del documento supera los 255 caracteres")
+	}
+	switch key.Kind {
+	case models.XAPIDocumentState:
+		if key.Agent == "" {
+			return locator, xapiInvalid("parámetro inválido: falta agent")
+		}
+		agent, err := parseXAPIAgentParam(key.Agent, true)
+		if err != nil {
+			return locator, err
+		}
+		locator.agent = agent
+		if key.Registration != "" {
+			if !xapiUUIDPattern.MatchString(key.Registration) {
+				return locator, xapiInvalid("parámetro inválido: registration debe ser un UUID")
+			}
+			locator.registration = strings.ToLower(key.Registration)
+		}
+	case models.XAPIDocumentActivityProfile:
+		// El perfil solo depende de la actividad: agent y registration no forman parte de la clave.
+	default:
+		return locator, xapiInvalid("parámetro inválido: tipo de documento desconocido")
+	}
+	return locator, nil
+}
+
+// xapiETag es el ETag (SHA-1 del contenido) que exige la especificación para los documentos.
+func xapiETag(content []byte) string {
+	sum := sha1.Sum(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
+
+// checkXAPIPrecondition aplica If-Match e If-None-Match sobre el documento actual (nil si
+// no existe). Los encabezados llevan el ETag entre comillas.
+func checkXAPIPrecondition(existing *models.XAPIDocument, cond XAPIPrecondition) error {
+	if cond.IfMatch != "" && (existing == nil || !etagMatches(cond.IfMatch, `"`+existing.ETag+`"`)) {
+		return errXAPIPrecondition
+	}
+	if cond.IfNoneMatch != "" && existing != nil && etagMatches(cond.IfNoneMatch, `"`+existing.ETag+`"`) {
+		return errXAPIPrecondition
+	}
+	return nil
+}
+
+// xapiDocumentFailure deja pasar los errores esperados de los documentos y registra los de BD.
+func xapiDocumentFailure(operation string, err error) error {
+	var validationErr *XAPIValidationError
+	if errors.As(err, &validationErr) {
+		return err
+	}
+	switch err {
+	case errXAPIDocumentNotFound, errXAPIPrecondition, errXAPIDocumentExists:
+		return err
+	}
+	log.Printf("Error al %s: %v", operation, err)
+	return fmt.Errorf("no se pudo %s", operation)
+}
+
+func findXAPIDocument(tx *gorm.DB, clientID uint, locator xapiDocumentLocator, lock bool) (*models.XAPIDocument, error) {
+	query := tx
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var documents []models.XAPIDocument
+	if err := query.Where("key_hash = ?", locator.hash(clientID)).Limit(1).Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	if len(documents) == 0 {
+		return nil, nil
+	}
+	return &documents[0], nil
+}
+
+// GetDocument devuelve un documento de estado o de perfil de actividad.
+func (s *XAPIService) GetDocument(client *models.XAPIClient, key XAPIDocumentKey) (*models.XAPIDocument, error) {
+	locator, err := locateXAPIDocument(key, true)
+	if err != nil {
+		return nil, err
+	}
+	document, err := findXAPIDocument(s.DB, client.ID, locator, false)
+	if err != nil {
+		return nil, xapiDocumentFailure("obtener el documento", err)
+	}
+	if document == nil {
+		return nil, errXAPIDocumentNotFound
+	}
+	return document, nil
+}
+
+// ListDocumentIDs devuelve los identificadores de los documentos de la actividad (y, en el
+// estado, del agente y el registro), opcionalmente solo los modificados después de since.
+func (s *XAPIService) ListDocumentIDs(client *models.XAPIClient, key XAPIDocumentKey, since *time.Time) ([]string, error) {
+	key.DocumentID = ""
+	locator, err := locateXAPIDocument(key, false)
+	if err != nil {
+		return nil, err
+	}
+	query := s.DB.Model(&models.XAPIDocument{}).
+		Where("client_id = ? AND kind = ? AND activity_id = ?", client.ID, locator.kind, locator.activityID)
+	if locator.kind == models.XAPIDocumentState {
+		query = query.Where("agent = ? AND registration = ?", locator.agent, locator.registration)
+	}
+	if since != nil {
+		query = query.Where("updated_at > ?", since.UTC())
+	}
+	ids := []string{}
+	if err := query.Order("document_id").Pluck("document_id", &ids).Error; err != nil {
+		return nil, xapiDocumentFailure("obtener los documentos", err)
+	}
+	return ids, nil
+}
+
+// SaveDocument crea o reemplaza un documento (PUT) o, con merge, combina sus propiedades
+// de primer nivel con las del documento actual (POST); la combinación solo se admite entre
+// objetos JSON. Reemplazar un perfil de actividad existente exige If-Match o If-None-Match.
+func (s *XAPIService) SaveDocument(client *models.XAPIClient, key XAPIDocumentKey, content []byte, contentType string,
+	cond XAPIPrecondition, merge bool) (*models.XAPIDocument, error) {
+	locator, err := locateXAPIDocument(key, true)
+	if err != nil {
+		return nil, err
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	var incoming map[string]interface{}
+	if merge {
+		if !isJSONContentType(contentType) || decodeXAPIJSON(content, &incoming) != nil || incoming == nil {
+			return nil, xapiInvalid("documento inválido: POST solo admite objetos JSON")
+		}
+	}
+
+	tx := s.DB.Begin()
+	existing, err := findXAPIDocument(tx, client.ID, locator, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, xapiDocumentFailure("guardar el documento", err)
+	}
+	if err := checkXAPIPrecondition(existing, cond); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !merge && existing != nil && locator.kind == models.XAPIDocumentActivityProfile &&
+		cond.IfMatch == "" && cond.IfNoneMatch == "" {
+		tx.Rollback()
+		return nil, errXAPIDocumentExists
+	}
+	if merge && existing != nil {
+		var current map[string]interface{}
+		if !isJSONContentType(existing.ContentType) || decodeXAPIJSON(existing.Content, &current) != nil || current == nil {
+			tx.Rollback()
+			return nil, xapiInvalid("documento inválido: el documento actual no es un objeto JSON y no se puede combinar")
+		}
+		for property, value := range incoming {
+			current[property] = value
+		}
+		if content, err = json.Marshal(current); err != nil {
+			tx.Rollback()
+			return nil, xapiDocumentFailure("guardar el documento", err)
+		}
+		contentType = "application/json"
+	}
+
+	document := existing
+	if document == nil {
+		document = &models.XAPIDocument{
+			KeyHash:      locator.hash(client.ID),
+			ClientID:     client.ID,
+			Kind:         locator.kind,
+			ActivityID:   locator.activityID,
+			Agent:        locator.agent,
+			Registration: locator.registration,
+			DocumentID:   locator.documentID,
+		}
+	}
+	document.Content = content
+	document.ContentType = contentType
+	document.ETag = xapiETag(content)
+	if err := tx.Save(document).Error; err != nil {
+		tx.Rollback()
+		return nil, xapiDocumentFailure("guardar el documento", err)
+	}
+	tx.Commit()
+	return document, nil
+}
+
+// DeleteDocuments borra un documento o, en el estado y sin DocumentID, todos los del agente
+// en la actividad (y registro). Borrar lo que no existe no es un error.
+func (s *XAPIService) DeleteDocuments(client *models.XAPIClient, key XAPIDocumentKey, cond XAPIPrecondition) error {
+	locator, err := locateXAPIDocument(key, key.Kind == models.XAPIDocumentActivityProfile)
+	if err != nil {
+		return err
+	}
+	tx := s.DB.Begin()
+	if locator.documentID == "" {
+		if err := tx.Where("client_id = ? AND kind = ? AND activity_id = ? AND agent = ? AND registration = ?",
+			client.ID, locator.kind, locator.activityID, locator.agent, locator.registration).
+			Delete(&models.XAPIDocument{}).Error; err != nil {
+			tx.Rollback()
+			return xapiDocumentFailure("eliminar los documentos", err)
+		}
+		tx.Commit()
+		return nil
+	}
+	existing, err := findXAPIDocument(tx, client.ID, locator, true)
+	if err != nil {
+		tx.Rollback()
+		return xapiDocumentFailure("eliminar el documento", err)
+	}
+	if err := checkXAPIPrecondition(existing, cond); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if existing != nil {
+		if err := tx.Delete(existing).Error; err != nil {
+			tx.Rollback()
+			return xapiDocumentFailure("eliminar el documento", err)
+		}
+	}
+	tx.Commit()
+	return nil
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+)
+
+// XAPIClientDTO define el cuerpo de POST y PUT /api/v1/admin/xapi-clients.
+type XAPIClientDTO struct {
+	Name   string `json:"name" binding:"required,min=1,max=100"`
+	Active *bool  `json:"active,omitempty"` // Por defecto true
+}
+
+// XAPIClientSecretDTO devuelve el cliente junto con su secreto. El secreto solo se muestra
+// al crear el cliente o al rotarlo.
+type XAPIClientSecretDTO struct {
+	models.XAPIClient
+	Secret string `json:"secret"`
+}
+
+// XAPIActivityLinkDTO define el cuerpo de POST /api/v1/admin/xapi-clients/:id/activities.
+type XAPIActivityLinkDTO struct {
+	ActivityID string `json:"activity_id" binding:"required,max=500"`
+	LessonID   uint   `json:"lesson_id" binding:"required"`
+}
+
+// XAPIServiceInterface define el LRS (Learning Record Store) xAPI: la administración de
+// los clientes que lo usan y las declaraciones y documentos que envían.
+type XAPIServiceInterface interface {
+	ListClients() ([]models.XAPIClient, error)
+	GetClient(id uint) (*models.XAPIClient, error)
+	CreateClient(actor RequestActor, dto XAPIClientDTO) (*XAPIClientSecretDTO, error)
+	UpdateClient(actor RequestActor, id uint, dto XAPIClientDTO) (*models.XAPIClient, error)
+	DeleteClient(actor RequestActor, id uint) error
+	RotateClientSecret(actor RequestActor, id uint) (*XAPIClientSecretDTO, error)
+	ListActivityLinks(clientID uint) ([]models.XAPIActivityLink, error)
+	LinkActivity(actor RequestActor, clientID uint, dto XAPIActivityLinkDTO) (*models.XAPIActivityLink, error)
+	UnlinkActivity(actor RequestActor, clientID, linkID uint) error
+
+	AuthenticateClient(key, secret string) (*models.XAPIClient, error)
+	StoreStatements(client *models.XAPIClient, body []byte, statementID string) ([]string, error)
+	GetStatement(client *models.XAPIClient, statementID string, voided bool, format string) (*models.XAPIStatement, error)
+	QueryStatements(client *models.XAPIClient, query XAPIStatementQuery) (*XAPIStatementResult, error)
+	GetDocument(client *models.XAPIClient, key XAPIDocumentKey) (*models.XAPIDocument, error)
+	ListDocumentIDs(client *models.XAPIClient, key XAPIDocumentKey, since *time.Time) ([]string, error)
+	SaveDocument(client *models.XAPIClient, key XAPIDocumentKey, content []byte, contentType string, cond XAPIPrecondition, merge bool) (*models.XAPIDocument, error)
+	DeleteDocuments(client *models.XAPIClient, key XAPIDocumentKey, cond XAPIPrecondition) error
+}
+
+// XAPIService implementa XAPIServiceInterface. HomePage es la URL pública de la aplicación:
+// identifica las cuentas (account.homePage) de los empleados y de los clientes.
+type XAPIService struct {
+	DB       *gorm.DB
+	HomePage string
+}
+
+// NewXAPIService crea una nueva instancia de XAPIService.
+func NewXAPIService(db *gorm.DB, homePage string) *XAPIService {
+	return &XAPIService{DB: db, HomePage: strings.TrimRight(homePage, "/")}
+}
+
+var (
+	errXAPIClientNotFound   = errors.New("cliente xAPI no encontrado")
+	errXAPIClientAuth       = errors.New("credenciales xAPI inválidas")
+	errXAPILinkNotFound     = errors.New("actividad vinculada no encontrada")
+	errXAPIStatementMissing = errors.New("declaración no encontrada")
+)
+
+// hashXAPISecret calcula el hash guardado del secreto. El secreto es aleatorio y largo,
+// así que basta un SHA-256; no hace falta el costo de Argon2id en cada petición del LRS.
+func hashXAPISecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newXAPICredentials() (string, string, error) {
+	key, err := randomHex(12)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", "", err
+	}
+	return "xapi_" + key, "xsec_" + secret, nil
+}
+
+func xapiClientAuditSnapshot(client *models.XAPIClient) map[string]interface{} {
+	return map[string]interface{}{
+		"name":   client.Name,
+		"key":    client.AccessKey,
+		"active": client.Active,
+	}
+}
+
+// xapiFailure deja pasar los errores esperados de la administración de clientes y registra
+// los de BD.
+func xapiFailure(operation string, err error) error {
+	switch err {
+	case errXAPIClientNotFound, errXAPILinkNotFound, errLessonNotFound:
+		return err
+	}
+	if strings.HasPrefix(err.Error(), "la actividad") {
+		return err
+	}
+	log.Printf("Error al %s: %v", operation, err)
+	return fmt.Errorf("no se pudo %s", operation)
+}
+
+func findXAPIClient(tx *gorm.DB, id uint) (*models.XAPIClient, error) {
+	var client models.XAPIClient
+	if err := tx.First(&client, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errXAPIClientNotFound
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+// ListClients lista los clientes del LRS.
+func (s *XAPIService) ListClients() ([]models.XAPIClient, error) {
+	var clients []models.XAPIClient
+	if err := s.DB.Order("name, id").Find(&clients).Error; err != nil {
+		log.Printf("Error al listar clientes xAPI: %v", err)
+		return nil, errors.New("no se pudieron obtener los clientes xAPI")
+	}
+	return clients, nil
+}
+
+// GetClient devuelve un cliente.
+func (s *XAPIService) GetClient(id uint) (*models.XAPIClient, error) {
+	client, err := findXAPIClient(s.DB, id)
+	if err != nil {
+		return nil, xapiFailure("obtener el cliente xAPI", err)
+	}
+	return client, nil
+}
+
+// CreateClient crea un cliente con credenciales nuevas.
+func (s *XAPIService) CreateClient(actor RequestActor, dto XAPIClientDTO) (*XAPIClientSecretDTO, error) {
+	key, secret, err := newXAPICredentials()
+	if err != nil {
+		return nil, errors.New("no se pudo crear el cliente xAPI")
+	}
+	client := models.XAPIClient{
+		Name:       strings.TrimSpace(dto.Name),
+		AccessKey:  key,
+		SecretHash: hashXAPISecret(secret),
+		Active:     dto.Active == nil || *dto.Active,
+	}
+	if actor.UserID != 0 {
+		createdBy := actor.UserID
+		client.CreatedByID = &createdBy
+	}
+
+	tx := s.DB.Begin()
+	if err := tx.Create(&client).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al crear cliente xAPI: %v", err)
+		return nil, errors.New("no se pudo crear el cliente xAPI")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionXAPIClientCreated,
+		TargetType: models.AuditTargetXAPIClient,
+		TargetID:   client.ID,
+		After:      xapiClientAuditSnapshot(&client),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar creación del cliente xAPI: %v", err)
+		return nil, errors.New("no se pudo crear el cliente xAPI")
+	}
+	tx.Commit()
+	return &XAPIClientSecretDTO{XAPIClient: client, Secret: secret}, nil
+}
+
+// UpdateClient cambia el nombre o el estado de un cliente. Un cliente inactivo no puede
+// autenticarse; sus declaraciones y documentos se conservan.
+func (s *XAPIService) UpdateClient(actor RequestActor, id uint, dto XAPIClientDTO) (*models.XAPIClient, error) {
+	tx := s.DB.Begin()
+	client, err := findXAPIClient(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, xapiFailure("actualizar el cliente xAPI", err)
+	}
+	before := xapiClientAuditSnapshot(client)
+	client.Name = strings.TrimSpace(dto.Name)
+	client.Active = dto.Active == nil || *dto.Active
+	if err := tx.Save(client).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al actualizar cliente xAPI %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el cliente xAPI")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionXAPIClientUpdated,
+		TargetType: models.AuditTargetXAPIClient,
+		TargetID:   client.ID,
+		Before:     before,
+		After:      xapiClientAuditSnapshot(client),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar actualización del cliente xAPI %d: %v", id, err)
+		return nil, errors.New("no se pudo actualizar el cliente xAPI")
+	}
+	tx.Commit()
+	return client, nil
+}
+
+// DeleteClient elimina un cliente y sus actividades vinculadas. Las declaraciones que envió
+// se conservan.
+func (s *XAPIService) DeleteClient(actor RequestActor, id uint) error {
+	tx := s.DB.Begin()
+	client, err := findXAPIClient(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return xapiFailure("eliminar el cliente xAPI", err)
+	}
+	if err := tx.Where("client_id = ?", client.ID).Delete(&models.XAPIActivityLink{}).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar las actividades del cliente xAPI %d: %v", id, err)
+		return errors.New("no se pudo eliminar el cliente xAPI")
+	}
+	if err := tx.Delete(client).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al eliminar cliente xAPI %d: %v", id, err)
+		return errors.New("no se pudo eliminar el cliente xAPI")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionXAPIClientDeleted,
+		TargetType: models.AuditTargetXAPIClient,
+		TargetID:   client.ID,
+		Before:     xapiClientAuditSnapshot(client),
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar eliminación del cliente xAPI %d: %v", id, err)
+		return errors.New("no se pudo eliminar el cliente xAPI")
+	}
+	tx.Commit()
+	return nil
+}
+
+// RotateClientSecret reemplaza el secreto del cliente; el anterior deja de valer de inmediato.
+func (s *XAPIService) RotateClientSecret(actor RequestActor, id uint) (*XAPIClientSecretDTO, error) {
+	_, secret, err := newXAPICredentials()
+	if err != nil {
+		return nil, errors.New("no se pudo rotar el secreto del cliente xAPI")
+	}
+	tx := s.DB.Begin()
+	client, err := findXAPIClient(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, xapiFailure("rotar el secreto del cliente xAPI", err)
+	}
+	client.SecretHash = hashXAPISecret(secret)
+	if err := tx.Model(client).Update("secret_hash", client.SecretHash).Error; err != nil {
+		tx.Rollback()
+		log.Printf("Error al rotar el secreto del cliente xAPI %d: %v", id, err)
+		return nil, errors.New("no se pudo rotar el secreto del cliente xAPI")
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionXAPISecretRotated,
+		TargetType: models.AuditTargetXAPIClient,
+		TargetID:   client.ID,
+	}); err != nil {
+		tx.Rollback()
+		log.Printf("Error al auditar rotación del secreto del cliente xAPI %d: %v", id, err)
+		return nil, errors.New("no se pudo rotar el secreto del cliente xAPI")
+	}
+	tx.Commit()
+	return &XAPIClientSecretDTO{XAPIClient: *client, Secret: secret}, nil
+}
+
+// ListActivityLinks lista las actividades de un cliente vinculadas a lecciones.
+func (s *XAPIService) ListActivityLinks(clientID uint) ([]models.XAPIActivityLink, error) {
+	if _, err := findXAPIClient(s.DB, clientID); err != nil {
+		return nil, xapiFailure("obtener las actividades del cliente xAPI", err)
+	}
+	var links []models.XAPIActivityLink
+	if err := s.DB.Where("client_id = ?", clientID).Order("activity_id").Find(&links).Error; err != nil {
+		log.Printf("Error al listar las actividades del cliente xAPI %d: %v", clientID, err)
+		return nil, errors.New("no se pudieron obtener las actividades del cliente xAPI")
+	}
+	return links, nil
+}
+
+// LinkActivity vincula una actividad del cliente a una lección. Desde ese momento las
+// declaraciones del cliente sobre la actividad actualizan el avance de la lección; las ya
+// guardadas no se reprocesan.
+func (s *XAPIService) LinkActivity(actor RequestActor, clientID uint, dto XAPIActivityLinkDTO) (*models.XAPIActivityLink, error) {
+	activityID := strings.TrimSpace(dto.ActivityID)
+	if !isXAPIIRI(activityID) {
+		return nil, errors.New("la actividad debe ser un IRI absoluto")
+	}
+	tx := s.DB.Begin()
+	client, err := findXAPIClient(tx, clientID)
+	if err != nil {
+		tx.Rollback()
+		return nil, xapiFailure("vincular la actividad", err)
+	}
+	var lessonTypes []models.LessonType
+	if err := tx.Model(&models.Lesson{}).
+		Joins("JOIN course_modules ON course_modules.id = lessons.module_id AND course_modules.deleted_at IS NULL").
+		Joins("JOIN courses ON courses.id = course_modules.course_id AND courses.deleted_at IS NULL").
+		Where("lessons.id = ?", dto.LessonID).Pluck("lessons.type", &lessonTypes).Error; err != nil {
+		tx.Rollback()
+		return nil, xapiFailure("vincular la actividad", err)
+	}
+	if len(lessonTypes) == 0 {
+		tx.Rollback()
+		return nil, errLessonNotFound
+	}
+	if lessonTypes[0] == models.LessonScorm {
+		// El avance de las lecciones SCORM lo informa el paquete (ver ScormService.Commit).
+		tx.Rollback()
+		return nil, errors.New("la actividad no se puede vincular a una lección SCORM")
+	}
+	var existing int64
+	if err := tx.Model(&models.XAPIActivityLink{}).Where("client_id = ? AND activity_id = ?", client.ID, activityID).
+		Count(&existing).Error; err != nil {
+		tx.Rollback()
+		return nil, xapiFailure("vincular la actividad", err)
+	}
+	if existing > 0 {
+		tx.Rollback()
+		return nil, errors.New("la actividad ya está vinculada a una lección")
+	}
+
+	link := models.XAPIActivityLink{ClientID: client.ID, ActivityID: activityID, LessonID: dto.LessonID}
+	if actor.UserID != 0 {
+		createdBy := actor.UserID
+		link.CreatedByID = &createdBy
+	}
+	if err := tx.Create(&link).Error; err != nil {
+		tx.Rollback()
+		return nil, xapiFailure("vincular la actividad", err)
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionXAPIActivityLinked,
+		TargetType: models.AuditTargetXAPIClient,
+		TargetID:   client.ID,
+		After:      map[string]interface{}{"activity_id": link.ActivityID, "lesson_id": link.LessonID},
+	}); err != nil {
+		tx.Rollback()
+		return nil, xapiFailure("vincular la actividad", err)
+	}
+	tx.Commit()
+	return &link, nil
+}
+
+// UnlinkActivity quita el vínculo de una actividad. El avance ya registrado se conserva.
+func (s *XAPIService) UnlinkActivity(actor RequestActor, clientID, linkID uint) error {
+	tx := s.DB.Begin()
+	var link models.XAPIActivityLink
+	if err := tx.Where("id = ? AND client_id = ?", linkID, clientID).First(&link).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errXAPILinkNotFound
+		}
+		return xapiFailure("desvincular la actividad", err)
+	}
+	if err := tx.Delete(&link).Error; err != nil {
+		tx.Rollback()
+		return xapiFailure("desvincular la actividad", err)
+	}
+	if err := recordAudit(tx, actor, auditRecord{
+		Action:     models.AuditActionXAPIActivityUnlinked,
+		TargetType: models.AuditTargetXAPIClient,
+		TargetID:   clientID,
+		Before:     map[string]interface{}{"activity_id": link.ActivityID, "lesson_id": link.LessonID},
+	}); err != nil {
+		tx.Rollback()
+		return xapiFailure("desvincular la actividad", err)
+	}
+	tx.Commit()
+	return nil
+}
+
+// AuthenticateClient valida las credenciales HTTP Basic de un cliente activo.
+func (s *XAPIService) AuthenticateClient(key, secret string) (*models.XAPIClient, error) {
+	if key == "" || secret == "" {
+		return nil, errXAPIClientAuth
+	}
+	var client models.XAPIClient
+	if err := s.DB.Where("access_key = ?", key).Limit(1).Find(&client).Error; err != nil {
+		log.Printf("Error al autenticar el cliente xAPI %s: %v", key, err)
+		return nil, errors.New("no se pudo autenticar el cliente xAPI")
+	}
+	if client.ID == 0 || !client.Active ||
+		subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashXAPISecret(secret))) != 1 {
+		return nil, errXAPIClientAuth
+	}
+	return &client, nil
+}
+
+// xapiAuthority es la autoridad que el LRS asigna a las declaraciones del cliente.
+func (s *XAPIService) xapiAuthority(client *models.XAPIClient) map[string]interface{} {
+	return map[string]interface{}{
+		"objectType": "Agent",
+		"name":       client.Name,
+		"account":    map[string]interface{}{"homePage": s.HomePage, "name": client.AccessKey},
+	}
+}
@@ -0,0 +1,1194 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultXAPIStatementLimit = 100
+	maxXAPIStatementLimit     = 500
+	maxXAPIStatementBatch     = 200
+
+	xapiVerbVoided        = "http://adlnet.gov/expapi/verbs/voided"
+	xapiProgressExtension = "https://w3id.org/xapi/cmi5/result/extensions/progress"
+)
+
+var (
+	xapiUUIDPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	xapiSHA1Pattern     = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+	xapiDurationPattern = regexp.MustCompile(`^P(?:\d+(?:\.\d+)?W|(?:\d+(?:\.\d+)?Y)?(?:\d+(?:\.\d+)?M)?(?:\d+(?:\.\d+)?D)?(?:T(?:\d+(?:\.\d+)?H)?(?:\d+(?:\.\d+)?M)?(?:\d+(?:\.\d+)?S)?)?)$`)
+)
+
+// xapiCompletionVerbs completan la lección vinculada; los demás verbos de xapiProgressVerbs
+// solo la marcan en curso (o completada si el resultado lo indica).
+var xapiCompletionVerbs = map[string]bool{
+	"http://adlnet.gov/expapi/verbs/completed": true,
+	"http://adlnet.gov/expapi/verbs/passed":    true,
+	"http://adlnet.gov/expapi/verbs/mastered":  true,
+}
+
+var xapiProgressVerbs = map[string]bool{
+	"http://adlnet.gov/expapi/verbs/completed":   true,
+	"http://adlnet.gov/expapi/verbs/passed":      true,
+	"http://adlnet.gov/expapi/verbs/mastered":    true,
+	"http://adlnet.gov/expapi/verbs/failed":      true,
+	"http://adlnet.gov/expapi/verbs/launched":    true,
+	"http://adlnet.gov/expapi/verbs/initialized": true,
+	"http://adlnet.gov/expapi/verbs/attempted":   true,
+	"http://adlnet.gov/expapi/verbs/experienced": true,
+	"http://adlnet.gov/expapi/verbs/progressed":  true,
+	"http://adlnet.gov/expapi/verbs/resumed":     true,
+	"http://adlnet.gov/expapi/verbs/suspended":   true,
+	"http://adlnet.gov/expapi/verbs/terminated":  true,
+}
+
+// XAPIStatementQuery son los filtros de GET /api/v1/xapi/statements.
+type XAPIStatementQuery struct {
+	Agent             string // JSON de un agente o grupo identificado
+	Verb              string
+	Activity          string
+	Registration      string
+	RelatedActivities bool
+	RelatedAgents     bool
+	Since             *time.Time
+	Until             *time.Time
+	Limit             int
+	Ascending         bool
+	Format            string
+	// Cursor es el ID interno de la última declaración de la página anterior (ver More).
+	Cursor uint
+}
+
+// XAPIStatementResult es una página de declaraciones. NextCursor es 0 si no hay más; el
+// handler lo convierte en la URL "more" de la respuesta.
+type XAPIStatementResult struct {
+	Statements []json.RawMessage `json:"statements"`
+	More       string            `json:"more"`
+	NextCursor uint              `json:"-"`
+}
+
+// XAPIValidationError indica una declaración o una consulta que no cumple la especificación.
+type XAPIValidationError struct {
+	Reason string
+}
+
+func (e *XAPIValidationError) Error() string {
+	return e.Reason
+}
+
+func xapiInvalid(format string, args ...interface{}) error {
+	return &XAPIValidationError{Reason: fmt.Sprintf(format, args...)}
+}
+
+var (
+	errXAPIStatementConflict = errors.New("ya existe una declaración con ese id y otro contenido")
+	errXAPIVoidForeign       = errors.New("no se puede anular una declaración de otro cliente")
+)
+
+// isXAPIIRI indica si s es un IRI absoluto (con esquema).
+func isXAPIIRI(s string) bool {
+	if s == "" || strings.ContainsAny(s, " \t\n") {
+		return false
+	}
+	parsed, err := url.Parse(s)
+	return err == nil && parsed.Scheme != ""
+}
+
+// newXAPIStatementID genera un UUID versión 4.
+func newXAPIStatementID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	buf[6] = buf[6]&0x0f | 0x40
+	buf[8] = buf[8]&0x3f | 0x80
+	h := hex.EncodeToString(buf)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+// decodeXAPIJSON decodifica conservando los números tal como llegaron.
+func decodeXAPIJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("contenido adicional después del JSON")
+	}
+	return nil
+}
+
+func xapiObject(v interface{}, field string) (map[string]interface{}, error) {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, xapiInvalid("declaración inválida: %s debe ser un objeto", field)
+	}
+	return object, nil
+}
+
+func xapiAllowedKeys(object map[string]interface{}, field string, allowed ...string) error {
+	for key := range object {
+		known := false
+		for _, name := range allowed {
+			if key == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return xapiInvalid("declaración inválida: propiedad desconocida %s.%s", field, key)
+		}
+	}
+	return nil
+}
+
+func xapiString(object map[string]interface{}, key, field string) (string, bool, error) {
+	v, ok := object[key]
+	if !ok {
+		return "", false, nil
+	}
+	s, isString := v.(string)
+	if !isString {
+		return "", false, xapiInvalid("declaración inválida: %s.%s debe ser texto", field, key)
+	}
+	return s, true, nil
+}
+
+func xapiNumber(v interface{}, field string) (float64, error) {
+	number, ok := v.(json.Number)
+	if !ok {
+		return 0, xapiInvalid("declaración inválida: %s debe ser un número", field)
+	}
+	value, err := number.Float64()
+	if err != nil {
+		return 0, xapiInvalid("declaración inválida: %s debe ser un número", field)
+	}
+	return value, nil
+}
+
+func validateXAPILanguageMap(v interface{}, field string) error {
+	languages, err := xapiObject(v, field)
+	if err != nil {
+		return err
+	}
+	for tag, text := range languages {
+		if _, ok := text.(string); !ok || tag == "" {
+			return xapiInvalid("declaración inválida: %s debe ser un mapa de idiomas", field)
+		}
+	}
+	return nil
+}
+
+func validateXAPIExtensions(v interface{}, field string) error {
+	extensions, err := xapiObject(v, field)
+	if err != nil {
+		return err
+	}
+	for key := range extensions {
+		if !isXAPIIRI(key) {
+			return xapiInvalid("declaración inválida: las claves de %s deben ser IRI", field)
+		}
+	}
+	return nil
+}
+
+// validateXAPIAgent valida un agente o, si allowGroup, un grupo. Un agente tiene
+// exactamente un identificador (mbox, mbox_sha1sum, openid o account); un grupo tiene a lo
+// sumo uno y, si es anónimo, al menos un miembro.
+func validateXAPIAgent(v interface{}, field string, allowGroup bool) error {
+	agent, err := xapiObject(v, field)
+	if err != nil {
+		return err
+	}
+	objectType, _, err := xapiString(agent, "objectType", field)
+	if err != nil {
+		return err
+	}
+	isGroup := objectType == "Group"
+	switch {
+	case objectType != "" && objectType != "Agent" && !isGroup:
+		return xapiInvalid("declaración inválida: %s.objectType debe ser Agent o Group", field)
+	case isGroup && !allowGroup:
+		return xapiInvalid("declaración inválida: %s debe ser un agente", field)
+	}
+	allowed := []string{"objectType", "name", "mbox", "mbox_sha1sum", "openid", "account"}
+	if isGroup {
+		allowed = append(allowed, "member")
+	}
+	if err := xapiAllowedKeys(agent, field, allowed...); err != nil {
+		return err
+	}
+	if _, _, err := xapiString(agent, "name", field); err != nil {
+		return err
+	}
+
+	identifiers := 0
+	if mbox, ok, err := xapiString(agent, "mbox", field); err != nil {
+		return err
+	} else if ok {
+		if !strings.HasPrefix(mbox, "mailto:") || len(mbox) <= len("mailto:") {
+			return xapiInvalid("declaración inválida: %s.mbox debe ser un IRI mailto:", field)
+		}
+		identifiers++
+	}
+	if sum, ok, err := xapiString(agent, "mbox_sha1sum", field); err != nil {
+		return err
+	} else if ok {
+		if !xapiSHA1Pattern.MatchString(sum) {
+			return xapiInvalid("declaración inválida: %s.mbox_sha1sum debe ser un SHA-1 en hexadecimal", field)
+		}
+		identifiers++
+	}
+	if openid, ok, err := xapiString(agent, "openid", field); err != nil {
+		return err
+	} else if ok {
+		if !isXAPIIRI(openid) {
+			return xapiInvalid("declaración inválida: %s.openid debe ser un IRI", field)
+		}
+		identifiers++
+	}
+	if raw, ok := agent["account"]; ok {
+		account, err := xapiObject(raw, field+".account")
+		if err != nil {
+			return err
+		}
+		if err := xapiAllowedKeys(account, field+".account", "homePage", "name"); err != nil {
+			return err
+		}
+		homePage, _, err := xapiString(account, "homePage", field+".account")
+		if err != nil {
+			return err
+		}
+		name, _, err := xapiString(account, "name", field+".account")
+		if err != nil {
+			return err
+		}
+		if !isXAPIIRI(homePage) || name == "" {
+			return xapiInvalid("declaración inválida: %s.account necesita homePage (IRL) y name", field)
+		}
+		identifiers++
+	}
+
+	switch {
+	case identifiers > 1:
+		return xapiInvalid("declaración inválida: %s tiene más de un identificador", field)
+	case identifiers == 0 && !isGroup:
+		return xapiInvalid("declaración inválida: %s no tiene identificador (mbox, mbox_sha1sum, openid o account)", field)
+	}
+	if isGroup {
+		members, hasMembers := agent["member"]
+		list, ok := members.([]interface{})
+		if hasMembers && !ok {
+			return xapiInvalid("declaración inválida: %s.member debe ser una lista", field)
+		}
+		if identifiers == 0 && len(list) == 0 {
+			return xapiInvalid("declaración inválida: el grupo anónimo %s necesita miembros", field)
+		}
+		for i, member := range list {
+			if err := validateXAPIAgent(member, fmt.Sprintf("%s.member[%d]", field, i), false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateXAPIActivity(activity map[string]interface{}, field string) error {
+	if err := xapiAllowedKeys(activity, field, "objectType", "id", "definition"); err != nil {
+		return err
+	}
+	id, _, err := xapiString(activity, "id", field)
+	if err != nil {
+		return err
+	}
+	if !isXAPIIRI(id) {
+		return xapiInvalid("declaración inválida: %s.id debe ser un IRI", field)
+	}
+	if len(id) > 500 {
+		return xapiInvalid("declaración inválida: %s.id supera los 500 caracteres", field)
+	}
+	raw, ok := activity["definition"]
+	if !ok {
+		return nil
+	}
+	definition, err := xapiObject(raw, field+".definition")
+	if err != nil {
+		return err
+	}
+	if err := xapiAllowedKeys(definition, field+".definition", "name", "description", "type", "moreInfo",
+		"extensions", "interactionType", "correctResponsesPattern", "choices", "scale", "source", "target", "steps"); err != nil {
+		return err
+	}
+	for _, key := range []string{"name", "description"} {
+		if v, ok := definition[key]; ok {
+			if err := validateXAPILanguageMap(v, field+".definition."+key); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range []string{"type", "moreInfo"} {
+		if value, ok, err := xapiString(definition, key, field+".definition"); err != nil {
+			return err
+		} else if ok && !isXAPIIRI(value) {
+			return xapiInvalid("declaración inválida: %s.definition.%s debe ser un IRI", field, key)
+		}
+	}
+	if v, ok := definition["extensions"]; ok {
+		return validateXAPIExtensions(v, field+".definition.extensions")
+	}
+	return nil
+}
+
+// validateXAPIObject valida el objeto de una declaración: actividad (por defecto), agente,
+// grupo, referencia a otra declaración o, fuera de una subdeclaración, una subdeclaración.
+func validateXAPIObject(v interface{}, field string, inSubStatement bool) error {
+	object, err := xapiObject(v, field)
+	if err != nil {
+		return err
+	}
+	objectType, _, err := xapiString(object, "objectType", field)
+	if err != nil {
+		return err
+	}
+	switch objectType {
+	case "", "Activity":
+		return validateXAPIActivity(object, field)
+	case "Agent", "Group":
+		return validateXAPIAgent(object, field, true)
+	case "StatementRef":
+		if err := xapiAllowedKeys(object, field, "objectType", "id"); err != nil {
+			return err
+		}
+		if id, _, err := xapiString(object, "id", field); err != nil {
+			return err
+		} else if !xapiUUIDPattern.MatchString(id) {
+			return xapiInvalid("declaración inválida: %s.id debe ser un UUID", field)
+		}
+		return nil
+	case "SubStatement":
+		if inSubStatement {
+			return xapiInvalid("declaración inválida: una subdeclaración no puede contener otra")
+		}
+		return validateXAPIStatement(object, true)
+	default:
+		return xapiInvalid("declaración inválida: %s.objectType desconocido: %s", field, objectType)
+	}
+}
+
+func validateXAPIResult(v interface{}) error {
+	result, err := xapiObject(v, "result")
+	if err != nil {
+		return err
+	}
+	if err := xapiAllowedKeys(result, "result", "score", "success", "completion", "response", "duration", "extensions"); err != nil {
+		return err
+	}
+	if raw, ok := result["score"]; ok {
+		score, err := xapiObject(raw, "result.score")
+		if err != nil {
+			return err
+		}
+		if err := xapiAllowedKeys(score, "result.score", "scaled", "raw", "min", "max"); err != nil {
+			return err
+		}
+		values := map[string]float64{}
+		for key := range score {
+			value, err := xapiNumber(score[key], "result.score."+key)
+			if err != nil {
+				return err
+			}
+			values[key] = value
+		}
+		if scaled, ok := values["scaled"]; ok && (scaled < -1 || scaled > 1) {
+			return xapiInvalid("declaración inválida: result.score.scaled debe estar entre -1 y 1")
+		}
+		min, hasMin := values["min"]
+		max, hasMax := values["max"]
+		raw, hasRaw := values["raw"]
+		if (hasMin && hasMax && min >= max) || (hasRaw && hasMin && raw < min) || (hasRaw && hasMax && raw > max) {
+			return xapiInvalid("declaración inválida: result.score debe cumplir min <= raw <= max y min < max")
+		}
+	}
+	for _, key := range []string{"success", "completion"} {
+		if v, ok := result[key]; ok {
+			if _, isBool := v.(bool); !isBool {
+				return xapiInvalid("declaración inválida: result.%s debe ser verdadero o falso", key)
+			}
+		}
+	}
+	if _, _, err := xapiString(result, "response", "result"); err != nil {
+		return err
+	}
+	if duration, ok, err := xapiString(result, "duration", "result"); err != nil {
+		return err
+	} else if ok && (!xapiDurationPattern.MatchString(duration) || duration == "P" || strings.HasSuffix(duration, "T")) {
+		return xapiInvalid("declaración inválida: result.duration debe ser una duración ISO 8601")
+	}
+	if v, ok := result["extensions"]; ok {
+		return validateXAPIExtensions(v, "result.extensions")
+	}
+	return nil
+}
+
+// xapiActivityList normaliza una lista de contextActivities: la especificación admite
+// una sola actividad en lugar de la lista.
+func xapiActivityList(v interface{}) []interface{} {
+	if list, ok := v.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{v}
+}
+
+func validateXAPIContext(v interface{}, objectIsActivity bool) error {
+	context, err := xapiObject(v, "context")
+	if err != nil {
+		return err
+	}
+	if err := xapiAllowedKeys(context, "context", "registration", "instructor", "team", "contextActivities",
+		"revision", "platform", "language", "statement", "extensions"); err != nil {
+		return err
+	}
+	if registration, ok, err := xapiString(context, "registration", "context"); err != nil {
+		return err
+	} else if ok && !xapiUUIDPattern.MatchString(registration) {
+		return xapiInvalid("declaración inválida: context.registration debe ser un UUID")
+	}
+	if instructor, ok := context["instructor"]; ok {
+		if err := validateXAPIAgent(instructor, "context.instructor", true); err != nil {
+			return err
+		}
+	}
+	if team, ok := context["team"]; ok {
+		if err := validateXAPIAgent(team, "context.team", true); err != nil {
+			return err
+		}
+		if objectType, _ := team.(map[string]interface{})["objectType"].(string); objectType != "Group" {
+			return xapiInvalid("declaración inválida: context.team debe ser un grupo")
+		}
+	}
+	if raw, ok := context["contextActivities"]; ok {
+		activities, err := xapiObject(raw, "context.contextActivities")
+		if err != nil {
+			return err
+		}
+		if err := xapiAllowedKeys(activities, "context.contextActivities", "parent", "grouping", "category", "other"); err != nil {
+			return err
+		}
+		for key, list := range activities {
+			for i, item := range xapiActivityList(list) {
+				field := fmt.Sprintf("context.contextActivities.%s[%d]", key, i)
+				activity, err := xapiObject(item, field)
+				if err != nil {
+					return err
+				}
+				if objectType, _, err := xapiString(activity, "objectType", field); err != nil {
+					return err
+				} else if objectType != "" && objectType != "Activity" {
+					return xapiInvalid("declaración inválida: %s debe ser una actividad", field)
+				}
+				if err := validateXAPIActivity(activity, field); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, key := range []string{"revision", "platform", "language"} {
+		if _, ok, err := xapiString(context, key, "context"); err != nil {
+			return err
+		} else if ok && key != "language" && !objectIsActivity {
+			return xapiInvalid("declaración inválida: context.%s solo se admite si el objeto es una actividad", key)
+		}
+	}
+	if statement, ok := context["statement"]; ok {
+		ref, err := xapiObject(statement, "context.statement")
+		if err != nil {
+			return err
+		}
+		if objectType, _ := ref["objectType"].(string); objectType != "StatementRef" {
+			return xapiInvalid("declaración inválida: context.statement debe ser un StatementRef")
+		}
+		if err := validateXAPIObject(ref, "context.statement", true); err != nil {
+			return err
+		}
+	}
+	if v, ok := context["extensions"]; ok {
+		return validateXAPIExtensions(v, "context.extensions")
+	}
+	return nil
+}
+
+// validateXAPIStatement valida una declaración (o subdeclaración) según xAPI 1.0.3. Los
+// adjuntos solo se aceptan por referencia (fileUrl): el LRS no recibe contenido multipart.
+func validateXAPIStatement(statement map[string]interface{}, subStatement bool) error {
+	if subStatement {
+		if err := xapiAllowedKeys(statement, "object", "objectType", "actor", "verb", "object", "result", "context",
+			"timestamp", "attachments"); err != nil {
+			return err
+		}
+	} else if err := xapiAllowedKeys(statement, "statement", "id", "actor", "verb", "object", "result", "context",
+		"timestamp", "stored", "authority", "version", "attachments"); err != nil {
+		return err
+	}
+
+	if id, ok, err := xapiString(statement, "id", "statement"); err != nil {
+		return err
+	} else if ok && !xapiUUIDPattern.MatchString(id) {
+		return xapiInvalid("declaración inválida: id debe ser un UUID")
+	}
+	for _, key := range []string{"actor", "verb", "object"} {
+		if _, ok := statement[key]; !ok {
+			return xapiInvalid("declaración inválida: falta %s", key)
+		}
+	}
+	if err := validateXAPIAgent(statement["actor"], "actor", true); err != nil {
+		return err
+	}
+	verb, err := xapiObject(statement["verb"], "verb")
+	if err != nil {
+		return err
+	}
+	if err := xapiAllowedKeys(verb, "verb", "id", "display"); err != nil {
+		return err
+	}
+	if id, _, err := xapiString(verb, "id", "verb"); err != nil {
+		return err
+	} else if !isXAPIIRI(id) || len(id) > 500 {
+		return xapiInvalid("declaración inválida: verb.id debe ser un IRI de hasta 500 caracteres")
+	}
+	if display, ok := verb["display"]; ok {
+		if err := validateXAPILanguageMap(display, "verb.display"); err != nil {
+			return err
+		}
+	}
+	if err := validateXAPIObject(statement["object"], "object", subStatement); err != nil {
+		return err
+	}
+	if result, ok := statement["result"]; ok {
+		if err := validateXAPIResult(result); err != nil {
+			return err
+		}
+	}
+	if context, ok := statement["context"]; ok {
+		objectType, _ := statement["object"].(map[string]interface{})["objectType"].(string)
+		if err := validateXAPIContext(context, objectType == "" || objectType == "Activity"); err != nil {
+			return err
+		}
+	}
+	if timestamp, ok, err := xapiString(statement, "timestamp", "statement"); err != nil {
+		return err
+	} else if ok {
+		if _, err := time.Parse(time.RFC3339Nano, timestamp); err != nil {
+			return xapiInvalid("declaración inválida: timestamp debe tener formato ISO 8601 con zona horaria")
+		}
+	}
+	if version, ok, err := xapiString(statement, "version", "statement"); err != nil {
+		return err
+	} else if ok && version != "1.0" && !strings.HasPrefix(version, "1.0.") {
+		return xapiInvalid("declaración inválida: versión de xAPI no soportada: %s", version)
+	}
+	if raw, ok := statement["attachments"]; ok {
+		attachments, isList := raw.([]interface{})
+		if !isList {
+			return xapiInvalid("declaración inválida: attachments debe ser una lista")
+		}
+		for i, item := range attachments {
+			field := fmt.Sprintf("attachments[%d]", i)
+			attachment, err := xapiObject(item, field)
+			if err != nil {
+				return err
+			}
+			fileURL, _, err := xapiString(attachment, "fileUrl", field)
+			if err != nil {
+				return err
+			}
+			if !isXAPIIRI(fileURL) {
+				return xapiInvalid("declaración inválida: el LRS solo admite adjuntos con fileUrl")
+			}
+		}
+	}
+	if verbID, _ := verb["id"].(string); verbID == xapiVerbVoided {
+		if objectType, _ := statement["object"].(map[string]interface{})["objectType"].(string); objectType != "StatementRef" {
+			return xapiInvalid("declaración inválida: una declaración de anulación debe referenciar otra con StatementRef")
+		}
+	}
+	return nil
+}
+
+// xapiAgentKey devuelve el identificador canónico de un agente o grupo identificado, o ""
+// si es un grupo anónimo. Los identificadores demasiado largos para indexarlos se guardan
+// como hash.
+func xapiAgentKey(agent map[string]interface{}) string {
+	var key string
+	switch {
+	case agent["mbox"] != nil:
+		key = "mbox:" + strings.ToLower(agent["mbox"].(string))
+	case agent["mbox_sha1sum"] != nil:
+		key = "mbox_sha1sum:" + strings.ToLower(agent["mbox_sha1sum"].(string))
+	case agent["openid"] != nil:
+		key = "openid:" + agent["openid"].(string)
+	case agent["account"] != nil:
+		account := agent["account"].(map[string]interface{})
+		key = "account:" + account["homePage"].(string) + "|" + account["name"].(string)
+	default:
+		return ""
+	}
+	if len(key) > 500 {
+		sum := sha256.Sum256([]byte(key))
+		key = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	return key
+}
+
+// parseXAPIAgentParam lee el parámetro agent (JSON) de las consultas y los documentos.
+func parseXAPIAgentParam(raw string, allowGroup bool) (string, error) {
+	var agent interface{}
+	if err := decodeXAPIJSON([]byte(raw), &agent); err != nil {
+		return "", xapiInvalid("parámetro inválido: agent debe ser un agente en JSON")
+	}
+	if err := validateXAPIAgent(agent, "agent", allowGroup); err != nil {
+		return "", xapiInvalid("parámetro inválido: %s", strings.TrimPrefix(err.Error(), "declaración inválida: "))
+	}
+	key := xapiAgentKey(agent.(map[string]interface{}))
+	if key == "" {
+		return "", xapiInvalid("parámetro inválido: agent debe estar identificado")
+	}
+	return key, nil
+}
+
+// xapiRef es un agente o una actividad que aparece en una declaración.
+type xapiRef struct {
+	kind    string
+	value   string
+	primary bool
+}
+
+// collectXAPIRefs reúne los agentes y actividades de una declaración ya validada. El actor
+// y el objeto son primarios; los miembros de grupos, el contexto, la autoridad y el
+// contenido de una subdeclaración solo cuentan para related_agents y related_activities.
+func collectXAPIRefs(statement map[string]interface{}) []models.XAPIStatementRef {
+	var refs []xapiRef
+	addAgent := func(v interface{}, primary bool) {
+		agent, ok := v.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if key := xapiAgentKey(agent); key != "" {
+			refs = append(refs, xapiRef{"agent", key, primary})
+		}
+		if members, ok := agent["member"].([]interface{}); ok {
+			for _, member := range members {
+				if key := xapiAgentKey(member.(map[string]interface{})); key != "" {
+					refs = append(refs, xapiRef{"agent", key, false})
+				}
+			}
+		}
+	}
+	var addStatement func(st map[string]interface{}, primary bool)
+	addStatement = func(st map[string]interface{}, primary bool) {
+		addAgent(st["actor"], primary)
+		object, _ := st["object"].(map[string]interface{})
+		switch objectType, _ := object["objectType"].(string); objectType {
+		case "", "Activity":
+			refs = append(refs, xapiRef{"activity", object["id"].(string), primary})
+		case "Agent", "Group":
+			addAgent(object, primary)
+		case "SubStatement":
+			addStatement(object, false)
+		}
+		context, _ := st["context"].(map[string]interface{})
+		addAgent(context["instructor"], false)
+		addAgent(context["team"], false)
+		if activities, ok := context["contextActivities"].(map[string]interface{}); ok {
+			for _, list := range activities {
+				for _, item := range xapiActivityList(list) {
+					refs = append(refs, xapiRef{"activity", item.(map[string]interface{})["id"].(string), false})
+				}
+			}
+		}
+	}
+	addStatement(statement, true)
+	addAgent(statement["authority"], false)
+
+	index := map[string]int{}
+	var rows []models.XAPIStatementRef
+	for _, ref := range refs {
+		if i, ok := index[ref.kind+"\x00"+ref.value]; ok {
+			rows[i].IsPrimary = rows[i].IsPrimary || ref.primary
+			continue
+		}
+		index[ref.kind+"\x00"+ref.value] = len(rows)
+		rows = append(rows, models.XAPIStatementRef{Kind: ref.kind, Value: ref.value, IsPrimary: ref.primary})
+	}
+	return rows
+}
+
+// sameXAPIStatement compara una declaración recibida con una ya guardada ignorando lo que
+// agrega el LRS, para aceptar los reenvíos idénticos.
+func sameXAPIStatement(received map[string]interface{}, stored json.RawMessage) bool {
+	var existing map[string]interface{}
+	if err := decodeXAPIJSON(stored, &existing); err != nil {
+		return false
+	}
+	delete(existing, "stored")
+	delete(existing, "authority")
+	for _, key := range []string{"version", "timestamp"} {
+		if _, ok := received[key]; !ok {
+			delete(existing, key)
+		}
+	}
+	return reflect.DeepEqual(received, existing)
+}
+
+// xapiLearner reconoce al empleado del actor: por el correo de mbox o por una cuenta cuyo
+// homePage es la URL de la aplicación y cuyo name es el nombre de usuario.
+func (s *XAPIService) xapiLearner(tx *gorm.DB, actor map[string]interface{}) (*models.User, error) {
+	if objectType, _ := actor["objectType"].(string); objectType == "Group" {
+		return nil, nil
+	}
+	query := tx.Model(&models.User{}).Select("users.id, users.username, users.role")
+	if mbox, ok := actor["mbox"].(string); ok {
+		query = query.Joins("JOIN employee_details ON employee_details.user_id = users.id").
+			Where("employee_details.email = ?", strings.TrimPrefix(mbox, "mailto:"))
+	} else if account, ok := actor["account"].(map[string]interface{}); ok &&
+		strings.TrimRight(account["homePage"].(string), "/") == s.HomePage {
+		query = query.Where("users.username = ?", account["name"])
+	} else {
+		return nil, nil
+	}
+	var users []models.User
+	if err := query.Limit(1).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+	return &users[0], nil
+}
+
+// xapiProgressDTO traduce el verbo y el resultado a un envío de avance. Devuelve false si
+// la declaración no informa avance. La nota no se usa: el avance de la lección es cuánto
+// se recorrió, no cuánto se acertó.
+func xapiProgressDTO(statement map[string]interface{}, verbID string, occurredAt time.Time) (UpdateLessonProgressDTO, bool) {
+	dto := UpdateLessonProgressDTO{Completed: xapiCompletionVerbs[verbID], OccurredAt: &occurredAt}
+	if !xapiProgressVerbs[verbID] {
+		return dto, false
+	}
+	result, _ := statement["result"].(map[string]interface{})
+	if completion, _ := result["completion"].(bool); completion {
+		dto.Completed = true
+	}
+	if extensions, ok := result["extensions"].(map[string]interface{}); ok {
+		if number, ok := extensions[xapiProgressExtension].(json.Number); ok {
+			if value, err := number.Int64(); err == nil && value >= 0 && value <= 100 {
+				percent := int(value)
+				dto.Percent = &percent
+			}
+		}
+	}
+	return dto, true
+}
+
+// applyXAPIProgress aplica una declaración al avance de la lección vinculada a su actividad
+// si el actor es un empleado inscrito en el curso. Devuelve el empleado y la lección
+// afectados (nil si no corresponde).
+func (s *XAPIService) applyXAPIProgress(tx *gorm.DB, client *models.XAPIClient, statement map[string]interface{}, verbID string,
+	occurredAt time.Time, now time.Time) (*uint, *uint, error) {
+	learner, err := s.xapiLearner(tx, statement["actor"].(map[string]interface{}))
+	if err != nil || learner == nil {
+		return nil, nil, err
+	}
+	userID := learner.ID
+	object, _ := statement["object"].(map[string]interface{})
+	if objectType, _ := object["objectType"].(string); objectType != "" && objectType != "Activity" {
+		return &userID, nil, nil
+	}
+	dto, ok := xapiProgressDTO(statement, verbID, occurredAt)
+	if !ok {
+		return &userID, nil, nil
+	}
+
+	var link struct {
+		LessonID uint
+		CourseID uint
+	}
+	if err := tx.Model(&models.XAPIActivityLink{}).
+		Select("xapi_activity_links.lesson_id, course_modules.course_id").
+		Joins("JOIN lessons ON lessons.id = xapi_activity_links.lesson_id AND lessons.deleted_at IS NULL").
+		Joins("JOIN course_modules ON course_modules.id = lessons.module_id AND course_modules.deleted_at IS NULL").
+		Where("xapi_activity_links.client_id = ? AND xapi_activity_links.activity_id = ?", client.ID, object["id"]).
+		Limit(1).Scan(&link).Error; err != nil {
+		return nil, nil, err
+	}
+	if link.LessonID == 0 {
+		return &userID, nil, nil
+	}
+	enrollment, err := findUserEnrollment(tx, userID, link.CourseID, true)
+	if err == errNotEnrolled {
+		return &userID, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	actor := RequestActor{UserID: learner.ID, Username: learner.Username, Role: learner.Role}
+	if _, err := saveLessonProgress(tx, actor, enrollment, link.LessonID, dto, now); err != nil {
+		return nil, nil, err
+	}
+	lessonID := link.LessonID
+	return &userID, &lessonID, nil
+}
+
+// StoreStatements guarda una declaración (PUT, con statementID) o un lote (POST, objeto o
+// lista) y devuelve sus IDs. El lote se guarda completo o no se guarda. Un ID ya existente
+// con el mismo contenido no es un error; con otro contenido, sí. Las declaraciones de
+// anulación marcan la declaración referenciada como anulada, pero el avance que esa
+// declaración hubiera registrado se conserva.
+func (s *XAPIService) StoreStatements(client *models.XAPIClient, body []byte, statementID string) ([]string, error) {
+	var decoded interface{}
+	if err := decodeXAPIJSON(body, &decoded); err != nil {
+		return nil, xapiInvalid("declaración inválida: el cuerpo no es JSON válido")
+	}
+	var items []interface{}
+	switch value := decoded.(type) {
+	case map[string]interface{}:
+		items = []interface{}{value}
+	case []interface{}:
+		if statementID != "" {
+			return nil, xapiInvalid("declaración inválida: PUT admite una sola declaración")
+		}
+		items = value
+	default:
+		return nil, xapiInvalid("declaración inválida: se esperaba un objeto o una lista")
+	}
+	if len(items) == 0 {
+		return nil, xapiInvalid("declaración inválida: la lista está vacía")
+	}
+	if len(items) > maxXAPIStatementBatch {
+		return nil, xapiInvalid("declaración inválida: un lote admite hasta %d declaraciones", maxXAPIStatementBatch)
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	statements := make([]map[string]interface{}, 0, len(items))
+	ids := make([]string, 0, len(items))
+	seen := map[string]bool{}
+	for _, item := range items {
+		statement, err := xapiObject(item, "statement")
+		if err != nil {
+			return nil, err
+		}
+		if err := validateXAPIStatement(statement, false); err != nil {
+			return nil, err
+		}
+		id, _ := statement["id"].(string)
+		if statementID != "" {
+			if !xapiUUIDPattern.MatchString(statementID) {
+				return nil, xapiInvalid("parámetro inválido: statementId debe ser un UUID")
+			}
+			if id != "" && !strings.EqualFold(id, statementID) {
+				return nil, xapiInvalid("declaración inválida: el id no coincide con statementId")
+			}
+			id = statementID
+		}
+		if id == "" {
+			if id, err = newXAPIStatementID(); err != nil {
+				return nil, errors.New("no se pudieron guardar las declaraciones")
+			}
+		}
+		id = strings.ToLower(id)
+		if seen[id] {
+			return nil, xapiInvalid("declaración inválida: el lote repite el id %s", id)
+		}
+		seen[id] = true
+		statement["id"] = id
+		statements = append(statements, statement)
+		ids = append(ids, id)
+	}
+
+	tx := s.DB.Begin()
+	for _, statement := range statements {
+		if err := s.storeXAPIStatement(tx, client, statement, now); err != nil {
+			tx.Rollback()
+			var validationErr *XAPIValidationError
+			if errors.As(err, &validationErr) || err == errXAPIStatementConflict || err == errXAPIVoidForeign {
+				return nil, err
+			}
+			log.Printf("Error al guardar la declaración xAPI %v del cliente %d: %v", statement["id"], client.ID, err)
+			return nil, errors.New("no se pudieron guardar las declaraciones")
+		}
+	}
+	tx.Commit()
+	return ids, nil
+}
+
+// storeXAPIStatement guarda una declaración validada dentro de tx.
+func (s *XAPIService) storeXAPIStatement(tx *gorm.DB, client *models.XAPIClient, statement map[string]interface{}, now time.Time) error {
+	id := statement["id"].(string)
+	var existing models.XAPIStatement
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("statement_id = ?", id).Limit(1).Find(&existing).Error; err != nil {
+		return err
+	}
+	if existing.ID != 0 {
+		if existing.ClientID == client.ID && sameXAPIStatement(statement, existing.Statement) {
+			return nil
+		}
+		return errXAPIStatementConflict
+	}
+
+	verbID := statement["verb"].(map[string]interface{})["id"].(string)
+	voiding := verbID == xapiVerbVoided
+	if voiding {
+		targetID := strings.ToLower(statement["object"].(map[string]interface{})["id"].(string))
+		var target models.XAPIStatement
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("statement_id = ?", targetID).Limit(1).Find(&target).Error; err != nil {
+			return err
+		}
+		// La especificación pide aceptar la anulación aunque la declaración referenciada
+		// todavía no exista; en ese caso no tiene efecto.
+		if target.ID != 0 {
+			switch {
+			case target.ClientID != client.ID:
+				return errXAPIVoidForeign
+			case target.Voiding:
+				return xapiInvalid("declaración inválida: no se puede anular una declaración de anulación")
+			}
+			if err := tx.Model(&target).Update("voided", true).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	// Los reenvíos se comparan con lo recibido, así que las propiedades del LRS se agregan
+	// sobre una copia.
+	stored := make(map[string]interface{}, len(statement)+4)
+	for key, value := range statement {
+		stored[key] = value
+	}
+	stored["stored"] = now.Format(time.RFC3339Nano)
+	stored["authority"] = s.xapiAuthority(client)
+	if _, ok := stored["version"]; !ok {
+		stored["version"] = "1.0.0"
+	}
+	timestamp := now
+	if raw, ok := stored["timestamp"].(string); ok {
+		timestamp, _ = time.Parse(time.RFC3339Nano, raw)
+	} else {
+		stored["timestamp"] = stored["stored"]
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	row := models.XAPIStatement{
+		StatementID: id,
+		ClientID:    client.ID,
+		VerbID:      verbID,
+		Voiding:     voiding,
+		Timestamp:   timestamp.UTC(),
+		Stored:      now,
+		Statement:   data,
+	}
+	if context, ok := statement["context"].(map[string]interface{}); ok {
+		if registration, ok := context["registration"].(string); ok {
+			row.Registration = strings.ToLower(registration)
+		}
+	}
+	if !voiding {
+		if row.UserID, row.LessonID, err = s.applyXAPIProgress(tx, client, statement, verbID, timestamp, now); err != nil {
+			return err
+		}
+	}
+	if err := tx.Create(&row).Error; err != nil {
+		return err
+	}
+	refs := collectXAPIRefs(stored)
+	for i := range refs {
+		refs[i].StatementID = row.ID
+	}
+	return tx.Create(&refs).Error
+}
+
+// xapiIDsFormat aplica format=ids: los agentes y grupos quedan solo con su identificador
+// (y los miembros de los grupos anónimos) y las actividades solo con su id.
+func xapiIDsFormat(data json.RawMessage) json.RawMessage {
+	var statement map[string]interface{}
+	if err := decodeXAPIJSON(data, &statement); err != nil {
+		return data
+	}
+	var reduceAgent func(v interface{})
+	reduceAgent = func(v interface{}) {
+		agent, ok := v.(map[string]interface{})
+		if !ok {
+			return
+		}
+		delete(agent, "name")
+		if members, ok := agent["member"].([]interface{}); ok {
+			for _, member := range members {
+				reduceAgent(member)
+			}
+		}
+	}
+	var reduceStatement func(st map[string]interface{})
+	reduceStatement = func(st map[string]interface{}) {
+		reduceAgent(st["actor"])
+		reduceAgent(st["authority"])
+		if object, ok := st["object"].(map[string]interface{}); ok {
+			switch objectType, _ := object["objectType"].(string); objectType {
+			case "", "Activity":
+				delete(object, "definition")
+			case "Agent", "Group":
+				reduceAgent(object)
+			case "SubStatement":
+				reduceStatement(object)
+			}
+		}
+		if context, ok := st["context"].(map[string]interface{}); ok {
+			reduceAgent(context["instructor"])
+			reduceAgent(context["team"])
+			if activities, ok := context["contextActivities"].(map[string]interface{}); ok {
+				for _, list := range activities {
+					for _, item := range xapiActivityList(list) {
+						delete(item.(map[string]interface{}), "definition")
+					}
+				}
+			}
+		}
+	}
+	reduceStatement(statement)
+	reduced, err := json.Marshal(statement)
+	if err != nil {
+		return data
+	}
+	return reduced
+}
+
+func validXAPIFormat(format string) error {
+	switch format {
+	case "", "exact", "ids", "canonical":
+		return nil
+	}
+	return xapiInvalid("parámetro inválido: format debe ser exact, ids o canonical")
+}
+
+// GetStatement devuelve una declaración del cliente por su ID. Con voided, solo la devuelve
+// si fue anulada (voidedStatementId); sin él, solo si no lo fue (statementId). El formato
+// canonical se sirve igual que exact: el LRS no filtra los mapas de idiomas.
+func (s *XAPIService) GetStatement(client *models.XAPIClient, statementID string, voided bool, format string) (*models.XAPIStatement, error) {
+	if !xapiUUIDPattern.MatchString(statementID) {
+		return nil, xapiInvalid("parámetro inválido: el id de la declaración debe ser un UUID")
+	}
+	if err := validXAPIFormat(format); err != nil {
+		return nil, err
+	}
+	var statement models.XAPIStatement
+	if err := s.DB.Where("statement_id = ? AND client_id = ?", strings.ToLower(statementID), client.ID).
+		Limit(1).Find(&statement).Error; err != nil {
+		log.Printf("Error al obtener la declaración xAPI %s: %v", statementID, err)
+		return nil, errors.New("no se pudo obtener la declaración")
+	}
+	if statement.ID == 0 || statement.Voided != voided {
+		return nil, errXAPIStatementMissing
+	}
+	if format == "ids" {
+		statement.Statement = xapiIDsFormat(statement.Statement)
+	}
+	return &statement, nil
+}
+
+// QueryStatements devuelve las declaraciones no anuladas del cliente que cumplen los
+// filtros, de la más reciente a la más antigua (o al revés con ascending).
+func (s *XAPIService) QueryStatements(client *models.XAPIClient, q XAPIStatementQuery) (*XAPIStatementResult, error) {
+	if err := validXAPIFormat(q.Format); err != nil {
+		return nil, err
+	}
+	if q.Limit <= 0 || q.Limit > maxXAPIStatementLimit {
+		if q.Limit > maxXAPIStatementLimit {
+			q.Limit = maxXAPIStatementLimit
+		} else {
+			q.Limit = defaultXAPIStatementLimit
+		}
+	}
+	query := s.DB.Model(&models.XAPIStatement{}).Where("client_id = ? AND voided = ?", client.ID, false)
+	refFilter := func(kind, value string, related bool) *gorm.DB {
+		refs := s.DB.Model(&models.XAPIStatementRef{}).Select("statement_id").Where("kind = ? AND value = ?", kind, value)
+		if !related {
+			refs = refs.Where("is_primary = ?", true)
+		}
+		return refs
+	}
+	if q.Agent != "" {
+		key, err := parseXAPIAgentParam(q.Agent, true)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("id IN (?)", refFilter("agent", key, q.RelatedAgents))
+	}
+	if q.Verb != "" {
+		if !isXAPIIRI(q.Verb) {
+			return nil, xapiInvalid("parámetro inválido: verb debe ser un IRI")
+		}
+		query = query.Where("verb_id = ?", q.Verb)
+	}
+	if q.Activity != "" {
+		if !isXAPIIRI(q.Activity) {
+			return nil, xapiInvalid("parámetro inválido: activity debe ser un IRI")
+		}
+		query = query.Where("id IN (?)", refFilter("activity", q.Activity, q.RelatedActivities))
+	}
+	if q.Registration != "" {
+		if !xapiUUIDPattern.MatchString(q.Registration) {
+			return nil, xapiInvalid("parámetro inválido: registration debe ser un UUID")
+		}
+		query = query.Where("registration = ?", strings.ToLower(q.Registration))
+	}
+	if q.Since != nil {
+		query = query.Where("stored > ?", q.Since.UTC())
+	}
+	if q.Until != nil {
+		query = query.Where("stored <= ?", q.Until.UTC())
+	}
+	// El ID interno crece con stored, así que sirve de orden y de cursor estable.
+	order := "id DESC"
+	if q.Ascending {
+		order = "id ASC"
+		if q.Cursor != 0 {
+			query = query.Where("id > ?", q.Cursor)
+		}
+	} else if q.Cursor != 0 {
+		query = query.Where("id < ?", q.Cursor)
+	}
+
+	var rows []models.XAPIStatement
+	if err := query.Order(order).Limit(q.Limit + 1).Find(&rows).Error; err != nil {
+		log.Printf("Error al consultar las declaraciones xAPI del cliente %d: %v", client.ID, err)
+		return nil, errors.New("no se pudieron obtener las declaraciones")
+	}
+	result := &XAPIStatementResult{Statements: make([]json.RawMessage, 0, len(rows))}
+	if len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+		result.NextCursor = rows[len(rows)-1].ID
+	}
+	for _, row := range rows {
+		if q.Format == "ids" {
+			row.Statement = xapiIDsFormat(row.Statement)
+		}
+		result.Statements = append(result.Statements, row.Statement)
+	}
+	return result, nil
+}
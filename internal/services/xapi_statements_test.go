@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Unikyri/yamerito-mvp/internal/models"
+)
+
+const (
+	xapiTestActor  = `{"mbox":"mailto:ana@example.com"}`
+	xapiTestVerb   = `{"id":"http://adlnet.gov/expapi/verbs/completed","display":{"es":"completó"}}`
+	xapiTestObject = `{"id":"http://example.com/actividades/1"}`
+)
+
+// xapiTestStatement arma una declaración con el actor, verbo y objeto dados y las
+// propiedades extra (con su coma inicial) que se agreguen.
+func xapiTestStatement(actor, verb, object, extra string) string {
+	return `{"actor":` + actor + `,"verb":` + verb + `,"object":` + object + extra + `}`
+}
+
+func TestValidateXAPIStatement(t *testing.T) {
+	subStatement := func(extra string) string {
+		return `{"objectType":"SubStatement","actor":` + xapiTestActor + `,"verb":` + xapiTestVerb +
+			`,"object":` + xapiTestObject + extra + `}`
+	}
+	tests := []struct {
+		name      string
+		statement string
+		wantErr   bool
+	}{
+		{"declaración mínima", xapiTestStatement(xapiTestActor, xapiTestVerb, xapiTestObject, ""), false},
+		{"id en mayúsculas", xapiTestStatement(xapiTestActor, xapiTestVerb, xapiTestObject, `,"id":"3F2504E0-4F89-41D3-9A0C-0305E82C3301"`), false},
+		{"id que no es UUID", xapiTestStatement(xapiTestActor, xapiTestVerb, xapiTestObject, `,"id":"123"`), true},
+		{"propiedad desconocida", xapiTestStatement(xapiTestActor, xapiTestVerb, xapiTestObject, `,"extra":1`), true},
+		{"falta el verbo", `{"actor":` + xapiTestActor + `,"object":` + xapiTestObject + `}`, true},
+
+		{"actor con cuenta", xapiTestStatement(`{"account":{"homePage":"https://app.example.com","name":"ana"}}`, xapiTestVerb, xapiTestObject, ""), false},
+		{"actor con mbox_sha1sum", xapiTestStatement(`{"mbox_sha1sum":"da39a3ee5e6b4b0d3255bfef95601890afd80709"}`, xapiTestVerb, xapiTestObject, ""), false},
+		{"actor sin identificador", xapiTestStatement(`{"name":"Ana"}`, xapiTestVerb, xapiTestObject, ""), true},
+		{"actor con dos identificadores", xapiTestStatement(`{"mbox":"mailto:ana@example.com","openid":"http://example.com/ana"}`, xapiTestVerb, xapiTestObject, ""), true},
+		{"mbox sin mailto", xapiTestStatement(`{"mbox":"ana@example.com"}`, xapiTestVerb, xapiTestObject, ""), true},
+		{"cuenta sin name", xapiTestStatement(`{"account":{"homePage":"https://app.example.com"}}`, xapiTestVerb, xapiTestObject, ""), true},
+		{"grupo anónimo con miembros", xapiTestStatement(`{"objectType":"Group","member":[`+xapiTestActor+`]}`, xapiTestVerb, xapiTestObject, ""), false},
+		{"grupo anónimo sin miembros", xapiTestStatement(`{"objectType":"Group"}`, xapiTestVerb, xapiTestObject, ""), true},
+		{"grupo como miembro", xapiTestStatement(`{"objectType":"Group","member":[{"objectType":"Group","mbox":"mailto:g@example.com"}]}`, xapiTestVerb, xapiTestObject, ""), true},
+		{"objectType de actor desconocido", xapiTestStatement(`{"objectType":"Person","mbox":"mailto:ana@example.com"}`, xapiTestVerb, xapiTestObject, ""), true},
+
+		{"verbo sin IRI", xapiTestStatement(xapiTestActor, `{"id":"completed"}`, xapiTestObject, ""), true},
+		{"verbo con propiedad desconocida", xapiTestStatement(xapiTestActor, `{"id":"http://example.com/v","name":"v"}`, xapiTestObject, ""), true},
+		{"display que no es mapa de idiomas", xapiTestStatement(xapiTestActor, `{"id":"http://example.com/v","display":"completó"}`, xapiTestObject, ""), true},
+
+		{"objeto agente", xapiTestStatement(xapiTestActor, xapiTestVerb, `{"objectType":"Agent","mbox":"mailto:luis@example.com"}`, ""), false},
+		{"objeto StatementRef", xapiTestStatement(xapiTestActor, xapiTestVerb, `{"objectType":"StatementRef","id":"3f2504e0-4f89-41d3-9a0c-0305e82c3301"}`, ""), false},
+		{"StatementRef sin UUID", xapiTestStatement(xapiTestActor, xapiTestVerb, `{"objectType":"StatementRef","id":"x"}`, ""), true},
+		{"actividad sin IRI", xapiTestStatement(xapiTestActor, xapiTestVerb, `{"id":"actividad 1"}`, ""), true},
+		{"objectType de objeto desconocido", xapiTestStatement(xapiTestActor, xapiTestVerb, `{"objectType":"Cosa","id":"http://example.com/1"}`, ""), true},
+
+		{"resultado completo", xapiTestStatement(xapiTestActor, xapiTestVerb, xapiTestObject,
+			`,"result":{"score":{"scaled":0.8,"raw":8,"min":0,"max":10},"success":true,"completion":true,"duration":"PT1H30M"}`), false},
+		{"scaled fuera de rango", xapiTestStatement(xapiTestActor, xapiTestVerb, xapiTestObject, `,"result":{"score":{"scaled":1.5}}`), true},
+		{"raw mayor que max", xapiTestStatement(xapiTestActor, xapiTestVerb, xapiTestObject, `,"result":{"score":{"raw":11,"max":10}}`), true},
+		{"success que no es booleano", xapiTestStatement(xapiTestActor, xapiTestVerb, xapiTestObject, `,"result":{"success":"true"}`), true},
+		{"duración inválida", xapiTestStatement(xapiTestActor, xapiTestVerb, xapiTestObject, `,"result":{"duration":"PT"}`), true},
+
+		{"subdeclaración válida", xapiTestStatement(xapiTestActor, xapiTestVerb, subStatement(""), ""), false},
+		{"subdeclaración con id", xapiTestStatement(xapiTestActor, xapiTestVerb, subStatement(`,"id":"3f2504e0-4f89-41d3-9a0c-0305e82c3301"`), ""), true},
+		{"subdeclaración con stored", xapiTestStatement(xapiTestActor, xapiTestVerb, subStatement(`,"stored":"2026-01-01T00:00:00Z"`), ""), true},
+		{"subdeclaración con autoridad", xapiTestStatement(xapiTestActor, xapiTestVerb, subStatement(`,"authority":`+xapiTestActor), ""), true},
+		{"subdeclaración con versión", xapiTestStatement(xapiTestActor, xapiTestVerb, subStatement(`,"version":"1.0.0"`), ""), true},
+		{"subdeclaración anidada", xapiTestStatement(xapiTestActor, xapiTestVerb,
+			`{"objectType":"SubStatement","actor":`+xapiTestActor+`,"verb":`+xapiTestVerb+`,"object":`+subStatement("")+`}`, ""), true},
+
+		{"anulación con StatementRef", xapiTestStatement(xapiTestActor, `{"id":"`+xapiVerbVoided+`"}`,
+			`{"objectType":"StatementRef","id":"3f2504e0-4f89-41d3-9a0c-0305e82c3301"}`, ""), false},
+		{"anulación de una actividad", xapiTestStatement(xapiTestActor, `{"id":"`+xapiVerbVoided+`"}`, xapiTestObject, ""), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var statement map[string]interface{}
+			if err := decodeXAPIJSON([]byte(tt.statement), &statement); err != nil {
+				t.Fatalf("JSON de prueba inválido: %v", err)
+			}
+			err := validateXAPIStatement(statement, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateXAPIStatement() error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			var validationErr *XAPIValidationError
+			if err != nil && !errors.As(err, &validationErr) {
+				t.Errorf("se esperaba un XAPIValidationError, se obtuvo %T", err)
+			}
+		})
+	}
+}
+
+// Reenviar un id guardado solo se acepta si es la misma declaración del mismo cliente.
+func TestStoreStatementsExistingID(t *testing.T) {
+	const id = "3f2504e0-4f89-41d3-9a0c-0305e82c3301"
+	body := xapiTestStatement(xapiTestActor, xapiTestVerb, xapiTestObject, `,"id":"`+id+`"`)
+	stored := xapiTestStatement(xapiTestActor, xapiTestVerb, xapiTestObject,
+		`,"id":"`+id+`","stored":"2026-01-01T00:00:00Z","timestamp":"2026-01-01T00:00:00Z","version":"1.0.0","authority":{"mbox":"mailto:lrs@example.com"}`)
+	other := xapiTestStatement(xapiTestActor, `{"id":"http://adlnet.gov/expapi/verbs/attempted"}`, xapiTestObject, `,"id":"`+id+`"`)
+
+	tests := []struct {
+		name     string
+		clientID uint
+		existing string
+		wantErr  error
+	}{
+		{"reenvío idéntico", 1, stored, nil},
+		{"mismo id con otro contenido", 1, other, errXAPIStatementConflict},
+		{"mismo contenido de otro cliente", 2, stored, errXAPIStatementConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT \\* FROM `xapi_statements` WHERE statement_id = \\? LIMIT \\? FOR UPDATE").
+				WithArgs(id, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "statement_id", "client_id", "statement"}).
+					AddRow(9, id, tt.clientID, []byte(tt.existing)))
+			if tt.wantErr == nil {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			service := &XAPIService{DB: db, HomePage: "https://app.example.com"}
+			ids, err := service.StoreStatements(&models.XAPIClient{ID: 1}, []byte(body), "")
+			if err != tt.wantErr {
+				t.Fatalf("StoreStatements() error = %v, se esperaba %v", err, tt.wantErr)
+			}
+			if err == nil && (len(ids) != 1 || ids[0] != id) {
+				t.Errorf("ids = %v, se esperaba [%s]", ids, id)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Una anulación que apunta a otra anulación se rechaza sin tocar la referenciada.
+func TestStoreStatementsRejectsVoidingAVoiding(t *testing.T) {
+	const target = "3f2504e0-4f89-41d3-9a0c-0305e82c3301"
+	body := xapiTestStatement(xapiTestActor, `{"id":"`+xapiVerbVoided+`"}`, `{"objectType":"StatementRef","id":"`+target+`"}`, "")
+
+	db, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `xapi_statements` WHERE statement_id = \\? LIMIT \\? FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT \\* FROM `xapi_statements` WHERE statement_id = \\? LIMIT \\? FOR UPDATE").
+		WithArgs(target, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "statement_id", "client_id", "verb_id", "voiding"}).
+			AddRow(4, target, 1, xapiVerbVoided, true))
+	mock.ExpectRollback()
+
+	service := &XAPIService{DB: db, HomePage: "https://app.example.com"}
+	_, err := service.StoreStatements(&models.XAPIClient{ID: 1}, []byte(body), "")
+	var validationErr *XAPIValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("StoreStatements() error = %v, se esperaba un XAPIValidationError", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestXAPILearner(t *testing.T) {
+	tests := []struct {
+		name     string
+		actor    string
+		query    string
+		arg      string
+		wantUser bool
+	}{
+		{"por mbox", `{"mbox":"mailto:ana@example.com"}`,
+			"JOIN employee_details ON employee_details.user_id = users.id WHERE employee_details.email = \\?", "ana@example.com", true},
+		{"por cuenta de la aplicación", `{"account":{"homePage":"https://app.example.com/","name":"ana"}}`,
+			"WHERE users.username = \\?", "ana", true},
+		{"mbox sin empleado", `{"mbox":"mailto:nadie@example.com"}`,
+			"WHERE employee_details.email = \\?", "nadie@example.com", false},
+		{"cuenta de otro sistema", `{"account":{"homePage":"https://otro.example.com","name":"ana"}}`, "", "", false},
+		{"openid", `{"openid":"http://example.com/ana"}`, "", "", false},
+		{"grupo", `{"objectType":"Group","mbox":"mailto:equipo@example.com"}`, "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			if tt.query != "" {
+				rows := sqlmock.NewRows([]string{"id", "username", "role"})
+				if tt.wantUser {
+					rows.AddRow(7, "ana", models.RoleEmployee)
+				}
+				mock.ExpectQuery(tt.query).WithArgs(tt.arg, 1).WillReturnRows(rows)
+			}
+			var actor map[string]interface{}
+			if err := decodeXAPIJSON([]byte(tt.actor), &actor); err != nil {
+				t.Fatal(err)
+			}
+
+			service := &XAPIService{DB: db, HomePage: "https://app.example.com"}
+			user, err := service.xapiLearner(db, actor)
+			if err != nil {
+				t.Fatalf("xapiLearner() error = %v", err)
+			}
+			if (user != nil) != tt.wantUser {
+				t.Fatalf("xapiLearner() = %v, se esperaba usuario: %v", user, tt.wantUser)
+			}
+			if user != nil && user.ID != 7 {
+				t.Errorf("user.ID = %d, se esperaba 7", user.ID)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
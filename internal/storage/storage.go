@@ -0,0 +1,68 @@
+// Package storage abstrae el almacenamiento de archivos binarios (blobs) detrás de una
+// interfaz común, con implementaciones para el sistema de archivos local y para
+// servicios compatibles con S3 (AWS S3, MinIO, etc.).
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Unikyri/yamerito-mvp/internal/config"
+)
+
+// ErrNotFound se devuelve cuando la clave solicitada no existe.
+var ErrNotFound = errors.New("objeto no encontrado")
+
+// ObjectInfo describe un objeto almacenado.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// BlobStore es el contrato que cumple cualquier backend de almacenamiento.
+// Las claves usan '/' como separador (p. ej. "avatars/12/ab34/large.jpg").
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get devuelve el contenido del objeto; quien llama debe cerrarlo.
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete elimina el objeto. Eliminar una clave inexistente no es un error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New crea el BlobStore indicado por la configuración.
+func New(cfg config.StorageConfig) (BlobStore, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", "local":
+		return NewLocalStore(cfg.LocalDir)
+	case "s3":
+		return NewS3Store(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("driver de almacenamiento desconocido: '%s'", cfg.Driver)
+	}
+}
+
+// validateKey rechaza claves vacías, absolutas o con segmentos "." y "..", de modo que
+// ninguna clave pueda salir del directorio o del bucket configurado.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("clave de almacenamiento inválida: '%s'", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("clave de almacenamiento inválida: '%s'", key)
+		}
+	}
+	return nil
+}